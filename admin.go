@@ -0,0 +1,359 @@
+package grpckit
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AdminOption configures the admin API.
+type AdminOption func(*adminConfig)
+
+// adminConfig holds configuration for the admin API.
+type adminConfig struct {
+	token       string
+	tokenFile   string
+	tokenSecret string
+	prefix      string
+	localOnly   bool
+}
+
+// AdminToken sets the bearer token required to call admin endpoints.
+// Requests must send "Authorization: Bearer <token>".
+// If no token is configured, all admin requests are rejected.
+func AdminToken(token string) AdminOption {
+	return func(c *adminConfig) {
+		c.token = token
+	}
+}
+
+// AdminTokenFile reads the admin token from a file, such as a mounted
+// Kubernetes secret, when the server is created.
+func AdminTokenFile(path string) AdminOption {
+	return func(c *adminConfig) {
+		c.tokenFile = path
+	}
+}
+
+// AdminTokenSecret reads the admin token from the secrets provider set with
+// WithSecrets when the server is created.
+func AdminTokenSecret(name string) AdminOption {
+	return func(c *adminConfig) {
+		c.tokenSecret = name
+	}
+}
+
+// AdminLocalOnly rejects admin requests from clients other than the local
+// host (loopback addresses) with 403, whatever their token.
+func AdminLocalOnly() AdminOption {
+	return func(c *adminConfig) {
+		c.localOnly = true
+	}
+}
+
+// AdminPrefix sets the URL prefix for admin endpoints. Handlers and
+// built-in endpoints under it are rejected by New, as the admin API would
+// shadow them.
+// Default: "/admin"
+func AdminPrefix(prefix string) AdminOption {
+	return func(c *adminConfig) {
+		c.prefix = strings.TrimSuffix(prefix, "/")
+	}
+}
+
+// WithAdminAPI enables an authenticated admin HTTP API for runtime control.
+// Admin endpoints bypass the user auth middleware and maintenance mode;
+// they are protected only by the admin token. All admin actions are audit-logged.
+//
+// Endpoints (default prefix "/admin"):
+//   - GET  /admin/config: dump the effective configuration
+//   - GET  /admin/routes: list HTTP routes and gRPC methods
+//   - GET  /admin/stats: open connections, active gRPC calls and gateway connection states
+//   - POST /admin/loglevel?level=debug: set the log level
+//   - POST /admin/maintenance?enabled=true[&duration=15m]: toggle maintenance mode (503 for all other traffic)
+//   - POST /admin/ready?ready=false: flip readiness
+//   - POST /admin/drain: trigger graceful shutdown
+//   - GET  /admin/quotas?principal=user-42: show quota usage (with WithPrincipalQuota)
+//   - POST /admin/quotas/reset?principal=user-42: reset quota usage (with WithPrincipalQuota)
+//
+// Example:
+//
+//	grpckit.WithAdminAPI(grpckit.AdminToken(os.Getenv("ADMIN_TOKEN")))
+func WithAdminAPI(opts ...AdminOption) Option {
+	return func(c *serverConfig) {
+		cfg := &adminConfig{prefix: "/admin"}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		if !strings.HasPrefix(cfg.prefix, "/") {
+			c.invalid("WithAdminAPI: prefix %q must start with / and not be the root", cfg.prefix)
+			return
+		}
+		c.adminConfig = cfg
+	}
+}
+
+// SetLogLevel sets the server log level (debug, info, warn, error).
+func (s *Server) SetLogLevel(level string) error {
+	level = strings.ToLower(level)
+	slogLevel, ok := slogLevels[level]
+	if !ok {
+		return fmt.Errorf("%w: unknown log level %q", ErrInvalidConfig, level)
+	}
+	s.logLevel.Set(slogLevel)
+	s.emit(ConfigReloaded{Time: time.Now(), Setting: "log_level", Value: level})
+	return nil
+}
+
+// LogLevel returns the current log level.
+func (s *Server) LogLevel() string {
+	return levelName(s.logLevel.Level())
+}
+
+// adminMiddleware dispatches admin requests.
+func adminMiddleware(s *Server, next http.Handler) http.Handler {
+	admin := newAdminHandler(s)
+	prefix := s.cfg.adminConfig.prefix
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == prefix || strings.HasPrefix(r.URL.Path, prefix+"/") {
+			admin.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkAdminOverlap returns an error if the admin API, which is served
+// before routing, would shadow requests to a mux pattern.
+func checkAdminOverlap(cfg *serverConfig, pattern string) error {
+	if cfg.adminConfig == nil || pattern == "/" {
+		return nil
+	}
+	prefix := cfg.adminConfig.prefix
+	template := muxPatternTemplate(pattern)
+	if base, ok := strings.CutSuffix(template, "**"); ok {
+		if strings.HasPrefix(prefix+"/", base) {
+			return fmt.Errorf("%w: pattern %q covers the admin API prefix %q", ErrInvalidConfig, pattern, prefix)
+		}
+		template = strings.TrimSuffix(base, "/")
+	}
+	if template == prefix || strings.HasPrefix(template, prefix+"/") {
+		return fmt.Errorf("%w: pattern %q is under the admin API prefix %q", ErrInvalidConfig, pattern, prefix)
+	}
+	return nil
+}
+
+// newAdminHandler creates the admin API handler.
+func newAdminHandler(s *Server) http.Handler {
+	cfg := s.cfg.adminConfig
+	if cfg.token == "" {
+		s.logger.Warn("admin API enabled without AdminToken, all admin requests will be rejected", "component", "admin")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(cfg.prefix+"/config", adminGet(s.adminConfigDump))
+	mux.HandleFunc(cfg.prefix+"/routes", adminGet(s.adminRoutes))
+	mux.HandleFunc(cfg.prefix+"/stats", adminGet(func() any { return s.Stats() }))
+	mux.HandleFunc(cfg.prefix+"/loglevel", adminPost(func(r *http.Request) (any, error) {
+		level := r.URL.Query().Get("level")
+		if err := s.SetLogLevel(level); err != nil {
+			return nil, err
+		}
+		return map[string]string{"level": s.LogLevel()}, nil
+	}))
+	mux.HandleFunc(cfg.prefix+"/maintenance", adminPost(func(r *http.Request) (any, error) {
+		query := r.URL.Query()
+		enabled := parseBool(query.Get("enabled"))
+		if duration := query.Get("duration"); enabled && duration != "" {
+			d, err := time.ParseDuration(duration)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("%w: invalid duration %q", ErrInvalidConfig, duration)
+			}
+			s.SetMaintenanceFor(d)
+		} else {
+			s.SetMaintenance(enabled)
+		}
+		return map[string]bool{"maintenance": s.InMaintenance()}, nil
+	}))
+	mux.HandleFunc(cfg.prefix+"/ready", adminPost(func(r *http.Request) (any, error) {
+		s.SetReady(parseBool(r.URL.Query().Get("ready")))
+		return map[string]bool{"ready": s.healthHandler.IsReady()}, nil
+	}))
+	mux.HandleFunc(cfg.prefix+"/drain", adminPost(func(r *http.Request) (any, error) {
+		// Drain asynchronously so this response can be delivered
+		go s.shutdownWithReason("admin drain")
+		return map[string]string{"status": "draining"}, nil
+	}))
+	if s.cfg.quota != nil {
+		mux.HandleFunc(cfg.prefix+"/quotas", adminQuery(func(r *http.Request) (any, error) {
+			return s.adminQuotaUsage(r)
+		}))
+		mux.HandleFunc(cfg.prefix+"/quotas/reset", adminPost(func(r *http.Request) (any, error) {
+			principal := r.URL.Query().Get("principal")
+			if principal == "" {
+				return nil, fmt.Errorf("%w: missing principal", ErrInvalidConfig)
+			}
+			if err := s.ResetQuota(r.Context(), principal); err != nil {
+				return nil, err
+			}
+			return s.adminQuotaUsage(r)
+		}))
+	}
+	if s.cfg.lockout != nil {
+		mux.HandleFunc(cfg.prefix+"/lockouts/reset", adminPost(func(r *http.Request) (any, error) {
+			key := r.URL.Query().Get("key")
+			if key == "" {
+				return nil, fmt.Errorf("%w: missing key", ErrInvalidConfig)
+			}
+			if err := s.ResetLockout(r.Context(), key); err != nil {
+				return nil, err
+			}
+			return map[string]string{"key": key, "status": "unlocked"}, nil
+		}))
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cfg.localOnly {
+			if ip := net.ParseIP(hostOnly(r.RemoteAddr)); ip == nil || !ip.IsLoopback() {
+				s.logger.Warn("admin request denied", "component", "admin",
+					"method", r.Method, "path", r.URL.RequestURI(), "remote_addr", r.RemoteAddr)
+				http.Error(w, ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+		}
+		token := extractToken(r.Header.Get("Authorization"))
+		if cfg.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.token)) != 1 {
+			s.logger.Warn("admin request denied", "component", "admin",
+				"method", r.Method, "path", r.URL.RequestURI(), "remote_addr", r.RemoteAddr)
+			http.Error(w, ErrUnauthorized.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		s.logger.Info("admin request", "component", "admin",
+			"method", r.Method, "path", r.URL.RequestURI(), "remote_addr", r.RemoteAddr)
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// adminGet wraps a read-only admin action.
+func adminGet(fn func() any) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, fn())
+	}
+}
+
+// adminQuery wraps a read-only admin action taking request parameters.
+func adminQuery(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		result, err := fn(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, result)
+	}
+}
+
+// adminPost wraps a mutating admin action.
+func adminPost(fn func(r *http.Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		result, err := fn(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAdminJSON(w, http.StatusOK, result)
+	}
+}
+
+// writeAdminJSON writes v as a JSON response.
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// adminQuotaUsage returns the quota usage of the principal query parameter.
+func (s *Server) adminQuotaUsage(r *http.Request) (any, error) {
+	principal := r.URL.Query().Get("principal")
+	if principal == "" {
+		return nil, fmt.Errorf("%w: missing principal", ErrInvalidConfig)
+	}
+	usage, err := s.QuotaUsage(r.Context(), principal)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := make([]map[string]any, 0, len(usage))
+	for _, u := range usage {
+		limits = append(limits, map[string]any{
+			"limit":     u.Limit,
+			"window":    u.Window.String(),
+			"used":      u.Used,
+			"remaining": u.Remaining,
+			"reset":     u.Reset.UTC().Format(time.RFC3339),
+		})
+	}
+	return map[string]any{"principal": principal, "limits": limits}, nil
+}
+
+// adminConfigDump returns the effective (non-secret) configuration.
+func (s *Server) adminConfigDump() any {
+	return map[string]any{
+		"grpc_port":           s.cfg.grpcPort,
+		"http_port":           s.cfg.httpPort,
+		"health_enabled":      s.cfg.healthEnabled,
+		"metrics_enabled":     s.cfg.metricsEnabled,
+		"swagger_enabled":     s.cfg.swaggerEnabled,
+		"cors_enabled":        s.cfg.corsEnabled,
+		"auth_enabled":        s.cfg.authFunc != nil,
+		"protected_endpoints": s.cfg.protectedEndpoints,
+		"public_endpoints":    s.cfg.publicEndpoints,
+		"graceful_timeout":    s.cfg.gracefulTimeout.String(),
+		"log_level":           s.LogLevel(),
+		"maintenance":         s.InMaintenance(),
+		"ready":               s.healthHandler.IsReady(),
+		"time":                s.now().UTC().Format(time.RFC3339),
+	}
+}
+
+// adminRoutes returns the registered HTTP routes and gRPC methods.
+func (s *Server) adminRoutes() any {
+	var methods []string
+	for name, info := range s.grpcServer.GetServiceInfo() {
+		for _, m := range info.Methods {
+			methods = append(methods, "/"+name+"/"+m.Name)
+		}
+	}
+	sort.Strings(methods)
+
+	s.routesMu.Lock()
+	routes := append([]string(nil), s.routes...)
+	s.routesMu.Unlock()
+
+	return map[string]any{
+		"http": routes,
+		"grpc": methods,
+	}
+}
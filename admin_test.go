@@ -0,0 +1,239 @@
+package grpckit
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func newAdminTestServer(t *testing.T, opts ...AdminOption) (*Server, http.Handler) {
+	t.Helper()
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithHealthCheck(),
+		WithAuth(MockAuthFunc("user-token", "user")),
+		WithPublicEndpoints("/healthz", "/readyz"),
+		WithHTTPHandlerFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {}),
+		WithAdminAPI(opts...),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	gw := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	return s, s.buildHTTPHandler(gw)
+}
+
+func adminRequest(handler http.Handler, method, target, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	_, handler := newAdminTestServer(t, AdminToken("admin-secret"))
+
+	if rec := adminRequest(handler, http.MethodGet, "/admin/config", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/admin/config", "user-token"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 with user token, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/admin/config", "admin-secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 with admin token, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_LocalOnly(t *testing.T) {
+	_, handler := newAdminTestServer(t, AdminToken("dev"), AdminLocalOnly())
+
+	for addr, want := range map[string]int{
+		"127.0.0.1:40000":   http.StatusOK,
+		"[::1]:40000":       http.StatusOK,
+		"192.0.2.1:40000":   http.StatusForbidden,
+		"203.0.113.7:40000": http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+		req.RemoteAddr = addr
+		req.Header.Set("Authorization", "Bearer dev")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", addr, want, rec.Code)
+		}
+	}
+}
+
+func TestAdmin_NoTokenConfigured(t *testing.T) {
+	_, handler := newAdminTestServer(t)
+
+	if rec := adminRequest(handler, http.MethodGet, "/admin/config", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 when no admin token configured, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_LogLevel(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+
+	rec := adminRequest(handler, http.MethodPost, "/admin/loglevel?level=debug", "t")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if s.LogLevel() != "debug" {
+		t.Errorf("expected log level debug, got %s", s.LogLevel())
+	}
+
+	if rec := adminRequest(handler, http.MethodPost, "/admin/loglevel?level=verbose", "t"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid level, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/admin/loglevel?level=info", "t"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_Maintenance(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+
+	if rec := adminRequest(handler, http.MethodPost, "/admin/maintenance?enabled=true", "t"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !s.InMaintenance() {
+		t.Fatal("expected maintenance mode enabled")
+	}
+
+	if rec := adminRequest(handler, http.MethodGet, "/api/v1/items", "user-token"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 during maintenance, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/healthz", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected health check to bypass maintenance, got %d", rec.Code)
+	}
+
+	adminRequest(handler, http.MethodPost, "/admin/maintenance?enabled=false", "t")
+	if rec := adminRequest(handler, http.MethodGet, "/api/v1/items", "user-token"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after maintenance, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_MaintenanceDuration(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+
+	if rec := adminRequest(handler, http.MethodPost, "/admin/maintenance?enabled=true&duration=15m", "t"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	rec := adminRequest(handler, http.MethodGet, "/api/v1/items", "user-token")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 during maintenance, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "900" && got != "899" {
+		t.Errorf("expected Retry-After counting down from 900, got %q", got)
+	}
+
+	s.SetMaintenanceFor(-time.Minute)
+	if got := adminRequest(handler, http.MethodGet, "/api/v1/items", "user-token").Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected default Retry-After past the expected end, got %q", got)
+	}
+	s.SetMaintenance(true)
+	if got := adminRequest(handler, http.MethodGet, "/api/v1/items", "user-token").Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected default Retry-After, got %q", got)
+	}
+
+	if rec := adminRequest(handler, http.MethodPost, "/admin/maintenance?enabled=true&duration=soon", "t"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for invalid duration, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_Ready(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+
+	adminRequest(handler, http.MethodPost, "/admin/ready?ready=false", "t")
+	if s.healthHandler.IsReady() {
+		t.Error("expected server not ready")
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/readyz", ""); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected /readyz 503, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_Routes(t *testing.T) {
+	_, handler := newAdminTestServer(t, AdminToken("t"), AdminPrefix("/_admin/"))
+
+	rec := adminRequest(handler, http.MethodGet, "/_admin/routes", "t")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+
+	var body struct {
+		HTTP []string `json:"http"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	expected := map[string]bool{"/healthz": true, "/readyz": true, "/webhook": true}
+	for _, r := range body.HTTP {
+		delete(expected, r)
+	}
+	if len(expected) > 0 {
+		t.Errorf("missing routes: %v (got %v)", expected, body.HTTP)
+	}
+}
+
+func TestAdmin_Drain(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+
+	if rec := adminRequest(handler, http.MethodPost, "/admin/drain", "t"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	<-s.done
+	if s.healthHandler.IsReady() {
+		t.Error("expected server not ready after drain")
+	}
+}
+
+func TestWithAdminAPI_Overlap(t *testing.T) {
+	tests := map[string][]Option{
+		"handler under prefix": {WithAdminAPI(), WithHTTPHandlerFunc("/admin/custom", func(w http.ResponseWriter, r *http.Request) {})},
+		"subtree over prefix":  {WithAdminAPI(AdminPrefix("/ops/admin")), WithHTTPHandlerFunc("/ops/", func(w http.ResponseWriter, r *http.Request) {})},
+		"metrics under prefix": {WithAdminAPI(), WithMetrics(), WithMetricsPath("/admin/metrics")},
+		"method pattern":       {WithAdminAPI(), WithHTTPHandlerFunc("GET /admin", func(w http.ResponseWriter, r *http.Request) {})},
+		"root prefix":          {WithAdminAPI(AdminPrefix("/"))},
+		"relative prefix":      {WithAdminAPI(AdminPrefix("admin"))},
+	}
+	for name, opts := range tests {
+		opts = append(opts, WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if _, err := New(opts...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+
+	s, _ := newAdminTestServer(t, AdminToken("t"))
+	if err := s.RegisterHTTPHandler("/administration", http.NotFoundHandler()); err != nil {
+		t.Errorf("expected a path outside the prefix to be allowed, got %v", err)
+	}
+	if err := s.RegisterHTTPHandler("/admin/plugins/", http.NotFoundHandler()); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected a dynamic handler under the prefix to be rejected, got %v", err)
+	}
+}
+
+func TestAdmin_ConfigTime(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithClock(NewFakeClock(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC))),
+		WithAdminAPI(AdminToken("t")),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if got := s.adminConfigDump().(map[string]any)["time"]; got != "2030-01-02T03:04:05Z" {
+		t.Errorf("expected the server clock's time, got %v", got)
+	}
+}
@@ -0,0 +1,134 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataAnnotator returns gRPC metadata to forward from a REST request.
+// ctx is the HTTP request context, so values set by the auth function
+// (e.g. with ContextWithPrincipal) are available.
+type MetadataAnnotator func(ctx context.Context, r *http.Request) metadata.MD
+
+// WithMetadataAnnotator forwards metadata derived from REST requests to the
+// gRPC handlers, e.g. the client IP, user agent, locale or authenticated
+// principal, where they are read with metadata.FromIncomingContext like
+// metadata sent by gRPC clients. Annotators run in order; later ones add to
+// (and may repeat) the keys of earlier ones.
+//
+// Annotated keys replace the values clients send for the same keys as
+// Grpc-Metadata-* headers, so handlers can trust them: a REST client
+// cannot pass its own "x-principal" ahead of the annotator's.
+//
+// Keys are lowercased, as gRPC requires. Keys starting with "grpc-", which
+// are reserved, and keys or values gRPC would reject (values of keys not
+// ending in "-bin" must be printable ASCII) are dropped and logged, instead
+// of failing the call.
+//
+// Example:
+//
+//	grpckit.WithMetadataAnnotator(func(ctx context.Context, r *http.Request) metadata.MD {
+//	    return metadata.Pairs(
+//	        "x-client-ip", r.RemoteAddr,
+//	        "x-locale", r.Header.Get("Accept-Language"),
+//	        "x-principal", grpckit.PrincipalFromContext(ctx),
+//	    )
+//	})
+func WithMetadataAnnotator(annotator MetadataAnnotator) Option {
+	return func(c *serverConfig) {
+		if annotator == nil {
+			c.invalid("WithMetadataAnnotator: nil annotator")
+			return
+		}
+		c.metadataAnnotators = append(c.metadataAnnotators, annotator)
+	}
+}
+
+// annotatedMetadataKey is the context key for the metadata computed by
+// annotatorMiddleware.
+type annotatedMetadataKey struct{}
+
+// annotatorMiddleware runs the annotators for gateway requests and removes
+// the Grpc-Metadata-* headers of the keys they set, which the gateway
+// would otherwise forward ahead of the annotated values.
+func annotatorMiddleware(annotators []MetadataAnnotator, next http.Handler) http.Handler {
+	annotate := annotateMetadata(annotators)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md := annotate(r.Context(), r)
+		r = r.WithContext(context.WithValue(r.Context(), annotatedMetadataKey{}, md))
+		cloned := false
+		for key := range md {
+			name := http.CanonicalHeaderKey(runtime.MetadataHeaderPrefix + key)
+			if _, ok := r.Header[name]; !ok {
+				continue
+			}
+			// r is a copy, but its header is shared with the caller's request
+			if !cloned {
+				r.Header, cloned = r.Header.Clone(), true
+			}
+			delete(r.Header, name)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// annotatedMetadata is the runtime.WithMetadata function returning the
+// metadata computed by annotatorMiddleware.
+func annotatedMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md, _ := ctx.Value(annotatedMetadataKey{}).(metadata.MD)
+	return md
+}
+
+// annotateMetadata combines the annotators into a runtime.WithMetadata
+// function.
+func annotateMetadata(annotators []MetadataAnnotator) func(context.Context, *http.Request) metadata.MD {
+	return func(ctx context.Context, r *http.Request) metadata.MD {
+		md := metadata.MD{}
+		for _, annotator := range annotators {
+			for k, values := range annotator(ctx, r) {
+				key := strings.ToLower(k)
+				if !validMetadataKey(key) {
+					loggerFrom(ctx).Warn("dropping invalid metadata key from annotator", "component", "gateway", "key", k)
+					continue
+				}
+				for _, v := range values {
+					if !strings.HasSuffix(key, "-bin") && !validMetadataValue(v) {
+						loggerFrom(ctx).Warn("dropping invalid metadata value from annotator", "component", "gateway", "key", key)
+						continue
+					}
+					md.Append(key, v)
+				}
+			}
+		}
+		return md
+	}
+}
+
+// validMetadataKey reports whether key is a lowercase gRPC metadata key that
+// is not reserved.
+func validMetadataKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "grpc-") {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// validMetadataValue reports whether v is a valid ASCII metadata value.
+func validMetadataValue(v string) bool {
+	for i := 0; i < len(v); i++ {
+		if v[i] < 0x20 || v[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
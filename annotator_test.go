@@ -0,0 +1,113 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAnnotateMetadata(t *testing.T) {
+	buf := captureLog(t)
+	annotate := annotateMetadata([]MetadataAnnotator{
+		func(ctx context.Context, r *http.Request) metadata.MD {
+			return metadata.MD{"X-Locale": {r.Header.Get("Accept-Language")}, "grpc-status": {"0"}}
+		},
+		func(ctx context.Context, r *http.Request) metadata.MD {
+			return metadata.MD{"x-locale": {"fallback"}, "x-name": {"café"}, "x-name-bin": {"café"}, "bad key": {"v"}}
+		},
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("Accept-Language", "it-IT")
+	md := annotate(req.Context(), req)
+
+	if got := md.Get("x-locale"); len(got) != 2 || got[0] != "it-IT" || got[1] != "fallback" {
+		t.Errorf("expected lowercased key with both values, got %v", got)
+	}
+	if got := md.Get("x-name-bin"); len(got) != 1 {
+		t.Errorf("expected binary value to be kept, got %v", got)
+	}
+	for _, key := range []string{"grpc-status", "x-name", "bad key"} {
+		if _, ok := md[key]; ok {
+			t.Errorf("expected %q to be dropped, got %v", key, md)
+		}
+	}
+	if !strings.Contains(buf.String(), "key=grpc-status") || !strings.Contains(buf.String(), "key=x-name") {
+		t.Errorf("expected dropped keys to be logged, got %q", buf.String())
+	}
+}
+
+type metadataItemServer struct {
+	itempb.UnimplementedItemServiceServer
+	md chan metadata.MD
+}
+
+func (s *metadataItemServer) GetItem(ctx context.Context, req *itempb.GetItemRequest) (*itempb.GetItemResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.md <- md
+	return &itempb.GetItemResponse{}, nil
+}
+
+func TestWithMetadataAnnotator(t *testing.T) {
+	items := &metadataItemServer{md: make(chan metadata.MD, 1)}
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, items)
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+		WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+			return ContextWithPrincipal(ctx, "alice"), nil
+		}),
+		WithMetadataAnnotator(func(ctx context.Context, r *http.Request) metadata.MD {
+			return metadata.Pairs("x-principal", PrincipalFromContext(ctx), "x-user-agent", r.UserAgent())
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Shutdown()
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items/1", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("User-Agent", "test-client/1.0")
+	// Clients cannot supply annotated keys, but other metadata is forwarded
+	req.Header.Set("Grpc-Metadata-X-Principal", "root")
+	req.Header.Set("Grpc-Metadata-X-Tenant", "acme")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	md := <-items.md
+	if got := md.Get("x-principal"); len(got) != 1 || got[0] != "alice" {
+		t.Errorf("expected principal from the auth context, got %v", got)
+	}
+	if got := md.Get("x-user-agent"); len(got) != 1 || got[0] != "test-client/1.0" {
+		t.Errorf("expected user agent, got %v", got)
+	}
+	if got := md.Get("x-tenant"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("expected client metadata to be forwarded, got %v", got)
+	}
+	if got := req.Header.Get("Grpc-Metadata-X-Principal"); got != "root" {
+		t.Errorf("expected the caller's request to be left unchanged, got %q", got)
+	}
+}
+
+func TestWithMetadataAnnotator_Nil(t *testing.T) {
+	_, err := New(WithMetadataAnnotator(nil), WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
@@ -2,10 +2,12 @@ package grpckit
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"path"
 	"strings"
 
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
@@ -21,25 +23,44 @@ func authMiddleware(cfg *serverConfig, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Check if this endpoint requires auth
 		if !requiresAuth(r.URL.Path, cfg) {
+			traceSkipped(r.Context())
 			next.ServeHTTP(w, r)
 			return
 		}
 
-		// Extract token from Authorization header
-		token := extractToken(r.Header.Get("Authorization"))
-
-		// Call auth function
-		ctx, err := cfg.authFunc(r.Context(), token)
+		// Call auth function with the Authorization header token
+		scheme, token := authToken(r.Header.Get("Authorization"))
+		ctx, err := cfg.authFunc(contextWithAuthScheme(r.Context(), scheme), token)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusUnauthorized)
+			code := runtime.HTTPStatusFromCode(status.Code(authError(err)))
+			if code == http.StatusUnauthorized && cfg.basicAuthRealm != "" {
+				// Let browsers prompt for credentials
+				w.Header().Set("WWW-Authenticate", `Basic realm="`+cfg.basicAuthRealm+`", charset="UTF-8"`)
+			}
+			http.Error(w, err.Error(), code)
 			return
 		}
 
-		// Continue with enriched context
-		next.ServeHTTP(w, r.WithContext(ctx))
+		// Continue with enriched context (keeping bearer tokens for pass-through),
+		// keeping authenticated responses out of shared caches
+		if isBearer(scheme) {
+			ctx = contextWithToken(ctx, token)
+		}
+		cw := &cacheControlWriter{ResponseWriter: w, header: noStore}
+		next.ServeHTTP(cw, r.WithContext(ctx))
+		cw.writeHeader(http.StatusOK)
 	})
 }
 
+// authError converts an auth function error to a gRPC status: ErrForbidden
+// becomes PermissionDenied (403), anything else Unauthenticated (401).
+func authError(err error) error {
+	if errors.Is(err, ErrForbidden) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Unauthenticated, err.Error())
+}
+
 // grpcAuthInterceptor creates a gRPC unary interceptor for authentication.
 func grpcAuthInterceptor(cfg *serverConfig) grpc.UnaryServerInterceptor {
 	return func(
@@ -54,6 +75,7 @@ func grpcAuthInterceptor(cfg *serverConfig) grpc.UnaryServerInterceptor {
 
 		// Check if this method requires auth
 		if !requiresAuth(info.FullMethod, cfg) {
+			traceSkipped(ctx)
 			return handler(ctx, req)
 		}
 
@@ -63,16 +85,10 @@ func grpcAuthInterceptor(cfg *serverConfig) grpc.UnaryServerInterceptor {
 			return nil, status.Error(codes.Unauthenticated, "missing metadata")
 		}
 
-		tokens := md.Get("authorization")
-		token := ""
-		if len(tokens) > 0 {
-			token = extractToken(tokens[0])
-		}
-
-		// Call auth function
-		newCtx, err := cfg.authFunc(ctx, token)
+		// Call auth function with the authorization metadata credentials
+		newCtx, err := cfg.authFunc(authorizationContext(ctx, md))
 		if err != nil {
-			return nil, status.Error(codes.Unauthenticated, err.Error())
+			return nil, authError(err)
 		}
 
 		return handler(newCtx, req)
@@ -93,6 +109,7 @@ func grpcStreamAuthInterceptor(cfg *serverConfig) grpc.StreamServerInterceptor {
 
 		// Check if this method requires auth
 		if !requiresAuth(info.FullMethod, cfg) {
+			traceSkipped(ss.Context())
 			return handler(srv, ss)
 		}
 
@@ -104,16 +121,10 @@ func grpcStreamAuthInterceptor(cfg *serverConfig) grpc.StreamServerInterceptor {
 			return status.Error(codes.Unauthenticated, "missing metadata")
 		}
 
-		tokens := md.Get("authorization")
-		token := ""
-		if len(tokens) > 0 {
-			token = extractToken(tokens[0])
-		}
-
-		// Call auth function
-		_, err := cfg.authFunc(ctx, token)
+		// Call auth function with the authorization metadata credentials
+		_, err := cfg.authFunc(authorizationContext(ctx, md))
 		if err != nil {
-			return status.Error(codes.Unauthenticated, err.Error())
+			return authError(err)
 		}
 
 		return handler(srv, ss)
@@ -126,8 +137,8 @@ func requiresAuth(urlPath string, cfg *serverConfig) bool {
 	if len(cfg.protectedEndpoints) > 0 {
 		// Use compiled patterns if available (created via WithProtectedEndpoints)
 		// Fall back to matchesAnyPattern for backward compatibility (e.g., in tests)
-		if len(cfg.protectedExactMap) > 0 || len(cfg.protectedWildcards) > 0 {
-			return matchesCompiledPatterns(urlPath, cfg.protectedExactMap, cfg.protectedWildcards)
+		if cfg.protectedMatcher != nil {
+			return cfg.protectedMatcher.Match(urlPath)
 		}
 		return matchesAnyPattern(urlPath, cfg.protectedEndpoints)
 	}
@@ -136,8 +147,8 @@ func requiresAuth(urlPath string, cfg *serverConfig) bool {
 	if len(cfg.publicEndpoints) > 0 {
 		// Use compiled patterns if available (created via WithPublicEndpoints)
 		// Fall back to matchesAnyPattern for backward compatibility (e.g., in tests)
-		if len(cfg.publicExactMap) > 0 || len(cfg.publicWildcards) > 0 {
-			return !matchesCompiledPatterns(urlPath, cfg.publicExactMap, cfg.publicWildcards)
+		if cfg.publicMatcher != nil {
+			return !cfg.publicMatcher.Match(urlPath)
 		}
 		return !matchesAnyPattern(urlPath, cfg.publicEndpoints)
 	}
@@ -146,34 +157,8 @@ func requiresAuth(urlPath string, cfg *serverConfig) bool {
 	return cfg.authFunc != nil
 }
 
-// matchesCompiledPatterns checks if a path matches any compiled patterns.
-// Uses O(1) map lookup for exact matches, then falls back to wildcard patterns.
-func matchesCompiledPatterns(urlPath string, exactMap map[string]bool, wildcards []compiledPattern) bool {
-	// O(1) exact match lookup
-	if exactMap[urlPath] {
-		return true
-	}
-
-	// Check wildcard patterns (still O(n) but typically few patterns)
-	for _, cp := range wildcards {
-		if cp.isDouble {
-			// "/**" suffix - check prefix match
-			if strings.HasPrefix(urlPath, cp.prefix) {
-				return true
-			}
-		} else {
-			// Single "*" - use path.Match
-			matched, _ := path.Match(cp.pattern, urlPath)
-			if matched {
-				return true
-			}
-		}
-	}
-	return false
-}
-
 // matchesAnyPattern checks if a path matches any of the glob patterns.
-// Deprecated: Use matchesCompiledPatterns for better performance.
+// Deprecated: Use a pathmatch.Matcher for better performance.
 func matchesAnyPattern(urlPath string, patterns []string) bool {
 	for _, pattern := range patterns {
 		if matchPattern(pattern, urlPath) {
@@ -206,6 +191,98 @@ func matchPattern(pattern, urlPath string) bool {
 	return false
 }
 
+// authSchemeKey is the context key for the inbound Authorization scheme.
+type authSchemeKey struct{}
+
+// contextWithAuthScheme returns a context carrying the Authorization scheme.
+func contextWithAuthScheme(ctx context.Context, scheme string) context.Context {
+	return context.WithValue(ctx, authSchemeKey{}, scheme)
+}
+
+// AuthSchemeFromContext returns the scheme of the inbound Authorization
+// header, such as "Bearer", "Basic", "Digest" or a custom scheme, so an
+// AuthFunc can accept several kinds of credentials. The well-known schemes
+// are returned in their canonical case. It returns "" when the header has no
+// scheme, i.e. a bare token. The AuthFunc gets bearer tokens without their
+// scheme, and other credentials as sent, scheme included.
+//
+// Example:
+//
+//	grpckit.WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+//	    switch grpckit.AuthSchemeFromContext(ctx) {
+//	    case "Bearer", "":
+//	        return verifyJWT(ctx, token)
+//	    case "ApiKey":
+//	        return verifyAPIKey(ctx, strings.TrimPrefix(token, "ApiKey "))
+//	    }
+//	    return nil, grpckit.ErrUnauthorized
+//	})
+func AuthSchemeFromContext(ctx context.Context) string {
+	scheme, _ := ctx.Value(authSchemeKey{}).(string)
+	return scheme
+}
+
+// authorizationContext returns the arguments for the auth function from
+// gRPC metadata: the context carrying the scheme, and the token.
+func authorizationContext(ctx context.Context, md metadata.MD) (context.Context, string) {
+	header := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		header = values[0]
+	}
+	scheme, token := authToken(header)
+	return contextWithAuthScheme(ctx, scheme), token
+}
+
+// authToken returns the scheme of an Authorization header value and the
+// token passed to the auth function: the credentials of a bearer (or bare)
+// token, and the whole value for other schemes, e.g. "Basic dXNlcjpwYXNz",
+// so auth functions that parse their own scheme keep working.
+func authToken(header string) (scheme, token string) {
+	scheme, credentials := parseAuthorization(header)
+	if isBearer(scheme) {
+		return scheme, credentials
+	}
+	return scheme, header
+}
+
+// parseAuthorization splits an Authorization header value into its scheme
+// and credentials (RFC 9110), e.g. "Basic dXNlcjpwYXNz" into "Basic" and
+// "dXNlcjpwYXNz". Credentials are passed through as-is, including Digest
+// parameters. A value without a scheme is returned as credentials.
+func parseAuthorization(header string) (scheme, credentials string) {
+	i := strings.IndexByte(header, ' ')
+	if i <= 0 || !isTokenString(header[:i]) {
+		return "", header
+	}
+	scheme, credentials = header[:i], header[i+1:]
+	for _, known := range []string{"Bearer", "Basic", "Digest"} {
+		if strings.EqualFold(scheme, known) {
+			return known, credentials
+		}
+	}
+	return scheme, credentials
+}
+
+// isBearer reports whether credentials of scheme are bearer tokens, which
+// includes bare tokens without a scheme.
+func isBearer(scheme string) bool {
+	return scheme == "" || scheme == "Bearer"
+}
+
+// isTokenString reports whether s is an RFC 9110 token, as used for schemes.
+func isTokenString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // extractToken extracts the token from the Authorization header.
 // Handles "Bearer <token>" format with case-insensitive prefix matching.
 // Optimized to avoid allocations from strings.ToLower().
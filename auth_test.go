@@ -3,6 +3,7 @@ package grpckit
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -319,6 +320,28 @@ func TestAuthMiddleware_AuthFailure(t *testing.T) {
 	}
 }
 
+func TestAuthMiddleware_Forbidden(t *testing.T) {
+	cfg := &serverConfig{
+		authFunc: func(ctx context.Context, token string) (context.Context, error) {
+			return nil, ErrForbidden
+		},
+	}
+
+	handler := authMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("next handler should not be called on auth failure")
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/users", nil)
+	req.Header.Set("Authorization", "Bearer valid-token")
+	rec := httptest.NewRecorder()
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected status 403, got %d", rec.Code)
+	}
+}
+
 func TestGRPCAuthInterceptor_NoAuthFunc(t *testing.T) {
 	cfg := &serverConfig{authFunc: nil}
 	interceptor := grpcAuthInterceptor(cfg)
@@ -448,3 +471,80 @@ func TestGRPCAuthInterceptor_AuthFailure(t *testing.T) {
 		t.Errorf("expected Unauthenticated error, got %v", err)
 	}
 }
+
+func TestGRPCAuthInterceptor_Forbidden(t *testing.T) {
+	cfg := &serverConfig{
+		authFunc: func(ctx context.Context, token string) (context.Context, error) {
+			return nil, fmt.Errorf("role viewer: %w", ErrForbidden)
+		},
+	}
+	interceptor := grpcAuthInterceptor(cfg)
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Error("handler should not be called")
+		return nil, nil
+	}
+
+	md := metadata.New(map[string]string{"authorization": "Bearer valid-token"})
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err := interceptor(ctx, "request", &grpc.UnaryServerInfo{FullMethod: "/test/Method"}, handler)
+
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied error, got %v", err)
+	}
+}
+
+func TestParseAuthorization(t *testing.T) {
+	tests := []struct {
+		header      string
+		scheme      string
+		credentials string
+	}{
+		{"Bearer abc123", "Bearer", "abc123"},
+		{"bearer abc123", "Bearer", "abc123"},
+		{"BASIC dXNlcjpwYXNz", "Basic", "dXNlcjpwYXNz"},
+		{`Digest username="u", realm="r", response="x"`, "Digest", `username="u", realm="r", response="x"`},
+		{"ApiKey k-1", "ApiKey", "k-1"},
+		{"rawtoken", "", "rawtoken"},
+		{"", "", ""},
+		{"not/a-scheme token", "", "not/a-scheme token"},
+	}
+
+	for _, tt := range tests {
+		scheme, credentials := parseAuthorization(tt.header)
+		if scheme != tt.scheme || credentials != tt.credentials {
+			t.Errorf("parseAuthorization(%q) = %q, %q, want %q, %q", tt.header, scheme, credentials, tt.scheme, tt.credentials)
+		}
+	}
+}
+
+func TestAuthMiddleware_Schemes(t *testing.T) {
+	var gotScheme, gotCredentials string
+	cfg := &serverConfig{
+		authFunc: func(ctx context.Context, credentials string) (context.Context, error) {
+			gotScheme, gotCredentials = AuthSchemeFromContext(ctx), credentials
+			return ctx, nil
+		},
+	}
+	var token string
+	handler := authMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token = TokenFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Authorization", "ApiKey k-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotScheme != "ApiKey" || gotCredentials != "ApiKey k-1" {
+		t.Errorf("expected ApiKey scheme and the header as sent, got %q %q", gotScheme, gotCredentials)
+	}
+	if token != "" {
+		t.Errorf("expected non-bearer credentials not to be forwarded, got %q", token)
+	}
+
+	req.Header.Set("Authorization", "Bearer t-1")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if gotScheme != "Bearer" || token != "t-1" {
+		t.Errorf("expected bearer token, got scheme %q token %q", gotScheme, token)
+	}
+}
@@ -0,0 +1,225 @@
+package grpckit
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// maxAuthFailureClients bounds the clients tracked at once; expired windows
+// are pruned when it is reached.
+const maxAuthFailureClients = 10000
+
+// WithAuthFailureTracking makes requests rejected by WithAuth visible instead
+// of silent 401s. Every rejected HTTP request and gRPC call is logged at info
+// level with the client IP and request ID, and with WithMetrics counted in
+// grpckit_auth_failures_total{protocol,code}.
+//
+// When a client IP fails threshold times within window, which may mean
+// credential stuffing, a warning is logged and an AuthFailuresExceeded event
+// is delivered to the WithEventListener listeners, e.g. to page someone or
+// block the IP upstream. The alert fires once per client and window. A
+// threshold of 0 disables alerts.
+//
+// The client IP is the peer address; behind a load balancer it is the load
+// balancer's address unless it preserves client IPs (e.g. PROXY protocol).
+//
+// Example:
+//
+//	grpckit.WithAuthFailureTracking(20, time.Minute),
+//	grpckit.WithEventListener(func(e grpckit.Event) {
+//	    if ev, ok := e.(grpckit.AuthFailuresExceeded); ok {
+//	        alerts.Send("possible credential stuffing from " + ev.ClientIP)
+//	    }
+//	}),
+//
+// Example output:
+//
+//	level=INFO msg=rejected component=auth protocol=http method=GET path=/api/v1/items client_ip=203.0.113.7 code=401 request_id=4bf92f35
+//	level=INFO msg=rejected component=auth protocol=grpc method=/item.v1.ItemService/GetItem client_ip=203.0.113.7 code=Unauthenticated error="invalid token" request_id=9c1e7a02
+//	level=WARN msg="too many failed authentications" component=auth client_ip=203.0.113.7 failures=20 window=1m0s
+func WithAuthFailureTracking(threshold int, window time.Duration) Option {
+	return func(c *serverConfig) {
+		if threshold < 0 || (threshold > 0 && window <= 0) {
+			c.invalid("WithAuthFailureTracking: invalid threshold %d per %v", threshold, window)
+			return
+		}
+		c.authFailures = &authFailureTracker{
+			threshold: threshold,
+			window:    window,
+			clients:   make(map[string]*authFailureWindow),
+		}
+	}
+}
+
+// authFailureTracker counts failed authentications per client IP in fixed
+// windows.
+type authFailureTracker struct {
+	threshold int
+	window    time.Duration
+
+	mu      sync.Mutex
+	clients map[string]*authFailureWindow
+}
+
+// authFailureWindow holds a client's failures in the current window.
+type authFailureWindow struct {
+	start    time.Time
+	failures int
+}
+
+// record counts a failure and returns the failures in the current window
+// when the threshold has just been reached, or 0.
+func (t *authFailureTracker) record(clientIP string, now time.Time) int {
+	if t.threshold <= 0 {
+		return 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.clients[clientIP]
+	if !ok || now.Sub(w.start) >= t.window {
+		if !ok && len(t.clients) >= maxAuthFailureClients {
+			t.prune(now)
+		}
+		w = &authFailureWindow{start: now}
+		t.clients[clientIP] = w
+	}
+	w.failures++
+	if w.failures == t.threshold {
+		return w.failures
+	}
+	return 0
+}
+
+// prune drops the clients whose window has expired.
+func (t *authFailureTracker) prune(now time.Time) {
+	for ip, w := range t.clients {
+		if now.Sub(w.start) >= t.window {
+			delete(t.clients, ip)
+		}
+	}
+}
+
+// reportAuthFailure logs, counts and tracks one rejected request.
+func (s *Server) reportAuthFailure(ctx context.Context, protocol, clientIP, code string, fields *LogFieldSet) {
+	if s.metrics != nil {
+		s.metrics.authFailures.WithLabelValues(protocol, code).Inc()
+	}
+	if s.logger.Enabled(ctx, slog.LevelInfo) {
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "rejected",
+			append([]slog.Attr{slog.String("component", "auth")}, fields.attrs()...)...)
+	}
+
+	failures := s.cfg.authFailures.record(clientIP, s.now())
+	if failures == 0 {
+		return
+	}
+	s.logger.Warn("too many failed authentications", "component", "auth",
+		"client_ip", clientIP,
+		"failures", failures,
+		"window", s.cfg.authFailures.window)
+	s.emit(AuthFailuresExceeded{
+		Time:     time.Now(),
+		ClientIP: clientIP,
+		Failures: failures,
+		Window:   s.cfg.authFailures.window,
+	})
+}
+
+// authFailureMiddleware reports requests the auth middleware rejected, i.e.
+// answered without calling the next handler.
+func authFailureMiddleware(s *Server, next http.Handler) http.Handler {
+	type reachedKey struct{}
+	auth := authMiddleware(s.cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*r.Context().Value(reachedKey{}).(*bool) = true
+		next.ServeHTTP(w, r)
+	}))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached := false
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		auth.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), reachedKey{}, &reached)))
+		if reached {
+			return
+		}
+
+		clientIP := hostOnly(r.RemoteAddr)
+		requestID := requestIDFromContext(r.Context())
+		if requestID == "" {
+			requestID = r.Header.Get(requestIDHeader)
+		}
+		code := strconv.Itoa(rw.statusCode)
+		line := &LogFieldSet{}
+		line.Add("protocol", "http").
+			Add("method", r.Method).
+			Add("path", r.URL.Path).
+			Add("client_ip", clientIP).
+			Add("code", code).
+			Add("request_id", requestID)
+		s.reportAuthFailure(r.Context(), "http", clientIP, code, line)
+	})
+}
+
+// authFailureUnaryInterceptor reports calls the auth interceptor rejected.
+func authFailureUnaryInterceptor(s *Server, auth grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		reached := false
+		resp, err := auth(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			reached = true
+			return handler(ctx, req)
+		})
+		if err != nil && !reached {
+			s.reportGRPCAuthFailure(ctx, info.FullMethod, err)
+		}
+		return resp, err
+	}
+}
+
+// authFailureStreamInterceptor reports streams the auth interceptor rejected.
+func authFailureStreamInterceptor(s *Server, auth grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		reached := false
+		err := auth(srv, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+			reached = true
+			return handler(srv, ss)
+		})
+		if err != nil && !reached {
+			s.reportGRPCAuthFailure(ss.Context(), info.FullMethod, err)
+		}
+		return err
+	}
+}
+
+// reportGRPCAuthFailure reports a gRPC call rejected by authentication.
+func (s *Server) reportGRPCAuthFailure(ctx context.Context, method string, err error) {
+	clientIP := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		clientIP = hostOnly(p.Addr.String())
+	}
+	st := status.Convert(err)
+	line := &LogFieldSet{}
+	line.Add("protocol", "grpc").
+		Add("method", method).
+		Add("client_ip", clientIP).
+		Add("code", st.Code()).
+		Add("error", st.Message()).
+		Add("request_id", requestIDFromContext(ctx))
+	s.reportAuthFailure(ctx, "grpc", clientIP, st.Code().String(), line)
+}
+
+// hostOnly strips the port from an address, if any.
+func hostOnly(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
@@ -0,0 +1,143 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthFailureTracking_HTTP(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+
+	var alerts []AuthFailuresExceeded
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithMetrics(),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/public"),
+		WithAuthFailureTracking(3, time.Minute),
+		WithEventListener(func(e Event) {
+			if ev, ok := e.(AuthFailuresExceeded); ok {
+				alerts = append(alerts, ev)
+			}
+		}),
+		WithHTTPHandler("/denied", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusForbidden)
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	serve := func(path, token string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "203.0.113.7:41000"
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Accepted, public and handler-rejected requests are not auth failures
+	serve("/api/v1/items", "token")
+	serve("/public", "")
+	serve("/denied", "token")
+	if buf.Len() != 0 {
+		t.Fatalf("expected no auth failures, got %q", buf.String())
+	}
+
+	for i := 0; i < 4; i++ {
+		if code := serve("/api/v1/items", "wrong"); code != http.StatusUnauthorized {
+			t.Fatalf("expected 401, got %d", code)
+		}
+	}
+
+	if !strings.Contains(buf.String(), "msg=rejected component=auth protocol=http method=GET path=/api/v1/items client_ip=203.0.113.7 code=401") {
+		t.Errorf("expected rejected request to be logged, got %q", buf.String())
+	}
+	if strings.Count(buf.String(), `msg="too many failed authentications" component=auth client_ip=203.0.113.7 failures=3`) != 1 {
+		t.Errorf("expected one threshold warning, got %q", buf.String())
+	}
+	if len(alerts) != 1 || alerts[0].ClientIP != "203.0.113.7" || alerts[0].Failures != 3 {
+		t.Errorf("expected one alert event, got %+v", alerts)
+	}
+	if v := testutil.ToFloat64(s.metrics.authFailures.WithLabelValues("http", "401")); v != 4 {
+		t.Errorf("expected 4 counted failures, got %v", v)
+	}
+}
+
+func TestAuthFailureTracking_GRPC(t *testing.T) {
+	buf := captureLog(t)
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCHealthService(),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithAuthFailureTracking(0, 0),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	client := healthpb.NewHealthClient(ts.GRPCClientConn(context.Background()))
+	_, err = client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "msg=rejected component=auth protocol=grpc method=/grpc.health.v1.Health/Check") ||
+		!strings.Contains(buf.String(), "code=Unauthenticated") {
+		t.Errorf("expected rejected call to be logged, got %q", buf.String())
+	}
+	if strings.Contains(buf.String(), "too many failed") {
+		t.Errorf("expected no alert with threshold 0, got %q", buf.String())
+	}
+}
+
+func TestAuthFailureTracker_Window(t *testing.T) {
+	tracker := &authFailureTracker{threshold: 2, window: time.Minute, clients: make(map[string]*authFailureWindow)}
+	now := time.Now()
+
+	if n := tracker.record("10.0.0.1", now); n != 0 {
+		t.Errorf("expected no alert on first failure, got %d", n)
+	}
+	if n := tracker.record("10.0.0.1", now.Add(time.Second)); n != 2 {
+		t.Errorf("expected alert at threshold, got %d", n)
+	}
+	if n := tracker.record("10.0.0.1", now.Add(2*time.Second)); n != 0 {
+		t.Errorf("expected one alert per window, got %d", n)
+	}
+
+	// A new window starts counting again
+	tracker.record("10.0.0.1", now.Add(time.Minute))
+	if n := tracker.record("10.0.0.1", now.Add(time.Minute+time.Second)); n != 2 {
+		t.Errorf("expected alert in the next window, got %d", n)
+	}
+
+	// Expired clients are pruned
+	tracker.prune(now.Add(3 * time.Minute))
+	if len(tracker.clients) != 0 {
+		t.Errorf("expected expired clients to be pruned, got %d", len(tracker.clients))
+	}
+}
+
+func TestWithAuthFailureTracking_Invalid(t *testing.T) {
+	_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithAuthFailureTracking(5, 0))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
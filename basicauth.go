@@ -0,0 +1,78 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+)
+
+// BasicAuthFunc validates a username and password. Return an error to reject
+// the request (ErrForbidden for 403), or an enriched context to allow it.
+type BasicAuthFunc func(ctx context.Context, username, password string) (context.Context, error)
+
+// WithBasicAuth enables HTTP Basic authentication, a convenience for
+// internal tools where issuing tokens is overkill. It replaces WithAuth, and
+// WithProtectedEndpoints and WithPublicEndpoints apply as usual. Requests
+// without Basic credentials are rejected; 401 responses carry a
+// WWW-Authenticate header with realm, so browsers prompt for credentials.
+// The username becomes the principal (see PrincipalFromContext) unless the
+// validator sets another one.
+//
+// Basic credentials are sent in clear text on every request: only use it
+// over TLS.
+//
+// Example:
+//
+//	grpckit.WithBasicAuth("ops-console", grpckit.BasicAuthUsers(map[string]string{
+//	    "alice": os.Getenv("ALICE_PASSWORD"),
+//	}))
+func WithBasicAuth(realm string, validate BasicAuthFunc) Option {
+	return func(c *serverConfig) {
+		if validate == nil {
+			c.invalid("WithBasicAuth: nil validator")
+			return
+		}
+		c.authFunc = basicAuthFunc(validate)
+		c.basicAuthRealm = strings.ReplaceAll(realm, `"`, "")
+	}
+}
+
+// basicAuthFunc adapts a BasicAuthFunc to an AuthFunc.
+func basicAuthFunc(validate BasicAuthFunc) AuthFunc {
+	return func(ctx context.Context, token string) (context.Context, error) {
+		scheme, credentials := parseAuthorization(token)
+		if scheme != "Basic" {
+			return nil, ErrUnauthorized
+		}
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return nil, ErrUnauthorized
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return nil, ErrUnauthorized
+		}
+
+		ctx, err = validate(ctx, username, password)
+		if err != nil {
+			return nil, err
+		}
+		if PrincipalFromContext(ctx) == "" {
+			ctx = ContextWithPrincipal(ctx, username)
+		}
+		return ctx, nil
+	}
+}
+
+// BasicAuthUsers returns a BasicAuthFunc accepting a fixed set of users,
+// mapping usernames to passwords. Passwords are compared in constant time.
+func BasicAuthUsers(users map[string]string) BasicAuthFunc {
+	return func(ctx context.Context, username, password string) (context.Context, error) {
+		expected, ok := users[username]
+		if subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 || !ok {
+			return nil, ErrUnauthorized
+		}
+		return ctx, nil
+	}
+}
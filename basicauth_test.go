@@ -0,0 +1,110 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithBasicAuth(t *testing.T) {
+	var principal string
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithBasicAuth("ops", BasicAuthUsers(map[string]string{"alice": "s3cret"})),
+		WithHTTPHandler("/tools", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal = PrincipalFromContext(r.Context())
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	tests := []struct {
+		name string
+		auth func(r *http.Request)
+		want int
+	}{
+		{"valid", func(r *http.Request) { r.SetBasicAuth("alice", "s3cret") }, http.StatusOK},
+		{"wrong password", func(r *http.Request) { r.SetBasicAuth("alice", "wrong") }, http.StatusUnauthorized},
+		{"unknown user", func(r *http.Request) { r.SetBasicAuth("mallory", "s3cret") }, http.StatusUnauthorized},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cret") }, http.StatusUnauthorized},
+		{"malformed", func(r *http.Request) { r.Header.Set("Authorization", "Basic !!!") }, http.StatusUnauthorized},
+		{"missing", func(r *http.Request) {}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+			tt.auth(req)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, rec.Code)
+			}
+			challenge := rec.Header().Get("WWW-Authenticate")
+			if tt.want == http.StatusUnauthorized && challenge != `Basic realm="ops", charset="UTF-8"` {
+				t.Errorf("expected Basic challenge, got %q", challenge)
+			}
+		})
+	}
+	if principal != "alice" {
+		t.Errorf("expected username as principal, got %q", principal)
+	}
+}
+
+func TestWithBasicAuth_Forbidden(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithBasicAuth("ops", func(ctx context.Context, username, password string) (context.Context, error) {
+			return nil, ErrForbidden
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/tools", nil)
+	req.SetBasicAuth("bob", "pw")
+	rec := httptest.NewRecorder()
+	s.buildHTTPHandler(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden || rec.Header().Get("WWW-Authenticate") != "" {
+		t.Errorf("expected 403 without challenge, got %d %q", rec.Code, rec.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestWithBasicAuth_GRPC(t *testing.T) {
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCHealthService(),
+		WithBasicAuth("ops", BasicAuthUsers(map[string]string{"alice": "s3cret"})),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+	client := healthpb.NewHealthClient(ts.GRPCClientConn(context.Background()))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic YWxpY2U6czNjcmV0")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("expected valid credentials to pass, got %v", err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic YWxpY2U6d3Jvbmc=")
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestWithBasicAuth_NilValidator(t *testing.T) {
+	_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithBasicAuth("ops", nil))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
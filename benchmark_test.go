@@ -0,0 +1,201 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// discardWriter is a ResponseWriter reusing its header map, so benchmarks
+// only count the allocations of the code under test.
+type discardWriter struct {
+	header http.Header
+	status int
+}
+
+func newDiscardWriter() *discardWriter {
+	return &discardWriter{header: make(http.Header)}
+}
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *discardWriter) WriteHeader(code int)        { w.status = code }
+
+// reset clears the headers written by the previous request.
+func (w *discardWriter) reset() {
+	for k := range w.header {
+		delete(w.header, k)
+	}
+	w.status = 0
+}
+
+// benchmarkHandler serves req with handler b.N times.
+func benchmarkHandler(b *testing.B, handler http.Handler, req *http.Request) {
+	b.Helper()
+	w := newDiscardWriter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.reset()
+		handler.ServeHTTP(w, req)
+	}
+}
+
+// newBenchmarkStack builds the HTTP stack of a typical server: CORS,
+// metrics, auth with public endpoints, and a cached public endpoint.
+func newBenchmarkStack(b testing.TB, opts ...Option) http.Handler {
+	b.Helper()
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	body := []byte(`{"status":"ok"}`)
+	cacheControl := []string{"max-age=60"}
+	opts = append([]Option{
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithMetrics(),
+		WithCORS(),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/healthz", "/api/v1/public/**", "/api/v1/catalog/*"),
+		WithHTTPHandler("/api/v1/public/status", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header()["Cache-Control"] = cacheControl
+			w.Write(body)
+		})),
+	}, opts...)
+	s, err := New(opts...)
+	if err != nil {
+		b.Fatalf("New failed: %v", err)
+	}
+	return s.buildHTTPHandler(http.NotFoundHandler())
+}
+
+func BenchmarkHTTPStack_PublicGET(b *testing.B) {
+	handler := newBenchmarkStack(b)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/status", nil)
+	benchmarkHandler(b, handler, req)
+}
+
+// TestHTTPStack_PublicGETAllocs guards the zero-allocation fast path.
+func TestHTTPStack_PublicGETAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are skewed by the race detector")
+	}
+	handler := newBenchmarkStack(t)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/status", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := newDiscardWriter()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		w.reset()
+		handler.ServeHTTP(w, req)
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations for a public GET, got %v", allocs)
+	}
+	if w.status != 0 || w.header.Get("Cache-Control") != "max-age=60" || w.header.Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("unexpected response: %d %v", w.status, w.header)
+	}
+}
+
+func BenchmarkHTTPStack_AuthenticatedGET(b *testing.B) {
+	handler := newBenchmarkStack(b)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/public/status", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.URL.Path = "/api/v1/private"
+	benchmarkHandler(b, handler, req)
+}
+
+func BenchmarkRequiresAuth(b *testing.B) {
+	cfg := newServerConfig()
+	WithAuth(MockAuthFuncAllowAll())(cfg)
+	WithPublicEndpoints("/healthz", "/readyz", "/api/v1/public/**", "/api/v1/catalog/*")(cfg)
+
+	for _, path := range []string{"/healthz", "/api/v1/public/items/1", "/api/v1/catalog/books", "/api/v1/orders/42"} {
+		b.Run(path, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				requiresAuth(path, cfg)
+			}
+		})
+	}
+}
+
+func BenchmarkNormalizePath(b *testing.B) {
+	for _, path := range []string{"/healthz", "/api/v1/items/12345", "/api/v1/items/550e8400-e29b-41d4-a716-446655440000/reviews"} {
+		b.Run(path, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				normalizePath(path)
+			}
+		})
+	}
+}
+
+func BenchmarkMetricsMiddleware(b *testing.B) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := newMetrics("", prometheus.DefaultRegisterer)
+	handler := metricsMiddleware(m, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items/12345", nil)
+	benchmarkHandler(b, handler, req)
+}
+
+func BenchmarkCORSMiddleware(b *testing.B) {
+	handler := corsMiddleware(DefaultCORSConfig())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	benchmarkHandler(b, handler, req)
+}
+
+func BenchmarkMarshalers(b *testing.B) {
+	msg, _ := structpb.NewStruct(map[string]interface{}{
+		"id":    "item-1",
+		"name":  "Widget",
+		"price": 9.99,
+		"tags":  []interface{}{"a", "b"},
+	})
+	marshalers := map[string]interface {
+		Marshal(v interface{}) ([]byte, error)
+	}{
+		"json":        newJSONMarshaler(&JSONOptions{}),
+		"json_format": newJSONMarshaler(&JSONOptions{Int64AsNumber: true}),
+	}
+	for name, m := range marshalers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := m.Marshal(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkFormValuesToJSON(b *testing.B) {
+	values := url.Values{"name": {"Widget"}, "price": {"9.99"}, "quantity": {"3"}, "address.city": {"Rome"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := valuesToJSON(values, nil, CoerceInfer); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAuthInterceptor(b *testing.B) {
+	cfg := newServerConfig()
+	WithAuth(MockAuthFunc("token", "user"))(cfg)
+	interceptor := grpcAuthInterceptor(cfg)
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer token"))
+	info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/GetItem"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil }
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		interceptor(ctx, nil, info, handler)
+	}
+}
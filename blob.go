@@ -0,0 +1,178 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gyozatech/grpckit/blob"
+)
+
+// BlobOption configures the blob endpoints registered by WithBlobStore.
+type BlobOption func(*blobConfig)
+
+// blobConfig holds configuration for blob endpoints.
+type blobConfig struct {
+	prefix   string
+	maxSize  int64
+	authFunc AuthFunc
+	readOnly bool
+}
+
+// BlobPrefix sets the URL prefix for blob endpoints.
+// Default: "/blobs/"
+func BlobPrefix(prefix string) BlobOption {
+	return func(c *blobConfig) {
+		if !strings.HasSuffix(prefix, "/") {
+			prefix += "/"
+		}
+		c.prefix = prefix
+	}
+}
+
+// BlobMaxSize sets the maximum upload size in bytes.
+// Default: 32MB
+func BlobMaxSize(size int64) BlobOption {
+	return func(c *blobConfig) {
+		c.maxSize = size
+	}
+}
+
+// BlobAuth requires every blob request to pass the given auth function,
+// independently of WithProtectedEndpoints/WithPublicEndpoints.
+func BlobAuth(authFunc AuthFunc) BlobOption {
+	return func(c *blobConfig) {
+		c.authFunc = authFunc
+	}
+}
+
+// BlobReadOnly disables upload and delete endpoints.
+func BlobReadOnly() BlobOption {
+	return func(c *blobConfig) {
+		c.readOnly = true
+	}
+}
+
+// WithBlobStore registers upload/download endpoints backed by an object store.
+// Content is streamed to and from the store without being buffered in memory.
+//
+// Endpoints (default prefix "/blobs/"):
+//   - GET/HEAD {prefix}{key}: download (with Range support for seekable stores)
+//   - PUT/POST {prefix}{key}: upload the raw request body
+//   - DELETE {prefix}{key}: delete
+//
+// Example:
+//
+//	store, _ := blob.NewDirStore("/var/lib/attachments")
+//	grpckit.WithBlobStore(store,
+//	    grpckit.BlobPrefix("/api/v1/attachments/"),
+//	    grpckit.BlobMaxSize(100<<20),
+//	    grpckit.BlobAuth(authFunc),
+//	)
+func WithBlobStore(store blob.Store, opts ...BlobOption) Option {
+	cfg := &blobConfig{
+		prefix:  "/blobs/",
+		maxSize: 32 << 20, // 32MB default
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return WithHTTPHandler(cfg.prefix, blobHandler(store, cfg))
+}
+
+// blobHandler creates the HTTP handler serving blob endpoints.
+func blobHandler(store blob.Store, cfg *blobConfig) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Authenticate first, so unauthenticated clients can't probe keys
+		if cfg.authFunc != nil {
+			scheme, token := authToken(r.Header.Get("Authorization"))
+			ctx, err := cfg.authFunc(contextWithAuthScheme(r.Context(), scheme), token)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			r = r.WithContext(ctx)
+		}
+
+		key := strings.TrimPrefix(r.URL.Path, cfg.prefix)
+		if !blob.ValidKey(key) {
+			http.Error(w, blob.ErrInvalidKey.Error(), http.StatusBadRequest)
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			rc, info, err := store.Get(r.Context(), key)
+			if err != nil {
+				writeFileError(w, r, err, blobErrorStatus(err))
+				return
+			}
+			(&FileResponse{
+				Name:        path.Base(key),
+				ContentType: info.ContentType,
+				Content:     rc,
+				Size:        info.Size,
+				ModTime:     info.ModTime,
+			}).ServeHTTP(w, r)
+
+		case http.MethodPut, http.MethodPost:
+			if cfg.readOnly {
+				blobMethodNotAllowed(w, cfg)
+				return
+			}
+			if r.ContentLength > cfg.maxSize {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			body := http.MaxBytesReader(w, r.Body, cfg.maxSize)
+			info := blob.Info{Size: r.ContentLength, ContentType: r.Header.Get("Content-Type")}
+			if err := store.Put(r.Context(), key, body, info); err != nil {
+				var maxErr *http.MaxBytesError
+				if errors.As(err, &maxErr) {
+					http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+				writeFileError(w, r, err, blobErrorStatus(err))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+
+		case http.MethodDelete:
+			if cfg.readOnly {
+				blobMethodNotAllowed(w, cfg)
+				return
+			}
+			if err := store.Delete(r.Context(), key); err != nil {
+				writeFileError(w, r, err, blobErrorStatus(err))
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+
+		default:
+			blobMethodNotAllowed(w, cfg)
+		}
+	})
+}
+
+// blobMethodNotAllowed writes a 405 response with the allowed methods.
+func blobMethodNotAllowed(w http.ResponseWriter, cfg *blobConfig) {
+	if cfg.readOnly {
+		w.Header().Set("Allow", "GET, HEAD")
+	} else {
+		w.Header().Set("Allow", "GET, HEAD, PUT, POST, DELETE")
+	}
+	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+}
+
+// blobErrorStatus maps store errors to HTTP status codes.
+func blobErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, blob.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, blob.ErrInvalidKey):
+		return http.StatusBadRequest
+	default:
+		return fileErrorStatus(err)
+	}
+}
@@ -0,0 +1,75 @@
+// Package blob defines the storage abstraction used by grpckit's blob
+// endpoints (see grpckit.WithBlobStore).
+//
+// Implement Store to back attachments with S3, GCS or any other object
+// storage. Content is always passed as a stream, so implementations should
+// upload/download without buffering whole objects in memory.
+//
+// Example S3 adapter (using aws-sdk-go-v2's manager.Uploader):
+//
+//	type S3Store struct {
+//	    client   *s3.Client
+//	    uploader *manager.Uploader
+//	    bucket   string
+//	}
+//
+//	func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, info blob.Info) error {
+//	    _, err := s.uploader.Upload(ctx, &s3.PutObjectInput{
+//	        Bucket: &s.bucket, Key: &key, Body: r, ContentType: &info.ContentType,
+//	    })
+//	    return err
+//	}
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by Store implementations when a key does not exist.
+var ErrNotFound = errors.New("blob: not found")
+
+// ErrInvalidKey is returned when a key is empty or attempts path traversal.
+var ErrInvalidKey = errors.New("blob: invalid key")
+
+// Info describes a stored object.
+type Info struct {
+	// Size is the object size in bytes (-1 if unknown when uploading).
+	Size int64
+
+	// ContentType is the MIME type of the object.
+	ContentType string
+
+	// ModTime is the last modification time of the object.
+	ModTime time.Time
+}
+
+// Store is an object storage backend.
+type Store interface {
+	// Put streams r to the object identified by key, replacing any existing object.
+	Put(ctx context.Context, key string, r io.Reader, info Info) error
+
+	// Get opens the object identified by key. The caller must close the reader.
+	// If the returned reader implements io.Seeker, range requests are supported.
+	Get(ctx context.Context, key string) (io.ReadCloser, Info, error)
+
+	// Delete removes the object identified by key.
+	Delete(ctx context.Context, key string) error
+}
+
+// ValidKey reports whether key is safe to use as an object key.
+// Keys must be non-empty, relative, and must not contain ".." segments.
+func ValidKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, seg := range strings.Split(key, "/") {
+		if seg == "" || seg == "." || seg == ".." {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestValidKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		expected bool
+	}{
+		{"file.txt", true},
+		{"a/b/c.png", true},
+		{"", false},
+		{"/abs", false},
+		{"../escape", false},
+		{"a/../b", false},
+		{"a//b", false},
+		{"a\\b", false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidKey(tt.key); got != tt.expected {
+			t.Errorf("ValidKey(%q) = %v, want %v", tt.key, got, tt.expected)
+		}
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	if err := store.Put(ctx, "docs/a.txt", strings.NewReader("hello"), Info{ContentType: "text/plain"}); err != nil {
+		t.Fatalf("Put failed: %v", err)
+	}
+
+	rc, info, err := store.Get(ctx, "docs/a.txt")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(data) != "hello" {
+		t.Errorf("expected 'hello', got %q", data)
+	}
+	if info.Size != 5 {
+		t.Errorf("expected size 5, got %d", info.Size)
+	}
+	if _, ok := rc.(io.Seeker); !ok {
+		t.Error("expected seekable reader")
+	}
+
+	if err := store.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if _, _, err := store.Get(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := store.Delete(ctx, "docs/a.txt"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound deleting missing key, got %v", err)
+	}
+	if err := store.Put(ctx, "../x", strings.NewReader(""), Info{}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestDirStore(t *testing.T) {
+	store, err := NewDirStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDirStore failed: %v", err)
+	}
+	testStore(t, store)
+}
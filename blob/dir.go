@@ -0,0 +1,100 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+)
+
+// DirStore is a Store backed by a local directory.
+// Objects are written to a temporary file and renamed into place,
+// so readers never observe partially uploaded content.
+type DirStore struct {
+	root string
+}
+
+// NewDirStore creates a store rooted at dir, creating it if needed.
+func NewDirStore(dir string) (*DirStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DirStore{root: dir}, nil
+}
+
+// path resolves key to a file path inside the store root.
+func (d *DirStore) path(key string) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(d.root, filepath.FromSlash(key)), nil
+}
+
+// Put streams r into the file for key.
+func (d *DirStore) Put(ctx context.Context, key string, r io.Reader, info Info) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get opens the file for key. The returned *os.File is seekable.
+func (d *DirStore) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	p, err := d.path(key)
+	if err != nil {
+		return nil, Info{}, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, Info{}, ErrNotFound
+		}
+		return nil, Info{}, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, Info{}, err
+	}
+	return f, Info{
+		Size:        stat.Size(),
+		ContentType: mime.TypeByExtension(filepath.Ext(p)),
+		ModTime:     stat.ModTime(),
+	}, nil
+}
+
+// Delete removes the file for key.
+func (d *DirStore) Delete(ctx context.Context, key string) error {
+	p, err := d.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package blob
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory Store, useful for tests and local development.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	data []byte
+	info Info
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{objects: make(map[string]memoryObject)}
+}
+
+// Put stores the content of r under key.
+func (m *MemoryStore) Put(ctx context.Context, key string, r io.Reader, info Info) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	info.Size = int64(len(data))
+	info.ModTime = time.Now()
+
+	m.mu.Lock()
+	m.objects[key] = memoryObject{data: data, info: info}
+	m.mu.Unlock()
+	return nil
+}
+
+// Get returns a seekable reader over the object stored under key.
+func (m *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, Info, error) {
+	m.mu.RLock()
+	obj, ok := m.objects[key]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, Info{}, ErrNotFound
+	}
+	return readSeekNopCloser{bytes.NewReader(obj.data)}, obj.info, nil
+}
+
+// Delete removes the object stored under key.
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.objects[key]; !ok {
+		return ErrNotFound
+	}
+	delete(m.objects, key)
+	return nil
+}
+
+// readSeekNopCloser adds a no-op Close to a bytes.Reader while keeping it seekable.
+type readSeekNopCloser struct {
+	*bytes.Reader
+}
+
+func (readSeekNopCloser) Close() error { return nil }
@@ -0,0 +1,164 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gyozatech/grpckit/blob"
+)
+
+func newTestBlobHandler(opts ...BlobOption) (http.Handler, *blob.MemoryStore) {
+	store := blob.NewMemoryStore()
+	cfg := newServerConfig()
+	WithBlobStore(store, opts...)(cfg)
+	return cfg.httpHandlers[0].handler, store
+}
+
+func TestBlobHandler_UploadDownloadDelete(t *testing.T) {
+	handler, _ := newTestBlobHandler()
+
+	req := httptest.NewRequest(http.MethodPut, "/blobs/docs/a.txt", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("upload: expected 201, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/docs/a.txt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("download: expected 200, got %d", rec.Code)
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("expected body 'hello', got %q", rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain" {
+		t.Errorf("expected Content-Type text/plain, got %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/blobs/docs/a.txt", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/docs/a.txt", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestBlobHandler_MaxSize(t *testing.T) {
+	handler, _ := newTestBlobHandler(BlobMaxSize(4))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/blobs/big", strings.NewReader("too large")))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rec.Code)
+	}
+
+	// Unknown length (chunked) uploads are limited while streaming
+	req := httptest.NewRequest(http.MethodPut, "/blobs/big", strings.NewReader("too large"))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for streamed body, got %d", rec.Code)
+	}
+}
+
+func TestBlobHandler_InvalidKey(t *testing.T) {
+	handler, _ := newTestBlobHandler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestBlobHandler_Auth(t *testing.T) {
+	handler, _ := newTestBlobHandler(BlobAuth(MockAuthFunc("secret", "user-1")))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/a", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", rec.Code)
+	}
+
+	// Keys are validated only once authenticated
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blobs/../secret", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for an invalid key without token, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/blobs/a", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 with valid token, got %d", rec.Code)
+	}
+}
+
+// failingBlobStore is a blob.Store whose operations fail with internal
+// details.
+type failingBlobStore struct{}
+
+func (failingBlobStore) Put(ctx context.Context, key string, r io.Reader, info blob.Info) error {
+	return errors.New("dial tcp 10.0.3.7:9000: connection refused")
+}
+
+func (failingBlobStore) Get(ctx context.Context, key string) (io.ReadCloser, blob.Info, error) {
+	return nil, blob.Info{}, errors.New("dial tcp 10.0.3.7:9000: connection refused")
+}
+
+func (failingBlobStore) Delete(ctx context.Context, key string) error {
+	return errors.New("dial tcp 10.0.3.7:9000: connection refused")
+}
+
+func TestBlobHandler_StoreError(t *testing.T) {
+	buf := captureLog(t)
+	cfg := newServerConfig()
+	WithBlobStore(failingBlobStore{})(cfg)
+	handler := cfg.httpHandlers[0].handler
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, "/blobs/a", strings.NewReader("x")))
+		if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "10.0.3.7") {
+			t.Errorf("%s: expected 500 without details, got %d %q", method, rec.Code, rec.Body.String())
+		}
+	}
+	if !strings.Contains(buf.String(), "10.0.3.7") {
+		t.Errorf("expected store errors to be logged, got %q", buf.String())
+	}
+}
+
+func TestBlobHandler_ReadOnly(t *testing.T) {
+	handler, store := newTestBlobHandler(BlobReadOnly(), BlobPrefix("/files"))
+	_ = store.Put(context.Background(), "a", strings.NewReader("a"), blob.Info{})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/files/a", strings.NewReader("b")))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+	if allow := rec.Header().Get("Allow"); allow != "GET, HEAD" {
+		t.Errorf("expected Allow 'GET, HEAD', got %q", allow)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/files/a", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,248 @@
+package grpckit
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Errors returned by the request body helpers. Use BodyErrorStatus to map
+// them to an HTTP status.
+var (
+	// ErrBodyTooLarge is returned when a body exceeds its maximum size,
+	// after decompression.
+	ErrBodyTooLarge = errors.New("request body too large")
+	// ErrUnsupportedEncoding is returned for a Content-Encoding other than
+	// gzip, deflate or identity.
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+)
+
+// marshalersKey is the context key for the gateway mux whose marshalers
+// DecodeBody uses.
+type marshalersKey struct{}
+
+// defaultMarshalers serves DecodeBody outside a grpckit server, e.g. in
+// handler unit tests, with the gateway's default marshalers.
+var defaultMarshalers = runtime.NewServeMux()
+
+// withMarshalers makes the gateway marshalers available to DecodeBody in a
+// custom HTTP handler.
+func (s *Server) withMarshalers(handler http.Handler) http.Handler {
+	gwMux := s.gwMux
+	if gwMux == nil {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), marshalersKey{}, gwMux)
+		handler.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// BodyReader returns the body of r for streaming reads in a custom HTTP
+// handler, decompressed according to its Content-Encoding (gzip or
+// deflate), and limited to maxSize bytes both as sent and after
+// decompression, so compressed bodies can't expand past it. Reads past the
+// limit fail with ErrBodyTooLarge, and the connection is closed after the
+// response as with http.MaxBytesReader. A maxSize <= 0 means no limit.
+// Deflate bodies are zlib streams, as HTTP defines them, or raw deflate
+// data, which some clients send instead.
+//
+// Example:
+//
+//	grpckit.WithHTTPHandlerFunc("/imports", func(w http.ResponseWriter, r *http.Request) {
+//	    body, err := grpckit.BodyReader(w, r, 100<<20)
+//	    if err != nil {
+//	        http.Error(w, err.Error(), grpckit.BodyErrorStatus(err))
+//	        return
+//	    }
+//	    defer body.Close()
+//	    if err := importCSV(r.Context(), body); err != nil {
+//	        code := grpckit.BodyErrorStatus(err)
+//	        if code >= http.StatusInternalServerError {
+//	            log.Printf("import failed: %v", err) // don't send internal details
+//	            http.Error(w, http.StatusText(code), code)
+//	            return
+//	        }
+//	        http.Error(w, err.Error(), code)
+//	    }
+//	})
+func BodyReader(w http.ResponseWriter, r *http.Request, maxSize int64) (io.ReadCloser, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return http.NoBody, nil
+	}
+	raw := r.Body
+	if maxSize > 0 {
+		if r.ContentLength > maxSize {
+			return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrBodyTooLarge, r.ContentLength, maxSize)
+		}
+		raw = http.MaxBytesReader(w, raw, maxSize)
+	}
+
+	var body io.ReadCloser
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+		body = raw
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(raw)
+		if err != nil {
+			return nil, bodyError(err, maxSize)
+		}
+		body = readCloser{zr, raw}
+	case "deflate":
+		zr, err := deflateReader(raw)
+		if err != nil {
+			return nil, bodyError(err, maxSize)
+		}
+		body = readCloser{zr, raw}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, encoding)
+	}
+	if maxSize > 0 {
+		body = &limitedBody{body: body, remaining: maxSize, maxSize: maxSize}
+	}
+	return body, nil
+}
+
+// deflateReader returns a decompressor for a deflate body: zlib, if it
+// starts with a zlib header, or else raw deflate.
+func deflateReader(raw io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(raw)
+	if header, err := br.Peek(2); err == nil && header[0]&0x0f == 8 && (uint16(header[0])<<8|uint16(header[1]))%31 == 0 {
+		return zlib.NewReader(br)
+	}
+	return flate.NewReader(br), nil
+}
+
+// ReadBody reads the whole body of r, like BodyReader.
+func ReadBody(w http.ResponseWriter, r *http.Request, maxSize int64) ([]byte, error) {
+	body, err := BodyReader(w, r, maxSize)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, bodyError(err, maxSize)
+	}
+	return data, nil
+}
+
+// DecodeBody reads the body of r like ReadBody and decodes it into v with
+// the marshaler the gateway uses for its Content-Type, so custom handlers
+// accept the same formats as the REST services (JSON with the configured
+// JSONOptions, and formats added with WithFormURLEncodedSupport,
+// WithXMLSupport or WithMarshaler). Decoding errors are InvalidArgument
+// statuses (or a *ValidationError listing the invalid fields, where the
+// marshaler reports them), which map to 400 with BodyErrorStatus.
+//
+// Example:
+//
+//	var event WebhookEvent
+//	if err := grpckit.DecodeBody(w, r, 1<<20, &event); err != nil {
+//	    http.Error(w, err.Error(), grpckit.BodyErrorStatus(err))
+//	    return
+//	}
+func DecodeBody(w http.ResponseWriter, r *http.Request, maxSize int64, v any) error {
+	data, err := ReadBody(w, r, maxSize)
+	if err != nil {
+		return err
+	}
+	mux, _ := r.Context().Value(marshalersKey{}).(*runtime.ServeMux)
+	if mux == nil {
+		mux = defaultMarshalers
+	}
+	inbound, _ := runtime.MarshalerForRequest(mux, r)
+	if err := inbound.Unmarshal(data, v); err != nil {
+		var verr *ValidationError
+		if errors.As(err, &verr) {
+			return err
+		}
+		return status.Errorf(codes.InvalidArgument, "invalid request body: %v", err)
+	}
+	return nil
+}
+
+// BodyErrorStatus returns the HTTP status for an error returned by the
+// request body helpers: 413 for ErrBodyTooLarge, 415 for
+// ErrUnsupportedEncoding, 400 for corrupt compressed bodies and, for other
+// errors (including DecodeBody's and errors returned while processing a
+// stream), the status gRPC handlers would get for them (see RegisterError).
+func BodyErrorStatus(err error) int {
+	var corrupt flate.CorruptInputError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedEncoding):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, gzip.ErrHeader), errors.Is(err, gzip.ErrChecksum),
+		errors.Is(err, zlib.ErrHeader), errors.Is(err, zlib.ErrChecksum),
+		errors.Is(err, io.ErrUnexpectedEOF), errors.As(err, &corrupt):
+		return http.StatusBadRequest
+	}
+	return fileErrorStatus(err)
+}
+
+// bodyError turns the error of http.MaxBytesReader into ErrBodyTooLarge.
+func bodyError(err error, maxSize int64) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return fmt.Errorf("%w: the limit is %d bytes", ErrBodyTooLarge, maxSize)
+	}
+	return err
+}
+
+// readCloser reads from a decompressor and closes both it and the
+// compressed body.
+type readCloser struct {
+	io.ReadCloser
+	raw io.Closer
+}
+
+func (rc readCloser) Close() error {
+	rc.ReadCloser.Close()
+	return rc.raw.Close()
+}
+
+// limitedBody fails reads past the size limit with ErrBodyTooLarge, instead
+// of silently truncating like io.LimitReader.
+type limitedBody struct {
+	body      io.ReadCloser
+	remaining int64
+	maxSize   int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.remaining < 0 {
+		return 0, fmt.Errorf("%w: the limit is %d bytes", ErrBodyTooLarge, b.maxSize)
+	}
+	// Read one byte past the limit to tell a body of exactly maxSize bytes
+	// from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		n += int(b.remaining)
+		return n, fmt.Errorf("%w: the limit is %d bytes", ErrBodyTooLarge, b.maxSize)
+	}
+	if err != nil {
+		err = bodyError(err, b.maxSize)
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	return b.body.Close()
+}
@@ -0,0 +1,150 @@
+package grpckit
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte(data))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zlibbed(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write([]byte(data))
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func deflated(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	fw, _ := flate.NewWriter(&buf, flate.DefaultCompression)
+	fw.Write([]byte(data))
+	if err := fw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadBody(t *testing.T) {
+	corruptZlib := zlibbed(t, "hello")
+	corruptZlib[len(corruptZlib)-1] ^= 0xff
+
+	tests := []struct {
+		name     string
+		body     []byte
+		encoding string
+		maxSize  int64
+		expected string
+		status   int // 0 on success
+	}{
+		{"plain", []byte("hello"), "", 5, "hello", 0},
+		{"no limit", []byte("hello"), "", 0, "hello", 0},
+		{"too large", []byte("hello!"), "", 5, "", http.StatusRequestEntityTooLarge},
+		{"gzip", gzipped(t, "hello"), "gzip", 64, "hello", 0},
+		{"gzip bomb", gzipped(t, strings.Repeat("a", 1<<20)), "gzip", 1024, "", http.StatusRequestEntityTooLarge},
+		{"corrupt gzip", []byte("not gzip"), "gzip", 1024, "", http.StatusBadRequest},
+		{"deflate", zlibbed(t, "hello"), "deflate", 64, "hello", 0},
+		{"raw deflate", deflated(t, "hello"), "deflate", 64, "hello", 0},
+		{"deflate bomb", zlibbed(t, strings.Repeat("a", 1<<20)), "deflate", 1024, "", http.StatusRequestEntityTooLarge},
+		{"corrupt deflate", corruptZlib, "deflate", 1024, "", http.StatusBadRequest},
+		{"unsupported encoding", []byte("hello"), "br", 1024, "", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/upload", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Encoding", tt.encoding)
+			data, err := ReadBody(httptest.NewRecorder(), req, tt.maxSize)
+			if tt.status != 0 {
+				if err == nil || BodyErrorStatus(err) != tt.status {
+					t.Errorf("expected status %d, got %v", tt.status, err)
+				}
+				return
+			}
+			if err != nil || string(data) != tt.expected {
+				t.Errorf("expected %q, got %q, %v", tt.expected, data, err)
+			}
+		})
+	}
+}
+
+func TestBodyReader_UnknownLength(t *testing.T) {
+	// Chunked bodies have no Content-Length to reject upfront
+	req := httptest.NewRequest(http.MethodPost, "/upload", io.MultiReader(strings.NewReader("hello"), strings.NewReader(" world")))
+	req.ContentLength = -1
+	body, err := BodyReader(httptest.NewRecorder(), req, 8)
+	if err != nil {
+		t.Fatalf("BodyReader failed: %v", err)
+	}
+	data, err := io.ReadAll(body)
+	if !errors.Is(err, ErrBodyTooLarge) || string(data) != "hello wo" {
+		t.Errorf("expected the first 8 bytes and ErrBodyTooLarge, got %q, %v", data, err)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	var got struct {
+		Name  string `json:"name" xml:"name"`
+		Count int    `json:"count" xml:"count"`
+	}
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if err := DecodeBody(w, r, 1024, &got); err != nil {
+			http.Error(w, err.Error(), BodyErrorStatus(err))
+		}
+	}
+	s := newSlowTestServer(t, WithXMLSupport(), WithHTTPHandlerFunc("/hooks", handler))
+	gw, err := s.newGatewayMux(context.Background(), "localhost:0", []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())})
+	if err != nil {
+		t.Fatalf("newGatewayMux failed: %v", err)
+	}
+	h := s.buildHTTPHandler(gw)
+
+	// XML is decoded with the server's marshaler registry
+	req := httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`<item><name>book</name><count>2</count></item>`))
+	req.Header.Set("Content-Type", "application/xml; charset=utf-8")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || got.Name != "book" || got.Count != 2 {
+		t.Errorf("expected XML body to be decoded, got %d %+v: %s", rec.Code, got, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`{"name":`))
+	req.Header.Set("Content-Type", "application/json")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for malformed JSON, got %d", rec.Code)
+	}
+
+	// Outside a server, the gateway's default JSON marshaler is used
+	req = httptest.NewRequest(http.MethodPost, "/hooks", strings.NewReader(`{"name":"pen","count":3}`))
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK || got.Name != "pen" || got.Count != 3 {
+		t.Errorf("expected JSON body to be decoded, got %d %+v: %s", rec.Code, got, rec.Body.String())
+	}
+}
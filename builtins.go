@@ -0,0 +1,81 @@
+package grpckit
+
+import (
+	"context"
+	"strings"
+)
+
+// builtinRoute is an endpoint registered by grpckit itself on the HTTP mux.
+type builtinRoute struct {
+	pattern string
+	owner   string
+}
+
+// builtinRoutes returns the built-in endpoints enabled by cfg, including the
+// grpc-gateway catch-all.
+func builtinRoutes(cfg *serverConfig) []builtinRoute {
+	routes := []builtinRoute{{"/", "the grpc-gateway catch-all"}}
+	if cfg.healthEnabled {
+		routes = append(routes, builtinRoute{cfg.livenessPath, "WithHealthCheck"}, builtinRoute{cfg.readinessPath, "WithHealthCheck"})
+	}
+	if cfg.metricsEnabled {
+		routes = append(routes, builtinRoute{cfg.metricsPath, "WithMetrics"})
+	}
+	if cfg.swaggerEnabled {
+		routes = append(routes, builtinRoute{cfg.swaggerPrefix + "/", "WithSwagger"})
+	}
+	return routes
+}
+
+// WithPublicBuiltinEndpoints makes the enabled health, metrics and Swagger
+// endpoints public, at the paths configured with WithHealthPaths,
+// WithMetricsPath and WithSwaggerPrefix, so relocating them does not require
+// updating WithPublicEndpoints. It has no effect with WithProtectedEndpoints,
+// where only the listed paths require authentication anyway.
+//
+// Example:
+//
+//	grpckit.WithAuth(authFunc),
+//	grpckit.WithPublicBuiltinEndpoints(),
+//	grpckit.WithPublicEndpoints("/api/v1/catalog/**"),
+func WithPublicBuiltinEndpoints() Option {
+	return func(c *serverConfig) {
+		c.publicBuiltins = true
+	}
+}
+
+// InfrastructurePaths returns the health check and metrics paths of the
+// server handling ctx, as configured with WithHealthPaths and
+// WithMetricsPath, so middleware can skip the requests of probes and
+// scrapers wherever they are. It returns nil if ctx is not the context of a
+// request served by grpckit.
+//
+// Example:
+//
+//	if slices.Contains(grpckit.InfrastructurePaths(r.Context()), r.URL.Path) {
+//	    next.ServeHTTP(w, r) // not audited
+//	    return
+//	}
+func InfrastructurePaths(ctx context.Context) []string {
+	s, ok := ctx.Value(serverKey{}).(*Server)
+	if !ok {
+		return nil
+	}
+	return []string{s.cfg.livenessPath, s.cfg.readinessPath, s.cfg.metricsPath}
+}
+
+// publicBuiltinPatterns returns the public endpoint patterns matching the
+// built-in endpoints enabled by cfg.
+func publicBuiltinPatterns(cfg *serverConfig) []string {
+	var patterns []string
+	for _, r := range builtinRoutes(cfg) {
+		switch {
+		case r.pattern == "/":
+		case strings.HasSuffix(r.pattern, "/"):
+			patterns = append(patterns, r.pattern+"**")
+		default:
+			patterns = append(patterns, r.pattern)
+		}
+	}
+	return patterns
+}
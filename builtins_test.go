@@ -0,0 +1,69 @@
+package grpckit
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestBuiltinRoutes(t *testing.T) {
+	cfg := newServerConfig()
+	if got := builtinRoutes(cfg); len(got) != 1 || got[0].pattern != "/" {
+		t.Errorf("expected only the gateway catch-all, got %v", got)
+	}
+
+	WithHealthPaths("/livez", "/ready")(cfg)
+	WithMetricsPath("/admin/metrics")(cfg)
+	WithSwaggerFile("api.json")(cfg)
+	WithSwaggerPrefix("/docs/")(cfg)
+
+	var patterns []string
+	for _, r := range builtinRoutes(cfg) {
+		patterns = append(patterns, r.pattern)
+	}
+	if want := []string{"/", "/livez", "/ready", "/admin/metrics", "/docs/"}; !reflect.DeepEqual(patterns, want) {
+		t.Errorf("expected %v, got %v", want, patterns)
+	}
+}
+
+func TestWithPublicBuiltinEndpoints(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t,
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicBuiltinEndpoints(),
+		WithPublicEndpoints("/api/v1/catalog/**"),
+		WithHealthPaths("/livez", "/ready"),
+		WithMetricsPath("/admin/metrics"),
+		WithSwaggerFile("api.json"),
+		WithSwaggerPrefix("/docs"),
+	)
+
+	for path, want := range map[string]bool{
+		"/livez":            false,
+		"/ready":            false,
+		"/admin/metrics":    false,
+		"/docs/spec.json":   false,
+		"/api/v1/catalog/1": false,
+		"/healthz":          true,
+		"/metrics":          true,
+		"/api/v1/orders":    true,
+	} {
+		if got := requiresAuth(path, s.cfg); got != want {
+			t.Errorf("requiresAuth(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestInfrastructurePaths(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithHealthPaths("/livez", "/ready"), WithMetricsPath("/internal/metrics"))
+	want := []string{"/livez", "/ready", "/internal/metrics"}
+	if got := InfrastructurePaths(s.withServer(context.Background())); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := InfrastructurePaths(context.Background()); got != nil {
+		t.Errorf("expected nil outside a request, got %v", got)
+	}
+}
@@ -0,0 +1,175 @@
+package grpckit
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// CachePolicy describes the Cache-Control header of GET responses.
+type CachePolicy struct {
+	// MaxAge is how long responses may be reused (max-age). Responses are
+	// public unless Private is set, and also get an Expires header.
+	MaxAge time.Duration
+
+	// SharedMaxAge overrides MaxAge for shared caches such as CDNs (s-maxage).
+	SharedMaxAge time.Duration
+
+	// StaleWhileRevalidate lets caches serve stale responses for this long
+	// while they revalidate in the background.
+	StaleWhileRevalidate time.Duration
+
+	// Private restricts caching to the client, e.g. for per-user responses.
+	Private bool
+
+	// NoCache requires caches to revalidate before reusing a response.
+	NoCache bool
+
+	// NoStore forbids caching. It overrides every other setting.
+	NoStore bool
+
+	// Immutable tells clients the response does not change while fresh.
+	Immutable bool
+}
+
+// String returns the Cache-Control header value of the policy.
+func (p CachePolicy) String() string {
+	if p.NoStore {
+		return "no-store"
+	}
+	var directives []string
+	switch {
+	case p.Private:
+		directives = append(directives, "private")
+	case p.MaxAge > 0 || p.SharedMaxAge > 0:
+		directives = append(directives, "public")
+	}
+	if p.NoCache {
+		directives = append(directives, "no-cache")
+	}
+	if p.MaxAge > 0 || !p.NoCache {
+		directives = append(directives, "max-age="+seconds(p.MaxAge))
+	}
+	if p.SharedMaxAge > 0 {
+		directives = append(directives, "s-maxage="+seconds(p.SharedMaxAge))
+	}
+	if p.StaleWhileRevalidate > 0 {
+		directives = append(directives, "stale-while-revalidate="+seconds(p.StaleWhileRevalidate))
+	}
+	if p.Immutable {
+		directives = append(directives, "immutable")
+	}
+	return strings.Join(directives, ", ")
+}
+
+// seconds formats d as whole seconds.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(d/time.Second), 10)
+}
+
+// cachePolicy holds a CachePolicy for the paths matching its patterns.
+type cachePolicy struct {
+	paths  *pathmatch.Matcher
+	header []string
+	maxAge time.Duration
+}
+
+// WithCacheControl sets the Cache-Control header of successful GET and HEAD
+// responses for paths matching the patterns (same globs as
+// WithProtectedEndpoints), so handlers don't have to. The first matching
+// policy wins, and handlers setting Cache-Control themselves keep theirs.
+//
+// With WithAuth, authenticated responses get "Cache-Control: no-store" by
+// default, so shared caches never serve one user's data to another;
+// a matching policy (e.g. Private with a MaxAge) overrides it.
+//
+// Example:
+//
+//	grpckit.WithCacheControl(grpckit.CachePolicy{MaxAge: 5 * time.Minute}, "/api/v1/catalog/**")
+//	grpckit.WithCacheControl(grpckit.CachePolicy{Private: true, MaxAge: time.Minute}, "/api/v1/me")
+func WithCacheControl(policy CachePolicy, patterns ...string) Option {
+	return func(c *serverConfig) {
+		if len(patterns) == 0 {
+			c.invalid("WithCacheControl: no patterns")
+			return
+		}
+		p := cachePolicy{
+			paths:  c.compilePatterns("WithCacheControl", patterns),
+			header: []string{policy.String()},
+		}
+		if !policy.NoStore {
+			p.maxAge = policy.MaxAge
+		}
+		c.cachePolicies = append(c.cachePolicies, p)
+	}
+}
+
+// noStore is the Cache-Control header of authenticated responses.
+var noStore = []string{"no-store"}
+
+// cacheControlMiddleware applies the first matching CachePolicy to GET and
+// HEAD responses.
+func cacheControlMiddleware(policies []cachePolicy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			for i := range policies {
+				if policies[i].paths.Match(r.URL.Path) {
+					cw := &cacheControlWriter{ResponseWriter: w, header: policies[i].header, maxAge: policies[i].maxAge}
+					next.ServeHTTP(cw, r)
+					cw.writeHeader(http.StatusOK)
+					return
+				}
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cacheControlWriter sets Cache-Control to header on successful responses,
+// once the status is known, unless the handler set it. A positive maxAge
+// also sets Expires.
+type cacheControlWriter struct {
+	http.ResponseWriter
+	header  []string
+	maxAge  time.Duration
+	written bool
+}
+
+func (w *cacheControlWriter) writeHeader(code int) {
+	if w.written {
+		return
+	}
+	w.written = true
+	h := w.ResponseWriter.Header()
+	if _, ok := h["Cache-Control"]; ok || code >= http.StatusBadRequest {
+		return
+	}
+	h["Cache-Control"] = w.header
+	if w.maxAge > 0 {
+		h.Set("Expires", time.Now().Add(w.maxAge).UTC().Format(http.TimeFormat))
+	}
+}
+
+func (w *cacheControlWriter) WriteHeader(code int) {
+	w.writeHeader(code)
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cacheControlWriter) Write(b []byte) (int, error) {
+	w.writeHeader(http.StatusOK)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *cacheControlWriter) Flush() {
+	w.writeHeader(http.StatusOK)
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,97 @@
+package grpckit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCachePolicy_String(t *testing.T) {
+	tests := []struct {
+		policy CachePolicy
+		want   string
+	}{
+		{CachePolicy{MaxAge: 5 * time.Minute}, "public, max-age=300"},
+		{CachePolicy{Private: true, MaxAge: time.Minute}, "private, max-age=60"},
+		{CachePolicy{MaxAge: time.Minute, SharedMaxAge: time.Hour, StaleWhileRevalidate: 30 * time.Second}, "public, max-age=60, s-maxage=3600, stale-while-revalidate=30"},
+		{CachePolicy{MaxAge: 365 * 24 * time.Hour, Immutable: true}, "public, max-age=31536000, immutable"},
+		{CachePolicy{NoCache: true}, "no-cache"},
+		{CachePolicy{NoStore: true, MaxAge: time.Minute}, "no-store"},
+		{CachePolicy{}, "max-age=0"},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.String(); got != tt.want {
+			t.Errorf("%+v: expected %q, got %q", tt.policy, tt.want, got)
+		}
+	}
+}
+
+func TestWithCacheControl(t *testing.T) {
+	s := newSlowTestServer(t,
+		WithCacheControl(CachePolicy{MaxAge: 5 * time.Minute}, "/catalog/**"),
+		WithCacheControl(CachePolicy{NoStore: true}, "/catalog/live"),
+		WithHTTPHandlerFunc("/catalog/", func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/catalog/missing":
+				http.NotFound(w, r)
+			case "/catalog/custom":
+				w.Header().Set("Cache-Control", "max-age=1")
+			}
+		}),
+	)
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	tests := []struct {
+		method, path string
+		want         string
+		expires      bool
+	}{
+		{http.MethodGet, "/catalog/books", "public, max-age=300", true},
+		{http.MethodHead, "/catalog/books", "public, max-age=300", true},
+		{http.MethodGet, "/catalog/live", "public, max-age=300", true}, // first match wins
+		{http.MethodPost, "/catalog/books", "", false},
+		{http.MethodGet, "/catalog/missing", "", false},
+		{http.MethodGet, "/catalog/custom", "max-age=1", false},
+		{http.MethodGet, "/other", "", false},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if got := rec.Header().Get("Cache-Control"); got != tt.want {
+			t.Errorf("%s %s: expected Cache-Control %q, got %q", tt.method, tt.path, tt.want, got)
+		}
+		if expires := rec.Header().Get("Expires"); (expires != "") != tt.expires {
+			t.Errorf("%s %s: unexpected Expires %q", tt.method, tt.path, expires)
+		}
+	}
+
+	cfg := newServerConfig()
+	WithCacheControl(CachePolicy{MaxAge: time.Minute})(cfg)
+	if len(cfg.errs) != 1 {
+		t.Errorf("expected missing patterns to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestCacheControl_Authenticated(t *testing.T) {
+	s := newSlowTestServer(t,
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/public"),
+		WithCacheControl(CachePolicy{Private: true, MaxAge: time.Minute}, "/me"),
+	)
+	handler := s.buildHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for path, want := range map[string]string{
+		"/orders": "no-store",
+		"/me":     "private, max-age=60",
+		"/public": "",
+	} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Authorization", "Bearer token")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if got := rec.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: expected Cache-Control %q, got %q", path, want, got)
+		}
+	}
+}
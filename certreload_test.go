@@ -0,0 +1,142 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// copyCert copies a certificate and key file to certFile and keyFile.
+func copyCert(t *testing.T, fromCert, fromKey, certFile, keyFile string) {
+	t.Helper()
+	for from, to := range map[string]string{fromCert: certFile, fromKey: keyFile} {
+		data, err := os.ReadFile(from)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(to, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestWithCertReloader_Config(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	c := newServerConfig()
+	WithCertReloader(certFile, keyFile, time.Minute)(c)
+	cfg, err := buildTLSConfig(c)
+	if err != nil {
+		t.Fatalf("buildTLSConfig failed: %v", err)
+	}
+	if cert, err := cfg.GetCertificate(&tls.ClientHelloInfo{}); err != nil || cert == nil {
+		t.Errorf("expected initial certificate, got %v (%v)", cert, err)
+	}
+
+	c = newServerConfig()
+	WithCertReloader(certFile, keyFile, 0)(c)
+	if len(c.errs) != 1 || !errors.Is(c.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected zero interval to be rejected, got %v", c.errs)
+	}
+
+	c = newServerConfig()
+	WithCertReloader(filepath.Join(t.TempDir(), "missing.crt"), keyFile, time.Minute)(c)
+	if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for missing file, got %v", err)
+	}
+
+	c = newServerConfig()
+	WithTLS(certFile, keyFile)(c)
+	WithCertReloader(certFile, keyFile, time.Minute)(c)
+	if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected WithTLS and WithCertReloader to conflict, got %v", err)
+	}
+}
+
+type staticCertProvider struct{ cert *tls.Certificate }
+
+func (p staticCertProvider) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return p.cert, nil
+}
+
+func TestWithCertificateProvider(t *testing.T) {
+	want := &tls.Certificate{}
+	c := newServerConfig()
+	WithCertificateProvider(staticCertProvider{cert: want})(c)
+	cfg, err := buildTLSConfig(c)
+	if err != nil {
+		t.Fatalf("buildTLSConfig failed: %v", err)
+	}
+	if cert, _ := cfg.GetCertificate(&tls.ClientHelloInfo{}); cert != want {
+		t.Errorf("expected the provider's certificate, got %v", cert)
+	}
+
+	c = newServerConfig()
+	WithCertificateProvider(nil)(c)
+	if len(c.errs) != 1 {
+		t.Errorf("expected nil provider to be rejected, got %v", c.errs)
+	}
+}
+
+func TestCertReloader_SwapsRotatedCertificate(t *testing.T) {
+	buf := captureLog(t)
+	firstCert, firstKey := writeTestCert(t)
+	secondCert, secondKey := writeTestCert(t)
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	copyCert(t, firstCert, firstKey, certFile, keyFile)
+
+	var events []Event
+	s := newSlowTestServer(t,
+		WithCertReloader(certFile, keyFile, 10*time.Millisecond),
+		WithEventListener(func(e Event) { events = append(events, e) }),
+	)
+	r := s.cfg.certProvider.(*certReloader)
+	first, _ := r.GetCertificate(nil)
+
+	// Unchanged files are not reloaded
+	s.reloadCertificate(r)
+	if cert, _ := r.GetCertificate(nil); cert != first || len(events) != 0 {
+		t.Fatalf("expected unchanged certificate to be kept")
+	}
+
+	// A certificate without its key is retried, keeping the current one
+	copyCert(t, secondCert, firstKey, certFile, keyFile)
+	s.reloadCertificate(r)
+	if cert, _ := r.GetCertificate(nil); cert != first {
+		t.Fatalf("expected mismatched key pair to keep the current certificate")
+	}
+	if !strings.Contains(buf.String(), "TLS certificate reload failed") {
+		t.Errorf("expected failed reload to be logged, got %q", buf.String())
+	}
+
+	// Once rotated completely, the new certificate is served
+	copyCert(t, secondCert, secondKey, certFile, keyFile)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.watchCertificate(ctx, r)
+		close(done)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for cert, _ := r.GetCertificate(nil); cert == first; cert, _ = r.GetCertificate(nil) {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for certificate reload")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if len(events) != 1 {
+		t.Fatalf("expected one reload event, got %v", events)
+	}
+	if ev, ok := events[0].(ConfigReloaded); !ok || ev.Setting != "tls_certificate" {
+		t.Errorf("unexpected event %#v", events[0])
+	}
+}
@@ -0,0 +1,146 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/grpc"
+)
+
+// ChainHTTP composes middleware into one, applied in the order given (first
+// = outermost), like WithHTTPMiddleware. Use it to build per-handler chains:
+//
+//	webhook := grpckit.ChainHTTP(
+//	    grpckit.NamedMiddleware("signature", signatureMiddleware("secret")),
+//	    grpckit.OnlyPaths(auditMiddleware, "/webhooks/billing/**"),
+//	)
+//	grpckit.WithHTTPHandler("/webhooks/", webhook(webhookHandler))
+func ChainHTTP(mw ...HTTPMiddleware) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		for i := len(mw) - 1; i >= 0; i-- {
+			next = mw[i](next)
+		}
+		return next
+	}
+}
+
+// ChainUnary composes unary interceptors into one, applied in the order given
+// (first = outermost), like WithUnaryInterceptor.
+func ChainUnary(interceptors ...grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ChainStream composes stream interceptors into one, applied in the order
+// given (first = outermost), like WithStreamInterceptor.
+func ChainStream(interceptors ...grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], handler
+			handler = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return handler(srv, ss)
+	}
+}
+
+// OnlyPaths applies mw only to requests whose path matches one of the
+// patterns; other requests skip it. Patterns match like WithPublicEndpoints:
+// exact paths, "*" for one segment and a trailing "/**" for any suffix.
+//
+// Example:
+//
+//	grpckit.WithHTTPMiddleware(grpckit.OnlyPaths(auditMiddleware, "/api/v1/admin/**"))
+func OnlyPaths(mw HTTPMiddleware, patterns ...string) HTTPMiddleware {
+	return pathCondition(mw, pathmatch.Compile(patterns...), true)
+}
+
+// WithHTTPMiddlewareFor adds a custom HTTP middleware applied only to requests
+// whose path matches one of the patterns, like WithHTTPMiddleware with
+// OnlyPaths. Malformed patterns are reported by New.
+//
+// Example:
+//
+//	grpckit.WithHTTPMiddlewareFor(auditMiddleware, "/api/v1/admin/**", "/api/v1/billing/*")
+func WithHTTPMiddlewareFor(middleware HTTPMiddleware, patterns ...string) Option {
+	return func(c *serverConfig) {
+		if middleware == nil {
+			c.invalid("WithHTTPMiddlewareFor: nil middleware")
+			return
+		}
+		if len(patterns) == 0 {
+			c.invalid("WithHTTPMiddlewareFor: no patterns")
+			return
+		}
+		paths := c.compilePatterns("WithHTTPMiddlewareFor", patterns)
+		c.httpMiddlewares = append(c.httpMiddlewares, pathCondition(middleware, paths, true))
+	}
+}
+
+// ExceptPaths applies mw to every request except those whose path matches
+// one of the patterns, using the same patterns as OnlyPaths.
+//
+// Example:
+//
+//	grpckit.WithHTTPMiddleware(grpckit.ExceptPaths(gzipMiddleware, "/metrics", "/events/**"))
+func ExceptPaths(mw HTTPMiddleware, patterns ...string) HTTPMiddleware {
+	return pathCondition(mw, pathmatch.Compile(patterns...), false)
+}
+
+// pathCondition applies mw when the request path matching paths equals
+// match.
+func pathCondition(mw HTTPMiddleware, paths *pathmatch.Matcher, match bool) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if paths.Match(r.URL.Path) == match {
+				wrapped.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NamedMiddleware names mw in the WithMiddlewareTrace output, which lists
+// custom middleware by position otherwise.
+func NamedMiddleware(name string, mw HTTPMiddleware) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t := traceFromContext(r.Context()); t != nil {
+				t.add(name)
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
+
+// NamedUnary names a unary interceptor in the WithMiddlewareTrace output.
+func NamedUnary(name string, interceptor grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if t := traceFromContext(ctx); t != nil {
+			t.add(name)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// NamedStream names a stream interceptor in the WithMiddlewareTrace output.
+func NamedStream(name string, interceptor grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if t := traceFromContext(ss.Context()); t != nil {
+			t.add(name)
+		}
+		return interceptor(srv, ss, info, handler)
+	}
+}
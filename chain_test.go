@@ -0,0 +1,168 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// recordingMiddleware appends name to order when it runs.
+func recordingMiddleware(order *[]string, name string) HTTPMiddleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*order = append(*order, name)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChainHTTP(t *testing.T) {
+	var order []string
+	chain := ChainHTTP(recordingMiddleware(&order, "a"), recordingMiddleware(&order, "b"))
+	handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	if strings.Join(order, ",") != "a,b,handler" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestChainUnary(t *testing.T) {
+	var order []string
+	record := func(name string) grpc.UnaryServerInterceptor {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			order = append(order, name+":"+info.FullMethod)
+			return handler(ctx, req)
+		}
+	}
+
+	resp, err := ChainUnary(record("a"), record("b"))(context.Background(), "req", &grpc.UnaryServerInfo{FullMethod: "/svc/M"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			order = append(order, "handler")
+			return req, nil
+		})
+	if err != nil || resp != "req" {
+		t.Fatalf("unexpected result %v, %v", resp, err)
+	}
+	if strings.Join(order, ",") != "a:/svc/M,b:/svc/M,handler" {
+		t.Errorf("unexpected order %v", order)
+	}
+}
+
+func TestChainStream(t *testing.T) {
+	var order []string
+	record := func(name string) grpc.StreamServerInterceptor {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			order = append(order, name)
+			return handler(srv, ss)
+		}
+	}
+
+	err := ChainStream(record("a"), record("b"))(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		order = append(order, "handler")
+		return nil
+	})
+	if err != nil || strings.Join(order, ",") != "a,b,handler" {
+		t.Errorf("unexpected result %v, order %v", err, order)
+	}
+}
+
+func TestOnlyPathsExceptPaths(t *testing.T) {
+	tests := []struct {
+		path       string
+		wantOnly   bool
+		wantExcept bool
+	}{
+		{"/admin", true, false},
+		{"/api/v1/admin/users", true, false},
+		{"/items/42", true, false},
+		{"/items/42/reviews", false, true},
+		{"/public", false, true},
+	}
+
+	patterns := []string{"/admin", "/api/v1/admin/**", "/items/*"}
+	for _, tt := range tests {
+		var order []string
+		next := http.NotFoundHandler()
+		only := OnlyPaths(recordingMiddleware(&order, "only"), patterns...)(next)
+		except := ExceptPaths(recordingMiddleware(&order, "except"), patterns...)(next)
+
+		only.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+		except.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+		ran := strings.Join(order, ",")
+		if strings.Contains(ran, "only") != tt.wantOnly || strings.Contains(ran, "except") != tt.wantExcept {
+			t.Errorf("%s: unexpected middleware run: %q", tt.path, ran)
+		}
+	}
+}
+
+func TestWithHTTPMiddlewareFor(t *testing.T) {
+	cfg := newServerConfig()
+	var order []string
+	WithHTTPMiddlewareFor(recordingMiddleware(&order, "admin"), "/admin", "/api/v1/admin/**")(cfg)
+	if len(cfg.errs) != 0 {
+		t.Fatalf("unexpected errors: %v", cfg.errs)
+	}
+	if len(cfg.httpMiddlewares) != 1 {
+		t.Fatalf("expected 1 middleware, got %d", len(cfg.httpMiddlewares))
+	}
+	handler := cfg.httpMiddlewares[0](http.NotFoundHandler())
+
+	for path, want := range map[string]bool{"/admin": true, "/api/v1/admin/users": true, "/api/v1/items": false} {
+		order = nil
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		if got := len(order) == 1; got != want {
+			t.Errorf("%s: middleware ran = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestWithHTTPMiddlewareFor_Invalid(t *testing.T) {
+	mw := func(next http.Handler) http.Handler { return next }
+	for name, opt := range map[string]Option{
+		"nil middleware":    WithHTTPMiddlewareFor(nil, "/admin"),
+		"no patterns":       WithHTTPMiddlewareFor(mw),
+		"malformed pattern": WithHTTPMiddlewareFor(mw, "/a/[*"),
+	} {
+		cfg := newServerConfig()
+		opt(cfg)
+		if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, cfg.errs)
+		}
+	}
+}
+
+func TestNamedMiddleware_Trace(t *testing.T) {
+	noop := func(next http.Handler) http.Handler { return next }
+	handler := newTraceTestHandler(t, WithHTTPHandler("/webhook",
+		ChainHTTP(NamedMiddleware("signature", noop))(http.NotFoundHandler())))
+
+	req := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(middlewareTraceHeader); !strings.HasSuffix(got, "route /webhook, signature") {
+		t.Errorf("expected named middleware in trace, got %q", got)
+	}
+}
+
+func TestNamedUnary_Trace(t *testing.T) {
+	trace := &middlewareTrace{}
+	ctx := context.WithValue(context.Background(), middlewareTraceKey{}, trace)
+	interceptor := NamedUnary("audit", func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ctx, req)
+	})
+
+	_, _ = interceptor(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) { return nil, nil })
+	if got := trace.join(", "); got != "audit" {
+		t.Errorf("expected named interceptor in trace, got %q", got)
+	}
+}
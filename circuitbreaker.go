@@ -0,0 +1,356 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// CircuitState is the state of a circuit breaker.
+type CircuitState int
+
+const (
+	// CircuitClosed allows all calls through.
+	CircuitClosed CircuitState = iota
+	// CircuitHalfOpen allows a limited number of probe calls through.
+	CircuitHalfOpen
+	// CircuitOpen rejects all calls with codes.Unavailable.
+	CircuitOpen
+)
+
+// String returns the state name.
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitHalfOpen:
+		return "half-open"
+	case CircuitOpen:
+		return "open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// Name identifies the breaker in metrics (e.g. "gateway", "billing-client").
+	// Default: "default"
+	Name string
+
+	// FailureThreshold is the failure ratio (0-1) that opens the circuit.
+	// Default: 0.5
+	FailureThreshold float64
+
+	// MinRequests is the minimum number of calls in a window before the
+	// failure ratio is evaluated. Default: 20
+	MinRequests int
+
+	// Window is the interval over which calls are counted. Default: 10s
+	Window time.Duration
+
+	// OpenTimeout is how long the circuit stays open before allowing probes.
+	// Default: 30s
+	OpenTimeout time.Duration
+
+	// HalfOpenRequests is the number of successful probes required to close
+	// the circuit again. Default: 1
+	HalfOpenRequests int
+
+	// IsFailure decides whether an error counts as a failure.
+	// Default: Unavailable, DeadlineExceeded, ResourceExhausted, Internal and Unknown.
+	IsFailure func(err error) bool
+}
+
+// DefaultCircuitBreakerConfig returns a CircuitBreakerConfig with default values.
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		Name:             "default",
+		FailureThreshold: 0.5,
+		MinRequests:      20,
+		Window:           10 * time.Second,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenRequests: 1,
+		IsFailure:        isCircuitFailure,
+	}
+}
+
+// isCircuitFailure reports whether err indicates an unhealthy backend.
+func isCircuitFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
+
+// CircuitBreaker isolates failing gRPC methods. Each method has its own
+// circuit, so one failing RPC does not block calls to healthy ones.
+//
+// CircuitBreaker implements prometheus.Collector, exposing a
+// grpckit_circuit_breaker_state gauge (0=closed, 1=half-open, 2=open).
+type CircuitBreaker struct {
+	cfg      CircuitBreakerConfig
+	mu       sync.Mutex
+	circuits map[string]*circuit
+	now      func() time.Time
+	desc     *prometheus.Desc
+}
+
+// circuit holds the state for a single method.
+type circuit struct {
+	state       CircuitState
+	windowStart time.Time
+	requests    int
+	failures    int
+	openedAt    time.Time
+	probes      int // in-flight probes in half-open state
+	successes   int // successful probes in half-open state
+}
+
+// NewCircuitBreaker creates a circuit breaker. Zero fields in cfg use defaults.
+//
+// Example (outbound client):
+//
+//	cb := grpckit.NewCircuitBreaker(grpckit.CircuitBreakerConfig{Name: "billing"})
+//	prometheus.MustRegister(cb)
+//	conn, _ := grpc.NewClient(addr, grpc.WithUnaryInterceptor(cb.UnaryClientInterceptor()))
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	def := DefaultCircuitBreakerConfig()
+	if cfg.Name == "" {
+		cfg.Name = def.Name
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = def.FailureThreshold
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = def.MinRequests
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = def.Window
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = def.OpenTimeout
+	}
+	if cfg.HalfOpenRequests <= 0 {
+		cfg.HalfOpenRequests = def.HalfOpenRequests
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = def.IsFailure
+	}
+
+	return &CircuitBreaker{
+		cfg:      cfg,
+		circuits: make(map[string]*circuit),
+		now:      time.Now,
+		desc: prometheus.NewDesc(
+			"grpckit_circuit_breaker_state",
+			"Circuit breaker state per method (0=closed, 1=half-open, 2=open)",
+			[]string{"method"},
+			prometheus.Labels{"breaker": cfg.Name},
+		),
+	}
+}
+
+// State returns the current state of the circuit for a method.
+func (cb *CircuitBreaker) State(method string) CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	c, ok := cb.circuits[method]
+	if !ok {
+		return CircuitClosed
+	}
+	cb.refreshLocked(c)
+	return c.state
+}
+
+// allow checks whether a call may proceed, reserving a probe slot in half-open state.
+func (cb *CircuitBreaker) allow(method string) bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c, ok := cb.circuits[method]
+	if !ok {
+		c = &circuit{windowStart: cb.now()}
+		cb.circuits[method] = c
+	}
+	cb.refreshLocked(c)
+
+	switch c.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if c.probes+c.successes >= cb.cfg.HalfOpenRequests {
+			return false
+		}
+		c.probes++
+		return true
+	default:
+		return true
+	}
+}
+
+// refreshLocked advances time-based transitions (open → half-open, window reset).
+func (cb *CircuitBreaker) refreshLocked(c *circuit) {
+	now := cb.now()
+	if c.state == CircuitOpen && now.Sub(c.openedAt) >= cb.cfg.OpenTimeout {
+		c.state = CircuitHalfOpen
+		c.probes, c.successes = 0, 0
+	}
+	if c.state == CircuitClosed && now.Sub(c.windowStart) >= cb.cfg.Window {
+		c.windowStart = now
+		c.requests, c.failures = 0, 0
+	}
+}
+
+// record records the outcome of a call.
+func (cb *CircuitBreaker) record(method string, err error) {
+	failed := cb.cfg.IsFailure(err)
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	c := cb.circuits[method]
+	switch c.state {
+	case CircuitHalfOpen:
+		// Calls let through before the circuit opened may complete
+		// after it turned half-open, without a probe slot to free
+		if c.probes > 0 {
+			c.probes--
+		}
+		if failed {
+			c.state = CircuitOpen
+			c.openedAt = cb.now()
+			return
+		}
+		c.successes++
+		if c.successes >= cb.cfg.HalfOpenRequests {
+			c.state = CircuitClosed
+			c.windowStart = cb.now()
+			c.requests, c.failures = 0, 0
+		}
+	case CircuitClosed:
+		c.requests++
+		if failed {
+			c.failures++
+		}
+		if c.requests >= cb.cfg.MinRequests &&
+			float64(c.failures)/float64(c.requests) >= cb.cfg.FailureThreshold {
+			c.state = CircuitOpen
+			c.openedAt = cb.now()
+		}
+	}
+}
+
+// retryIn returns how long until the circuit of method lets probe calls
+// through, or a second if it is half-open with all probes in flight.
+func (cb *CircuitBreaker) retryIn(method string) time.Duration {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if c, ok := cb.circuits[method]; ok && c.state == CircuitOpen {
+		if d := c.openedAt.Add(cb.cfg.OpenTimeout).Sub(cb.now()); d > 0 {
+			return d
+		}
+	}
+	return time.Second
+}
+
+// errCircuitOpen returns the error for rejected calls, with a RetryInfo
+// detail so clients (and REST responses, via Retry-After) back off until
+// the circuit allows probes again.
+func (cb *CircuitBreaker) errCircuitOpen(method string) error {
+	st := status.Newf(codes.Unavailable, "circuit breaker open for %s", method)
+	if withInfo, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(cb.retryIn(method))}); err == nil {
+		st = withInfo
+	}
+	return st.Err()
+}
+
+// UnaryClientInterceptor returns a client interceptor enforcing the breaker.
+func (cb *CircuitBreaker) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !cb.allow(method) {
+			return cb.errCircuitOpen(method)
+		}
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		cb.record(method, err)
+		return err
+	}
+}
+
+// StreamClientInterceptor returns a stream client interceptor enforcing the breaker.
+// Only stream establishment is tracked, not errors on individual messages.
+func (cb *CircuitBreaker) StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if !cb.allow(method) {
+			return nil, cb.errCircuitOpen(method)
+		}
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		cb.record(method, err)
+		return stream, err
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (cb *CircuitBreaker) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cb.desc
+}
+
+// Collect implements prometheus.Collector.
+func (cb *CircuitBreaker) Collect(ch chan<- prometheus.Metric) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	for method, c := range cb.circuits {
+		cb.refreshLocked(c)
+		ch <- prometheus.MustNewConstMetric(cb.desc, prometheus.GaugeValue, float64(c.state), method)
+	}
+}
+
+// registerCircuitBreaker exports the breaker state on reg. A breaker with
+// the same name registered before, e.g. by an earlier server in the same
+// process, keeps being exported, so a warning is logged instead of failing.
+func registerCircuitBreaker(reg prometheus.Registerer, cb *CircuitBreaker, logger *slog.Logger) {
+	if err := reg.Register(cb); err != nil {
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			logger.Warn("circuit breaker already registered, its metrics reflect the first one", "component", "metrics", "breaker", cb.cfg.Name)
+			return
+		}
+		logger.Warn("failed to register circuit breaker collector", "component", "metrics", "breaker", cb.cfg.Name, "error", err)
+	}
+}
+
+// WithGatewayCircuitBreaker protects the grpc-gateway → gRPC connection with a
+// circuit breaker. When a method's circuit is open, REST calls fail fast with
+// 503 instead of piling up on an unhealthy backend. The breaker state is
+// exported on /metrics when WithMetrics is enabled.
+//
+// Example:
+//
+//	grpckit.WithGatewayCircuitBreaker(grpckit.CircuitBreakerConfig{
+//	    FailureThreshold: 0.5,
+//	    MinRequests:      20,
+//	    OpenTimeout:      30 * time.Second,
+//	})
+func WithGatewayCircuitBreaker(cfg CircuitBreakerConfig) Option {
+	return func(c *serverConfig) {
+		if cfg.Name == "" {
+			cfg.Name = "gateway"
+		}
+		c.gatewayCircuitBreaker = NewCircuitBreaker(cfg)
+	}
+}
@@ -0,0 +1,308 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// invokeWith returns a UnaryInvoker that always returns err.
+func invokeWith(err error) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		return err
+	}
+}
+
+// retryDelay returns the RetryInfo delay of err, or 0.
+func retryDelay(err error) time.Duration {
+	for _, detail := range status.Convert(err).Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok {
+			return info.GetRetryDelay().AsDuration()
+		}
+	}
+	return 0
+}
+
+func newTestBreaker(now *time.Time) *CircuitBreaker {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{
+		Name:             "test",
+		FailureThreshold: 0.5,
+		MinRequests:      4,
+		Window:           time.Minute,
+		OpenTimeout:      10 * time.Second,
+		HalfOpenRequests: 2,
+	})
+	cb.now = func() time.Time { return *now }
+	return cb
+}
+
+func TestNewCircuitBreaker_Defaults(t *testing.T) {
+	cb := NewCircuitBreaker(CircuitBreakerConfig{})
+	def := DefaultCircuitBreakerConfig()
+
+	if cb.cfg.Name != def.Name {
+		t.Errorf("expected name %q, got %q", def.Name, cb.cfg.Name)
+	}
+	if cb.cfg.MinRequests != def.MinRequests {
+		t.Errorf("expected MinRequests %d, got %d", def.MinRequests, cb.cfg.MinRequests)
+	}
+	if cb.cfg.OpenTimeout != def.OpenTimeout {
+		t.Errorf("expected OpenTimeout %v, got %v", def.OpenTimeout, cb.cfg.OpenTimeout)
+	}
+	if cb.cfg.IsFailure == nil {
+		t.Error("expected default IsFailure")
+	}
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	ctx := context.Background()
+	method := "/test.Service/Get"
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	// Below MinRequests the circuit stays closed
+	for i := 0; i < 3; i++ {
+		interceptor(ctx, method, nil, nil, nil, invokeWith(unavailable))
+	}
+	if got := cb.State(method); got != CircuitClosed {
+		t.Fatalf("expected closed below volume threshold, got %s", got)
+	}
+
+	interceptor(ctx, method, nil, nil, nil, invokeWith(unavailable))
+	if got := cb.State(method); got != CircuitOpen {
+		t.Fatalf("expected open, got %s", got)
+	}
+
+	// Open circuit fails fast without calling the invoker
+	called := false
+	err := interceptor(ctx, method, nil, nil, nil, func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		called = true
+		return nil
+	})
+	if called {
+		t.Error("invoker should not be called while open")
+	}
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+	if got := retryDelay(err); got != 10*time.Second {
+		t.Errorf("expected RetryInfo delay of 10s, got %v", got)
+	}
+	now = now.Add(4 * time.Second)
+	err = interceptor(ctx, method, nil, nil, nil, invokeWith(nil))
+	if got := retryDelay(err); got != 6*time.Second {
+		t.Errorf("expected RetryInfo delay of 6s, got %v", got)
+	}
+
+	// After OpenTimeout the circuit is half-open and needs 2 successful probes
+	now = now.Add(6 * time.Second)
+	if got := cb.State(method); got != CircuitHalfOpen {
+		t.Fatalf("expected half-open, got %s", got)
+	}
+	if err := interceptor(ctx, method, nil, nil, nil, invokeWith(nil)); err != nil {
+		t.Fatalf("probe failed: %v", err)
+	}
+	if got := cb.State(method); got != CircuitHalfOpen {
+		t.Fatalf("expected half-open after 1 probe, got %s", got)
+	}
+	interceptor(ctx, method, nil, nil, nil, invokeWith(nil))
+	if got := cb.State(method); got != CircuitClosed {
+		t.Fatalf("expected closed after probes, got %s", got)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenFailureReopens(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	ctx := context.Background()
+	method := "/test.Service/Get"
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	for i := 0; i < 4; i++ {
+		interceptor(ctx, method, nil, nil, nil, invokeWith(unavailable))
+	}
+	now = now.Add(10 * time.Second)
+
+	interceptor(ctx, method, nil, nil, nil, invokeWith(unavailable))
+	if got := cb.State(method); got != CircuitOpen {
+		t.Fatalf("expected open after failed probe, got %s", got)
+	}
+}
+
+func TestCircuitBreaker_HalfOpenLimitsProbes(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	method := "/test.Service/Get"
+
+	for i := 0; i < 4; i++ {
+		cb.allow(method)
+		cb.record(method, status.Error(codes.Unavailable, "down"))
+	}
+	now = now.Add(10 * time.Second)
+
+	// HalfOpenRequests=2: only two concurrent probes are admitted
+	if !cb.allow(method) || !cb.allow(method) {
+		t.Fatal("expected two probes to be allowed")
+	}
+	if cb.allow(method) {
+		t.Error("expected third probe to be rejected")
+	}
+}
+
+func TestCircuitBreaker_StaleCallInHalfOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	method := "/test.Service/Get"
+
+	// A call let through while closed completes after the circuit turned
+	// half-open: it must not free a probe slot it never took
+	cb.allow(method)
+	for i := 0; i < 4; i++ {
+		cb.allow(method)
+		cb.record(method, status.Error(codes.Unavailable, "down"))
+	}
+	now = now.Add(10 * time.Second)
+	if got := cb.State(method); got != CircuitHalfOpen {
+		t.Fatalf("expected half-open, got %s", got)
+	}
+	cb.record(method, nil)
+	if c := cb.circuits[method]; c.probes != 0 {
+		t.Fatalf("expected no probes in flight, got %d", c.probes)
+	}
+
+	// One probe slot is left, not three
+	if !cb.allow(method) {
+		t.Fatal("expected a probe to be allowed")
+	}
+	if cb.allow(method) {
+		t.Error("expected the probe limit to hold")
+	}
+}
+
+func TestCircuitBreaker_PerMethodIsolation(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	ctx := context.Background()
+
+	for i := 0; i < 4; i++ {
+		interceptor(ctx, "/test.Service/Broken", nil, nil, nil, invokeWith(status.Error(codes.Unavailable, "down")))
+	}
+
+	if got := cb.State("/test.Service/Broken"); got != CircuitOpen {
+		t.Errorf("expected broken method open, got %s", got)
+	}
+	if err := interceptor(ctx, "/test.Service/Healthy", nil, nil, nil, invokeWith(nil)); err != nil {
+		t.Errorf("expected healthy method to pass, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_IgnoresClientErrors(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	method := "/test.Service/Get"
+
+	for i := 0; i < 10; i++ {
+		interceptor(context.Background(), method, nil, nil, nil, invokeWith(status.Error(codes.InvalidArgument, "bad")))
+	}
+	if got := cb.State(method); got != CircuitClosed {
+		t.Errorf("expected client errors not to open circuit, got %s", got)
+	}
+}
+
+func TestCircuitBreaker_WindowReset(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	method := "/test.Service/Get"
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	for i := 0; i < 3; i++ {
+		interceptor(context.Background(), method, nil, nil, nil, invokeWith(unavailable))
+	}
+	now = now.Add(time.Minute)
+	interceptor(context.Background(), method, nil, nil, nil, invokeWith(unavailable))
+
+	if got := cb.State(method); got != CircuitClosed {
+		t.Errorf("expected counts to reset with the window, got %s", got)
+	}
+}
+
+func TestCircuitBreaker_StreamInterceptor(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.StreamClientInterceptor()
+	method := "/test.Service/Watch"
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return nil, status.Error(codes.Unavailable, "down")
+	}
+
+	for i := 0; i < 4; i++ {
+		interceptor(context.Background(), &grpc.StreamDesc{}, nil, method, streamer)
+	}
+	_, err := interceptor(context.Background(), &grpc.StreamDesc{}, nil, method, streamer)
+	if status.Code(err) != codes.Unavailable || cb.State(method) != CircuitOpen {
+		t.Errorf("expected open circuit to reject stream, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_Collect(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+
+	for i := 0; i < 4; i++ {
+		interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, invokeWith(errors.New("boom")))
+	}
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(cb)
+	if n := testutil.CollectAndCount(cb, "grpckit_circuit_breaker_state"); n != 1 {
+		t.Fatalf("expected 1 series, got %d", n)
+	}
+	if v := testutil.ToFloat64(cb); v != float64(CircuitOpen) {
+		t.Errorf("expected gauge value %d, got %v", CircuitOpen, v)
+	}
+}
+
+func TestRegisterCircuitBreaker_Duplicate(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	reg := prometheus.NewRegistry()
+
+	registerCircuitBreaker(reg, NewCircuitBreaker(CircuitBreakerConfig{Name: "gateway"}), logger)
+	registerCircuitBreaker(reg, NewCircuitBreaker(CircuitBreakerConfig{Name: "gateway"}), logger)
+	if !strings.Contains(buf.String(), "circuit breaker already registered") {
+		t.Errorf("expected a warning for the duplicate breaker, got %q", buf.String())
+	}
+}
+
+func TestWithGatewayCircuitBreaker(t *testing.T) {
+	cfg := newServerConfig()
+	WithGatewayCircuitBreaker(CircuitBreakerConfig{MinRequests: 5})(cfg)
+
+	if cfg.gatewayCircuitBreaker == nil {
+		t.Fatal("expected gateway circuit breaker to be set")
+	}
+	if cfg.gatewayCircuitBreaker.cfg.Name != "gateway" {
+		t.Errorf("expected name gateway, got %q", cfg.gatewayCircuitBreaker.cfg.Name)
+	}
+	if cfg.gatewayCircuitBreaker.cfg.MinRequests != 5 {
+		t.Errorf("expected MinRequests 5, got %d", cfg.gatewayCircuitBreaker.cfg.MinRequests)
+	}
+}
@@ -0,0 +1,115 @@
+// Package client creates outbound gRPC connections that behave consistently
+// with grpckit servers: request-scoped values (request ID, trace context and
+// any configured metadata) are propagated from the inbound request to every
+// outbound call made with the handler's context.
+//
+// Example:
+//
+//	conn, err := client.New("billing:9090",
+//	    client.Insecure(),
+//	    client.WithPropagation(grpckit.PropagateMetadata("x-tenant-id", "accept-language")),
+//	    client.WithTokenPassthrough(),
+//	)
+//	billing := billingpb.NewBillingClient(conn)
+//
+//	// In a handler: ctx carries the inbound request's values
+//	resp, err := billing.Charge(ctx, req)
+package client
+
+import (
+	"errors"
+
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// config holds the connection configuration.
+type config struct {
+	dialOpts         []grpc.DialOption
+	propagation      []grpckit.PropagationOption
+	tokenOpts        []grpckit.TokenOption
+	tokenPassthrough bool
+	token            string
+	insecure         bool
+}
+
+// Option configures a client connection.
+type Option func(*config)
+
+// Insecure disables transport security, e.g. for in-cluster plaintext traffic.
+func Insecure() Option {
+	return func(c *config) {
+		c.dialOpts = append(c.dialOpts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		c.insecure = true
+	}
+}
+
+// WithDialOptions adds gRPC dial options (credentials, interceptors, ...).
+// Interceptors added here run after the grpckit ones.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(c *config) {
+		c.dialOpts = append(c.dialOpts, opts...)
+	}
+}
+
+// WithPropagation configures which request-scoped values are propagated in
+// addition to the request ID and trace context.
+func WithPropagation(opts ...grpckit.PropagationOption) Option {
+	return func(c *config) {
+		c.propagation = append(c.propagation, opts...)
+	}
+}
+
+// WithTokenPassthrough forwards the inbound bearer token on outbound calls
+// (see grpckit.TokenPassthroughUnaryClientInterceptor).
+func WithTokenPassthrough(opts ...grpckit.TokenOption) Option {
+	return func(c *config) {
+		c.tokenPassthrough = true
+		c.tokenOpts = append(c.tokenOpts, opts...)
+	}
+}
+
+// WithToken sends token as a bearer token on every call, e.g. a service
+// account token (see grpckit.TokenCredentials). The token is only sent over
+// plaintext connections with Insecure. It cannot be combined with
+// WithTokenPassthrough.
+func WithToken(token string) Option {
+	return func(c *config) {
+		c.token = token
+	}
+}
+
+// New creates a client connection to target with request-scoped value
+// propagation installed. Transport credentials must be provided, either with
+// Insecure or through WithDialOptions.
+func New(target string, opts ...Option) (*grpc.ClientConn, error) {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.token != "" && cfg.tokenPassthrough {
+		return nil, errors.New("client: WithToken and WithTokenPassthrough are mutually exclusive")
+	}
+
+	unary := []grpc.UnaryClientInterceptor{grpckit.PropagationUnaryClientInterceptor(cfg.propagation...)}
+	stream := []grpc.StreamClientInterceptor{grpckit.PropagationStreamClientInterceptor(cfg.propagation...)}
+	if cfg.tokenPassthrough {
+		unary = append(unary, grpckit.TokenPassthroughUnaryClientInterceptor(cfg.tokenOpts...))
+		stream = append(stream, grpckit.TokenPassthroughStreamClientInterceptor(cfg.tokenOpts...))
+	}
+
+	dialOpts := append([]grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(unary...),
+		grpc.WithChainStreamInterceptor(stream...),
+	}, cfg.dialOpts...)
+	if cfg.token != "" {
+		creds := grpckit.TokenCredentials(cfg.token)
+		if cfg.insecure {
+			creds = grpckit.InsecureTokenCredentials(cfg.token)
+		}
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(creds))
+	}
+
+	return grpc.NewClient(target, dialOpts...)
+}
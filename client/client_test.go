@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startServer starts an in-memory gRPC server recording incoming metadata.
+func startServer(t *testing.T, got *metadata.MD) grpc.DialOption {
+	t.Helper()
+	lis := bufconn.Listen(1024 * 1024)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		*got, _ = metadata.FromIncomingContext(ctx)
+		return handler(ctx, req)
+	}))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return lis.DialContext(ctx)
+	})
+}
+
+func TestNew_Propagates(t *testing.T) {
+	var got metadata.MD
+	dialer := startServer(t, &got)
+
+	conn, err := New("passthrough:///bufnet",
+		Insecure(),
+		WithDialOptions(dialer),
+		WithPropagation(),
+		WithTokenPassthrough(),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer conn.Close()
+
+	// Simulate a handler context of an inbound gRPC request
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"x-request-id", "req-1",
+		"traceparent", "00-abc-def-01",
+		"authorization", "Bearer user-token",
+	))
+
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	for key, want := range map[string]string{
+		"x-request-id":  "req-1",
+		"traceparent":   "00-abc-def-01",
+		"authorization": "Bearer user-token",
+	} {
+		if v := got.Get(key); len(v) != 1 || v[0] != want {
+			t.Errorf("expected %s=%q downstream, got %v", key, want, v)
+		}
+	}
+}
+
+func TestNew_NoTokenPassthroughByDefault(t *testing.T) {
+	var got metadata.MD
+	dialer := startServer(t, &got)
+
+	conn, err := New("passthrough:///bufnet", Insecure(), WithDialOptions(dialer))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer conn.Close()
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer user-token"))
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	if v := got.Get("authorization"); len(v) != 0 {
+		t.Errorf("expected token not to be forwarded without WithTokenPassthrough, got %v", v)
+	}
+}
+
+func TestNew_RequiresCredentials(t *testing.T) {
+	if _, err := New("localhost:9090"); err == nil {
+		t.Error("expected error without transport credentials")
+	}
+}
+
+func TestNew_WithToken(t *testing.T) {
+	var got metadata.MD
+	dialer := startServer(t, &got)
+
+	conn, err := New("passthrough:///bufnet", Insecure(), WithDialOptions(dialer), WithToken("service-token"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+	if v := got.Get("authorization"); len(v) != 1 || v[0] != "Bearer service-token" {
+		t.Errorf("expected bearer token downstream, got %v", v)
+	}
+}
+
+func TestNew_WithTokenAndPassthrough(t *testing.T) {
+	if _, err := New("localhost:9090", Insecure(), WithToken("t"), WithTokenPassthrough()); err == nil {
+		t.Error("expected error combining WithToken and WithTokenPassthrough")
+	}
+}
+
+func TestNew_WithToken_RequiresTransportSecurity(t *testing.T) {
+	// Plaintext without Insecure must not send the token
+	_, err := New("localhost:9090",
+		WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials())),
+		WithToken("service-token"),
+	)
+	if err == nil {
+		t.Error("expected error for a token over plaintext without Insecure")
+	}
+}
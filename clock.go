@@ -0,0 +1,82 @@
+package grpckit
+
+import "time"
+
+// Clock tells the current time. Servers use the system clock unless
+// WithClock sets another one, such as a FakeClock in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock backed by time.Now.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// WithClock sets the clock of time-dependent features, so tests can advance
+// time deterministically instead of sleeping:
+//   - quota windows (WithPrincipalQuota)
+//   - lockout windows and durations (WithLockout), through its store
+//   - the gateway circuit breaker's window and open timeout
+//     (WithGatewayCircuitBreaker)
+//   - failed authentication windows (WithAuthFailureTracking)
+//   - rate limit token buckets (WithRateLimit)
+//   - timed maintenance (SetMaintenanceFor)
+//
+// Stores passed to WithPrincipalQuota and WithLockout follow the clock too
+// when they have a SetClock(func() time.Time) method, like
+// quota.MemoryStore. Call SetClock yourself for stores used elsewhere, such
+// as a dedup.MemoryStore. Latencies (logs, metrics and timeouts) are always
+// measured with the system clock.
+//
+// Example:
+//
+//	clock := grpckit.NewFakeClock(time.Now())
+//	ts, _ := grpckit.NewTestServer(
+//	    grpckit.WithClock(clock),
+//	    grpckit.WithPrincipalQuota(quota.NewMemoryStore(), grpckit.QuotaLimit(10, time.Minute)),
+//	    ...
+//	)
+//	// exhaust the quota, then
+//	clock.Advance(time.Minute)
+func WithClock(clock Clock) Option {
+	return func(c *serverConfig) {
+		if clock == nil {
+			c.invalid("WithClock: nil clock")
+			return
+		}
+		c.clock = clock
+	}
+}
+
+// applyClock switches the time-dependent features to the configured clock.
+// It runs once all options are applied, as WithClock may come before the
+// options it affects.
+func applyClock(c *serverConfig) {
+	if _, ok := c.clock.(systemClock); ok {
+		return
+	}
+	now := c.clock.Now
+	if c.quota != nil {
+		c.quota.now = now
+		setStoreClock(c.quota.store, now)
+	}
+	if c.lockout != nil {
+		setStoreClock(c.lockout.store, now)
+	}
+	if c.gatewayCircuitBreaker != nil {
+		c.gatewayCircuitBreaker.now = now
+	}
+}
+
+// setStoreClock sets the clock of a store supporting it.
+func setStoreClock(store any, now func() time.Time) {
+	if s, ok := store.(interface{ SetClock(func() time.Time) }); ok {
+		s.SetClock(now)
+	}
+}
+
+// now returns the current time of the server's clock.
+func (s *Server) now() time.Time {
+	return s.cfg.clock.Now()
+}
@@ -0,0 +1,64 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gyozatech/grpckit/quota"
+	"google.golang.org/grpc"
+)
+
+func TestWithClock(t *testing.T) {
+	clock := NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	s := newSlowTestServer(t,
+		WithClock(clock),
+		WithPrincipalQuota(quota.NewMemoryStore(), QuotaLimit(2, time.Minute)),
+		WithGatewayCircuitBreaker(CircuitBreakerConfig{}),
+	)
+
+	// Quota windows and the in-memory store follow the clock
+	ctx := context.Background()
+	for i, exceeded := range []bool{false, false, true} {
+		result, err := s.cfg.quota.take(ctx, "alice")
+		if err != nil || (result.exceeded != nil) != exceeded {
+			t.Fatalf("call %d: expected exceeded=%v, got %+v, %v", i, exceeded, result, err)
+		}
+	}
+	clock.Advance(time.Minute)
+	if result, _ := s.cfg.quota.take(ctx, "alice"); result.exceeded != nil || result.remaining != 1 {
+		t.Errorf("expected a new window after advancing the clock, got %+v", result)
+	}
+
+	if got := s.cfg.gatewayCircuitBreaker.now(); !got.Equal(clock.Now()) {
+		t.Errorf("expected circuit breaker to use the clock, got %v", got)
+	}
+
+	// Timed maintenance counts down with the clock
+	s.SetMaintenanceFor(time.Minute)
+	clock.Advance(45 * time.Second)
+	if got := s.maintenanceRetryAfter(); got != "15" {
+		t.Errorf("expected Retry-After 15, got %s", got)
+	}
+}
+
+func TestWithClock_Nil(t *testing.T) {
+	_, err := New(WithGRPCService(func(grpc.ServiceRegistrar) {}), WithClock(nil))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	clock.Advance(time.Hour)
+	if got := clock.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("expected %v, got %v", start.Add(time.Hour), got)
+	}
+	clock.Set(start)
+	if got := clock.Now(); !got.Equal(start) {
+		t.Errorf("expected %v, got %v", start, got)
+	}
+}
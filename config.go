@@ -1,6 +1,8 @@
 package grpckit
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"strconv"
 	"strings"
@@ -11,13 +13,18 @@ import (
 
 // Config represents the configuration file structure.
 type Config struct {
-	GRPC    GRPCConfig    `yaml:"grpc"`
-	HTTP    HTTPConfig    `yaml:"http"`
-	Health  FeatureConfig `yaml:"health"`
-	Metrics FeatureConfig `yaml:"metrics"`
-	Swagger SwaggerConfig `yaml:"swagger"`
-	Auth    AuthConfig    `yaml:"auth"`
-	Log     LogConfig     `yaml:"log"`
+	GRPC     GRPCConfig     `yaml:"grpc"`
+	HTTP     HTTPConfig     `yaml:"http"`
+	Health   FeatureConfig  `yaml:"health"`
+	Metrics  FeatureConfig  `yaml:"metrics"`
+	Swagger  SwaggerConfig  `yaml:"swagger"`
+	Auth     AuthConfig     `yaml:"auth"`
+	Log      LogConfig      `yaml:"log"`
+	TLS      TLSConfig      `yaml:"tls"`
+	Shutdown ShutdownConfig `yaml:"shutdown"`
+	Admin    AdminConfig    `yaml:"admin"`
+
+	MiddlewareTrace FeatureConfig `yaml:"middleware_trace"`
 }
 
 // GRPCConfig holds gRPC server configuration.
@@ -27,7 +34,8 @@ type GRPCConfig struct {
 
 // HTTPConfig holds HTTP server configuration.
 type HTTPConfig struct {
-	Port int `yaml:"port"`
+	Port    int           `yaml:"port"`
+	Timeout time.Duration `yaml:"timeout"`
 }
 
 // FeatureConfig holds feature toggle configuration.
@@ -52,15 +60,47 @@ type LogConfig struct {
 	Level string `yaml:"level"`
 }
 
-// LoadConfigFile loads configuration from a YAML file.
+// TLSConfig holds TLS certificate configuration.
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+}
+
+// ShutdownConfig holds graceful shutdown configuration.
+type ShutdownConfig struct {
+	GracefulTimeout time.Duration `yaml:"graceful_timeout"`
+	Delay           time.Duration `yaml:"delay"`
+}
+
+// AdminConfig holds admin API configuration. The token is read from a
+// file or the secrets provider (see WithSecrets), never from the config
+// file itself.
+type AdminConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	TokenFile   string `yaml:"token_file"`
+	TokenSecret string `yaml:"token_secret"`
+}
+
+// LoadConfigFile loads configuration from a YAML file. The file is checked
+// against the fields of Config first: unknown keys (e.g. a misspelled
+// "heath:"), values of the wrong type and invalid durations are returned
+// together as *ConfigError values with their line numbers.
 func LoadConfigFile(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := checkConfig(&doc); err != nil {
+		return nil, err
+	}
+
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := doc.Decode(&cfg); err != nil {
 		return nil, err
 	}
 
@@ -81,6 +121,9 @@ func applyConfigFile(cfg *serverConfig, fileCfg *Config) {
 	if fileCfg.Metrics.Enabled {
 		cfg.metricsEnabled = true
 	}
+	if fileCfg.MiddlewareTrace.Enabled {
+		cfg.middlewareTrace = true
+	}
 	if fileCfg.Swagger.Enabled {
 		cfg.swaggerEnabled = true
 		cfg.swaggerPath = fileCfg.Swagger.Path
@@ -94,6 +137,22 @@ func applyConfigFile(cfg *serverConfig, fileCfg *Config) {
 	if fileCfg.Log.Level != "" {
 		cfg.logLevel = fileCfg.Log.Level
 	}
+	if fileCfg.TLS.CertFile != "" {
+		cfg.tlsCertFile = fileCfg.TLS.CertFile
+		cfg.tlsKeyFile = fileCfg.TLS.KeyFile
+	}
+	if fileCfg.HTTP.Timeout > 0 {
+		cfg.httpTimeout = fileCfg.HTTP.Timeout
+	}
+	if fileCfg.Shutdown.GracefulTimeout > 0 {
+		cfg.gracefulTimeout = fileCfg.Shutdown.GracefulTimeout
+	}
+	if fileCfg.Shutdown.Delay > 0 {
+		cfg.shutdownDelay = fileCfg.Shutdown.Delay
+	}
+	if fileCfg.Admin.Enabled {
+		WithAdminAPI(AdminTokenFile(fileCfg.Admin.TokenFile), AdminTokenSecret(fileCfg.Admin.TokenSecret))(cfg)
+	}
 }
 
 // applyEnvVars applies configuration from environment variables.
@@ -101,12 +160,16 @@ func applyEnvVars(cfg *serverConfig) {
 	if v := os.Getenv("GRPCKIT_GRPC_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
 			cfg.grpcPort = port
+		} else {
+			cfg.invalid("GRPCKIT_GRPC_PORT=%q is not a port", v)
 		}
 	}
 
 	if v := os.Getenv("GRPCKIT_HTTP_PORT"); v != "" {
 		if port, err := strconv.Atoi(v); err == nil {
 			cfg.httpPort = port
+		} else {
+			cfg.invalid("GRPCKIT_HTTP_PORT=%q is not a port", v)
 		}
 	}
 
@@ -118,6 +181,10 @@ func applyEnvVars(cfg *serverConfig) {
 		cfg.metricsEnabled = parseBool(v)
 	}
 
+	if v := os.Getenv("GRPCKIT_MIDDLEWARE_TRACE"); v != "" {
+		cfg.middlewareTrace = parseBool(v)
+	}
+
 	if v := os.Getenv("GRPCKIT_SWAGGER_ENABLED"); v != "" {
 		cfg.swaggerEnabled = parseBool(v)
 	}
@@ -133,6 +200,24 @@ func applyEnvVars(cfg *serverConfig) {
 	if v := os.Getenv("GRPCKIT_GRACEFUL_TIMEOUT"); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
 			cfg.gracefulTimeout = d
+		} else {
+			cfg.invalid("GRPCKIT_GRACEFUL_TIMEOUT=%q is not a duration", v)
+		}
+	}
+
+	if v := os.Getenv("GRPCKIT_HTTP_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.httpTimeout = d
+		} else {
+			cfg.invalid("GRPCKIT_HTTP_TIMEOUT=%q is not a duration", v)
+		}
+	}
+
+	if v := os.Getenv("GRPCKIT_SHUTDOWN_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			cfg.shutdownDelay = d
+		} else {
+			cfg.invalid("GRPCKIT_SHUTDOWN_DELAY=%q is not a duration", v)
 		}
 	}
 
@@ -143,6 +228,14 @@ func applyEnvVars(cfg *serverConfig) {
 	if v := os.Getenv("GRPCKIT_PUBLIC_ENDPOINTS"); v != "" {
 		cfg.publicEndpoints = strings.Split(v, ",")
 	}
+
+	if v := os.Getenv("GRPCKIT_TLS_CERT_FILE"); v != "" {
+		cfg.tlsCertFile = v
+	}
+
+	if v := os.Getenv("GRPCKIT_TLS_KEY_FILE"); v != "" {
+		cfg.tlsKeyFile = v
+	}
 }
 
 // parseBool parses a boolean from common string representations.
@@ -153,11 +246,24 @@ func parseBool(s string) bool {
 
 // WithConfigFile loads configuration from a YAML file.
 // File configuration is applied first, then overridden by code options.
+// A missing file is ignored, as file configuration is optional; a file that
+// cannot be read or parsed, or that doesn't match Config (see
+// LoadConfigFile), makes New return ErrInvalidConfig with every problem
+// found.
 func WithConfigFile(path string) Option {
 	return func(c *serverConfig) {
 		fileCfg, err := LoadConfigFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, err := range joined.Unwrap() {
+				c.invalid("config file %s: %v", path, err)
+			}
+			return
+		}
 		if err != nil {
-			// Log warning but don't fail - file config is optional
+			c.invalid("config file %s: %v", path, err)
 			return
 		}
 		applyConfigFile(c, fileCfg)
@@ -1,8 +1,10 @@
 package grpckit
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -105,6 +107,7 @@ func TestApplyConfigFile(t *testing.T) {
 			PublicEndpoints:    []string{"/public/*"},
 		},
 		Log: LogConfig{Level: "debug"},
+		TLS: TLSConfig{CertFile: "/etc/tls/tls.crt", KeyFile: "/etc/tls/tls.key"},
 	}
 
 	applyConfigFile(cfg, fileCfg)
@@ -136,6 +139,9 @@ func TestApplyConfigFile(t *testing.T) {
 	if cfg.logLevel != "debug" {
 		t.Errorf("expected log level debug, got %s", cfg.logLevel)
 	}
+	if cfg.tlsCertFile != "/etc/tls/tls.crt" || cfg.tlsKeyFile != "/etc/tls/tls.key" {
+		t.Errorf("expected TLS files to be applied, got %q, %q", cfg.tlsCertFile, cfg.tlsKeyFile)
+	}
 }
 
 func TestApplyConfigFile_ZeroValues(t *testing.T) {
@@ -166,8 +172,12 @@ func TestApplyEnvVars(t *testing.T) {
 		"GRPCKIT_SWAGGER_PATH",
 		"GRPCKIT_LOG_LEVEL",
 		"GRPCKIT_GRACEFUL_TIMEOUT",
+		"GRPCKIT_SHUTDOWN_DELAY",
+		"GRPCKIT_HTTP_TIMEOUT",
 		"GRPCKIT_PROTECTED_ENDPOINTS",
 		"GRPCKIT_PUBLIC_ENDPOINTS",
+		"GRPCKIT_TLS_CERT_FILE",
+		"GRPCKIT_TLS_KEY_FILE",
 	}
 	originalValues := make(map[string]string)
 	for _, key := range envVars {
@@ -192,8 +202,12 @@ func TestApplyEnvVars(t *testing.T) {
 	os.Setenv("GRPCKIT_SWAGGER_PATH", "/api/swagger.json")
 	os.Setenv("GRPCKIT_LOG_LEVEL", "warn")
 	os.Setenv("GRPCKIT_GRACEFUL_TIMEOUT", "60s")
+	os.Setenv("GRPCKIT_SHUTDOWN_DELAY", "5s")
+	os.Setenv("GRPCKIT_HTTP_TIMEOUT", "15s")
 	os.Setenv("GRPCKIT_PROTECTED_ENDPOINTS", "/api/v1/*,/admin/*")
 	os.Setenv("GRPCKIT_PUBLIC_ENDPOINTS", "/healthz,/readyz")
+	os.Setenv("GRPCKIT_TLS_CERT_FILE", "/etc/tls/tls.crt")
+	os.Setenv("GRPCKIT_TLS_KEY_FILE", "/etc/tls/tls.key")
 
 	cfg := newServerConfig()
 	applyEnvVars(cfg)
@@ -222,12 +236,21 @@ func TestApplyEnvVars(t *testing.T) {
 	if cfg.gracefulTimeout != 60*time.Second {
 		t.Errorf("expected graceful timeout 60s, got %v", cfg.gracefulTimeout)
 	}
+	if cfg.shutdownDelay != 5*time.Second {
+		t.Errorf("expected shutdown delay 5s, got %v", cfg.shutdownDelay)
+	}
+	if cfg.httpTimeout != 15*time.Second {
+		t.Errorf("expected HTTP timeout 15s, got %v", cfg.httpTimeout)
+	}
 	if len(cfg.protectedEndpoints) != 2 {
 		t.Errorf("expected 2 protected endpoints, got %d", len(cfg.protectedEndpoints))
 	}
 	if len(cfg.publicEndpoints) != 2 {
 		t.Errorf("expected 2 public endpoints, got %d", len(cfg.publicEndpoints))
 	}
+	if cfg.tlsCertFile != "/etc/tls/tls.crt" || cfg.tlsKeyFile != "/etc/tls/tls.key" {
+		t.Errorf("expected TLS files from env, got %q, %q", cfg.tlsCertFile, cfg.tlsKeyFile)
+	}
 }
 
 func TestApplyEnvVars_InvalidValues(t *testing.T) {
@@ -329,3 +352,153 @@ func TestWithConfigFile_NonExistent(t *testing.T) {
 		t.Errorf("non-existent config should not modify config, got gRPC port %d", cfg.grpcPort)
 	}
 }
+
+func TestWithConfigFile_Invalid(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("grpc: [not a map"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg := newServerConfig()
+	WithConfigFile(configPath)(cfg)
+	if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected a config error, got %v", cfg.errs)
+	}
+
+	// A missing file is not an error
+	cfg = newServerConfig()
+	WithConfigFile("/nonexistent/config.yaml")(cfg)
+	if len(cfg.errs) != 0 {
+		t.Errorf("expected missing file to be ignored, got %v", cfg.errs)
+	}
+}
+
+func TestApplyEnvVars_InvalidValuesReported(t *testing.T) {
+	t.Setenv("GRPCKIT_HTTP_PORT", "http")
+	t.Setenv("GRPCKIT_SHUTDOWN_DELAY", "5")
+
+	cfg := newServerConfig()
+	applyEnvVars(cfg)
+	if len(cfg.errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", cfg.errs)
+	}
+	if !strings.Contains(cfg.errs[0].Error(), `GRPCKIT_HTTP_PORT="http"`) {
+		t.Errorf("expected error to name the variable, got %v", cfg.errs[0])
+	}
+}
+
+func TestLoadConfigFile_Schema(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `grpc:
+  port: "ninety"
+heath:
+  enabled: true
+http:
+  timeout: 30
+metrics:
+  enabled: maybe
+shutdown:
+  delay: 5x
+auth:
+  public_endpoints: /healthz
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	_, err := LoadConfigFile(configPath)
+	if err == nil {
+		t.Fatal("expected schema errors")
+	}
+	expected := []string{
+		`line 2: grpc.port: expected an integer, got "ninety"`,
+		`line 3: heath: unknown key (did you mean "health"?)`,
+		`line 6: http.timeout: invalid duration "30" (add a unit, e.g. "30s")`,
+		`line 8: metrics.enabled: expected true or false, got "maybe"`,
+		`line 10: shutdown.delay: invalid duration "5x" (e.g. "30s", "1m30s")`,
+		`line 12: auth.public_endpoints: expected a list, got "/healthz"`,
+	}
+	if got := err.Error(); got != strings.Join(expected, "\n") {
+		t.Errorf("unexpected errors:\n%s", got)
+	}
+	var cerr *ConfigError
+	if !errors.As(err, &cerr) || cerr.Line != 2 || cerr.Column != 9 || cerr.Key != "grpc.port" {
+		t.Errorf("expected a ConfigError at line 2, column 9, got %+v", cerr)
+	}
+}
+
+func TestLoadConfigFile_Durations(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `http:
+  timeout: 10s
+shutdown:
+  graceful_timeout: 1m
+  delay: 5s
+health:
+  enabled: yes
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	fileCfg, err := LoadConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	cfg := newServerConfig()
+	applyConfigFile(cfg, fileCfg)
+	if cfg.httpTimeout != 10*time.Second || cfg.gracefulTimeout != time.Minute || cfg.shutdownDelay != 5*time.Second {
+		t.Errorf("unexpected durations: timeout %v, graceful %v, delay %v", cfg.httpTimeout, cfg.gracefulTimeout, cfg.shutdownDelay)
+	}
+	if !cfg.healthEnabled {
+		t.Error("expected health enabled")
+	}
+}
+
+func TestLoadConfigFile_MergeKeys(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	configContent := `health: &enabled
+  enabled: true
+metrics:
+  <<: *enabled
+swagger:
+  <<: [*enabled]
+  path: ./api/swagger.json
+`
+	if err := os.WriteFile(configPath, []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	fileCfg, err := LoadConfigFile(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfigFile failed: %v", err)
+	}
+	if !fileCfg.Metrics.Enabled || !fileCfg.Swagger.Enabled || fileCfg.Swagger.Path != "./api/swagger.json" {
+		t.Errorf("expected merged keys to be applied, got %+v", fileCfg)
+	}
+
+	// Merged keys are checked against the mapping they are merged into
+	if err := os.WriteFile(configPath, []byte(configContent+"grpc:\n  <<: *enabled\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	_, err = LoadConfigFile(configPath)
+	if err == nil || err.Error() != "line 2: grpc.enabled: unknown key" {
+		t.Errorf("expected an unknown merged key, got %v", err)
+	}
+}
+
+func TestWithConfigFile_SchemaErrors(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("heath:\n  enabled: true\nmetric:\n  enabled: true\n"), 0644); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+
+	cfg := newServerConfig()
+	WithConfigFile(configPath)(cfg)
+	if len(cfg.errs) != 2 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Fatalf("expected one config error per problem, got %v", cfg.errs)
+	}
+	if !strings.Contains(cfg.errs[1].Error(), `line 3: metric: unknown key (did you mean "metrics"?)`) {
+		t.Errorf("unexpected error %v", cfg.errs[1])
+	}
+}
@@ -0,0 +1,194 @@
+package grpckit
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigError is a problem found in a config file, at a line and column of
+// the file.
+type ConfigError struct {
+	Line    int
+	Column  int
+	Key     string // dotted path of the key, e.g. "http.timeout"
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: %s: %s", e.Line, e.Key, e.Message)
+}
+
+// durationType is the type of duration fields.
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// checkConfig checks a parsed config file against the fields of Config,
+// reporting unknown keys (with the closest known key, for misspellings),
+// values of the wrong type and invalid durations. yaml.Unmarshal would
+// ignore unknown keys and stop at the first wrong value.
+func checkConfig(doc *yaml.Node) error {
+	var errs []error
+	if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 {
+		checkConfigNode(doc.Content[0], reflect.TypeOf(Config{}), "", &errs)
+	}
+	return errors.Join(errs...)
+}
+
+// checkConfigNode checks node against the type t of the field at path.
+func checkConfigNode(node *yaml.Node, t reflect.Type, path string, errs *[]error) {
+	fail := func(format string, args ...any) {
+		*errs = append(*errs, &ConfigError{Line: node.Line, Column: node.Column, Key: path, Message: fmt.Sprintf(format, args...)})
+	}
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	if node.Tag == "!!null" {
+		return
+	}
+
+	switch {
+	case t.Kind() == reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			fail("expected a mapping of keys, got %s", describeNode(node))
+			return
+		}
+		fields := configFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Tag == "!!merge" {
+				checkMergeNode(value, t, path, errs)
+				continue
+			}
+			keyPath := key.Value
+			if path != "" {
+				keyPath = path + "." + key.Value
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				msg := "unknown key"
+				if suggestion := closestKey(key.Value, fields); suggestion != "" {
+					msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+				}
+				*errs = append(*errs, &ConfigError{Line: key.Line, Column: key.Column, Key: keyPath, Message: msg})
+				continue
+			}
+			checkConfigNode(value, field.Type, keyPath, errs)
+		}
+	case t.Kind() == reflect.Slice:
+		if node.Kind != yaml.SequenceNode {
+			fail("expected a list, got %s", describeNode(node))
+			return
+		}
+		for i, item := range node.Content {
+			checkConfigNode(item, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case node.Kind != yaml.ScalarNode:
+		fail("expected a single value, got %s", describeNode(node))
+	case t == durationType:
+		if _, err := time.ParseDuration(node.Value); err != nil {
+			if _, numErr := strconv.Atoi(node.Value); numErr == nil {
+				fail("invalid duration %q (add a unit, e.g. \"%ss\")", node.Value, node.Value)
+			} else {
+				fail("invalid duration %q (e.g. \"30s\", \"1m30s\")", node.Value)
+			}
+		}
+	case t.Kind() == reflect.Bool:
+		if node.Tag != "!!bool" && !yaml11Bool(node) {
+			fail("expected true or false, got %q", node.Value)
+		}
+	case t.Kind() == reflect.Int:
+		if node.Tag != "!!int" {
+			fail("expected an integer, got %q", node.Value)
+		}
+	}
+}
+
+// checkMergeNode checks the value of a merge key ("<<: *defaults"), a
+// mapping or a list of mappings whose keys are merged into the mapping of
+// type t at path.
+func checkMergeNode(node *yaml.Node, t reflect.Type, path string, errs *[]error) {
+	if node.Kind == yaml.SequenceNode {
+		for _, item := range node.Content {
+			checkConfigNode(item, t, path, errs)
+		}
+		return
+	}
+	checkConfigNode(node, t, path, errs)
+}
+
+// yaml11Bool reports whether node is a YAML 1.1 boolean such as yes or off,
+// which yaml.Unmarshal still accepts for bool fields.
+func yaml11Bool(node *yaml.Node) bool {
+	if node.Style != 0 {
+		return false
+	}
+	switch strings.ToLower(node.Value) {
+	case "y", "yes", "n", "no", "on", "off":
+		return true
+	}
+	return false
+}
+
+// configFields returns the fields of a config struct by YAML key.
+func configFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get("yaml"), ","); name != "" && name != "-" {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// describeNode names the kind of a YAML node for error messages.
+func describeNode(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "a mapping"
+	case yaml.SequenceNode:
+		return "a list"
+	}
+	return strconv.Quote(node.Value)
+}
+
+// closestKey returns the known key closest to a misspelled one, or "" if
+// none is within two edits.
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	best, bestDistance := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDistance || (d == bestDistance && name < best) {
+			best, bestDistance = name, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
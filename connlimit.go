@@ -0,0 +1,99 @@
+package grpckit
+
+import (
+	"net"
+	"sync"
+)
+
+// WithMaxConnections caps the number of open connections per listener (the
+// gRPC and HTTP ports each get n; in single port mode the shared port gets
+// n). Connections beyond the limit are accepted and closed immediately, so
+// a connection flood cannot exhaust file descriptors while the kernel
+// backlog fills up. With WithMetrics, rejected connections are counted in
+// grpckit_connections_rejected_total{listener}.
+//
+// HTTP/2 and gRPC clients multiplex requests over few connections, so this
+// limit is about sockets, not requests; see WithConcurrencyLimit for the
+// latter.
+//
+// The REST gateway calls the gRPC server over a loopback connection to the
+// gRPC port (the shared port in single port mode), which counts toward the
+// limit like any other: while the limit is reached, REST calls fail with
+// 503 until a slot frees up. Leave headroom above the expected client
+// connections. Handler and NewTestServer connect the gateway in memory, so
+// they are not affected. Zero disables the limit.
+//
+// Example:
+//
+//	grpckit.WithMaxConnections(10000)
+func WithMaxConnections(n int) Option {
+	return func(c *serverConfig) {
+		if n < 0 {
+			c.invalid("WithMaxConnections: negative limit %d", n)
+			return
+		}
+		c.maxConnections = n
+	}
+}
+
+// limitListener is a net.Listener that closes connections accepted while
+// the limit is reached.
+type limitListener struct {
+	net.Listener
+	sem      chan struct{}
+	onReject func()
+}
+
+// newLimitListener wraps lis to allow at most n open connections.
+func newLimitListener(lis net.Listener, n int, onReject func()) *limitListener {
+	return &limitListener{
+		Listener: lis,
+		sem:      make(chan struct{}, n),
+		onReject: onReject,
+	}
+}
+
+// Accept waits for a connection within the limit.
+func (l *limitListener) Accept() (net.Conn, error) {
+	for {
+		c, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		select {
+		case l.sem <- struct{}{}:
+			return &limitConn{Conn: c, release: func() { <-l.sem }}, nil
+		default:
+			c.Close()
+			if l.onReject != nil {
+				l.onReject()
+			}
+		}
+	}
+}
+
+// limitConn releases its slot in the limit when closed.
+type limitConn struct {
+	net.Conn
+	release   func()
+	closeOnce sync.Once
+}
+
+// Close closes the connection and releases its slot.
+func (c *limitConn) Close() error {
+	err := c.Conn.Close()
+	c.closeOnce.Do(c.release)
+	return err
+}
+
+// limitListener applies WithMaxConnections to a listener, if configured.
+func (s *Server) limitListener(name string, lis net.Listener) net.Listener {
+	if s.cfg.maxConnections <= 0 {
+		return lis
+	}
+	return newLimitListener(lis, s.cfg.maxConnections, func() {
+		if s.metrics != nil {
+			s.metrics.connectionsRejected.WithLabelValues(name).Inc()
+		}
+	})
+}
@@ -0,0 +1,100 @@
+package grpckit
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+// acceptOne accepts a connection in the background.
+func acceptOne(lis net.Listener) <-chan net.Conn {
+	ch := make(chan net.Conn, 1)
+	go func() {
+		c, err := lis.Accept()
+		if err == nil {
+			ch <- c
+		}
+	}()
+	return ch
+}
+
+// expectClosed checks that the server closed the client connection.
+func expectClosed(t *testing.T, c net.Conn) {
+	t.Helper()
+	_ = c.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := c.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("expected connection to be closed, got %v", err)
+	}
+}
+
+func TestLimitListener(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics(), WithMaxConnections(1))
+
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	lis := s.limitListener("http", raw)
+	defer lis.Close()
+
+	accepted := acceptOne(lis)
+	first, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer first.Close()
+	serverConn := <-accepted
+
+	// Over the limit: accepted and closed, while Accept keeps waiting
+	accepted = acceptOne(lis)
+	second, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer second.Close()
+	expectClosed(t, second)
+	if v := testutil.ToFloat64(s.metrics.connectionsRejected.WithLabelValues("http")); v != 1 {
+		t.Errorf("expected 1 rejected connection, got %v", v)
+	}
+
+	// Closing a connection frees its slot, once
+	serverConn.Close()
+	serverConn.Close()
+	third, err := net.Dial("tcp", lis.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer third.Close()
+	select {
+	case c := <-accepted:
+		c.Close()
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected connection to be accepted after a slot was freed")
+	}
+}
+
+func TestLimitListener_Disabled(t *testing.T) {
+	s := newSlowTestServer(t)
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer raw.Close()
+	if lis := s.limitListener("http", raw); lis != raw {
+		t.Errorf("expected listener to be unwrapped without a limit")
+	}
+}
+
+func TestWithMaxConnections_Negative(t *testing.T) {
+	_, err := New(WithMaxConnections(-1), WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
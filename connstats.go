@@ -0,0 +1,189 @@
+package grpckit
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// ConnectionStats is a snapshot of the server's open connections and active
+// calls, to spot saturation before limits (WithMaxConnections,
+// WithConcurrencyLimit, WithStreamingLimits) are hit.
+type ConnectionStats struct {
+	// Connections is the number of open connections per listener: "grpc",
+	// "http", or "grpc+http" in single port mode.
+	Connections map[string]int64 `json:"connections"`
+	// ActiveStreams is the number of active gRPC calls per method (unary
+	// calls included), including those made by the gateway for REST
+	// requests.
+	ActiveStreams map[string]int64 `json:"active_streams"`
+	// Gateway is the state of the gateway's connections to the local gRPC
+	// server, once they served a request.
+	Gateway []GatewayConnectionState `json:"gateway"`
+}
+
+// GatewayConnectionState is the state of a gateway connection to the local
+// gRPC server.
+type GatewayConnectionState struct {
+	Target string `json:"target"`
+	// State is IDLE, CONNECTING, READY, TRANSIENT_FAILURE or SHUTDOWN.
+	State string `json:"state"`
+}
+
+// connStats tracks the counts reported by Server.Stats.
+type connStats struct {
+	// Open connections per listener name
+	conns sync.Map // string -> *atomic.Int64
+
+	// Active calls per gRPC method
+	streams sync.Map // string -> *atomic.Int64
+
+	// Gateway client connections, seen by their first call
+	gateway sync.Map // *grpc.ClientConn -> struct{}
+}
+
+// counter returns the counter for key in m, creating it if needed.
+func counter(m *sync.Map, key string) *atomic.Int64 {
+	if c, ok := m.Load(key); ok {
+		return c.(*atomic.Int64)
+	}
+	c, _ := m.LoadOrStore(key, new(atomic.Int64))
+	return c.(*atomic.Int64)
+}
+
+// snapshot returns the counts of m.
+func snapshot(m *sync.Map) map[string]int64 {
+	counts := map[string]int64{}
+	m.Range(func(key, value any) bool {
+		counts[key.(string)] = value.(*atomic.Int64).Load()
+		return true
+	})
+	return counts
+}
+
+// Stats returns the current connection and stream statistics. They are also
+// exposed by the admin API (GET /admin/stats) and, with WithMetrics, as the
+// gauges grpckit_open_connections{listener},
+// grpckit_grpc_active_streams{method} and grpckit_gateway_connections{state}.
+//
+// Example:
+//
+//	stats := server.Stats()
+//	log.Printf("open HTTP connections: %d", stats.Connections["http"])
+func (s *Server) Stats() ConnectionStats {
+	stats := ConnectionStats{
+		Connections:   snapshot(&s.stats.conns),
+		ActiveStreams: snapshot(&s.stats.streams),
+		Gateway:       []GatewayConnectionState{},
+	}
+	s.stats.gateway.Range(func(key, _ any) bool {
+		cc := key.(*grpc.ClientConn)
+		stats.Gateway = append(stats.Gateway, GatewayConnectionState{Target: cc.Target(), State: cc.GetState().String()})
+		return true
+	})
+	sort.Slice(stats.Gateway, func(i, j int) bool {
+		return stats.Gateway[i].Target < stats.Gateway[j].Target
+	})
+	return stats
+}
+
+// countConnections wraps lis to count its open connections.
+func (s *Server) countConnections(name string, lis net.Listener) net.Listener {
+	return &countingListener{Listener: lis, open: counter(&s.stats.conns, name)}
+}
+
+// countingListener is a net.Listener counting its open connections.
+type countingListener struct {
+	net.Listener
+	open *atomic.Int64
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	l.open.Add(1)
+	return &limitConn{Conn: c, release: func() { l.open.Add(-1) }}, nil
+}
+
+// gatewayConnUnaryInterceptor records the gateway connection of a call.
+func (s *Server) gatewayConnUnaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	s.trackGatewayConn(cc)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// gatewayConnStreamInterceptor records the gateway connection of a stream.
+func (s *Server) gatewayConnStreamInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	s.trackGatewayConn(cc)
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+func (s *Server) trackGatewayConn(cc *grpc.ClientConn) {
+	if _, ok := s.stats.gateway.Load(cc); !ok {
+		s.stats.gateway.Store(cc, struct{}{})
+	}
+}
+
+// statsCollector exports Server.Stats as Prometheus gauges, read at scrape
+// time.
+type statsCollector struct {
+	s           *Server
+	connections *prometheus.Desc
+	streams     *prometheus.Desc
+	gateway     *prometheus.Desc
+}
+
+func newStatsCollector(s *Server, namespace string) *statsCollector {
+	return &statsCollector{
+		s: s,
+		connections: prometheus.NewDesc(namespace+"_open_connections",
+			"Number of open connections per listener", []string{"listener"}, nil),
+		streams: prometheus.NewDesc(namespace+"_grpc_active_streams",
+			"Number of active gRPC calls per method", []string{"method"}, nil),
+		gateway: prometheus.NewDesc(namespace+"_gateway_connections",
+			"Number of gateway connections to the local gRPC server per state", []string{"state"}, nil),
+	}
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.connections
+	ch <- c.streams
+	ch <- c.gateway
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.s.Stats()
+	for listener, n := range stats.Connections {
+		ch <- prometheus.MustNewConstMetric(c.connections, prometheus.GaugeValue, float64(n), listener)
+	}
+	for method, n := range stats.ActiveStreams {
+		ch <- prometheus.MustNewConstMetric(c.streams, prometheus.GaugeValue, float64(n), method)
+	}
+	states := map[string]int{}
+	for _, conn := range stats.Gateway {
+		states[conn.State]++
+	}
+	for state, n := range states {
+		ch <- prometheus.MustNewConstMetric(c.gateway, prometheus.GaugeValue, float64(n), state)
+	}
+}
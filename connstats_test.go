@@ -0,0 +1,166 @@
+package grpckit
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestStats_Connections(t *testing.T) {
+	s := newSlowTestServer(t)
+	raw, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	lis := s.countConnections("http", raw)
+	defer lis.Close()
+
+	client, err := net.Dial("tcp", raw.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	conn, err := lis.Accept()
+	if err != nil {
+		t.Fatalf("accept: %v", err)
+	}
+	if n := s.Stats().Connections["http"]; n != 1 {
+		t.Errorf("expected 1 open connection, got %d", n)
+	}
+
+	// Closing twice only counts once
+	conn.Close()
+	conn.Close()
+	if n := s.Stats().Connections["http"]; n != 0 {
+		t.Errorf("expected 0 open connections, got %d", n)
+	}
+}
+
+func TestStats_ActiveStreams(t *testing.T) {
+	s := newSlowTestServer(t)
+	info := &grpc.UnaryServerInfo{FullMethod: "/feed.Feed/Get"}
+
+	_, err := s.shutdownUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if n := s.Stats().ActiveStreams["/feed.Feed/Get"]; n != 1 {
+			t.Errorf("expected 1 active call during the handler, got %d", n)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := s.Stats().ActiveStreams["/feed.Feed/Get"]; n != 0 {
+		t.Errorf("expected 0 active calls after the handler, got %d", n)
+	}
+}
+
+func TestStats_GatewayConnections(t *testing.T) {
+	s := newSlowTestServer(t)
+	cc, err := grpc.NewClient("localhost:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer cc.Close()
+
+	s.trackGatewayConn(cc)
+	s.trackGatewayConn(cc)
+	gateway := s.Stats().Gateway
+	if len(gateway) != 1 || gateway[0].Target != "localhost:1" || gateway[0].State != "IDLE" {
+		t.Errorf("unexpected gateway connections %+v", gateway)
+	}
+}
+
+func TestStats_Metrics(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t)
+	counter(&s.stats.conns, "grpc").Add(3)
+	counter(&s.stats.streams, "/feed.Feed/Watch").Add(2)
+
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(newStatsCollector(s, "grpckit"))
+	if n := testutil.CollectAndCount(reg, "grpckit_open_connections", "grpckit_grpc_active_streams"); n != 2 {
+		t.Errorf("expected 2 series, got %d", n)
+	}
+
+	// Registered with WithMetrics
+	reg = prometheus.NewRegistry()
+	prometheus.DefaultRegisterer = reg
+	s = newSlowTestServer(t, WithMetrics())
+	counter(&s.stats.conns, "http").Add(1)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	found := false
+	for _, f := range families {
+		found = found || f.GetName() == "grpckit_open_connections"
+	}
+	if !found {
+		t.Error("expected grpckit_open_connections with WithMetrics")
+	}
+}
+
+func TestAdmin_Stats(t *testing.T) {
+	s, handler := newAdminTestServer(t, AdminToken("t"))
+	active := counter(&s.stats.streams, "/feed.Feed/Watch")
+	active.Add(1)
+	defer active.Add(-1)
+
+	rec := adminRequest(handler, http.MethodGet, "/admin/stats", "t")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	var stats ConnectionStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if stats.ActiveStreams["/feed.Feed/Watch"] != 1 || stats.Gateway == nil {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestStats_Server(t *testing.T) {
+	bound := make(chan ListenerBound, 1)
+	s := newSlowTestServer(t,
+		WithGRPCPort(0),
+		WithHTTPPort(0),
+		WithHealthCheck(),
+		WithEventListener(func(e Event) {
+			if lb, ok := e.(ListenerBound); ok {
+				bound <- lb
+			}
+		}),
+	)
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+	defer func() {
+		s.Shutdown()
+		<-errCh
+	}()
+
+	var addr string
+	select {
+	case lb := <-bound:
+		addr = lb.Addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ListenerBound")
+	}
+	client := &http.Client{Transport: &http.Transport{}}
+	resp, err := client.Get("http://" + addr + "/healthz")
+	if err != nil {
+		t.Fatalf("GET /healthz failed: %v", err)
+	}
+	resp.Body.Close()
+	if n := s.Stats().Connections["grpc+http"]; n != 1 {
+		t.Errorf("expected the keep-alive connection to be counted, got %d", n)
+	}
+	client.CloseIdleConnections()
+}
@@ -0,0 +1,95 @@
+package grpckit
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// contentTypeRule holds the accepted request content types for a pattern.
+type contentTypeRule struct {
+	paths    *pathmatch.Matcher
+	accepted []string
+	response []byte
+}
+
+// WithAcceptedContentTypes restricts the request bodies accepted on the
+// paths matching pattern to the given media types, e.g. "application/json"
+// or "multipart/form-data". A type may end in "/*" to accept a family, e.g.
+// "image/*". Requests with a body of any other type, or without a
+// Content-Type, are rejected with 415 Unsupported Media Type before any
+// marshaler or handler runs. Requests without a body are not checked.
+//
+// Patterns support the same globs as WithProtectedEndpoints; the first
+// matching pattern wins, and paths matching none accept every registered
+// content type.
+//
+// Example:
+//
+//	grpckit.WithAcceptedContentTypes("/api/v1/items/**", "application/json"),
+//	grpckit.WithAcceptedContentTypes("/api/v1/import", "multipart/form-data"),
+func WithAcceptedContentTypes(pattern string, contentTypes ...string) Option {
+	return func(c *serverConfig) {
+		if len(contentTypes) == 0 {
+			c.invalid("WithAcceptedContentTypes(%q): no content types", pattern)
+			return
+		}
+		accepted := make([]string, len(contentTypes))
+		for i, ct := range contentTypes {
+			accepted[i] = strings.ToLower(strings.TrimSpace(ct))
+		}
+		message, _ := json.Marshal("unsupported content type, expected " + strings.Join(accepted, " or "))
+
+		c.contentTypeRules = append(c.contentTypeRules, contentTypeRule{
+			paths:    c.compilePatterns("WithAcceptedContentTypes", []string{pattern}),
+			accepted: accepted,
+			response: []byte(`{"code":3,"message":` + string(message) + `,"details":[]}`),
+		})
+	}
+}
+
+// accepts reports whether a Content-Type header value is accepted.
+func (rule *contentTypeRule) accepts(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, accepted := range rule.accepted {
+		if family, ok := strings.CutSuffix(accepted, "/*"); ok {
+			if strings.HasPrefix(mediaType, family+"/") {
+				return true
+			}
+		} else if mediaType == accepted {
+			return true
+		}
+	}
+	return false
+}
+
+// contentTypeMiddleware rejects request bodies of a content type not
+// accepted by the first rule matching the path.
+func contentTypeMiddleware(rules []contentTypeRule, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body == nil || r.Body == http.NoBody || r.ContentLength == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for i := range rules {
+			rule := &rules[i]
+			if !rule.paths.Match(r.URL.Path) {
+				continue
+			}
+			if !rule.accepts(r.Header.Get("Content-Type")) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnsupportedMediaType)
+				w.Write(rule.response)
+				return
+			}
+			break
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,78 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestWithAcceptedContentTypes(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithAcceptedContentTypes("/api/v1/items/**", "application/json"),
+		WithAcceptedContentTypes("/api/v1/import", "multipart/form-data", "text/*"),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := s.buildHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{"json accepted", http.MethodPost, "/api/v1/items", "application/json; charset=utf-8", "{}", http.StatusOK},
+		{"xml rejected", http.MethodPost, "/api/v1/items/42", "application/xml", "<item/>", http.StatusUnsupportedMediaType},
+		{"missing type rejected", http.MethodPost, "/api/v1/items", "", "{}", http.StatusUnsupportedMediaType},
+		{"no body not checked", http.MethodGet, "/api/v1/items", "", "", http.StatusOK},
+		{"multipart accepted", http.MethodPost, "/api/v1/import", "multipart/form-data; boundary=x", "--x--", http.StatusOK},
+		{"family accepted", http.MethodPost, "/api/v1/import", "text/csv", "a,b", http.StatusOK},
+		{"json rejected", http.MethodPost, "/api/v1/import", "application/json", "{}", http.StatusUnsupportedMediaType},
+		{"unrestricted path", http.MethodPost, "/api/v1/users", "application/xml", "<user/>", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("expected %d, got %d", tt.want, rec.Code)
+			}
+		})
+	}
+}
+
+func TestWithAcceptedContentTypes_Response(t *testing.T) {
+	cfg := newServerConfig()
+	WithAcceptedContentTypes("/upload", "multipart/form-data", "image/*")(cfg)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/upload", strings.NewReader("{}"))
+	req.Header.Set("Content-Type", "application/json")
+	contentTypeMiddleware(cfg.contentTypeRules, http.NotFoundHandler()).ServeHTTP(rec, req)
+
+	want := `{"code":3,"message":"unsupported content type, expected multipart/form-data or image/*","details":[]}`
+	if rec.Code != http.StatusUnsupportedMediaType || rec.Body.String() != want {
+		t.Errorf("unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWithAcceptedContentTypes_NoTypes(t *testing.T) {
+	_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithAcceptedContentTypes("/upload"))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
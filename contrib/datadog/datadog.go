@@ -0,0 +1,126 @@
+// Package datadog integrates Datadog APM (dd-trace-go) with grpckit.
+//
+// It is a separate module so that services not using Datadog don't pull in
+// dd-trace-go. Starting and stopping the tracer remains the application's
+// responsibility:
+//
+//	tracer.Start(tracer.WithService("item-service"))
+//	defer tracer.Stop()
+//
+//	grpckit.Run(
+//	    grpckit.WithGRPCService(...),
+//	    datadog.WithTracing(datadog.ServiceName("item-service")),
+//	)
+package datadog
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+	grpctrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/google.golang.org/grpc"
+	httptrace "gopkg.in/DataDog/dd-trace-go.v1/contrib/net/http"
+)
+
+// config holds the adapter configuration.
+type config struct {
+	serviceName    string
+	untracedPaths  map[string]bool
+	untracedMethod []string
+	grpcOpts       []grpctrace.Option
+	httpOpts       []httptrace.Option
+}
+
+// Option configures the Datadog adapter.
+type Option func(*config)
+
+// ServiceName sets the service name reported on HTTP and gRPC spans.
+// Default: the tracer's global service name.
+func ServiceName(name string) Option {
+	return func(c *config) {
+		c.serviceName = name
+	}
+}
+
+// UntracedPaths sets HTTP paths that are not traced.
+// Default: the server's health check and metrics paths (see
+// grpckit.InfrastructurePaths), wherever WithHealthPaths and WithMetricsPath
+// put them.
+func UntracedPaths(paths ...string) Option {
+	return func(c *config) {
+		c.untracedPaths = make(map[string]bool, len(paths))
+		for _, p := range paths {
+			c.untracedPaths[p] = true
+		}
+	}
+}
+
+// UntracedMethods sets gRPC methods (e.g. /grpc.health.v1.Health/Check)
+// that are not traced.
+func UntracedMethods(methods ...string) Option {
+	return func(c *config) {
+		c.untracedMethod = append(c.untracedMethod, methods...)
+	}
+}
+
+// GRPCOptions passes additional options to the dd-trace-go gRPC interceptors.
+func GRPCOptions(opts ...grpctrace.Option) Option {
+	return func(c *config) {
+		c.grpcOpts = append(c.grpcOpts, opts...)
+	}
+}
+
+// HTTPOptions passes additional options to the dd-trace-go HTTP middleware.
+func HTTPOptions(opts ...httptrace.Option) Option {
+	return func(c *config) {
+		c.httpOpts = append(c.httpOpts, opts...)
+	}
+}
+
+// WithTracing returns a grpckit option that traces HTTP requests, gRPC
+// server calls, and the grpc-gateway → gRPC connection, so a REST call
+// shows up as one trace spanning both sides.
+//
+// HTTP spans are named by method and path, with ID-like segments replaced
+// (see grpckit.NormalizePath): "GET /api/v1/items/:id". Pass
+// httptrace.WithResourceNamer in HTTPOptions to name them otherwise.
+func WithTracing(opts ...Option) grpckit.Option {
+	cfg := &config{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	grpcOpts := cfg.grpcOpts
+	if cfg.serviceName != "" {
+		grpcOpts = append(grpcOpts, grpctrace.WithServiceName(cfg.serviceName))
+	}
+	if len(cfg.untracedMethod) > 0 {
+		grpcOpts = append(grpcOpts, grpctrace.WithUntracedMethods(cfg.untracedMethod...))
+	}
+
+	// Defaults first, so HTTPOptions can override them
+	httpOpts := append([]httptrace.Option{
+		httptrace.WithIgnoreRequest(func(r *http.Request) bool {
+			if cfg.untracedPaths == nil {
+				return slices.Contains(grpckit.InfrastructurePaths(r.Context()), r.URL.Path)
+			}
+			return cfg.untracedPaths[r.URL.Path]
+		}),
+		httptrace.WithResourceNamer(func(r *http.Request) string {
+			return r.Method + " " + grpckit.NormalizePath(r.URL.Path)
+		}),
+	}, cfg.httpOpts...)
+
+	return grpckit.WithOptions(
+		grpckit.WithHTTPMiddleware(func(next http.Handler) http.Handler {
+			return httptrace.WrapHandler(next, cfg.serviceName, "", httpOpts...)
+		}),
+		grpckit.WithUnaryInterceptor(grpctrace.UnaryServerInterceptor(grpcOpts...)),
+		grpckit.WithStreamInterceptor(grpctrace.StreamServerInterceptor(grpcOpts...)),
+		grpckit.WithGatewayDialOption(
+			grpc.WithChainUnaryInterceptor(grpctrace.UnaryClientInterceptor(grpcOpts...)),
+			grpc.WithChainStreamInterceptor(grpctrace.StreamClientInterceptor(grpcOpts...)),
+		),
+	)
+}
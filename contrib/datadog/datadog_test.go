@@ -0,0 +1,92 @@
+package datadog
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestWithTracing_HTTP(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ts, err := grpckit.NewTestServer(
+		grpckit.WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		grpckit.WithHealthCheck(),
+		grpckit.WithHTTPHandlerFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		grpckit.WithHTTPHandlerFunc("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}),
+		WithTracing(ServiceName("orders-api")),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	for _, path := range []string{"/orders", "/orders/42", "/healthz"} {
+		resp, err := ts.HTTPClient().Get(ts.URL(path))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	spans := mt.FinishedSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans (health check untraced), got %d", len(spans))
+	}
+	if got := spans[0].Tag("service.name"); got != "orders-api" {
+		t.Errorf("expected service orders-api, got %v", got)
+	}
+	// IDs are replaced, so resources don't grow with the number of orders
+	for i, want := range []string{"GET /orders", "GET /orders/:id"} {
+		if got := spans[i].Tag("resource.name"); got != want {
+			t.Errorf("expected resource %q, got %v", want, got)
+		}
+	}
+}
+
+func TestUntracedPaths(t *testing.T) {
+	cfg := &config{}
+	UntracedPaths("/ping")(cfg)
+
+	if !cfg.untracedPaths["/ping"] || cfg.untracedPaths["/healthz"] {
+		t.Errorf("expected only /ping untraced, got %v", cfg.untracedPaths)
+	}
+}
+
+func TestWithTracing_RelocatedProbes(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	ts, err := grpckit.NewTestServer(
+		grpckit.WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		grpckit.WithHealthCheck(),
+		grpckit.WithHealthPaths("/livez", "/ready"),
+		WithTracing(),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	for _, path := range []string{"/livez", "/ready", "/healthz"} {
+		resp, err := ts.HTTPClient().Get(ts.URL(path))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	// Only /healthz, no longer a health check, is traced
+	spans := mt.FinishedSpans()
+	if len(spans) != 1 || spans[0].Tag("resource.name") != "GET /healthz" {
+		t.Errorf("expected relocated health checks to be untraced, got %v", spans)
+	}
+}
@@ -0,0 +1,254 @@
+// Package lambda runs grpckit services on AWS Lambda, behind API Gateway
+// REST APIs (payload format 1.0), HTTP APIs (2.0) or function URLs.
+//
+// It is a separate module so that services not deployed on Lambda don't pull
+// in aws-lambda-go. No listener is opened: each invocation event is turned
+// into an HTTP request for Server.Handler, with the full middleware chain,
+// and the response back into the event's response format. The gRPC services
+// are reached in memory through the gateway; native gRPC clients can't call
+// a function.
+//
+//	server, err := grpckit.New(
+//	    grpckit.WithGRPCService(...),
+//	    grpckit.WithRESTService(...),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	lambda.Start(server)
+//
+// For Cloud Run and other platforms that route requests to a port, use
+// grpckit.CloudRunDefaults instead.
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	awslambda "github.com/aws/aws-lambda-go/lambda"
+	"github.com/gyozatech/grpckit"
+)
+
+// ErrUnsupportedEvent is returned for invocation events that are not API
+// Gateway or function URL HTTP requests.
+var ErrUnsupportedEvent = errors.New("unsupported Lambda event")
+
+// Handler handles a Lambda invocation event.
+type Handler func(ctx context.Context, event json.RawMessage) (any, error)
+
+// Start runs server as a Lambda function. Like aws-lambda-go's lambda.Start,
+// it blocks and exits the process on fatal errors.
+func Start(server *grpckit.Server) {
+	handler, err := NewHandler(server)
+	if err != nil {
+		log.Fatalf("grpckit lambda: %v", err)
+	}
+	awslambda.Start(handler)
+}
+
+// NewHandler returns the Lambda handler serving invocation events with
+// server.Handler, e.g. to wrap it or call it in tests.
+func NewHandler(server *grpckit.Server) (Handler, error) {
+	h, err := server.Handler()
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, event json.RawMessage) (any, error) {
+		var probe struct {
+			Version    string `json:"version"`
+			HTTPMethod string `json:"httpMethod"`
+		}
+		if err := json.Unmarshal(event, &probe); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnsupportedEvent, err)
+		}
+		switch {
+		case probe.Version == "2.0":
+			var req events.APIGatewayV2HTTPRequest
+			if err := json.Unmarshal(event, &req); err != nil {
+				return nil, err
+			}
+			return serveV2(ctx, h, &req)
+		case probe.HTTPMethod != "":
+			var req events.APIGatewayProxyRequest
+			if err := json.Unmarshal(event, &req); err != nil {
+				return nil, err
+			}
+			return serveV1(ctx, h, &req)
+		default:
+			return nil, ErrUnsupportedEvent
+		}
+	}, nil
+}
+
+// serveV1 serves a payload format 1.0 event (API Gateway REST APIs).
+func serveV1(ctx context.Context, h http.Handler, event *events.APIGatewayProxyRequest) (*events.APIGatewayProxyResponse, error) {
+	query := url.Values(event.MultiValueQueryStringParameters)
+	if query == nil {
+		query = url.Values{}
+		for k, v := range event.QueryStringParameters {
+			query.Set(k, v)
+		}
+	}
+	r, err := newRequest(ctx, event.HTTPMethod, event.Path, query.Encode(), event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	if event.MultiValueHeaders != nil {
+		for k, values := range event.MultiValueHeaders {
+			for _, v := range values {
+				r.Header.Add(k, v)
+			}
+		}
+	} else {
+		for k, v := range event.Headers {
+			r.Header.Set(k, v)
+		}
+	}
+	finishRequest(r, event.RequestContext.Identity.SourceIP)
+
+	w := newResponseWriter()
+	h.ServeHTTP(w, r)
+	body, isBase64 := w.encodedBody()
+	return &events.APIGatewayProxyResponse{
+		StatusCode:        w.status,
+		MultiValueHeaders: w.header,
+		Body:              body,
+		IsBase64Encoded:   isBase64,
+	}, nil
+}
+
+// serveV2 serves a payload format 2.0 event (API Gateway HTTP APIs and
+// function URLs).
+func serveV2(ctx context.Context, h http.Handler, event *events.APIGatewayV2HTTPRequest) (*events.APIGatewayV2HTTPResponse, error) {
+	r, err := newRequest(ctx, event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString, event.Body, event.IsBase64Encoded)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range event.Headers {
+		r.Header.Set(k, v)
+	}
+	if len(event.Cookies) > 0 {
+		r.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	finishRequest(r, event.RequestContext.HTTP.SourceIP)
+
+	w := newResponseWriter()
+	h.ServeHTTP(w, r)
+	body, isBase64 := w.encodedBody()
+
+	// Payload format 2.0 returns cookies separately, and other headers with
+	// their values joined
+	cookies := w.header.Values("Set-Cookie")
+	w.header.Del("Set-Cookie")
+	headers := make(map[string]string, len(w.header))
+	for k, values := range w.header {
+		headers[k] = strings.Join(values, ",")
+	}
+	return &events.APIGatewayV2HTTPResponse{
+		StatusCode:      w.status,
+		Headers:         headers,
+		Cookies:         cookies,
+		Body:            body,
+		IsBase64Encoded: isBase64,
+	}, nil
+}
+
+// newRequest creates the HTTP request of an event.
+func newRequest(ctx context.Context, method, path, rawQuery, body string, isBase64 bool) (*http.Request, error) {
+	data := []byte(body)
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64 body: %w", err)
+		}
+		data = decoded
+	}
+	target := (&url.URL{Path: path, RawQuery: rawQuery}).RequestURI()
+	r, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	r.RequestURI = target
+	return r, nil
+}
+
+// finishRequest sets the fields of r derived from its headers, and the
+// client address.
+func finishRequest(r *http.Request, sourceIP string) {
+	r.Host = r.Header.Get("Host")
+	if sourceIP != "" {
+		r.RemoteAddr = sourceIP + ":0"
+	}
+}
+
+// responseWriter buffers a response for the Lambda result.
+type responseWriter struct {
+	header      http.Header
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{header: http.Header{}, status: http.StatusOK}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(b)
+}
+
+// Flush is a no-op: the response is returned once the handler is done.
+func (w *responseWriter) Flush() {}
+
+// encodedBody returns the body, base64-encoded unless it is text.
+func (w *responseWriter) encodedBody() (string, bool) {
+	if w.header.Get("Content-Encoding") == "" && isText(w.header.Get("Content-Type")) {
+		return w.body.String(), false
+	}
+	return base64.StdEncoding.EncodeToString(w.body.Bytes()), true
+}
+
+// isText reports whether a response of contentType can be returned as is.
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"),
+		strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/xml", "application/javascript",
+		"application/x-www-form-urlencoded", "application/x-ndjson":
+		return true
+	}
+	return false
+}
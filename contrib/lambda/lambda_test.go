@@ -0,0 +1,157 @@
+package lambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+)
+
+func newTestHandler(t *testing.T) Handler {
+	t.Helper()
+	server, err := grpckit.New(
+		grpckit.WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		grpckit.WithHealthCheck(),
+		grpckit.WithHTTPHandlerFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Add("Set-Cookie", "a=1")
+			w.Header().Add("Set-Cookie", "b=2")
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]any{
+				"method": r.Method,
+				"query":  r.URL.Query().Get("q"),
+				"header": r.Header.Get("X-Test"),
+				"cookie": r.Header.Get("Cookie"),
+				"remote": r.RemoteAddr,
+				"body":   string(body),
+			})
+		}),
+		grpckit.WithHTTPHandlerFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte{0x89, 'P', 'N', 'G'})
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	t.Cleanup(server.Shutdown)
+
+	handler, err := NewHandler(server)
+	if err != nil {
+		t.Fatalf("NewHandler failed: %v", err)
+	}
+	return handler
+}
+
+func invoke(t *testing.T, h Handler, event any) any {
+	t.Helper()
+	data, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := h(context.Background(), data)
+	if err != nil {
+		t.Fatalf("invocation failed: %v", err)
+	}
+	return resp
+}
+
+func TestHandler_V1(t *testing.T) {
+	h := newTestHandler(t)
+
+	resp := invoke(t, h, events.APIGatewayProxyRequest{
+		HTTPMethod:                      http.MethodPost,
+		Path:                            "/echo",
+		MultiValueQueryStringParameters: map[string][]string{"q": {"books"}},
+		MultiValueHeaders:               map[string][]string{"X-Test": {"yes"}},
+		Body:                            base64.StdEncoding.EncodeToString([]byte("hello")),
+		IsBase64Encoded:                 true,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Identity: events.APIGatewayRequestIdentity{SourceIP: "203.0.113.7"},
+		},
+	}).(*events.APIGatewayProxyResponse)
+
+	if resp.StatusCode != http.StatusCreated || resp.IsBase64Encoded {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	var got map[string]string
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatalf("invalid body %q: %v", resp.Body, err)
+	}
+	want := map[string]string{"method": "POST", "query": "books", "header": "yes", "cookie": "", "remote": "203.0.113.7:0", "body": "hello"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+	if cookies := resp.MultiValueHeaders["Set-Cookie"]; len(cookies) != 2 {
+		t.Errorf("expected both cookies, got %v", cookies)
+	}
+}
+
+func TestHandler_V2(t *testing.T) {
+	h := newTestHandler(t)
+
+	event := events.APIGatewayV2HTTPRequest{
+		Version:        "2.0",
+		RawPath:        "/echo",
+		RawQueryString: "q=films",
+		Cookies:        []string{"session=abc", "theme=dark"},
+		Headers:        map[string]string{"x-test": "yes"},
+		Body:           "hi",
+	}
+	event.RequestContext.HTTP.Method = http.MethodPut
+	event.RequestContext.HTTP.SourceIP = "198.51.100.1"
+	resp := invoke(t, h, event).(*events.APIGatewayV2HTTPResponse)
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	var got map[string]string
+	if err := json.Unmarshal([]byte(resp.Body), &got); err != nil {
+		t.Fatalf("invalid body %q: %v", resp.Body, err)
+	}
+	want := map[string]string{"method": "PUT", "query": "films", "header": "yes", "cookie": "session=abc; theme=dark", "remote": "198.51.100.1:0", "body": "hi"}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s: expected %q, got %q", k, v, got[k])
+		}
+	}
+	if len(resp.Cookies) != 2 || resp.Headers["Set-Cookie"] != "" {
+		t.Errorf("expected cookies returned separately, got %v and %v", resp.Cookies, resp.Headers)
+	}
+}
+
+func TestHandler_BinaryAndBuiltins(t *testing.T) {
+	h := newTestHandler(t)
+
+	event := events.APIGatewayV2HTTPRequest{Version: "2.0", RawPath: "/image"}
+	event.RequestContext.HTTP.Method = http.MethodGet
+	resp := invoke(t, h, event).(*events.APIGatewayV2HTTPResponse)
+	if !resp.IsBase64Encoded || resp.Body != base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("expected base64 binary body, got %+v", resp)
+	}
+
+	event = events.APIGatewayV2HTTPRequest{Version: "2.0", RawPath: "/healthz"}
+	event.RequestContext.HTTP.Method = http.MethodGet
+	if resp := invoke(t, h, event).(*events.APIGatewayV2HTTPResponse); resp.StatusCode != http.StatusOK {
+		t.Errorf("expected built-in health endpoint, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandler_UnsupportedEvent(t *testing.T) {
+	h := newTestHandler(t)
+
+	_, err := h(context.Background(), json.RawMessage(`{"Records":[]}`))
+	if !errors.Is(err, ErrUnsupportedEvent) {
+		t.Errorf("expected ErrUnsupportedEvent, got %v", err)
+	}
+}
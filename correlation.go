@@ -0,0 +1,136 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// requestIDHeader is the HTTP header carrying the request ID.
+	requestIDHeader = "X-Request-ID"
+
+	// requestIDMetadataKey is the gRPC metadata key carrying the request ID.
+	requestIDMetadataKey = "x-request-id"
+
+	// maxRequestIDLength bounds client-supplied request IDs.
+	maxRequestIDLength = 128
+)
+
+// traceHeaders are W3C trace context headers forwarded from REST calls to gRPC.
+var traceHeaders = []string{"traceparent", "tracestate"}
+
+// requestIDKey is the context key for the request ID.
+type requestIDKey struct{}
+
+// contextWithRequestID returns a context carrying the request ID.
+func contextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFromContext returns the request ID, or "" if none is set.
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID generates a random 128-bit request ID.
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// validRequestID reports whether a client-supplied request ID is safe to log
+// and propagate: non-empty, bounded, printable ASCII without spaces.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// ensureRequestID returns the request with an ID in its context, honoring a
+// valid X-Request-ID header and generating one otherwise.
+func ensureRequestID(r *http.Request) (*http.Request, string) {
+	if id := requestIDFromContext(r.Context()); id != "" {
+		return r, id
+	}
+	id := r.Header.Get(requestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	return r.WithContext(contextWithRequestID(r.Context(), id)), id
+}
+
+// gatewayCorrelationMetadata forwards the request ID and trace context of a
+// REST call to the gRPC server, so both sides log the same identifiers.
+func gatewayCorrelationMetadata(ctx context.Context, r *http.Request) metadata.MD {
+	md := metadata.MD{}
+	id := requestIDFromContext(ctx)
+	if id == "" {
+		id = r.Header.Get(requestIDHeader)
+	}
+	if validRequestID(id) {
+		md.Set(requestIDMetadataKey, id)
+	}
+	for _, h := range traceHeaders {
+		if v := r.Header.Get(h); v != "" {
+			md.Set(h, v)
+		}
+	}
+	return md
+}
+
+// correlationContext extracts the request ID from incoming gRPC metadata and
+// prepares the call's log fields with it.
+func correlationContext(ctx context.Context) context.Context {
+	ctx, fields := withLogFields(ctx)
+	md, _ := metadata.FromIncomingContext(ctx)
+	if ids := md.Get(requestIDMetadataKey); len(ids) > 0 && validRequestID(ids[0]) {
+		ctx = contextWithRequestID(ctx, ids[0])
+		fields.Add("request_id", ids[0])
+	}
+	return ctx
+}
+
+// correlationUnaryInterceptor makes the caller's request ID available to
+// gRPC handlers and interceptors via the context and LogFields.
+func correlationUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(correlationContext(ctx), req)
+}
+
+// correlationStreamInterceptor is the stream variant of correlationUnaryInterceptor.
+func correlationStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: correlationContext(ss.Context())})
+}
+
+// contextServerStream overrides the context of a grpc.ServerStream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the overridden context.
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,131 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestValidRequestID(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{"abc-123", true},
+		{"4bf92f3577b34da6a3ce929d0e0e4736", true},
+		{"", false},
+		{"has space", false},
+		{"line\nbreak", false},
+		{strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		if got := validRequestID(tt.id); got != tt.valid {
+			t.Errorf("validRequestID(%q) = %v, expected %v", tt.id, got, tt.valid)
+		}
+	}
+}
+
+func TestEnsureRequestID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set(requestIDHeader, "client-id")
+	req, id := ensureRequestID(req)
+	if id != "client-id" || requestIDFromContext(req.Context()) != "client-id" {
+		t.Errorf("expected client-supplied ID to be honored, got %q", id)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set(requestIDHeader, "bad id")
+	_, id = ensureRequestID(req)
+	if len(id) != 32 {
+		t.Errorf("expected generated 32-char ID for invalid header, got %q", id)
+	}
+}
+
+func TestGatewayCorrelationMetadata(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx := contextWithRequestID(req.Context(), "req-1")
+
+	md := gatewayCorrelationMetadata(ctx, req)
+
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("expected request ID metadata, got %v", got)
+	}
+	if got := md.Get("traceparent"); len(got) != 1 {
+		t.Errorf("expected traceparent to be forwarded, got %v", got)
+	}
+	if got := md.Get("tracestate"); len(got) != 0 {
+		t.Errorf("expected absent tracestate not to be forwarded, got %v", got)
+	}
+}
+
+func TestCorrelationUnaryInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-1"))
+
+	var gotID string
+	var gotFields string
+	_, err := correlationUnaryInterceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			gotID = requestIDFromContext(ctx)
+			gotFields = LogFields(ctx).String()
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotID != "req-1" {
+		t.Errorf("expected request ID req-1, got %q", gotID)
+	}
+	if gotFields != "request_id=req-1" {
+		t.Errorf("expected log fields to carry request ID, got %q", gotFields)
+	}
+}
+
+func TestCorrelationStreamInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-2"))
+
+	var gotID string
+	err := correlationStreamInterceptor(nil, &contextServerStream{ctx: ctx}, &grpc.StreamServerInfo{},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			gotID = requestIDFromContext(ss.Context())
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if gotID != "req-2" {
+		t.Errorf("expected request ID req-2, got %q", gotID)
+	}
+}
+
+func TestAccessLogMiddleware_RequestID(t *testing.T) {
+	buf := captureLog(t)
+
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	var md metadata.MD
+	handler := accessLogMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		md = gatewayCorrelationMetadata(r.Context(), r)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	req.Header.Set(requestIDHeader, "req-3")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), "request_id=req-3") {
+		t.Errorf("expected access log to contain request ID, got %q", buf.String())
+	}
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-3" {
+		t.Errorf("expected gateway metadata to carry the same request ID, got %v", got)
+	}
+}
@@ -80,11 +80,15 @@ func corsMiddleware(cfg CORSConfig) HTTPMiddleware {
 		cfg.MaxAge = 86400
 	}
 
-	// Pre-compute header values (computed once at middleware creation)
-	allowedMethods := strings.Join(cfg.AllowedMethods, ", ")
-	allowedHeaders := strings.Join(cfg.AllowedHeaders, ", ")
-	exposedHeaders := strings.Join(cfg.ExposedHeaders, ", ")
-	maxAgeStr := strconv.Itoa(cfg.MaxAge)
+	// Pre-compute header values (computed once at middleware creation). They
+	// are assigned to the header map directly, as Header.Set allocates.
+	allowedMethods := []string{strings.Join(cfg.AllowedMethods, ", ")}
+	allowedHeaders := []string{strings.Join(cfg.AllowedHeaders, ", ")}
+	exposedHeaders := []string{strings.Join(cfg.ExposedHeaders, ", ")}
+	maxAgeStr := []string{strconv.Itoa(cfg.MaxAge)}
+	wildcardOrigin := []string{"*"}
+	allowCredentials := []string{"true"}
+	varyOrigin := []string{"Origin"}
 
 	// Build origin map for O(1) lookups
 	originMap := make(map[string]bool, len(cfg.AllowedOrigins))
@@ -101,36 +105,38 @@ func corsMiddleware(cfg CORSConfig) HTTPMiddleware {
 			origin := r.Header.Get("Origin")
 
 			// Determine the allowed origin to return using O(1) map lookup
-			allowedOrigin := ""
+			var allowedOrigin []string
 			if origin != "" {
 				if hasWildcard {
-					allowedOrigin = "*"
+					allowedOrigin = wildcardOrigin
 				} else if originMap[origin] {
-					allowedOrigin = origin
+					allowedOrigin = []string{origin}
 				}
 			}
 
+			// Vary header tells caches that response varies based on Origin,
+			// including responses without CORS headers
+			addVary(w.Header(), varyOrigin)
+
 			// Set CORS headers if origin is allowed
-			if allowedOrigin != "" {
-				w.Header().Set("Access-Control-Allow-Origin", allowedOrigin)
-				w.Header().Set("Access-Control-Allow-Methods", allowedMethods)
-				w.Header().Set("Access-Control-Allow-Headers", allowedHeaders)
+			if allowedOrigin != nil {
+				h := w.Header()
+				h["Access-Control-Allow-Origin"] = allowedOrigin
+				h["Access-Control-Allow-Methods"] = allowedMethods
+				h["Access-Control-Allow-Headers"] = allowedHeaders
 
 				if len(cfg.ExposedHeaders) > 0 {
-					w.Header().Set("Access-Control-Expose-Headers", exposedHeaders)
+					h["Access-Control-Expose-Headers"] = exposedHeaders
 				}
 
-				if cfg.AllowCredentials && allowedOrigin != "*" {
-					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				if cfg.AllowCredentials && !hasWildcard {
+					h["Access-Control-Allow-Credentials"] = allowCredentials
 				}
-
-				// Vary header tells caches that response varies based on Origin
-				w.Header().Add("Vary", "Origin")
 			}
 
 			// Handle preflight OPTIONS request
 			if r.Method == http.MethodOptions {
-				w.Header().Set("Access-Control-Max-Age", maxAgeStr)
+				w.Header()["Access-Control-Max-Age"] = maxAgeStr
 				w.WriteHeader(http.StatusOK)
 				return
 			}
@@ -225,3 +225,21 @@ func TestCORSMiddleware_DefaultsApplied(t *testing.T) {
 		t.Error("expected default methods to be set")
 	}
 }
+
+func TestCORSMiddleware_VaryWithoutAllowedOrigin(t *testing.T) {
+	handler := corsMiddleware(CORSConfig{AllowedOrigins: []string{"https://example.com"}})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, origin := range []string{"", "https://evil.com"} {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Vary"); got != "Origin" {
+			t.Errorf("origin %q: expected Vary Origin, got %q", origin, got)
+		}
+	}
+}
@@ -0,0 +1,272 @@
+package grpckit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// requestCostHeader is the HTTP header returning the cost of a request.
+	requestCostHeader = "X-Request-Cost"
+
+	// requestCostMetadataKey is the gRPC metadata key returning the cost of
+	// a call.
+	requestCostMetadataKey = "x-request-cost"
+)
+
+// RequestCostConfig configures request cost accounting.
+type RequestCostConfig struct {
+	// Header returns the costs of each request to the client, in the
+	// X-Request-Cost header of HTTP responses and the x-request-cost
+	// metadata of gRPC calls (header for unary calls, trailer for streams),
+	// e.g. "db_rows=120, bytes=4096". Costs reported after an HTTP response
+	// started are counted but not returned.
+	Header bool
+}
+
+// WithRequestCost lets handlers report what a request cost them, in units
+// of their choice (database rows scanned, bytes processed, ...), with
+// AddRequestCost. The costs of each request are:
+//
+//   - added to its log fields as cost_<kind>, so the access and gRPC logs
+//     show which callers send expensive requests
+//   - observed in the grpckit_request_cost{protocol,operation,kind}
+//     histogram when metrics are enabled, for capacity planning
+//   - returned to the client when config.Header is set
+//
+// Costs reported by gRPC handlers of REST calls are returned in the HTTP
+// response too.
+//
+// Example:
+//
+//	grpckit.WithRequestCost(grpckit.RequestCostConfig{Header: true})
+//
+//	rows, scanned := s.db.Query(ctx, filter)
+//	grpckit.AddRequestCost(ctx, "db_rows", float64(scanned))
+func WithRequestCost(config RequestCostConfig) Option {
+	return func(c *serverConfig) {
+		c.requestCost = &config
+	}
+}
+
+// costKey is the context key for the request's costSet.
+type costKey struct{}
+
+// costSet accumulates the costs of a request, by kind in the order they
+// were first reported. It is safe for concurrent use.
+type costSet struct {
+	mu     sync.Mutex
+	kinds  []string
+	amount map[string]float64
+}
+
+// withCosts returns a context carrying a new, empty costSet.
+func withCosts(ctx context.Context) (context.Context, *costSet) {
+	c := &costSet{amount: make(map[string]float64)}
+	return context.WithValue(ctx, costKey{}, c), c
+}
+
+// AddRequestCost adds amount to the cost of kind (e.g. "db_rows") of the
+// request being handled. Negative amounts are ignored, and so are calls
+// outside a request accounted by WithRequestCost.
+func AddRequestCost(ctx context.Context, kind string, amount float64) {
+	c, ok := ctx.Value(costKey{}).(*costSet)
+	if !ok || kind == "" || !(amount >= 0) || math.IsInf(amount, 1) {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.amount[kind]; !ok {
+		c.kinds = append(c.kinds, kind)
+	}
+	c.amount[kind] += amount
+}
+
+// RequestCost returns the costs reported so far for the request being
+// handled, by kind, or nil outside a request accounted by WithRequestCost.
+func RequestCost(ctx context.Context) map[string]float64 {
+	c, ok := ctx.Value(costKey{}).(*costSet)
+	if !ok {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	costs := make(map[string]float64, len(c.amount))
+	for kind, amount := range c.amount {
+		costs[kind] = amount
+	}
+	return costs
+}
+
+// String formats the costs as comma-separated kind=amount pairs.
+func (c *costSet) String() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var b strings.Builder
+	for i, kind := range c.kinds {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(kind)
+		b.WriteByte('=')
+		b.WriteString(strconv.FormatFloat(c.amount[kind], 'g', -1, 64))
+	}
+	return b.String()
+}
+
+// report adds the costs to the request's log fields and metrics.
+func (c *costSet) report(ctx context.Context, m *Metrics, protocol, operation string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	fields := LogFields(ctx)
+	for _, kind := range c.kinds {
+		fields.Add("cost_"+kind, c.amount[kind])
+		if m != nil {
+			m.requestCost.WithLabelValues(protocol, operation, kind).Observe(c.amount[kind])
+		}
+	}
+}
+
+// requestCostMiddleware accounts the costs reported by HTTP handlers.
+// Costs of the gRPC calls of REST requests are accounted on the gRPC side.
+func requestCostMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, costs := withCosts(r.Context())
+		if s.cfg.requestCost.Header {
+			w = &costWriter{ResponseWriter: w, costs: costs}
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		operation := r.URL.Path
+		if s.metrics != nil {
+			operation = s.metrics.pathLabel(r.URL.Path)
+		}
+		costs.report(ctx, s.metrics, "http", operation)
+	})
+}
+
+// costWriter adds the costs reported so far to X-Request-Cost when the
+// response starts, after the ones of the gRPC call, if any.
+type costWriter struct {
+	http.ResponseWriter
+	costs   *costSet
+	written bool
+}
+
+func (w *costWriter) writeHeader() {
+	if w.written {
+		return
+	}
+	w.written = true
+	costs := w.costs.String()
+	if costs == "" {
+		return
+	}
+	h := w.ResponseWriter.Header()
+	if grpcCosts := h.Get(requestCostHeader); grpcCosts != "" {
+		costs = grpcCosts + ", " + costs
+	}
+	h.Set(requestCostHeader, costs)
+}
+
+func (w *costWriter) WriteHeader(code int) {
+	w.writeHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *costWriter) Write(b []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *costWriter) Flush() {
+	w.writeHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *costWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestCostUnaryInterceptor accounts the costs reported by unary handlers
+// and returns them in the response header.
+func requestCostUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, costs := withCosts(ctx)
+		resp, err := handler(ctx, req)
+		if s.cfg.requestCost.Header {
+			if v := costs.String(); v != "" {
+				_ = grpc.SetHeader(ctx, metadata.Pairs(requestCostMetadataKey, v))
+			}
+		}
+		costs.report(ctx, s.metrics, "grpc", info.FullMethod)
+		return resp, err
+	}
+}
+
+// requestCostStreamInterceptor is the stream variant of
+// requestCostUnaryInterceptor, returning the costs in the trailer.
+func requestCostStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, costs := withCosts(ss.Context())
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+		if s.cfg.requestCost.Header {
+			if v := costs.String(); v != "" {
+				ss.SetTrailer(metadata.Pairs(requestCostMetadataKey, v))
+			}
+		}
+		costs.report(ctx, s.metrics, "grpc", info.FullMethod)
+		return err
+	}
+}
+
+// requestCostResponseOption returns the costs of the gRPC call of a REST
+// request in X-Request-Cost.
+func requestCostResponseOption(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	setRequestCostHeader(ctx, w)
+	return nil
+}
+
+// setRequestCostHeader replaces the forwarded Grpc-Metadata-X-Request-Cost
+// header with X-Request-Cost.
+func setRequestCostHeader(ctx context.Context, w http.ResponseWriter) {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return
+	}
+	values := md.HeaderMD.Get(requestCostMetadataKey)
+	if len(values) == 0 {
+		values = md.TrailerMD.Get(requestCostMetadataKey)
+	}
+	if len(values) == 0 {
+		return
+	}
+	// The error handler forwards metadata after this runs; drop it from the
+	// metadata so only the plain header is sent
+	delete(md.HeaderMD, requestCostMetadataKey)
+	delete(md.TrailerMD, requestCostMetadataKey)
+	w.Header().Del(runtime.MetadataHeaderPrefix + requestCostMetadataKey)
+	w.Header().Set(requestCostHeader, values[0])
+}
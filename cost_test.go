@@ -0,0 +1,111 @@
+package grpckit
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestAddRequestCost(t *testing.T) {
+	// Outside an accounted request, calls are ignored
+	AddRequestCost(context.Background(), "db_rows", 10)
+	if costs := RequestCost(context.Background()); costs != nil {
+		t.Errorf("expected no costs outside a request, got %v", costs)
+	}
+
+	ctx, costs := withCosts(context.Background())
+	AddRequestCost(ctx, "db_rows", 100)
+	AddRequestCost(ctx, "bytes", 4096)
+	AddRequestCost(ctx, "db_rows", 20)
+	AddRequestCost(ctx, "db_rows", -5)
+	AddRequestCost(ctx, "db_rows", math.NaN())
+	if got := RequestCost(ctx); got["db_rows"] != 120 || got["bytes"] != 4096 || len(got) != 2 {
+		t.Errorf("unexpected costs %v", got)
+	}
+	if got := costs.String(); got != "db_rows=120, bytes=4096" {
+		t.Errorf("unexpected formatted costs %q", got)
+	}
+}
+
+func TestWithRequestCost_HTTP(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithMetrics(), WithAccessLog(), WithRequestCost(RequestCostConfig{Header: true}),
+		WithHTTPHandlerFunc("/report", func(w http.ResponseWriter, r *http.Request) {
+			AddRequestCost(r.Context(), "bytes", 512)
+			w.Write([]byte("ok"))
+			AddRequestCost(r.Context(), "db_rows", 3) // after the response started
+		}))
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/report", nil))
+
+	if got := rec.Header().Get(requestCostHeader); got != "bytes=512" {
+		t.Errorf("expected costs reported before the response in the header, got %q", got)
+	}
+	if line := buf.String(); !strings.Contains(line, "cost_bytes=512 cost_db_rows=3") {
+		t.Errorf("expected costs in the access log, got %q", line)
+	}
+	if n := testutil.CollectAndCount(s.metrics.requestCost); n != 2 {
+		t.Errorf("expected 2 cost series, got %d", n)
+	}
+}
+
+func TestRequestCostUnaryInterceptor(t *testing.T) {
+	s := newSlowTestServer(t, WithRequestCost(RequestCostConfig{Header: true}))
+	stream := &headerStream{}
+	ctx, fields := withLogFields(grpc.NewContextWithServerTransportStream(context.Background(), stream))
+
+	_, err := requestCostUnaryInterceptor(s)(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/ListItems"},
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			AddRequestCost(ctx, "db_rows", 250)
+			return nil, nil
+		})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := stream.header.Get(requestCostMetadataKey); len(got) != 1 || got[0] != "db_rows=250" {
+		t.Errorf("expected costs in the response header, got %v", got)
+	}
+	if v, _ := fields.Get("cost_db_rows"); v != 250.0 {
+		t.Errorf("expected costs in the log fields, got %v", v)
+	}
+
+	// The gateway returns them in X-Request-Cost
+	rec := httptest.NewRecorder()
+	rec.Header().Set(runtime.MetadataHeaderPrefix+requestCostMetadataKey, "forwarded")
+	gwCtx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: stream.header})
+	if err := requestCostResponseOption(gwCtx, rec, nil); err != nil {
+		t.Fatalf("response option failed: %v", err)
+	}
+	if got := rec.Header().Get(requestCostHeader); got != "db_rows=250" {
+		t.Errorf("unexpected X-Request-Cost %q", got)
+	}
+	if got := rec.Header().Get(runtime.MetadataHeaderPrefix + requestCostMetadataKey); got != "" {
+		t.Errorf("expected forwarded metadata header to be removed, got %q", got)
+	}
+}
+
+func TestRequestCostResponseOption_NotSet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: metadata.Pairs("x-other", "v")})
+	if err := requestCostResponseOption(ctx, rec, nil); err != nil {
+		t.Fatalf("response option failed: %v", err)
+	}
+	if got := rec.Header().Get(requestCostHeader); got != "" {
+		t.Errorf("expected no X-Request-Cost, got %q", got)
+	}
+}
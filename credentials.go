@@ -0,0 +1,56 @@
+package grpckit
+
+import (
+	"context"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TokenCredentials returns per-RPC credentials sending token as
+// "authorization: Bearer <token>" on every call, the format WithAuth expects.
+// Use it with grpc.WithPerRPCCredentials, client.WithToken or
+// TestServer.GRPCClientConnWithToken.
+//
+// The credentials require transport security: calls over plaintext
+// connections fail instead of leaking the token. Use InsecureTokenCredentials
+// for tests and plaintext in-cluster traffic.
+//
+// Example:
+//
+//	conn, _ := grpc.NewClient(addr,
+//	    grpc.WithTransportCredentials(creds),
+//	    grpc.WithPerRPCCredentials(grpckit.TokenCredentials(os.Getenv("SERVICE_TOKEN"))),
+//	)
+func TokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCredentials{token: token, secure: true}
+}
+
+// InsecureTokenCredentials is TokenCredentials also sent over connections
+// without transport security, e.g. to a TestServer or over plaintext
+// in-cluster traffic. Never use it across untrusted networks.
+//
+// Example:
+//
+//	conn, _ := grpc.NewClient("localhost:9090",
+//	    grpc.WithTransportCredentials(insecure.NewCredentials()),
+//	    grpc.WithPerRPCCredentials(grpckit.InsecureTokenCredentials("dev-token")),
+//	)
+func InsecureTokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCredentials{token: token}
+}
+
+// tokenCredentials attaches a static bearer token to every call.
+type tokenCredentials struct {
+	token  string
+	secure bool
+}
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (c tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + c.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (c tokenCredentials) RequireTransportSecurity() bool {
+	return c.secure
+}
@@ -0,0 +1,54 @@
+package grpckit
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestTokenCredentials(t *testing.T) {
+	creds := TokenCredentials("abc123")
+	md, err := creds.GetRequestMetadata(context.Background())
+	if err != nil {
+		t.Fatalf("GetRequestMetadata failed: %v", err)
+	}
+	if md["authorization"] != "Bearer abc123" {
+		t.Errorf("expected bearer authorization, got %v", md)
+	}
+	if !creds.RequireTransportSecurity() {
+		t.Error("expected credentials to require transport security")
+	}
+	if InsecureTokenCredentials("abc123").RequireTransportSecurity() {
+		t.Error("expected insecure credentials to be allowed without transport security")
+	}
+}
+
+func TestTestServer_GRPCClientConnWithToken(t *testing.T) {
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCHealthService(),
+		WithAuth(MockAuthFunc("valid-token", "user-123")),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	ctx := context.Background()
+	conn := ts.GRPCClientConnWithToken(ctx, "valid-token")
+	if conn != ts.GRPCClientConnWithToken(ctx, "valid-token") {
+		t.Error("expected connection to be cached per token")
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("expected valid token to pass, got %v", err)
+	}
+
+	_, err = healthpb.NewHealthClient(ts.GRPCClientConnWithToken(ctx, "wrong")).Check(ctx, &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated, got %v", err)
+	}
+}
@@ -0,0 +1,159 @@
+// Package dedup defines the storage used by grpckit.DeduplicateWebhooks to
+// remember which webhook events have already been processed.
+//
+// MemoryStore remembers events in process memory, which is enough for a
+// single instance. Implement Store on top of Redis to deduplicate across
+// instances and restarts.
+//
+// Example Redis adapter (using go-redis v9):
+//
+//	type RedisStore struct {
+//	    client *redis.Client
+//	}
+//
+//	func (s *RedisStore) Claim(ctx context.Context, key string, ttl time.Duration) (dedup.State, error) {
+//	    ok, err := s.client.SetNX(ctx, key, "pending", ttl).Result()
+//	    if err != nil || ok {
+//	        return dedup.Claimed, err
+//	    }
+//	    value, err := s.client.Get(ctx, key).Result()
+//	    if errors.Is(err, redis.Nil) {
+//	        return dedup.Pending, nil // expired in between; let the sender retry
+//	    }
+//	    if value == "done" {
+//	        return dedup.Completed, err
+//	    }
+//	    return dedup.Pending, err
+//	}
+//
+//	func (s *RedisStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+//	    return s.client.Set(ctx, key, "done", ttl).Err()
+//	}
+//
+//	func (s *RedisStore) Release(ctx context.Context, key string) error {
+//	    return s.client.Del(ctx, key).Err()
+//	}
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// State is the state of a key when it is claimed.
+type State int
+
+const (
+	// Claimed means the key was free and is now claimed by the caller.
+	Claimed State = iota
+	// Pending means the key is claimed by a call that has not completed.
+	Pending
+	// Completed means the key was claimed by a call that completed.
+	Completed
+)
+
+// Store records claimed event keys. Implementations must be safe for
+// concurrent use; Claim must be atomic across all instances sharing the store.
+type Store interface {
+	// Claim records key as pending for the given time to live if it is not
+	// already claimed, and returns the state of key before the call:
+	// Claimed if this call claimed it, otherwise Pending or Completed.
+	Claim(ctx context.Context, key string, ttl time.Duration) (State, error)
+
+	// Complete marks a claimed key as completed for the given time to live.
+	Complete(ctx context.Context, key string, ttl time.Duration) error
+
+	// Release removes a claim so the event can be processed again.
+	// Releasing a missing key is not an error.
+	Release(ctx context.Context, key string) error
+}
+
+// MemoryStore is an in-memory Store for single-instance deployments and tests.
+type MemoryStore struct {
+	mu      sync.Mutex
+	claims  map[string]claim
+	sweepAt int
+	now     func() time.Time
+}
+
+// claim is a claimed key.
+type claim struct {
+	expires time.Time
+	done    bool
+}
+
+// minSweep is the number of claims below which expired ones are not swept.
+const minSweep = 1024
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		claims:  make(map[string]claim),
+		sweepAt: minSweep,
+		now:     time.Now,
+	}
+}
+
+// SetClock sets the function returning the current time, which decides
+// when claims expire, e.g. to a fake clock's Now in tests. Call it before
+// using the store.
+func (m *MemoryStore) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// Claim records key as pending unless it is already claimed.
+func (m *MemoryStore) Claim(ctx context.Context, key string, ttl time.Duration) (State, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.claims[key]; ok && now.Before(c.expires) {
+		if c.done {
+			return Completed, nil
+		}
+		return Pending, nil
+	}
+	m.claims[key] = claim{expires: now.Add(ttl)}
+	m.sweep(now)
+	return Claimed, nil
+}
+
+// Complete marks key as completed.
+func (m *MemoryStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.claims[key] = claim{expires: now.Add(ttl), done: true}
+	m.sweep(now)
+	return nil
+}
+
+// Release removes the claim on key.
+func (m *MemoryStore) Release(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.claims, key)
+	return nil
+}
+
+// sweep removes expired claims once the map has grown past sweepAt, so
+// memory stays proportional to the number of live claims.
+func (m *MemoryStore) sweep(now time.Time) {
+	if len(m.claims) < m.sweepAt {
+		return
+	}
+	for key, c := range m.claims {
+		if !now.Before(c.expires) {
+			delete(m.claims, key)
+		}
+	}
+	m.sweepAt = 2 * len(m.claims)
+	if m.sweepAt < minSweep {
+		m.sweepAt = minSweep
+	}
+}
@@ -0,0 +1,79 @@
+package dedup
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_Claim(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	if state, err := m.Claim(ctx, "evt_1", time.Hour); state != Claimed || err != nil {
+		t.Fatalf("expected first claim to succeed, got %v (%v)", state, err)
+	}
+	if state, _ := m.Claim(ctx, "evt_1", time.Hour); state != Pending {
+		t.Errorf("expected second claim to find the key pending, got %v", state)
+	}
+	if state, _ := m.Claim(ctx, "evt_2", time.Hour); state != Claimed {
+		t.Error("expected claim of another key to succeed")
+	}
+
+	now = now.Add(time.Hour)
+	if state, _ := m.Claim(ctx, "evt_1", time.Hour); state != Claimed {
+		t.Error("expected claim to succeed after expiry")
+	}
+}
+
+func TestMemoryStore_Release(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	_, _ = m.Claim(ctx, "evt_1", time.Hour)
+	if err := m.Release(ctx, "evt_1"); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	if state, _ := m.Claim(ctx, "evt_1", time.Hour); state != Claimed {
+		t.Error("expected claim to succeed after release")
+	}
+	if err := m.Release(ctx, "missing"); err != nil {
+		t.Errorf("expected releasing a missing key to succeed, got %v", err)
+	}
+}
+
+func TestMemoryStore_Complete(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	_, _ = m.Claim(ctx, "evt_1", time.Minute)
+	if err := m.Complete(ctx, "evt_1", time.Hour); err != nil {
+		t.Fatalf("Complete failed: %v", err)
+	}
+	now = now.Add(30 * time.Minute)
+	if state, _ := m.Claim(ctx, "evt_1", time.Minute); state != Completed {
+		t.Errorf("expected completed key to outlive the claim TTL, got %v", state)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < minSweep-1; i++ {
+		_, _ = m.Claim(ctx, "old-"+strconv.Itoa(i), time.Second)
+	}
+	now = now.Add(time.Minute)
+	_, _ = m.Claim(ctx, "new", time.Minute)
+
+	if n := len(m.claims); n != 1 {
+		t.Errorf("expected expired claims to be swept, got %d", n)
+	}
+}
@@ -0,0 +1,105 @@
+package grpckit
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// deprecatedEndpoint holds the deprecation metadata for a pattern.
+type deprecatedEndpoint struct {
+	pattern string
+	paths   *pathmatch.Matcher
+	sunset  time.Time
+	link    string
+}
+
+// WithDeprecatedEndpoint marks the paths matching pattern as deprecated.
+// Responses carry a "Deprecation: true" header, a Sunset header (RFC 8594)
+// with sunsetDate unless it is zero, and a Link header pointing to link
+// (e.g. a migration guide) unless it is empty. Patterns support the same
+// globs as WithProtectedEndpoints; the first matching pattern wins.
+//
+// Every call to a deprecated endpoint is logged at warn level with the
+// principal (see ContextWithPrincipal), user agent and request ID, and with
+// WithMetrics counted in grpckit_deprecated_requests_total{pattern}, so you
+// can tell who still uses an endpoint and when it is safe to remove.
+//
+// Example:
+//
+//	grpckit.WithDeprecatedEndpoint("/api/v1/orders/**",
+//	    time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC),
+//	    "https://docs.example.com/migrate-to-v2")
+//
+// Example output:
+//
+//	level=WARN msg="deprecated endpoint" component=http method=GET path=/api/v1/orders/42 pattern=/api/v1/orders/** sunset=2026-06-30 principal=user-42 user_agent=orders-cli/1.2 request_id=4bf92f35
+func WithDeprecatedEndpoint(pattern string, sunsetDate time.Time, link string) Option {
+	return func(c *serverConfig) {
+		c.deprecatedEndpoints = append(c.deprecatedEndpoints, deprecatedEndpoint{
+			pattern: pattern,
+			paths:   c.compilePatterns("WithDeprecatedEndpoint", []string{pattern}),
+			sunset:  sunsetDate,
+			link:    link,
+		})
+	}
+}
+
+// deprecationFor returns the deprecation metadata that applies to a path.
+func deprecationFor(cfg *serverConfig, urlPath string) (deprecatedEndpoint, bool) {
+	for _, d := range cfg.deprecatedEndpoints {
+		if d.paths.Match(urlPath) {
+			return d, true
+		}
+	}
+	return deprecatedEndpoint{}, false
+}
+
+// deprecationMiddleware sets deprecation headers and reports usage of
+// deprecated endpoints.
+func deprecationMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, ok := deprecationFor(s.cfg, r.URL.Path)
+		if !ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		h := w.Header()
+		h.Set("Deprecation", "true")
+		if !d.sunset.IsZero() {
+			h.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.link != "" {
+			h.Add("Link", "<"+d.link+`>; rel="deprecation"`)
+		}
+
+		if s.metrics != nil {
+			s.metrics.deprecatedRequests.WithLabelValues(d.pattern).Inc()
+		}
+		if s.logger.Enabled(r.Context(), slog.LevelWarn) {
+			requestID := requestIDFromContext(r.Context())
+			if requestID == "" {
+				requestID = r.Header.Get(requestIDHeader)
+			}
+			attrs := []slog.Attr{
+				slog.String("component", "http"),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.String("pattern", d.pattern),
+			}
+			if !d.sunset.IsZero() {
+				attrs = append(attrs, slog.String("sunset", d.sunset.UTC().Format(time.DateOnly)))
+			}
+			attrs = append(attrs,
+				slog.String("principal", PrincipalFromContext(r.Context())),
+				slog.String("user_agent", r.UserAgent()),
+				slog.String("request_id", requestID))
+			s.logger.LogAttrs(r.Context(), slog.LevelWarn, "deprecated endpoint", attrs...)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
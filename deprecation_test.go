@@ -0,0 +1,88 @@
+package grpckit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestDeprecationMiddleware(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	sunset := time.Date(2026, 6, 30, 0, 0, 0, 0, time.UTC)
+	s := newSlowTestServer(t,
+		WithMetrics(),
+		WithDeprecatedEndpoint("/api/v1/orders/**", sunset, "https://docs.example.com/migrate"),
+	)
+
+	handler := deprecationMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/orders/42", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	req.Header.Set("User-Agent", "orders-cli/1.2")
+	req = req.WithContext(ContextWithPrincipal(req.Context(), "user-42"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Deprecation"); got != "true" {
+		t.Errorf("expected Deprecation: true, got %q", got)
+	}
+	if got := rec.Header().Get("Sunset"); got != "Tue, 30 Jun 2026 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header %q", got)
+	}
+	if got := rec.Header().Get("Link"); got != `<https://docs.example.com/migrate>; rel="deprecation"` {
+		t.Errorf("unexpected Link header %q", got)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`msg="deprecated endpoint" component=http`, "path=/api/v1/orders/42", "pattern=/api/v1/orders/**", "sunset=2026-06-30", "principal=user-42", "orders-cli/1.2", "request_id=req-1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log line, got %q", want, out)
+		}
+	}
+	if v := testutil.ToFloat64(s.metrics.deprecatedRequests.WithLabelValues("/api/v1/orders/**")); v != 1 {
+		t.Errorf("expected deprecated request to be counted, got %v", v)
+	}
+}
+
+func TestDeprecationMiddleware_NotDeprecated(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithDeprecatedEndpoint("/api/v1/legacy", time.Time{}, ""))
+
+	handler := deprecationMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v2/items", nil))
+	if rec.Header().Get("Deprecation") != "" || buf.Len() != 0 {
+		t.Errorf("expected no deprecation for other paths, got headers %v log %q", rec.Header(), buf.String())
+	}
+
+	// Zero sunset and empty link omit the optional headers
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/legacy", nil))
+	if rec.Header().Get("Deprecation") != "true" || rec.Header().Get("Sunset") != "" || rec.Header().Get("Link") != "" {
+		t.Errorf("unexpected headers %v", rec.Header())
+	}
+}
+
+func TestDeprecatedEndpoint_ThroughAuth(t *testing.T) {
+	captureLog(t)
+	s := newSlowTestServer(t,
+		WithAuth(MockAuthFunc("token", "user")),
+		WithDeprecatedEndpoint("/api/v1/*", time.Time{}, ""),
+	)
+	handler := s.buildHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("Deprecation") != "true" {
+		t.Errorf("expected deprecated response, got %d %v", rec.Code, rec.Header())
+	}
+}
@@ -0,0 +1,136 @@
+package grpckit
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// RegisterHTTPHandler registers a custom HTTP handler, like WithHTTPHandler,
+// on a server that has already been created. It may be called before Start
+// or while the server is running, e.g. by plugins loaded at runtime; new
+// handlers serve requests immediately and go through the global middleware
+// chain. A pattern that conflicts with an existing route returns
+// ErrInvalidConfig.
+//
+// Example:
+//
+//	if err := server.RegisterHTTPHandler("/plugins/reports/", plugin.Handler()); err != nil {
+//	    log.Printf("plugin not loaded: %v", err)
+//	}
+func (s *Server) RegisterHTTPHandler(pattern string, handler http.Handler) error {
+	if pattern == "" || handler == nil {
+		return fmt.Errorf("%w: RegisterHTTPHandler: empty pattern or nil handler for %q", ErrInvalidConfig, pattern)
+	}
+
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+
+	for _, h := range s.cfg.httpHandlers {
+		if h.pattern == pattern {
+			return fmt.Errorf("%w: HTTP handler for %q already registered", ErrInvalidConfig, pattern)
+		}
+	}
+	reg := httpHandlerRegistration{pattern: pattern, handler: handler}
+	if s.mux != nil {
+		if err := checkAdminOverlap(s.cfg, pattern); err != nil {
+			return err
+		}
+		if err := handleSafely(s.mux, pattern, s.withMarshalers(handler)); err != nil {
+			return err
+		}
+		s.routes = append(s.routes, pattern)
+	} else if errs := checkHTTPHandlers(s.cfg, append(slices.Clip(s.cfg.httpHandlers), reg)); len(errs) > 0 {
+		return errs[0]
+	}
+	s.cfg.httpHandlers = append(s.cfg.httpHandlers, reg)
+	return nil
+}
+
+// checkHTTPHandlers registers the built-in endpoints and handlers on a
+// scratch mux and returns an error for each pattern http.ServeMux would
+// panic on when the server builds its handler: duplicates, patterns taken by
+// a built-in endpoint and otherwise conflicting or malformed patterns. Built-in
+// endpoints conflicting with each other, e.g. metrics and health checks on
+// the same path, are reported too.
+func checkHTTPHandlers(cfg *serverConfig, handlers []httpHandlerRegistration) []error {
+	mux := http.NewServeMux()
+	owners := make(map[string]string)
+	var errs []error
+	for _, r := range builtinRoutes(cfg) {
+		if owner, ok := owners[r.pattern]; ok {
+			errs = append(errs, fmt.Errorf("%w: %s path %q is already used by %s", ErrInvalidConfig, r.owner, r.pattern, owner))
+			continue
+		}
+		if err := checkAdminOverlap(cfg, r.pattern); err != nil {
+			errs = append(errs, fmt.Errorf("%s path: %w", r.owner, err))
+			continue
+		}
+		if err := handleSafely(mux, r.pattern, http.NotFoundHandler()); err != nil {
+			errs = append(errs, fmt.Errorf("%s path %q: %w", r.owner, r.pattern, err))
+			continue
+		}
+		owners[r.pattern] = r.owner
+	}
+
+	for _, h := range handlers {
+		if owner, ok := owners[h.pattern]; ok {
+			errs = append(errs, fmt.Errorf("%w: HTTP handler pattern %q is already registered by %s", ErrInvalidConfig, h.pattern, owner))
+			continue
+		}
+		if err := checkAdminOverlap(cfg, h.pattern); err != nil {
+			errs = append(errs, fmt.Errorf("HTTP handler: %w", err))
+			continue
+		}
+		if err := handleSafely(mux, h.pattern, http.NotFoundHandler()); err != nil {
+			errs = append(errs, fmt.Errorf("HTTP handler pattern %q: %w", h.pattern, err))
+			continue
+		}
+		owners[h.pattern] = "another WithHTTPHandler"
+		if h.owner != "" {
+			owners[h.pattern] = h.owner
+		}
+	}
+	return errs
+}
+
+// handleSafely registers a handler on mux, returning an error instead of
+// panicking on invalid or conflicting patterns.
+func handleSafely(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%w: %v", ErrInvalidConfig, p)
+		}
+	}()
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// RegisterGRPCService registers a gRPC service, like WithGRPCService, on a
+// server that has not been started yet. gRPC does not support adding
+// services to a running server, so calling it after Start returns
+// ErrServerStarted. With WithGRPCHealthService, the new services are
+// reported as SERVING.
+//
+// REST routes for the service still need a WithRESTService registrar.
+func (s *Server) RegisterGRPCService(registrar ServiceRegistrar) error {
+	if registrar == nil {
+		return fmt.Errorf("%w: RegisterGRPCService: nil registrar", ErrInvalidConfig)
+	}
+	if s.started.Load() {
+		return ErrServerStarted
+	}
+
+	before := s.grpcServer.GetServiceInfo()
+	registrar(s.grpcServer)
+	if s.grpcHealth != nil {
+		for name := range s.grpcServer.GetServiceInfo() {
+			if _, ok := before[name]; !ok {
+				s.grpcHealth.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+			}
+		}
+	}
+	return nil
+}
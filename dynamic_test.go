@@ -0,0 +1,145 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestRegisterHTTPHandler_BeforeStart(t *testing.T) {
+	s := newSlowTestServer(t)
+	if err := s.RegisterHTTPHandler("/plugin", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("plugin"))
+	})); err != nil {
+		t.Fatalf("RegisterHTTPHandler failed: %v", err)
+	}
+
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plugin", nil))
+	if rec.Body.String() != "plugin" {
+		t.Errorf("expected plugin handler, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRegisterHTTPHandler_AfterBuild(t *testing.T) {
+	s := newSlowTestServer(t, WithHTTPHandlerFunc("/static", func(w http.ResponseWriter, r *http.Request) {}))
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	// Register while requests are being served
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		pattern := fmt.Sprintf("/plugins/%d", i)
+		go func() {
+			defer wg.Done()
+			if err := s.RegisterHTTPHandler(pattern, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(pattern))
+			})); err != nil {
+				t.Errorf("RegisterHTTPHandler(%s) failed: %v", pattern, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, pattern, nil))
+		}()
+	}
+	wg.Wait()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/plugins/7", nil))
+	if rec.Body.String() != "/plugins/7" {
+		t.Errorf("expected dynamically registered handler, got %d %q", rec.Code, rec.Body.String())
+	}
+	if routes := s.adminRoutes().(map[string]any)["http"].([]string); len(routes) != 11 {
+		t.Errorf("expected 11 routes, got %v", routes)
+	}
+}
+
+func TestRegisterHTTPHandler_Conflicts(t *testing.T) {
+	s := newSlowTestServer(t, WithHealthCheck(), WithHTTPHandlerFunc("/static", func(w http.ResponseWriter, r *http.Request) {}))
+	noop := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	if err := s.RegisterHTTPHandler("/static", noop); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected duplicate to be rejected, got %v", err)
+	}
+	if err := s.RegisterHTTPHandler("", noop); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected empty pattern to be rejected, got %v", err)
+	}
+	if err := s.RegisterHTTPHandler("/readyz", noop); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected conflict with built-in route to be rejected before build, got %v", err)
+	}
+
+	s.buildHTTPHandler(http.NotFoundHandler())
+	if err := s.RegisterHTTPHandler("/healthz", noop); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected conflict with built-in route to be rejected, got %v", err)
+	}
+}
+
+func TestNew_HTTPHandlerConflicts(t *testing.T) {
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"duplicate", []Option{WithHTTPHandlerFunc("/webhook", noop), WithHTTPHandlerFunc("/webhook", noop)}, `"/webhook" is already registered by another WithHTTPHandler`},
+		{"metrics", []Option{WithMetrics(), WithHTTPHandlerFunc("/metrics", noop)}, `"/metrics" is already registered by WithMetrics`},
+		{"swagger", []Option{WithSwaggerFile("api.json"), WithHTTPHandlerFunc("/swagger/", noop)}, `"/swagger/" is already registered by WithSwagger`},
+		{"catch-all", []Option{WithHTTPHandlerFunc("/", noop)}, "grpc-gateway catch-all"},
+		{"metrics on a health path", []Option{WithHealthPaths("/metrics", "/ready"), WithMetrics()}, `WithMetrics path "/metrics" is already used by WithHealthCheck`},
+		{"conflicting wildcards", []Option{WithHTTPHandlerFunc("/a/{x}", noop), WithHTTPHandlerFunc("/{y}/b", noop)}, `pattern "/{y}/b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithGRPCService(func(s grpc.ServiceRegistrar) {})}, tt.opts...)
+			_, err := New(opts...)
+			if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("expected ErrInvalidConfig mentioning %q, got %v", tt.want, err)
+			}
+		})
+	}
+
+	// Handlers on distinct paths, including below built-in ones, are fine
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	if _, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithMetrics(), WithHealthCheck(),
+		WithHTTPHandlerFunc("/metrics/custom", noop), WithHTTPHandlerFunc("/webhook", noop)); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestRegisterGRPCService(t *testing.T) {
+	s := newSlowTestServer(t, WithGRPCHealthService())
+	register := func(r grpc.ServiceRegistrar) {
+		itempb.RegisterItemServiceServer(r, itempb.UnimplementedItemServiceServer{})
+	}
+
+	if err := s.RegisterGRPCService(register); err != nil {
+		t.Fatalf("RegisterGRPCService failed: %v", err)
+	}
+	if _, ok := s.grpcServer.GetServiceInfo()["item.v1.ItemService"]; !ok {
+		t.Error("expected service to be registered")
+	}
+	resp, err := s.grpcHealth.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "item.v1.ItemService"})
+	if err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected new service to be SERVING, got %v (%v)", resp, err)
+	}
+
+	if err := s.RegisterGRPCService(nil); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected nil registrar to be rejected, got %v", err)
+	}
+	s.started.Store(true)
+	if err := s.RegisterGRPCService(register); !errors.Is(err, ErrServerStarted) {
+		t.Errorf("expected ErrServerStarted after Start, got %v", err)
+	}
+}
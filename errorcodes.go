@@ -0,0 +1,162 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// registeredError maps a domain error to the status returned to clients.
+type registeredError struct {
+	err     error
+	code    codes.Code
+	message string
+}
+
+// errorRegistry holds the registered domain errors, most recent first.
+var errorRegistry struct {
+	mu      sync.RWMutex
+	entries []registeredError
+}
+
+func init() {
+	RegisterError(ErrNotFound, codes.NotFound, "not found")
+	RegisterError(ErrUnauthorized, codes.Unauthenticated, ErrUnauthorized.Error())
+	RegisterError(ErrForbidden, codes.PermissionDenied, ErrForbidden.Error())
+	RegisterError(ErrServerShuttingDown, codes.Unavailable, ErrServerShuttingDown.Error())
+}
+
+// RegisterError maps a domain error to a gRPC code and public message.
+// Handlers returning err (or an error wrapping it) reply with that status,
+// which the gateway translates to the matching HTTP status (NotFound → 404).
+// An empty message keeps the error text. Registering an error again replaces
+// its mapping.
+//
+// Example:
+//
+//	var ErrOutOfStock = errors.New("inventory: out of stock")
+//
+//	func init() {
+//	    grpckit.RegisterError(ErrOutOfStock, codes.FailedPrecondition, "item out of stock")
+//	}
+func RegisterError(err error, code codes.Code, message string) {
+	errorRegistry.mu.Lock()
+	defer errorRegistry.mu.Unlock()
+
+	entries := make([]registeredError, 0, len(errorRegistry.entries)+1)
+	entries = append(entries, registeredError{err: err, code: code, message: message})
+	for _, e := range errorRegistry.entries {
+		if e.err != err {
+			entries = append(entries, e)
+		}
+	}
+	errorRegistry.entries = entries
+}
+
+// ErrorToStatus converts err to a gRPC status error. Errors that already carry
+// a status are returned unchanged; registered errors get their code and
+// public message; context errors map to Canceled and DeadlineExceeded.
+// Other errors are returned unchanged and reach clients as codes.Unknown.
+func ErrorToStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(interface{ GRPCStatus() *status.Status }); ok {
+		return err
+	}
+
+	errorRegistry.mu.RLock()
+	entries := errorRegistry.entries
+	errorRegistry.mu.RUnlock()
+
+	for _, e := range entries {
+		if errors.Is(err, e.err) {
+			message := e.message
+			if message == "" {
+				message = err.Error()
+			}
+			return status.Error(e.code, message)
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return err
+}
+
+// recoverToStatus turns a recovered panic into an Internal status, logging
+// the panic value and stack trace. The panic details are never sent to clients.
+func recoverToStatus(ctx context.Context, method string, p any) error {
+	loggerFrom(ctx).Error("handler panicked", "component", "grpc",
+		"method", method, "panic", fmt.Sprint(p), "stack", string(debug.Stack()))
+	return status.Error(codes.Internal, "internal error")
+}
+
+// errorUnaryInterceptor converts handler errors with ErrorToStatus and
+// recovers panics as codes.Internal.
+func errorUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (resp interface{}, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			resp, err = nil, recoverToStatus(ctx, info.FullMethod, p)
+		}
+	}()
+
+	resp, err = handler(ctx, req)
+	return resp, ErrorToStatus(err)
+}
+
+// errorStreamInterceptor is the stream counterpart of errorUnaryInterceptor.
+func errorStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = recoverToStatus(ss.Context(), info.FullMethod, p)
+		}
+	}()
+
+	return ErrorToStatus(handler(srv, ss))
+}
+
+// gatewayErrorHandler converts errors with ErrorToStatus before writing the
+// REST error response, so errors raised on the gateway side (e.g. by
+// in-process handlers or dial interceptors) get the same HTTP status as
+// their gRPC counterparts.
+func gatewayErrorHandler(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+	// Restore per-field details dropped by the generated decode error handling
+	if verr := requestValidationError(r); verr != nil && status.Code(err) == codes.InvalidArgument {
+		err = verr
+	}
+	err = ErrorToStatus(err)
+	// Quota and request cost headers, and Retry-After for calls rejected by WithPrincipalQuota
+	// or an open circuit breaker
+	setQuotaHeaders(ctx, w)
+	setRetryAfter(w, err)
+	setRequestCostHeader(ctx, w)
+	runtime.DefaultHTTPErrorHandler(ctx, mux, m, w, r, err)
+}
+
+// gatewayStreamErrorHandler is the streaming counterpart of gatewayErrorHandler.
+func gatewayStreamErrorHandler(ctx context.Context, err error) *status.Status {
+	return status.Convert(ErrorToStatus(err))
+}
@@ -0,0 +1,134 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestErrorToStatus(t *testing.T) {
+	errDomain := errors.New("inventory: out of stock")
+	RegisterError(errDomain, codes.FailedPrecondition, "item out of stock")
+
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"builtin not found", ErrNotFound, codes.NotFound, "not found"},
+		{"wrapped registered", fmt.Errorf("get item 42: %w", errDomain), codes.FailedPrecondition, "item out of stock"},
+		{"existing status", status.Error(codes.AlreadyExists, "dup"), codes.AlreadyExists, "dup"},
+		{"deadline", context.DeadlineExceeded, codes.DeadlineExceeded, context.DeadlineExceeded.Error()},
+		{"unregistered", errors.New("boom"), codes.Unknown, "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(ErrorToStatus(tt.err))
+			if st.Code() != tt.code {
+				t.Errorf("expected code %v, got %v", tt.code, st.Code())
+			}
+			if st.Message() != tt.message {
+				t.Errorf("expected message %q, got %q", tt.message, st.Message())
+			}
+		})
+	}
+
+	if ErrorToStatus(nil) != nil {
+		t.Error("expected nil for nil error")
+	}
+}
+
+func TestRegisterError_Replaces(t *testing.T) {
+	errDomain := errors.New("quota")
+	RegisterError(errDomain, codes.Internal, "")
+	RegisterError(errDomain, codes.ResourceExhausted, "")
+
+	st := status.Convert(ErrorToStatus(errDomain))
+	if st.Code() != codes.ResourceExhausted {
+		t.Errorf("expected latest registration to win, got %v", st.Code())
+	}
+	if st.Message() != "quota" {
+		t.Errorf("expected error text as message, got %q", st.Message())
+	}
+}
+
+func TestErrorUnaryInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+
+	_, err := errorUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, ErrNotFound
+	})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", err)
+	}
+
+	buf := captureLog(t)
+	_, err = errorUnaryInterceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		panic("nil map")
+	})
+	st := status.Convert(err)
+	if st.Code() != codes.Internal || st.Message() != "internal error" {
+		t.Errorf("expected generic Internal status for panic, got %v", err)
+	}
+	if !strings.Contains(buf.String(), `method=/test.Service/Get panic="nil map"`) {
+		t.Errorf("expected panic to be logged, got %q", buf.String())
+	}
+}
+
+func TestErrorStreamInterceptor(t *testing.T) {
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"}
+
+	err := errorStreamInterceptor(nil, nil, info, func(srv interface{}, ss grpc.ServerStream) error {
+		return ErrForbidden
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+
+	captureLog(t)
+	err = errorStreamInterceptor(nil, &contextServerStream{ctx: context.Background()}, info, func(srv interface{}, ss grpc.ServerStream) error {
+		panic("boom")
+	})
+	if status.Code(err) != codes.Internal {
+		t.Errorf("expected Internal for panic, got %v", err)
+	}
+}
+
+func TestGatewayErrorHandler(t *testing.T) {
+	mux := runtime.NewServeMux()
+	req := httptest.NewRequest(http.MethodGet, "/v1/items/42", nil)
+
+	tests := []struct {
+		err    error
+		status int
+	}{
+		{ErrNotFound, http.StatusNotFound},
+		{ErrForbidden, http.StatusForbidden},
+		{ErrUnauthorized, http.StatusUnauthorized},
+		{status.Error(codes.InvalidArgument, "bad id"), http.StatusBadRequest},
+		{errors.New("boom"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		gatewayErrorHandler(context.Background(), mux, &runtime.JSONPb{}, rec, req, tt.err)
+		if rec.Code != tt.status {
+			t.Errorf("%v: expected status %d, got %d", tt.err, tt.status, rec.Code)
+		}
+	}
+
+	if st := gatewayStreamErrorHandler(context.Background(), ErrNotFound); st.Code() != codes.NotFound {
+		t.Errorf("expected NotFound for stream errors, got %v", st.Code())
+	}
+}
@@ -16,6 +16,15 @@ var (
 	// ErrServiceNotRegistered is returned when no services are registered.
 	ErrServiceNotRegistered = errors.New("no services registered")
 
+	// ErrServerStarted is returned when an operation requires a server that
+	// has not been started yet.
+	ErrServerStarted = errors.New("server already started")
+
 	// ErrNotFound is returned when a resource is not found.
 	ErrNotFound = errors.New("not found")
+
+	// ErrServerShuttingDown is the cause of contexts returned by
+	// ShutdownContext once the server starts draining. Handlers returning it
+	// reply with codes.Unavailable, so clients retry on another instance.
+	ErrServerShuttingDown = errors.New("server shutting down")
 )
@@ -0,0 +1,119 @@
+package grpckit
+
+import (
+	"fmt"
+	"time"
+)
+
+// Event is a typed server lifecycle event delivered to listeners registered
+// with WithEventListener. Use a type switch to handle specific events.
+type Event interface {
+	// EventTime returns when the event occurred.
+	EventTime() time.Time
+}
+
+// ServerStarting is emitted when Start is called, before any listener is bound.
+type ServerStarting struct {
+	Time     time.Time
+	GRPCPort int
+	HTTPPort int
+}
+
+// ListenerBound is emitted once a network listener is accepting connections.
+type ListenerBound struct {
+	Time time.Time
+	// Protocol is "grpc", "http" or "grpc+http" (combined mode).
+	Protocol string
+	// Addr is the bound address (resolves ":0" to the actual port).
+	Addr string
+}
+
+// ShutdownInitiated is emitted when graceful shutdown begins.
+type ShutdownInitiated struct {
+	Time time.Time
+	// Reason describes what triggered the shutdown (e.g. "signal: terminated", "admin drain").
+	Reason string
+}
+
+// ShutdownComplete is emitted after all servers have stopped.
+type ShutdownComplete struct {
+	Time     time.Time
+	Duration time.Duration
+}
+
+// ConfigReloaded is emitted when configuration changes at runtime.
+type ConfigReloaded struct {
+	Time time.Time
+	// Setting is the name of the changed setting (e.g. "log_level", "maintenance").
+	Setting string
+	// Value is the new value.
+	Value string
+}
+
+// AuthFailuresExceeded is emitted when a client fails authentication more
+// often than allowed by WithAuthFailureTracking.
+type AuthFailuresExceeded struct {
+	Time time.Time
+	// ClientIP is the address of the client.
+	ClientIP string
+	// Failures is the number of failed authentications within Window.
+	Failures int
+	Window   time.Duration
+}
+
+// EventTime returns when the event occurred.
+func (e ServerStarting) EventTime() time.Time { return e.Time }
+
+// EventTime returns when the event occurred.
+func (e ListenerBound) EventTime() time.Time { return e.Time }
+
+// EventTime returns when the event occurred.
+func (e ShutdownInitiated) EventTime() time.Time { return e.Time }
+
+// EventTime returns when the event occurred.
+func (e ShutdownComplete) EventTime() time.Time { return e.Time }
+
+// EventTime returns when the event occurred.
+func (e ConfigReloaded) EventTime() time.Time { return e.Time }
+
+// EventTime returns when the event occurred.
+func (e AuthFailuresExceeded) EventTime() time.Time { return e.Time }
+
+// EventListener receives server lifecycle events.
+// Listeners are called synchronously and should return quickly.
+type EventListener func(Event)
+
+// WithEventListener registers a listener for server lifecycle events.
+// Multiple listeners can be registered; they are called in registration order.
+//
+// Example:
+//
+//	grpckit.WithEventListener(func(e grpckit.Event) {
+//	    switch ev := e.(type) {
+//	    case grpckit.ListenerBound:
+//	        registry.Register(serviceName, ev.Addr)
+//	    case grpckit.ShutdownInitiated:
+//	        registry.Deregister(serviceName)
+//	    }
+//	})
+func WithEventListener(listener EventListener) Option {
+	return func(c *serverConfig) {
+		c.eventListeners = append(c.eventListeners, listener)
+	}
+}
+
+// emit delivers an event to all registered listeners.
+// A panicking listener is logged and does not affect other listeners.
+func (s *Server) emit(e Event) {
+	for _, listener := range s.cfg.eventListeners {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					s.logger.Error("event listener panicked", "component", "events",
+						"event", fmt.Sprintf("%T", e), "panic", fmt.Sprint(r))
+				}
+			}()
+			listener(e)
+		}()
+	}
+}
@@ -0,0 +1,92 @@
+package grpckit
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+func TestWithEventListener(t *testing.T) {
+	cfg := newServerConfig()
+	WithEventListener(func(Event) {})(cfg)
+	WithEventListener(func(Event) {})(cfg)
+
+	if len(cfg.eventListeners) != 2 {
+		t.Errorf("expected 2 listeners, got %d", len(cfg.eventListeners))
+	}
+}
+
+func TestEmit_RecoversPanic(t *testing.T) {
+	called := false
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithEventListener(func(Event) { panic("boom") }),
+		WithEventListener(func(Event) { called = true }),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	s.emit(ConfigReloaded{Time: time.Now(), Setting: "log_level", Value: "debug"})
+
+	if !called {
+		t.Error("expected second listener to be called after first panicked")
+	}
+}
+
+func TestServerLifecycleEvents(t *testing.T) {
+	var mu sync.Mutex
+	var events []Event
+	bound := make(chan ListenerBound, 1)
+
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCPort(0),
+		WithHTTPPort(0),
+		WithEventListener(func(e Event) {
+			mu.Lock()
+			events = append(events, e)
+			mu.Unlock()
+			if lb, ok := e.(ListenerBound); ok {
+				bound <- lb
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+
+	select {
+	case lb := <-bound:
+		if lb.Protocol != "grpc+http" {
+			t.Errorf("expected combined protocol, got %s", lb.Protocol)
+		}
+		if lb.Addr == "" {
+			t.Error("expected bound address")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ListenerBound")
+	}
+
+	s.Shutdown()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if _, ok := events[0].(ServerStarting); !ok {
+		t.Errorf("expected first event ServerStarting, got %T", events[0])
+	}
+	if _, ok := events[len(events)-2].(ShutdownInitiated); !ok {
+		t.Errorf("expected ShutdownInitiated, got %T", events[len(events)-2])
+	}
+	if _, ok := events[len(events)-1].(ShutdownComplete); !ok {
+		t.Errorf("expected last event ShutdownComplete, got %T", events[len(events)-1])
+	}
+}
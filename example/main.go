@@ -76,11 +76,8 @@ func main() {
 
 		// Authentication (optional - comment out to disable)
 		grpckit.WithAuth(AuthFunc),
+		grpckit.WithPublicBuiltinEndpoints(), // health, metrics and Swagger
 		grpckit.WithPublicEndpoints(
-			"/healthz",
-			"/readyz",
-			"/metrics",
-			"/swagger/*",
 			"/api/v1/items",   // Allow public list
 			"/api/v1/items/*", // Allow public CRUD for demo
 			"/webhook",        // Webhook uses its own auth (signature validation)
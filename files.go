@@ -0,0 +1,220 @@
+package grpckit
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// FileResponse describes a file to be streamed to an HTTP client.
+// Unlike returning []byte inside a proto message, the content is copied
+// directly from Content to the response, so large files are never fully
+// buffered in memory.
+//
+// If Content implements io.ReadSeeker, Range requests are supported and
+// Accept-Ranges, Content-Range and 206 Partial Content are handled automatically.
+// Otherwise the content is streamed with io.Copy and Size (if known) is used
+// as the Content-Length.
+type FileResponse struct {
+	// Name is the filename advertised in the Content-Disposition header.
+	Name string
+
+	// ContentType is the MIME type of the file.
+	// Default: detected from the Name extension, falling back to application/octet-stream
+	ContentType string
+
+	// Content is the file content. If it implements io.Closer it is closed
+	// once the response has been written.
+	Content io.Reader
+
+	// Size is the content length in bytes. Ignored for io.ReadSeeker content.
+	// Use -1 or 0 when unknown (no Content-Length header is sent).
+	Size int64
+
+	// ModTime is used for Last-Modified and conditional requests (optional).
+	ModTime time.Time
+
+	// Inline serves the file with "inline" disposition instead of "attachment",
+	// so browsers display it instead of downloading it.
+	Inline bool
+}
+
+// FileFunc resolves the file to serve for a request.
+// Return ErrNotFound, ErrUnauthorized or ErrForbidden to produce the
+// corresponding HTTP status; any other error results in 500.
+type FileFunc func(r *http.Request) (*FileResponse, error)
+
+// ServeHTTP writes the file to the response, honoring Range requests when possible.
+func (f *FileResponse) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if c, ok := f.Content.(io.Closer); ok {
+		defer c.Close()
+	}
+
+	contentType := f.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(f.Name))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+
+	if f.Name != "" {
+		w.Header().Set("Content-Disposition", contentDisposition(f.Name, f.Inline))
+	}
+
+	// Seekable content: let net/http handle Range, If-Range and conditional headers
+	if rs, ok := f.Content.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, f.Name, f.ModTime, rs)
+		return
+	}
+
+	// Non-seekable content: stream as-is without range support
+	w.Header().Set("Accept-Ranges", "none")
+	if !f.ModTime.IsZero() {
+		w.Header().Set("Last-Modified", f.ModTime.UTC().Format(http.TimeFormat))
+	}
+	if f.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(f.Size, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if r.Method == http.MethodHead || f.Content == nil {
+		return
+	}
+	_, _ = io.Copy(w, f.Content)
+}
+
+// contentDisposition builds a Content-Disposition header value for the filename.
+// Non-ASCII filenames are encoded per RFC 2231.
+func contentDisposition(name string, inline bool) string {
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
+	if v := mime.FormatMediaType(disposition, map[string]string{"filename": filepath.Base(name)}); v != "" {
+		return v
+	}
+	return disposition
+}
+
+// FileHandler returns an http.Handler that serves the file returned by fn.
+// Only GET and HEAD requests are accepted.
+//
+// Example:
+//
+//	grpckit.WithHTTPHandler("/files/", grpckit.FileHandler(func(r *http.Request) (*grpckit.FileResponse, error) {
+//	    f, err := os.Open(filepath.Join(dataDir, path.Base(r.URL.Path)))
+//	    if err != nil {
+//	        return nil, grpckit.ErrNotFound
+//	    }
+//	    info, _ := f.Stat()
+//	    return &grpckit.FileResponse{Name: info.Name(), Content: f, ModTime: info.ModTime()}, nil
+//	}))
+func FileHandler(fn FileFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		file, err := fn(r)
+		if err != nil {
+			writeFileError(w, r, err, fileErrorStatus(err))
+			return
+		}
+		if file == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		file.ServeHTTP(w, r)
+	})
+}
+
+// writeFileError writes err with the given status. Server errors get a
+// generic message, as they may reveal paths or backend details, and are
+// logged instead.
+func writeFileError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	if code >= http.StatusInternalServerError {
+		loggerFrom(r.Context()).Error("file request failed", "component", "files", "path", r.URL.Path, "error", err)
+		http.Error(w, http.StatusText(code), code)
+		return
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// fileErrorStatus maps errors returned by a FileFunc to HTTP status codes,
+// using the same mapping as gRPC handlers (see RegisterError).
+func fileErrorStatus(err error) int {
+	return runtime.HTTPStatusFromCode(status.Code(ErrorToStatus(err)))
+}
+
+// WithFileDownload registers a download endpoint that streams files resolved by fn.
+// This is a convenience wrapper around WithHTTPHandler and FileHandler.
+//
+// Example:
+//
+//	grpckit.WithFileDownload("/downloads/", func(r *http.Request) (*grpckit.FileResponse, error) {
+//	    return &grpckit.FileResponse{Name: "report.csv", Content: openReport()}, nil
+//	})
+func WithFileDownload(pattern string, fn FileFunc) Option {
+	return WithHTTPHandler(pattern, FileHandler(fn))
+}
+
+// downloadFilenameMetadataKey is the gRPC header carrying the filename set by
+// SetDownloadFilename to the gateway.
+const downloadFilenameMetadataKey = "grpckit-download-filename"
+
+// SetDownloadFilename makes the REST response of the current gRPC call a
+// download: the gateway sends "Content-Disposition: attachment" with name,
+// so CSV, XLSX or binary exports are saved under a proper filename without a
+// custom HTTP handler. Call it from the handler before returning the
+// response. gRPC clients only see the header metadata.
+//
+// Example:
+//
+//	func (s *ReportService) Export(ctx context.Context, req *pb.ExportRequest) (*pb.Report, error) {
+//	    if err := grpckit.SetDownloadFilename(ctx, "report-"+req.Month+".xlsx"); err != nil {
+//	        return nil, err
+//	    }
+//	    return s.buildReport(ctx, req)
+//	}
+func SetDownloadFilename(ctx context.Context, name string) error {
+	// Escaped so non-ASCII names survive the HTTP/2 header encoding
+	return grpc.SetHeader(ctx, metadata.Pairs(downloadFilenameMetadataKey, url.PathEscape(name)))
+}
+
+// downloadFilenameResponseOption is the gateway forward-response hook applying
+// SetDownloadFilename. It also removes the internal header the gateway
+// forwarded as Grpc-Metadata-*.
+func downloadFilenameResponseOption(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return nil
+	}
+	values := md.HeaderMD.Get(downloadFilenameMetadataKey)
+	if len(values) == 0 {
+		return nil
+	}
+	w.Header().Del(runtime.MetadataHeaderPrefix + downloadFilenameMetadataKey)
+
+	name, err := url.PathUnescape(values[0])
+	if err != nil || name == "" {
+		return nil
+	}
+	w.Header().Set("Content-Disposition", contentDisposition(name, false))
+	return nil
+}
@@ -0,0 +1,218 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFileResponse_Seekable(t *testing.T) {
+	f := &FileResponse{
+		Name:    "report.csv",
+		Content: strings.NewReader("a,b,c\n1,2,3\n"),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/report", nil)
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "12" {
+		t.Errorf("expected Content-Length 12, got %q", got)
+	}
+	if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+		t.Errorf("expected Accept-Ranges bytes, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename=report.csv` {
+		t.Errorf("unexpected Content-Disposition: %q", got)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/csv") {
+		t.Errorf("expected text/csv content type, got %q", got)
+	}
+}
+
+func TestFileResponse_Range(t *testing.T) {
+	f := &FileResponse{
+		Name:    "data.bin",
+		Content: strings.NewReader("0123456789"),
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/data", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("expected status 206, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "2345" {
+		t.Errorf("expected body '2345', got %q", body)
+	}
+	if got := rec.Header().Get("Content-Range"); got != "bytes 2-5/10" {
+		t.Errorf("unexpected Content-Range: %q", got)
+	}
+}
+
+func TestFileResponse_NonSeekable(t *testing.T) {
+	f := &FileResponse{
+		Name:        "stream.txt",
+		ContentType: "text/plain",
+		Content:     io.NopCloser(strings.NewReader("streamed")),
+		Size:        8,
+		Inline:      true,
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/stream", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	rec := httptest.NewRecorder()
+	f.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "streamed" {
+		t.Errorf("expected full body, got %q", body)
+	}
+	if got := rec.Header().Get("Accept-Ranges"); got != "none" {
+		t.Errorf("expected Accept-Ranges none, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "8" {
+		t.Errorf("expected Content-Length 8, got %q", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "inline; filename=stream.txt" {
+		t.Errorf("unexpected Content-Disposition: %q", got)
+	}
+}
+
+func TestContentDisposition_NonASCII(t *testing.T) {
+	got := contentDisposition("résumé.pdf", false)
+	if !strings.HasPrefix(got, "attachment; filename*=utf-8''") {
+		t.Errorf("expected RFC 2231 encoded filename, got %q", got)
+	}
+}
+
+func TestFileHandler_Errors(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected int
+	}{
+		{ErrNotFound, http.StatusNotFound},
+		{ErrUnauthorized, http.StatusUnauthorized},
+		{ErrForbidden, http.StatusForbidden},
+		{errors.New("open /srv/data/f: permission denied"), http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			buf := captureLog(t)
+			handler := FileHandler(func(r *http.Request) (*FileResponse, error) {
+				return nil, tt.err
+			})
+
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/f", nil))
+
+			if rec.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, rec.Code)
+			}
+			if tt.expected == http.StatusInternalServerError {
+				if strings.Contains(rec.Body.String(), "/srv") || !strings.Contains(buf.String(), "/srv/data/f") {
+					t.Errorf("expected error to be logged, not sent: body %q, log %q", rec.Body.String(), buf.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFileHandler_MethodNotAllowed(t *testing.T) {
+	handler := FileHandler(func(r *http.Request) (*FileResponse, error) {
+		t.Fatal("file func should not be called")
+		return nil, nil
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/f", nil))
+
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status 405, got %d", rec.Code)
+	}
+}
+
+func TestWithFileDownload(t *testing.T) {
+	cfg := newServerConfig()
+	WithFileDownload("/downloads/", func(r *http.Request) (*FileResponse, error) {
+		return &FileResponse{Name: "a.txt", Content: strings.NewReader("a")}, nil
+	})(cfg)
+
+	if len(cfg.httpHandlers) != 1 {
+		t.Fatalf("expected 1 HTTP handler, got %d", len(cfg.httpHandlers))
+	}
+	if cfg.httpHandlers[0].pattern != "/downloads/" {
+		t.Errorf("expected pattern /downloads/, got %s", cfg.httpHandlers[0].pattern)
+	}
+}
+
+// headerStream records headers set with grpc.SetHeader.
+type headerStream struct {
+	grpc.ServerTransportStream
+	header metadata.MD
+}
+
+func (s *headerStream) Method() string { return "/test.Service/Export" }
+
+func (s *headerStream) SetHeader(md metadata.MD) error {
+	s.header = metadata.Join(s.header, md)
+	return nil
+}
+
+func TestSetDownloadFilename(t *testing.T) {
+	stream := &headerStream{}
+	ctx := grpc.NewContextWithServerTransportStream(context.Background(), stream)
+
+	if err := SetDownloadFilename(ctx, "résumé 2024.csv"); err != nil {
+		t.Fatalf("SetDownloadFilename failed: %v", err)
+	}
+
+	// Simulate the gateway: the header arrives as server metadata
+	rec := httptest.NewRecorder()
+	rec.Header().Set(runtime.MetadataHeaderPrefix+downloadFilenameMetadataKey, "forwarded")
+	gwCtx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: stream.header})
+	if err := downloadFilenameResponseOption(gwCtx, rec, nil); err != nil {
+		t.Fatalf("response option failed: %v", err)
+	}
+
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename*=utf-8''r%C3%A9sum%C3%A9%202024.csv` {
+		t.Errorf("unexpected Content-Disposition %q", got)
+	}
+	if got := rec.Header().Get(runtime.MetadataHeaderPrefix + downloadFilenameMetadataKey); got != "" {
+		t.Errorf("expected internal header to be removed, got %q", got)
+	}
+}
+
+func TestDownloadFilenameResponseOption_NotSet(t *testing.T) {
+	rec := httptest.NewRecorder()
+	ctx := runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: metadata.Pairs("x-other", "v")})
+	if err := downloadFilenameResponseOption(ctx, rec, nil); err != nil {
+		t.Fatalf("response option failed: %v", err)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != "" {
+		t.Errorf("expected no Content-Disposition, got %q", got)
+	}
+
+	// Path components are stripped from the filename
+	ctx = runtime.NewServerMetadataContext(context.Background(), runtime.ServerMetadata{HeaderMD: metadata.Pairs(downloadFilenameMetadataKey, "..%2F..%2Fetc%2Fpasswd")})
+	_ = downloadFilenameResponseOption(ctx, rec, nil)
+	if got := rec.Header().Get("Content-Disposition"); got != "attachment; filename=passwd" {
+		t.Errorf("expected base name only, got %q", got)
+	}
+}
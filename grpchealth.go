@@ -0,0 +1,108 @@
+package grpckit
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// healthWatchInterval is how often Watch streams of the overall status run
+// the readiness checks (their results are cached, see CheckCacheTTL).
+const healthWatchInterval = time.Second
+
+// WithGRPCHealthService registers the standard grpc.health.v1.Health service,
+// so gRPC clients and load balancers with client-side health checking can
+// probe the server. Every registered service starts as SERVING; the overall
+// status ("") follows the server's readiness, like /readyz: it is
+// NOT_SERVING while the server is not ready or a readiness check (see
+// WithReadinessCheck) fails. Use Server.SetServiceHealth to mark individual
+// services as degraded.
+//
+// When WithAuth protects all methods, add "/grpc.health.v1.Health/*" to
+// WithPublicEndpoints so unauthenticated probes succeed.
+func WithGRPCHealthService() Option {
+	return func(c *serverConfig) {
+		c.grpcHealth = true
+	}
+}
+
+// registerGRPCHealth registers the health service and marks all services
+// already registered on grpcServer as SERVING. checks returns the readiness
+// checks the overall status depends on.
+func registerGRPCHealth(grpcServer *grpc.Server, checks func() []*healthCheck) *health.Server {
+	hs := health.NewServer()
+	for name := range grpcServer.GetServiceInfo() {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+	healthpb.RegisterHealthServer(grpcServer, &readinessHealthServer{Server: hs, checks: checks})
+	return hs
+}
+
+// readinessHealthServer is the health service, with the overall status
+// also NOT_SERVING while a readiness check fails.
+type readinessHealthServer struct {
+	*health.Server
+	checks func() []*healthCheck
+}
+
+// Check implements healthpb.HealthServer.
+func (h *readinessHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	resp, err := h.Server.Check(ctx, req)
+	if err != nil || req.GetService() != "" || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		return resp, err
+	}
+	if checks := h.checks(); len(checks) > 0 {
+		if _, healthy := runHealthChecks(checks); !healthy {
+			return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+		}
+	}
+	return resp, nil
+}
+
+// Watch implements healthpb.HealthServer. With readiness checks, the
+// overall status is polled, as check results change without notice.
+func (h *readinessHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if req.GetService() != "" || len(h.checks()) == 0 {
+		return h.Server.Watch(req, stream)
+	}
+
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		resp, err := h.Check(stream.Context(), req)
+		if err != nil {
+			return err
+		}
+		if resp.GetStatus() != last {
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+			last = resp.GetStatus()
+		}
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// SetServiceHealth sets the gRPC health status of a single service (e.g.
+// "item.v1.ItemService"), so clients can route away from a degraded service
+// while others on the same server remain available. Requires
+// WithGRPCHealthService.
+//
+// Example:
+//
+//	server.SetServiceHealth("item.v1.ItemService", healthpb.HealthCheckResponse_NOT_SERVING)
+func (s *Server) SetServiceHealth(service string, status healthpb.HealthCheckResponse_ServingStatus) {
+	if s.grpcHealth == nil {
+		s.logger.Warn("SetServiceHealth ignored, enable WithGRPCHealthService", "component", "health", "service", service)
+		return
+	}
+	s.grpcHealth.SetServingStatus(service, status)
+}
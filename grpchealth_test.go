@@ -0,0 +1,138 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// testServiceDesc is a minimal service used to exercise per-service health.
+var testServiceDesc = grpc.ServiceDesc{
+	ServiceName: "test.v1.TestService",
+	HandlerType: (*interface{})(nil),
+}
+
+func newGRPCHealthTestServer(t *testing.T) (*TestServer, healthpb.HealthClient) {
+	t.Helper()
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			s.RegisterService(&testServiceDesc, struct{}{})
+		}),
+		WithGRPCHealthService(),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	return ts, healthpb.NewHealthClient(ts.GRPCClientConn(context.Background()))
+}
+
+func checkHealth(t *testing.T, client healthpb.HealthClient, service string) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+	if err != nil {
+		t.Fatalf("health check for %q failed: %v", service, err)
+	}
+	return resp.Status
+}
+
+func TestGRPCHealth_RegisteredServicesServing(t *testing.T) {
+	ts, client := newGRPCHealthTestServer(t)
+	defer ts.Close()
+
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected overall SERVING, got %s", got)
+	}
+	if got := checkHealth(t, client, "test.v1.TestService"); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected service SERVING, got %s", got)
+	}
+}
+
+func TestServer_SetServiceHealth(t *testing.T) {
+	ts, client := newGRPCHealthTestServer(t)
+	defer ts.Close()
+
+	ts.SetServiceHealth("test.v1.TestService", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	if got := checkHealth(t, client, "test.v1.TestService"); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected service NOT_SERVING, got %s", got)
+	}
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected overall status to stay SERVING, got %s", got)
+	}
+}
+
+func TestServer_SetReady_GRPCHealth(t *testing.T) {
+	ts, client := newGRPCHealthTestServer(t)
+	defer ts.Close()
+
+	ts.SetReady(false)
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected overall NOT_SERVING when not ready, got %s", got)
+	}
+
+	ts.SetReady(true)
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected overall SERVING when ready, got %s", got)
+	}
+}
+
+func TestServer_SetServiceHealth_Disabled(t *testing.T) {
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	// Must not panic without WithGRPCHealthService
+	s.SetServiceHealth("test.v1.TestService", healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func TestGRPCHealth_ReadinessChecks(t *testing.T) {
+	var healthy atomic.Bool
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			s.RegisterService(&testServiceDesc, struct{}{})
+		}),
+		WithGRPCHealthService(),
+		WithReadinessCheck("db", func(ctx context.Context) error {
+			if !healthy.Load() {
+				return errors.New("down")
+			}
+			return nil
+		}, CheckCacheTTL(0)),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+	client := healthpb.NewHealthClient(ts.GRPCClientConn(context.Background()))
+
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected overall NOT_SERVING with a failing check, got %s", got)
+	}
+	if got := checkHealth(t, client, "test.v1.TestService"); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected service to stay SERVING, got %s", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Watch failed: %v", err)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("expected NOT_SERVING, got %v, %v", resp, err)
+	}
+
+	healthy.Store(true)
+	if got := checkHealth(t, client, ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected overall SERVING once the check passes, got %s", got)
+	}
+	if resp, err := stream.Recv(); err != nil || resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected Watch to report SERVING, got %v, %v", resp, err)
+	}
+}
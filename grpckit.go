@@ -18,7 +18,7 @@
 //
 // grpckit supports:
 //   - gRPC and REST (via grpc-gateway) on separate or same port
-//   - Single port mode with automatic h2c multiplexing
+//   - Single port mode with automatic h2c multiplexing (or ALPN over TLS)
 //   - Health checks (/healthz, /readyz)
 //   - Prometheus metrics (/metrics)
 //   - Swagger UI (/swagger/)
@@ -35,21 +35,30 @@ package grpckit
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit/internal/pathmatch"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -59,7 +68,53 @@ type Server struct {
 	grpcServer    *grpc.Server
 	httpServer    *http.Server
 	healthHandler *healthHandler
+	grpcHealth    *health.Server
 	metrics       *Metrics
+	tlsConfig     *tls.Config
+	gatewayCert   *tls.Certificate
+	stats         connStats
+
+	// Signs the client certificates the gateway forwards (see signClientCert)
+	clientCertKey []byte
+
+	// Identifies gateway calls checked by WithRateLimit already
+	rateLimitToken string
+
+	// Identifies gateway calls timed by WithSlowRequestThreshold already
+	slowRequestToken string
+
+	// Built-in endpoints on the gRPC port (WithGRPCPortEndpoints)
+	grpcPortServer *http.Server
+
+	// Handler built for mounting in another HTTP server (see Handler)
+	handlerOnce sync.Once
+	handler     http.Handler
+	handlerErr  error
+
+	// Runtime state (controllable via the admin API)
+	routesMu         sync.Mutex // guards routes, mux, gwMux and cfg.httpHandlers
+	routes           []string
+	mux              *http.ServeMux
+	gwMux            *runtime.ServeMux // marshalers for DecodeBody
+	started          atomic.Bool
+	logger           *slog.Logger
+	logLevel         *slog.LevelVar
+	maintenance      atomic.Bool
+	maintenanceUntil atomic.Int64 // expected end, in Unix nanoseconds (0 if unknown)
+	done             chan struct{}
+	shutdownOnce     sync.Once
+
+	// Cancelled when draining begins on shutdown (see ShutdownContext)
+	draining context.Context
+	drain    context.CancelCauseFunc
+	rpcs     atomic.Int64  // active gRPC calls
+	rpcsIdle chan struct{} // signaled when rpcs drops to 0 while draining
+
+	// Listeners (tracked for socket handover on restart)
+	listenersMu sync.Mutex
+	listeners   map[string]net.Listener
+	inherited   map[string]net.Listener
+	boundCount  atomic.Int32
 }
 
 // New creates a new Server with the given options.
@@ -75,36 +130,187 @@ func New(opts ...Option) (*Server, error) {
 	}
 
 	// Validate configuration
+	cfg.logLevel = strings.ToLower(cfg.logLevel)
+	if _, ok := slogLevels[cfg.logLevel]; !ok {
+		cfg.invalid("unknown log level %q", cfg.logLevel)
+	}
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(slogLevels[cfg.logLevel])
+	cfg.logger = slog.New(&levelHandler{level: logLevel, handler: cfg.logger.Handler()})
+	cfg.errs = append(cfg.errs, checkHTTPHandlers(cfg, append(slices.Clip(cfg.httpHandlers), reverseProxyRoutes(cfg)...))...)
+	if cfg.grpcPortEndpoints && !cfg.healthEnabled && !cfg.metricsEnabled {
+		cfg.invalid("WithGRPCPortEndpoints: neither health checks nor metrics are enabled")
+	}
+	checkRBAC(cfg)
+	checkTenantMetrics(cfg)
+	resolveSecrets(cfg)
+	exemptBuiltins(cfg)
+	if cfg.publicBuiltins {
+		WithPublicEndpoints(publicBuiltinPatterns(cfg)...)(cfg)
+	}
+	if err := errors.Join(cfg.errs...); err != nil {
+		return nil, err
+	}
+	applyClock(cfg)
 	if len(cfg.grpcServices) == 0 && len(cfg.restServices) == 0 {
 		return nil, ErrServiceNotRegistered
 	}
+	if err := validateRouteRewrites(cfg.routeRewrites); err != nil {
+		return nil, err
+	}
+	if err := validateStrictMode(cfg); err != nil {
+		return nil, err
+	}
+
+	server := &Server{
+		cfg:       cfg,
+		done:      make(chan struct{}),
+		logger:    cfg.logger,
+		logLevel:  logLevel,
+		inherited: inheritedListeners(cfg.logger),
+	}
+	server.draining, server.drain = context.WithCancelCause(context.Background())
+	server.rpcsIdle = make(chan struct{}, 1)
+	if len(cfg.rateLimits) > 0 {
+		server.rateLimitToken = newGatewayToken()
+	}
+	if cfg.slowRequestThreshold > 0 {
+		server.slowRequestToken = newGatewayToken()
+	}
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	server.tlsConfig = tlsConfig
+	if tlsConfig != nil && cfg.grpcPortEndpoints && cfg.grpcPort != cfg.httpPort {
+		return nil, fmt.Errorf("%w: WithGRPCPortEndpoints does not support TLS on the gRPC port", ErrInvalidConfig)
+	}
+	if cfg.mtlsCAFile != "" {
+		if err := server.setupGatewayCertificate(); err != nil {
+			return nil, err
+		}
+	}
+
+	registerReverseProxies(server)
 
 	// Build gRPC server with interceptors
 	grpcOpts := []grpc.ServerOption{}
 
-	// Build unary interceptor chain: auth (if configured) + custom interceptors
-	var unaryInterceptors []grpc.UnaryServerInterceptor
+	// In single port mode TLS is terminated by the HTTP server instead
+	if tlsConfig != nil && cfg.grpcPort != cfg.httpPort {
+		grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	// Raise the transport limit for per-method limits above the default
+	if size := maxRecvMsgSize(cfg.grpcSizeLimits); size > 0 {
+		grpcOpts = append(grpcOpts, grpc.MaxRecvMsgSize(size))
+	}
+	sizeChecks := cfg.metricsEnabled || len(cfg.grpcSizeLimits) > 0
+
+	// Build unary interceptor chain: shutdown notice + client certificates + correlation + request IDs + logging + metrics + request costs + error conversion + message sizes + maintenance mode + auth + rate limits + RBAC + tenants + quotas + slow requests (if configured) + custom interceptors
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		server.shutdownUnaryInterceptor,
+	}
+	if server.gatewayCert != nil {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("client_cert", server.clientCertUnaryInterceptor))
+	}
+	unaryInterceptors = append(unaryInterceptors, server.tracedUnary("correlation", correlationUnaryInterceptor))
+	if cfg.requestID {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("request_id", requestIDUnaryInterceptor))
+	}
+	if cfg.grpcLogging != nil {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("grpc_logging", grpcLoggingUnaryInterceptor(server)))
+	}
+	if cfg.metricsEnabled {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("metrics", grpcMetricsUnaryInterceptor(server)))
+	}
+	if cfg.requestCost != nil {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("request_cost", requestCostUnaryInterceptor(server)))
+	}
+	unaryInterceptors = append(unaryInterceptors, server.tracedUnary("errors", errorUnaryInterceptor))
+	if sizeChecks {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("message_size", messageSizeUnaryInterceptor(server)))
+	}
+	unaryInterceptors = append(unaryInterceptors, server.maintenanceUnaryInterceptor)
 	if cfg.authFunc != nil {
-		unaryInterceptors = append(unaryInterceptors, grpcAuthInterceptor(cfg))
+		auth := grpcAuthInterceptor(cfg)
+		if cfg.authFailures != nil {
+			auth = authFailureUnaryInterceptor(server, auth)
+		}
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("auth", auth))
+	}
+	if len(cfg.rateLimits) > 0 {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("rate_limit", rateLimitUnaryInterceptor(server)))
+	}
+	if len(cfg.rbacRules) > 0 {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("rbac", rbacUnaryInterceptor(server)))
 	}
-	for _, reg := range cfg.unaryInterceptors {
-		unaryInterceptors = append(unaryInterceptors, wrapUnaryInterceptor(reg))
+	if cfg.tenantResolver != nil {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("tenant", tenantUnaryInterceptor(server)))
 	}
-	if len(unaryInterceptors) > 0 {
-		grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(unaryInterceptors...))
+	if cfg.quota != nil {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("quota", quotaUnaryInterceptor(server)))
 	}
+	if cfg.slowRequestThreshold > 0 {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary("slow_request", slowRequestUnaryInterceptor(server)))
+	}
+	for i, reg := range cfg.unaryInterceptors {
+		unaryInterceptors = append(unaryInterceptors, server.tracedUnary(fmt.Sprintf("interceptor[%d]", i), wrapUnaryInterceptor(reg)))
+	}
+	if cfg.middlewareTrace {
+		unaryInterceptors = append([]grpc.UnaryServerInterceptor{server.traceUnaryInterceptor}, unaryInterceptors...)
+	}
+	grpcOpts = append(grpcOpts, grpc.ChainUnaryInterceptor(unaryInterceptors...))
 
-	// Build stream interceptor chain: auth (if configured) + custom interceptors
-	var streamInterceptors []grpc.StreamServerInterceptor
+	// Build stream interceptor chain: shutdown notice + client certificates + correlation + request IDs + logging + metrics + request costs + error conversion + message sizes + maintenance mode + auth + rate limits + RBAC + tenants (if configured) + custom interceptors
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		server.shutdownStreamInterceptor,
+	}
+	if server.gatewayCert != nil {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("client_cert", server.clientCertStreamInterceptor))
+	}
+	streamInterceptors = append(streamInterceptors, server.tracedStream("correlation", correlationStreamInterceptor))
+	if cfg.requestID {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("request_id", requestIDStreamInterceptor))
+	}
+	if cfg.grpcLogging != nil {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("grpc_logging", grpcLoggingStreamInterceptor(server)))
+	}
+	if cfg.metricsEnabled {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("metrics", grpcMetricsStreamInterceptor(server)))
+	}
+	if cfg.requestCost != nil {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("request_cost", requestCostStreamInterceptor(server)))
+	}
+	streamInterceptors = append(streamInterceptors, server.tracedStream("errors", errorStreamInterceptor))
+	if sizeChecks {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("message_size", messageSizeStreamInterceptor(server)))
+	}
+	streamInterceptors = append(streamInterceptors, server.maintenanceStreamInterceptor)
 	if cfg.authFunc != nil {
-		streamInterceptors = append(streamInterceptors, grpcStreamAuthInterceptor(cfg))
+		auth := grpcStreamAuthInterceptor(cfg)
+		if cfg.authFailures != nil {
+			auth = authFailureStreamInterceptor(server, auth)
+		}
+		streamInterceptors = append(streamInterceptors, server.tracedStream("auth", auth))
+	}
+	if len(cfg.rateLimits) > 0 {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("rate_limit", rateLimitStreamInterceptor(server)))
+	}
+	if len(cfg.rbacRules) > 0 {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("rbac", rbacStreamInterceptor(server)))
 	}
-	for _, reg := range cfg.streamInterceptors {
-		streamInterceptors = append(streamInterceptors, wrapStreamInterceptor(reg))
+	if cfg.tenantResolver != nil {
+		streamInterceptors = append(streamInterceptors, server.tracedStream("tenant", tenantStreamInterceptor(server)))
 	}
-	if len(streamInterceptors) > 0 {
-		grpcOpts = append(grpcOpts, grpc.ChainStreamInterceptor(streamInterceptors...))
+	for i, reg := range cfg.streamInterceptors {
+		streamInterceptors = append(streamInterceptors, server.tracedStream(fmt.Sprintf("interceptor[%d]", i), wrapStreamInterceptor(reg)))
 	}
+	if cfg.middlewareTrace {
+		streamInterceptors = append([]grpc.StreamServerInterceptor{server.traceStreamInterceptor}, streamInterceptors...)
+	}
+	grpcOpts = append(grpcOpts, grpc.ChainStreamInterceptor(streamInterceptors...))
 
 	grpcServer := grpc.NewServer(grpcOpts...)
 
@@ -113,24 +319,59 @@ func New(opts ...Option) (*Server, error) {
 		svc.registrar(grpcServer)
 	}
 
+	// Register grpc.health.v1 for the services registered above
+	if cfg.grpcHealth {
+		server.grpcHealth = registerGRPCHealth(grpcServer, func() []*healthCheck {
+			return server.healthHandler.readiness()
+		})
+	}
+
 	// Enable reflection for grpcurl/grpcui
-	reflection.Register(grpcServer)
+	if !cfg.noReflection {
+		reflection.Register(grpcServer)
+	}
 
 	// Create health handler
 	healthHandler := newHealthHandler()
+	healthHandler.readinessChecks = cfg.readinessChecks
+	healthHandler.livenessChecks = cfg.livenessChecks
 
 	// Create metrics if enabled
 	var metrics *Metrics
 	if cfg.metricsEnabled {
-		metrics = newMetrics("grpckit")
+		metrics = newMetrics("grpckit", cfg.metricsRegisterer)
+		if cfg.runtimeMetrics {
+			registerRuntimeCollectors(cfg.metricsRegisterer, cfg.logger)
+		}
+		if cfg.gatewayCircuitBreaker != nil {
+			registerCircuitBreaker(cfg.metricsRegisterer, cfg.gatewayCircuitBreaker, cfg.logger)
+		}
+		cfg.metricsRegisterer.MustRegister(newStatsCollector(server, "grpckit"))
+		if cfg.metricsRoutesOnly {
+			metrics.routes = newMetricsRouter(cfg, grpcServer)
+		}
+		if cfg.tenantMetrics != nil {
+			metrics.tenants = newTenantLabeler(cfg.tenantMetrics.TopN, metrics.deleteTenant)
+		}
+	}
+
+	server.grpcServer = grpcServer
+	server.healthHandler = healthHandler
+	server.metrics = metrics
+	if cfg.grpcPortEndpoints {
+		// Built here rather than in startGRPC, so Shutdown never races
+		// with its assignment
+		server.grpcPortServer = &http.Server{
+			Handler:           server.grpcPortHandler(),
+			ReadHeaderTimeout: grpcPortReadHeaderTimeout,
+		}
 	}
 
-	return &Server{
-		cfg:           cfg,
-		grpcServer:    grpcServer,
-		healthHandler: healthHandler,
-		metrics:       metrics,
-	}, nil
+	if err := server.initModules(); err != nil {
+		return nil, err
+	}
+
+	return server, nil
 }
 
 // Run creates and starts a server with the given options.
@@ -147,12 +388,23 @@ func Run(opts ...Option) error {
 // Start starts the gRPC and HTTP servers.
 // It blocks until the server is stopped.
 func (s *Server) Start() error {
+	s.started.Store(true)
+	s.emit(ServerStarting{Time: time.Now(), GRPCPort: s.cfg.grpcPort, HTTPPort: s.cfg.httpPort})
+
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Setup signal handling for graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	// SIGHUP triggers a zero-downtime restart
+	hupCh := make(chan os.Signal, 1)
+	if s.cfg.gracefulRestart {
+		signal.Notify(hupCh, syscall.SIGHUP)
+		defer signal.Stop(hupCh)
+	}
 
 	g, ctx := errgroup.WithContext(ctx)
 
@@ -173,15 +425,30 @@ func (s *Server) Start() error {
 		})
 	}
 
+	// Pick up rotated certificates (WithCertReloader)
+	if r, ok := s.cfg.certProvider.(*certReloader); ok {
+		go s.watchCertificate(ctx, r)
+	}
+
 	// Wait for shutdown signal
 	g.Go(func() error {
-		select {
-		case sig := <-sigCh:
-			log.Printf("Received signal %v, shutting down...", sig)
-			s.Shutdown()
-			return nil
-		case <-ctx.Done():
-			return ctx.Err()
+		for {
+			select {
+			case <-hupCh:
+				s.logger.Info("received SIGHUP, restarting", "component", "server")
+				if err := s.Restart(); err != nil {
+					s.logger.Error("restart failed, continuing to serve", "component", "server", "error", err)
+				}
+			case sig := <-sigCh:
+				s.logger.Info("received signal, shutting down", "component", "server", "signal", sig.String())
+				s.shutdownWithReason("signal: " + sig.String())
+				return nil
+			case <-s.done:
+				// Shutdown was triggered programmatically (e.g. admin drain, restart)
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	})
 
@@ -191,234 +458,487 @@ func (s *Server) Start() error {
 // startGRPC starts the gRPC server.
 func (s *Server) startGRPC() error {
 	addr := fmt.Sprintf(":%d", s.cfg.grpcPort)
-	lis, err := net.Listen("tcp", addr)
+	lis, err := s.listen("grpc", addr)
 	if err != nil {
-		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+		return err
 	}
+	lis = s.countConnections("grpc", lis)
 
-	log.Printf("gRPC server listening on %s", addr)
+	if s.cfg.grpcPortEndpoints {
+		var httpLis net.Listener
+		lis, httpLis = newSplitListener(lis)
+		go func() {
+			if err := s.grpcPortServer.Serve(httpLis); err != http.ErrServerClosed {
+				s.logger.Error("gRPC port HTTP server failed", "component", "server", "error", err)
+			}
+		}()
+		s.logger.Info("gRPC server listening", "component", "server", "addr", addr, "builtin_endpoints", true)
+	} else {
+		s.logger.Info("gRPC server listening", "component", "server", "addr", addr)
+	}
+	s.listenerBound("grpc", lis)
 	return s.grpcServer.Serve(lis)
 }
 
 // startHTTP starts the HTTP/REST server with grpc-gateway.
 func (s *Server) startHTTP(ctx context.Context) error {
-	// Create grpc-gateway mux with marshaler options
-	gwMux := runtime.NewServeMux(buildMarshalerOptions(s.cfg)...)
-
 	// Register REST services via grpc-gateway
 	grpcEndpoint := fmt.Sprintf("localhost:%d", s.cfg.grpcPort)
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	opts := []grpc.DialOption{s.gatewayTransportCredentials()}
 
-	for _, registrar := range s.cfg.restServices {
-		if err := registrar(ctx, gwMux, grpcEndpoint, opts); err != nil {
-			return fmt.Errorf("failed to register REST service: %w", err)
-		}
-	}
-
-	// Create main HTTP mux
-	mux := http.NewServeMux()
-
-	// Register health endpoints
-	if s.cfg.healthEnabled {
-		registerHealthEndpoints(mux, s.healthHandler)
+	gwMux, err := s.newGatewayMux(ctx, grpcEndpoint, opts)
+	if err != nil {
+		return err
 	}
 
-	// Register metrics endpoint
-	if s.cfg.metricsEnabled {
-		registerMetricsEndpoint(mux)
+	// Create HTTP server
+	addr := fmt.Sprintf(":%d", s.cfg.httpPort)
+	s.httpServer = &http.Server{
+		Addr:        addr,
+		Handler:     s.buildHTTPHandler(gwMux),
+		BaseContext: s.baseContext,
+		ConnContext: s.connContext,
 	}
 
-	// Register swagger endpoints
-	if s.cfg.swaggerEnabled {
-		if swaggerData := getSwaggerData(); len(swaggerData) > 0 {
-			if err := registerSwaggerEndpointsFromBytes(mux, swaggerData); err != nil {
-				log.Printf("Warning: failed to register Swagger endpoints: %v", err)
-			}
-		} else if s.cfg.swaggerPath != "" {
-			if err := registerSwaggerEndpoints(mux, s.cfg.swaggerPath); err != nil {
-				log.Printf("Warning: failed to register Swagger endpoints: %v", err)
-			}
-		} else {
-			// Swagger enabled but no data - register 404 handler
-			registerSwaggerNotFound(mux)
-		}
+	lis, err := s.listen("http", addr)
+	if err != nil {
+		return err
 	}
+	lis = s.countConnections("http", lis)
 
-	// Register custom HTTP handlers (before grpc-gateway catch-all)
-	for _, h := range s.cfg.httpHandlers {
-		mux.Handle(h.pattern, h.handler)
+	s.logger.Info("HTTP server listening", "component", "server", "addr", addr)
+	s.listenerBound("http", lis)
+	if err := s.serveHTTP(lis); err != http.ErrServerClosed {
+		return err
 	}
+	return nil
+}
 
-	// Mount grpc-gateway mux for all other paths (catch-all)
-	mux.Handle("/", gwMux)
-
-	// Build middleware chain (applied to ALL HTTP requests)
-	var handler http.Handler = mux
+// startCombined starts a combined gRPC + HTTP server on a single port using h2c,
+// or HTTP/2 negotiated via ALPN when TLS is configured.
+// This allows both gRPC and REST to be served on the same port.
+func (s *Server) startCombined(ctx context.Context) error {
+	// Register REST services via grpc-gateway
+	// In combined mode, we connect to ourselves via the same port
+	grpcEndpoint := fmt.Sprintf("localhost:%d", s.cfg.grpcPort)
+	opts := []grpc.DialOption{s.gatewayTransportCredentials()}
 
-	// Apply custom HTTP middlewares (in reverse order so first registered = outermost)
-	for i := len(s.cfg.httpMiddlewares) - 1; i >= 0; i-- {
-		handler = s.cfg.httpMiddlewares[i](handler)
+	gwMux, err := s.newGatewayMux(ctx, grpcEndpoint, opts)
+	if err != nil {
+		return err
 	}
 
-	// Apply built-in auth middleware
-	if s.cfg.authFunc != nil {
-		handler = authMiddleware(s.cfg, handler)
-	}
+	httpHandler := s.buildHTTPHandler(gwMux)
 
-	// Apply built-in metrics middleware
-	if s.cfg.metricsEnabled && s.metrics != nil {
-		handler = metricsMiddleware(s.metrics, handler)
-	}
+	// Create a combined handler that routes gRPC and HTTP requests
+	combinedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Check if this is a gRPC request
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			s.grpcServer.ServeHTTP(w, r)
+		} else {
+			httpHandler.ServeHTTP(w, r)
+		}
+	})
 
-	// Apply built-in CORS middleware (outermost, handles preflight OPTIONS)
-	if s.cfg.corsEnabled && s.cfg.corsConfig != nil {
-		handler = corsMiddleware(*s.cfg.corsConfig)(handler)
+	// Wrap with h2c handler for HTTP/2 cleartext support (with TLS, HTTP/2
+	// is negotiated via ALPN instead)
+	var handler http.Handler = combinedHandler
+	var h2s *http2.Server
+	if s.tlsConfig == nil {
+		h2s = &http2.Server{}
+		handler = h2c.NewHandler(combinedHandler, h2s)
 	}
 
 	// Create HTTP server
-	addr := fmt.Sprintf(":%d", s.cfg.httpPort)
+	addr := fmt.Sprintf(":%d", s.cfg.grpcPort)
 	s.httpServer = &http.Server{
-		Addr:    addr,
-		Handler: handler,
+		Addr:        addr,
+		Handler:     handler,
+		BaseContext: s.baseContext,
+		ConnContext: s.connContext,
+	}
+	// Send GOAWAY to h2c connections on shutdown, as for HTTP/2 over TLS
+	if h2s != nil {
+		if err := http2.ConfigureServer(s.httpServer, h2s); err != nil {
+			return err
+		}
 	}
 
-	log.Printf("HTTP server listening on %s", addr)
-	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+	lis, err := s.listen("grpc+http", addr)
+	if err != nil {
+		return err
+	}
+	lis = s.countConnections("grpc+http", lis)
+
+	s.logger.Info("gRPC + HTTP server listening", "component", "server", "addr", addr, "mode", "combined")
+	s.listenerBound("grpc+http", lis)
+	if err := s.serveHTTP(lis); err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
 
-// startCombined starts a combined gRPC + HTTP server on a single port using h2c.
-// This allows both gRPC and REST to be served on the same port.
-func (s *Server) startCombined(ctx context.Context) error {
-	// Build the HTTP handler (same as startHTTP)
-	gwMux := runtime.NewServeMux(buildMarshalerOptions(s.cfg)...)
+// newGatewayMux creates the grpc-gateway mux and registers all REST services
+// against the given gRPC endpoint.
+func (s *Server) newGatewayMux(ctx context.Context, endpoint string, opts []grpc.DialOption) (*runtime.ServeMux, error) {
+	// Create grpc-gateway mux with error mapping and marshaler options
+	// (user-supplied gateway options come later and may override the error handlers)
+	errorHandler := gatewayErrorHandler
+	if len(s.cfg.trailerHeaders) > 0 {
+		errorHandler = trailerHeadersErrorHandler(s.cfg.trailerHeaders, errorHandler)
+	}
+	gwOpts := []runtime.ServeMuxOption{
+		runtime.WithErrorHandler(errorHandler),
+		runtime.WithStreamErrorHandler(gatewayStreamErrorHandler),
+	}
+	// Stream large responses (before user options, so a custom rewriter replaces it)
+	var streamer *responseStreamer
+	if s.cfg.largeResponseThreshold > 0 {
+		streamer = &responseStreamer{threshold: s.cfg.largeResponseThreshold}
+		gwOpts = append(gwOpts, runtime.WithForwardResponseRewriter(streamer.rewrite))
+	}
+	gwOpts = append(gwOpts, buildMarshalerOptions(s.cfg)...)
+	// Answer OPTIONS and unsupported methods on known paths with 204/405 and
+	// Allow, and apply custom not found / method not allowed handlers
+	routes := collectHTTPRoutes(s.grpcServer)
+	warnShadowedRoutes(s.cfg, routes)
+	gwOpts = append(gwOpts, runtime.WithRoutingErrorHandler(gatewayRoutingErrorHandler(s.cfg, routes)))
+	// Forward request ID and trace context so REST and gRPC logs correlate
+	gwOpts = append(gwOpts, runtime.WithMetadata(gatewayCorrelationMetadata))
+	if s.gatewayCert != nil {
+		gwOpts = append(gwOpts, runtime.WithMetadata(s.clientCertMetadata))
+	}
+	if len(s.cfg.metadataAnnotators) > 0 {
+		gwOpts = append(gwOpts, runtime.WithMetadata(annotatedMetadata))
+	}
+	// Apply download filenames set with SetDownloadFilename
+	gwOpts = append(gwOpts, runtime.WithForwardResponseOption(downloadFilenameResponseOption))
+	if s.cfg.quota != nil {
+		gwOpts = append(gwOpts, runtime.WithForwardResponseOption(quotaHeadersResponseOption))
+	}
+	// Expose trailers configured with WithTrailerHeaders as headers
+	if len(s.cfg.trailerHeaders) > 0 {
+		gwOpts = append(gwOpts, runtime.WithForwardResponseOption(trailerHeadersResponseOption(s.cfg.trailerHeaders)))
+	}
+	if s.cfg.requestCost != nil && s.cfg.requestCost.Header {
+		gwOpts = append(gwOpts, runtime.WithForwardResponseOption(requestCostResponseOption))
+	}
+	if streamer != nil {
+		gwOpts = append(gwOpts, runtime.WithForwardResponseOption(largeResponseStreamOption))
+	}
+	if s.cfg.streamingLimits != nil {
+		gwOpts = append(gwOpts, runtime.WithForwardResponseOption(streamingLimitsResponseOption))
+	}
+	if s.cfg.queryOptions != nil {
+		// Process-wide: queryOptionsMiddleware selects the options per server
+		installQueryParser.Do(func() {
+			gwOpts = append(gwOpts, runtime.SetQueryParameterParser(dispatchQueryParser{}))
+		})
+	}
+	gwMux := runtime.NewServeMux(gwOpts...)
+	if streamer != nil {
+		streamer.mux = gwMux
+	}
 
-	// Register REST services via grpc-gateway
-	// In combined mode, we connect to ourselves via the same port
-	grpcEndpoint := fmt.Sprintf("localhost:%d", s.cfg.grpcPort)
-	opts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	// Track gateway connections for Stats
+	opts = append(opts,
+		grpc.WithChainUnaryInterceptor(s.gatewayConnUnaryInterceptor),
+		grpc.WithChainStreamInterceptor(s.gatewayConnStreamInterceptor),
+	)
+	// Check rate limits matching gRPC methods keyed by the REST client
+	if len(s.cfg.rateLimits) > 0 {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(s.rateLimitClientUnaryInterceptor),
+			grpc.WithChainStreamInterceptor(s.rateLimitClientStreamInterceptor),
+		)
+	}
+	// Mark calls the HTTP middleware times already as slow requests
+	if s.cfg.slowRequestThreshold > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(s.slowRequestClientUnaryInterceptor))
+	}
+	opts = append(opts, streamingLimitsDialOptions(s.cfg.streamingLimits)...)
+	opts = append(opts, s.cfg.gatewayDialOpts...)
+
+	// Retries run outside the circuit breaker so each attempt is counted by it
+	if r := s.cfg.gatewayRetry; r != nil {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(r.unaryClientInterceptor()))
+	}
+	if cb := s.cfg.gatewayCircuitBreaker; cb != nil {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(cb.UnaryClientInterceptor()),
+			grpc.WithChainStreamInterceptor(cb.StreamClientInterceptor()),
+		)
+	}
 
-	for _, registrar := range s.cfg.restServices {
-		if err := registrar(ctx, gwMux, grpcEndpoint, opts); err != nil {
-			return fmt.Errorf("failed to register REST service: %w", err)
+	if err := s.registerRESTServices(ctx, gwMux, endpoint, opts); err != nil {
+		return nil, err
+	}
+	for _, hook := range s.cfg.gatewayMuxHooks {
+		if err := hook(gwMux); err != nil {
+			return nil, fmt.Errorf("gateway mux hook failed: %w", err)
 		}
 	}
+	return gwMux, nil
+}
 
+// buildHTTPHandler builds the complete HTTP handler: built-in endpoints,
+// custom handlers and the grpc-gateway catch-all, wrapped in the middleware chain.
+func (s *Server) buildHTTPHandler(gwMux http.Handler) http.Handler {
 	// Create main HTTP mux
 	mux := http.NewServeMux()
+	s.routesMu.Lock()
+	defer s.routesMu.Unlock()
+	s.routes = s.routes[:0]
 
 	// Register health endpoints
 	if s.cfg.healthEnabled {
-		registerHealthEndpoints(mux, s.healthHandler)
+		registerHealthEndpoints(mux, s.healthHandler, s.cfg.livenessPath, s.cfg.readinessPath)
+		s.routes = append(s.routes, s.cfg.livenessPath, s.cfg.readinessPath)
 	}
 
 	// Register metrics endpoint
 	if s.cfg.metricsEnabled {
-		registerMetricsEndpoint(mux)
+		registerMetricsEndpoint(mux, s.cfg)
+		s.routes = append(s.routes, s.cfg.metricsPath)
 	}
 
 	// Register swagger endpoints
 	if s.cfg.swaggerEnabled {
 		if swaggerData := getSwaggerData(); len(swaggerData) > 0 {
-			if err := registerSwaggerEndpointsFromBytes(mux, swaggerData); err != nil {
-				log.Printf("Warning: failed to register Swagger endpoints: %v", err)
+			if err := registerSwaggerEndpointsFromBytes(mux, swaggerData, s.cfg.swaggerPrefix); err != nil {
+				s.logger.Warn("failed to register Swagger endpoints", "component", "swagger", "error", err)
 			}
 		} else if s.cfg.swaggerPath != "" {
-			if err := registerSwaggerEndpoints(mux, s.cfg.swaggerPath); err != nil {
-				log.Printf("Warning: failed to register Swagger endpoints: %v", err)
+			if err := registerSwaggerEndpoints(mux, s.cfg.swaggerPath, s.cfg.swaggerPrefix); err != nil {
+				s.logger.Warn("failed to register Swagger endpoints", "component", "swagger", "error", err)
 			}
 		} else {
 			// Swagger enabled but no data - register 404 handler
-			registerSwaggerNotFound(mux)
+			registerSwaggerNotFound(mux, s.cfg.swaggerPrefix)
 		}
+		s.routes = append(s.routes, s.cfg.swaggerPrefix+"/")
 	}
 
 	// Register custom HTTP handlers (before grpc-gateway catch-all)
+	s.gwMux, _ = gwMux.(*runtime.ServeMux)
 	for _, h := range s.cfg.httpHandlers {
-		mux.Handle(h.pattern, h.handler)
+		mux.Handle(h.pattern, s.withMarshalers(h.handler))
+		s.routes = append(s.routes, h.pattern)
 	}
 
 	// Mount grpc-gateway mux for all other paths (catch-all)
-	mux.Handle("/", gwMux)
+	var gateway http.Handler = gwMux
+	if s.cfg.queryOptions != nil {
+		gateway = queryOptionsMiddleware(&queryParser{opts: *s.cfg.queryOptions, logger: s.logger}, gateway)
+	}
+	gateway = jsonMarshalerMiddleware(s.cfg, withValidationBody(largeResponseMiddleware(s.cfg.largeResponseThreshold, gateway)))
+	gateway = maxPageSizeMiddleware(s.cfg.maxPageSize, gateway)
+	gateway = paramRulesMiddleware(s.cfg.paramRules, gateway)
+	gateway = requestTimeoutHeaderMiddleware(s.cfg.maxRequestTimeout, gateway)
+	if s.cfg.htmlTables {
+		gateway = htmlAcceptMiddleware(gateway)
+	}
+	gateway = varyAcceptMiddleware(s.cfg, gateway)
+	if len(s.cfg.metadataAnnotators) > 0 {
+		gateway = annotatorMiddleware(s.cfg.metadataAnnotators, gateway)
+	}
+	gateway = streamingLimitsMiddleware(s, gateway)
+	mux.Handle("/", gateway)
+	s.mux = mux
 
 	// Build middleware chain (applied to ALL HTTP requests)
-	var httpHandler http.Handler = mux
+	var handler http.Handler = s.traceRoute(mux)
+
+	// Apply built-in JSONP wrapping (innermost, wraps handler responses only)
+	if s.cfg.jsonp != nil {
+		handler = s.traced("jsonp", jsonpMiddleware(s.cfg.jsonp, handler))
+	}
+
+	// Apply built-in content type restrictions (before body parsing)
+	if len(s.cfg.contentTypeRules) > 0 {
+		handler = s.traced("content_type", contentTypeMiddleware(s.cfg.contentTypeRules, handler))
+	}
 
 	// Apply custom HTTP middlewares (in reverse order so first registered = outermost)
 	for i := len(s.cfg.httpMiddlewares) - 1; i >= 0; i-- {
-		httpHandler = s.cfg.httpMiddlewares[i](httpHandler)
+		handler = s.traced(fmt.Sprintf("middleware[%d]", i), s.cfg.httpMiddlewares[i](handler))
+	}
+
+	// Apply built-in request priority and load shedding (after auth, so the
+	// classifier can see the principal)
+	if s.cfg.priorityClassifier != nil || s.cfg.maxConcurrentRequests > 0 {
+		handler = s.traced("priority", priorityMiddleware(s.cfg, s.metrics, handler))
+	}
+
+	// Apply built-in slow request logging (after auth, so the principal is known)
+	if s.cfg.slowRequestThreshold > 0 {
+		handler = s.traced("slow_request", slowRequestMiddleware(s, handler))
+	}
+
+	// Apply built-in deprecation headers and usage logging (after auth, so
+	// the caller is known)
+	if len(s.cfg.deprecatedEndpoints) > 0 {
+		handler = s.traced("deprecation", deprecationMiddleware(s, handler))
+	}
+
+	// Apply built-in Cache-Control policies (after auth, so they override
+	// its no-store default)
+	if len(s.cfg.cachePolicies) > 0 {
+		handler = s.traced("cache_control", cacheControlMiddleware(s.cfg.cachePolicies, handler))
+	}
+
+	// Apply built-in tenant resolution (after auth, so the resolver can see
+	// the principal)
+	if s.cfg.tenantResolver != nil {
+		handler = s.traced("tenant", tenantMiddleware(s, handler))
+	}
+
+	// Apply built-in role checks (after auth, so they see the roles)
+	if len(s.cfg.rbacRules) > 0 {
+		handler = s.traced("rbac", rbacMiddleware(s, handler))
+	}
+
+	// Apply built-in rate limits (after auth, so they can be keyed by
+	// principal)
+	if len(s.cfg.rateLimits) > 0 {
+		handler = s.traced("rate_limit", rateLimitMiddleware(s, handler))
 	}
 
 	// Apply built-in auth middleware
 	if s.cfg.authFunc != nil {
-		httpHandler = authMiddleware(s.cfg, httpHandler)
+		if s.cfg.authFailures != nil {
+			handler = s.traced("auth", authFailureMiddleware(s, handler))
+		} else {
+			handler = s.traced("auth", authMiddleware(s.cfg, handler))
+		}
+	}
+
+	// Apply built-in brute-force lockouts (before auth, so they see its rejections)
+	if s.cfg.lockout != nil {
+		handler = s.traced("lockout", lockoutMiddleware(s, handler))
+	}
+
+	// Apply built-in request timeout middleware
+	if s.cfg.httpTimeout > 0 || len(s.cfg.httpTimeoutOverrides) > 0 {
+		handler = s.traced("timeout", timeoutMiddleware(s.cfg, handler))
+	}
+
+	// Apply built-in redirects and route aliases (before auth and timeouts,
+	// so they see the aliased path)
+	if len(s.cfg.routeRewrites) > 0 {
+		handler = s.traced("route_rewrite", routeRewriteMiddleware(s.cfg.routeRewrites, handler))
+	}
+
+	// Apply built-in maintenance mode (before user auth)
+	handler = maintenanceMiddleware(s, handler)
+
+	// Apply built-in admin API (bypasses user auth and maintenance mode)
+	if s.cfg.adminConfig != nil {
+		handler = s.traced("admin", adminMiddleware(s, handler))
 	}
 
 	// Apply built-in metrics middleware
 	if s.cfg.metricsEnabled && s.metrics != nil {
-		httpHandler = metricsMiddleware(s.metrics, httpHandler)
+		handler = s.traced("metrics", metricsMiddleware(s.metrics, handler))
 	}
 
 	// Apply built-in CORS middleware (outermost, handles preflight OPTIONS)
 	if s.cfg.corsEnabled && s.cfg.corsConfig != nil {
-		httpHandler = corsMiddleware(*s.cfg.corsConfig)(httpHandler)
+		handler = s.traced("cors", corsMiddleware(*s.cfg.corsConfig)(handler))
 	}
 
-	// Create a combined handler that routes gRPC and HTTP requests
-	combinedHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Check if this is a gRPC request
-		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
-			s.grpcServer.ServeHTTP(w, r)
-		} else {
-			httpHandler.ServeHTTP(w, r)
-		}
-	})
-
-	// Wrap with h2c handler for HTTP/2 cleartext support
-	h2cHandler := h2c.NewHandler(combinedHandler, &http2.Server{})
+	// Apply built-in request cost accounting (inside the access log, so it
+	// can add the costs to its line)
+	if s.cfg.requestCost != nil {
+		handler = s.traced("request_cost", requestCostMiddleware(s, handler))
+	}
 
-	// Create HTTP server
-	addr := fmt.Sprintf(":%d", s.cfg.grpcPort)
-	s.httpServer = &http.Server{
-		Addr:    addr,
-		Handler: h2cHandler,
+	// Apply built-in access log (outermost, sees the final status)
+	if s.cfg.accessLog {
+		handler = s.traced("access_log", accessLogMiddleware(s, handler))
 	}
 
-	log.Printf("gRPC + HTTP server listening on %s (combined mode)", addr)
-	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
-		return err
+	// Apply built-in request IDs (outermost, so every middleware sees the ID)
+	if s.cfg.requestID {
+		handler = s.traced("request_id", requestIDMiddleware(handler))
 	}
-	return nil
+
+	// Start the middleware trace (outermost, if configured)
+	return s.traceMiddleware(handler)
 }
 
 // Shutdown gracefully shuts down the server.
+// It is safe to call multiple times; only the first call has an effect.
 func (s *Server) Shutdown() {
-	// Mark as not ready
+	s.shutdownWithReason("shutdown requested")
+}
+
+// shutdownWithReason shuts down the server once, recording what triggered it.
+func (s *Server) shutdownWithReason(reason string) {
+	s.shutdownOnce.Do(func() {
+		s.shutdown(reason)
+	})
+}
+
+// shutdown performs the actual graceful shutdown sequence.
+func (s *Server) shutdown(reason string) {
+	defer close(s.done)
+
+	start := time.Now()
+	s.emit(ShutdownInitiated{Time: start, Reason: reason})
+
+	// Mark as not ready (gRPC health reports NOT_SERVING for all services)
 	s.healthHandler.SetReady(false)
+	if s.grpcHealth != nil {
+		s.grpcHealth.Shutdown()
+	}
+
+	// Keep serving while load balancers observe the readiness change
+	if s.cfg.shutdownDelay > 0 {
+		s.logger.Info("waiting before draining connections", "component", "shutdown", "delay", s.cfg.shutdownDelay)
+		time.Sleep(s.cfg.shutdownDelay)
+	}
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), s.cfg.gracefulTimeout)
 	defer cancel()
 
-	// Shutdown HTTP server
+	// Warn long-running handlers (see ShutdownContext) before draining
+	s.drain(ErrServerShuttingDown)
+
+	// Shutdown HTTP server, closing the connections left at the deadline
 	if s.httpServer != nil {
 		if err := s.httpServer.Shutdown(ctx); err != nil {
-			log.Printf("HTTP server shutdown error: %v", err)
+			s.logger.Error("HTTP server shutdown failed", "component", "shutdown", "error", err)
+			s.httpServer.Close()
+		}
+	}
+
+	if s.grpcPortServer != nil {
+		if err := s.grpcPortServer.Shutdown(ctx); err != nil {
+			s.logger.Error("gRPC port HTTP server shutdown failed", "component", "shutdown", "error", err)
+			s.grpcPortServer.Close()
 		}
 	}
 
 	// Gracefully stop gRPC server
-	s.grpcServer.GracefulStop()
+	s.stopGRPC(ctx)
 
-	log.Println("Server stopped")
+	s.logger.Info("server stopped", "component", "shutdown", "duration", time.Since(start).Round(time.Millisecond))
+	s.emit(ShutdownComplete{Time: time.Now(), Duration: time.Since(start)})
 }
 
 // SetReady sets the readiness state of the server.
 // Use this to temporarily mark the server as not ready during maintenance.
 func (s *Server) SetReady(ready bool) {
 	s.healthHandler.SetReady(ready)
+	if s.grpcHealth != nil {
+		status := healthpb.HealthCheckResponse_SERVING
+		if !ready {
+			status = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		s.grpcHealth.SetServingStatus("", status)
+	}
 }
 
 // GRPCServer returns the underlying gRPC server.
@@ -435,20 +955,18 @@ func (s *Server) HTTPServer() *http.Server {
 }
 
 // wrapUnaryInterceptor wraps an interceptor with endpoint exclusion logic.
-// Uses a pre-built map for O(1) endpoint lookup.
+// Uses compiled patterns for O(1) exact endpoint lookup.
 func wrapUnaryInterceptor(reg unaryInterceptorRegistration) grpc.UnaryServerInterceptor {
 	if len(reg.exceptEndpoints) == 0 {
 		return reg.interceptor
 	}
 
-	// Build map for O(1) lookups
-	exceptMap := make(map[string]bool, len(reg.exceptEndpoints))
-	for _, ep := range reg.exceptEndpoints {
-		exceptMap[ep] = true
-	}
+	// Compile patterns for O(1) exact lookups
+	except := pathmatch.Compile(reg.exceptEndpoints...)
 
 	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		if exceptMap[info.FullMethod] {
+		if except.Match(info.FullMethod) {
+			traceSkipped(ctx)
 			return handler(ctx, req) // Skip interceptor
 		}
 		return reg.interceptor(ctx, req, info, handler)
@@ -456,20 +974,20 @@ func wrapUnaryInterceptor(reg unaryInterceptorRegistration) grpc.UnaryServerInte
 }
 
 // wrapStreamInterceptor wraps a stream interceptor with endpoint exclusion logic.
-// Uses a pre-built map for O(1) endpoint lookup.
+// Uses compiled patterns for O(1) exact endpoint lookup.
 func wrapStreamInterceptor(reg streamInterceptorRegistration) grpc.StreamServerInterceptor {
 	if len(reg.exceptEndpoints) == 0 {
 		return reg.interceptor
 	}
 
-	// Build map for O(1) lookups
-	exceptMap := make(map[string]bool, len(reg.exceptEndpoints))
-	for _, ep := range reg.exceptEndpoints {
-		exceptMap[ep] = true
-	}
+	// Compile patterns for O(1) exact lookups
+	except := pathmatch.Compile(reg.exceptEndpoints...)
 
 	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		if exceptMap[info.FullMethod] {
+		if except.Match(info.FullMethod) {
+			if ss != nil {
+				traceSkipped(ss.Context())
+			}
 			return handler(srv, ss) // Skip interceptor
 		}
 		return reg.interceptor(srv, ss, info, handler)
@@ -2,6 +2,7 @@ package grpckit
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -323,6 +324,41 @@ func TestWrapUnaryInterceptor_WithExceptions(t *testing.T) {
 	}
 }
 
+func TestWrapUnaryInterceptor_WildcardExceptions(t *testing.T) {
+	called := false
+	reg := unaryInterceptorRegistration{
+		interceptor: func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			called = true
+			return handler(ctx, req)
+		},
+		exceptEndpoints: []string{"/grpc.health.v1.Health/*"},
+	}
+	wrapped := wrapUnaryInterceptor(reg)
+	handler := func(ctx context.Context, req any) (any, error) { return nil, nil }
+
+	for method, want := range map[string]bool{
+		"/grpc.health.v1.Health/Check": false,
+		"/grpc.health.v1.Health/Watch": false,
+		"/item.v1.ItemService/GetItem": true,
+	} {
+		called = false
+		wrapped(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, handler)
+		if called != want {
+			t.Errorf("%s: interceptor called = %v, want %v", method, called, want)
+		}
+	}
+}
+
+func TestWithUnaryInterceptor_InvalidException(t *testing.T) {
+	cfg := newServerConfig()
+	WithUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(ctx, req)
+	}, ExceptEndpoints("/a/[*"))(cfg)
+	if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", cfg.errs)
+	}
+}
+
 func TestWrapStreamInterceptor_NoExceptions(t *testing.T) {
 	called := false
 	interceptor := func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
@@ -421,3 +457,32 @@ func TestNew_DifferentPortMode(t *testing.T) {
 		t.Error("expected different ports for separate mode")
 	}
 }
+
+func TestShutdown_DelayKeepsServingWhileNotReady(t *testing.T) {
+	server, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithShutdownDelay(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	start := time.Now()
+	go server.Shutdown()
+
+	// Readiness flips immediately, before draining begins
+	time.Sleep(20 * time.Millisecond)
+	if server.healthHandler.IsReady() {
+		t.Error("expected server to be not ready during shutdown delay")
+	}
+	select {
+	case <-server.done:
+		t.Fatal("expected shutdown to wait for the delay")
+	default:
+	}
+
+	<-server.done
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("expected shutdown to take at least 100ms, took %v", elapsed)
+	}
+}
@@ -0,0 +1,157 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// GRPCLoggingConfig configures the built-in gRPC request logging.
+type GRPCLoggingConfig struct {
+	// ExcludeMethods lists methods that are not logged, in the format
+	// /package.Service/Method. Glob patterns are supported.
+	ExcludeMethods []string
+
+	// LogPayloads logs request and response messages of unary calls when the
+	// log level is debug. Payloads may contain sensitive data.
+	LogPayloads bool
+}
+
+// grpcLoggingConfig is the compiled form of GRPCLoggingConfig.
+type grpcLoggingConfig struct {
+	logPayloads bool
+	exclude     *pathmatch.Matcher
+}
+
+// WithGRPCLogging logs one line per gRPC call with the method, status code,
+// duration, peer and request ID, plus any fields handlers attached with
+// LogFields. Successful calls are logged at info level, failed calls at warn.
+//
+// Example:
+//
+//	grpckit.WithGRPCLogging(grpckit.GRPCLoggingConfig{
+//	    ExcludeMethods: []string{"/grpc.health.v1.Health/*"},
+//	    LogPayloads:    true, // only at debug level
+//	})
+//
+// Example output:
+//
+//	level=INFO msg=call component=grpc method=/item.v1.ItemService/GetItem code=OK duration=812µs peer=127.0.0.1:53412 request_id=4bf92f35...
+func WithGRPCLogging(config GRPCLoggingConfig) Option {
+	return func(c *serverConfig) {
+		c.grpcLogging = &grpcLoggingConfig{
+			logPayloads: config.LogPayloads,
+			exclude:     c.compilePatterns("WithGRPCLogging", config.ExcludeMethods),
+		}
+	}
+}
+
+// grpcLoggingUnaryInterceptor logs unary calls.
+func grpcLoggingUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	cfg := s.cfg.grpcLogging
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if cfg.exclude.Match(info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		ctx = ensureGRPCRequestID(ctx)
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		line := grpcLogLine(ctx, info.FullMethod, err, time.Since(start))
+		if cfg.logPayloads && s.logger.Enabled(ctx, slog.LevelDebug) {
+			line.Add("request", formatPayload(req))
+			if err == nil {
+				line.Add("response", formatPayload(resp))
+			}
+		}
+		logGRPCCall(s, ctx, line, err)
+		return resp, err
+	}
+}
+
+// grpcLoggingStreamInterceptor logs streaming calls when they end.
+func grpcLoggingStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	cfg := s.cfg.grpcLogging
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if cfg.exclude.Match(info.FullMethod) {
+			return handler(srv, ss)
+		}
+
+		ctx := ensureGRPCRequestID(ss.Context())
+		start := time.Now()
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+
+		logGRPCCall(s, ctx, grpcLogLine(ctx, info.FullMethod, err, time.Since(start)), err)
+		return err
+	}
+}
+
+// ensureGRPCRequestID assigns a request ID to calls that arrived without one
+// (e.g. direct gRPC clients), so every logged call can be correlated.
+func ensureGRPCRequestID(ctx context.Context) context.Context {
+	if requestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	id := newRequestID()
+	LogFields(ctx).Add("request_id", id)
+	return contextWithRequestID(ctx, id)
+}
+
+// grpcLogLine builds the standard fields of a gRPC log line.
+func grpcLogLine(ctx context.Context, method string, err error, duration time.Duration) *LogFieldSet {
+	line := &LogFieldSet{}
+	line.Add("method", method).
+		Add("code", status.Code(err).String()).
+		Add("duration", duration.Round(time.Microsecond))
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		line.Add("peer", p.Addr.String())
+	}
+	if err != nil {
+		line.Add("error", status.Convert(err).Message())
+	}
+	return line
+}
+
+// logGRPCCall writes the log line if the call's level is enabled, appending
+// the fields attached via LogFields (including request_id).
+func logGRPCCall(s *Server, ctx context.Context, line *LogFieldSet, err error) {
+	level := slog.LevelInfo
+	if status.Code(err) != codes.OK {
+		level = slog.LevelWarn
+	}
+	if !s.logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := append([]slog.Attr{slog.String("component", "grpc")}, line.attrs()...)
+	s.logger.LogAttrs(ctx, level, "call", append(attrs, LogFields(ctx).attrs()...)...)
+}
+
+// formatPayload renders a message for logging, as compact JSON for protobuf messages.
+func formatPayload(v interface{}) string {
+	if m, ok := v.(proto.Message); ok {
+		if b, err := protojson.Marshal(m); err == nil {
+			return string(b)
+		}
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,158 @@
+package grpckit
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func newLoggingTestServer(t *testing.T, level string, config GRPCLoggingConfig) *Server {
+	t.Helper()
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithLogLevel(level),
+		WithGRPCLogging(config),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+// callUnary runs a unary call through the correlation and logging interceptors.
+func callUnary(s *Server, ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	info := &grpc.UnaryServerInfo{FullMethod: method}
+	logging := grpcLoggingUnaryInterceptor(s)
+	return correlationUnaryInterceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return logging(ctx, req, info, handler)
+	})
+}
+
+func TestGRPCLogging_Unary(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "info", GRPCLoggingConfig{})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-1"))
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}})
+
+	callUnary(s, ctx, "/test.Service/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		LogFields(ctx).Add("item_id", "i-1")
+		return nil, nil
+	})
+
+	line := buf.String()
+	for _, want := range []string{
+		"msg=call component=grpc method=/test.Service/Get code=OK duration=",
+		"peer=10.0.0.1:5000",
+		"request_id=req-1 item_id=i-1",
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected log line to contain %q, got %q", want, line)
+		}
+	}
+}
+
+func TestGRPCLogging_GeneratesRequestID(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "info", GRPCLoggingConfig{})
+
+	var id string
+	callUnary(s, context.Background(), "/test.Service/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		id = requestIDFromContext(ctx)
+		return nil, nil
+	})
+
+	if id == "" {
+		t.Fatal("expected request ID to be generated for direct gRPC calls")
+	}
+	if !strings.Contains(buf.String(), "request_id="+id) {
+		t.Errorf("expected generated request ID in log, got %q", buf.String())
+	}
+}
+
+func TestGRPCLogging_Levels(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "warn", GRPCLoggingConfig{})
+
+	callUnary(s, context.Background(), "/test.Service/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if buf.Len() != 0 {
+		t.Errorf("expected successful call not to be logged at warn, got %q", buf.String())
+	}
+
+	callUnary(s, context.Background(), "/test.Service/Get", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.NotFound, "no such item")
+	})
+	if line := buf.String(); !strings.Contains(line, "code=NotFound") || !strings.Contains(line, `error="no such item"`) {
+		t.Errorf("expected failed call to be logged, got %q", line)
+	}
+}
+
+func TestGRPCLogging_Payloads(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "debug", GRPCLoggingConfig{LogPayloads: true})
+
+	callUnary(s, context.Background(), "/test.Service/Echo", wrapperspb.String("ping"),
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return wrapperspb.String("pong"), nil
+		})
+
+	line := buf.String()
+	if !strings.Contains(line, `request="\"ping\""`) || !strings.Contains(line, `response="\"pong\""`) {
+		t.Errorf("expected payloads in debug log, got %q", line)
+	}
+
+	// Payloads are omitted above debug level
+	buf.Reset()
+	s.SetLogLevel("info")
+	callUnary(s, context.Background(), "/test.Service/Echo", wrapperspb.String("ping"),
+		func(ctx context.Context, req interface{}) (interface{}, error) {
+			return wrapperspb.String("pong"), nil
+		})
+	if strings.Contains(buf.String(), "request=") {
+		t.Errorf("expected no payloads at info level, got %q", buf.String())
+	}
+}
+
+func TestGRPCLogging_ExcludeMethods(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "info", GRPCLoggingConfig{
+		ExcludeMethods: []string{"/grpc.health.v1.Health/*"},
+	})
+
+	callUnary(s, context.Background(), "/grpc.health.v1.Health/Check", nil, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+
+	if buf.Len() != 0 {
+		t.Errorf("expected excluded method not to be logged, got %q", buf.String())
+	}
+}
+
+func TestGRPCLogging_Stream(t *testing.T) {
+	buf := captureLog(t)
+	s := newLoggingTestServer(t, "info", GRPCLoggingConfig{})
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-s"))
+	ss := &contextServerStream{ctx: correlationContext(ctx)}
+	err := grpcLoggingStreamInterceptor(s)(nil, ss, &grpc.StreamServerInfo{FullMethod: "/test.Service/Watch"},
+		func(srv interface{}, ss grpc.ServerStream) error {
+			return status.Error(codes.Unavailable, "gone")
+		})
+
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected handler error to be returned, got %v", err)
+	}
+	if line := buf.String(); !strings.Contains(line, "method=/test.Service/Watch code=Unavailable") || !strings.Contains(line, "request_id=req-s") {
+		t.Errorf("unexpected stream log line: %q", line)
+	}
+}
@@ -0,0 +1,179 @@
+package grpckit
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// WithGRPCPortEndpoints also serves the health and metrics endpoints on the
+// gRPC port in split-port mode, so probes and scrapers can reach them where
+// the HTTP port is internal-only. Connections starting with the HTTP/2
+// preface go to the gRPC server as before; other connections (HTTP/1.1
+// probes and scrapers) are served the enabled endpoints at their configured
+// paths, and get 404 for anything else. Metrics restrictions apply on both
+// ports.
+//
+// It has no effect in single-port mode, where the endpoints are already
+// served on the shared port. TLS on the gRPC port is not supported, as the
+// protocol can't be told apart before the handshake.
+//
+// Example:
+//
+//	grpckit.WithHealthCheck(),
+//	grpckit.WithMetrics(),
+//	grpckit.WithGRPCPortEndpoints(),
+func WithGRPCPortEndpoints() Option {
+	return func(c *serverConfig) {
+		c.grpcPortEndpoints = true
+	}
+}
+
+// grpcPortHandler returns the handler of the built-in endpoints served on
+// the gRPC port.
+func (s *Server) grpcPortHandler() http.Handler {
+	mux := http.NewServeMux()
+	if s.cfg.healthEnabled {
+		registerHealthEndpoints(mux, s.healthHandler, s.cfg.livenessPath, s.cfg.readinessPath)
+	}
+	if s.cfg.metricsEnabled {
+		registerMetricsEndpoint(mux, s.cfg)
+	}
+	return mux
+}
+
+// sniffTimeout bounds how long a new connection on a split listener may
+// take to send enough bytes to tell its protocol.
+const sniffTimeout = 10 * time.Second
+
+// grpcPortReadHeaderTimeout bounds how long the endpoints on the gRPC port
+// wait for request headers, so idle clients can't hold connections open.
+const grpcPortReadHeaderTimeout = 10 * time.Second
+
+// splitListener splits the connections of a listener by protocol: HTTP/2
+// connections are accepted from grpc, the others from http. Closing grpc
+// closes the underlying listener; closing http only stops its connections.
+type splitListener struct {
+	lis  net.Listener
+	grpc *splitChild
+	http *splitChild
+}
+
+// splitChild is one side of a splitListener.
+type splitChild struct {
+	parent    *splitListener
+	conns     chan net.Conn
+	done      chan struct{}
+	closeOnce sync.Once
+	closeLis  bool
+	err       error // set before done is closed when lis fails
+}
+
+// newSplitListener starts splitting the connections of lis.
+func newSplitListener(lis net.Listener) (grpcLis, httpLis net.Listener) {
+	l := &splitListener{lis: lis}
+	l.grpc = &splitChild{parent: l, conns: make(chan net.Conn), done: make(chan struct{}), closeLis: true}
+	l.http = &splitChild{parent: l, conns: make(chan net.Conn), done: make(chan struct{})}
+	go l.serve()
+	return l.grpc, l.http
+}
+
+// serve accepts connections until the underlying listener fails.
+func (l *splitListener) serve() {
+	for {
+		conn, err := l.lis.Accept()
+		if err != nil {
+			l.grpc.fail(err)
+			l.http.fail(err)
+			return
+		}
+		go l.dispatch(conn)
+	}
+}
+
+// dispatch reads from conn until its protocol is known and hands it over,
+// with the bytes read replayed, to the matching child.
+func (l *splitListener) dispatch(conn net.Conn) {
+	preface := []byte(http2.ClientPreface)
+	buf := make([]byte, len(preface))
+	n := 0
+
+	_ = conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	for n < len(buf) && bytes.Equal(buf[:n], preface[:n]) {
+		read, err := conn.Read(buf[n:])
+		n += read
+		if err != nil {
+			break
+		}
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+	if n == 0 {
+		conn.Close()
+		return
+	}
+
+	child := l.http
+	if bytes.Equal(buf[:n], preface) {
+		child = l.grpc
+	}
+	child.deliver(&sniffedConn{Conn: conn, r: io.MultiReader(bytes.NewReader(buf[:n]), conn)})
+}
+
+// deliver hands conn to the Accept of c, closing it if c is closed.
+func (c *splitChild) deliver(conn net.Conn) {
+	select {
+	case c.conns <- conn:
+	case <-c.done:
+		conn.Close()
+	}
+}
+
+// fail closes c because the underlying listener failed with err.
+func (c *splitChild) fail(err error) {
+	c.closeOnce.Do(func() {
+		c.err = err
+		close(c.done)
+	})
+}
+
+func (c *splitChild) Accept() (net.Conn, error) {
+	select {
+	case conn := <-c.conns:
+		return conn, nil
+	case <-c.done:
+		if c.err != nil {
+			return nil, c.err
+		}
+		return nil, net.ErrClosed
+	}
+}
+
+func (c *splitChild) Close() error {
+	var err error
+	c.closeOnce.Do(func() {
+		close(c.done)
+		if c.closeLis {
+			err = c.parent.lis.Close()
+		}
+	})
+	return err
+}
+
+func (c *splitChild) Addr() net.Addr {
+	return c.parent.lis.Addr()
+}
+
+// sniffedConn is a connection whose first bytes were already read.
+type sniffedConn struct {
+	net.Conn
+	r io.Reader
+}
+
+func (c *sniffedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
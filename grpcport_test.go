@@ -0,0 +1,107 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSplitListener(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithHealthCheck(), WithMetrics(), WithGRPCPortEndpoints())
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	grpcLis, httpLis := newSplitListener(lis)
+
+	grpcServer := grpc.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, health.NewServer())
+	grpcDone := make(chan error, 1)
+	go func() { grpcDone <- grpcServer.Serve(grpcLis) }()
+
+	httpServer := &http.Server{Handler: s.grpcPortHandler()}
+	httpDone := make(chan error, 1)
+	go func() { httpDone <- httpServer.Serve(httpLis) }()
+
+	// gRPC calls reach the gRPC server
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil || resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("expected gRPC health check to succeed, got %v, %v", resp, err)
+	}
+
+	// HTTP/1.1 requests get the built-in endpoints only
+	for path, want := range map[string]int{"/healthz": http.StatusOK, "/metrics": http.StatusOK, "/v1/items": http.StatusNotFound} {
+		res, err := http.Get("http://" + lis.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("GET %s: expected %d, got %d", path, want, res.StatusCode)
+		}
+	}
+
+	// Closing the HTTP side keeps gRPC serving; stopping gRPC closes the listener
+	if err := httpServer.Shutdown(ctx); err != nil {
+		t.Fatalf("HTTP shutdown: %v", err)
+	}
+	if err := <-httpDone; !errors.Is(err, http.ErrServerClosed) {
+		t.Errorf("expected ErrServerClosed, got %v", err)
+	}
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Errorf("expected gRPC to keep serving, got %v", err)
+	}
+	grpcServer.GracefulStop()
+	if err := <-grpcDone; err != nil {
+		t.Errorf("expected gRPC Serve to return nil, got %v", err)
+	}
+	if _, err := net.Dial("tcp", lis.Addr().String()); err == nil {
+		t.Error("expected listener to be closed")
+	}
+}
+
+func TestWithGRPCPortEndpoints_Validation(t *testing.T) {
+	_, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCPortEndpoints(),
+	)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig without built-in endpoints, got %v", err)
+	}
+}
+
+func TestWithGRPCPortEndpoints_Server(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithHealthCheck(),
+		WithGRPCPortEndpoints(),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// The server exists before Start, so Shutdown can't race with it
+	if s.grpcPortServer == nil || s.grpcPortServer.ReadHeaderTimeout != grpcPortReadHeaderTimeout {
+		t.Fatalf("expected gRPC port server with a read header timeout, got %+v", s.grpcPortServer)
+	}
+	s.Shutdown()
+}
@@ -0,0 +1,204 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultMaxRecvMsgSize is grpc-go's default limit for received messages.
+const defaultMaxRecvMsgSize = 4 << 20
+
+// messageSizeBuckets are the histogram buckets for gRPC message sizes:
+// 64 bytes to 16MB.
+var messageSizeBuckets = []float64{64, 256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+
+// MessageSizeLimits bounds the size of gRPC messages in bytes (serialized,
+// uncompressed). Zero means no limit.
+type MessageSizeLimits struct {
+	// MaxRecv limits each request message.
+	MaxRecv int
+
+	// MaxSend limits each response message.
+	MaxSend int
+}
+
+// messageSizeLimit holds MessageSizeLimits for the methods matching its patterns.
+type messageSizeLimit struct {
+	methods *pathmatch.Matcher
+	limits  MessageSizeLimits
+}
+
+// WithGRPCMessageSizeLimits sets per-method message size limits. Methods are
+// given as /package.Service/Method and support glob patterns; without
+// methods the limits apply to all methods. The first matching limits win.
+// Oversized messages are rejected with codes.ResourceExhausted and an
+// ErrorInfo detail (reason MESSAGE_TOO_LARGE).
+//
+// Limits above grpc-go's default of 4MB raise the transport limit for the
+// matching methods only; other methods keep the 4MB limit.
+//
+// Example:
+//
+//	grpckit.WithGRPCMessageSizeLimits(grpckit.MessageSizeLimits{MaxRecv: 32 << 20}, "/media.v1.MediaService/Upload"),
+//	grpckit.WithGRPCMessageSizeLimits(grpckit.MessageSizeLimits{MaxRecv: 64 << 10}, "/item.v1.ItemService/*"),
+func WithGRPCMessageSizeLimits(limits MessageSizeLimits, methods ...string) Option {
+	return func(c *serverConfig) {
+		c.grpcSizeLimits = append(c.grpcSizeLimits, messageSizeLimit{
+			methods: c.compilePatterns("WithGRPCMessageSizeLimits", methods),
+			limits:  limits,
+		})
+	}
+}
+
+// maxRecvMsgSize returns the transport limit needed for the configured
+// limits, or 0 if the grpc-go default suffices.
+func maxRecvMsgSize(limits []messageSizeLimit) int {
+	max := 0
+	for _, l := range limits {
+		if l.limits.MaxRecv > max {
+			max = l.limits.MaxRecv
+		}
+	}
+	if max <= defaultMaxRecvMsgSize {
+		return 0
+	}
+	return max
+}
+
+// messageSizes measures and limits the messages of one method.
+type messageSizes struct {
+	method  string
+	limits  MessageSizeLimits
+	metrics *Metrics
+}
+
+// newMessageSizes resolves the limits for method.
+func newMessageSizes(s *Server, method string) *messageSizes {
+	m := &messageSizes{method: method, metrics: s.metrics}
+	for _, l := range s.cfg.grpcSizeLimits {
+		if l.methods.Empty() || l.methods.Match(method) {
+			m.limits = l.limits
+			return m
+		}
+	}
+	// A raised transport limit must not apply to methods without limits
+	if maxRecvMsgSize(s.cfg.grpcSizeLimits) > 0 {
+		m.limits.MaxRecv = defaultMaxRecvMsgSize
+	}
+	return m
+}
+
+// recv records and checks a received message.
+func (m *messageSizes) recv(msg interface{}) error {
+	size := messageSize(msg)
+	if m.metrics != nil {
+		m.metrics.grpcRequestSize.WithLabelValues(m.method).Observe(float64(size))
+	}
+	if m.limits.MaxRecv > 0 && size > m.limits.MaxRecv {
+		return m.tooLarge("request", size, m.limits.MaxRecv)
+	}
+	return nil
+}
+
+// send records and checks a message about to be sent.
+func (m *messageSizes) send(msg interface{}) error {
+	size := messageSize(msg)
+	if m.metrics != nil {
+		m.metrics.grpcResponseSize.WithLabelValues(m.method).Observe(float64(size))
+	}
+	if m.limits.MaxSend > 0 && size > m.limits.MaxSend {
+		return m.tooLarge("response", size, m.limits.MaxSend)
+	}
+	return nil
+}
+
+// tooLarge builds the ResourceExhausted error for an oversized message.
+func (m *messageSizes) tooLarge(kind string, size, limit int) error {
+	st := status.New(codes.ResourceExhausted,
+		fmt.Sprintf("%s message of %d bytes exceeds the limit of %d bytes", kind, size, limit))
+	detailed, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason: "MESSAGE_TOO_LARGE",
+		Domain: "grpckit",
+		Metadata: map[string]string{
+			"method":  m.method,
+			"message": kind,
+			"size":    strconv.Itoa(size),
+			"limit":   strconv.Itoa(limit),
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
+
+// messageSize returns the serialized size of a proto message.
+func messageSize(msg interface{}) int {
+	if pm, ok := msg.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
+
+// messageSizeUnaryInterceptor records message sizes and enforces limits.
+func messageSizeUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		sizes := newMessageSizes(s, info.FullMethod)
+		if err := sizes.recv(req); err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		if err := sizes.send(resp); err != nil {
+			return nil, err
+		}
+		return resp, nil
+	}
+}
+
+// messageSizeStreamInterceptor records and limits every stream message.
+func messageSizeStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		return handler(srv, &sizedServerStream{ServerStream: ss, sizes: newMessageSizes(s, info.FullMethod)})
+	}
+}
+
+// sizedServerStream applies messageSizes to each message of a stream.
+type sizedServerStream struct {
+	grpc.ServerStream
+	sizes *messageSizes
+}
+
+func (s *sizedServerStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.sizes.recv(m)
+}
+
+func (s *sizedServerStream) SendMsg(m interface{}) error {
+	if err := s.sizes.send(m); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
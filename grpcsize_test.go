@@ -0,0 +1,153 @@
+package grpckit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func sizeTestServer(opts ...Option) *Server {
+	cfg := newServerConfig()
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return &Server{cfg: cfg}
+}
+
+func TestMessageSizeUnaryInterceptor_Limits(t *testing.T) {
+	s := sizeTestServer(
+		WithGRPCMessageSizeLimits(MessageSizeLimits{MaxRecv: 10, MaxSend: 20}, "/test.Service/*"),
+	)
+	interceptor := messageSizeUnaryInterceptor(s)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+	echo := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.String(strings.Repeat("r", 30)), nil
+	}
+
+	// Request over the limit
+	_, err := interceptor(context.Background(), wrapperspb.String(strings.Repeat("x", 20)), info, echo)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	if !strings.Contains(status.Convert(err).Message(), "request message of 22 bytes exceeds the limit of 10 bytes") {
+		t.Errorf("unexpected message %q", status.Convert(err).Message())
+	}
+	var detail *errdetails.ErrorInfo
+	for _, d := range status.Convert(err).Details() {
+		if ei, ok := d.(*errdetails.ErrorInfo); ok {
+			detail = ei
+		}
+	}
+	if detail == nil || detail.Reason != "MESSAGE_TOO_LARGE" || detail.Metadata["method"] != "/test.Service/Get" || detail.Metadata["limit"] != "10" {
+		t.Errorf("expected ErrorInfo detail, got %v", detail)
+	}
+
+	// Response over the limit
+	_, err = interceptor(context.Background(), wrapperspb.String("ok"), info, echo)
+	if status.Code(err) != codes.ResourceExhausted || !strings.Contains(err.Error(), "response message") {
+		t.Errorf("expected response limit error, got %v", err)
+	}
+
+	// Other methods are not limited
+	other := &grpc.UnaryServerInfo{FullMethod: "/other.Service/Get"}
+	if _, err := interceptor(context.Background(), wrapperspb.String(strings.Repeat("x", 20)), other, echo); err != nil {
+		t.Errorf("expected other method to pass, got %v", err)
+	}
+}
+
+func TestMessageSizes_RaisedTransportLimit(t *testing.T) {
+	s := sizeTestServer(
+		WithGRPCMessageSizeLimits(MessageSizeLimits{MaxRecv: 32 << 20}, "/media.Service/Upload"),
+	)
+
+	if got := maxRecvMsgSize(s.cfg.grpcSizeLimits); got != 32<<20 {
+		t.Errorf("expected transport limit of 32MB, got %d", got)
+	}
+	if got := newMessageSizes(s, "/media.Service/Upload").limits.MaxRecv; got != 32<<20 {
+		t.Errorf("expected 32MB for matching method, got %d", got)
+	}
+	if got := newMessageSizes(s, "/item.Service/Get").limits.MaxRecv; got != defaultMaxRecvMsgSize {
+		t.Errorf("expected default limit for other methods, got %d", got)
+	}
+
+	small := sizeTestServer(WithGRPCMessageSizeLimits(MessageSizeLimits{MaxRecv: 1 << 20}))
+	if got := maxRecvMsgSize(small.cfg.grpcSizeLimits); got != 0 {
+		t.Errorf("expected no transport change for small limits, got %d", got)
+	}
+	if got := newMessageSizes(small, "/item.Service/Get").limits.MaxRecv; got != 1<<20 {
+		t.Errorf("expected limits without methods to apply to all, got %d", got)
+	}
+}
+
+// countingServerStream counts sent messages.
+type countingServerStream struct {
+	grpc.ServerStream
+	sent int
+}
+
+func (s *countingServerStream) SendMsg(m interface{}) error {
+	s.sent++
+	return nil
+}
+
+func TestMessageSizeStreamInterceptor(t *testing.T) {
+	s := sizeTestServer(WithGRPCMessageSizeLimits(MessageSizeLimits{MaxSend: 5}))
+	interceptor := messageSizeStreamInterceptor(s)
+	info := &grpc.StreamServerInfo{FullMethod: "/test.Service/List"}
+
+	stream := &countingServerStream{}
+	err := interceptor(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+		if err := ss.SendMsg(wrapperspb.String("ok")); err != nil {
+			return err
+		}
+		return ss.SendMsg(wrapperspb.String("too long"))
+	})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted for oversized stream message, got %v", err)
+	}
+	if stream.sent != 1 {
+		t.Errorf("expected only the first message to be sent, got %d", stream.sent)
+	}
+}
+
+func TestMessageSizeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	prometheus.DefaultRegisterer = reg
+	s := sizeTestServer()
+	s.metrics = newMetrics("test_sizes", prometheus.DefaultRegisterer)
+
+	interceptor := messageSizeUnaryInterceptor(s)
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+	_, err := interceptor(context.Background(), wrapperspb.String("hello"), info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return wrapperspb.String("hello world"), nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	sums := map[string]float64{}
+	for _, mf := range families {
+		for _, m := range mf.GetMetric() {
+			if h := m.GetHistogram(); h != nil && h.GetSampleCount() == 1 {
+				sums[mf.GetName()] = h.GetSampleSum()
+			}
+		}
+	}
+	if sums["test_sizes_grpc_request_size_bytes"] != 7 {
+		t.Errorf("expected request size 7, got %v", sums)
+	}
+	if sums["test_sizes_grpc_response_size_bytes"] != 13 {
+		t.Errorf("expected response size 13, got %v", sums)
+	}
+}
@@ -0,0 +1,79 @@
+package grpckit
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Handler returns the fully composed HTTP handler (built-in endpoints,
+// custom handlers and the grpc-gateway, wrapped in the middleware chain)
+// without starting any listener, so grpckit can be mounted in an HTTP server
+// or serverless runtime that owns the listener. The gateway reaches the gRPC
+// services in memory. HTTP/2 gRPC requests are served too, if the host
+// server supports them (e.g. with h2c or TLS).
+//
+// Use either Handler or Start, not both. The handler is built on the first
+// call; later calls return the same handler. Shutdown stops the in-memory
+// gRPC server, but the host owns (and must shut down) its HTTP server.
+//
+// Example:
+//
+//	h, err := server.Handler()
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	mux := http.NewServeMux()
+//	mux.Handle("/api/", h)
+//	mux.HandleFunc("/legacy", legacyHandler)
+func (s *Server) Handler() (http.Handler, error) {
+	s.handlerOnce.Do(func() {
+		s.started.Store(true)
+		s.handler, s.handlerErr = s.buildInMemoryHandler()
+	})
+	return s.handler, s.handlerErr
+}
+
+// buildInMemoryHandler serves the gRPC server on an in-memory listener and
+// builds the HTTP handler with a gateway connected to it.
+func (s *Server) buildInMemoryHandler() (http.Handler, error) {
+	lis := bufconn.Listen(bufSize)
+	go func() {
+		_ = s.grpcServer.Serve(lis)
+	}()
+
+	// Gateway connections are closed when the context is canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	opts := []grpc.DialOption{
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	gwMux, err := s.newGatewayMux(ctx, "passthrough:///bufnet", opts)
+	if err != nil {
+		cancel()
+		s.grpcServer.Stop()
+		return nil, err
+	}
+
+	httpHandler := s.buildHTTPHandler(gwMux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			s.grpcServer.ServeHTTP(w, r)
+			return
+		}
+		// Hosts don't use the server's base context
+		httpHandler.ServeHTTP(w, r.WithContext(s.withServer(r.Context())))
+	}), nil
+}
@@ -0,0 +1,57 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/grpc"
+)
+
+func TestServer_Handler(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, itempb.UnimplementedItemServiceServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+		WithHealthCheck(),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Shutdown()
+
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	// Mounted in a host mux next to its own routes
+	mux := http.NewServeMux()
+	mux.Handle("/", h)
+	mux.HandleFunc("/legacy", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	for path, want := range map[string]int{
+		"/healthz":      http.StatusOK,
+		"/api/v1/items": http.StatusNotImplemented, // reached the gRPC service in memory
+		"/legacy":       http.StatusTeapot,
+	} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("GET %s: expected %d, got %d: %s", path, want, rec.Code, rec.Body.String())
+		}
+	}
+
+	again, err := s.Handler()
+	if err != nil || again == nil {
+		t.Errorf("expected the same handler on later calls, got %v, %v", again, err)
+	}
+	if err := s.RegisterGRPCService(func(grpc.ServiceRegistrar) {}); !errors.Is(err, ErrServerStarted) {
+		t.Errorf("expected ErrServerStarted after Handler, got %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package grpckit
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"sync"
 	"sync/atomic"
+	"time"
 )
 
 // Pre-computed response bytes to avoid JSON encoding on every request.
@@ -13,12 +18,171 @@ var (
 
 // HealthStatus represents the health check response.
 type HealthStatus struct {
-	Status string `json:"status"`
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// Default settings for custom health checks.
+const (
+	defaultCheckTimeout  = 2 * time.Second
+	defaultCheckCacheTTL = time.Second
+)
+
+// HealthCheckFunc checks a dependency (database, cache, downstream service).
+// It returns nil when healthy and should honor ctx cancellation.
+type HealthCheckFunc func(ctx context.Context) error
+
+// HealthCheckOption configures a custom health check.
+type HealthCheckOption func(*healthCheck)
+
+// CheckTimeout sets how long a check may run before it is reported as failed.
+// Default: 2s
+func CheckTimeout(d time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.timeout = d
+	}
+}
+
+// CheckCacheTTL sets how long a check result is reused before the check runs
+// again, so frequent probes don't hammer dependencies. Zero disables caching.
+// Default: 1s
+func CheckCacheTTL(d time.Duration) HealthCheckOption {
+	return func(c *healthCheck) {
+		c.ttl = d
+	}
+}
+
+// healthCheck is a named check with timeout, cached result and at most one
+// execution in flight.
+type healthCheck struct {
+	name    string
+	fn      HealthCheckFunc
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu       sync.Mutex
+	lastErr  error
+	lastRun  time.Time
+	inflight chan struct{} // closed when the running check finishes
+}
+
+// newHealthCheck creates a health check with defaults applied.
+func newHealthCheck(name string, fn HealthCheckFunc, opts ...HealthCheckOption) *healthCheck {
+	c := &healthCheck{
+		name:    name,
+		fn:      fn,
+		timeout: defaultCheckTimeout,
+		ttl:     defaultCheckCacheTTL,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// run returns the check result, reusing a fresh cached result and never
+// waiting longer than the check timeout, even if the check itself hangs.
+func (c *healthCheck) run() error {
+	c.mu.Lock()
+	if !c.lastRun.IsZero() && time.Since(c.lastRun) < c.ttl {
+		err := c.lastErr
+		c.mu.Unlock()
+		return err
+	}
+	done := c.inflight
+	if done == nil {
+		done = make(chan struct{})
+		c.inflight = done
+		go c.execute(done)
+	}
+	c.mu.Unlock()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return c.lastErr
+	case <-timer.C:
+		return fmt.Errorf("timed out after %s", c.timeout)
+	}
+}
+
+// execute runs the check function and stores its result.
+func (c *healthCheck) execute(done chan struct{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return c.fn(ctx)
+	}()
+	if err == nil && ctx.Err() != nil {
+		err = fmt.Errorf("timed out after %s", c.timeout)
+	}
+
+	c.mu.Lock()
+	c.lastErr = err
+	c.lastRun = time.Now()
+	c.inflight = nil
+	c.mu.Unlock()
+	close(done)
+}
+
+// runHealthChecks runs checks in parallel and returns the per-check results
+// and whether all passed.
+func runHealthChecks(checks []*healthCheck) (map[string]string, bool) {
+	results := make(map[string]string, len(checks))
+	healthy := true
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c *healthCheck) {
+			defer wg.Done()
+			result := "ok"
+			if err := c.run(); err != nil {
+				result = err.Error()
+			}
+			mu.Lock()
+			results[c.name] = result
+			if result != "ok" {
+				healthy = false
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	return results, healthy
+}
+
+// writeHealthStatus writes a health response with per-check results,
+// using failStatus as the status text when unhealthy.
+func writeHealthStatus(w http.ResponseWriter, healthy bool, checks map[string]string, failStatus string) {
+	status := HealthStatus{Status: "ok", Checks: checks}
+	code := http.StatusOK
+	if !healthy {
+		status.Status = failStatus
+		code = http.StatusServiceUnavailable
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
 }
 
 // healthHandler manages health check state and handlers.
 type healthHandler struct {
-	ready atomic.Bool
+	ready           atomic.Bool
+	mu              sync.RWMutex // guards readinessChecks, added to at startup
+	readinessChecks []*healthCheck
+	livenessChecks  []*healthCheck
 }
 
 // newHealthHandler creates a new health handler.
@@ -38,11 +202,38 @@ func (h *healthHandler) IsReady() bool {
 	return h.ready.Load()
 }
 
+// addReadinessCheck adds a readiness check, unless one with the same name
+// exists already, as the gateway is built again by Handler and Start.
+func (h *healthHandler) addReadinessCheck(check *healthCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, c := range h.readinessChecks {
+		if c.name == check.name {
+			return
+		}
+	}
+	// The slice may share its array with the server config
+	h.readinessChecks = append(h.readinessChecks[:len(h.readinessChecks):len(h.readinessChecks)], check)
+}
+
+// readiness returns the readiness checks.
+func (h *healthHandler) readiness() []*healthCheck {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.readinessChecks
+}
+
 // LivenessHandler returns the liveness probe handler.
-// This endpoint always returns 200 OK if the server is running.
-// Uses pre-computed response bytes for optimal performance.
+// This endpoint returns 200 OK if the server is running and all liveness
+// checks pass. Without checks, pre-computed response bytes are used.
 func (h *healthHandler) LivenessHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if len(h.livenessChecks) > 0 {
+			results, healthy := runHealthChecks(h.livenessChecks)
+			writeHealthStatus(w, healthy, results, "unhealthy")
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusOK)
 		_, _ = w.Write(healthOKResponse)
@@ -50,10 +241,17 @@ func (h *healthHandler) LivenessHandler() http.HandlerFunc {
 }
 
 // ReadinessHandler returns the readiness probe handler.
-// This endpoint returns 200 OK if the server is ready to accept traffic.
-// Uses pre-computed response bytes for optimal performance.
+// This endpoint returns 200 OK if the server is ready to accept traffic and
+// all readiness checks pass. Checks run in parallel with per-check timeouts
+// and cached results. Without checks, pre-computed response bytes are used.
 func (h *healthHandler) ReadinessHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		if checks := h.readiness(); h.IsReady() && len(checks) > 0 {
+			results, healthy := runHealthChecks(checks)
+			writeHealthStatus(w, healthy, results, "not ready")
+			return
+		}
+
 		w.Header().Set("Content-Type", "application/json")
 		if h.IsReady() {
 			w.WriteHeader(http.StatusOK)
@@ -65,8 +263,74 @@ func (h *healthHandler) ReadinessHandler() http.HandlerFunc {
 	}
 }
 
-// registerHealthEndpoints registers health check endpoints on the mux.
-func registerHealthEndpoints(mux *http.ServeMux, h *healthHandler) {
-	mux.HandleFunc("/healthz", h.LivenessHandler())
-	mux.HandleFunc("/readyz", h.ReadinessHandler())
+// registerHealthEndpoints registers the liveness and readiness endpoints on
+// the mux.
+func registerHealthEndpoints(mux *http.ServeMux, h *healthHandler, liveness, readiness string) {
+	mux.HandleFunc(liveness, h.LivenessHandler())
+	mux.HandleFunc(readiness, h.ReadinessHandler())
+}
+
+// WithReadinessCheck adds a named dependency check to /readyz. Checks run in
+// parallel, each bounded by its timeout (default 2s), and results are cached
+// (default 1s), so a hung dependency cannot make /readyz itself hang past the
+// probe timeout. Failing checks make /readyz return 503 with per-check details.
+//
+// Example:
+//
+//	grpckit.WithReadinessCheck("postgres", func(ctx context.Context) error {
+//	    return db.PingContext(ctx)
+//	}, grpckit.CheckTimeout(500*time.Millisecond))
+func WithReadinessCheck(name string, check HealthCheckFunc, opts ...HealthCheckOption) Option {
+	return func(c *serverConfig) {
+		c.healthEnabled = true
+		c.readinessChecks = append(c.readinessChecks, newHealthCheck(name, check, opts...))
+	}
+}
+
+// WithLivenessCheck adds a named check to /healthz, so the probe fails (and
+// the orchestrator restarts the process) when an internal component is
+// wedged, e.g. a worker loop that stopped making progress. Checks run like
+// readiness checks: in parallel, with timeouts and cached results.
+//
+// Liveness checks should only test the process itself; checking external
+// dependencies here restarts healthy pods during outages.
+//
+// Example:
+//
+//	hb := grpckit.NewHeartbeat(30 * time.Second)
+//	go worker(hb) // calls hb.Beat() on every loop iteration
+//	grpckit.WithLivenessCheck("worker", hb.Check)
+func WithLivenessCheck(name string, check HealthCheckFunc, opts ...HealthCheckOption) Option {
+	return func(c *serverConfig) {
+		c.healthEnabled = true
+		c.livenessChecks = append(c.livenessChecks, newHealthCheck(name, check, opts...))
+	}
+}
+
+// Heartbeat detects stalled goroutines: the owner calls Beat regularly and
+// Check fails once no beat was recorded within maxAge.
+type Heartbeat struct {
+	maxAge time.Duration
+	last   atomic.Int64 // unix nanoseconds
+}
+
+// NewHeartbeat creates a heartbeat that counts as beating from creation.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	hb := &Heartbeat{maxAge: maxAge}
+	hb.Beat()
+	return hb
+}
+
+// Beat records that the owning goroutine is making progress.
+func (hb *Heartbeat) Beat() {
+	hb.last.Store(time.Now().UnixNano())
+}
+
+// Check is a HealthCheckFunc that fails when the last beat is older than maxAge.
+func (hb *Heartbeat) Check(ctx context.Context) error {
+	age := time.Since(time.Unix(0, hb.last.Load()))
+	if age > hb.maxAge {
+		return fmt.Errorf("no heartbeat for %s", age.Round(time.Millisecond))
+	}
+	return nil
 }
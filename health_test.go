@@ -1,10 +1,15 @@
 package grpckit
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewHealthHandler(t *testing.T) {
@@ -136,7 +141,7 @@ func TestRegisterHealthEndpoints(t *testing.T) {
 	h := newHealthHandler()
 	mux := http.NewServeMux()
 
-	registerHealthEndpoints(mux, h)
+	registerHealthEndpoints(mux, h, "/healthz", "/readyz")
 
 	// Test /healthz
 	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
@@ -157,6 +162,37 @@ func TestRegisterHealthEndpoints(t *testing.T) {
 	}
 }
 
+func TestWithHealthPaths(t *testing.T) {
+	s := newSlowTestServer(t, WithHealthPaths("/internal/healthz", "/internal/readyz"))
+	s.healthHandler.SetReady(true)
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	for path, want := range map[string]int{
+		"/internal/healthz": http.StatusOK,
+		"/internal/readyz":  http.StatusOK,
+		"/healthz":          http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+
+	// The relocated path is free for custom handlers
+	if err := s.RegisterHTTPHandler("/healthz", http.NotFoundHandler()); err != nil {
+		t.Errorf("expected /healthz to be free, got %v", err)
+	}
+
+	for _, paths := range [][2]string{{"", "/readyz"}, {"healthz", "/readyz"}, {"/probe", "/probe"}, {"/", "/readyz"}, {"/healthz", "/"}} {
+		cfg := newServerConfig()
+		WithHealthPaths(paths[0], paths[1])(cfg)
+		if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+			t.Errorf("WithHealthPaths(%q, %q): expected ErrInvalidConfig, got %v", paths[0], paths[1], cfg.errs)
+		}
+	}
+}
+
 func TestHealthStatus_JSONSerialization(t *testing.T) {
 	status := HealthStatus{Status: "ok"}
 
@@ -210,3 +246,175 @@ func TestHealthHandler_ConcurrentAccess(t *testing.T) {
 		<-done
 	}
 }
+
+func TestHealthCheck_Timeout(t *testing.T) {
+	block := make(chan struct{})
+	defer close(block)
+
+	c := newHealthCheck("hung", func(ctx context.Context) error {
+		<-block // ignores ctx, like a wedged driver call
+		return nil
+	}, CheckTimeout(20*time.Millisecond))
+
+	start := time.Now()
+	err := c.run()
+	if err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected run to return near the timeout, took %v", elapsed)
+	}
+}
+
+func TestHealthCheck_Cache(t *testing.T) {
+	var calls atomic.Int32
+	c := newHealthCheck("db", func(ctx context.Context) error {
+		calls.Add(1)
+		return errors.New("down")
+	}, CheckCacheTTL(time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if err := c.run(); err == nil || err.Error() != "down" {
+			t.Fatalf("expected cached error, got %v", err)
+		}
+	}
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected check to run once, ran %d times", n)
+	}
+}
+
+func TestHealthCheck_SingleInflight(t *testing.T) {
+	var calls atomic.Int32
+	block := make(chan struct{})
+	c := newHealthCheck("slow", func(ctx context.Context) error {
+		calls.Add(1)
+		<-block
+		return nil
+	}, CheckTimeout(10*time.Millisecond), CheckCacheTTL(0))
+
+	c.run()
+	c.run()
+	close(block)
+
+	if n := calls.Load(); n != 1 {
+		t.Errorf("expected hung check not to be started again, ran %d times", n)
+	}
+}
+
+func TestHealthCheck_Panic(t *testing.T) {
+	c := newHealthCheck("bad", func(ctx context.Context) error {
+		panic("boom")
+	})
+
+	if err := c.run(); err == nil || !strings.Contains(err.Error(), "panic") {
+		t.Errorf("expected panic to be reported as failure, got %v", err)
+	}
+}
+
+func TestHealthHandler_ReadinessChecks(t *testing.T) {
+	h := newHealthHandler()
+	h.readinessChecks = []*healthCheck{
+		newHealthCheck("db", func(ctx context.Context) error { return nil }),
+		newHealthCheck("cache", func(ctx context.Context) error { return errors.New("connection refused") }),
+	}
+
+	rec := httptest.NewRecorder()
+	h.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+
+	var status HealthStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if status.Checks["db"] != "ok" || status.Checks["cache"] != "connection refused" {
+		t.Errorf("unexpected check results: %v", status.Checks)
+	}
+}
+
+func TestHealthHandler_ReadinessChecksParallel(t *testing.T) {
+	h := newHealthHandler()
+	for _, name := range []string{"a", "b", "c"} {
+		h.readinessChecks = append(h.readinessChecks, newHealthCheck(name, func(ctx context.Context) error {
+			time.Sleep(50 * time.Millisecond)
+			return nil
+		}))
+	}
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	h.ReadinessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200, got %d", rec.Code)
+	}
+	if elapsed := time.Since(start); elapsed > 140*time.Millisecond {
+		t.Errorf("expected checks to run in parallel, took %v", elapsed)
+	}
+}
+
+func TestWithReadinessCheck(t *testing.T) {
+	cfg := newServerConfig()
+	WithReadinessCheck("db", func(ctx context.Context) error { return nil }, CheckTimeout(time.Second))(cfg)
+
+	if !cfg.healthEnabled {
+		t.Error("expected health endpoints to be enabled")
+	}
+	if len(cfg.readinessChecks) != 1 || cfg.readinessChecks[0].timeout != time.Second {
+		t.Errorf("expected one check with 1s timeout, got %v", cfg.readinessChecks)
+	}
+}
+
+func TestHealthHandler_LivenessChecks(t *testing.T) {
+	h := newHealthHandler()
+	h.livenessChecks = []*healthCheck{
+		newHealthCheck("worker", func(ctx context.Context) error { return errors.New("stalled") }),
+	}
+
+	rec := httptest.NewRecorder()
+	h.LivenessHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503, got %d", rec.Code)
+	}
+
+	var status HealthStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if status.Status != "unhealthy" || status.Checks["worker"] != "stalled" {
+		t.Errorf("unexpected liveness status: %+v", status)
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	hb := NewHeartbeat(30 * time.Millisecond)
+
+	if err := hb.Check(context.Background()); err != nil {
+		t.Errorf("expected fresh heartbeat to pass, got %v", err)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if err := hb.Check(context.Background()); err == nil {
+		t.Error("expected stale heartbeat to fail")
+	}
+
+	hb.Beat()
+	if err := hb.Check(context.Background()); err != nil {
+		t.Errorf("expected heartbeat to recover after Beat, got %v", err)
+	}
+}
+
+func TestWithLivenessCheck(t *testing.T) {
+	cfg := newServerConfig()
+	WithLivenessCheck("worker", NewHeartbeat(time.Minute).Check)(cfg)
+
+	if !cfg.healthEnabled {
+		t.Error("expected health endpoints to be enabled")
+	}
+	if len(cfg.livenessChecks) != 1 || cfg.livenessChecks[0].name != "worker" {
+		t.Errorf("expected one liveness check, got %v", cfg.livenessChecks)
+	}
+}
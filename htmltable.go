@@ -0,0 +1,230 @@
+package grpckit
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// htmlTableStyle keeps the tables readable without external assets.
+const htmlTableStyle = `body{font-family:sans-serif;margin:1em}` +
+	`table{border-collapse:collapse;margin-bottom:1em}` +
+	`th,td{border:1px solid #ccc;padding:4px 8px;text-align:left;vertical-align:top}` +
+	`th{background:#f4f4f4}`
+
+// HTMLTableMarshaler renders responses as minimal, read-only HTML tables, so
+// API URLs can be inspected in a browser. Messages with a single repeated
+// message field (list responses) are rendered as one row per element; other
+// messages as a field/value table. Nested messages are shown as JSON. All
+// values are HTML-escaped. Requests cannot be decoded from HTML.
+type HTMLTableMarshaler struct{}
+
+// ContentType returns the MIME type for HTML.
+func (h *HTMLTableMarshaler) ContentType(_ interface{}) string {
+	return "text/html; charset=utf-8"
+}
+
+// Marshal renders v as an HTML page.
+func (h *HTMLTableMarshaler) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	msg, ok := v.(proto.Message)
+	if !ok {
+		// Non-message values (e.g. stream wrappers) are shown as JSON
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		writeHTMLPage(&buf, "Response", func() {
+			buf.WriteString("<pre>" + html.EscapeString(string(data)) + "</pre>")
+		})
+		return buf.Bytes(), nil
+	}
+
+	m := msg.ProtoReflect()
+	writeHTMLPage(&buf, string(m.Descriptor().Name()), func() {
+		list := listField(m.Descriptor())
+		writeHTMLDetail(&buf, m, list)
+		if list != nil {
+			writeHTMLList(&buf, m.Get(list).List(), list.Message())
+		}
+	})
+	return buf.Bytes(), nil
+}
+
+// Unmarshal is not supported: the HTML marshaler is output only.
+func (h *HTMLTableMarshaler) Unmarshal(_ []byte, _ interface{}) error {
+	return errors.New("html marshaler: decoding is not supported")
+}
+
+// NewDecoder returns a decoder that always fails.
+func (h *HTMLTableMarshaler) NewDecoder(_ io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v interface{}) error {
+		return h.Unmarshal(nil, v)
+	})
+}
+
+// NewEncoder returns an encoder writing HTML pages to w.
+func (h *HTMLTableMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v interface{}) error {
+		data, err := h.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// WithHTMLTableSupport enables text/html responses rendered as HTML tables,
+// for browsing GET endpoints during debugging or incident triage. Browsers
+// preferring text/html get the tables; other clients are unaffected.
+//
+// Example:
+//
+//	grpckit.WithHTMLTableSupport()
+func WithHTMLTableSupport() Option {
+	return func(c *serverConfig) {
+		WithMarshaler("text/html", &HTMLTableMarshaler{})(c)
+		c.htmlTables = true
+	}
+}
+
+// htmlAcceptMiddleware normalizes browser Accept headers (e.g.
+// "text/html,application/xhtml+xml,*/*;q=0.8") to "text/html" for GET
+// requests, as the gateway only matches exact Accept values.
+func htmlAcceptMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			accept := r.Header.Get("Accept")
+			first, _, _ := strings.Cut(accept, ",")
+			first, _, _ = strings.Cut(first, ";")
+			if strings.TrimSpace(first) == "text/html" {
+				r.Header.Set("Accept", "text/html")
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// listField returns the only repeated message field of md, or nil.
+func listField(md protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	var found protoreflect.FieldDescriptor
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.IsList() && fd.Message() != nil {
+			if found != nil {
+				return nil
+			}
+			found = fd
+		}
+	}
+	return found
+}
+
+// writeHTMLPage writes the page skeleton around body.
+func writeHTMLPage(buf *bytes.Buffer, title string, body func()) {
+	title = html.EscapeString(title)
+	buf.WriteString(`<!DOCTYPE html><html><head><meta charset="utf-8"><title>` + title + `</title>`)
+	buf.WriteString(`<style>` + htmlTableStyle + `</style></head><body><h1>` + title + `</h1>`)
+	body()
+	buf.WriteString("</body></html>\n")
+}
+
+// writeHTMLDetail writes a field/value table of m, skipping the list field.
+func writeHTMLDetail(buf *bytes.Buffer, m protoreflect.Message, skip protoreflect.FieldDescriptor) {
+	fields := m.Descriptor().Fields()
+	if fields.Len() == 0 || (skip != nil && fields.Len() == 1) {
+		return
+	}
+	buf.WriteString("<table><tr><th>Field</th><th>Value</th></tr>")
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd == skip {
+			continue
+		}
+		buf.WriteString("<tr><th>" + html.EscapeString(string(fd.Name())) + "</th><td>")
+		buf.WriteString(html.EscapeString(htmlCell(m, fd)))
+		buf.WriteString("</td></tr>")
+	}
+	buf.WriteString("</table>")
+}
+
+// writeHTMLList writes one row per element of list, one column per field.
+func writeHTMLList(buf *bytes.Buffer, list protoreflect.List, md protoreflect.MessageDescriptor) {
+	fields := md.Fields()
+	buf.WriteString("<table><tr>")
+	for i := 0; i < fields.Len(); i++ {
+		buf.WriteString("<th>" + html.EscapeString(string(fields.Get(i).Name())) + "</th>")
+	}
+	buf.WriteString("</tr>")
+	for i := 0; i < list.Len(); i++ {
+		elem := list.Get(i).Message()
+		buf.WriteString("<tr>")
+		for j := 0; j < fields.Len(); j++ {
+			buf.WriteString("<td>" + html.EscapeString(htmlCell(elem, fields.Get(j))) + "</td>")
+		}
+		buf.WriteString("</tr>")
+	}
+	buf.WriteString("</table>")
+}
+
+// htmlCell formats the value of fd in m as text.
+func htmlCell(m protoreflect.Message, fd protoreflect.FieldDescriptor) string {
+	if fd.HasPresence() && !m.Has(fd) {
+		return ""
+	}
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		list := v.List()
+		parts := make([]string, list.Len())
+		for i := range parts {
+			parts[i] = htmlValue(fd, list.Get(i))
+		}
+		return strings.Join(parts, ", ")
+	case fd.IsMap():
+		var parts []string
+		v.Map().Range(func(k protoreflect.MapKey, val protoreflect.Value) bool {
+			parts = append(parts, k.String()+": "+htmlValue(fd.MapValue(), val))
+			return true
+		})
+		sort.Strings(parts)
+		return strings.Join(parts, ", ")
+	default:
+		return htmlValue(fd, v)
+	}
+}
+
+// htmlValue formats a single value of fd.
+func htmlValue(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return fmt.Sprint(int32(v.Enum()))
+	case protoreflect.BytesKind:
+		return base64.StdEncoding.EncodeToString(v.Bytes())
+	default:
+		return v.String()
+	}
+}
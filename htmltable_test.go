@@ -0,0 +1,132 @@
+package grpckit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestHTMLTableMarshaler_Detail(t *testing.T) {
+	m := &HTMLTableMarshaler{}
+	data, err := m.Marshal(&errdetails.RequestInfo{RequestId: "<script>alert(1)</script>", ServingData: "a&b"})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out := string(data)
+
+	if !strings.Contains(out, "<title>RequestInfo</title>") {
+		t.Errorf("expected message name as title, got %s", out)
+	}
+	if !strings.Contains(out, "<tr><th>request_id</th><td>&lt;script&gt;alert(1)&lt;/script&gt;</td></tr>") {
+		t.Errorf("expected escaped request_id row, got %s", out)
+	}
+	if !strings.Contains(out, "<td>a&amp;b</td>") {
+		t.Errorf("expected escaped serving_data, got %s", out)
+	}
+	if strings.Contains(out, "<script>") {
+		t.Errorf("expected no unescaped markup, got %s", out)
+	}
+}
+
+func TestHTMLTableMarshaler_List(t *testing.T) {
+	m := &HTMLTableMarshaler{}
+	data, err := m.Marshal(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "name", Description: "required"},
+		{Field: "age", Description: "expected a 32-bit integer"},
+	}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out := string(data)
+
+	if !strings.Contains(out, "<th>field</th><th>description</th>") {
+		t.Errorf("expected one column per element field, got %s", out)
+	}
+	if !strings.Contains(out, "<tr><td>name</td><td>required</td>") {
+		t.Errorf("expected row for first element, got %s", out)
+	}
+	if !strings.Contains(out, "<tr><td>age</td><td>expected a 32-bit integer</td>") {
+		t.Errorf("expected row for second element, got %s", out)
+	}
+	if strings.Contains(out, "<th>Field</th><th>Value</th>") {
+		t.Errorf("expected no detail table for a list-only message, got %s", out)
+	}
+}
+
+func TestHTMLTableMarshaler_Values(t *testing.T) {
+	m := &HTMLTableMarshaler{}
+	data, err := m.Marshal(&descriptorpb.FieldDescriptorProto{
+		Name:    proto.String("id"),
+		Label:   descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+		Options: &descriptorpb.FieldOptions{Deprecated: proto.Bool(true)},
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	out := string(data)
+
+	if !strings.Contains(out, "<th>label</th><td>LABEL_REPEATED</td>") {
+		t.Errorf("expected enum value name, got %s", out)
+	}
+	if !strings.Contains(out, "<th>options</th><td>{&#34;deprecated&#34;:true}</td>") {
+		t.Errorf("expected nested message as escaped JSON, got %s", out)
+	}
+	if !strings.Contains(out, "<th>type_name</th><td></td>") {
+		t.Errorf("expected unset field to be empty, got %s", out)
+	}
+}
+
+func TestHTMLTableMarshaler_Unmarshal(t *testing.T) {
+	m := &HTMLTableMarshaler{}
+	if err := m.Unmarshal([]byte("<p>"), &errdetails.RequestInfo{}); err == nil {
+		t.Error("expected decoding to be rejected")
+	}
+	if err := m.NewDecoder(strings.NewReader("<p>")).Decode(&errdetails.RequestInfo{}); err == nil {
+		t.Error("expected decoder to fail")
+	}
+}
+
+func TestWithHTMLTableSupport(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTMLTableSupport()(cfg)
+
+	if _, ok := cfg.marshalers["text/html"]; !ok {
+		t.Error("expected HTML marshaler to be registered")
+	}
+	if !cfg.htmlTables {
+		t.Error("expected HTML tables to be enabled")
+	}
+}
+
+func TestHTMLAcceptMiddleware(t *testing.T) {
+	tests := []struct {
+		method string
+		accept string
+		want   string
+	}{
+		{http.MethodGet, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html"},
+		{http.MethodGet, "text/html;q=0.9", "text/html"},
+		{http.MethodGet, "application/json, text/html", "application/json, text/html"},
+		{http.MethodGet, "*/*", "*/*"},
+		{http.MethodPost, "text/html,*/*", "text/html,*/*"},
+	}
+
+	for _, tt := range tests {
+		var got string
+		handler := htmlAcceptMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = r.Header.Get("Accept")
+		}))
+		req := httptest.NewRequest(tt.method, "/api/v1/items", nil)
+		req.Header.Set("Accept", tt.accept)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if got != tt.want {
+			t.Errorf("%s %q: expected Accept %q, got %q", tt.method, tt.accept, tt.want, got)
+		}
+	}
+}
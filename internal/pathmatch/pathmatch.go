@@ -0,0 +1,108 @@
+// Package pathmatch compiles the URL path and gRPC method patterns accepted
+// by grpckit options (WithPublicEndpoints, ExceptEndpoints, OnlyPaths, ...)
+// into matchers, so matching a request costs a map lookup plus a scan of the
+// few wildcard patterns, without allocating.
+//
+// Patterns are either exact ("/healthz", "/item.v1.ItemService/GetItem"),
+// a prefix ending in "/**" that matches everything below it
+// ("/api/v1/**"), or a path.Match glob where "*" matches one segment
+// ("/api/v1/items/*", "/item.v1.ItemService/*").
+package pathmatch
+
+import (
+	"path"
+	"strings"
+)
+
+// Matcher matches strings against compiled patterns. A nil Matcher matches
+// nothing. Matchers are immutable and safe for concurrent use.
+type Matcher struct {
+	exact    map[string]struct{}
+	prefixes []string
+	globs    []glob
+	patterns []string
+}
+
+// glob is a path.Match pattern with its literal prefix, used to reject most
+// strings without running the matcher.
+type glob struct {
+	pattern string
+	literal string
+}
+
+// Compile compiles patterns into a Matcher. It returns nil if there are no
+// patterns.
+func Compile(patterns ...string) *Matcher {
+	if len(patterns) == 0 {
+		return nil
+	}
+	m := &Matcher{
+		exact:    make(map[string]struct{}, len(patterns)),
+		patterns: append([]string(nil), patterns...),
+	}
+	for _, p := range patterns {
+		switch {
+		case !strings.Contains(p, "*"):
+			m.exact[p] = struct{}{}
+		case strings.HasSuffix(p, "/**"):
+			m.prefixes = append(m.prefixes, strings.TrimSuffix(p, "/**"))
+		default:
+			literal := p
+			if i := strings.IndexAny(p, `*?[\`); i >= 0 {
+				literal = p[:i]
+			}
+			m.globs = append(m.globs, glob{pattern: p, literal: literal})
+		}
+	}
+	return m
+}
+
+// Match reports whether s matches any pattern.
+func (m *Matcher) Match(s string) bool {
+	if m == nil {
+		return false
+	}
+	if _, ok := m.exact[s]; ok {
+		return true
+	}
+	for _, prefix := range m.prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	for _, g := range m.globs {
+		if !strings.HasPrefix(s, g.literal) {
+			continue
+		}
+		if matched, _ := path.Match(g.pattern, s); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Empty reports whether m has no patterns.
+func (m *Matcher) Empty() bool {
+	return m == nil || len(m.patterns) == 0
+}
+
+// Patterns returns the patterns m was compiled from.
+func (m *Matcher) Patterns() []string {
+	if m == nil {
+		return nil
+	}
+	return m.patterns
+}
+
+// Validate returns path.ErrBadPattern if a glob pattern is malformed, e.g.
+// has an unclosed "[". Malformed globs never match.
+func Validate(patterns ...string) error {
+	for _, p := range patterns {
+		if strings.Contains(p, "*") && !strings.HasSuffix(p, "/**") {
+			if _, err := path.Match(p, ""); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package pathmatch
+
+import (
+	"errors"
+	"path"
+	"testing"
+)
+
+func TestMatcher(t *testing.T) {
+	m := Compile("/healthz", "/api/v1/public/**", "/api/v1/items/*", "/item.v1.ItemService/*", "/files/[ab]*")
+
+	tests := []struct {
+		s    string
+		want bool
+	}{
+		{"/healthz", true},
+		{"/healthz/x", false},
+		{"/api/v1/public", true},
+		{"/api/v1/public/a/b/c", true},
+		{"/api/v1/items/42", true},
+		{"/api/v1/items/42/reviews", false},
+		{"/api/v1/items", false},
+		{"/item.v1.ItemService/GetItem", true},
+		{"/item.v2.ItemService/GetItem", false},
+		{"/files/a.txt", true},
+		{"/files/c.txt", false},
+		{"/other", false},
+	}
+	for _, tt := range tests {
+		if got := m.Match(tt.s); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestMatcher_Nil(t *testing.T) {
+	m := Compile()
+	if m != nil {
+		t.Fatalf("expected nil matcher without patterns, got %+v", m)
+	}
+	if m.Match("/anything") || !m.Empty() || m.Patterns() != nil {
+		t.Error("expected nil matcher to match nothing and be empty")
+	}
+}
+
+func TestMatcher_Patterns(t *testing.T) {
+	patterns := []string{"/a", "/b/**"}
+	m := Compile(patterns...)
+	patterns[0] = "/changed"
+	if got := m.Patterns(); len(got) != 2 || got[0] != "/a" || m.Empty() {
+		t.Errorf("expected patterns to be copied, got %v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("/a", "/b/**", "/c/*"); err != nil {
+		t.Errorf("expected valid patterns, got %v", err)
+	}
+	if err := Validate("/c/[*"); !errors.Is(err, path.ErrBadPattern) {
+		t.Errorf("expected ErrBadPattern, got %v", err)
+	}
+}
+
+func BenchmarkMatch(b *testing.B) {
+	m := Compile("/healthz", "/readyz", "/api/v1/public/**", "/api/v1/catalog/*", "/api/v1/admin/*")
+	for _, s := range []string{"/healthz", "/api/v1/public/items/1", "/api/v1/catalog/books", "/api/v1/orders/42"} {
+		b.Run(s, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				m.Match(s)
+			}
+		})
+	}
+}
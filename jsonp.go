@@ -0,0 +1,131 @@
+package grpckit
+
+import (
+	"bytes"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// jsonpCallbackPattern restricts callback names to (dotted) JavaScript
+// identifiers, so the callback cannot inject script.
+var jsonpCallbackPattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*(\.[A-Za-z_$][A-Za-z0-9_$]*)*$`)
+
+// maxJSONPCallbackLength limits the callback name length.
+const maxJSONPCallbackLength = 128
+
+// invalidCallbackResponse is the body returned for an invalid callback name,
+// in the grpc-gateway error format for codes.InvalidArgument.
+var invalidCallbackResponse = []byte(`{"code":3,"message":"invalid callback name","details":[]}`)
+
+// jsonpConfig holds the JSONP configuration.
+type jsonpConfig struct {
+	paths *pathmatch.Matcher
+}
+
+// WithJSONP enables JSONP for legacy embedders: GET requests with a
+// "?callback=name" parameter get their JSON response wrapped in a call to
+// name, served as JavaScript. Callback names must be JavaScript identifiers
+// (dots allowed), otherwise the request is rejected with 400.
+//
+// JSONP responses can be read by any website, so limit it to public data
+// with patterns (same globs as WithProtectedEndpoints); without patterns it
+// applies to every path. Responses are buffered.
+//
+// Example:
+//
+//	grpckit.WithJSONP("/api/v1/public/**")
+func WithJSONP(patterns ...string) Option {
+	return func(c *serverConfig) {
+		c.jsonp = &jsonpConfig{paths: c.compilePatterns("WithJSONP", patterns)}
+	}
+}
+
+// matches reports whether JSONP is enabled for a path.
+func (c *jsonpConfig) matches(urlPath string) bool {
+	return c.paths.Empty() || c.paths.Match(urlPath)
+}
+
+// jsonpMiddleware wraps JSON responses in the requested callback.
+func jsonpMiddleware(cfg *jsonpConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || !cfg.matches(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		query := r.URL.Query()
+		if !query.Has("callback") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		callback := query.Get("callback")
+		if len(callback) > maxJSONPCallbackLength || !jsonpCallbackPattern.MatchString(callback) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write(invalidCallbackResponse)
+			return
+		}
+
+		// Keep the parameter away from the gateway query parser
+		query.Del("callback")
+		r.URL.RawQuery = query.Encode()
+
+		jw := &jsonpWriter{header: make(http.Header)}
+		next.ServeHTTP(jw, r)
+
+		dst := w.Header()
+		for k, v := range jw.header {
+			dst[k] = v
+		}
+		if jw.code == 0 {
+			jw.code = http.StatusOK
+		}
+
+		body := jw.buf.Bytes()
+		if strings.HasPrefix(jw.header.Get("Content-Type"), "application/json") {
+			// The leading comment defuses content-sniffing attacks (e.g. Rosetta Flash)
+			wrapped := make([]byte, 0, len(body)+len(callback)+8)
+			wrapped = append(wrapped, "/**/"...)
+			wrapped = append(wrapped, callback...)
+			wrapped = append(wrapped, '(')
+			wrapped = append(wrapped, bytes.TrimSpace(body)...)
+			wrapped = append(wrapped, ");"...)
+			body = wrapped
+
+			dst.Set("Content-Type", "text/javascript; charset=utf-8")
+			dst.Set("X-Content-Type-Options", "nosniff")
+			dst.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		w.WriteHeader(jw.code)
+		_, _ = w.Write(body)
+	})
+}
+
+// jsonpWriter buffers a response so it can be wrapped in the callback.
+type jsonpWriter struct {
+	header http.Header
+	code   int
+	buf    bytes.Buffer
+}
+
+func (w *jsonpWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *jsonpWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+	}
+}
+
+func (w *jsonpWriter) Write(b []byte) (int, error) {
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
@@ -0,0 +1,105 @@
+package grpckit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func jsonpTestHandler(t *testing.T, cfg *serverConfig) http.Handler {
+	t.Helper()
+	return jsonpMiddleware(cfg.jsonp, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Has("callback") {
+			t.Error("expected callback parameter to be removed")
+		}
+		if r.URL.Path == "/text" {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = w.Write([]byte("hello"))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":5}` + "\n"))
+	}))
+}
+
+func TestJSONP_WrapsJSON(t *testing.T) {
+	cfg := newServerConfig()
+	WithJSONP()(cfg)
+	handler := jsonpTestHandler(t, cfg)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/items?callback=jQuery.cb_1&page=2", nil))
+
+	if want := `/**/jQuery.cb_1({"code":5});`; rec.Body.String() != want {
+		t.Errorf("expected %s, got %s", want, rec.Body.String())
+	}
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected status to be preserved, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/javascript; charset=utf-8" {
+		t.Errorf("expected JavaScript content type, got %q", ct)
+	}
+	if rec.Header().Get("X-Content-Type-Options") != "nosniff" {
+		t.Error("expected nosniff header")
+	}
+}
+
+func TestJSONP_InvalidCallback(t *testing.T) {
+	cfg := newServerConfig()
+	WithJSONP()(cfg)
+	handler := jsonpTestHandler(t, cfg)
+
+	for _, cb := range []string{"", "alert(1)", "a%3Bb", "1abc", "a..b", "%3Cscript%3E"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+		req.URL.RawQuery = "callback=" + cb
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("callback %q: expected 400, got %d", cb, rec.Code)
+		}
+	}
+}
+
+func TestJSONP_Passthrough(t *testing.T) {
+	cfg := newServerConfig()
+	WithJSONP("/api/v1/public/**")(cfg)
+
+	called := false
+	handler := jsonpMiddleware(cfg.jsonp, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+
+	tests := []struct {
+		method string
+		target string
+	}{
+		{http.MethodGet, "/api/v1/private/items?callback=cb"},
+		{http.MethodPost, "/api/v1/public/items?callback=cb"},
+		{http.MethodGet, "/api/v1/public/items"},
+	}
+	for _, tt := range tests {
+		called = false
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+		if !called || rec.Body.String() != `{}` {
+			t.Errorf("%s %s: expected unwrapped response, got %q", tt.method, tt.target, rec.Body.String())
+		}
+	}
+}
+
+func TestJSONP_NonJSONResponse(t *testing.T) {
+	cfg := newServerConfig()
+	WithJSONP()(cfg)
+	handler := jsonpTestHandler(t, cfg)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/text?callback=cb", nil))
+
+	if rec.Body.String() != "hello" || rec.Header().Get("Content-Type") != "text/plain" {
+		t.Errorf("expected non-JSON response unchanged, got %q (%s)", rec.Body.String(), rec.Header().Get("Content-Type"))
+	}
+}
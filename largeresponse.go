@@ -0,0 +1,304 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// responseChunkSize is the amount of a streamed response buffered before it
+// is written and flushed to the client.
+const responseChunkSize = 32 << 10
+
+// WithLargeResponseStreaming streams unary REST responses whose serialized
+// protobuf size is at least threshold bytes, instead of marshaling the whole
+// body into memory before writing it. Such responses are sent with chunked
+// transfer encoding (no Content-Length), in pooled chunks of 32KB:
+//
+//   - JSON responses encode the repeated message fields of the response
+//     (e.g. the items of an export) one element at a time
+//   - google.api.HttpBody responses write their data in chunks
+//
+// Other responses, pretty-printed JSON, messages using the response_body
+// HTTP rule option and non-JSON marshalers are written as before. The
+// output is the same JSON, except that streamed fields may move to the end
+// of the object.
+//
+// Streaming is implemented with the gateway's forward response rewriter, so a
+// runtime.WithForwardResponseRewriter passed to WithGatewayOptions disables it.
+//
+// Example:
+//
+//	grpckit.WithLargeResponseStreaming(1 << 20) // stream responses of 1MB and more
+func WithLargeResponseStreaming(threshold int) Option {
+	return func(c *serverConfig) {
+		if threshold <= 0 {
+			c.invalid("WithLargeResponseStreaming: invalid threshold %d", threshold)
+			return
+		}
+		c.largeResponseThreshold = threshold
+	}
+}
+
+// largeResponseKey is the context key of the *largeResponseWriter of a
+// gateway request.
+type largeResponseKey struct{}
+
+// largeResponseMiddleware makes the response writer and request available to
+// the gateway's forward response rewriter (see responseStreamer).
+func largeResponseMiddleware(threshold int, next http.Handler) http.Handler {
+	if threshold <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lw := &largeResponseWriter{ResponseWriter: w}
+		r = r.WithContext(context.WithValue(r.Context(), largeResponseKey{}, lw))
+		lw.req = r
+		next.ServeHTTP(lw, r)
+	})
+}
+
+// largeResponseWriter discards what the gateway writes once the response
+// has been streamed.
+type largeResponseWriter struct {
+	http.ResponseWriter
+	req          *http.Request
+	streamed     bool
+	serverStream bool
+}
+
+func (w *largeResponseWriter) WriteHeader(code int) {
+	if !w.streamed {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *largeResponseWriter) Write(b []byte) (int, error) {
+	if w.streamed {
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *largeResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *largeResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// largeResponseStreamOption is the gateway forward-response hook marking
+// server-streaming responses, whose messages are written as they arrive
+// and must not be streamed again: the gateway calls it once with a nil
+// message before the first one.
+func largeResponseStreamOption(ctx context.Context, _ http.ResponseWriter, resp proto.Message) error {
+	if w, ok := ctx.Value(largeResponseKey{}).(*largeResponseWriter); ok && resp == nil {
+		w.serverStream = true
+	}
+	return nil
+}
+
+// responseStreamer is the gateway forward response rewriter applying
+// WithLargeResponseStreaming. Streamed responses are replaced with an empty
+// message, so the gateway doesn't marshal them again.
+type responseStreamer struct {
+	threshold int
+	mux       *runtime.ServeMux
+}
+
+func (s *responseStreamer) rewrite(ctx context.Context, resp proto.Message) (any, error) {
+	w, ok := ctx.Value(largeResponseKey{}).(*largeResponseWriter)
+	if !ok || w.streamed || w.serverStream || s.mux == nil || proto.Size(resp) < s.threshold {
+		return resp, nil
+	}
+	if _, ok := resp.(interface{ XXX_ResponseBody() interface{} }); ok {
+		return resp, nil
+	}
+
+	_, m := runtime.MarshalerForRequest(s.mux, w.req)
+	var err error
+	if body, ok := resp.(*httpbody.HttpBody); ok {
+		if _, ok := m.(*runtime.HTTPBodyMarshaler); !ok {
+			return resp, nil
+		}
+		w.streamed = true
+		err = streamHTTPBody(w.ResponseWriter, body)
+	} else {
+		jsonPb, ok := streamableJSON(m)
+		if !ok {
+			return resp, nil
+		}
+		head, fields := splitStreamedFields(resp)
+		if len(fields) == 0 {
+			return resp, nil
+		}
+		// Marshal everything but the streamed fields first, so marshaling
+		// errors are still reported by the gateway
+		raw, herr := m.Marshal(head)
+		if herr != nil {
+			return resp, nil
+		}
+		w.streamed = true
+		err = streamJSON(w.ResponseWriter, m, raw, resp.ProtoReflect(), fields, jsonPb.UseProtoNames)
+	}
+	if err != nil {
+		loggerFrom(w.req.Context()).Warn("failed to stream response", "component", "gateway", "path", w.req.URL.Path, "error", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+// streamableJSON returns the protojson marshaler of m if m writes compact
+// JSON this package knows how to stream.
+func streamableJSON(m runtime.Marshaler) (*runtime.JSONPb, bool) {
+	switch t := m.(type) {
+	case *runtime.HTTPBodyMarshaler:
+		return streamableJSON(t.Marshaler)
+	case *runtime.JSONPb:
+		return t, t.Indent == ""
+	case *StrictJSONMarshaler:
+		return t.JSONPb, t.Indent == ""
+	case *jsonFormatMarshaler:
+		jsonPb, ok := streamableJSON(t.Marshaler)
+		return jsonPb, ok && t.indent == ""
+	}
+	return nil, false
+}
+
+// splitStreamedFields returns a shallow copy of resp without its populated
+// repeated message fields, and those fields. Well-known types have a
+// special JSON form and are never split.
+func splitStreamedFields(resp proto.Message) (proto.Message, []protoreflect.FieldDescriptor) {
+	src := resp.ProtoReflect()
+	if strings.HasPrefix(string(src.Descriptor().FullName()), "google.protobuf.") {
+		return resp, nil
+	}
+	head := src.New()
+	var fields []protoreflect.FieldDescriptor
+	src.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.IsList() && fd.Message() != nil {
+			fields = append(fields, fd)
+		} else {
+			head.Set(fd, v)
+		}
+		return true
+	})
+	return head.Interface(), fields
+}
+
+// streamJSON writes the JSON object head with the repeated fields of msg
+// encoded element by element in place of (or after) their keys.
+func streamJSON(w http.ResponseWriter, m runtime.Marshaler, head []byte, msg protoreflect.Message, fields []protoreflect.FieldDescriptor, protoNames bool) error {
+	w.Header().Del("Content-Length")
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	byName := make(map[string]protoreflect.FieldDescriptor, len(fields))
+	for _, fd := range fields {
+		byName[fieldJSONName(fd, protoNames)] = fd
+	}
+
+	writeList := func(fd protoreflect.FieldDescriptor) error {
+		list := msg.Get(fd).List()
+		buf.WriteByte('[')
+		for i := 0; i < list.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			data, err := m.Marshal(list.Get(i).Message().Interface())
+			if err != nil {
+				return err
+			}
+			buf.Write(data)
+			if buf.Len() >= responseChunkSize {
+				if err := writeChunk(w, buf); err != nil {
+					return err
+				}
+			}
+		}
+		buf.WriteByte(']')
+		delete(byName, fieldJSONName(fd, protoNames))
+		return nil
+	}
+
+	keys := 0
+	err := writeJSONObject(buf, head, func(key string, val []byte) error {
+		keys++
+		if fd, ok := byName[key]; ok {
+			return writeList(fd)
+		}
+		buf.Write(val)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Fields left out of head (no EmitUnpopulated) go before the closing brace
+	if len(byName) > 0 {
+		buf.Truncate(buf.Len() - 1)
+		for _, fd := range fields {
+			name := fieldJSONName(fd, protoNames)
+			if _, ok := byName[name]; !ok {
+				continue
+			}
+			if keys > 0 {
+				buf.WriteByte(',')
+			}
+			keys++
+			writeJSONString(buf, name)
+			buf.WriteByte(':')
+			if err := writeList(fd); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	}
+	return writeChunk(w, buf)
+}
+
+// fieldJSONName returns the JSON key protojson uses for fd.
+func fieldJSONName(fd protoreflect.FieldDescriptor, protoNames bool) string {
+	if protoNames {
+		return string(fd.Name())
+	}
+	return fd.JSONName()
+}
+
+// streamHTTPBody writes the data of body in chunks.
+func streamHTTPBody(w http.ResponseWriter, body *httpbody.HttpBody) error {
+	w.Header().Del("Content-Length")
+	data := body.GetData()
+	for len(data) > 0 {
+		n := min(len(data), responseChunkSize)
+		if _, err := w.Write(data[:n]); err != nil {
+			return err
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		data = data[n:]
+	}
+	return nil
+}
+
+// writeChunk writes and flushes the buffered part of a streamed response.
+func writeChunk(w http.ResponseWriter, buf *bytes.Buffer) error {
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	buf.Reset()
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
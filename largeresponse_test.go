@@ -0,0 +1,193 @@
+package grpckit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithLargeResponseStreaming(t *testing.T) {
+	cfg := newServerConfig()
+	WithLargeResponseStreaming(1 << 20)(cfg)
+	if cfg.largeResponseThreshold != 1<<20 {
+		t.Errorf("expected threshold 1MB, got %d", cfg.largeResponseThreshold)
+	}
+
+	cfg = newServerConfig()
+	WithLargeResponseStreaming(0)(cfg)
+	if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected invalid threshold to be rejected, got %v", cfg.errs)
+	}
+}
+
+// forwardLarge writes resp like the gateway does for a unary call, with
+// WithLargeResponseStreaming and the given gateway options.
+func forwardLarge(t *testing.T, threshold int, resp proto.Message, accept string, opts ...runtime.ServeMuxOption) *httptest.ResponseRecorder {
+	t.Helper()
+	streamer := &responseStreamer{threshold: threshold}
+	mux := runtime.NewServeMux(append([]runtime.ServeMuxOption{
+		runtime.WithForwardResponseRewriter(streamer.rewrite),
+		runtime.WithForwardResponseOption(largeResponseStreamOption),
+	}, opts...)...)
+	streamer.mux = mux
+
+	handler := largeResponseMiddleware(threshold, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		_, m := runtime.MarshalerForRequest(mux, r)
+		runtime.ForwardResponseMessage(ctx, mux, m, w, r, resp, mux.GetForwardResponseOptions()...)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/export", nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestLargeResponseStreaming_ServerStream(t *testing.T) {
+	streamer := &responseStreamer{threshold: 1024}
+	mux := runtime.NewServeMux(
+		runtime.WithForwardResponseRewriter(streamer.rewrite),
+		runtime.WithForwardResponseOption(largeResponseStreamOption),
+	)
+	streamer.mux = mux
+
+	msgs := []proto.Message{largeBadRequest(100), largeBadRequest(100)}
+	handler := largeResponseMiddleware(1024, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		_, m := runtime.MarshalerForRequest(mux, r)
+		runtime.ForwardResponseStream(ctx, mux, m, w, r, func() (proto.Message, error) {
+			if len(msgs) == 0 {
+				return nil, io.EOF
+			}
+			msg := msgs[0]
+			msgs = msgs[1:]
+			return msg, nil
+		}, mux.GetForwardResponseOptions()...)
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/export:stream", nil))
+
+	lines := bytes.Split(bytes.TrimSpace(rec.Body.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 stream chunks, got %d", len(lines))
+	}
+	for _, line := range lines {
+		if !bytes.HasPrefix(line, []byte(`{"result":{"fieldViolations":[`)) {
+			t.Errorf("expected chunk to be written by the gateway, got %.40s", line)
+		}
+	}
+}
+
+func largeBadRequest(n int) *errdetails.BadRequest {
+	msg := &errdetails.BadRequest{}
+	for i := 0; i < n; i++ {
+		msg.FieldViolations = append(msg.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fmt.Sprintf("items[%d].name", i),
+			Description: "must not be empty",
+		})
+	}
+	return msg
+}
+
+func TestLargeResponseStreaming_JSON(t *testing.T) {
+	msg := largeBadRequest(5000)
+	want, err := (&runtime.JSONPb{}).Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	rec := forwardLarge(t, 1024, msg, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if cl := rec.Result().Header.Get("Content-Length"); cl != "" {
+		t.Errorf("expected no Content-Length for a streamed response, got %s", cl)
+	}
+	if !rec.Flushed {
+		t.Error("expected streamed response to be flushed")
+	}
+	assertSameJSON(t, rec.Body.Bytes(), want)
+}
+
+func TestLargeResponseStreaming_EmitUnpopulated(t *testing.T) {
+	msg := largeBadRequest(100)
+	jsonPb := &runtime.JSONPb{}
+	jsonPb.EmitUnpopulated = true
+	jsonPb.UseProtoNames = true
+	want, err := jsonPb.Marshal(msg)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	rec := forwardLarge(t, 1024, msg, "", runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonPb))
+	assertSameJSON(t, rec.Body.Bytes(), want)
+	if !bytes.HasPrefix(rec.Body.Bytes(), []byte(`{"field_violations":[{`)) {
+		t.Errorf("expected streamed field in place, got %.40s", rec.Body.String())
+	}
+}
+
+func TestLargeResponseStreaming_BelowThreshold(t *testing.T) {
+	msg := largeBadRequest(2)
+	rec := forwardLarge(t, 1<<20, msg, "")
+
+	if cl := rec.Header().Get("Content-Length"); cl != fmt.Sprint(rec.Body.Len()) {
+		t.Errorf("expected buffered response with Content-Length, got %q", cl)
+	}
+	if rec.Flushed {
+		t.Error("expected small response not to be streamed")
+	}
+}
+
+func TestLargeResponseStreaming_Pretty(t *testing.T) {
+	msg := largeBadRequest(100)
+	jsonPb := &runtime.JSONPb{}
+	jsonPb.Indent = "  "
+	rec := forwardLarge(t, 1024, msg, "", runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonPb))
+
+	if rec.Flushed || !bytes.Contains(rec.Body.Bytes(), []byte("\n  ")) {
+		t.Error("expected pretty-printed response not to be streamed")
+	}
+}
+
+func TestLargeResponseStreaming_HTTPBody(t *testing.T) {
+	data := bytes.Repeat([]byte("id,name\n"), 20000)
+	msg := &httpbody.HttpBody{ContentType: "text/csv", Data: data}
+	rec := forwardLarge(t, 1024, msg, "")
+
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" {
+		t.Errorf("expected text/csv, got %q", ct)
+	}
+	if !rec.Flushed {
+		t.Error("expected HttpBody to be streamed")
+	}
+	if !bytes.Equal(rec.Body.Bytes(), data) {
+		t.Errorf("expected body of %d bytes, got %d", len(data), rec.Body.Len())
+	}
+}
+
+func assertSameJSON(t *testing.T, got, want []byte) {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("invalid JSON %.80s: %v", got, err)
+	}
+	if err := json.Unmarshal(want, &w); err != nil {
+		t.Fatalf("invalid JSON %.80s: %v", want, err)
+	}
+	if !reflect.DeepEqual(g, w) {
+		t.Errorf("expected %.80s, got %.80s", want, got)
+	}
+}
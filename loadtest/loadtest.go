@@ -0,0 +1,453 @@
+// Package loadtest replays recorded REST and gRPC requests against a
+// grpckit.TestServer or a live server at a target rate, and reports latency
+// percentiles, e.g. to gate merges on performance regressions in CI.
+//
+// Example:
+//
+//	func TestPerformance(t *testing.T) {
+//	    ts, _ := grpckit.NewTestServer(opts...)
+//	    defer ts.Close()
+//
+//	    f, _ := os.Open("testdata/traffic.jsonl")
+//	    requests, _ := loadtest.Load(f)
+//
+//	    report, err := loadtest.Run(context.Background(), requests,
+//	        loadtest.WithTestServer(ts),
+//	        loadtest.RPS(200),
+//	        loadtest.Duration(10*time.Second),
+//	    )
+//	    if err != nil {
+//	        t.Fatal(err)
+//	    }
+//	    t.Log(report)
+//	    if err := report.Check(loadtest.Gate{P99: 50 * time.Millisecond, MaxErrorRate: 0.01}); err != nil {
+//	        t.Error(err)
+//	    }
+//	}
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Option configures a load test run.
+type Option func(*config)
+
+// config holds the configuration of a run.
+type config struct {
+	httpClient  *http.Client
+	baseURL     string
+	grpcConn    grpc.ClientConnInterface
+	rps         float64
+	duration    time.Duration
+	concurrency int
+	timeout     time.Duration
+}
+
+// WithHTTP sends HTTP requests with client to baseURL, e.g.
+// "http://localhost:8080".
+func WithHTTP(client *http.Client, baseURL string) Option {
+	return func(c *config) {
+		c.httpClient = client
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// WithGRPC sends gRPC calls on conn.
+func WithGRPC(conn grpc.ClientConnInterface) Option {
+	return func(c *config) {
+		c.grpcConn = conn
+	}
+}
+
+// WithTestServer sends HTTP requests and gRPC calls to an in-memory test
+// server.
+func WithTestServer(ts *grpckit.TestServer) Option {
+	return func(c *config) {
+		c.httpClient = ts.HTTPClient()
+		c.baseURL = ts.BaseURL()
+		c.grpcConn = ts.GRPCClientConn(context.Background())
+	}
+}
+
+// RPS sets the target request rate. Requests are started at a fixed pace,
+// cycling through the recorded requests, whether or not earlier ones have
+// completed.
+// Default: 50
+func RPS(n float64) Option {
+	return func(c *config) {
+		c.rps = n
+	}
+}
+
+// Duration sets how long requests are sent.
+// Default: 10 seconds
+func Duration(d time.Duration) Option {
+	return func(c *config) {
+		c.duration = d
+	}
+}
+
+// Concurrency bounds the requests in flight. When it is reached, the run
+// slows down and the achieved rate falls below the target.
+// Default: 100
+func Concurrency(n int) Option {
+	return func(c *config) {
+		c.concurrency = n
+	}
+}
+
+// Timeout bounds each request; timed out requests count as errors.
+// Default: 10 seconds
+func Timeout(d time.Duration) Option {
+	return func(c *config) {
+		c.timeout = d
+	}
+}
+
+// Run replays requests at the configured rate until the duration elapses or
+// ctx is canceled, waits for the requests in flight and returns the report.
+// It returns an error if the configuration is invalid or a request has no
+// matching target, before sending anything.
+//
+// A request fails if it cannot be sent, or if it is answered with a server
+// error: an HTTP 5xx status, or a gRPC code mapping to one, such as
+// Unavailable or Internal. Client errors, such as 404 or NotFound, are
+// counted in Codes but are not failures, as recorded traffic often contains
+// them.
+func Run(ctx context.Context, requests []Request, opts ...Option) (*Report, error) {
+	cfg := &config{
+		rps:         50,
+		duration:    10 * time.Second,
+		concurrency: 100,
+		timeout:     10 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	switch {
+	case len(requests) == 0:
+		return nil, errors.New("loadtest: no requests")
+	case cfg.rps <= 0 || cfg.duration <= 0 || cfg.concurrency <= 0 || cfg.timeout <= 0:
+		return nil, errors.New("loadtest: rps, duration, concurrency and timeout must be positive")
+	}
+	for _, req := range requests {
+		if req.IsGRPC() && cfg.grpcConn == nil {
+			return nil, fmt.Errorf("loadtest: %s: no gRPC target, use WithGRPC or WithTestServer", req.name())
+		}
+		if !req.IsGRPC() && cfg.httpClient == nil {
+			return nil, fmt.Errorf("loadtest: %s: no HTTP target, use WithHTTP or WithTestServer", req.name())
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = newReport()
+		sem     = make(chan struct{}, cfg.concurrency)
+	)
+	interval := time.Duration(float64(time.Second) / cfg.rps)
+	start := time.Now()
+	end := start.Add(cfg.duration)
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+loop:
+	for i := 0; ; i++ {
+		next := start.Add(time.Duration(i) * interval)
+		if !next.Before(end) {
+			break
+		}
+		timer.Reset(time.Until(next))
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-timer.C:
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case sem <- struct{}{}:
+		}
+
+		req := requests[i%len(requests)]
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			reqCtx, cancel := context.WithTimeout(ctx, cfg.timeout)
+			defer cancel()
+
+			began := time.Now()
+			code, failed := cfg.send(reqCtx, req)
+			latency := time.Since(began)
+
+			mu.Lock()
+			results.add(req.name(), code, failed, latency)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	results.finish(time.Since(start))
+	return results, nil
+}
+
+// send sends one request and returns its status code and whether it failed.
+func (c *config) send(ctx context.Context, req Request) (string, bool) {
+	if req.IsGRPC() {
+		return c.sendGRPC(ctx, req)
+	}
+	return c.sendHTTP(ctx, req)
+}
+
+// sendHTTP sends an HTTP request and reads the response.
+func (c *config) sendHTTP(ctx context.Context, req Request) (string, bool) {
+	var body io.Reader
+	if req.Body != "" {
+		body = strings.NewReader(req.Body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, c.baseURL+req.Path, body)
+	if err != nil {
+		return "error", true
+	}
+	for k, v := range req.Header {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "error", true
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return "error", true
+	}
+	return strconv.Itoa(resp.StatusCode), resp.StatusCode >= 500
+}
+
+// sendGRPC sends a unary gRPC call. The response is read but not decoded.
+func (c *config) sendGRPC(ctx context.Context, req Request) (string, bool) {
+	for k, v := range req.Metadata {
+		ctx = metadata.AppendToOutgoingContext(ctx, k, v)
+	}
+	var reply []byte
+	err := c.grpcConn.Invoke(ctx, req.GRPCMethod, req.Message, &reply, grpc.ForceCodec(rawCodec{}))
+	code := status.Code(err)
+	return code.String(), runtime.HTTPStatusFromCode(code) >= 500
+}
+
+// rawCodec sends and receives messages in protobuf wire format as is.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	switch m := v.(type) {
+	case []byte:
+		return m, nil
+	case proto.Message:
+		return proto.Marshal(m)
+	}
+	return nil, fmt.Errorf("loadtest: cannot marshal %T", v)
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return fmt.Errorf("loadtest: cannot unmarshal into %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+// Name matches the proto codec, so servers see a regular call.
+func (rawCodec) Name() string { return "proto" }
+
+// Stats summarizes the requests of a run, or of one request name.
+type Stats struct {
+	// Requests is the number of completed requests, Errors those that failed.
+	Requests int
+	Errors   int
+
+	// Codes counts requests by HTTP status or gRPC code, e.g. "200" or
+	// "Unavailable"; "error" counts requests that could not be sent.
+	Codes map[string]int
+
+	// Latency percentiles, from sending a request to reading its response.
+	Min, Mean, P50, P90, P95, P99, Max time.Duration
+
+	latencies []time.Duration
+}
+
+// ErrorRate returns the fraction of requests that failed.
+func (s *Stats) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests)
+}
+
+// Percentile returns the latency below which p percent of requests
+// completed, e.g. Percentile(99.9).
+func (s *Stats) Percentile(p float64) time.Duration {
+	if len(s.latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p/100*float64(len(s.latencies)))) - 1
+	rank = max(0, min(rank, len(s.latencies)-1))
+	return s.latencies[rank]
+}
+
+// add records one request.
+func (s *Stats) add(code string, failed bool, latency time.Duration) {
+	s.Requests++
+	if failed {
+		s.Errors++
+	}
+	s.Codes[code]++
+	s.latencies = append(s.latencies, latency)
+}
+
+// finish computes the latency percentiles.
+func (s *Stats) finish() {
+	if len(s.latencies) == 0 {
+		return
+	}
+	sort.Slice(s.latencies, func(i, j int) bool { return s.latencies[i] < s.latencies[j] })
+	var total time.Duration
+	for _, l := range s.latencies {
+		total += l
+	}
+	s.Min = s.latencies[0]
+	s.Max = s.latencies[len(s.latencies)-1]
+	s.Mean = total / time.Duration(len(s.latencies))
+	s.P50 = s.Percentile(50)
+	s.P90 = s.Percentile(90)
+	s.P95 = s.Percentile(95)
+	s.P99 = s.Percentile(99)
+}
+
+// Report is the result of a run.
+type Report struct {
+	// Stats covers all requests.
+	Stats
+
+	// Elapsed is the duration of the run, including waiting for the last
+	// requests. RPS is the achieved request rate.
+	Elapsed time.Duration
+	RPS     float64
+
+	// Endpoints holds the stats of each request name.
+	Endpoints map[string]*Stats
+}
+
+// newReport creates an empty report.
+func newReport() *Report {
+	return &Report{
+		Stats:     Stats{Codes: make(map[string]int)},
+		Endpoints: make(map[string]*Stats),
+	}
+}
+
+// add records one request.
+func (r *Report) add(name, code string, failed bool, latency time.Duration) {
+	r.Stats.add(code, failed, latency)
+	s, ok := r.Endpoints[name]
+	if !ok {
+		s = &Stats{Codes: make(map[string]int)}
+		r.Endpoints[name] = s
+	}
+	s.add(code, failed, latency)
+}
+
+// finish computes the rate and percentiles.
+func (r *Report) finish(elapsed time.Duration) {
+	r.Elapsed = elapsed
+	if elapsed > 0 {
+		r.RPS = float64(r.Requests) / elapsed.Seconds()
+	}
+	r.Stats.finish()
+	for _, s := range r.Endpoints {
+		s.finish()
+	}
+}
+
+// String formats the report as a table, one line per request name.
+//
+// Example output:
+//
+//	requests=2000 errors=0 rps=199.8 p50=1.2ms p90=2.8ms p95=3.5ms p99=7.9ms max=12.1ms
+//	  GET /api/v1/items                  requests=1000 errors=0 p50=1.1ms p99=6.2ms
+//	  /item.v1.ItemService/GetItem       requests=1000 errors=0 p50=1.3ms p99=8.4ms
+func (r *Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "requests=%d errors=%d rps=%.1f p50=%v p90=%v p95=%v p99=%v max=%v",
+		r.Requests, r.Errors, r.RPS, round(r.P50), round(r.P90), round(r.P95), round(r.P99), round(r.Max))
+
+	names := make([]string, 0, len(r.Endpoints))
+	for name := range r.Endpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		s := r.Endpoints[name]
+		fmt.Fprintf(&b, "\n  %-34s requests=%d errors=%d p50=%v p99=%v",
+			name, s.Requests, s.Errors, round(s.P50), round(s.P99))
+	}
+	return b.String()
+}
+
+// round rounds latencies for display.
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
+
+// Gate holds the limits a run must meet, e.g. in a CI performance gate.
+// Zero fields are not checked.
+type Gate struct {
+	P50, P95, P99 time.Duration
+	MaxErrorRate  float64
+	MinRPS        float64
+}
+
+// Check returns an error listing the limits of g the report exceeds.
+func (r *Report) Check(g Gate) error {
+	var violations []string
+	check := func(name string, got, limit time.Duration) {
+		if limit > 0 && got > limit {
+			violations = append(violations, fmt.Sprintf("%s %v exceeds %v", name, round(got), limit))
+		}
+	}
+	check("p50", r.P50, g.P50)
+	check("p95", r.P95, g.P95)
+	check("p99", r.P99, g.P99)
+	if g.MaxErrorRate > 0 && r.ErrorRate() > g.MaxErrorRate {
+		violations = append(violations, fmt.Sprintf("error rate %.4f exceeds %.4f", r.ErrorRate(), g.MaxErrorRate))
+	}
+	if g.MinRPS > 0 && r.RPS < g.MinRPS {
+		violations = append(violations, fmt.Sprintf("rps %.1f below %.1f", r.RPS, g.MinRPS))
+	}
+	if len(violations) > 0 {
+		return fmt.Errorf("loadtest: %s", strings.Join(violations, "; "))
+	}
+	return nil
+}
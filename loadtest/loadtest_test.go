@@ -0,0 +1,158 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func newTestServer(t *testing.T, opts ...grpckit.Option) (*grpckit.TestServer, *atomic.Int64) {
+	t.Helper()
+	var hits atomic.Int64
+	opts = append([]grpckit.Option{
+		grpckit.WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		grpckit.WithGRPCHealthService(),
+		grpckit.WithHTTPHandler("/items", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			hits.Add(1)
+			io.Copy(io.Discard, r.Body)
+			w.Write([]byte(`{"items":[]}`))
+		})),
+		grpckit.WithHTTPHandler("/broken", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})),
+	}, opts...)
+	ts, err := grpckit.NewTestServer(opts...)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	t.Cleanup(ts.Close)
+	return ts, &hits
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	var traffic bytes.Buffer
+	rec := NewRecorder(&traffic, "X-Tenant")
+	ts, hits := newTestServer(t,
+		grpckit.WithHTTPMiddleware(rec.HTTPMiddleware),
+		grpckit.WithUnaryInterceptor(rec.UnaryServerInterceptor()),
+	)
+
+	req, _ := http.NewRequest(http.MethodPost, ts.URL("/items?dry_run=1"), strings.NewReader(`{"name":"a"}`))
+	req.Header.Set("X-Tenant", "acme")
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := ts.HTTPClient().Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if _, err := healthpb.NewHealthClient(ts.GRPCClientConn(context.Background())).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call failed: %v", err)
+	}
+
+	requests, err := Load(&traffic)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %d: %+v", len(requests), requests)
+	}
+	httpReq, grpcReq := requests[0], requests[1]
+	if httpReq.Method != http.MethodPost || httpReq.Path != "/items?dry_run=1" || httpReq.Body != `{"name":"a"}` {
+		t.Errorf("unexpected recorded HTTP request: %+v", httpReq)
+	}
+	if httpReq.Header["X-Tenant"] != "acme" || httpReq.Header["Authorization"] != "" {
+		t.Errorf("expected only listed headers to be recorded, got %v", httpReq.Header)
+	}
+	if grpcReq.GRPCMethod != "/grpc.health.v1.Health/Check" {
+		t.Errorf("unexpected recorded gRPC call: %+v", grpcReq)
+	}
+
+	hits.Store(0)
+	report, err := Run(context.Background(), requests,
+		WithTestServer(ts),
+		RPS(200),
+		Duration(100*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if report.Requests != 20 || report.Errors != 0 {
+		t.Errorf("expected 20 successful requests, got %d with %d errors", report.Requests, report.Errors)
+	}
+	if report.Codes["200"] != 10 || report.Codes["OK"] != 10 || hits.Load() != 10 {
+		t.Errorf("expected 10 requests of each kind, got %v (%d hits)", report.Codes, hits.Load())
+	}
+	if s := report.Endpoints["POST /items?dry_run=1"]; s == nil || s.Requests != 10 {
+		t.Errorf("expected per-endpoint stats, got %v", report.Endpoints)
+	}
+	if report.P50 <= 0 || report.P50 > report.P99 || report.P99 > report.Max {
+		t.Errorf("inconsistent percentiles: %s", report)
+	}
+	if err := report.Check(Gate{P99: time.Minute, MaxErrorRate: 0.01}); err != nil {
+		t.Errorf("expected gate to pass, got %v", err)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	ts, _ := newTestServer(t)
+
+	report, err := Run(context.Background(), []Request{
+		{Method: http.MethodGet, Path: "/broken"},
+		{Method: http.MethodGet, Path: "/missing"},
+		{GRPCMethod: "/unknown.Service/Method"},
+	}, WithTestServer(ts), RPS(300), Duration(99*time.Millisecond))
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	// 5xx and Unimplemented fail; 404 does not
+	if report.Errors != 20 || report.Codes["404"] != 10 || report.Codes["Unimplemented"] != 10 {
+		t.Errorf("unexpected errors %d, codes %v", report.Errors, report.Codes)
+	}
+
+	err = report.Check(Gate{MaxErrorRate: 0.5, MinRPS: 1e6})
+	if err == nil || !strings.Contains(err.Error(), "error rate") || !strings.Contains(err.Error(), "rps") {
+		t.Errorf("expected error rate and rps violations, got %v", err)
+	}
+}
+
+func TestRun_Invalid(t *testing.T) {
+	ctx := context.Background()
+	if _, err := Run(ctx, nil, WithHTTP(http.DefaultClient, "http://localhost")); err == nil {
+		t.Error("expected error without requests")
+	}
+	if _, err := Run(ctx, []Request{{GRPCMethod: "/a.B/C"}}, WithHTTP(http.DefaultClient, "http://localhost")); err == nil {
+		t.Error("expected error without gRPC target")
+	}
+	if _, err := Run(ctx, []Request{{Method: "GET", Path: "/"}}, WithHTTP(http.DefaultClient, "http://localhost"), RPS(0)); err == nil {
+		t.Error("expected error with zero rate")
+	}
+}
+
+func TestLoad_Invalid(t *testing.T) {
+	if _, err := Load(strings.NewReader("{\"method\":\"GET\",\"path\":\"/\"}\n\n{\"name\":\"x\"}\n")); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected error on line 3, got %v", err)
+	}
+}
+
+func TestStats_Percentile(t *testing.T) {
+	s := &Stats{Codes: make(map[string]int)}
+	for i := 1; i <= 100; i++ {
+		s.add("OK", false, time.Duration(i)*time.Millisecond)
+	}
+	s.finish()
+	if s.P50 != 50*time.Millisecond || s.P99 != 99*time.Millisecond || s.Max != 100*time.Millisecond || s.Min != time.Millisecond {
+		t.Errorf("unexpected percentiles: p50=%v p99=%v min=%v max=%v", s.P50, s.P99, s.Min, s.Max)
+	}
+	if s.Percentile(99.9) != 100*time.Millisecond {
+		t.Errorf("expected p99.9 of 100ms, got %v", s.Percentile(99.9))
+	}
+}
@@ -0,0 +1,179 @@
+package loadtest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxRecordedBody bounds the HTTP request bodies kept by a Recorder.
+const maxRecordedBody = 1 << 20
+
+// Request is one recorded request. HTTP requests set Method and Path; gRPC
+// calls set GRPCMethod and Message, the request message in protobuf wire
+// format, so any service can be replayed without its generated types.
+type Request struct {
+	// Name groups requests in the report. Default: "METHOD /path" for HTTP
+	// requests, the full method for gRPC calls.
+	Name string `json:"name,omitempty"`
+
+	Method string            `json:"method,omitempty"`
+	Path   string            `json:"path,omitempty"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+
+	GRPCMethod string            `json:"grpc_method,omitempty"`
+	Message    []byte            `json:"message,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// IsGRPC reports whether r is a gRPC call.
+func (r Request) IsGRPC() bool {
+	return r.GRPCMethod != ""
+}
+
+// name returns the name r is reported under.
+func (r Request) name() string {
+	switch {
+	case r.Name != "":
+		return r.Name
+	case r.IsGRPC():
+		return r.GRPCMethod
+	default:
+		return r.Method + " " + r.Path
+	}
+}
+
+// GRPCRequest builds a gRPC request from a message, e.g. to write requests
+// by hand instead of recording them.
+func GRPCRequest(method string, msg proto.Message) (Request, error) {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return Request{}, fmt.Errorf("loadtest: marshal %s request: %w", method, err)
+	}
+	return Request{GRPCMethod: method, Message: b}, nil
+}
+
+// Load reads requests written by a Recorder (or by hand), one JSON object per
+// line. Empty lines are ignored.
+func Load(r io.Reader) ([]Request, error) {
+	var requests []Request
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 4*maxRecordedBody)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return nil, fmt.Errorf("loadtest: line %d: %w", line, err)
+		}
+		if !req.IsGRPC() && (req.Method == "" || req.Path == "") {
+			return nil, fmt.Errorf("loadtest: line %d: request needs method and path, or grpc_method", line)
+		}
+		requests = append(requests, req)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("loadtest: %w", err)
+	}
+	return requests, nil
+}
+
+// Recorder captures the requests a server receives, one JSON object per
+// line, for replay with Load and Run. Install it on a staging or canary
+// instance to capture realistic traffic:
+//
+//	f, _ := os.Create("traffic.jsonl")
+//	rec := loadtest.NewRecorder(f, "Authorization")
+//	server, _ := grpckit.New(
+//	    grpckit.WithHTTPMiddleware(rec.HTTPMiddleware),
+//	    grpckit.WithUnaryInterceptor(rec.UnaryServerInterceptor()),
+//	    // ... other options
+//	)
+//
+// Recorded requests may contain personal data; review them before checking
+// them in. HTTP bodies over 1 MiB and streaming calls are not recorded.
+type Recorder struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	header []string
+}
+
+// NewRecorder creates a recorder writing to w. Only the listed headers (or
+// gRPC metadata keys) are recorded, so credentials are left out unless asked
+// for.
+func NewRecorder(w io.Writer, headers ...string) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w), header: headers}
+}
+
+// record writes one request.
+func (rec *Recorder) record(req Request) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	_ = rec.enc.Encode(req)
+}
+
+// HTTPMiddleware records HTTP requests, including REST calls through the
+// gateway. Its signature matches grpckit.HTTPMiddleware.
+func (rec *Recorder) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Request{Method: r.Method, Path: r.URL.RequestURI()}
+		for _, h := range rec.header {
+			if v := r.Header.Get(h); v != "" {
+				if req.Header == nil {
+					req.Header = make(map[string]string)
+				}
+				req.Header[h] = v
+			}
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxRecordedBody+1))
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+			if err != nil || len(body) > maxRecordedBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+			req.Body = string(body)
+		}
+
+		rec.record(req)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// UnaryServerInterceptor records unary gRPC calls. Calls through the REST
+// gateway are recorded by HTTPMiddleware instead.
+func (rec *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		// The gateway sets x-forwarded-host on the calls it proxies
+		if msg, ok := req.(proto.Message); ok && len(md.Get("x-forwarded-host")) == 0 {
+			if b, err := proto.Marshal(msg); err == nil {
+				recorded := Request{GRPCMethod: info.FullMethod, Message: b}
+				for _, key := range rec.header {
+					if v := md.Get(key); len(v) > 0 {
+						if recorded.Metadata == nil {
+							recorded.Metadata = make(map[string]string)
+						}
+						recorded.Metadata[key] = v[0]
+					}
+				}
+				rec.record(recorded)
+			}
+		}
+		return handler(ctx, req)
+	}
+}
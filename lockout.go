@@ -0,0 +1,229 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"github.com/gyozatech/grpckit/quota"
+)
+
+// lockoutResponse is the body returned while a key is locked out.
+var lockoutResponse = []byte(`{"code":8,"message":"too many failed attempts, try again later","details":[]}`)
+
+// LockoutOption configures brute-force lockouts.
+type LockoutOption func(*lockoutConfig)
+
+// lockoutConfig holds configuration for brute-force lockouts.
+type lockoutConfig struct {
+	store    quota.Store
+	attempts int64
+	window   time.Duration
+	duration time.Duration
+	keyFunc  func(r *http.Request) string
+	paths    *pathmatch.Matcher
+}
+
+// LockoutAttempts locks a key out after n failed attempts within window.
+// Default: 5 attempts within 15 minutes
+func LockoutAttempts(n int, window time.Duration) LockoutOption {
+	return func(c *lockoutConfig) {
+		c.attempts = int64(n)
+		c.window = window
+	}
+}
+
+// LockoutDuration sets how long a key stays locked out.
+// Default: 15 minutes
+func LockoutDuration(d time.Duration) LockoutOption {
+	return func(c *lockoutConfig) {
+		c.duration = d
+	}
+}
+
+// LockoutKey sets the function deriving the lockout key from a request, e.g.
+// a client ID header. It must not consume the request body. Requests for
+// which it returns "" are not tracked.
+// Default: the client IP. Behind a load balancer or proxy that does not
+// preserve client IPs, every client shares the proxy's IP, so one client
+// failing to log in locks out all of them: key by a header the proxy sets
+// (and clients can't forge), or by the account being logged into.
+func LockoutKey(fn func(r *http.Request) string) LockoutOption {
+	return func(c *lockoutConfig) {
+		c.keyFunc = fn
+	}
+}
+
+// LockoutKeyFunc keys lockouts with a RateLimitKeyFunc, such as
+// RateLimitByAPIKey, so lockouts and WithRateLimit can share key functions.
+// Requests for which it returns "" are not tracked.
+func LockoutKeyFunc(fn RateLimitKeyFunc) LockoutOption {
+	return func(c *lockoutConfig) {
+		c.keyFunc = func(r *http.Request) string {
+			return fn(rateLimitKeyContext(r.Context(), r.RemoteAddr, r.Header))
+		}
+	}
+}
+
+// LockoutPaths restricts lockouts to the paths matching the patterns, using
+// the same globs as WithProtectedEndpoints; "/**" covers all paths.
+// Default: paths whose last segment, or custom verb as in
+// "/v1/sessions:login", is login, signin, sign-in, logon, authenticate,
+// token, password-reset, reset-password, forgot-password, mfa or otp
+func LockoutPaths(patterns ...string) LockoutOption {
+	return func(c *lockoutConfig) {
+		c.paths = pathmatch.Compile(patterns...)
+	}
+}
+
+// WithLockout temporarily locks out clients that keep failing to
+// authenticate on login endpoints: by default paths such as "/login",
+// "/api/v1/auth/token" or "/password-reset" (see LockoutPaths). An HTTP
+// response with status 401 or 403 counts as a failed attempt, whether it
+// comes from WithAuth or from the handler itself. Once a key reaches the
+// allowed attempts, its requests are answered with 429 Too Many Requests
+// and a Retry-After header until the lockout expires. Keys default to the
+// client IP, which behind a load balancer may be shared by all clients
+// (see LockoutKey).
+//
+// Counters are kept in store, under "grpckit:lockout:" keys, so the same
+// quota.Store used by WithPrincipalQuota can hold them: quota.NewMemoryStore
+// for a single instance, or a shared store such as Redis so lockouts apply
+// across instances. If the store fails, requests are allowed and the error is
+// logged. Use Server.ResetLockout to lift a lockout early.
+//
+// Lockouts cover HTTP requests, including REST calls through the gateway;
+// direct gRPC calls are not checked.
+//
+// Example:
+//
+//	grpckit.WithLockout(quota.NewMemoryStore(),
+//	    grpckit.LockoutPaths("/api/v1/login", "/api/v1/password-reset"),
+//	    grpckit.LockoutAttempts(5, 10*time.Minute),
+//	    grpckit.LockoutDuration(30*time.Minute),
+//	)
+//
+// Example output:
+//
+//	level=WARN msg="locked out" component=auth key=203.0.113.7 path=/api/v1/login attempts=5 duration=30m0s
+func WithLockout(store quota.Store, opts ...LockoutOption) Option {
+	return func(c *serverConfig) {
+		cfg := &lockoutConfig{
+			store:    store,
+			attempts: 5,
+			window:   15 * time.Minute,
+			duration: 15 * time.Minute,
+			keyFunc:  func(r *http.Request) string { return hostOnly(r.RemoteAddr) },
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		switch {
+		case store == nil:
+			c.invalid("WithLockout: nil store")
+		case cfg.attempts <= 0 || cfg.window <= 0 || cfg.duration <= 0:
+			c.invalid("WithLockout: attempts, window and duration must be positive")
+		default:
+			c.lockout = cfg
+		}
+	}
+}
+
+// ResetLockout lifts the lockout of key and clears its failed attempts.
+func (s *Server) ResetLockout(ctx context.Context, key string) error {
+	l := s.cfg.lockout
+	if l == nil {
+		return fmt.Errorf("%w: lockouts not configured", ErrInvalidConfig)
+	}
+	if err := l.store.Delete(ctx, lockoutLockedKey(key)); err != nil {
+		return err
+	}
+	return l.store.Delete(ctx, lockoutFailuresKey(key))
+}
+
+func lockoutFailuresKey(key string) string { return "grpckit:lockout:failures:" + key }
+func lockoutLockedKey(key string) string   { return "grpckit:lockout:locked:" + key }
+
+// loginSegments are the last path segments of the paths lockouts cover by
+// default.
+var loginSegments = map[string]bool{
+	"login": true, "signin": true, "sign-in": true, "logon": true, "authenticate": true, "token": true,
+	"password-reset": true, "reset-password": true, "forgot-password": true, "mfa": true, "otp": true,
+}
+
+// matches reports whether lockouts cover urlPath.
+func (l *lockoutConfig) matches(urlPath string) bool {
+	if !l.paths.Empty() {
+		return l.paths.Match(urlPath)
+	}
+	segment := strings.ToLower(path.Base(urlPath))
+	if _, verb, ok := strings.Cut(segment, ":"); ok {
+		segment = verb
+	}
+	return loginSegments[segment]
+}
+
+// lockoutMiddleware rejects locked out keys and counts failed attempts.
+func lockoutMiddleware(s *Server, next http.Handler) http.Handler {
+	l := s.cfg.lockout
+	retryAfter := strconv.FormatInt(ceilSeconds(l.duration), 10)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !l.matches(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := l.keyFunc(r)
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		locked, err := l.store.Get(ctx, lockoutLockedKey(key))
+		if err != nil {
+			s.logger.Warn("lockout store failed, allowing request", "component", "auth", "path", r.URL.Path, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+		if locked > 0 {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write(lockoutResponse)
+			return
+		}
+
+		rw := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(rw, r)
+		if rw.statusCode != http.StatusUnauthorized && rw.statusCode != http.StatusForbidden {
+			return
+		}
+
+		// Use a fresh context, as the request's may be canceled by now
+		ctx = context.WithoutCancel(ctx)
+		failures, err := l.store.Incr(ctx, lockoutFailuresKey(key), l.window)
+		if err != nil {
+			s.logger.Warn("lockout store failed", "component", "auth", "path", r.URL.Path, "error", err)
+			return
+		}
+		if failures < l.attempts {
+			return
+		}
+		if _, err := l.store.Incr(ctx, lockoutLockedKey(key), l.duration); err != nil {
+			s.logger.Warn("lockout store failed", "component", "auth", "path", r.URL.Path, "error", err)
+			return
+		}
+		_ = l.store.Delete(ctx, lockoutFailuresKey(key))
+		s.logger.Warn("locked out", "component", "auth",
+			"key", key,
+			"path", r.URL.Path,
+			"attempts", failures,
+			"duration", l.duration)
+	})
+}
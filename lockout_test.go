@@ -0,0 +1,171 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyozatech/grpckit/quota"
+	"google.golang.org/grpc"
+)
+
+func newLockoutTestServer(t *testing.T, opts ...LockoutOption) (*Server, http.Handler) {
+	t.Helper()
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithLockout(quota.NewMemoryStore(), opts...),
+		WithHTTPHandler("/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Password") != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s, s.buildHTTPHandler(http.NotFoundHandler())
+}
+
+func login(handler http.Handler, ip, password string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login", nil)
+	req.RemoteAddr = ip + ":40000"
+	req.Header.Set("X-Password", password)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWithLockout(t *testing.T) {
+	buf := captureLog(t)
+	_, handler := newLockoutTestServer(t, LockoutAttempts(3, time.Minute), LockoutDuration(10*time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if rec := login(handler, "203.0.113.7", "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("attempt %d: expected 401, got %d", i+1, rec.Code)
+		}
+	}
+
+	// Locked out, even with the right password
+	rec := login(handler, "203.0.113.7", "secret")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "600" {
+		t.Errorf("expected 429 with Retry-After, got %d %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+	if !strings.Contains(buf.String(), `msg="locked out" component=auth key=203.0.113.7 path=/login attempts=3 duration=10m0s`) {
+		t.Errorf("expected lockout to be logged, got %q", buf.String())
+	}
+
+	// Other clients are not affected
+	if rec := login(handler, "198.51.100.1", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected other client to be allowed, got %d", rec.Code)
+	}
+}
+
+func TestWithLockout_AuthRejections(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithLockout(quota.NewMemoryStore(), LockoutAttempts(2, time.Minute), LockoutPaths("/api/**")),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	var code int
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+		req.Header.Set("Authorization", "Bearer wrong")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		code = rec.Code
+	}
+	if code != http.StatusTooManyRequests {
+		t.Errorf("expected WithAuth rejections to lock out, got %d", code)
+	}
+}
+
+func TestLockoutConfig_DefaultPaths(t *testing.T) {
+	l := &lockoutConfig{}
+	for urlPath, want := range map[string]bool{
+		"/login":                   true,
+		"/api/v1/auth/token":       true,
+		"/api/v1/Password-Reset":   true,
+		"/v1/sessions:login":       true,
+		"/api/v1/items":            false,
+		"/api/v1/users/42/profile": false,
+		"/":                        false,
+	} {
+		if got := l.matches(urlPath); got != want {
+			t.Errorf("matches(%s) = %v, want %v", urlPath, got, want)
+		}
+	}
+}
+
+func TestWithLockout_PathsAndKey(t *testing.T) {
+	_, handler := newLockoutTestServer(t,
+		LockoutAttempts(1, time.Minute),
+		LockoutPaths("/other"),
+	)
+	login(handler, "203.0.113.7", "wrong")
+	if rec := login(handler, "203.0.113.7", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected unmatched path not to lock out, got %d", rec.Code)
+	}
+
+	_, handler = newLockoutTestServer(t,
+		LockoutAttempts(1, time.Minute),
+		LockoutKey(func(r *http.Request) string { return "" }),
+	)
+	login(handler, "203.0.113.7", "wrong")
+	if rec := login(handler, "203.0.113.7", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected empty key not to be tracked, got %d", rec.Code)
+	}
+
+	// Rate limit key functions see the request's headers as metadata
+	_, handler = newLockoutTestServer(t,
+		LockoutAttempts(1, time.Minute),
+		LockoutKeyFunc(RateLimitByAPIKey("X-Password")),
+	)
+	login(handler, "203.0.113.7", "wrong")
+	if rec := login(handler, "203.0.113.7", "wrong"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected key from the header to be locked out, got %d", rec.Code)
+	}
+	if rec := login(handler, "203.0.113.7", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected another key not to be locked out, got %d", rec.Code)
+	}
+}
+
+func TestResetLockout(t *testing.T) {
+	s, handler := newLockoutTestServer(t, LockoutAttempts(1, time.Minute))
+	login(handler, "203.0.113.7", "wrong")
+	if rec := login(handler, "203.0.113.7", "secret"); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected lockout, got %d", rec.Code)
+	}
+
+	if err := s.ResetLockout(context.Background(), "203.0.113.7"); err != nil {
+		t.Fatalf("ResetLockout failed: %v", err)
+	}
+	if rec := login(handler, "203.0.113.7", "secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected lockout to be lifted, got %d", rec.Code)
+	}
+}
+
+func TestWithLockout_Invalid(t *testing.T) {
+	svc := WithGRPCService(func(s grpc.ServiceRegistrar) {})
+	if _, err := New(svc, WithLockout(nil)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected nil store to be rejected, got %v", err)
+	}
+	if _, err := New(svc, WithLockout(quota.NewMemoryStore(), LockoutAttempts(0, time.Minute))); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected zero attempts to be rejected, got %v", err)
+	}
+
+	s, _ := New(svc)
+	if err := s.ResetLockout(context.Background(), "key"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ResetLockout without lockouts to fail, got %v", err)
+	}
+}
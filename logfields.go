@@ -0,0 +1,153 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LogFieldSet collects structured fields for a request's canonical log line.
+// Fields are emitted in the order they were added. It is safe for concurrent use.
+type LogFieldSet struct {
+	mu     sync.Mutex
+	fields []logField
+}
+
+// logField is a single key/value pair.
+type logField struct {
+	key   string
+	value any
+}
+
+// logFieldsKey is the context key for the request's LogFieldSet.
+type logFieldsKey struct{}
+
+// withLogFields returns a context carrying a new, empty LogFieldSet.
+func withLogFields(ctx context.Context) (context.Context, *LogFieldSet) {
+	f := &LogFieldSet{}
+	return context.WithValue(ctx, logFieldsKey{}, f), f
+}
+
+// LogFields returns the log fields of the current request. Fields added here
+// are included in the access log line written when the request completes,
+// so handlers don't need to log separately.
+//
+// Outside a logged request it returns a detached set, so calls are always safe.
+//
+// Example:
+//
+//	grpckit.LogFields(ctx).Add("order_id", order.ID).Add("items", len(order.Items))
+func LogFields(ctx context.Context) *LogFieldSet {
+	if f, ok := ctx.Value(logFieldsKey{}).(*LogFieldSet); ok {
+		return f
+	}
+	return &LogFieldSet{}
+}
+
+// Add adds a field. Adding an existing key replaces its value.
+func (f *LogFieldSet) Add(key string, value any) *LogFieldSet {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.fields {
+		if f.fields[i].key == key {
+			f.fields[i].value = value
+			return f
+		}
+	}
+	f.fields = append(f.fields, logField{key: key, value: value})
+	return f
+}
+
+// Get returns the value of a field and whether it was set.
+func (f *LogFieldSet) Get(key string) (any, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, field := range f.fields {
+		if field.key == key {
+			return field.value, true
+		}
+	}
+	return nil, false
+}
+
+// String formats the fields as space-separated key=value pairs (logfmt).
+func (f *LogFieldSet) String() string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var b strings.Builder
+	for i, field := range f.fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field.key)
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(field.value))
+	}
+	return b.String()
+}
+
+// attrs returns the fields as slog attributes.
+func (f *LogFieldSet) attrs() []slog.Attr {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	attrs := make([]slog.Attr, len(f.fields))
+	for i, field := range f.fields {
+		attrs[i] = slog.Any(field.key, field.value)
+	}
+	return attrs
+}
+
+// logfmtValue formats a value, quoting it if it contains spaces, quotes or '='.
+func logfmtValue(v any) string {
+	s := fmt.Sprint(v)
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// accessLogMiddleware writes one canonical log line per HTTP request,
+// including any fields added by handlers via LogFields.
+func accessLogMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, requestID := ensureRequestID(r)
+		ctx, fields := withLogFields(r.Context())
+		start := time.Now()
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+
+		if !s.logger.Enabled(ctx, slog.LevelInfo) {
+			return
+		}
+		s.logger.LogAttrs(ctx, slog.LevelInfo, "request", append([]slog.Attr{
+			slog.String("component", "http"),
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", wrapped.statusCode),
+			slog.Duration("duration", time.Since(start).Round(time.Microsecond)),
+			slog.String("request_id", requestID),
+		}, fields.attrs()...)...)
+	})
+}
+
+// WithAccessLog writes one structured log line per HTTP request with the
+// method, path, status, duration and request ID, plus any fields handlers
+// attached with LogFields. The request ID (taken from X-Request-ID or
+// generated) is forwarded to gRPC as x-request-id metadata, so gRPC-side
+// logs of the same call can be correlated. Lines are written at info level and suppressed when the log
+// level is warn or error.
+//
+// Example output:
+//
+//	level=INFO msg=request component=http method=POST path=/api/v1/orders status=200 duration=1.204ms request_id=4bf92f35 order_id=42
+func WithAccessLog() Option {
+	return func(c *serverConfig) {
+		c.accessLog = true
+	}
+}
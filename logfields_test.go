@@ -0,0 +1,100 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+// captureLog redirects the standard logger for the duration of a test.
+func captureLog(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(prev) })
+	return &buf
+}
+
+func TestLogFields_Detached(t *testing.T) {
+	// Outside a request, Add must not panic
+	f := LogFields(context.Background()).Add("k", "v")
+	if v, ok := f.Get("k"); !ok || v != "v" {
+		t.Errorf("expected detached set to hold field, got %v", v)
+	}
+}
+
+func TestLogFields_FromContext(t *testing.T) {
+	ctx, fields := withLogFields(context.Background())
+	LogFields(ctx).Add("order_id", 42)
+
+	if v, ok := fields.Get("order_id"); !ok || v != 42 {
+		t.Errorf("expected order_id 42, got %v", v)
+	}
+}
+
+func TestLogFieldSet_String(t *testing.T) {
+	f := &LogFieldSet{}
+	f.Add("a", 1).Add("msg", "hello world").Add("empty", "").Add("a", 2)
+
+	expected := `a=2 msg="hello world" empty=""`
+	if got := f.String(); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func TestAccessLogMiddleware(t *testing.T) {
+	buf := captureLog(t)
+
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	handler := accessLogMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		LogFields(r.Context()).Add("order_id", "o-1")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+	line := buf.String()
+	for _, want := range []string{"msg=request component=http method=POST path=/orders status=201 duration=", "order_id=o-1"} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expected log line to contain %q, got %q", want, line)
+		}
+	}
+	if strings.Count(line, "\n") != 1 {
+		t.Errorf("expected a single log line, got %q", line)
+	}
+}
+
+func TestAccessLogMiddleware_SuppressedAboveInfo(t *testing.T) {
+	buf := captureLog(t)
+
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithLogLevel("warn"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	handler := accessLogMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/x", nil))
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no access log at warn level, got %q", buf.String())
+	}
+}
+
+func TestWithAccessLog(t *testing.T) {
+	cfg := newServerConfig()
+	WithAccessLog()(cfg)
+
+	if !cfg.accessLog {
+		t.Error("expected access log enabled")
+	}
+}
@@ -0,0 +1,108 @@
+package grpckit
+
+import (
+	"context"
+	"log"
+	"log/slog"
+	"strings"
+)
+
+// WithLogger sets the logger of the server. Startup, shutdown, auth
+// failures, gateway registration and the other subsystems log through it
+// with structured fields, and so do the access and gRPC logs. Records are
+// filtered by the server log level (see WithLogLevel and SetLogLevel) before
+// reaching the logger's handler.
+//
+// The default logger writes logfmt lines through the standard log package,
+// so log.SetOutput and log.SetFlags still apply.
+//
+// Example:
+//
+//	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+//	grpckit.WithLogger(logger.With("service", "orders"))
+func WithLogger(l *slog.Logger) Option {
+	return func(c *serverConfig) {
+		if l == nil {
+			c.invalid("WithLogger: logger is nil")
+			return
+		}
+		c.logger = l
+	}
+}
+
+// slogLevels maps the log level names to slog levels.
+var slogLevels = map[string]slog.Level{
+	"debug": slog.LevelDebug,
+	"info":  slog.LevelInfo,
+	"warn":  slog.LevelWarn,
+	"error": slog.LevelError,
+}
+
+// levelName returns the name of a log level.
+func levelName(level slog.Level) string {
+	for name, l := range slogLevels {
+		if l == level {
+			return name
+		}
+	}
+	return strings.ToLower(level.String())
+}
+
+// stdLogWriter writes log records through the standard log package.
+type stdLogWriter struct{}
+
+func (stdLogWriter) Write(p []byte) (int, error) {
+	return len(p), log.Output(2, string(p))
+}
+
+// newDefaultLogger returns the default logger, writing logfmt lines without
+// time (the log package adds its own) through the standard log package.
+func newDefaultLogger(level slog.Leveler) *slog.Logger {
+	return slog.New(slog.NewTextHandler(stdLogWriter{}, &slog.HandlerOptions{
+		Level: level,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+}
+
+// defaultLogger is the logger used without WithLogger. New filters it by the
+// server log level.
+var defaultLogger = newDefaultLogger(slog.LevelDebug)
+
+// fallbackLogger logs for code running outside a request served by a Server
+// (e.g. handlers mounted elsewhere), at info level.
+var fallbackLogger = newDefaultLogger(slog.LevelInfo)
+
+// levelHandler filters records below the server log level.
+type levelHandler struct {
+	level   *slog.LevelVar
+	handler slog.Handler
+}
+
+func (h *levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.handler.Enabled(ctx, level)
+}
+
+func (h *levelHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.handler.Handle(ctx, r)
+}
+
+func (h *levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithAttrs(attrs)}
+}
+
+func (h *levelHandler) WithGroup(name string) slog.Handler {
+	return &levelHandler{level: h.level, handler: h.handler.WithGroup(name)}
+}
+
+// loggerFrom returns the logger of the server handling the request of ctx.
+func loggerFrom(ctx context.Context) *slog.Logger {
+	if s, ok := ctx.Value(serverKey{}).(*Server); ok {
+		return s.logger
+	}
+	return fallbackLogger
+}
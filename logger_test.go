@@ -0,0 +1,89 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestLevelHandler(t *testing.T) {
+	tests := []struct {
+		current, level string
+		expected       bool
+	}{
+		{"debug", "debug", true},
+		{"info", "debug", false},
+		{"info", "warn", true},
+		{"warn", "info", false},
+		{"error", "warn", false},
+	}
+
+	for _, tt := range tests {
+		level := new(slog.LevelVar)
+		level.Set(slogLevels[tt.current])
+		h := &levelHandler{level: level, handler: slog.NewTextHandler(&bytes.Buffer{}, &slog.HandlerOptions{Level: slog.LevelDebug})}
+		if got := h.Enabled(context.Background(), slogLevels[tt.level]); got != tt.expected {
+			t.Errorf("Enabled(%q) at %q = %v, expected %v", tt.level, tt.current, got, tt.expected)
+		}
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s := newSlowTestServer(t, WithLogger(logger.With("service", "orders")), WithLogLevel("error"))
+
+	// Filtered by the server log level, not the handler's
+	s.SetServiceHealth("item.v1.ItemService", healthpb.HealthCheckResponse_NOT_SERVING)
+	if buf.Len() != 0 {
+		t.Fatalf("expected warning to be filtered at error level, got %q", buf.String())
+	}
+
+	if err := s.SetLogLevel("warn"); err != nil {
+		t.Fatalf("SetLogLevel failed: %v", err)
+	}
+	s.SetServiceHealth("item.v1.ItemService", healthpb.HealthCheckResponse_NOT_SERVING)
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("invalid JSON record %q: %v", buf.String(), err)
+	}
+	if record["level"] != "WARN" || record["component"] != "health" || record["service"] != "item.v1.ItemService" {
+		t.Errorf("unexpected record %v", record)
+	}
+
+	cfg := newServerConfig()
+	WithLogger(nil)(cfg)
+	if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected nil logger to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestDefaultLogger(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithLogLevel("debug"))
+	s.logger.Debug("checking", "component", "test", "key", "a b")
+
+	// Written through the log package, which adds its own time
+	if line := buf.String(); !strings.Contains(line, `level=DEBUG msg=checking component=test key="a b"`) || strings.Contains(line, "time=") {
+		t.Errorf("unexpected line %q", line)
+	}
+	if s.LogLevel() != "debug" {
+		t.Errorf("expected debug level, got %q", s.LogLevel())
+	}
+}
+
+func TestLoggerFrom(t *testing.T) {
+	s := newSlowTestServer(t)
+	if loggerFrom(s.withServer(context.Background())) != s.logger {
+		t.Error("expected the server's logger in a request context")
+	}
+	if loggerFrom(context.Background()) != fallbackLogger {
+		t.Error("expected the fallback logger outside a request")
+	}
+}
@@ -0,0 +1,119 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// defaultMaintenanceRetryAfter is the Retry-After of requests rejected during
+// maintenance without an expected end.
+const defaultMaintenanceRetryAfter = 60 * time.Second
+
+// SetMaintenance toggles maintenance mode. While enabled, all HTTP requests
+// except health checks, metrics and the admin API receive 503 Service Unavailable
+// with "Retry-After: 60", and gRPC calls except those of the health service
+// fail with Unavailable and a RetryInfo detail.
+func (s *Server) SetMaintenance(enabled bool) {
+	s.maintenanceUntil.Store(0)
+	s.maintenance.Store(enabled)
+	s.emit(ConfigReloaded{Time: time.Now(), Setting: "maintenance", Value: strconv.FormatBool(enabled)})
+}
+
+// SetMaintenanceFor enables maintenance mode for an expected duration d.
+// Rejected requests get a Retry-After counting down to its end, so clients
+// come back when the service does. Maintenance mode stays enabled until
+// SetMaintenance(false); past the expected end, Retry-After is 60 again.
+func (s *Server) SetMaintenanceFor(d time.Duration) {
+	s.maintenanceUntil.Store(s.now().Add(d).UnixNano())
+	s.maintenance.Store(true)
+	s.emit(ConfigReloaded{Time: time.Now(), Setting: "maintenance", Value: "true"})
+}
+
+// maintenanceRetryDelay returns how long clients rejected during
+// maintenance should wait, in whole seconds.
+func (s *Server) maintenanceRetryDelay() time.Duration {
+	if until := s.maintenanceUntil.Load(); until != 0 {
+		if d := time.Unix(0, until).Sub(s.now()); d > 0 {
+			return time.Duration(ceilSeconds(d)) * time.Second
+		}
+	}
+	return defaultMaintenanceRetryAfter
+}
+
+// maintenanceRetryAfter returns the Retry-After header of requests rejected
+// during maintenance.
+func (s *Server) maintenanceRetryAfter() string {
+	return strconv.FormatInt(int64(s.maintenanceRetryDelay()/time.Second), 10)
+}
+
+// InMaintenance reports whether maintenance mode is enabled.
+func (s *Server) InMaintenance() bool {
+	return s.maintenance.Load()
+}
+
+// isMaintenanceExempt reports whether a path is served during maintenance:
+// the health and metrics endpoints.
+func isMaintenanceExempt(cfg *serverConfig, urlPath string) bool {
+	return urlPath == cfg.livenessPath || urlPath == cfg.readinessPath || urlPath == cfg.metricsPath
+}
+
+// maintenanceMiddleware rejects HTTP requests during maintenance.
+func maintenanceMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.InMaintenance() && !isMaintenanceExempt(s.cfg, r.URL.Path) {
+			w.Header().Set("Retry-After", s.maintenanceRetryAfter())
+			http.Error(w, "service under maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// maintenanceError returns the error of gRPC calls rejected during
+// maintenance, or nil if the call may proceed. Health checks are served, so
+// probes and load balancers see the server's actual state.
+func (s *Server) maintenanceError(method string) error {
+	if !s.InMaintenance() || strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+	st, _ := status.New(codes.Unavailable, "service under maintenance").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(s.maintenanceRetryDelay())},
+	)
+	return st.Err()
+}
+
+// maintenanceUnaryInterceptor rejects unary calls during maintenance.
+func (s *Server) maintenanceUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := s.maintenanceError(info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// maintenanceStreamInterceptor rejects streaming calls during maintenance.
+func (s *Server) maintenanceStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := s.maintenanceError(info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
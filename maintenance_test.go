@@ -0,0 +1,95 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestMaintenance_WithoutAdminAPI(t *testing.T) {
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, itempb.UnimplementedItemServiceServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+		WithHealthCheck(),
+		WithGRPCHealthService(),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+	conn := ts.GRPCClientConn(context.Background())
+	items := itempb.NewItemServiceClient(conn)
+
+	ts.SetMaintenanceFor(15 * time.Minute)
+
+	resp, err := ts.HTTPClient().Get(ts.URL("/api/v1/items/1"))
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != "900" {
+		t.Errorf("expected 503 with Retry-After 900, got %d %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+	}
+	resp, err = ts.HTTPClient().Get(ts.URL("/healthz"))
+	if err != nil {
+		t.Fatalf("GET failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected health check to bypass maintenance, got %d", resp.StatusCode)
+	}
+
+	_, err = items.GetItem(context.Background(), &itempb.GetItemRequest{Id: "1"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() != 15*time.Minute {
+		t.Errorf("expected 15m RetryInfo, got %v", retry)
+	}
+	if got := checkHealth(t, healthpb.NewHealthClient(conn), ""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected gRPC health checks to bypass maintenance, got %s", got)
+	}
+
+	ts.SetMaintenance(false)
+	if _, err := items.GetItem(context.Background(), &itempb.GetItemRequest{Id: "1"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("expected the call to reach the service after maintenance, got %v", err)
+	}
+}
+
+func TestMaintenanceStreamInterceptor(t *testing.T) {
+	s := newSlowTestServer(t)
+	called := false
+	call := func() error {
+		stream := &contextServerStream{ctx: context.Background()}
+		info := &grpc.StreamServerInfo{FullMethod: "/item.v1.ItemService/Watch"}
+		return s.maintenanceStreamInterceptor(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+			called = true
+			return nil
+		})
+	}
+
+	s.SetMaintenance(true)
+	if err := call(); status.Code(err) != codes.Unavailable || called {
+		t.Errorf("expected Unavailable without calling the handler, got %v", err)
+	}
+	s.SetMaintenance(false)
+	if err := call(); err != nil || !called {
+		t.Errorf("expected the handler to be called, got %v", err)
+	}
+}
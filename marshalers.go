@@ -3,22 +3,28 @@ package grpckit
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime"
 	"mime/multipart"
+	"net/http"
 	"net/url"
-	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
-	"unicode"
+	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit/internal/pathmatch"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // bufferPool provides reusable byte buffers to reduce GC pressure.
@@ -45,39 +51,42 @@ func putBuffer(buf *bytes.Buffer) {
 	bufferPool.Put(buf)
 }
 
-// titleCase capitalizes the first letter of a string.
-// This is a simple replacement for the deprecated strings.Title.
-func titleCase(s string) string {
-	if s == "" {
-		return s
-	}
-	r := []rune(s)
-	r[0] = unicode.ToUpper(r[0])
-	return string(r)
-}
-
 // buildMarshalerOptions converts the marshaler configuration to ServeMuxOptions.
 func buildMarshalerOptions(cfg *serverConfig) []runtime.ServeMuxOption {
 	var opts []runtime.ServeMuxOption
 
+	strict := cfg.jsonOptions != nil && cfg.jsonOptions.StrictFields
+
 	// Apply JSON options if set
 	if cfg.jsonOptions != nil {
-		jsonMarshaler := &runtime.JSONPb{
-			MarshalOptions: protojson.MarshalOptions{
-				UseProtoNames:   cfg.jsonOptions.UseProtoNames,
-				EmitUnpopulated: cfg.jsonOptions.EmitUnpopulated,
-				Indent:          cfg.jsonOptions.Indent,
-			},
-			UnmarshalOptions: protojson.UnmarshalOptions{
-				DiscardUnknown: cfg.jsonOptions.DiscardUnknown,
-			},
-		}
+		jsonMarshaler := newJSONMarshaler(cfg.jsonOptions)
 		opts = append(opts, runtime.WithMarshalerOption("application/json", jsonMarshaler))
 		opts = append(opts, runtime.WithMarshalerOption(runtime.MIMEWildcard, jsonMarshaler))
 	}
 
+	// Pretty-printed and per-endpoint JSON marshalers are registered under
+	// MIME types selected by jsonMarshalerMiddleware
+	if cfg.prettyJSON {
+		base := JSONOptions{EmitUnpopulated: true} // gateway default
+		if cfg.jsonOptions != nil {
+			base = *cfg.jsonOptions
+		}
+		opts = append(opts, runtime.WithMarshalerOption(prettyJSONMIME, prettyJSONMarshaler(base)))
+	}
+	for i, o := range cfg.jsonOverrides {
+		opts = append(opts, runtime.WithMarshalerOption(jsonOverrideMIME(i, false), newJSONMarshaler(&o.options)))
+		if cfg.prettyJSON {
+			opts = append(opts, runtime.WithMarshalerOption(jsonOverrideMIME(i, true), prettyJSONMarshaler(o.options)))
+		}
+	}
+
 	// Apply custom marshalers
 	for mimeType, marshaler := range cfg.marshalers {
+		if fm, ok := marshaler.(*FormMarshaler); ok && strict && !fm.StrictFields {
+			strictForm := *fm
+			strictForm.StrictFields = true
+			marshaler = &strictForm
+		}
 		opts = append(opts, runtime.WithMarshalerOption(mimeType, marshaler))
 	}
 
@@ -87,6 +96,448 @@ func buildMarshalerOptions(cfg *serverConfig) []runtime.ServeMuxOption {
 	return opts
 }
 
+// ============================================================================
+// Strict JSON Marshaler
+// ============================================================================
+
+// StrictJSONMarshaler is a JSON marshaler that rejects unknown fields,
+// reporting every unknown key as a *ValidationError instead of only the
+// first one. It is installed by JSONOptions.StrictFields.
+type StrictJSONMarshaler struct {
+	*runtime.JSONPb
+}
+
+// Unmarshal parses JSON into v, failing on unknown fields.
+func (m *StrictJSONMarshaler) Unmarshal(data []byte, v interface{}) error {
+	if err := m.JSONPb.Unmarshal(data, v); err != nil {
+		if unknown := unknownJSONFieldsError(v, data); unknown != nil {
+			return unknown
+		}
+		return err
+	}
+	return nil
+}
+
+// NewDecoder returns a decoder reading JSON values from r.
+func (m *StrictJSONMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
+	return &strictJSONDecoder{r: r, dec: json.NewDecoder(r), marshaler: m}
+}
+
+// strictJSONDecoder implements runtime.Decoder for StrictJSONMarshaler.
+type strictJSONDecoder struct {
+	r         io.Reader
+	dec       *json.Decoder
+	marshaler *StrictJSONMarshaler
+}
+
+func (d *strictJSONDecoder) Decode(v interface{}) error {
+	var raw json.RawMessage
+	if err := d.dec.Decode(&raw); err != nil {
+		return err
+	}
+	return reportValidationError(d.r, d.marshaler.Unmarshal(raw, v))
+}
+
+// jsonOverride holds per-pattern JSON options.
+type jsonOverride struct {
+	paths   *pathmatch.Matcher
+	options JSONOptions
+}
+
+// prettyJSONMIME is the Accept value requesting indented JSON (see WithPrettyJSON).
+const prettyJSONMIME = "application/json+pretty"
+
+// jsonOverrideMIME is the internal MIME type the marshaler of override i is
+// registered under.
+func jsonOverrideMIME(i int, pretty bool) string {
+	if pretty {
+		return "application/json+pretty; grpckit-override=" + strconv.Itoa(i)
+	}
+	return "application/json; grpckit-override=" + strconv.Itoa(i)
+}
+
+// prettyJSONMarshaler builds an indented JSON marshaler for opts.
+func prettyJSONMarshaler(opts JSONOptions) runtime.Marshaler {
+	opts.Indent = "  "
+	return &runtime.HTTPBodyMarshaler{Marshaler: newJSONMarshaler(&opts)}
+}
+
+// jsonMarshalerMiddleware selects the pretty-printed and per-endpoint JSON
+// marshalers. The gateway picks the response marshaler by exact Accept header
+// match, so matching requests get the internal MIME type of the marshaler.
+func jsonMarshalerMiddleware(cfg *serverConfig, next http.Handler) http.Handler {
+	if len(cfg.jsonOverrides) == 0 && !cfg.prettyJSON {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The parameter is always stripped, but only selects pretty JSON
+		// for clients accepting JSON: it doesn't override an XML Accept
+		param := cfg.prettyJSON && prettyParam(r)
+		jsonAccepted := acceptsJSON(r.Header.Values("Accept"))
+		pretty := cfg.prettyJSON && (r.Header.Get("Accept") == prettyJSONMIME || (param && jsonAccepted))
+		if pretty || jsonAccepted {
+			accept := ""
+			if pretty {
+				accept = prettyJSONMIME
+			}
+			for i, o := range cfg.jsonOverrides {
+				if o.paths.Match(r.URL.Path) {
+					accept = jsonOverrideMIME(i, pretty)
+					break
+				}
+			}
+			if accept != "" {
+				r.Header.Set("Accept", accept)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// varyAccept is the Vary header of responses negotiated by Accept.
+var varyAccept = []string{"Accept"}
+
+// varyAcceptMiddleware adds "Vary: Accept" to gateway responses when their
+// format depends on the Accept header (custom marshalers, HTML tables, or
+// pretty-printed JSON), so CDNs and proxies don't serve one client's XML to
+// another expecting JSON. CORS adds Origin the same way.
+func varyAcceptMiddleware(cfg *serverConfig, next http.Handler) http.Handler {
+	if len(cfg.marshalers) == 0 && !cfg.prettyJSON {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addVary(w.Header(), varyAccept)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// addVary adds the single header name in vary to the Vary header, unless
+// it is already listed. vary is shared and must not be modified.
+func addVary(h http.Header, vary []string) {
+	values, ok := h["Vary"]
+	if !ok {
+		h["Vary"] = vary
+		return
+	}
+	for _, v := range values {
+		for _, name := range strings.Split(v, ",") {
+			name = strings.TrimSpace(name)
+			if name == "*" || strings.EqualFold(name, vary[0]) {
+				return
+			}
+		}
+	}
+	h["Vary"] = append(values[:len(values):len(values)], vary[0])
+}
+
+// prettyParam reports whether the request has a "pretty" query parameter
+// set to true, removing it so it doesn't reach the gateway query parser.
+func prettyParam(r *http.Request) bool {
+	query := r.URL.Query()
+	values, ok := query["pretty"]
+	if !ok {
+		return false
+	}
+	query.Del("pretty")
+	r.URL.RawQuery = query.Encode()
+
+	pretty, err := strconv.ParseBool(values[0])
+	return values[0] == "" || (err == nil && pretty)
+}
+
+// acceptsJSON reports whether Accept header values allow a JSON response.
+func acceptsJSON(values []string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		for more := true; more; {
+			var part string
+			part, v, more = strings.Cut(v, ",")
+			mediaType, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+			switch mediaType {
+			case "application/json", "application/*", "*/*":
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newJSONMarshaler builds the JSON marshaler for opts.
+func newJSONMarshaler(opts *JSONOptions) runtime.Marshaler {
+	jsonPb := &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{
+			UseProtoNames:   opts.UseProtoNames,
+			EmitUnpopulated: opts.EmitUnpopulated,
+			UseEnumNumbers:  opts.UseEnumNumbers,
+			Indent:          opts.Indent,
+		},
+		UnmarshalOptions: protojson.UnmarshalOptions{
+			DiscardUnknown: opts.DiscardUnknown && !opts.StrictFields,
+		},
+	}
+
+	var m runtime.Marshaler = jsonPb
+	if opts.StrictFields {
+		m = &StrictJSONMarshaler{JSONPb: jsonPb}
+	}
+	if opts.Int64AsNumber || opts.TimestampFormat != TimestampRFC3339 || opts.DurationFormat != DurationString {
+		m = &jsonFormatMarshaler{
+			Marshaler:     m,
+			indent:        opts.Indent,
+			int64AsNumber: opts.Int64AsNumber,
+			overflow:      opts.Int64Overflow,
+			timestamps:    opts.TimestampFormat,
+			durations:     opts.DurationFormat,
+		}
+	}
+	return m
+}
+
+// ============================================================================
+// Formatted JSON Marshaler
+// ============================================================================
+
+// maxSafeJSONInt is the largest integer JavaScript numbers represent exactly.
+const maxSafeJSONInt = 1<<53 - 1
+
+// jsonFormatMarshaler rewrites protojson output for legacy API contracts:
+// 64-bit integers as numbers (JSONOptions.Int64AsNumber) and timestamps and
+// durations as milliseconds. Decoding is left to the wrapped marshaler.
+type jsonFormatMarshaler struct {
+	runtime.Marshaler
+	indent        string
+	int64AsNumber bool
+	overflow      Int64Overflow
+	timestamps    TimestampFormat
+	durations     DurationFormat
+}
+
+// Marshal serializes v to JSON in the configured format.
+func (m *jsonFormatMarshaler) Marshal(v interface{}) ([]byte, error) {
+	data, err := m.Marshaler.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := m.writeMessage(&buf, msg.ProtoReflect().Descriptor(), data, ""); err != nil {
+		return nil, err
+	}
+	if m.indent == "" {
+		return buf.Bytes(), nil
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", m.indent); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// NewEncoder returns an encoder writing newline-delimited JSON to w.
+func (m *jsonFormatMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v interface{}) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		_, err = w.Write([]byte("\n"))
+		return err
+	})
+}
+
+// writeMessage writes the JSON object raw of message type md, rewriting its
+// 64-bit integer fields. Values that are not objects (well-known types such
+// as Timestamp) are written unchanged, except Int64Value and UInt64Value.
+func (m *jsonFormatMarshaler) writeMessage(buf *bytes.Buffer, md protoreflect.MessageDescriptor, raw []byte, path string) error {
+	switch md.FullName() {
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		if m.int64AsNumber {
+			return m.writeNumber(buf, raw, path)
+		}
+	case "google.protobuf.Timestamp":
+		if m.timestamps == TimestampEpochMillis {
+			return writeMillis(buf, raw, func(s string) (int64, error) {
+				t, err := time.Parse(time.RFC3339Nano, s)
+				return t.UnixMilli(), err
+			})
+		}
+	case "google.protobuf.Duration":
+		if m.durations == DurationMillis {
+			return writeMillis(buf, raw, func(s string) (int64, error) {
+				d, err := time.ParseDuration(s)
+				return d.Milliseconds(), err
+			})
+		}
+	}
+	trimmed := bytes.TrimSpace(raw)
+	if len(trimmed) == 0 || trimmed[0] != '{' || freeFormTypes[md.FullName()] {
+		buf.Write(raw)
+		return nil
+	}
+
+	return writeJSONObject(buf, raw, func(key string, val []byte) error {
+		fd := md.Fields().ByJSONName(key)
+		if fd == nil {
+			fd = md.Fields().ByName(protoreflect.Name(key))
+		}
+		if fd == nil {
+			buf.Write(val)
+			return nil
+		}
+		return m.writeField(buf, fd, val, path+key)
+	})
+}
+
+// writeField writes the value of fd, handling lists and maps.
+func (m *jsonFormatMarshaler) writeField(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, raw []byte, path string) error {
+	switch {
+	case fd.IsMap():
+		return writeJSONObject(buf, raw, func(key string, val []byte) error {
+			return m.writeSingular(buf, fd.MapValue(), val, path+"."+key)
+		})
+	case fd.IsList():
+		return writeJSONArray(buf, raw, func(val []byte) error {
+			return m.writeSingular(buf, fd, val, path)
+		})
+	default:
+		return m.writeSingular(buf, fd, raw, path)
+	}
+}
+
+// writeSingular writes a single (non-list, non-map) value of fd.
+func (m *jsonFormatMarshaler) writeSingular(buf *bytes.Buffer, fd protoreflect.FieldDescriptor, raw []byte, path string) error {
+	switch fd.Kind() {
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if !m.int64AsNumber {
+			buf.Write(raw)
+			return nil
+		}
+		return m.writeNumber(buf, raw, path)
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return m.writeMessage(buf, fd.Message(), raw, path+".")
+	default:
+		buf.Write(raw)
+		return nil
+	}
+}
+
+// writeNumber writes a string-encoded integer as a number, applying the
+// overflow policy to values beyond ±2^53-1.
+func (m *jsonFormatMarshaler) writeNumber(buf *bytes.Buffer, raw []byte, path string) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		// Not a string (e.g. null): keep as is
+		buf.Write(raw)
+		return nil
+	}
+
+	safe := false
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		safe = n >= -maxSafeJSONInt && n <= maxSafeJSONInt
+	} else if _, err := strconv.ParseUint(s, 10, 64); err != nil {
+		buf.Write(raw)
+		return nil
+	}
+
+	if !safe {
+		switch m.overflow {
+		case Int64OverflowString:
+			buf.Write(raw)
+			return nil
+		case Int64OverflowError:
+			return fmt.Errorf("field %s: value %s exceeds the JSON safe integer range", strings.TrimSuffix(path, "."), s)
+		}
+	}
+	buf.WriteString(s)
+	return nil
+}
+
+// writeMillis writes a string-encoded timestamp or duration as a number of
+// milliseconds computed by parse. Values parse rejects are kept as is.
+func writeMillis(buf *bytes.Buffer, raw []byte, parse func(string) (int64, error)) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err != nil {
+		buf.Write(raw)
+		return nil
+	}
+	ms, err := parse(s)
+	if err != nil {
+		buf.Write(raw)
+		return nil
+	}
+	buf.WriteString(strconv.FormatInt(ms, 10))
+	return nil
+}
+
+// writeJSONObject writes the JSON object raw compactly, preserving key order
+// and calling fn to write each value.
+func writeJSONObject(buf *bytes.Buffer, raw []byte, fn func(key string, val []byte) error) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		buf.Write(raw)
+		return nil
+	}
+
+	buf.WriteByte('{')
+	for i := 0; dec.More(); i++ {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(tok)
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := fn(tok.(string), val); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeJSONArray writes the JSON array raw compactly, calling fn to write
+// each element.
+func writeJSONArray(buf *bytes.Buffer, raw []byte, fn func(val []byte) error) error {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('[') {
+		buf.Write(raw)
+		return nil
+	}
+
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		var val json.RawMessage
+		if err := dec.Decode(&val); err != nil {
+			return err
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := fn(val); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
 // ============================================================================
 // Form URL-Encoded Marshaler
 // ============================================================================
@@ -108,6 +559,13 @@ func buildMarshalerOptions(cfg *serverConfig) []runtime.ServeMuxOption {
 //	name=John&email=john@example.com&age=30
 type FormMarshaler struct {
 	runtime.JSONPb // Fallback for output (forms are typically input-only)
+
+	// StrictFields rejects forms containing keys that are not proto fields
+	// instead of ignoring them (see JSONOptions.StrictFields).
+	StrictFields bool
+
+	// Coercion types the values the target doesn't (default: CoerceInfer).
+	Coercion FormCoercion
 }
 
 // ContentType returns the MIME type for form data.
@@ -122,7 +580,16 @@ func (f *FormMarshaler) Unmarshal(data []byte, v interface{}) error {
 		return fmt.Errorf("failed to parse form data: %w", err)
 	}
 
-	return populateFromValues(values, v)
+	if f.StrictFields {
+		if err := unknownFieldsError(v, formFieldValues(values)); err != nil {
+			return err
+		}
+	}
+
+	if err := populateFromValues(values, v, f.Coercion); err != nil {
+		return validationError(v, formFieldValues(values), err)
+	}
+	return nil
 }
 
 // NewDecoder returns a decoder for streaming form data.
@@ -141,13 +608,35 @@ func (d *formDecoder) Decode(v interface{}) error {
 	if err != nil {
 		return err
 	}
-	return d.marshaler.Unmarshal(data, v)
+	return reportValidationError(d.r, d.marshaler.Unmarshal(data, v))
 }
 
+// FormCoercion controls how form and multipart values, which are all
+// strings, are typed in the JSON document decoded into the target when the
+// target doesn't say.
+//
+// Values of proto scalar fields always follow the field type: string fields
+// keep the value as sent (so "007" or "123" stay strings), numeric fields
+// get the exact digits (protojson parses them at full precision, so large
+// int64 IDs and decimals are not rounded), bool fields accept the values of
+// strconv.ParseBool. Coercion applies to the other values: fields of type
+// google.protobuf.Value, and values decoded into non-proto types (e.g. with
+// DecodeBody).
+type FormCoercion int
+
+const (
+	// CoerceInfer turns "true" and "false" into booleans and decimal
+	// numbers ("42", "-0.5", but not "007" or "1e3") into JSON numbers with
+	// the same digits; anything else stays a string. This is the default.
+	CoerceInfer FormCoercion = iota
+	// CoerceStrings keeps every value a string.
+	CoerceStrings
+)
+
 // populateFromValues populates a proto message from URL values.
-func populateFromValues(values url.Values, v interface{}) error {
+func populateFromValues(values url.Values, v interface{}, coercion FormCoercion) error {
 	// Convert to JSON then unmarshal via JSONPb for proper proto handling
-	jsonData, err := valuesToJSON(values)
+	jsonData, err := valuesToJSON(values, messageDescriptor(v), coercion)
 	if err != nil {
 		return err
 	}
@@ -160,9 +649,19 @@ func populateFromValues(values url.Values, v interface{}) error {
 	return jsonMarshaler.Unmarshal(jsonData, v)
 }
 
-// valuesToJSON converts URL values to JSON bytes.
+// messageDescriptor returns the descriptor of v, or nil if v is not a proto
+// message.
+func messageDescriptor(v interface{}) protoreflect.MessageDescriptor {
+	if msg, ok := v.(proto.Message); ok {
+		return msg.ProtoReflect().Descriptor()
+	}
+	return nil
+}
+
+// valuesToJSON converts URL values to JSON bytes, typing them with the
+// fields of md (which may be nil).
 // Supports nested fields via dot notation and repeated fields.
-func valuesToJSON(values url.Values) ([]byte, error) {
+func valuesToJSON(values url.Values, md protoreflect.MessageDescriptor, coercion FormCoercion) ([]byte, error) {
 	result := make(map[string]interface{})
 
 	for key, vals := range values {
@@ -173,17 +672,22 @@ func valuesToJSON(values url.Values) ([]byte, error) {
 		// Handle nested keys (e.g., "address.street" -> {"address": {"street": ...}})
 		parts := strings.Split(key, ".")
 		current := result
+		currentMD := md
 
 		for i, part := range parts {
+			var fd protoreflect.FieldDescriptor
+			if currentMD != nil {
+				fd = findField(currentMD, part)
+			}
 			if i == len(parts)-1 {
 				// Last part - set the value
 				if len(vals) == 1 {
-					current[part] = inferType(vals[0])
+					current[part] = coerceFormValue(fd, vals[0], coercion)
 				} else {
 					// Multiple values = array
 					arr := make([]interface{}, len(vals))
 					for j, v := range vals {
-						arr[j] = inferType(v)
+						arr[j] = coerceFormValue(fd, v, coercion)
 					}
 					current[part] = arr
 				}
@@ -195,6 +699,10 @@ func valuesToJSON(values url.Values) ([]byte, error) {
 				if nested, ok := current[part].(map[string]interface{}); ok {
 					current = nested
 				}
+				currentMD = nil
+				if fd != nil && !fd.IsMap() {
+					currentMD = fd.Message()
+				}
 			}
 		}
 	}
@@ -202,7 +710,52 @@ func valuesToJSON(values url.Values) ([]byte, error) {
 	return marshalJSON(result)
 }
 
-// inferType attempts to infer the Go type from a string value.
+// coerceFormValue types the string value of field fd (nil if unknown) for
+// protojson. Values that don't parse as the field type are passed as
+// strings, for protojson to reject and validationError to report.
+func coerceFormValue(fd protoreflect.FieldDescriptor, s string, coercion FormCoercion) interface{} {
+	if fd == nil || fd.IsMap() {
+		return coerceValue(s, coercion)
+	}
+	kind := fd.Kind()
+	if md := fd.Message(); md != nil {
+		switch md.FullName() {
+		case "google.protobuf.BoolValue":
+			kind = protoreflect.BoolKind
+		case "google.protobuf.Value":
+			return coerceValue(s, coercion)
+		default:
+			// Other well-known types (timestamps, durations, numeric
+			// wrappers, ...) parse strings
+			return s
+		}
+	}
+	switch kind {
+	case protoreflect.BoolKind:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	case protoreflect.EnumKind:
+		// protojson accepts enum numbers as numbers only
+		if _, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return json.Number(s)
+		}
+	}
+	// Strings, bytes (base64), enum names, and numbers, which protojson
+	// parses from strings without going through float64
+	return s
+}
+
+// coerceValue types a value the target doesn't type.
+func coerceValue(s string, coercion FormCoercion) interface{} {
+	if coercion == CoerceStrings {
+		return s
+	}
+	return inferType(s)
+}
+
+// inferType attempts to infer the JSON type from a string value: booleans,
+// and decimal numbers, kept as written in a json.Number.
 func inferType(s string) interface{} {
 	// Try boolean
 	if s == "true" {
@@ -212,25 +765,55 @@ func inferType(s string) interface{} {
 		return false
 	}
 
-	// Try integer
-	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return i
+	// Try number, without exponent (often part of IDs or codes)
+	if !strings.ContainsAny(s, "eE") && validJSONNumber(s) {
+		return json.Number(s)
 	}
 
-	// Try float
-	if f, err := strconv.ParseFloat(s, 64); err == nil {
-		// Only use float if it has a decimal point
-		if strings.Contains(s, ".") {
-			return f
+	// Default to string
+	return s
+}
+
+// validJSONNumber reports whether s is a number in JSON syntax.
+func validJSONNumber(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	digits := func() int {
+		n := 0
+		for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+			n++
 		}
+		return n
 	}
 
-	// Default to string
-	return s
+	// Integer part, without leading zeros
+	n := digits()
+	if n == 0 || n > 1 && s[0] == '0' {
+		return false
+	}
+	s = s[n:]
+	if strings.HasPrefix(s, ".") {
+		s = s[1:]
+		if n = digits(); n == 0 {
+			return false
+		}
+		s = s[n:]
+	}
+	if len(s) > 0 && (s[0] == 'e' || s[0] == 'E') {
+		s = s[1:]
+		if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+			s = s[1:]
+		}
+		if n = digits(); n == 0 {
+			return false
+		}
+		s = s[n:]
+	}
+	return s == ""
 }
 
 // marshalJSON is a simple JSON marshaler to avoid import cycles.
-// Uses buffer pooling to reduce GC pressure.
+// Uses buffer pooling to reduce GC pressure. Its output is deterministic:
+// see writeJSON.
 func marshalJSON(v interface{}) ([]byte, error) {
 	buf := getBuffer()
 	defer putBuffer(buf)
@@ -245,34 +828,52 @@ func marshalJSON(v interface{}) ([]byte, error) {
 	return result, nil
 }
 
+// writeJSON writes v, built of nil, bool, int64, float64, json.Number,
+// string, []byte, []interface{} and map[string]interface{} values, as JSON.
+// Other types can take part by implementing json.Marshaler. Object keys are
+// written in sorted order, like encoding/json does, so the same value always
+// produces the same bytes (for golden tests and hashing). Strings are escaped
+// as JSON strings and []byte is base64-encoded, as protojson expects for
+// bytes fields. json.Number is written as is, so numbers keep their digits;
+// invalid numbers and non-finite floats are an error.
 func writeJSON(w io.Writer, v interface{}) error {
 	switch val := v.(type) {
 	case nil:
-		_, err := w.Write([]byte("null"))
+		_, err := io.WriteString(w, "null")
 		return err
 	case bool:
 		if val {
-			_, err := w.Write([]byte("true"))
+			_, err := io.WriteString(w, "true")
 			return err
 		}
-		_, err := w.Write([]byte("false"))
+		_, err := io.WriteString(w, "false")
 		return err
 	case int64:
-		_, err := fmt.Fprintf(w, "%d", val)
+		_, err := io.WriteString(w, strconv.FormatInt(val, 10))
 		return err
 	case float64:
-		_, err := fmt.Fprintf(w, "%g", val)
+		if math.IsInf(val, 0) || math.IsNaN(val) {
+			return fmt.Errorf("unsupported value: %v", val)
+		}
+		_, err := io.WriteString(w, strconv.FormatFloat(val, 'g', -1, 64))
 		return err
-	case string:
-		_, err := fmt.Fprintf(w, "%q", val)
+	case json.Number:
+		if !validJSONNumber(string(val)) {
+			return fmt.Errorf("invalid number: %q", string(val))
+		}
+		_, err := io.WriteString(w, string(val))
 		return err
+	case string:
+		return writeJSONString(w, val)
+	case []byte:
+		return writeJSONString(w, base64.StdEncoding.EncodeToString(val))
 	case []interface{}:
-		if _, err := w.Write([]byte("[")); err != nil {
+		if _, err := io.WriteString(w, "["); err != nil {
 			return err
 		}
 		for i, item := range val {
 			if i > 0 {
-				if _, err := w.Write([]byte(",")); err != nil {
+				if _, err := io.WriteString(w, ","); err != nil {
 					return err
 				}
 			}
@@ -280,34 +881,61 @@ func writeJSON(w io.Writer, v interface{}) error {
 				return err
 			}
 		}
-		_, err := w.Write([]byte("]"))
+		_, err := io.WriteString(w, "]")
 		return err
 	case map[string]interface{}:
-		if _, err := w.Write([]byte("{")); err != nil {
+		if _, err := io.WriteString(w, "{"); err != nil {
 			return err
 		}
-		first := true
-		for k, item := range val {
-			if !first {
-				if _, err := w.Write([]byte(",")); err != nil {
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for i, k := range keys {
+			if i > 0 {
+				if _, err := io.WriteString(w, ","); err != nil {
 					return err
 				}
 			}
-			first = false
-			if _, err := fmt.Fprintf(w, "%q:", k); err != nil {
+			if err := writeJSONString(w, k); err != nil {
 				return err
 			}
-			if err := writeJSON(w, item); err != nil {
+			if _, err := io.WriteString(w, ":"); err != nil {
 				return err
 			}
+			if err := writeJSON(w, val[k]); err != nil {
+				return err
+			}
+		}
+		_, err := io.WriteString(w, "}")
+		return err
+	case json.Marshaler:
+		data, err := val.MarshalJSON()
+		if err != nil {
+			return err
 		}
-		_, err := w.Write([]byte("}"))
+		if !json.Valid(data) {
+			return fmt.Errorf("%T.MarshalJSON returned invalid JSON", v)
+		}
+		_, err = w.Write(data)
 		return err
 	default:
 		return fmt.Errorf("unsupported type: %T", v)
 	}
 }
 
+// writeJSONString writes s as a JSON string. Unlike strconv.Quote, it uses
+// JSON escapes (\u0007 rather than \a) and replaces invalid UTF-8.
+func writeJSONString(w io.Writer, s string) error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
 // ============================================================================
 // XML Marshaler
 // ============================================================================
@@ -346,13 +974,38 @@ func (x *XMLMarshaler) Marshal(v interface{}) ([]byte, error) {
 }
 
 // Unmarshal parses XML bytes into a proto message.
+// Fields that cannot be converted are reported together as a *ValidationError.
 func (x *XMLMarshaler) Unmarshal(data []byte, v interface{}) error {
-	return xml.Unmarshal(data, v)
+	if err := xml.Unmarshal(data, v); err != nil {
+		values, perr := xmlFieldValues(data)
+		if perr != nil {
+			return err
+		}
+		return validationError(v, values, err)
+	}
+	return nil
 }
 
-// NewDecoder returns an XML decoder for streaming.
+// NewDecoder returns a decoder reading a single XML document from r.
 func (x *XMLMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
-	return xml.NewDecoder(r)
+	return &xmlDecoder{r: r, marshaler: x}
+}
+
+// xmlDecoder implements runtime.Decoder for XML.
+type xmlDecoder struct {
+	r         io.Reader
+	marshaler *XMLMarshaler
+}
+
+func (d *xmlDecoder) Decode(v interface{}) error {
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 {
+		return io.EOF
+	}
+	return reportValidationError(d.r, d.marshaler.Unmarshal(data, v))
 }
 
 // NewEncoder returns an XML encoder for streaming.
@@ -372,7 +1025,7 @@ func (x *XMLMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
 // It works with google.api.HttpBody proto messages for raw byte handling,
 // or any proto message that has a bytes field.
 //
-// For messages with a 'data' bytes field, it reads/writes the raw bytes directly.
+// For messages with a 'data' bytes field (see DataField), it reads/writes the raw bytes directly.
 // For other messages, it falls back to proto binary encoding.
 //
 // Example proto definition for binary endpoints:
@@ -385,6 +1038,10 @@ func (x *XMLMarshaler) NewEncoder(w io.Writer) runtime.Encoder {
 type BinaryMarshaler struct {
 	// FallbackMarshaler is used for non-binary responses (default: JSONPb)
 	FallbackMarshaler runtime.Marshaler
+
+	// DataField is the proto field name of the bytes field holding the raw
+	// content (default: "data")
+	DataField string
 }
 
 // ContentType returns the MIME type for binary data.
@@ -394,12 +1051,11 @@ func (b *BinaryMarshaler) ContentType(_ interface{}) string {
 
 // Marshal serializes a message to binary.
 func (b *BinaryMarshaler) Marshal(v interface{}) ([]byte, error) {
-	// Check if it's a proto message with Data field
+	// Check if it's a proto message with a data field
 	if msg, ok := v.(proto.Message); ok {
-		// Try to get bytes from a 'data' field via reflection
-		rv := reflect.ValueOf(msg).Elem()
-		if dataField := rv.FieldByName("Data"); dataField.IsValid() && dataField.Kind() == reflect.Slice && dataField.Type().Elem().Kind() == reflect.Uint8 {
-			return dataField.Bytes(), nil
+		m := msg.ProtoReflect()
+		if fd := bytesField(m.Descriptor(), b.dataField()); fd != nil {
+			return m.Get(fd).Bytes(), nil
 		}
 		// Fall back to proto binary encoding
 		return proto.Marshal(msg)
@@ -417,10 +1073,9 @@ func (b *BinaryMarshaler) Marshal(v interface{}) ([]byte, error) {
 func (b *BinaryMarshaler) Unmarshal(data []byte, v interface{}) error {
 	// Check if it's a proto message
 	if msg, ok := v.(proto.Message); ok {
-		// Try to set bytes to a 'data' field via reflection
-		rv := reflect.ValueOf(msg).Elem()
-		if dataField := rv.FieldByName("Data"); dataField.IsValid() && dataField.CanSet() && dataField.Kind() == reflect.Slice && dataField.Type().Elem().Kind() == reflect.Uint8 {
-			dataField.SetBytes(data)
+		m := msg.ProtoReflect()
+		if fd := bytesField(m.Descriptor(), b.dataField()); fd != nil {
+			m.Set(fd, protoreflect.ValueOfBytes(data))
 			return nil
 		}
 		// Fall back to proto binary decoding
@@ -430,6 +1085,14 @@ func (b *BinaryMarshaler) Unmarshal(data []byte, v interface{}) error {
 	return errors.New("binary marshaler: unsupported type")
 }
 
+// dataField returns the configured data field name.
+func (b *BinaryMarshaler) dataField() string {
+	if b.DataField == "" {
+		return "data"
+	}
+	return b.DataField
+}
+
 // NewDecoder returns a decoder for binary data.
 func (b *BinaryMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
 	return &binaryDecoder{r: r, marshaler: b}
@@ -459,6 +1122,11 @@ type binaryEncoder struct {
 }
 
 func (e *binaryEncoder) Encode(v interface{}) error {
+	// Stream readers directly instead of buffering them in memory
+	if r, ok := v.(io.Reader); ok {
+		_, err := io.Copy(e.w, r)
+		return err
+	}
 	data, err := e.marshaler.Marshal(v)
 	if err != nil {
 		return err
@@ -491,6 +1159,9 @@ type MultipartMarshaler struct {
 	// MaxMemory limits memory usage for parsing (default: 32MB)
 	MaxMemory int64
 
+	// Coercion types the values the target doesn't (default: CoerceInfer).
+	Coercion FormCoercion
+
 	// Fallback for non-multipart responses
 	runtime.JSONPb
 }
@@ -511,12 +1182,13 @@ func (m *MultipartMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
 	if maxMem == 0 {
 		maxMem = 32 << 20 // 32MB default
 	}
-	return &multipartDecoder{r: r, maxMemory: maxMem}
+	return &multipartDecoder{r: r, maxMemory: maxMem, coercion: m.Coercion}
 }
 
 type multipartDecoder struct {
 	r         io.Reader
 	maxMemory int64
+	coercion  FormCoercion
 }
 
 func (d *multipartDecoder) Decode(v interface{}) error {
@@ -540,7 +1212,7 @@ func (d *multipartDecoder) Decode(v interface{}) error {
 	}
 	defer func() { _ = form.RemoveAll() }()
 
-	return populateFromMultipart(form, v)
+	return reportValidationError(d.r, populateFromMultipart(form, v, d.coercion))
 }
 
 // detectBoundary attempts to detect the multipart boundary from the data.
@@ -562,17 +1234,22 @@ func detectBoundary(data []byte) string {
 }
 
 // populateFromMultipart populates a proto message from multipart form data.
-func populateFromMultipart(form *multipart.Form, v interface{}) error {
+func populateFromMultipart(form *multipart.Form, v interface{}, coercion FormCoercion) error {
 	result := make(map[string]interface{})
+	md := messageDescriptor(v)
 
 	// Process regular fields
 	for key, vals := range form.Value {
+		var fd protoreflect.FieldDescriptor
+		if md != nil {
+			fd = findField(md, key)
+		}
 		if len(vals) == 1 {
-			result[key] = inferType(vals[0])
+			result[key] = coerceFormValue(fd, vals[0], coercion)
 		} else if len(vals) > 1 {
 			arr := make([]interface{}, len(vals))
 			for i, v := range vals {
-				arr[i] = inferType(v)
+				arr[i] = coerceFormValue(fd, v, coercion)
 			}
 			result[key] = arr
 		}
@@ -608,7 +1285,7 @@ func populateFromMultipart(form *multipart.Form, v interface{}) error {
 	}
 
 	// Convert to JSON then unmarshal
-	jsonData, err := marshalJSONWithBytes(result)
+	jsonData, err := marshalJSON(result)
 	if err != nil {
 		return err
 	}
@@ -618,102 +1295,10 @@ func populateFromMultipart(form *multipart.Form, v interface{}) error {
 			DiscardUnknown: true,
 		},
 	}
-	return jsonMarshaler.Unmarshal(jsonData, v)
-}
-
-// marshalJSONWithBytes marshals a map to JSON, encoding []byte as base64.
-// Uses buffer pooling to reduce GC pressure.
-func marshalJSONWithBytes(v map[string]interface{}) ([]byte, error) {
-	// Convert []byte to base64 strings for JSON encoding
-	converted := make(map[string]interface{})
-	for k, val := range v {
-		if data, ok := val.([]byte); ok {
-			// Encode bytes as base64 for proto's bytes fields
-			converted[k] = data
-		} else {
-			converted[k] = val
-		}
-	}
-
-	// Use encoding/json for proper handling with pooled buffer
-	buf := getBuffer()
-	defer putBuffer(buf)
-
-	if err := writeJSONWithBytes(buf, converted); err != nil {
-		return nil, err
-	}
-
-	// Must copy since buffer will be reused
-	result := make([]byte, buf.Len())
-	copy(result, buf.Bytes())
-	return result, nil
-}
-
-func writeJSONWithBytes(w io.Writer, v interface{}) error {
-	switch val := v.(type) {
-	case nil:
-		_, err := w.Write([]byte("null"))
-		return err
-	case bool:
-		if val {
-			_, err := w.Write([]byte("true"))
-			return err
-		}
-		_, err := w.Write([]byte("false"))
-		return err
-	case int64:
-		_, err := fmt.Fprintf(w, "%d", val)
-		return err
-	case float64:
-		_, err := fmt.Fprintf(w, "%g", val)
-		return err
-	case string:
-		_, err := fmt.Fprintf(w, "%q", val)
-		return err
-	case []byte:
-		// Encode as base64 string for proto bytes fields
-		_, err := fmt.Fprintf(w, "%q", string(val))
-		return err
-	case []interface{}:
-		if _, err := w.Write([]byte("[")); err != nil {
-			return err
-		}
-		for i, item := range val {
-			if i > 0 {
-				if _, err := w.Write([]byte(",")); err != nil {
-					return err
-				}
-			}
-			if err := writeJSONWithBytes(w, item); err != nil {
-				return err
-			}
-		}
-		_, err := w.Write([]byte("]"))
-		return err
-	case map[string]interface{}:
-		if _, err := w.Write([]byte("{")); err != nil {
-			return err
-		}
-		first := true
-		for k, item := range val {
-			if !first {
-				if _, err := w.Write([]byte(",")); err != nil {
-					return err
-				}
-			}
-			first = false
-			if _, err := fmt.Fprintf(w, "%q:", k); err != nil {
-				return err
-			}
-			if err := writeJSONWithBytes(w, item); err != nil {
-				return err
-			}
-		}
-		_, err := w.Write([]byte("}"))
-		return err
-	default:
-		return fmt.Errorf("unsupported type: %T", v)
+	if err := jsonMarshaler.Unmarshal(jsonData, v); err != nil {
+		return validationError(v, formFieldValues(form.Value), err)
 	}
+	return nil
 }
 
 // ============================================================================
@@ -834,8 +1419,9 @@ func WithMultipartSupportWithMaxMemory(maxMemory int64) Option {
 // ============================================================================
 
 // TextMarshaler handles text/plain content.
-// It maps plain text to a string field in the proto message.
-// For responses, it extracts a string field (defaults to "text" or "message").
+// It maps plain text to a string field in the proto message, selected by
+// proto field name. For responses, it extracts a string field (defaults to
+// "text", then "message").
 type TextMarshaler struct {
 	// InputField is the proto field name to populate with text input (default: "text")
 	InputField string
@@ -852,21 +1438,9 @@ func (t *TextMarshaler) ContentType(_ interface{}) string {
 // Marshal extracts a string field from the message.
 func (t *TextMarshaler) Marshal(v interface{}) ([]byte, error) {
 	if msg, ok := v.(proto.Message); ok {
-		rv := reflect.ValueOf(msg).Elem()
-
-		// Try output field
-		outputField := t.OutputField
-		if outputField == "" {
-			outputField = "text"
-		}
-
-		if field := rv.FieldByName(titleCase(outputField)); field.IsValid() && field.Kind() == reflect.String {
-			return []byte(field.String()), nil
-		}
-
-		// Try "message" as fallback
-		if field := rv.FieldByName("Message"); field.IsValid() && field.Kind() == reflect.String {
-			return []byte(field.String()), nil
+		m := msg.ProtoReflect()
+		if fd := textField(m.Descriptor(), t.OutputField); fd != nil {
+			return []byte(m.Get(fd).String()), nil
 		}
 	}
 
@@ -881,21 +1455,9 @@ func (t *TextMarshaler) Marshal(v interface{}) ([]byte, error) {
 // Unmarshal sets the text content to a string field in the message.
 func (t *TextMarshaler) Unmarshal(data []byte, v interface{}) error {
 	if msg, ok := v.(proto.Message); ok {
-		rv := reflect.ValueOf(msg).Elem()
-
-		inputField := t.InputField
-		if inputField == "" {
-			inputField = "text"
-		}
-
-		if field := rv.FieldByName(titleCase(inputField)); field.IsValid() && field.CanSet() && field.Kind() == reflect.String {
-			field.SetString(string(data))
-			return nil
-		}
-
-		// Try "message" as fallback
-		if field := rv.FieldByName("Message"); field.IsValid() && field.CanSet() && field.Kind() == reflect.String {
-			field.SetString(string(data))
+		m := msg.ProtoReflect()
+		if fd := textField(m.Descriptor(), t.InputField); fd != nil {
+			m.Set(fd, protoreflect.ValueOfString(string(data)))
 			return nil
 		}
 	}
@@ -903,6 +1465,36 @@ func (t *TextMarshaler) Unmarshal(data []byte, v interface{}) error {
 	return errors.New("text marshaler: no string field found to set")
 }
 
+// textField returns the string field named name (default "text"), falling
+// back to a "message" field.
+func textField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	if name == "" {
+		name = "text"
+	}
+	if fd := scalarField(md, name, protoreflect.StringKind); fd != nil {
+		return fd
+	}
+	return scalarField(md, "message", protoreflect.StringKind)
+}
+
+// bytesField returns the bytes field named name, or nil.
+func bytesField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	return scalarField(md, name, protoreflect.BytesKind)
+}
+
+// scalarField returns the singular field of the given kind named name (proto
+// or JSON name), or nil.
+func scalarField(md protoreflect.MessageDescriptor, name string, kind protoreflect.Kind) protoreflect.FieldDescriptor {
+	fd := md.Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		fd = md.Fields().ByJSONName(name)
+	}
+	if fd == nil || fd.Kind() != kind || fd.Cardinality() == protoreflect.Repeated {
+		return nil
+	}
+	return fd
+}
+
 // NewDecoder returns a decoder for text data.
 func (t *TextMarshaler) NewDecoder(r io.Reader) runtime.Decoder {
 	return &textDecoder{r: r, marshaler: t}
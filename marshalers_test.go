@@ -2,8 +2,25 @@ package grpckit
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/httpbody"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestFormMarshaler_ContentType(t *testing.T) {
@@ -22,13 +39,19 @@ func TestInferType(t *testing.T) {
 	}{
 		{"true", true},
 		{"false", false},
-		{"123", int64(123)},
-		{"-456", int64(-456)},
-		{"3.14", float64(3.14)},
+		{"123", json.Number("123")},
+		{"-456", json.Number("-456")},
+		{"3.14", json.Number("3.14")},
 		{"hello", "hello"},
 		{"", ""},
-		{"0", int64(0)},
-		{"1", int64(1)},
+		{"0", json.Number("0")},
+		{"1", json.Number("1")},
+		{"12345678901234567890", json.Number("12345678901234567890")},
+		{"0.10000000000000000001", json.Number("0.10000000000000000001")},
+		{"007", "007"},
+		{"1e3", "1e3"},
+		{"+1", "+1"},
+		{"1.", "1."},
 	}
 
 	for _, tt := range tests {
@@ -80,7 +103,7 @@ func TestValuesToJSON(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result, err := valuesToJSON(tt.values)
+			result, err := valuesToJSON(tt.values, nil, CoerceInfer)
 			if err != nil {
 				t.Fatalf("valuesToJSON failed: %v", err)
 			}
@@ -146,6 +169,51 @@ func TestWriteJSON(t *testing.T) {
 	}
 }
 
+func TestWriteJSON_Deterministic(t *testing.T) {
+	input := map[string]any{
+		"zeta":  int64(1),
+		"alpha": map[string]any{"y": true, "b": nil, "m": []any{"x"}},
+		"mid":   "bell\a <tag> \xff",
+	}
+	expected := `{"alpha":{"b":null,"m":["x"],"y":true},"mid":"bell\u0007 \u003ctag\u003e ` + "\ufffd" + `","zeta":1}`
+
+	for i := 0; i < 20; i++ {
+		result, err := marshalJSON(input)
+		if err != nil {
+			t.Fatalf("marshalJSON failed: %v", err)
+		}
+		if string(result) != expected {
+			t.Fatalf("expected %s, got %s", expected, result)
+		}
+	}
+}
+
+func TestWriteJSON_NonFinite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, math.Inf(1)); err == nil {
+		t.Errorf("expected error for +Inf, got %s", buf.String())
+	}
+}
+
+func TestWriteJSON_NumbersAndBytes(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeJSON(&buf, map[string]interface{}{
+		"big":  json.Number("18446744073709551615"),
+		"file": []byte{0xff, 0x00, 'a'},
+	})
+	if err != nil {
+		t.Fatalf("writeJSON failed: %v", err)
+	}
+	if want := `{"big":18446744073709551615,"file":"/wBh"}`; buf.String() != want {
+		t.Errorf("expected %s, got %s", want, buf.String())
+	}
+
+	buf.Reset()
+	if err := writeJSON(&buf, json.Number("1,5")); err == nil {
+		t.Errorf("expected error for invalid number, got %s", buf.String())
+	}
+}
+
 func TestWriteJSON_UnsupportedType(t *testing.T) {
 	var buf bytes.Buffer
 
@@ -311,6 +379,30 @@ func TestDetectBoundary(t *testing.T) {
 	}
 }
 
+func TestBinaryMarshaler_ProtoDataField(t *testing.T) {
+	m := &BinaryMarshaler{}
+
+	body := &httpbody.HttpBody{}
+	if err := m.Unmarshal([]byte("raw"), body); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if string(body.GetData()) != "raw" {
+		t.Errorf("expected data field to be set, got %q", body.GetData())
+	}
+
+	result, err := m.Marshal(body)
+	if err != nil || string(result) != "raw" {
+		t.Errorf("expected raw data field, got %q (%v)", result, err)
+	}
+
+	// Custom field, selected by proto field name
+	m = &BinaryMarshaler{DataField: "value"}
+	value := &wrapperspb.BytesValue{}
+	if err := m.Unmarshal([]byte("custom"), value); err != nil || string(value.GetValue()) != "custom" {
+		t.Errorf("expected value field to be set, got %q (%v)", value.GetValue(), err)
+	}
+}
+
 func TestTextMarshaler_ContentType(t *testing.T) {
 	m := &TextMarshaler{}
 	ct := m.ContentType(nil)
@@ -333,6 +425,34 @@ func TestTextMarshaler_MarshalString(t *testing.T) {
 	}
 }
 
+func TestTextMarshaler_ProtoFields(t *testing.T) {
+	// Field names that don't title-case to the Go name are found by proto name
+	m := &TextMarshaler{InputField: "request_id", OutputField: "request_id"}
+
+	info := &errdetails.RequestInfo{}
+	if err := m.Unmarshal([]byte("req-1"), info); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if info.GetRequestId() != "req-1" {
+		t.Errorf("expected request_id to be set, got %q", info.GetRequestId())
+	}
+	result, err := m.Marshal(info)
+	if err != nil || string(result) != "req-1" {
+		t.Errorf("expected request_id output, got %q (%v)", result, err)
+	}
+
+	// Falls back to a "message" field
+	msg := &errdetails.LocalizedMessage{}
+	if err := (&TextMarshaler{}).Unmarshal([]byte("hello"), msg); err != nil || msg.GetMessage() != "hello" {
+		t.Errorf("expected message fallback, got %q (%v)", msg.GetMessage(), err)
+	}
+
+	// Non-string fields are not used
+	if _, err := (&TextMarshaler{OutputField: "number"}).Marshal(&descriptorpb.FieldDescriptorProto{}); err == nil {
+		t.Error("expected error for non-string field")
+	}
+}
+
 func TestTextMarshaler_UnsupportedType(t *testing.T) {
 	m := &TextMarshaler{}
 
@@ -486,3 +606,417 @@ func TestBuildMarshalerOptions_NoOptions(t *testing.T) {
 		t.Errorf("expected 0 options for empty config, got %d", len(opts))
 	}
 }
+
+func TestBuildMarshalerOptions_StrictFields(t *testing.T) {
+	cfg := newServerConfig()
+	cfg.jsonOptions = &JSONOptions{DiscardUnknown: true, StrictFields: true}
+	form := &FormMarshaler{}
+	cfg.marshalers["application/x-www-form-urlencoded"] = form
+
+	mux := runtime.NewServeMux(buildMarshalerOptions(cfg)...)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Content-Type", "application/json")
+	in, _ := runtime.MarshalerForRequest(mux, req)
+	if _, ok := in.(*StrictJSONMarshaler); !ok {
+		t.Errorf("expected StrictJSONMarshaler for JSON, got %T", in)
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	in, _ = runtime.MarshalerForRequest(mux, req)
+	if fm, ok := in.(*FormMarshaler); !ok || !fm.StrictFields {
+		t.Errorf("expected strict FormMarshaler, got %#v", in)
+	}
+	if form.StrictFields {
+		t.Error("expected registered FormMarshaler not to be modified")
+	}
+}
+
+func TestStrictJSONMarshaler(t *testing.T) {
+	m := &StrictJSONMarshaler{JSONPb: &runtime.JSONPb{}}
+
+	msg := &descriptorpb.FieldDescriptorProto{}
+	if err := m.Unmarshal([]byte(`{"name":"id","jsonName":"id"}`), msg); err != nil {
+		t.Fatalf("unexpected error for known fields: %v", err)
+	}
+
+	err := m.Unmarshal([]byte(`{"name":"id","nmae":"x","options":{"packed":true,"pakced":true}}`), msg)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if len(verr.Fields) != 2 || verr.Fields[0].Field != "nmae" || verr.Fields[1].Field != "options.pakced" {
+		t.Errorf("expected every unknown key to be listed, got %+v", verr.Fields)
+	}
+
+	// Errors unrelated to unknown fields are returned unchanged
+	if err := m.Unmarshal([]byte(`{"number":"abc"}`), msg); errors.As(err, &verr) {
+		t.Errorf("expected plain decode error, got %v", err)
+	}
+}
+
+func TestStrictJSONMarshaler_Decoder(t *testing.T) {
+	m := &StrictJSONMarshaler{JSONPb: &runtime.JSONPb{}}
+
+	dec := m.NewDecoder(strings.NewReader(`{"name":"id"}`))
+	msg := &descriptorpb.FieldDescriptorProto{}
+	if err := dec.Decode(msg); err != nil || msg.GetName() != "id" {
+		t.Fatalf("unexpected decode result: %v %v", msg, err)
+	}
+	if err := dec.Decode(msg); err != io.EOF {
+		t.Errorf("expected io.EOF at end of input, got %v", err)
+	}
+}
+
+func TestFormMarshaler_StrictFields(t *testing.T) {
+	m := &FormMarshaler{StrictFields: true}
+	msg := &descriptorpb.FieldDescriptorProto{}
+
+	err := m.Unmarshal([]byte("name=id&nmae=x&options.pakced=true&options.packed=true"), msg)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if len(verr.Fields) != 2 || verr.Fields[0].Field != "nmae" || verr.Fields[1].Field != "options.pakced" {
+		t.Errorf("expected every unknown key to be listed, got %+v", verr.Fields)
+	}
+
+	// Without StrictFields unknown keys are ignored
+	if err := (&FormMarshaler{}).Unmarshal([]byte("name=id&nmae=x"), msg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestFormMarshaler_FieldTypes(t *testing.T) {
+	m := &FormMarshaler{}
+
+	// String fields keep their values exactly, even when they look like numbers
+	field := &descriptorpb.FieldDescriptorProto{}
+	if err := m.Unmarshal([]byte("name=123&default_value=0.10000000000000000001&number=7&label=LABEL_REPEATED&proto3_optional=1"), field); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if field.GetName() != "123" || field.GetDefaultValue() != "0.10000000000000000001" {
+		t.Errorf("expected exact strings, got %q and %q", field.GetName(), field.GetDefaultValue())
+	}
+	if field.GetNumber() != 7 || field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED || !field.GetProto3Optional() {
+		t.Errorf("unexpected typed fields: %v", field)
+	}
+
+	// Enums are accepted by number too
+	if err := m.Unmarshal([]byte("label=3"), field); err != nil || field.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		t.Errorf("expected enum by number, got %v: %v", field.GetLabel(), err)
+	}
+
+	// 64-bit integers beyond float64 precision are not rounded
+	opt := &descriptorpb.UninterpretedOption{}
+	if err := m.Unmarshal([]byte("positive_int_value=18446744073709551615&negative_int_value=-9007199254740993&double_value=0.1"), opt); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if opt.GetPositiveIntValue() != math.MaxUint64 || opt.GetNegativeIntValue() != -9007199254740993 || opt.GetDoubleValue() != 0.1 {
+		t.Errorf("unexpected numbers: %v", opt)
+	}
+
+	// Invalid values for typed fields are still rejected
+	if err := m.Unmarshal([]byte("number=seven"), field); err == nil {
+		t.Error("expected error for a non-numeric int32 field")
+	}
+}
+
+func TestFormMarshaler_Coercion(t *testing.T) {
+	values := map[string][]string{"count": {"42"}, "active": {"true"}}
+
+	data, err := valuesToJSON(values, nil, CoerceInfer)
+	if err != nil {
+		t.Fatalf("valuesToJSON failed: %v", err)
+	}
+	if want := `{"active":true,"count":42}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	data, err = valuesToJSON(values, nil, CoerceStrings)
+	if err != nil {
+		t.Fatalf("valuesToJSON failed: %v", err)
+	}
+	if want := `{"active":"true","count":"42"}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
+
+func TestInt64JSONMarshaler(t *testing.T) {
+	m := &jsonFormatMarshaler{Marshaler: &runtime.JSONPb{}, int64AsNumber: true}
+
+	msg := &descriptorpb.FieldOptions{
+		Packed: proto.Bool(true),
+		UninterpretedOption: []*descriptorpb.UninterpretedOption{
+			{PositiveIntValue: proto.Uint64(42), NegativeIntValue: proto.Int64(-7), IdentifierValue: proto.String("12")},
+		},
+	}
+	data, err := m.Marshal(msg)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"packed":true,"uninterpretedOption":[{"identifierValue":"12","positiveIntValue":42,"negativeIntValue":-7}]}`
+	if string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	// Numbers are accepted back on input
+	got := &descriptorpb.FieldOptions{}
+	if err := m.Unmarshal(data, got); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !proto.Equal(got, msg) {
+		t.Errorf("round trip mismatch: %v", got)
+	}
+
+	data, _ = m.Marshal(wrapperspb.Int64(5))
+	if string(data) != "5" {
+		t.Errorf("expected Int64Value as number, got %s", data)
+	}
+}
+
+func TestInt64JSONMarshaler_Overflow(t *testing.T) {
+	msg := &descriptorpb.UninterpretedOption{PositiveIntValue: proto.Uint64(1 << 60)}
+
+	tests := []struct {
+		overflow Int64Overflow
+		want     string
+	}{
+		{Int64OverflowString, `{"positiveIntValue":"1152921504606846976"}`},
+		{Int64OverflowNumber, `{"positiveIntValue":1152921504606846976}`},
+	}
+	for _, tt := range tests {
+		m := &jsonFormatMarshaler{Marshaler: &runtime.JSONPb{}, int64AsNumber: true, overflow: tt.overflow}
+		data, err := m.Marshal(msg)
+		if err != nil {
+			t.Fatalf("Marshal failed: %v", err)
+		}
+		if string(data) != tt.want {
+			t.Errorf("overflow %d: expected %s, got %s", tt.overflow, tt.want, data)
+		}
+	}
+
+	m := &jsonFormatMarshaler{Marshaler: &runtime.JSONPb{}, int64AsNumber: true, overflow: Int64OverflowError}
+	if _, err := m.Marshal(msg); err == nil || !strings.Contains(err.Error(), "positiveIntValue") {
+		t.Errorf("expected overflow error naming the field, got %v", err)
+	}
+}
+
+func TestInt64JSONMarshaler_Indent(t *testing.T) {
+	m := &jsonFormatMarshaler{
+		Marshaler:     &runtime.JSONPb{MarshalOptions: protojson.MarshalOptions{Indent: "  "}},
+		indent:        "  ",
+		int64AsNumber: true,
+	}
+
+	var buf bytes.Buffer
+	if err := m.NewEncoder(&buf).Encode(&descriptorpb.UninterpretedOption{NegativeIntValue: proto.Int64(-1)}); err != nil {
+		t.Fatalf("Encode failed: %v", err)
+	}
+	if want := "{\n  \"negativeIntValue\": -1\n}\n"; buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestBuildMarshalerOptions_Int64AsNumber(t *testing.T) {
+	cfg := newServerConfig()
+	cfg.jsonOptions = &JSONOptions{Int64AsNumber: true, StrictFields: true}
+
+	mux := runtime.NewServeMux(buildMarshalerOptions(cfg)...)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	_, out := runtime.MarshalerForRequest(mux, req)
+
+	m, ok := out.(*jsonFormatMarshaler)
+	if !ok {
+		t.Fatalf("expected jsonFormatMarshaler, got %T", out)
+	}
+	if _, ok := m.Marshaler.(*StrictJSONMarshaler); !ok {
+		t.Errorf("expected strict decoding to be kept, got %T", m.Marshaler)
+	}
+}
+
+func TestNewJSONMarshaler_WellKnownFormats(t *testing.T) {
+	m := newJSONMarshaler(&JSONOptions{
+		TimestampFormat: TimestampEpochMillis,
+		DurationFormat:  DurationMillis,
+		UseEnumNumbers:  true,
+	})
+
+	ts := timestamppb.New(time.Date(2024, 5, 1, 12, 0, 0, 500e6, time.UTC))
+	data, err := m.Marshal(ts)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	if want := "1714564800500"; string(data) != want {
+		t.Errorf("expected timestamp %s, got %s", want, data)
+	}
+
+	data, _ = m.Marshal(&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)})
+	if want := `{"retryDelay":1500}`; string(data) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+
+	data, _ = m.Marshal(&descriptorpb.FieldDescriptorProto{Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()})
+	if want := `{"label":3}`; string(bytes.ReplaceAll(data, []byte(" "), nil)) != want {
+		t.Errorf("expected %s, got %s", want, data)
+	}
+}
+
+func TestNewJSONMarshaler_Defaults(t *testing.T) {
+	// Without format options protojson output is used as is
+	if _, ok := newJSONMarshaler(&JSONOptions{}).(*runtime.JSONPb); !ok {
+		t.Error("expected plain JSONPb without format options")
+	}
+}
+
+func TestWithJSONOptionsFor(t *testing.T) {
+	cfg := newServerConfig()
+	WithJSONOptionsFor(JSONOptions{UseEnumNumbers: true}, "/api/v1/legacy/**")(cfg)
+
+	mux := runtime.NewServeMux(buildMarshalerOptions(cfg)...)
+	var got runtime.Marshaler
+	handler := jsonMarshalerMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, got = runtime.MarshalerForRequest(mux, r)
+	}))
+
+	tests := []struct {
+		path     string
+		accept   string
+		override bool
+	}{
+		{"/api/v1/legacy/items", "", true},
+		{"/api/v1/legacy/items", "application/json, text/plain", true},
+		{"/api/v1/legacy/items", "application/xml", false},
+		{"/api/v1/items", "", false},
+	}
+	for _, tt := range tests {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		pb, ok := got.(*runtime.JSONPb)
+		isOverride := ok && pb.UseEnumNumbers
+		if isOverride != tt.override {
+			t.Errorf("%s (Accept %q): expected override=%v, got %T", tt.path, tt.accept, tt.override, got)
+		}
+	}
+}
+
+func TestJSONMarshalerMiddleware_Pretty(t *testing.T) {
+	cfg := newServerConfig()
+	WithPrettyJSON()(cfg)
+	WithJSONOptionsFor(JSONOptions{UseEnumNumbers: true}, "/api/v1/legacy/**")(cfg)
+
+	mux := runtime.NewServeMux(buildMarshalerOptions(cfg)...)
+	var got runtime.Marshaler
+	var query string
+	handler := jsonMarshalerMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, got = runtime.MarshalerForRequest(mux, r)
+		query = r.URL.RawQuery
+	}))
+
+	tests := []struct {
+		target       string
+		accept       string
+		pretty       bool
+		enumNumbers  bool
+		wantRawQuery string
+	}{
+		{"/api/v1/items?pretty=true&page=2", "", true, false, "page=2"},
+		{"/api/v1/items?pretty", "", true, false, ""},
+		{"/api/v1/items", prettyJSONMIME, true, false, ""},
+		{"/api/v1/items?pretty=false", "", false, false, ""},
+		{"/api/v1/legacy/items?pretty=1", "", true, true, ""},
+		{"/api/v1/legacy/items", "", false, true, ""},
+		{"/api/v1/items?pretty=true&page=2", prettyJSONMIME, true, false, "page=2"},
+		{"/api/v1/items?pretty=true", "application/xml", false, false, ""},
+	}
+	for _, tt := range tests {
+		got = nil
+		req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		data, err := got.Marshal(&descriptorpb.FieldDescriptorProto{Label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum()})
+		if err != nil {
+			t.Fatalf("%s: Marshal failed: %v", tt.target, err)
+		}
+		if pretty := bytes.Contains(data, []byte("\n")); pretty != tt.pretty {
+			t.Errorf("%s: expected pretty=%v, got %s", tt.target, tt.pretty, data)
+		}
+		compact := bytes.Join(bytes.Fields(data), nil)
+		if enumNumbers := bytes.Contains(compact, []byte(`"label":3`)); enumNumbers != tt.enumNumbers {
+			t.Errorf("%s: expected enum numbers=%v, got %s", tt.target, tt.enumNumbers, data)
+		}
+		if query != tt.wantRawQuery {
+			t.Errorf("%s: expected query %q, got %q", tt.target, tt.wantRawQuery, query)
+		}
+	}
+}
+
+func TestJSONMarshalerMiddleware_PrettyDisabled(t *testing.T) {
+	cfg := newServerConfig()
+	handler := jsonMarshalerMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawQuery != "pretty=true" {
+			t.Errorf("expected query to be left alone, got %q", r.URL.RawQuery)
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/items?pretty=true", nil))
+}
+
+func TestVaryAcceptMiddleware(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"json only", nil, ""},
+		{"json options", []Option{WithJSONOptions(JSONOptions{UseProtoNames: true})}, ""},
+		{"xml", []Option{WithXMLSupport()}, "Accept"},
+		{"html tables", []Option{WithHTMLTableSupport()}, "Accept"},
+		{"pretty json", []Option{WithPrettyJSON()}, "Accept"},
+	}
+	for _, tt := range tests {
+		cfg := newServerConfig()
+		for _, opt := range tt.opts {
+			opt(cfg)
+		}
+		rec := httptest.NewRecorder()
+		varyAcceptMiddleware(cfg, next).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		if got := rec.Header().Get("Vary"); got != tt.want {
+			t.Errorf("%s: expected Vary %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAddVary(t *testing.T) {
+	tests := []struct {
+		existing []string
+		want     []string
+	}{
+		{nil, []string{"Accept"}},
+		{[]string{"Origin"}, []string{"Origin", "Accept"}},
+		{[]string{"Origin, accept"}, []string{"Origin, accept"}},
+		{[]string{"*"}, []string{"*"}},
+	}
+	for _, tt := range tests {
+		h := http.Header{}
+		if tt.existing != nil {
+			h["Vary"] = tt.existing
+		}
+		addVary(h, varyAccept)
+		if got := h["Vary"]; strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%v: expected %v, got %v", tt.existing, tt.want, got)
+		}
+	}
+	if len(varyAccept) != 1 || varyAccept[0] != "Accept" {
+		t.Errorf("shared header value modified: %v", varyAccept)
+	}
+}
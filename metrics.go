@@ -1,24 +1,53 @@
 package grpckit
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/netip"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
 )
 
 // Metrics holds all Prometheus metrics for the server.
 type Metrics struct {
-	requestsTotal    *prometheus.CounterVec
-	requestDuration  *prometheus.HistogramVec
-	requestsInFlight prometheus.Gauge
+	requestsTotal       *prometheus.CounterVec
+	requestDuration     *prometheus.HistogramVec
+	requestsInFlight    prometheus.Gauge
+	requestsShed        *prometheus.CounterVec
+	grpcRequestSize     *prometheus.HistogramVec
+	grpcResponseSize    *prometheus.HistogramVec
+	slowRequests        *prometheus.CounterVec
+	deprecatedRequests  *prometheus.CounterVec
+	connectionsRejected *prometheus.CounterVec
+	authFailures        *prometheus.CounterVec
+	slowClients         *prometheus.CounterVec
+	grpcRequestsTotal   *prometheus.CounterVec
+	grpcRequestDuration *prometheus.HistogramVec
+	requestCost         *prometheus.HistogramVec
+	tenantRequests      *prometheus.CounterVec
+	tenantQuota         *prometheus.CounterVec
+
+	// Route templates labeling paths (WithMetricsRoutes)
+	routes *metricsRouter
+
+	// Busiest tenants labeled by name (WithTenantMetrics)
+	tenants *tenantLabeler
 }
 
-// newMetrics creates and registers Prometheus metrics.
-func newMetrics(namespace string) *Metrics {
+// newMetrics creates Prometheus metrics and registers them on reg.
+func newMetrics(namespace string, reg prometheus.Registerer) *Metrics {
 	if namespace == "" {
 		namespace = "grpckit"
 	}
@@ -30,7 +59,7 @@ func newMetrics(namespace string) *Metrics {
 				Name:      "http_requests_total",
 				Help:      "Total number of HTTP requests",
 			},
-			[]string{"method", "path", "status"},
+			[]string{"method", "path", "status", "status_class"},
 		),
 		requestDuration: prometheus.NewHistogramVec(
 			prometheus.HistogramOpts{
@@ -48,24 +77,269 @@ func newMetrics(namespace string) *Metrics {
 				Help:      "Number of HTTP requests currently being processed",
 			},
 		),
+		requestsShed: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_shed_total",
+				Help:      "Total number of HTTP requests rejected due to overload",
+			},
+			[]string{"priority"},
+		),
+		grpcRequestSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "grpc_request_size_bytes",
+				Help:      "Size of received gRPC messages in bytes",
+				Buckets:   messageSizeBuckets,
+			},
+			[]string{"method"},
+		),
+		grpcResponseSize: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "grpc_response_size_bytes",
+				Help:      "Size of sent gRPC messages in bytes",
+				Buckets:   messageSizeBuckets,
+			},
+			[]string{"method"},
+		),
+		slowRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "slow_requests_total",
+				Help:      "Total number of requests exceeding the slow request threshold",
+			},
+			[]string{"protocol", "operation"},
+		),
+		deprecatedRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "deprecated_requests_total",
+				Help:      "Total number of requests to deprecated endpoints",
+			},
+			[]string{"pattern"},
+		),
+		connectionsRejected: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "connections_rejected_total",
+				Help:      "Total number of connections closed because the connection limit was reached",
+			},
+			[]string{"listener"},
+		),
+		authFailures: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "auth_failures_total",
+				Help:      "Total number of requests rejected by authentication",
+			},
+			[]string{"protocol", "code"},
+		),
+		slowClients: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_slow_clients_total",
+				Help:      "Total number of streaming HTTP clients disconnected for not reading responses",
+			},
+			[]string{"operation"},
+		),
+		grpcRequestsTotal: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "grpc_requests_total",
+				Help:      "Total number of gRPC calls",
+			},
+			[]string{"method", "code", "status_class"},
+		),
+		grpcRequestDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "grpc_request_duration_seconds",
+				Help:      "gRPC call duration in seconds",
+				Buckets:   prometheus.DefBuckets,
+			},
+			[]string{"method"},
+		),
+		requestCost: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "request_cost",
+				Help:      "Cost of requests reported by handlers, by kind",
+				Buckets:   prometheus.ExponentialBuckets(1, 10, 10),
+			},
+			[]string{"protocol", "operation", "kind"},
+		),
+		tenantRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "tenant_requests_total",
+				Help:      "Total number of requests by tenant",
+			},
+			[]string{"tenant", "protocol", "status_class"},
+		),
+		tenantQuota: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "tenant_quota_requests_total",
+				Help:      "Total number of calls counted against quotas by tenant",
+			},
+			[]string{"tenant", "result"},
+		),
 	}
 
 	// Register metrics
-	prometheus.MustRegister(m.requestsTotal)
-	prometheus.MustRegister(m.requestDuration)
-	prometheus.MustRegister(m.requestsInFlight)
+	reg.MustRegister(m.requestsTotal)
+	reg.MustRegister(m.requestDuration)
+	reg.MustRegister(m.requestsInFlight)
+	reg.MustRegister(m.requestsShed)
+	reg.MustRegister(m.grpcRequestSize)
+	reg.MustRegister(m.grpcResponseSize)
+	reg.MustRegister(m.slowRequests)
+	reg.MustRegister(m.deprecatedRequests)
+	reg.MustRegister(m.connectionsRejected)
+	reg.MustRegister(m.authFailures)
+	reg.MustRegister(m.slowClients)
+	reg.MustRegister(m.grpcRequestsTotal)
+	reg.MustRegister(m.grpcRequestDuration)
+	reg.MustRegister(m.requestCost)
+	reg.MustRegister(m.tenantRequests)
+	reg.MustRegister(m.tenantQuota)
 
 	return m
 }
 
-// metricsHandler returns the Prometheus metrics endpoint handler.
-func metricsHandler() http.Handler {
-	return promhttp.Handler()
+// registerRuntimeCollectors registers the Go runtime and process collectors.
+// The default Prometheus registry already includes them, so duplicate
+// registrations are ignored; custom registries get them added.
+func registerRuntimeCollectors(reg prometheus.Registerer, logger *slog.Logger) {
+	runtimeCollectors := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	}
+	for _, c := range runtimeCollectors {
+		if err := reg.Register(c); err != nil {
+			var are prometheus.AlreadyRegisteredError
+			if !errors.As(err, &are) {
+				logger.Warn("failed to register runtime metrics collector", "component", "metrics", "error", err)
+			}
+		}
+	}
+}
+
+// metricsHandler returns the Prometheus metrics endpoint handler. Like
+// promhttp.Handler, it gzips responses for scrapers accepting it, and it
+// also serves the OpenMetrics format to scrapers asking for it.
+func metricsHandler(reg prometheus.Registerer, gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.InstrumentMetricHandler(reg,
+		promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+}
+
+// registerMetricsEndpoint registers the metrics endpoint of cfg on the mux,
+// restricted by WithMetricsAccess if set.
+func registerMetricsEndpoint(mux *http.ServeMux, cfg *serverConfig) {
+	var handler http.Handler = metricsHandler(cfg.metricsRegisterer, cfg.metricsGatherer)
+	if cfg.metricsAccess != nil {
+		handler = metricsAccessMiddleware(cfg.metricsAccess, handler)
+	}
+	mux.Handle(cfg.metricsPath, handler)
 }
 
-// registerMetricsEndpoint registers the /metrics endpoint on the mux.
-func registerMetricsEndpoint(mux *http.ServeMux) {
-	mux.Handle("/metrics", metricsHandler())
+// MetricsOption restricts access to the metrics endpoint.
+type MetricsOption func(*metricsAccess)
+
+// metricsAccess holds the metrics endpoint restrictions.
+type metricsAccess struct {
+	basicAuth BasicAuthFunc
+	cidrs     []string
+	networks  []netip.Prefix
+}
+
+// MetricsBasicAuth requires scrapers to send HTTP Basic credentials accepted
+// by validate, e.g. BasicAuthUsers. It is independent of WithAuth and
+// WithBasicAuth; if those protect the metrics path too, make it public with
+// WithPublicBuiltinEndpoints.
+//
+// Example:
+//
+//	grpckit.WithMetrics(grpckit.MetricsBasicAuth(grpckit.BasicAuthUsers(map[string]string{
+//	    "prometheus": os.Getenv("SCRAPE_PASSWORD"),
+//	})))
+func MetricsBasicAuth(validate BasicAuthFunc) MetricsOption {
+	return func(a *metricsAccess) {
+		a.basicAuth = validate
+	}
+}
+
+// MetricsAllowedNetworks only serves the metrics endpoint to clients in the
+// given networks, as CIDRs ("10.0.0.0/8") or single addresses. The peer
+// address of the connection is checked, not X-Forwarded-For, so behind a
+// proxy the proxy's addresses must be allowed.
+//
+// Example:
+//
+//	grpckit.WithMetrics(grpckit.MetricsAllowedNetworks("10.0.0.0/8", "127.0.0.1"))
+func MetricsAllowedNetworks(cidrs ...string) MetricsOption {
+	return func(a *metricsAccess) {
+		a.cidrs = append(a.cidrs, cidrs...)
+	}
+}
+
+// parseNetworks parses CIDRs and single addresses into prefixes.
+func parseNetworks(cidrs []string) ([]netip.Prefix, error) {
+	networks := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		if prefix, err := netip.ParsePrefix(cidr); err == nil {
+			networks = append(networks, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q", cidr)
+		}
+		networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return networks, nil
+}
+
+// allows reports whether a client at remoteAddr may scrape the metrics.
+func (a *metricsAccess) allows(remoteAddr string) bool {
+	if len(a.networks) == 0 {
+		return true
+	}
+	addr, err := netip.ParseAddr(hostOnly(remoteAddr))
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, network := range a.networks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// metricsAccessMiddleware enforces the metrics endpoint restrictions.
+func metricsAccessMiddleware(a *metricsAccess, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.allows(r.RemoteAddr) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if a.basicAuth != nil {
+			username, password, ok := r.BasicAuth()
+			if ok {
+				_, err := a.basicAuth(r.Context(), username, password)
+				ok = err == nil
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="metrics"`)
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // metricsMiddleware wraps an HTTP handler to collect metrics.
@@ -76,33 +350,127 @@ func metricsMiddleware(m *Metrics, next http.Handler) http.Handler {
 
 		start := time.Now()
 
-		// Wrap response writer to capture status code
-		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		// Wrap response writer to capture status code. Wrappers are pooled,
+		// as a ResponseWriter may not be used after ServeHTTP returns.
+		wrapped := responseWriterPool.Get().(*responseWriter)
+		wrapped.ResponseWriter, wrapped.statusCode = w, http.StatusOK
 
 		next.ServeHTTP(wrapped, r)
 
 		duration := time.Since(start).Seconds()
 		statusStr := http.StatusText(wrapped.statusCode)
+		class := statusClass(wrapped.statusCode)
+		wrapped.ResponseWriter = nil
+		responseWriterPool.Put(wrapped)
 
 		// Normalize path to prevent cardinality explosion from dynamic IDs
-		normalizedPath := normalizePath(r.URL.Path)
+		normalizedPath := m.pathLabel(r.URL.Path)
+		method := m.methodLabel(r.Method)
 
-		m.requestsTotal.WithLabelValues(r.Method, normalizedPath, statusStr).Inc()
-		m.requestDuration.WithLabelValues(r.Method, normalizedPath).Observe(duration)
+		m.requestsTotal.WithLabelValues(method, normalizedPath, statusStr, class).Inc()
+		m.requestDuration.WithLabelValues(method, normalizedPath).Observe(duration)
 	})
 }
 
+// statusClasses are the status_class label values, indexed by status / 100.
+var statusClasses = [...]string{"", "1xx", "2xx", "3xx", "4xx", "5xx"}
+
+// statusClass returns the class of an HTTP status code ("2xx", "4xx", ...),
+// so SLOs can select errors without listing codes.
+func statusClass(code int) string {
+	if i := code / 100; i >= 1 && i < len(statusClasses) {
+		return statusClasses[i]
+	}
+	return otherRoute
+}
+
+// grpcMetricsUnaryInterceptor counts unary calls by code and status class
+// and observes their duration. The class is the one of the HTTP status the
+// gateway maps the code to, so HTTP and gRPC SLOs agree on what an error is.
+func grpcMetricsUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		s.metrics.observeGRPC(info.FullMethod, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// grpcMetricsStreamInterceptor is the stream counterpart of
+// grpcMetricsUnaryInterceptor, observing streams when they end.
+func grpcMetricsStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		s.metrics.observeGRPC(info.FullMethod, err, time.Since(start))
+		return err
+	}
+}
+
+// observeGRPC records a finished call.
+func (m *Metrics) observeGRPC(method string, err error, duration time.Duration) {
+	code := status.Code(err)
+	m.grpcRequestsTotal.WithLabelValues(method, code.String(), statusClass(runtime.HTTPStatusFromCode(code))).Inc()
+	m.grpcRequestDuration.WithLabelValues(method).Observe(duration.Seconds())
+}
+
 // responseWriter wraps http.ResponseWriter to capture the status code.
 type responseWriter struct {
 	http.ResponseWriter
 	statusCode int
 }
 
+// responseWriterPool holds responseWriters for reuse on the hot path.
+var responseWriterPool = sync.Pool{
+	New: func() interface{} {
+		return new(responseWriter)
+	},
+}
+
 func (w *responseWriter) WriteHeader(code int) {
 	w.statusCode = code
 	w.ResponseWriter.WriteHeader(code)
 }
 
+// FlushError flushes the response, so streaming responses (server-streaming
+// REST calls, Server-Sent Events) work through the middlewares recording the
+// status, and reports the errors of the flush.
+func (w *responseWriter) FlushError() error {
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *responseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// set write deadlines or hijack WebSocket upgrades.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// NormalizePath replaces the segments of path that look like IDs (numbers,
+// UUIDs, hex or random-looking tokens) with ":id", as in the path label of
+// the HTTP metrics. Use it to name requests in tracing and logging
+// integrations without one name per resource.
+//
+// Example:
+//
+//	grpckit.NormalizePath("/api/v1/items/42") // "/api/v1/items/:id"
+func NormalizePath(path string) string {
+	return normalizePath(path)
+}
+
 // normalizePath normalizes URL paths for metrics labels to prevent cardinality explosion.
 // It replaces dynamic path segments (IDs, UUIDs) with placeholders.
 // Examples:
@@ -114,13 +482,32 @@ func normalizePath(path string) string {
 		return path
 	}
 
-	parts := strings.Split(path, "/")
-	for i, part := range parts {
-		if part != "" && isLikelyID(part) {
-			parts[i] = ":id"
+	// Scan the segments first, so paths without IDs are returned as is
+	// without allocating
+	var b strings.Builder
+	last := 0
+	for start := 0; start < len(path); {
+		end := strings.IndexByte(path[start:], '/')
+		if end < 0 {
+			end = len(path)
+		} else {
+			end += start
+		}
+		if end > start && isLikelyID(path[start:end]) {
+			if b.Cap() == 0 {
+				b.Grow(len(path))
+			}
+			b.WriteString(path[last:start])
+			b.WriteString(":id")
+			last = end
 		}
+		start = end + 1
+	}
+	if last == 0 {
+		return path
 	}
-	return strings.Join(parts, "/")
+	b.WriteString(path[last:])
+	return b.String()
 }
 
 // isLikelyID checks if a path segment looks like a dynamic ID.
@@ -130,9 +517,12 @@ func isLikelyID(s string) bool {
 		return false
 	}
 
-	// Numeric IDs (any length)
-	if _, err := strconv.ParseInt(s, 10, 64); err == nil {
-		return true
+	// Numeric IDs (any length); only parse numeric-looking segments, as
+	// ParseInt allocates its error
+	if isNumeric(s) {
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return true
+		}
 	}
 
 	// UUID-like (36 chars with 4 dashes: xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx)
@@ -148,6 +538,22 @@ func isLikelyID(s string) bool {
 	return false
 }
 
+// isNumeric reports whether s is an optionally signed decimal number.
+func isNumeric(s string) bool {
+	if s[0] == '+' || s[0] == '-' {
+		s = s[1:]
+	}
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
 // isAlphanumericWithIDChars checks if string contains only alphanumeric chars plus - and _.
 func isAlphanumericWithIDChars(s string) bool {
 	for _, r := range s {
@@ -1,19 +1,29 @@
 package grpckit
 
 import (
+	"bufio"
+	"context"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/gyozatech/grpckit/quota"
 	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestNewMetrics(t *testing.T) {
 	// Unregister any existing metrics from previous tests
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("test_namespace")
+	m := newMetrics("test_namespace", prometheus.DefaultRegisterer)
 
 	if m == nil {
 		t.Fatal("expected non-nil metrics")
@@ -35,7 +45,7 @@ func TestNewMetrics(t *testing.T) {
 func TestNewMetrics_DefaultNamespace(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("")
+	m := newMetrics("", prometheus.DefaultRegisterer)
 
 	if m == nil {
 		t.Fatal("expected non-nil metrics")
@@ -48,7 +58,7 @@ func TestNewMetrics_DefaultNamespace(t *testing.T) {
 }
 
 func TestMetricsHandler(t *testing.T) {
-	handler := metricsHandler()
+	handler := metricsHandler(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
 
 	if handler == nil {
 		t.Fatal("expected non-nil handler")
@@ -73,7 +83,7 @@ func TestMetricsHandler(t *testing.T) {
 
 func TestRegisterMetricsEndpoint(t *testing.T) {
 	mux := http.NewServeMux()
-	registerMetricsEndpoint(mux)
+	registerMetricsEndpoint(mux, newServerConfig())
 
 	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
 	rec := httptest.NewRecorder()
@@ -85,10 +95,35 @@ func TestRegisterMetricsEndpoint(t *testing.T) {
 	}
 }
 
+func TestWithMetricsPath(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetricsPath("/internal/metrics"))
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	for path, want := range map[string]int{"/internal/metrics": http.StatusOK, "/metrics": http.StatusNotFound} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+	}
+	if !isMaintenanceExempt(s.cfg, "/internal/metrics") || isMaintenanceExempt(s.cfg, "/metrics") {
+		t.Error("expected the relocated path to be exempt from maintenance")
+	}
+
+	for _, path := range []string{"metrics", "/"} {
+		cfg := newServerConfig()
+		WithMetricsPath(path)(cfg)
+		if len(cfg.errs) != 1 {
+			t.Errorf("expected %q to be rejected, got %v", path, cfg.errs)
+		}
+	}
+}
+
 func TestMetricsMiddleware(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("mw_test")
+	m := newMetrics("mw_test", prometheus.DefaultRegisterer)
 
 	nextCalled := false
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -116,7 +151,7 @@ func TestMetricsMiddleware(t *testing.T) {
 func TestMetricsMiddleware_CapturesStatusCode(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("status_test")
+	m := newMetrics("status_test", prometheus.DefaultRegisterer)
 
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -137,7 +172,7 @@ func TestMetricsMiddleware_CapturesStatusCode(t *testing.T) {
 func TestMetricsMiddleware_InFlightGauge(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("flight_test")
+	m := newMetrics("flight_test", prometheus.DefaultRegisterer)
 
 	inFlightDuringRequest := false
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -201,7 +236,7 @@ func TestResponseWriter_Write(t *testing.T) {
 func TestMetricsMiddleware_MultipleRequests(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("multi_test")
+	m := newMetrics("multi_test", prometheus.DefaultRegisterer)
 
 	requestCount := 0
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -226,7 +261,7 @@ func TestMetricsMiddleware_MultipleRequests(t *testing.T) {
 func TestMetricsMiddleware_DifferentMethods(t *testing.T) {
 	prometheus.DefaultRegisterer = prometheus.NewRegistry()
 
-	m := newMetrics("methods_test")
+	m := newMetrics("methods_test", prometheus.DefaultRegisterer)
 
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
@@ -246,3 +281,285 @@ func TestMetricsMiddleware_DifferentMethods(t *testing.T) {
 		}
 	}
 }
+
+func TestRegisterRuntimeCollectors(t *testing.T) {
+	reg := prometheus.NewRegistry()
+
+	registerRuntimeCollectors(reg, defaultLogger)
+	// Registering twice must not fail (the default registry already has them)
+	registerRuntimeCollectors(reg, defaultLogger)
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+
+	found := map[string]bool{}
+	for _, f := range families {
+		found[f.GetName()] = true
+	}
+	if !found["go_goroutines"] {
+		t.Error("expected go_goroutines metric")
+	}
+	if !found["process_start_time_seconds"] && runtime.GOOS == "linux" {
+		t.Error("expected process_start_time_seconds metric")
+	}
+}
+
+func TestNew_RuntimeMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	prometheus.DefaultRegisterer = reg
+
+	_, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithRuntimeMetrics(),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	for _, f := range families {
+		if f.GetName() == "go_goroutines" {
+			return
+		}
+	}
+	t.Error("expected runtime metrics on the configured registry")
+}
+
+func TestWithMetricsRegistry(t *testing.T) {
+	def := prometheus.NewRegistry()
+	prometheus.DefaultRegisterer = def
+
+	// Two servers in one process, each with its own registry
+	regs := []*prometheus.Registry{prometheus.NewRegistry(), prometheus.NewRegistry()}
+	for _, reg := range regs {
+		s, err := New(
+			WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+			WithMetricsRegistry(reg),
+			WithRuntimeMetrics(),
+		)
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		handler, err := s.Handler()
+		if err != nil {
+			t.Fatalf("Handler failed: %v", err)
+		}
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		for _, name := range []string{"grpckit_http_requests_in_flight", "go_goroutines"} {
+			if !strings.Contains(rec.Body.String(), name) {
+				t.Errorf("expected %s on the metrics endpoint", name)
+			}
+		}
+	}
+
+	families, err := def.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+	if len(families) != 0 {
+		t.Errorf("expected nothing on the default registry, got %d families", len(families))
+	}
+}
+
+func TestNormalizePath_Segments(t *testing.T) {
+	tests := map[string]string{
+		"/api/v1/items":               "/api/v1/items",
+		"/api/v1/items/42/":           "/api/v1/items/:id/",
+		"/api//items/42":              "/api//items/:id",
+		"42/reviews/7":                ":id/reviews/:id",
+		"/items/-5":                   "/items/:id",
+		"/items/+":                    "/items/+",
+		"/items/9999999999999999999":  "/items/9999999999999999999",
+		"/items/99999999999999999999": "/items/:id",
+	}
+	for in, want := range tests {
+		if got := normalizePath(in); got != want {
+			t.Errorf("normalizePath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMetricsHandler_Negotiation(t *testing.T) {
+	handler := metricsHandler(prometheus.DefaultRegisterer, prometheus.DefaultGatherer)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0,text/plain;version=0.0.4;q=0.5")
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Errorf("expected OpenMetrics content type, got %q", ct)
+	}
+	if enc := rec.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Errorf("expected gzip encoding, got %q", enc)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("expected Prometheus text format by default, got %q", ct)
+	}
+}
+
+func TestWithMetrics_Access(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics(
+		MetricsAllowedNetworks("10.0.0.0/8", "::1"),
+		MetricsBasicAuth(BasicAuthUsers(map[string]string{"prometheus": "secret"})),
+	))
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	tests := []struct {
+		remoteAddr, username, password string
+		want                           int
+	}{
+		{"10.1.2.3:4000", "prometheus", "secret", http.StatusOK},
+		{"[::1]:4000", "prometheus", "secret", http.StatusOK},
+		{"[::ffff:10.1.2.3]:4000", "prometheus", "secret", http.StatusOK},
+		{"192.0.2.1:4000", "prometheus", "secret", http.StatusForbidden},
+		{"10.1.2.3:4000", "prometheus", "wrong", http.StatusUnauthorized},
+		{"10.1.2.3:4000", "", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.username != "" {
+			req.SetBasicAuth(tt.username, tt.password)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s: expected %d, got %d", tt.remoteAddr, tt.username, tt.want, rec.Code)
+		}
+		if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%s: expected WWW-Authenticate challenge", tt.remoteAddr)
+		}
+	}
+
+	cfg := newServerConfig()
+	WithMetrics(MetricsAllowedNetworks("10.0.0.0/33"))(cfg)
+	if len(cfg.errs) != 1 {
+		t.Errorf("expected invalid network to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestStatusClass(t *testing.T) {
+	tests := map[int]string{
+		http.StatusOK:                 "2xx",
+		http.StatusNoContent:          "2xx",
+		http.StatusMovedPermanently:   "3xx",
+		http.StatusNotFound:           "4xx",
+		http.StatusServiceUnavailable: "5xx",
+		http.StatusSwitchingProtocols: "1xx",
+		0:                             "other",
+		999:                           "other",
+	}
+	for code, want := range tests {
+		if got := statusClass(code); got != want {
+			t.Errorf("statusClass(%d) = %q, want %q", code, got, want)
+		}
+	}
+}
+
+func TestGRPCMetricsInterceptor(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics())
+	interceptor := grpcMetricsUnaryInterceptor(s)
+	info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/GetItem"}
+
+	for _, err := range []error{nil, status.Error(codes.NotFound, "no such item"), status.Error(codes.Unavailable, "db down")} {
+		_, _ = interceptor(context.Background(), nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+	}
+
+	for code, class := range map[string]string{"OK": "2xx", "NotFound": "4xx", "Unavailable": "5xx"} {
+		if v := testutil.ToFloat64(s.metrics.grpcRequestsTotal.WithLabelValues(info.FullMethod, code, class)); v != 1 {
+			t.Errorf("expected 1 call with code %s in class %s, got %v", code, class, v)
+		}
+	}
+	if n := testutil.CollectAndCount(s.metrics.grpcRequestDuration); n != 1 {
+		t.Errorf("expected 1 duration series, got %d", n)
+	}
+}
+
+// streamThroughHandler serves a response flushed in two parts through the
+// full HTTP middleware chain, checking the first part reaches the client
+// before the second is written.
+func streamThroughHandler(t *testing.T, s *Server) {
+	t.Helper()
+	release := make(chan struct{})
+	gateway := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "first\n")
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+			return
+		}
+		<-release
+		io.WriteString(w, "second\n")
+	})
+	srv := httptest.NewServer(s.buildHTTPHandler(gateway))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/items:stream")
+	if err != nil {
+		close(release)
+		t.Fatalf("GET: %v", err)
+	}
+	defer resp.Body.Close()
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	close(release)
+	if err != nil || line != "first\n" {
+		t.Fatalf("expected first part before the handler returned, got %q, %v (status %d)", line, err, resp.StatusCode)
+	}
+	if rest, _ := io.ReadAll(reader); string(rest) != "second\n" {
+		t.Errorf("expected second part, got %q", rest)
+	}
+}
+
+func TestResponseWriter_Streaming(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	captureLog(t)
+	s := newSlowTestServer(t,
+		WithMetrics(),
+		WithAccessLog(),
+		WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+			return ContextWithPrincipal(ctx, "acme/alice"), nil
+		}),
+		WithPublicEndpoints("/v1/**"),
+		WithAuthFailureTracking(0, time.Minute),
+		WithLockout(quota.NewMemoryStore(), LockoutPaths("/v1/**")),
+		WithTenantResolver(func(ctx context.Context) string { return "acme" }),
+		WithTenantMetrics(TenantMetricsConfig{}),
+	)
+	streamThroughHandler(t, s)
+}
+
+func TestResponseWriter_Unwrap(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &responseWriter{ResponseWriter: rec, statusCode: http.StatusOK}
+	if w.Unwrap() != rec {
+		t.Error("expected Unwrap to return the wrapped writer")
+	}
+	if err := w.FlushError(); err != nil || !rec.Flushed {
+		t.Errorf("expected flush to reach the recorder, got %v", err)
+	}
+}
+
+func TestNormalizePath_Exported(t *testing.T) {
+	if got := NormalizePath("/api/v1/items/42"); got != "/api/v1/items/:id" {
+		t.Errorf("expected /api/v1/items/:id, got %q", got)
+	}
+}
@@ -0,0 +1,229 @@
+package grpckit
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"gopkg.in/yaml.v3"
+)
+
+// otherRoute is the metrics path label of requests matching no known route.
+const otherRoute = "other"
+
+// WithMetricsRoutes labels HTTP metrics with declared route templates
+// instead of normalized paths: a request to /api/v1/items/42 is counted as
+// "/api/v1/items/{id}", and requests matching no route (scanners probing
+// random URLs) as "other", which bounds the label cardinality. Methods
+// other than the standard HTTP methods are counted as "other" too.
+//
+// Templates use the google.api.http syntax: "{id}" matches one segment and
+// "{path=**}" or "**" the rest of the path. The routes of google.api.http
+// annotations, the built-in endpoints and the patterns of WithHTTPHandler
+// are known without being listed; handlers added at runtime are not.
+// Templates that overlap match the most specific first.
+//
+// Example:
+//
+//	grpckit.WithMetrics(),
+//	grpckit.WithMetricsRoutes("/api/v1/legacy/{id}", "/static/**")
+func WithMetricsRoutes(templates ...string) Option {
+	return func(c *serverConfig) {
+		for _, template := range templates {
+			if !strings.HasPrefix(template, "/") {
+				c.invalid("WithMetricsRoutes: route %q is not an absolute path", template)
+				return
+			}
+		}
+		c.metricsRoutes = append(c.metricsRoutes, templates...)
+		c.metricsRoutesOnly = true
+	}
+}
+
+// WithMetricsRoutesFromSwagger is WithMetricsRoutes with the paths of an
+// OpenAPI (Swagger) spec in JSON or YAML, e.g. the one generated by
+// protoc-gen-openapiv2 for the gateway.
+//
+// Example:
+//
+//	//go:embed api/service.swagger.json
+//	var spec []byte
+//
+//	grpckit.WithMetricsRoutesFromSwagger(spec)
+func WithMetricsRoutesFromSwagger(spec []byte) Option {
+	return func(c *serverConfig) {
+		var doc struct {
+			Paths map[string]any `yaml:"paths"`
+		}
+		if err := yaml.Unmarshal(spec, &doc); err != nil {
+			c.invalid("WithMetricsRoutesFromSwagger: invalid spec: %v", err)
+			return
+		}
+		if len(doc.Paths) == 0 {
+			c.invalid("WithMetricsRoutesFromSwagger: spec has no paths")
+			return
+		}
+		templates := make([]string, 0, len(doc.Paths))
+		for path := range doc.Paths {
+			templates = append(templates, path)
+		}
+		sort.Strings(templates)
+		WithMetricsRoutes(templates...)(c)
+	}
+}
+
+// metricsRoute is a route template labeling HTTP metrics.
+type metricsRoute struct {
+	label    string
+	segments []string
+	suffix   string // ":verb", if any
+}
+
+// metricsRouter maps request paths to the label of the first matching route.
+type metricsRouter struct {
+	routes []metricsRoute
+}
+
+// newMetricsRouter returns the router of WithMetricsRoutes, with the routes
+// declared by the services registered on grpcServer, the built-in endpoints
+// and the HTTP handlers.
+func newMetricsRouter(cfg *serverConfig, grpcServer *grpc.Server) *metricsRouter {
+	templates := append([]string(nil), cfg.metricsRoutes...)
+	for _, r := range collectHTTPRoutes(grpcServer) {
+		templates = append(templates, r.template)
+	}
+	for _, b := range builtinRoutes(cfg) {
+		if b.pattern != "/" {
+			templates = append(templates, muxPatternTemplate(b.pattern))
+		}
+	}
+	for _, h := range cfg.httpHandlers {
+		templates = append(templates, muxPatternTemplate(h.pattern))
+	}
+
+	router := &metricsRouter{}
+	seen := make(map[string]bool)
+	for _, template := range templates {
+		if seen[template] || !strings.HasPrefix(template, "/") {
+			continue
+		}
+		seen[template] = true
+		segments, verb := parsePathTemplate(template)
+		route := metricsRoute{label: template, segments: segments}
+		if verb != "" {
+			route.suffix = ":" + verb
+		}
+		router.routes = append(router.routes, route)
+	}
+	sort.SliceStable(router.routes, func(i, j int) bool {
+		return moreSpecific(router.routes[i].segments, router.routes[j].segments)
+	})
+	return router
+}
+
+// muxPatternTemplate converts an http.ServeMux pattern to a route template:
+// "/files/" and "/files/{path...}" match the subtree, "/files/{$}" only
+// itself, and a method or host is dropped.
+func muxPatternTemplate(pattern string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimLeft(path, " ")
+	}
+	if i := strings.IndexByte(pattern, '/'); i > 0 {
+		pattern = pattern[i:]
+	}
+	if exact, ok := strings.CutSuffix(pattern, "{$}"); ok {
+		return exact
+	}
+	if i := strings.Index(pattern, "...}"); i >= 0 {
+		return pattern[:strings.LastIndexByte(pattern[:i], '{')] + "**"
+	}
+	if strings.HasSuffix(pattern, "/") && pattern != "/" {
+		return pattern + "**"
+	}
+	return pattern
+}
+
+// moreSpecific orders route segments: literals before "*" before "**".
+func moreSpecific(a, b []string) bool {
+	rank := func(seg string) int {
+		switch seg {
+		case "*":
+			return 1
+		case "**":
+			return 2
+		}
+		return 0
+	}
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if ra, rb := rank(a[i]), rank(b[i]); ra != rb {
+			return ra < rb
+		}
+	}
+	return len(a) > len(b)
+}
+
+// label returns the template of the first route matching urlPath, or
+// "other".
+func (m *metricsRouter) label(urlPath string) string {
+	for i := range m.routes {
+		if m.routes[i].matches(urlPath) {
+			return m.routes[i].label
+		}
+	}
+	return otherRoute
+}
+
+// matches reports whether urlPath matches the route. Unlike
+// httpRoute.matches it doesn't allocate, as it runs for every request.
+func (r *metricsRoute) matches(urlPath string) bool {
+	rest := strings.TrimPrefix(urlPath, "/")
+	if r.suffix != "" {
+		if !strings.HasSuffix(rest, r.suffix) {
+			return false
+		}
+		rest = rest[:len(rest)-len(r.suffix)]
+	}
+	done := false
+	for _, seg := range r.segments {
+		if seg == "**" {
+			return true
+		}
+		if done {
+			return false
+		}
+		component := rest
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			component, rest = rest[:i], rest[i+1:]
+		} else {
+			done = true
+		}
+		if seg != "*" && seg != component {
+			return false
+		}
+	}
+	return done
+}
+
+// pathLabel returns the metrics label of a request path: its route with
+// WithMetricsRoutes, or the normalized path.
+func (m *Metrics) pathLabel(urlPath string) string {
+	if m.routes != nil {
+		return m.routes.label(urlPath)
+	}
+	return normalizePath(urlPath)
+}
+
+// methodLabel returns the metrics label of a request method. With
+// WithMetricsRoutes, non-standard methods are counted as "other".
+func (m *Metrics) methodLabel(method string) string {
+	if m.routes == nil {
+		return method
+	}
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherRoute
+}
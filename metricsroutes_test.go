@@ -0,0 +1,120 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+func TestMuxPatternTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/webhook":                "/webhook",
+		"/files/":                 "/files/**",
+		"GET /items/{id}":         "/items/{id}",
+		"POST  /upload/{path...}": "/upload/**",
+		"example.com/docs/":       "/docs/**",
+		"/exact/{$}":              "/exact/",
+		"/":                       "/",
+	}
+	for pattern, want := range tests {
+		if got := muxPatternTemplate(pattern); got != want {
+			t.Errorf("muxPatternTemplate(%q) = %q, want %q", pattern, got, want)
+		}
+	}
+}
+
+func TestMetricsRouter_Label(t *testing.T) {
+	cfg := newServerConfig()
+	WithHealthCheck()(cfg)
+	WithMetricsRoutes("/api/v1/items/{id}", "/api/v1/items/search", "/files/{path=**}", "/v1/items:stream")(cfg)
+	WithHTTPHandlerFunc("/webhook", func(w http.ResponseWriter, r *http.Request) {})(cfg)
+	router := newMetricsRouter(cfg, grpc.NewServer())
+
+	tests := map[string]string{
+		"/api/v1/items/42":     "/api/v1/items/{id}",
+		"/api/v1/items/search": "/api/v1/items/search",
+		"/api/v1/items/42/x":   otherRoute,
+		"/files/a/b/c.txt":     "/files/{path=**}",
+		"/v1/items:stream":     "/v1/items:stream",
+		"/v1/items":            otherRoute,
+		"/healthz":             "/healthz",
+		"/webhook":             "/webhook",
+		"/wp-login.php":        otherRoute,
+		"/":                    otherRoute,
+	}
+	for path, want := range tests {
+		if got := router.label(path); got != want {
+			t.Errorf("label(%q) = %q, want %q", path, got, want)
+		}
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		router.label("/api/v1/items/42")
+		router.label("/wp-login.php")
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestWithMetricsRoutes_Invalid(t *testing.T) {
+	cfg := newServerConfig()
+	WithMetricsRoutes("api/items")(cfg)
+	if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected relative route to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestWithMetricsRoutesFromSwagger(t *testing.T) {
+	for name, spec := range map[string]string{
+		"json": `{"swagger": "2.0", "paths": {"/v1/items/{id}": {"get": {}}, "/v1/items": {"post": {}}}}`,
+		"yaml": "openapi: 3.0.0\npaths:\n  /v1/items/{id}:\n    get: {}\n  /v1/items:\n    post: {}\n",
+	} {
+		cfg := newServerConfig()
+		WithMetricsRoutesFromSwagger([]byte(spec))(cfg)
+		if len(cfg.errs) != 0 || !cfg.metricsRoutesOnly || len(cfg.metricsRoutes) != 2 || cfg.metricsRoutes[1] != "/v1/items/{id}" {
+			t.Errorf("%s: unexpected routes %v (%v)", name, cfg.metricsRoutes, cfg.errs)
+		}
+	}
+
+	for _, spec := range []string{`{"paths": `, `{"swagger": "2.0"}`} {
+		cfg := newServerConfig()
+		WithMetricsRoutesFromSwagger([]byte(spec))(cfg)
+		if len(cfg.errs) != 1 {
+			t.Errorf("expected spec %q to be rejected, got %v", spec, cfg.errs)
+		}
+	}
+}
+
+func TestMetricsMiddleware_Routes(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics(), WithMetricsRoutes("/api/v1/items/{id}"))
+	handler := metricsMiddleware(s.metrics, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, "/api/v1/items/1", nil),
+		httptest.NewRequest(http.MethodGet, "/api/v1/items/2", nil),
+		httptest.NewRequest(http.MethodGet, "/.env", nil),
+		httptest.NewRequest("PROPFIND", "/.git/config", nil),
+	} {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if v := testutil.ToFloat64(s.metrics.requestsTotal.WithLabelValues("GET", "/api/v1/items/{id}", "OK", "2xx")); v != 2 {
+		t.Errorf("expected 2 requests for the route, got %v", v)
+	}
+	if v := testutil.ToFloat64(s.metrics.requestsTotal.WithLabelValues("GET", "other", "OK", "2xx")); v != 1 {
+		t.Errorf("expected 1 request for other, got %v", v)
+	}
+	if v := testutil.ToFloat64(s.metrics.requestsTotal.WithLabelValues("other", "other", "OK", "2xx")); v != 1 {
+		t.Errorf("expected 1 request with other method, got %v", v)
+	}
+	if n := testutil.CollectAndCount(s.metrics.requestsTotal); n != 3 {
+		t.Errorf("expected 3 series, got %d", n)
+	}
+}
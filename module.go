@@ -0,0 +1,75 @@
+package grpckit
+
+import "fmt"
+
+// Module is a reusable bundle of server configuration, such as a team's
+// standard stack of auth, tracing, audit and metrics conventions, packaged
+// as one importable unit.
+type Module interface {
+	// Name identifies the module in errors; it must be unique per server.
+	Name() string
+
+	// Options returns the options the module contributes. They are applied
+	// where WithModule appears, so later options can override them.
+	Options() []Option
+
+	// Init is called at the end of New, once the server is built, e.g. to
+	// register handlers with Server.RegisterHTTPHandler. An error makes New
+	// fail.
+	Init(s *Server) error
+}
+
+// WithModule applies a module's options and schedules its Init. Modules may
+// include other modules in their options.
+//
+// Example:
+//
+//	type platformModule struct{ auth grpckit.AuthFunc }
+//
+//	func (m platformModule) Name() string { return "platform" }
+//
+//	func (m platformModule) Options() []grpckit.Option {
+//	    return []grpckit.Option{
+//	        grpckit.WithAuth(m.auth),
+//	        grpckit.WithPublicEndpoints("/healthz", "/readyz", "/metrics"),
+//	        grpckit.WithMetrics(),
+//	        grpckit.WithAccessLog(),
+//	    }
+//	}
+//
+//	func (m platformModule) Init(s *grpckit.Server) error {
+//	    return s.RegisterHTTPHandler("/platform/info", infoHandler)
+//	}
+//
+//	grpckit.Run(
+//	    grpckit.WithModule(platformModule{auth: verifyJWT}),
+//	    grpckit.WithGRPCService(...),
+//	)
+func WithModule(m Module) Option {
+	return func(c *serverConfig) {
+		if m == nil {
+			c.invalid("WithModule: nil module")
+			return
+		}
+		for _, existing := range c.modules {
+			if existing.Name() == m.Name() {
+				c.invalid("module %q registered twice", m.Name())
+				return
+			}
+		}
+		c.modules = append(c.modules, m)
+		for _, opt := range m.Options() {
+			opt(c)
+		}
+	}
+}
+
+// initModules calls Init on the registered modules, in registration order.
+func (s *Server) initModules() error {
+	for _, m := range s.cfg.modules {
+		if err := m.Init(s); err != nil {
+			return fmt.Errorf("module %s: %w", m.Name(), err)
+		}
+	}
+	return nil
+}
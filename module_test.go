@@ -0,0 +1,108 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// testModule is a configurable Module for tests.
+type testModule struct {
+	name    string
+	options []Option
+	init    func(s *Server) error
+}
+
+func (m testModule) Name() string      { return m.name }
+func (m testModule) Options() []Option { return m.options }
+
+func (m testModule) Init(s *Server) error {
+	if m.init == nil {
+		return nil
+	}
+	return m.init(s)
+}
+
+func TestWithModule(t *testing.T) {
+	var initialized *Server
+	platform := testModule{
+		name: "platform",
+		options: []Option{
+			WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+			WithLogLevel("warn"),
+			WithHTTPTimeout(5 * time.Second),
+		},
+		init: func(s *Server) error {
+			initialized = s
+			return s.RegisterHTTPHandler("/platform/info", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte("platform"))
+			}))
+		},
+	}
+
+	// Options after the module override it
+	s, err := New(WithModule(platform), WithLogLevel("debug"))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if initialized != s {
+		t.Error("expected Init to be called with the server")
+	}
+	if s.LogLevel() != "debug" || s.cfg.httpTimeout != 5*time.Second {
+		t.Errorf("unexpected config: level %s, timeout %v", s.LogLevel(), s.cfg.httpTimeout)
+	}
+
+	rec := httptest.NewRecorder()
+	s.buildHTTPHandler(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/platform/info", nil))
+	if rec.Body.String() != "platform" {
+		t.Errorf("expected module handler, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestWithModule_Nested(t *testing.T) {
+	var order []string
+	inner := testModule{name: "inner", init: func(*Server) error { order = append(order, "inner"); return nil }}
+	outer := testModule{
+		name:    "outer",
+		options: []Option{WithModule(inner), WithGRPCService(func(s grpc.ServiceRegistrar) {})},
+		init:    func(*Server) error { order = append(order, "outer"); return nil },
+	}
+
+	if _, err := New(WithModule(outer)); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if strings.Join(order, ",") != "outer,inner" {
+		t.Errorf("unexpected init order %v", order)
+	}
+}
+
+func TestWithModule_Errors(t *testing.T) {
+	svc := WithGRPCService(func(s grpc.ServiceRegistrar) {})
+	errBoom := errors.New("boom")
+
+	_, err := New(svc, WithModule(testModule{name: "audit", init: func(*Server) error { return errBoom }}))
+	if !errors.Is(err, errBoom) || !strings.Contains(err.Error(), "module audit") {
+		t.Errorf("expected Init error naming the module, got %v", err)
+	}
+
+	_, err = New(svc, WithModule(testModule{name: "audit"}), WithModule(testModule{name: "audit"}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected duplicate module to be rejected, got %v", err)
+	}
+
+	_, err = New(svc, WithModule(nil))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected nil module to be rejected, got %v", err)
+	}
+
+	// Misconfigured module options are reported
+	_, err = New(svc, WithModule(testModule{name: "broken", options: []Option{WithHTTPMiddleware(nil)}}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected module option error, got %v", err)
+	}
+}
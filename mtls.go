@@ -0,0 +1,266 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// clientCertMetadataKey carries the client certificate of a REST request to
+// the gRPC server, from the gateway, after an HMAC of it (see
+// signClientCert).
+const clientCertMetadataKey = "grpckit-client-cert-bin"
+
+// WithMTLS verifies client certificates against the PEM-encoded CA
+// certificates in caFile, on both the gRPC and HTTP listeners. With
+// requireClientCert, connections without a valid certificate are rejected
+// during the TLS handshake; otherwise a certificate is optional, but
+// verified when presented. Requires WithTLS or WithTLSConfig for the server
+// certificate; the CA file is loaded by New, which fails if it cannot be read.
+//
+// The verified certificate is available to AuthFunc and handlers via
+// ClientCertificateFromContext, for gRPC calls and REST requests alike (the
+// gateway forwards it to the gRPC server). AuthFunc is called with empty
+// credentials when the request has no Authorization header.
+//
+// Note that with requireClientCert, health checks and metrics on the HTTP
+// port also need a client certificate.
+//
+// Example:
+//
+//	grpckit.Run(
+//	    grpckit.WithTLS("/etc/tls/tls.crt", "/etc/tls/tls.key"),
+//	    grpckit.WithMTLS("/etc/tls/clients-ca.crt", true),
+//	    grpckit.WithAuth(func(ctx context.Context, _ string) (context.Context, error) {
+//	        cert := grpckit.ClientCertificateFromContext(ctx)
+//	        if cert == nil {
+//	            return nil, grpckit.ErrUnauthorized
+//	        }
+//	        return grpckit.ContextWithPrincipal(ctx, cert.Subject.CommonName), nil
+//	    }),
+//	)
+func WithMTLS(caFile string, requireClientCert bool) Option {
+	return func(c *serverConfig) {
+		if caFile == "" {
+			c.invalid("WithMTLS: empty CA file")
+			return
+		}
+		c.mtlsCAFile = caFile
+		c.mtlsRequire = requireClientCert
+	}
+}
+
+// configureClientCAs sets client certificate verification on tlsCfg with
+// the CA certificates of WithMTLS.
+func configureClientCAs(tlsCfg *tls.Config, cfg *serverConfig) error {
+	pem, err := os.ReadFile(cfg.mtlsCAFile)
+	if err != nil {
+		return fmt.Errorf("%w: loading client CA file: %v", ErrInvalidConfig, err)
+	}
+	// Don't modify a pool passed with WithTLSConfig
+	pool := x509.NewCertPool()
+	if tlsCfg.ClientCAs != nil {
+		pool = tlsCfg.ClientCAs.Clone()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("%w: no certificates in client CA file %s", ErrInvalidConfig, cfg.mtlsCAFile)
+	}
+	tlsCfg.ClientCAs = pool
+	if cfg.mtlsRequire {
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	} else {
+		tlsCfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return nil
+}
+
+// setupGatewayCertificate creates the client certificate the gateway
+// presents to the gRPC server, and trusts it. The certificate only lives in
+// memory, so calls made with it are known to come from the gateway, which
+// forwards the certificate of the REST client.
+func (s *Server) setupGatewayCertificate() error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("generating gateway client key: %w", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("generating gateway client certificate: %w", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: "grpckit gateway"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().AddDate(10, 0, 0),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("generating gateway client certificate: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return fmt.Errorf("generating gateway client certificate: %w", err)
+	}
+	s.gatewayCert = &tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+	s.tlsConfig.ClientCAs.AddCert(leaf)
+	s.clientCertKey = make([]byte, 32)
+	if _, err := rand.Read(s.clientCertKey); err != nil {
+		return fmt.Errorf("generating client certificate key: %w", err)
+	}
+	return nil
+}
+
+// signClientCert returns the forwarded form of a client certificate: an
+// HMAC of it with a key only this process knows, then the certificate. REST
+// clients can send the metadata key as a Grpc-Metadata-* header, which the
+// gateway forwards too; only the value the gateway signed is trusted.
+func (s *Server) signClientCert(der []byte) string {
+	mac := hmac.New(sha256.New, s.clientCertKey)
+	mac.Write(der)
+	return string(mac.Sum(nil)) + string(der)
+}
+
+// verifyClientCert returns the certificate of a forwarded value signed by
+// signClientCert, or nil.
+func (s *Server) verifyClientCert(value string) []byte {
+	if len(value) <= sha256.Size {
+		return nil
+	}
+	sum, der := []byte(value[:sha256.Size]), []byte(value[sha256.Size:])
+	mac := hmac.New(sha256.New, s.clientCertKey)
+	mac.Write(der)
+	if !hmac.Equal(sum, mac.Sum(nil)) {
+		return nil
+	}
+	return der
+}
+
+// clientCertKey is the context key of the client certificate of a REST
+// request forwarded by the gateway.
+type clientCertKey struct{}
+
+// tlsConnKey is the context key of the TLS connection of an HTTP request.
+type tlsConnKey struct{}
+
+// ClientCertificateFromContext returns the verified client certificate of
+// the gRPC call or REST request, or nil if the client presented none. See
+// WithMTLS.
+//
+// Example:
+//
+//	if cert := grpckit.ClientCertificateFromContext(ctx); cert != nil {
+//	    log.Printf("client %s", cert.Subject.CommonName)
+//	}
+func ClientCertificateFromContext(ctx context.Context) *x509.Certificate {
+	if cert, ok := ctx.Value(clientCertKey{}).(*x509.Certificate); ok {
+		return cert
+	}
+	if conn, ok := ctx.Value(tlsConnKey{}).(*tls.Conn); ok {
+		return verifiedLeaf(conn.ConnectionState())
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			return verifiedLeaf(info.State)
+		}
+	}
+	return nil
+}
+
+// verifiedLeaf returns the verified client certificate of a connection.
+func verifiedLeaf(state tls.ConnectionState) *x509.Certificate {
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return state.VerifiedChains[0][0]
+}
+
+// connContext is the http.Server connection context making
+// ClientCertificateFromContext work in HTTP handlers. The connection state
+// is read when needed, as the handshake completes after this is called.
+func (s *Server) connContext(ctx context.Context, c net.Conn) context.Context {
+	if tc, ok := c.(*tls.Conn); ok && s.gatewayCert != nil {
+		return context.WithValue(ctx, tlsConnKey{}, tc)
+	}
+	return ctx
+}
+
+// clientCertMetadata forwards the client certificate of a REST request to
+// the gRPC server.
+func (s *Server) clientCertMetadata(ctx context.Context, _ *http.Request) metadata.MD {
+	cert := ClientCertificateFromContext(ctx)
+	if cert == nil {
+		return nil
+	}
+	return metadata.Pairs(clientCertMetadataKey, s.signClientCert(cert.Raw))
+}
+
+// clientCertContext replaces the gateway's certificate of calls it makes
+// with the certificate of the REST client it forwarded, if any. The
+// forwarded certificate is ignored on calls from other clients.
+func (s *Server) clientCertContext(ctx context.Context) context.Context {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ctx
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ctx
+	}
+	leaf := verifiedLeaf(info.State)
+	if leaf == nil || !bytes.Equal(leaf.Raw, s.gatewayCert.Leaf.Raw) {
+		return ctx
+	}
+	var cert *x509.Certificate
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(clientCertMetadataKey) {
+		// Verified by the HTTP server already; values the gateway didn't
+		// sign come from the REST client
+		if der := s.verifyClientCert(value); der != nil {
+			cert, _ = x509.ParseCertificate(der)
+			break
+		}
+	}
+	return context.WithValue(ctx, clientCertKey{}, cert)
+}
+
+// clientCertUnaryInterceptor makes ClientCertificateFromContext return the
+// REST client's certificate in unary calls from the gateway.
+func (s *Server) clientCertUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(s.clientCertContext(ctx), req)
+}
+
+// clientCertStreamInterceptor makes ClientCertificateFromContext return the
+// REST client's certificate in stream calls from the gateway.
+func (s *Server) clientCertStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: s.clientCertContext(ss.Context())})
+}
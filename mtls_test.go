@@ -0,0 +1,273 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+// testCA issues client certificates for mTLS tests.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "grpckit-test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	file := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key, file: file}
+}
+
+// issue returns a client certificate for name signed by the CA.
+func (ca *testCA) issue(t *testing.T, name string) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestWithMTLS_Config(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	ca := newTestCA(t)
+
+	for _, require := range []bool{true, false} {
+		c := newServerConfig()
+		WithTLS(certFile, keyFile)(c)
+		WithMTLS(ca.file, require)(c)
+		cfg, err := buildTLSConfig(c)
+		if err != nil {
+			t.Fatalf("buildTLSConfig failed: %v", err)
+		}
+		want := map[bool]tls.ClientAuthType{true: tls.RequireAndVerifyClientCert, false: tls.VerifyClientCertIfGiven}[require]
+		if cfg.ClientAuth != want || cfg.ClientCAs == nil {
+			t.Errorf("require=%v: expected client auth %v, got %v", require, want, cfg.ClientAuth)
+		}
+	}
+
+	c := newServerConfig()
+	WithMTLS("", true)(c)
+	if len(c.errs) != 1 || !errors.Is(c.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected empty CA file to be rejected, got %v", c.errs)
+	}
+
+	// Without a server certificate, or with an unusable CA file
+	c = newServerConfig()
+	WithMTLS(ca.file, true)(c)
+	if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig without certificate, got %v", err)
+	}
+	for _, file := range []string{filepath.Join(t.TempDir(), "missing.crt"), keyFile} {
+		c = newServerConfig()
+		WithTLS(certFile, keyFile)(c)
+		WithMTLS(file, true)(c)
+		if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("expected ErrInvalidConfig for CA file %s, got %v", file, err)
+		}
+	}
+}
+
+func TestClientCertificateFromContext_None(t *testing.T) {
+	if cert := ClientCertificateFromContext(context.Background()); cert != nil {
+		t.Errorf("expected no certificate, got %v", cert.Subject)
+	}
+}
+
+func TestMTLS_CombinedMode(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	ca := newTestCA(t)
+	bound := make(chan ListenerBound, 1)
+
+	// Record the clients seen by the auth function
+	var mu sync.Mutex
+	var seen []string
+	authFunc := func(ctx context.Context, _ string) (context.Context, error) {
+		cert := ClientCertificateFromContext(ctx)
+		if cert == nil {
+			return nil, ErrUnauthorized
+		}
+		mu.Lock()
+		seen = append(seen, cert.Subject.CommonName)
+		mu.Unlock()
+		return ctx, nil
+	}
+
+	// GET /v1/check calls the gRPC health service through the gateway
+	restService := func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+		conn, err := grpc.NewClient(endpoint, opts...)
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			conn.Close()
+		}()
+		client := healthpb.NewHealthClient(conn)
+		return mux.HandlePath(http.MethodGet, "/v1/check", func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+			ctx, err := runtime.AnnotateContext(r.Context(), mux, r, "/grpc.health.v1.Health/Check")
+			if err == nil {
+				_, err = client.Check(ctx, &healthpb.HealthCheckRequest{})
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadGateway)
+			}
+		})
+	}
+
+	// The gateway dials the configured port, so it can't be 0
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := lis.Addr().(*net.TCPAddr).Port
+	lis.Close()
+
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithRESTService(restService),
+		WithGRPCPort(port),
+		WithHTTPPort(port),
+		WithTLS(certFile, keyFile),
+		WithMTLS(ca.file, true),
+		WithAuth(authFunc),
+		WithGRPCHealthService(),
+		WithEventListener(func(e Event) {
+			if lb, ok := e.(ListenerBound); ok {
+				bound <- lb
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+	defer func() {
+		s.Shutdown()
+		if err := <-errCh; err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	}()
+
+	var addr string
+	select {
+	case lb := <-bound:
+		addr = lb.Addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ListenerBound")
+	}
+	alice := ca.issue(t, "alice")
+	clientTLS := &tls.Config{InsecureSkipVerify: true, Certificates: []tls.Certificate{alice}}
+
+	// REST: the gateway forwards the client certificate to the gRPC server
+	httpClient := &http.Client{Transport: &http.Transport{TLSClientConfig: clientTLS.Clone()}}
+	resp, err := httpClient.Get("https://" + addr + "/v1/check")
+	if err != nil {
+		t.Fatalf("GET /v1/check failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// REST: a certificate sent as a Grpc-Metadata-* header, which the
+	// gateway forwards as metadata too, is ignored
+	rogue := newTestCA(t).issue(t, "root-admin")
+	req, _ := http.NewRequest(http.MethodGet, "https://"+addr+"/v1/check", nil)
+	req.Header.Set("Grpc-Metadata-Grpckit-Client-Cert-Bin", base64.StdEncoding.EncodeToString(rogue.Certificate[0]))
+	resp, err = httpClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /v1/check failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	// gRPC: a forged forwarded certificate from a client other than the
+	// gateway is ignored
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS.Clone())))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer conn.Close()
+	mallory := ca.issue(t, "mallory")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, clientCertMetadataKey, string(mallory.Certificate[0]))
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("gRPC health check failed: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	// REST requests, their gRPC calls from the gateway, and the gRPC call
+	if len(seen) != 5 || slices.ContainsFunc(seen, func(name string) bool { return name != "alice" }) {
+		t.Errorf("expected alice on every call, got %v", seen)
+	}
+
+	// Clients without a certificate are rejected during the handshake
+	noCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}}
+	if resp, err := noCert.Get("https://" + addr + "/v1/check"); err == nil {
+		resp.Body.Close()
+		t.Errorf("expected request without client certificate to fail, got %d", resp.StatusCode)
+	}
+}
@@ -2,12 +2,18 @@ package grpckit
 
 import (
 	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"github.com/gyozatech/grpckit/secrets"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
 )
 
@@ -20,7 +26,9 @@ type ServiceRegistrar func(s grpc.ServiceRegistrar)
 type RESTRegistrar func(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error
 
 // AuthFunc is a function that validates authentication and returns an enriched context.
-// The token parameter contains the value from the Authorization header (without "Bearer " prefix).
+// The token parameter contains the value from the Authorization header, without the "Bearer "
+// prefix for bearer tokens; other schemes (e.g. "Basic ...") are passed as sent. Use
+// AuthSchemeFromContext to get the scheme.
 // Return an error to reject the request, or an enriched context to allow it.
 type AuthFunc func(ctx context.Context, token string) (context.Context, error)
 
@@ -40,12 +48,13 @@ type interceptorConfig struct {
 }
 
 // ExceptEndpoints excludes the specified gRPC methods from this interceptor.
-// Methods should be in the format "/package.Service/Method".
+// Methods should be in the format "/package.Service/Method" and support the
+// same globs as WithProtectedEndpoints, e.g. "/grpc.health.v1.Health/*".
 //
 // Example:
 //
 //	grpckit.WithUnaryInterceptor(timingInterceptor,
-//	    grpckit.ExceptEndpoints("/item.v1.ItemService/CreateItem"),
+//	    grpckit.ExceptEndpoints("/item.v1.ItemService/CreateItem", "/grpc.health.v1.Health/*"),
 //	)
 func ExceptEndpoints(endpoints ...string) InterceptorOption {
 	return func(c *interceptorConfig) {
@@ -57,6 +66,7 @@ func ExceptEndpoints(endpoints ...string) InterceptorOption {
 type httpHandlerRegistration struct {
 	pattern string
 	handler http.Handler
+	owner   string // option that registered it, if not WithHTTPHandler
 }
 
 // unaryInterceptorRegistration holds a unary interceptor with its config.
@@ -84,8 +94,67 @@ type JSONOptions struct {
 
 	// DiscardUnknown ignores unknown fields during unmarshaling
 	DiscardUnknown bool
+
+	// StrictFields rejects JSON and form requests containing fields that are
+	// not in the proto message with 400 Bad Request, listing every unknown
+	// key. Takes precedence over DiscardUnknown.
+	StrictFields bool
+
+	// Int64AsNumber emits int64/uint64 fields as JSON numbers instead of
+	// protojson's strings. Both forms are always accepted on input.
+	Int64AsNumber bool
+
+	// Int64Overflow controls how Int64AsNumber handles values beyond the
+	// range JavaScript numbers represent exactly (±2^53-1).
+	Int64Overflow Int64Overflow
+
+	// UseEnumNumbers emits enum values as numbers instead of names
+	UseEnumNumbers bool
+
+	// TimestampFormat sets how google.protobuf.Timestamp fields are emitted
+	TimestampFormat TimestampFormat
+
+	// DurationFormat sets how google.protobuf.Duration fields are emitted
+	DurationFormat DurationFormat
 }
 
+// TimestampFormat selects the JSON output format of google.protobuf.Timestamp.
+type TimestampFormat int
+
+const (
+	// TimestampRFC3339 emits RFC 3339 strings, e.g. "2024-05-01T12:00:00Z" (default).
+	TimestampRFC3339 TimestampFormat = iota
+
+	// TimestampEpochMillis emits milliseconds since the Unix epoch.
+	TimestampEpochMillis
+)
+
+// DurationFormat selects the JSON output format of google.protobuf.Duration.
+type DurationFormat int
+
+const (
+	// DurationString emits seconds with an "s" suffix, e.g. "1.500s" (default).
+	DurationString DurationFormat = iota
+
+	// DurationMillis emits a number of milliseconds.
+	DurationMillis
+)
+
+// Int64Overflow controls how JSONOptions.Int64AsNumber encodes 64-bit values
+// that JavaScript clients cannot represent exactly.
+type Int64Overflow int
+
+const (
+	// Int64OverflowString keeps out-of-range values as strings (default).
+	Int64OverflowString Int64Overflow = iota
+
+	// Int64OverflowNumber emits every value as a number.
+	Int64OverflowNumber
+
+	// Int64OverflowError fails the response.
+	Int64OverflowError
+)
+
 // globalSwaggerData is set by generated init() code from swagger_gen.go.
 // This allows the swagger data to be embedded without user-visible //go:embed.
 // Protected by swaggerMu for concurrent access safety.
@@ -109,47 +178,84 @@ func getSwaggerData() []byte {
 	return globalSwaggerData
 }
 
-// compiledPattern holds a pre-compiled glob pattern for efficient matching.
-type compiledPattern struct {
-	prefix   string // For "/**" suffix patterns
-	pattern  string // Original pattern for path.Match
-	isDouble bool   // true for "/**", false for single "*"
-}
-
 // serverConfig holds all configuration for the server.
 type serverConfig struct {
 	// Ports
 	grpcPort int
 	httpPort int
 
+	// Time source of time-dependent features (see WithClock)
+	clock Clock
+
 	// Services
-	grpcServices []grpcServiceRegistration
-	restServices []RESTRegistrar
+	grpcServices          []grpcServiceRegistration
+	restServices          []RESTRegistrar
+	continueOnRESTFailure bool
 
 	// Authentication
 	authFunc           AuthFunc
+	basicAuthRealm     string
+	authFailures       *authFailureTracker
 	protectedEndpoints []string
 	publicEndpoints    []string
+	publicBuiltins     bool
 
 	// Pre-compiled patterns for O(1) exact match lookups
-	protectedExactMap    map[string]bool      // Exact patterns (no wildcards)
-	protectedWildcards   []compiledPattern    // Wildcard patterns
-	publicExactMap       map[string]bool      // Exact patterns (no wildcards)
-	publicWildcards      []compiledPattern    // Wildcard patterns
+	protectedMatcher *pathmatch.Matcher
+	publicMatcher    *pathmatch.Matcher
 
 	// Features
-	healthEnabled  bool
-	metricsEnabled bool
-	swaggerURL     string // URL for documentation (fetched at build time)
-	swaggerPath    string // Local file path (read at runtime)
-	swaggerEnabled bool
-	corsEnabled    bool
-	corsConfig     *CORSConfig
+	healthEnabled   bool
+	livenessPath    string
+	readinessPath   string
+	grpcHealth      bool
+	noReflection    bool
+	middlewareTrace bool
+	metricsEnabled  bool
+	metricsPath     string
+	metricsAccess   *metricsAccess
+	runtimeMetrics  bool
+	// Registry the metrics are registered on and served from
+	// (WithMetricsRegistry)
+	metricsRegisterer prometheus.Registerer
+	metricsGatherer   prometheus.Gatherer
+	// Route templates labeling HTTP metrics (WithMetricsRoutes)
+	metricsRoutes     []string
+	metricsRoutesOnly bool
+	swaggerURL      string // URL for documentation (fetched at build time)
+	swaggerPath     string // Local file path (read at runtime)
+	swaggerEnabled  bool
+	swaggerPrefix   string
+	corsEnabled     bool
+	corsConfig      *CORSConfig
+	adminConfig     *adminConfig
 
 	// Marshalers for custom content types
-	marshalers     map[string]runtime.Marshaler
-	jsonOptions    *JSONOptions
-	gatewayOptions []runtime.ServeMuxOption
+	marshalers       map[string]runtime.Marshaler
+	contentTypeRules []contentTypeRule
+	jsonOptions      *JSONOptions
+	jsonOverrides    []jsonOverride
+	prettyJSON       bool
+	htmlTables       bool
+	jsonp            *jsonpConfig
+	queryOptions     *QueryOptions
+	paramRules       []paramRule
+	maxPageSize      *ParamRules
+	cachePolicies    []cachePolicy
+	gatewayOptions   []runtime.ServeMuxOption
+	gatewayDialOpts  []grpc.DialOption
+	gatewayMuxHooks  []func(*runtime.ServeMux) error
+
+	// Streaming of large REST responses and limits for slow stream clients
+	largeResponseThreshold int
+	streamingLimits        *StreamingLimits
+
+	// Metadata forwarded from REST requests to gRPC handlers
+	metadataAnnotators []MetadataAnnotator
+
+	// Resilience for the gateway → gRPC connection
+	gatewayCircuitBreaker *CircuitBreaker
+	gatewayRetry          *retrier
 
 	// Custom HTTP handlers (not in proto)
 	httpHandlers []httpHandlerRegistration
@@ -161,11 +267,117 @@ type serverConfig struct {
 	unaryInterceptors  []unaryInterceptorRegistration
 	streamInterceptors []streamInterceptorRegistration
 
+	// Request timeouts
+	httpTimeout          time.Duration
+	httpTimeoutOverrides []timeoutOverride
+	maxRequestTimeout    time.Duration
+
+	// Custom health checks
+	readinessChecks []*healthCheck
+	livenessChecks  []*healthCheck
+
+	// Canonical access log line per HTTP request
+	accessLog bool
+
+	// Request IDs assigned to every request and returned in responses
+	requestID bool
+
+	// Request cost accounting
+	requestCost *RequestCostConfig
+
+	// Secrets provider (WithSecrets)
+	secrets secrets.Provider
+
+	// Token bucket rate limits (WithRateLimit)
+	rateLimits []*rateLimiter
+
+	// Role-based authorization (WithRBAC)
+	rbacRules []rbacRule
+
+	// Tenant resolution and per-tenant metrics
+	tenantResolver func(ctx context.Context) string
+	tenantMetrics  *TenantMetricsConfig
+
+	// Built-in gRPC request logging
+	grpcLogging *grpcLoggingConfig
+
+	// Per-method gRPC message size limits
+	grpcSizeLimits []messageSizeLimit
+
+	// Slow request logging
+	slowRequestThreshold time.Duration
+
+	// Deprecated endpoints
+	deprecatedEndpoints []deprecatedEndpoint
+
+	// Per-principal quotas and brute-force lockouts
+	quota   *quotaConfig
+	lockout *lockoutConfig
+
+	// gRPC trailers exposed as REST response headers
+	trailerHeaders map[string]string
+
+	// Custom routing fallbacks
+	notFoundHandler         http.Handler
+	methodNotAllowedHandler http.Handler
+
+	// Redirects and route aliases
+	routeRewrites []routeRewrite
+
+	// Reverse proxies to legacy upstreams
+	reverseProxies []*proxyConfig
+
+	// TLS for gRPC and HTTP
+	tlsConfig   *tls.Config
+	tlsCertFile string
+	tlsKeyFile  string
+
+	// Server certificates (WithCertificateProvider, WithCertReloader)
+	certProvider CertificateProvider
+
+	// Client certificate verification (mutual TLS)
+	mtlsCAFile  string
+	mtlsRequire bool
+
+	// Request priority and load shedding
+	priorityClassifier    PriorityClassifier
+	maxConcurrentRequests int
+
+	// Open connections per listener
+	maxConnections int
+
+	// Production safety checks
+	strictMode bool
+	strictSkip []StrictCheck
+
+	// Modules, in registration order
+	modules []Module
+
+	// Lifecycle event listeners
+	eventListeners []EventListener
+
 	// Shutdown
 	gracefulTimeout time.Duration
+	shutdownDelay   time.Duration
+
+	// Restarts
+	gracefulRestart bool
+	reusePort       bool
+
+	// Built-in endpoints also served on the gRPC port
+	grpcPortEndpoints bool
 
 	// Logging
 	logLevel string
+	logger   *slog.Logger // filtered by logLevel once New ran
+
+	// Misconfigurations found while applying options, reported by New
+	errs []error
+}
+
+// invalid records a configuration error, reported by New as ErrInvalidConfig.
+func (c *serverConfig) invalid(format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("%w: "+format, append([]any{ErrInvalidConfig}, args...)...))
 }
 
 // grpcServiceRegistration holds a service registrar function.
@@ -176,6 +388,7 @@ type grpcServiceRegistration struct {
 // newServerConfig creates a new server config with default values.
 func newServerConfig() *serverConfig {
 	return &serverConfig{
+		clock:                systemClock{},
 		grpcPort:             9090,
 		httpPort:             8080,
 		grpcServices:         make([]grpcServiceRegistration, 0),
@@ -186,44 +399,34 @@ func newServerConfig() *serverConfig {
 		httpMiddlewares:      make([]HTTPMiddleware, 0),
 		unaryInterceptors:    make([]unaryInterceptorRegistration, 0),
 		streamInterceptors:   make([]streamInterceptorRegistration, 0),
-		protectedExactMap:    make(map[string]bool),
-		protectedWildcards:   make([]compiledPattern, 0),
-		publicExactMap:       make(map[string]bool),
-		publicWildcards:      make([]compiledPattern, 0),
 		gracefulTimeout:      30 * time.Second,
+		livenessPath:         "/healthz",
+		readinessPath:        "/readyz",
+		metricsPath:          "/metrics",
+		metricsRegisterer:    prometheus.DefaultRegisterer,
+		metricsGatherer:      prometheus.DefaultGatherer,
+		swaggerPrefix:        defaultSwaggerPrefix,
 		logLevel:             "info",
+		logger:               defaultLogger,
 	}
 }
 
-// compilePatterns separates patterns into exact matches and wildcards.
-// Returns a map for O(1) exact lookups and a slice of compiled wildcards.
-func compilePatterns(patterns []string) (map[string]bool, []compiledPattern) {
-	exact := make(map[string]bool, len(patterns))
-	wildcards := make([]compiledPattern, 0)
-
-	for _, p := range patterns {
-		if strings.Contains(p, "*") {
-			if strings.HasSuffix(p, "/**") {
-				wildcards = append(wildcards, compiledPattern{
-					prefix:   strings.TrimSuffix(p, "/**"),
-					isDouble: true,
-				})
-			} else {
-				wildcards = append(wildcards, compiledPattern{
-					pattern: p,
-				})
-			}
-		} else {
-			exact[p] = true
-		}
+// compilePatterns compiles the patterns of option, reporting malformed
+// globs as invalid configuration.
+func (c *serverConfig) compilePatterns(option string, patterns []string) *pathmatch.Matcher {
+	if err := pathmatch.Validate(patterns...); err != nil {
+		c.invalid("%s: %v", option, err)
 	}
-
-	return exact, wildcards
+	return pathmatch.Compile(patterns...)
 }
 
 // WithGRPCPort sets the gRPC server port.
 func WithGRPCPort(port int) Option {
 	return func(c *serverConfig) {
+		if port < 0 || port > 65535 {
+			c.invalid("gRPC port %d out of range", port)
+			return
+		}
 		c.grpcPort = port
 	}
 }
@@ -231,6 +434,10 @@ func WithGRPCPort(port int) Option {
 // WithHTTPPort sets the HTTP/REST server port.
 func WithHTTPPort(port int) Option {
 	return func(c *serverConfig) {
+		if port < 0 || port > 65535 {
+			c.invalid("HTTP port %d out of range", port)
+			return
+		}
 		c.httpPort = port
 	}
 }
@@ -245,6 +452,10 @@ func WithHTTPPort(port int) Option {
 //	})
 func WithGRPCService(registrar ServiceRegistrar) Option {
 	return func(c *serverConfig) {
+		if registrar == nil {
+			c.invalid("WithGRPCService: nil registrar")
+			return
+		}
 		c.grpcServices = append(c.grpcServices, grpcServiceRegistration{
 			registrar: registrar,
 		})
@@ -259,6 +470,10 @@ func WithGRPCService(registrar ServiceRegistrar) Option {
 //	grpckit.WithRESTService(pb.RegisterMyServiceHandlerFromEndpoint)
 func WithRESTService(registrar RESTRegistrar) Option {
 	return func(c *serverConfig) {
+		if registrar == nil {
+			c.invalid("WithRESTService: nil registrar")
+			return
+		}
 		c.restServices = append(c.restServices, registrar)
 	}
 }
@@ -293,7 +508,7 @@ func WithProtectedEndpoints(patterns ...string) Option {
 	return func(c *serverConfig) {
 		c.protectedEndpoints = append(c.protectedEndpoints, patterns...)
 		// Recompile all patterns (allows multiple calls to WithProtectedEndpoints)
-		c.protectedExactMap, c.protectedWildcards = compilePatterns(c.protectedEndpoints)
+		c.protectedMatcher = c.compilePatterns("WithProtectedEndpoints", c.protectedEndpoints)
 	}
 }
 
@@ -308,7 +523,7 @@ func WithPublicEndpoints(patterns ...string) Option {
 	return func(c *serverConfig) {
 		c.publicEndpoints = append(c.publicEndpoints, patterns...)
 		// Recompile all patterns (allows multiple calls to WithPublicEndpoints)
-		c.publicExactMap, c.publicWildcards = compilePatterns(c.publicEndpoints)
+		c.publicMatcher = c.compilePatterns("WithPublicEndpoints", c.publicEndpoints)
 	}
 }
 
@@ -319,10 +534,103 @@ func WithHealthCheck() Option {
 	}
 }
 
-// WithMetrics enables the Prometheus metrics endpoint (/metrics).
-func WithMetrics() Option {
+// WithHealthPaths serves the liveness and readiness endpoints on other paths
+// than /healthz and /readyz, e.g. when the API already uses them or the
+// platform expects different probes. It implies WithHealthCheck.
+//
+// Example:
+//
+//	grpckit.WithHealthPaths("/internal/healthz", "/internal/readyz")
+func WithHealthPaths(liveness, readiness string) Option {
+	return func(c *serverConfig) {
+		if !strings.HasPrefix(liveness, "/") || !strings.HasPrefix(readiness, "/") || liveness == readiness || liveness == "/" || readiness == "/" {
+			c.invalid("WithHealthPaths: need two distinct absolute paths other than /, got %q and %q", liveness, readiness)
+			return
+		}
+		c.healthEnabled = true
+		c.livenessPath = liveness
+		c.readinessPath = readiness
+	}
+}
+
+// WithMetrics enables the Prometheus metrics endpoint (/metrics). It serves
+// the Prometheus text or OpenMetrics format, as negotiated by the scraper,
+// gzipped if accepted. Without options anyone reaching the HTTP port may
+// scrape it; see MetricsBasicAuth and MetricsAllowedNetworks.
+//
+// Example:
+//
+//	grpckit.WithMetrics(grpckit.MetricsAllowedNetworks("10.0.0.0/8"))
+func WithMetrics(opts ...MetricsOption) Option {
+	return func(c *serverConfig) {
+		c.metricsEnabled = true
+		if len(opts) == 0 {
+			return
+		}
+		access := &metricsAccess{}
+		for _, opt := range opts {
+			opt(access)
+		}
+		networks, err := parseNetworks(access.cidrs)
+		if err != nil {
+			c.invalid("WithMetrics: %v", err)
+			return
+		}
+		access.networks = networks
+		c.metricsAccess = access
+	}
+}
+
+// WithMetricsPath serves the Prometheus metrics endpoint on another path
+// than /metrics. It implies WithMetrics.
+//
+// Example:
+//
+//	grpckit.WithMetricsPath("/internal/metrics")
+func WithMetricsPath(path string) Option {
+	return func(c *serverConfig) {
+		if !strings.HasPrefix(path, "/") || path == "/" {
+			c.invalid("WithMetricsPath: path %q is not an absolute path other than /", path)
+			return
+		}
+		c.metricsEnabled = true
+		c.metricsPath = path
+	}
+}
+
+// WithMetricsRegistry registers the server's metrics on reg, and serves reg
+// on the metrics endpoint, instead of the global prometheus.DefaultRegisterer
+// and prometheus.DefaultGatherer. Servers with their own registries can run
+// in one process, e.g. in tests. It implies WithMetrics.
+//
+// Example:
+//
+//	reg := prometheus.NewRegistry()
+//	reg.MustRegister(ordersProcessed)
+//	grpckit.WithMetricsRegistry(reg)
+func WithMetricsRegistry(reg *prometheus.Registry) Option {
+	return func(c *serverConfig) {
+		if reg == nil {
+			c.invalid("WithMetricsRegistry: nil registry")
+			return
+		}
+		c.metricsEnabled = true
+		c.metricsRegisterer = reg
+		c.metricsGatherer = reg
+	}
+}
+
+// WithRuntimeMetrics exports Go runtime (goroutines, GC, memory) and process
+// (CPU, file descriptors, RSS) metrics. It implies WithMetrics.
+//
+// The collectors are registered on the registry set with
+// WithMetricsRegistry, which starts empty. The default registry already
+// includes them, so without WithMetricsRegistry the option has no effect
+// (unless prometheus.DefaultRegisterer was replaced when New is called).
+func WithRuntimeMetrics() Option {
 	return func(c *serverConfig) {
 		c.metricsEnabled = true
+		c.runtimeMetrics = true
 	}
 }
 
@@ -396,6 +704,24 @@ func WithSwaggerFile(path string) Option {
 	}
 }
 
+// WithSwaggerPrefix serves Swagger UI and the spec under another prefix than
+// /swagger: the UI at prefix + "/" and the spec at prefix + "/spec.json".
+// Swagger itself is enabled by WithSwagger or WithSwaggerFile.
+//
+// Example:
+//
+//	grpckit.WithSwaggerPrefix("/internal/docs")
+func WithSwaggerPrefix(prefix string) Option {
+	return func(c *serverConfig) {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if !strings.HasPrefix(prefix, "/") {
+			c.invalid("WithSwaggerPrefix: prefix %q is not an absolute path below /", prefix)
+			return
+		}
+		c.swaggerPrefix = prefix
+	}
+}
+
 // WithMarshaler registers a custom marshaler for a specific MIME type.
 // The marshaler handles both request parsing and response formatting.
 // Content-Type header determines which marshaler is used for requests,
@@ -406,6 +732,10 @@ func WithSwaggerFile(path string) Option {
 //	grpckit.WithMarshaler("application/msgpack", &MyMsgPackMarshaler{})
 func WithMarshaler(mimeType string, marshaler runtime.Marshaler) Option {
 	return func(c *serverConfig) {
+		if mimeType == "" || marshaler == nil {
+			c.invalid("WithMarshaler: empty MIME type or nil marshaler for %q", mimeType)
+			return
+		}
 		if c.marshalers == nil {
 			c.marshalers = make(map[string]runtime.Marshaler)
 		}
@@ -428,6 +758,10 @@ func WithMarshalers(marshalers map[string]runtime.Marshaler) Option {
 			c.marshalers = make(map[string]runtime.Marshaler)
 		}
 		for mimeType, marshaler := range marshalers {
+			if mimeType == "" || marshaler == nil {
+				c.invalid("WithMarshalers: empty MIME type or nil marshaler for %q", mimeType)
+				continue
+			}
 			c.marshalers[mimeType] = marshaler
 		}
 	}
@@ -449,6 +783,43 @@ func WithJSONOptions(opts JSONOptions) Option {
 	}
 }
 
+// WithJSONOptionsFor overrides the JSON response format for paths matching
+// the patterns, e.g. to keep legacy endpoints on epoch-millisecond timestamps
+// while the rest of the API uses RFC 3339. Patterns support the same globs
+// as WithProtectedEndpoints; the first matching override wins. The options
+// replace (not merge with) those of WithJSONOptions and apply to clients
+// accepting application/json (or anything).
+//
+// Example:
+//
+//	grpckit.WithJSONOptionsFor(grpckit.JSONOptions{
+//	    TimestampFormat: grpckit.TimestampEpochMillis,
+//	    UseEnumNumbers:  true,
+//	}, "/api/v1/legacy/**"),
+func WithJSONOptionsFor(opts JSONOptions, patterns ...string) Option {
+	return func(c *serverConfig) {
+		c.jsonOverrides = append(c.jsonOverrides, jsonOverride{
+			paths:   c.compilePatterns("WithJSONOptionsFor", patterns),
+			options: opts,
+		})
+	}
+}
+
+// WithPrettyJSON lets clients request indented JSON responses with
+// "?pretty=true" (or just "?pretty") or "Accept: application/json+pretty",
+// for debugging without enabling Indent for every response. The parameter
+// only applies to clients accepting JSON, so it doesn't turn an XML response
+// into JSON.
+//
+// Example:
+//
+//	curl http://localhost:8080/api/v1/items?pretty
+func WithPrettyJSON() Option {
+	return func(c *serverConfig) {
+		c.prettyJSON = true
+	}
+}
+
 // WithGatewayOption allows passing raw grpc-gateway ServeMuxOptions.
 // Use this for advanced customization not covered by other options.
 //
@@ -464,7 +835,70 @@ func WithGatewayOption(opt runtime.ServeMuxOption) Option {
 	}
 }
 
+// WithGatewayDialOption adds gRPC dial options used by the grpc-gateway to
+// connect to the gRPC server, e.g. client interceptors for tracing.
+//
+// Example:
+//
+//	grpckit.WithGatewayDialOption(grpc.WithChainUnaryInterceptor(tracingInterceptor))
+func WithGatewayDialOption(opts ...grpc.DialOption) Option {
+	return func(c *serverConfig) {
+		c.gatewayDialOpts = append(c.gatewayDialOpts, opts...)
+	}
+}
+
+// WithGatewayMuxHook registers a function called with the grpc-gateway
+// ServeMux once the REST services are registered, e.g. to add routes with
+// custom verbs or paths using runtime.ServeMux.HandlePath. Unlike
+// WithHTTPHandler, these routes go through the gateway: its marshalers
+// (runtime.MarshalerForRequest), metadata annotators and error handlers
+// apply. A hook registering a pattern that overlaps a generated route takes
+// precedence over it. An error returned by a hook stops the server.
+//
+// Example:
+//
+//	grpckit.WithGatewayMuxHook(func(mux *runtime.ServeMux) error {
+//	    return mux.HandlePath("POST", "/api/v1/items/{id}:archive", archiveItem)
+//	})
+func WithGatewayMuxHook(hook func(mux *runtime.ServeMux) error) Option {
+	return func(c *serverConfig) {
+		if hook == nil {
+			c.invalid("WithGatewayMuxHook: nil hook")
+			return
+		}
+		c.gatewayMuxHooks = append(c.gatewayMuxHooks, hook)
+	}
+}
+
+// WithOptions combines several options into one, so a set of options can be
+// packaged and applied with a single call.
+//
+// Example:
+//
+//	var observability = grpckit.WithOptions(
+//	    grpckit.WithMetrics(),
+//	    grpckit.WithAccessLog(),
+//	)
+func WithOptions(opts ...Option) Option {
+	return func(c *serverConfig) {
+		for _, opt := range opts {
+			opt(c)
+		}
+	}
+}
+
+// WithGRPCReflection enables or disables the gRPC server reflection service
+// used by grpcurl and grpcui. Reflection is enabled by default; disable it
+// in production to avoid exposing the API schema.
+func WithGRPCReflection(enabled bool) Option {
+	return func(c *serverConfig) {
+		c.noReflection = !enabled
+	}
+}
+
 // WithGracefulShutdown sets the timeout for graceful shutdown.
+// Connections and streams still open when it expires are closed; handlers
+// are warned when draining begins through ShutdownContext.
 // Default is 30 seconds.
 func WithGracefulShutdown(timeout time.Duration) Option {
 	return func(c *serverConfig) {
@@ -472,7 +906,28 @@ func WithGracefulShutdown(timeout time.Duration) Option {
 	}
 }
 
-// WithLogLevel sets the logging level (debug, info, warn, error).
+// WithShutdownDelay sets a delay between marking the server as not ready
+// and starting connection draining on shutdown.
+//
+// In Kubernetes, endpoint removal propagates to load balancers asynchronously
+// after a pod receives SIGTERM. Keeping the server fully operational (but failing
+// /readyz) for a few seconds lets in-flight LB traffic drain to other pods
+// instead of hitting connection resets. Default is 0 (drain immediately).
+//
+// Note: the delay counts towards the pod's terminationGracePeriodSeconds.
+//
+// Example:
+//
+//	grpckit.WithShutdownDelay(5 * time.Second)
+func WithShutdownDelay(delay time.Duration) Option {
+	return func(c *serverConfig) {
+		c.shutdownDelay = delay
+	}
+}
+
+// WithLogLevel sets the logging level (debug, info, warn, error). Records
+// below it are dropped before reaching the logger (see WithLogger); it can
+// be changed at runtime with SetLogLevel.
 func WithLogLevel(level string) Option {
 	return func(c *serverConfig) {
 		c.logLevel = level
@@ -490,14 +945,28 @@ func WithLogLevel(level string) Option {
 //	    myWebhookMiddleware(http.HandlerFunc(webhookHandler)),
 //	)
 //
+// Transforms, if any, rewrite the request (path, headers) before the handler
+// sees it; they run in order, after the global middleware.
+//
 // Example:
 //
 //	grpckit.WithHTTPHandler("/webhook", webhookHandler)
-func WithHTTPHandler(pattern string, handler http.Handler) Option {
+//	grpckit.WithHTTPHandler("/billing/", billingProxy,
+//	    grpckit.StripPrefix("/billing"),
+//	    grpckit.SetRequestHeader("X-Forwarded-Prefix", "/billing"))
+func WithHTTPHandler(pattern string, handler http.Handler, transforms ...RequestTransform) Option {
 	return func(c *serverConfig) {
+		if pattern == "" || handler == nil {
+			c.invalid("WithHTTPHandler: empty pattern or nil handler for %q", pattern)
+			return
+		}
+		h := handler
+		if len(transforms) > 0 {
+			h = transformRequest(transforms, h)
+		}
 		c.httpHandlers = append(c.httpHandlers, httpHandlerRegistration{
 			pattern: pattern,
-			handler: handler,
+			handler: h,
 		})
 	}
 }
@@ -513,8 +982,11 @@ func WithHTTPHandler(pattern string, handler http.Handler) Option {
 //	    log.Printf("Webhook: %s", body)
 //	    w.Write([]byte("OK"))
 //	})
-func WithHTTPHandlerFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) Option {
-	return WithHTTPHandler(pattern, http.HandlerFunc(handler))
+func WithHTTPHandlerFunc(pattern string, handler func(http.ResponseWriter, *http.Request), transforms ...RequestTransform) Option {
+	if handler == nil {
+		return WithHTTPHandler(pattern, nil, transforms...)
+	}
+	return WithHTTPHandler(pattern, http.HandlerFunc(handler), transforms...)
 }
 
 // WithHTTPMiddleware adds a middleware to the HTTP middleware chain.
@@ -534,6 +1006,10 @@ func WithHTTPHandlerFunc(pattern string, handler func(http.ResponseWriter, *http
 //	})
 func WithHTTPMiddleware(middleware HTTPMiddleware) Option {
 	return func(c *serverConfig) {
+		if middleware == nil {
+			c.invalid("WithHTTPMiddleware: nil middleware")
+			return
+		}
 		c.httpMiddlewares = append(c.httpMiddlewares, middleware)
 	}
 }
@@ -566,10 +1042,17 @@ func WithHTTPMiddleware(middleware HTTPMiddleware) Option {
 //	)
 func WithUnaryInterceptor(interceptor grpc.UnaryServerInterceptor, opts ...InterceptorOption) Option {
 	return func(c *serverConfig) {
+		if interceptor == nil {
+			c.invalid("WithUnaryInterceptor: nil interceptor")
+			return
+		}
 		cfg := &interceptorConfig{}
 		for _, opt := range opts {
 			opt(cfg)
 		}
+		if err := pathmatch.Validate(cfg.exceptEndpoints...); err != nil {
+			c.invalid("WithUnaryInterceptor: ExceptEndpoints: %v", err)
+		}
 		c.unaryInterceptors = append(c.unaryInterceptors, unaryInterceptorRegistration{
 			interceptor:     interceptor,
 			exceptEndpoints: cfg.exceptEndpoints,
@@ -605,10 +1088,17 @@ func WithUnaryInterceptor(interceptor grpc.UnaryServerInterceptor, opts ...Inter
 //	)
 func WithStreamInterceptor(interceptor grpc.StreamServerInterceptor, opts ...InterceptorOption) Option {
 	return func(c *serverConfig) {
+		if interceptor == nil {
+			c.invalid("WithStreamInterceptor: nil interceptor")
+			return
+		}
 		cfg := &interceptorConfig{}
 		for _, opt := range opts {
 			opt(cfg)
 		}
+		if err := pathmatch.Validate(cfg.exceptEndpoints...); err != nil {
+			c.invalid("WithStreamInterceptor: ExceptEndpoints: %v", err)
+		}
 		c.streamInterceptors = append(c.streamInterceptors, streamInterceptorRegistration{
 			interceptor:     interceptor,
 			exceptEndpoints: cfg.exceptEndpoints,
@@ -2,7 +2,9 @@ package grpckit
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"strings"
 	"testing"
 	"time"
 
@@ -309,6 +311,64 @@ func TestWithGracefulShutdown(t *testing.T) {
 	}
 }
 
+func TestWithShutdownDelay(t *testing.T) {
+	cfg := newServerConfig()
+
+	opt := WithShutdownDelay(5 * time.Second)
+	opt(cfg)
+
+	if cfg.shutdownDelay != 5*time.Second {
+		t.Errorf("expected 5s delay, got %v", cfg.shutdownDelay)
+	}
+}
+
+func TestWithGatewayDialOption(t *testing.T) {
+	cfg := newServerConfig()
+
+	opt := WithGatewayDialOption(grpc.WithUserAgent("a"), grpc.WithUserAgent("b"))
+	opt(cfg)
+
+	if len(cfg.gatewayDialOpts) != 2 {
+		t.Errorf("expected 2 dial options, got %d", len(cfg.gatewayDialOpts))
+	}
+}
+
+func TestWithOptions(t *testing.T) {
+	cfg := newServerConfig()
+
+	opt := WithOptions(WithGRPCPort(7070), WithLogLevel("debug"))
+	opt(cfg)
+
+	if cfg.grpcPort != 7070 {
+		t.Errorf("expected port 7070, got %d", cfg.grpcPort)
+	}
+	if cfg.logLevel != "debug" {
+		t.Errorf("expected debug, got %s", cfg.logLevel)
+	}
+}
+
+func TestWithRuntimeMetrics(t *testing.T) {
+	cfg := newServerConfig()
+
+	opt := WithRuntimeMetrics()
+	opt(cfg)
+
+	if !cfg.runtimeMetrics || !cfg.metricsEnabled {
+		t.Error("expected runtime metrics to enable metrics")
+	}
+}
+
+func TestWithPrettyJSON(t *testing.T) {
+	cfg := newServerConfig()
+
+	opt := WithPrettyJSON()
+	opt(cfg)
+
+	if !cfg.prettyJSON {
+		t.Error("expected pretty JSON to be enabled")
+	}
+}
+
 func TestWithLogLevel(t *testing.T) {
 	cfg := newServerConfig()
 
@@ -492,3 +552,39 @@ func TestMultipleOptionsChaining(t *testing.T) {
 		t.Errorf("expected debug log level, got %s", cfg.logLevel)
 	}
 }
+
+func TestNew_OptionErrors(t *testing.T) {
+	svc := WithGRPCService(func(s grpc.ServiceRegistrar) {})
+	cases := map[string]Option{
+		"grpc port":         WithGRPCPort(70000),
+		"http port":         WithHTTPPort(-1),
+		"nil grpc service":  WithGRPCService(nil),
+		"nil rest service":  WithRESTService(nil),
+		"nil marshaler":     WithMarshaler("application/xml", nil),
+		"empty mime type":   WithMarshalers(map[string]runtime.Marshaler{"": &runtime.JSONPb{}}),
+		"nil handler":       WithHTTPHandler("/webhook", nil),
+		"nil transformed":   WithHTTPHandler("/webhook", nil, StripPrefix("/webhook")),
+		"nil handler func":  WithHTTPHandlerFunc("/webhook", nil),
+		"nil middleware":    WithHTTPMiddleware(nil),
+		"nil unary":         WithUnaryInterceptor(nil),
+		"nil stream":        WithStreamInterceptor(nil),
+		"unknown log level": WithLogLevel("verbose"),
+		"nil registry":      WithMetricsRegistry(nil),
+	}
+	for name, opt := range cases {
+		if _, err := New(svc, opt); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+
+	// All errors are reported together
+	_, err := New(svc, WithGRPCPort(-1), WithHTTPMiddleware(nil))
+	if err == nil || !strings.Contains(err.Error(), "gRPC port -1") || !strings.Contains(err.Error(), "nil middleware") {
+		t.Errorf("expected both errors, got %v", err)
+	}
+
+	// Log levels are case-insensitive
+	if _, err := New(svc, WithLogLevel("DEBUG")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package grpckit
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// ParamRules declares aliases, defaults and maximums for query and form
+// fields, so public query-string contracts can stay stable while proto field
+// names evolve.
+type ParamRules struct {
+	// Aliases maps accepted parameter names to field paths,
+	// e.g. {"q": "query"}. When both are sent, the field path wins.
+	Aliases map[string]string
+
+	// Defaults maps field paths to values used when the parameter is absent,
+	// e.g. {"page_size": "50"}.
+	Defaults map[string]string
+
+	// Max maps integer field paths to their largest accepted value, e.g.
+	// {"page_size": 100}. Larger values, including ones overflowing int64,
+	// are lowered to it; other values are left for the gateway to validate.
+	Max map[string]int64
+}
+
+// defaultPageSizeParams are the parameters clamped by WithMaxPageSize when
+// none are given.
+var defaultPageSizeParams = []string{"page_size", "pageSize", "limit"}
+
+// paramRule holds ParamRules for the paths matching its patterns.
+type paramRule struct {
+	paths *pathmatch.Matcher
+	rules ParamRules
+}
+
+// WithParamRulesFor applies aliases, defaults and maximums to the query string and
+// URL-encoded form body of REST requests matching the patterns (same globs
+// as WithProtectedEndpoints), before they are bound to the request message.
+// The first matching rule set wins.
+//
+// Example:
+//
+//	grpckit.WithParamRulesFor(grpckit.ParamRules{
+//	    Aliases:  map[string]string{"q": "query"},
+//	    Defaults: map[string]string{"page_size": "50"},
+//	}, "/api/v1/search"),
+func WithParamRulesFor(rules ParamRules, patterns ...string) Option {
+	return func(c *serverConfig) {
+		c.paramRules = append(c.paramRules, paramRule{
+			paths: c.compilePatterns("WithParamRulesFor", patterns),
+			rules: rules,
+		})
+	}
+}
+
+// apply rewrites values with the aliases and defaults.
+func (p *ParamRules) apply(values url.Values) {
+	for alias, field := range p.Aliases {
+		v, ok := values[alias]
+		if !ok {
+			continue
+		}
+		delete(values, alias)
+		if _, exists := values[field]; !exists {
+			values[field] = v
+		}
+	}
+	for field, v := range p.Defaults {
+		if _, exists := values[field]; !exists {
+			values.Set(field, v)
+		}
+	}
+	for field, max := range p.Max {
+		for i, v := range values[field] {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if (err == nil || errors.Is(err, strconv.ErrRange)) && n > max {
+				values[field][i] = strconv.FormatInt(max, 10)
+			}
+		}
+	}
+}
+
+// WithMaxPageSize lowers pagination parameters above max to max on every
+// REST request, query string and URL-encoded form alike, so a single
+// "?limit=10000000" cannot make a backend load everything. params default
+// to page_size, pageSize and limit. It applies after WithParamRulesFor, so
+// aliases are clamped too; use ParamRules.Max for per-endpoint limits.
+//
+// Example:
+//
+//	grpckit.WithMaxPageSize(500)
+//	grpckit.WithMaxPageSize(100, "page_size", "per_page")
+func WithMaxPageSize(max int64, params ...string) Option {
+	return func(c *serverConfig) {
+		if max <= 0 {
+			c.invalid("WithMaxPageSize: max must be positive, got %d", max)
+			return
+		}
+		if len(params) == 0 {
+			params = defaultPageSizeParams
+		}
+		c.maxPageSize = &ParamRules{Max: make(map[string]int64, len(params))}
+		for _, p := range params {
+			c.maxPageSize.Max[p] = max
+		}
+	}
+}
+
+// maxPageSizeMiddleware applies the WithMaxPageSize limits to requests.
+func maxPageSizeMiddleware(rules *ParamRules, next http.Handler) http.Handler {
+	if rules == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		applyParamRules(rules, r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// paramRulesMiddleware applies the first matching ParamRules to requests.
+func paramRulesMiddleware(rules []paramRule, next http.Handler) http.Handler {
+	if len(rules) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := range rules {
+			if rules[i].paths.Match(r.URL.Path) {
+				applyParamRules(&rules[i].rules, r)
+				break
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// applyParamRules rewrites the query string and, for URL-encoded forms, the body.
+func applyParamRules(rules *ParamRules, r *http.Request) {
+	query := r.URL.Query()
+	rules.apply(query)
+	r.URL.RawQuery = query.Encode()
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/x-www-form-urlencoded" {
+		return
+	}
+	data, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		// Let the gateway report the read error
+		r.Body = io.NopCloser(&errReader{err: err})
+		return
+	}
+	form, err := url.ParseQuery(string(data))
+	if err != nil {
+		// Leave malformed forms for the marshaler to reject
+		r.Body = io.NopCloser(strings.NewReader(string(data)))
+		return
+	}
+	rules.apply(form)
+	encoded := form.Encode()
+	r.Body = io.NopCloser(strings.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	r.Header.Set("Content-Length", strconv.Itoa(len(encoded)))
+}
+
+// errReader returns err on every read.
+type errReader struct {
+	err error
+}
+
+func (e *errReader) Read([]byte) (int, error) {
+	return 0, e.err
+}
@@ -0,0 +1,150 @@
+package grpckit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// paramsTestHandler records the query and body seen by the gateway.
+func paramsTestHandler(cfg *serverConfig, query *url.Values, body *string) http.Handler {
+	return paramRulesMiddleware(cfg.paramRules, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		*body = string(data)
+	}))
+}
+
+func TestParamRules_Query(t *testing.T) {
+	cfg := newServerConfig()
+	WithParamRulesFor(ParamRules{
+		Aliases:  map[string]string{"q": "query", "limit": "page_size"},
+		Defaults: map[string]string{"page_size": "50", "order": "name"},
+	}, "/api/v1/search")(cfg)
+
+	var query url.Values
+	var body string
+	handler := paramsTestHandler(cfg, &query, &body)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/search?q=shoes&order=price", nil))
+	if query.Get("query") != "shoes" || query.Has("q") {
+		t.Errorf("expected alias q to be renamed, got %v", query)
+	}
+	if query.Get("page_size") != "50" {
+		t.Errorf("expected default page_size, got %v", query)
+	}
+	if query.Get("order") != "price" {
+		t.Errorf("expected explicit value to win over default, got %v", query)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/search?limit=10&query=a&q=b", nil))
+	if query.Get("page_size") != "10" {
+		t.Errorf("expected aliased value to win over default, got %v", query)
+	}
+	if query.Get("query") != "a" || query.Has("q") {
+		t.Errorf("expected field name to win over alias, got %v", query)
+	}
+}
+
+func TestParamRules_Form(t *testing.T) {
+	cfg := newServerConfig()
+	WithParamRulesFor(ParamRules{
+		Aliases:  map[string]string{"mail": "email"},
+		Defaults: map[string]string{"role": "viewer"},
+	}, "/api/v1/users")(cfg)
+
+	var query url.Values
+	var body string
+	handler := paramsTestHandler(cfg, &query, &body)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader("mail=a%40b.c&name=Ann"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if want := "email=a%40b.c&name=Ann&role=viewer"; body != want {
+		t.Errorf("expected form body %q, got %q", want, body)
+	}
+	if req.ContentLength != int64(len(body)) {
+		t.Errorf("expected Content-Length %d, got %d", len(body), req.ContentLength)
+	}
+
+	// Other bodies are left untouched
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/users", strings.NewReader(`{"mail":"a@b.c"}`))
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if body != `{"mail":"a@b.c"}` {
+		t.Errorf("expected JSON body to be unchanged, got %q", body)
+	}
+}
+
+func TestParamRules_NoMatch(t *testing.T) {
+	cfg := newServerConfig()
+	WithParamRulesFor(ParamRules{Defaults: map[string]string{"page_size": "50"}}, "/api/v1/search")(cfg)
+	WithParamRulesFor(ParamRules{Defaults: map[string]string{"page_size": "20"}}, "/api/v1/**")(cfg)
+
+	var query url.Values
+	var body string
+	handler := paramsTestHandler(cfg, &query, &body)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+	if query.Get("page_size") != "20" {
+		t.Errorf("expected second rule set to apply, got %v", query)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/other?x=1", nil))
+	if query.Has("page_size") || query.Get("x") != "1" {
+		t.Errorf("expected unmatched path to be unchanged, got %v", query)
+	}
+}
+
+func TestParamRules_Max(t *testing.T) {
+	values := url.Values{
+		"page_size": {"1000"},
+		"offset":    {"99999999999999999999"},
+		"limit":     {"abc"},
+		"depth":     {"3"},
+	}
+	rules := ParamRules{Max: map[string]int64{"page_size": 100, "offset": 5000, "limit": 10, "depth": 5}}
+	rules.apply(values)
+
+	want := url.Values{"page_size": {"100"}, "offset": {"5000"}, "limit": {"abc"}, "depth": {"3"}}
+	if values.Encode() != want.Encode() {
+		t.Errorf("expected %v, got %v", want, values)
+	}
+}
+
+func TestWithMaxPageSize(t *testing.T) {
+	cfg := newServerConfig()
+	WithMaxPageSize(100)(cfg)
+	WithParamRulesFor(ParamRules{Aliases: map[string]string{"per_page": "page_size"}}, "/api/v1/items")(cfg)
+
+	var query url.Values
+	var body string
+	handler := paramRulesMiddleware(cfg.paramRules, maxPageSizeMiddleware(cfg.maxPageSize, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+	})))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/items?per_page=5000&pageSize=7&limit=10000000", nil))
+	if query.Get("page_size") != "100" || query.Get("pageSize") != "7" || query.Get("limit") != "100" {
+		t.Errorf("expected aliased and default parameters to be clamped, got %v", query)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader("limit=500&name=x"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if body != "limit=100&name=x" {
+		t.Errorf("expected form limit to be clamped, got %q", body)
+	}
+
+	cfg = newServerConfig()
+	WithMaxPageSize(0)(cfg)
+	if len(cfg.errs) != 1 {
+		t.Errorf("expected zero max to be rejected, got %v", cfg.errs)
+	}
+}
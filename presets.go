@@ -0,0 +1,130 @@
+package grpckit
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// ProductionDefaults returns the vetted options for an internet-facing
+// production service. Pass it first, so later options can override any
+// setting:
+//
+//	grpckit.Run(
+//	    grpckit.ProductionDefaults(),
+//	    grpckit.WithCORSConfig(corsConfig),
+//	    grpckit.WithGRPCService(...),
+//	)
+//
+// It enables:
+//   - /healthz and /readyz, and the grpc.health.v1 service
+//   - Prometheus metrics, including Go runtime and process metrics
+//   - access logs at info level, and slow request logging over 1s
+//   - a 30s HTTP request timeout
+//   - a 5s shutdown delay, so load balancers stop routing before draining
+//     starts, and the default 30s graceful shutdown timeout
+//
+// gRPC reflection is disabled. CORS, Swagger and the admin API stay off;
+// enable them explicitly. Combine with WithStrictMode to catch the rest.
+func ProductionDefaults() Option {
+	return WithOptions(
+		WithHealthCheck(),
+		WithGRPCHealthService(),
+		WithMetrics(),
+		WithRuntimeMetrics(),
+		WithAccessLog(),
+		WithLogLevel("info"),
+		WithSlowRequestThreshold(time.Second),
+		WithHTTPTimeout(30*time.Second),
+		WithShutdownDelay(5*time.Second),
+		WithGracefulShutdown(30*time.Second),
+		WithGRPCReflection(false),
+	)
+}
+
+// InternalServiceDefaults returns the vetted options for a service only
+// reachable by other services, e.g. behind a mesh. It matches
+// ProductionDefaults, with a tighter 10s HTTP timeout and slow request
+// threshold of 500ms, as internal calls are usually part of a larger
+// request budget. gRPC reflection stays on for debugging with grpcurl
+// inside the mesh, and CORS stays off, as browsers do not call it directly.
+func InternalServiceDefaults() Option {
+	return WithOptions(
+		WithHealthCheck(),
+		WithGRPCHealthService(),
+		WithMetrics(),
+		WithRuntimeMetrics(),
+		WithAccessLog(),
+		WithLogLevel("info"),
+		WithSlowRequestThreshold(500*time.Millisecond),
+		WithHTTPTimeout(10*time.Second),
+		WithShutdownDelay(5*time.Second),
+		WithGracefulShutdown(30*time.Second),
+	)
+}
+
+// DevDefaults returns options for local development. Never use it in
+// production: it allows any CORS origin and serves the admin API with the
+// well-known token "dev", though only to clients on the local host.
+//
+// It enables:
+//   - /healthz, /readyz and Prometheus metrics
+//   - CORS for any origin
+//   - pretty JSON on request (?pretty) and HTML tables for browsers
+//   - access logs at debug level
+//   - the admin API (Authorization: Bearer dev) for loopback clients only,
+//     for runtime log levels, routes and config dumps
+//   - a 1s graceful shutdown, for fast restarts
+func DevDefaults() Option {
+	return WithOptions(
+		WithHealthCheck(),
+		WithMetrics(),
+		WithCORS(),
+		WithPrettyJSON(),
+		WithHTMLTableSupport(),
+		WithAccessLog(),
+		WithLogLevel("debug"),
+		WithAdminAPI(AdminToken("dev"), AdminLocalOnly()),
+		WithGracefulShutdown(time.Second),
+	)
+}
+
+// CloudRunDefaults returns options for request-driven platforms such as
+// Cloud Run, which route traffic to the port in the PORT environment
+// variable (default 8080) and stop sending requests before SIGTERM. gRPC
+// and REST share that port in single-port mode, so gRPC over HTTP/2
+// cleartext (h2c) works with "Use HTTP/2 end-to-end".
+//
+// It enables:
+//   - single-port mode on $PORT
+//   - /healthz and /readyz, and the grpc.health.v1 service
+//   - access logs at info level, and slow request logging over 1s
+//   - no shutdown delay, and a 9s graceful shutdown, within the 10s the
+//     platform waits after SIGTERM
+//
+// For AWS Lambda, which invokes functions per event without a listener,
+// see the contrib/lambda module.
+func CloudRunDefaults() Option {
+	return func(c *serverConfig) {
+		port := 8080
+		if v := os.Getenv("PORT"); v != "" {
+			p, err := strconv.Atoi(v)
+			if err != nil || p < 0 || p > 65535 {
+				c.invalid("PORT=%q is not a port", v)
+				return
+			}
+			port = p
+		}
+		WithOptions(
+			WithGRPCPort(port),
+			WithHTTPPort(port),
+			WithHealthCheck(),
+			WithGRPCHealthService(),
+			WithAccessLog(),
+			WithLogLevel("info"),
+			WithSlowRequestThreshold(time.Second),
+			WithShutdownDelay(0),
+			WithGracefulShutdown(9*time.Second),
+		)(c)
+	}
+}
@@ -0,0 +1,111 @@
+package grpckit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+func TestProductionDefaults(t *testing.T) {
+	cfg := newServerConfig()
+	ProductionDefaults()(cfg)
+
+	if !cfg.healthEnabled || !cfg.grpcHealth || !cfg.metricsEnabled || !cfg.runtimeMetrics {
+		t.Error("expected health checks and metrics")
+	}
+	if cfg.corsEnabled || cfg.adminConfig != nil || cfg.swaggerEnabled || !cfg.noReflection {
+		t.Error("expected CORS, admin API, Swagger and reflection to stay off")
+	}
+	if cfg.httpTimeout != 30*time.Second || cfg.shutdownDelay != 5*time.Second || cfg.slowRequestThreshold != time.Second {
+		t.Errorf("unexpected timeouts: http %v, shutdown delay %v, slow %v", cfg.httpTimeout, cfg.shutdownDelay, cfg.slowRequestThreshold)
+	}
+}
+
+func TestInternalServiceDefaults(t *testing.T) {
+	cfg := newServerConfig()
+	InternalServiceDefaults()(cfg)
+
+	if !cfg.healthEnabled || !cfg.grpcHealth || !cfg.metricsEnabled || cfg.corsEnabled {
+		t.Error("expected health checks and metrics without CORS")
+	}
+	if cfg.httpTimeout != 10*time.Second || cfg.slowRequestThreshold != 500*time.Millisecond {
+		t.Errorf("unexpected timeouts: http %v, slow %v", cfg.httpTimeout, cfg.slowRequestThreshold)
+	}
+}
+
+func TestDevDefaults(t *testing.T) {
+	cfg := newServerConfig()
+	DevDefaults()(cfg)
+
+	if !cfg.corsEnabled || cfg.corsConfig.AllowedOrigins[0] != "*" {
+		t.Error("expected permissive CORS")
+	}
+	if !cfg.prettyJSON || !cfg.htmlTables || cfg.logLevel != "debug" {
+		t.Error("expected pretty JSON, HTML tables and debug logging")
+	}
+	if cfg.adminConfig == nil || cfg.adminConfig.token != "dev" || !cfg.adminConfig.localOnly {
+		t.Error("expected local admin API with the dev token")
+	}
+}
+
+func TestCloudRunDefaults(t *testing.T) {
+	t.Setenv("PORT", "9000")
+	cfg := newServerConfig()
+	CloudRunDefaults()(cfg)
+
+	if cfg.grpcPort != 9000 || cfg.httpPort != 9000 {
+		t.Errorf("expected single-port mode on $PORT, got gRPC %d, HTTP %d", cfg.grpcPort, cfg.httpPort)
+	}
+	if !cfg.healthEnabled || !cfg.grpcHealth || cfg.shutdownDelay != 0 || cfg.gracefulTimeout != 9*time.Second {
+		t.Errorf("unexpected health or shutdown settings: delay %v, graceful %v", cfg.shutdownDelay, cfg.gracefulTimeout)
+	}
+
+	t.Setenv("PORT", "")
+	cfg = newServerConfig()
+	CloudRunDefaults()(cfg)
+	if cfg.grpcPort != 8080 || cfg.httpPort != 8080 {
+		t.Errorf("expected default port 8080, got gRPC %d, HTTP %d", cfg.grpcPort, cfg.httpPort)
+	}
+
+	t.Setenv("PORT", "http")
+	cfg = newServerConfig()
+	CloudRunDefaults()(cfg)
+	if len(cfg.errs) != 1 {
+		t.Errorf("expected invalid PORT to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestPresets_Overridable(t *testing.T) {
+	for name, preset := range map[string]Option{
+		"production": ProductionDefaults(),
+		"internal":   InternalServiceDefaults(),
+		"dev":        DevDefaults(),
+		"cloud run":  CloudRunDefaults(),
+	} {
+		prometheus.DefaultRegisterer = prometheus.NewRegistry()
+		s, err := New(preset, WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithLogLevel("warn"), WithHTTPTimeout(time.Minute))
+		if err != nil {
+			t.Fatalf("%s: New failed: %v", name, err)
+		}
+		if s.LogLevel() != "warn" || s.cfg.httpTimeout != time.Minute {
+			t.Errorf("%s: expected later options to override the preset", name)
+		}
+	}
+}
+
+func TestPresets_Streaming(t *testing.T) {
+	captureLog(t)
+	presets := map[string]Option{
+		"production": ProductionDefaults(),
+		"internal":   InternalServiceDefaults(),
+		"cloud run":  CloudRunDefaults(),
+	}
+	for name, preset := range presets {
+		t.Run(name, func(t *testing.T) {
+			prometheus.DefaultRegisterer = prometheus.NewRegistry()
+			streamThroughHandler(t, newSlowTestServer(t, preset))
+		})
+	}
+}
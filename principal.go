@@ -0,0 +1,36 @@
+package grpckit
+
+import "context"
+
+// principalKey is the context key for the authenticated principal.
+type principalKey struct{}
+
+// ContextWithPrincipal returns a context carrying the authenticated principal
+// (user, client or service ID). Call it from the auth function so built-in
+// features such as slow request logging can attribute requests.
+//
+// Example:
+//
+//	grpckit.WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+//	    claims, err := verify(token)
+//	    if err != nil {
+//	        return nil, grpckit.ErrUnauthorized
+//	    }
+//	    return grpckit.ContextWithPrincipal(ctx, claims.Subject), nil
+//	})
+func ContextWithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// PrincipalFromContext returns the principal set with ContextWithPrincipal.
+// It falls back to a string stored under UserIDKey (as set by the mock auth
+// functions) and returns "" for unauthenticated requests.
+func PrincipalFromContext(ctx context.Context) string {
+	if p, ok := ctx.Value(principalKey{}).(string); ok {
+		return p
+	}
+	if p, ok := ctx.Value(UserIDKey).(string); ok {
+		return p
+	}
+	return ""
+}
@@ -0,0 +1,22 @@
+package grpckit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPrincipalFromContext(t *testing.T) {
+	ctx := context.Background()
+	if p := PrincipalFromContext(ctx); p != "" {
+		t.Errorf("expected no principal, got %q", p)
+	}
+
+	if p := PrincipalFromContext(context.WithValue(ctx, UserIDKey, "user-1")); p != "user-1" {
+		t.Errorf("expected UserIDKey fallback, got %q", p)
+	}
+
+	ctx = ContextWithPrincipal(context.WithValue(ctx, UserIDKey, "user-1"), "svc-billing")
+	if p := PrincipalFromContext(ctx); p != "svc-billing" {
+		t.Errorf("expected explicit principal to win, got %q", p)
+	}
+}
@@ -0,0 +1,173 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// Priority is the importance class of a request. Under load, lower
+// priorities are shed first.
+type Priority int
+
+const (
+	// PriorityBestEffort requests (e.g. bulk exports) are shed first.
+	PriorityBestEffort Priority = -1
+	// PriorityNormal is the default priority.
+	PriorityNormal Priority = 0
+	// PriorityCritical requests (e.g. payments, health checks) are shed last.
+	PriorityCritical Priority = 1
+)
+
+// String returns the priority name.
+func (p Priority) String() string {
+	switch {
+	case p >= PriorityCritical:
+		return "critical"
+	case p <= PriorityBestEffort:
+		return "best-effort"
+	default:
+		return "normal"
+	}
+}
+
+// PriorityClassifier assigns a priority to an HTTP request, e.g. based on
+// its path, the authenticated principal in r.Context(), or a header.
+type PriorityClassifier func(r *http.Request) Priority
+
+// priorityKey is the context key for the request priority.
+type priorityKey struct{}
+
+// PriorityFromContext returns the priority assigned to the request,
+// or PriorityNormal if none was assigned.
+func PriorityFromContext(ctx context.Context) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Share of the concurrency limit each priority may use. Critical requests
+// may use all slots; best-effort requests are shed once half are busy.
+const (
+	bestEffortShare = 0.5
+	normalShare     = 0.9
+)
+
+// overloadResponse is the body returned when a request is shed.
+// It matches the grpc-gateway error format (code 14 = UNAVAILABLE), with a
+// RetryInfo detail matching the Retry-After header.
+var overloadResponse = []byte(`{"code":14,"message":"server overloaded","details":[` +
+	`{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"1s"}]}`)
+
+// concurrencyLimiter admits requests while in-flight capacity for their
+// priority is available.
+type concurrencyLimiter struct {
+	max      int64
+	inflight atomic.Int64
+}
+
+// limitFor returns the number of in-flight requests at which p is shed.
+func (l *concurrencyLimiter) limitFor(p Priority) int64 {
+	switch {
+	case p >= PriorityCritical:
+		return l.max
+	case p <= PriorityBestEffort:
+		return max(1, int64(float64(l.max)*bestEffortShare))
+	default:
+		return max(1, int64(float64(l.max)*normalShare))
+	}
+}
+
+// acquire reserves a slot for a request of priority p, reporting false if it must be shed.
+func (l *concurrencyLimiter) acquire(p Priority) bool {
+	if l.inflight.Add(1) > l.limitFor(p) {
+		l.inflight.Add(-1)
+		return false
+	}
+	return true
+}
+
+// release frees a slot reserved by acquire.
+func (l *concurrencyLimiter) release() {
+	l.inflight.Add(-1)
+}
+
+// priorityMiddleware classifies requests and sheds them when the concurrency
+// limit for their priority is reached. Built-in health and metrics endpoints
+// are always critical and never shed.
+func priorityMiddleware(cfg *serverConfig, m *Metrics, next http.Handler) http.Handler {
+	var limiter *concurrencyLimiter
+	if cfg.maxConcurrentRequests > 0 {
+		limiter = &concurrencyLimiter{max: int64(cfg.maxConcurrentRequests)}
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		exempt := isMaintenanceExempt(cfg, r.URL.Path)
+
+		p := PriorityNormal
+		switch {
+		case exempt:
+			p = PriorityCritical
+		case cfg.priorityClassifier != nil:
+			p = cfg.priorityClassifier(r)
+		}
+		r = r.WithContext(context.WithValue(r.Context(), priorityKey{}, p))
+
+		if limiter == nil || exempt {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !limiter.acquire(p) {
+			if m != nil {
+				m.requestsShed.WithLabelValues(p.String()).Inc()
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write(overloadResponse)
+			return
+		}
+		defer limiter.release()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// WithRequestPriority classifies HTTP requests as critical, normal or
+// best-effort. The priority is available to handlers and middleware via
+// PriorityFromContext and is honored by WithConcurrencyLimit, which sheds
+// best-effort requests first. The classifier runs after authentication, so
+// it can inspect the principal stored in the request context.
+//
+// Example:
+//
+//	grpckit.WithRequestPriority(func(r *http.Request) grpckit.Priority {
+//	    switch {
+//	    case strings.HasPrefix(r.URL.Path, "/api/v1/payments"):
+//	        return grpckit.PriorityCritical
+//	    case strings.HasPrefix(r.URL.Path, "/api/v1/exports"):
+//	        return grpckit.PriorityBestEffort
+//	    }
+//	    return grpckit.PriorityNormal
+//	})
+func WithRequestPriority(classifier PriorityClassifier) Option {
+	return func(c *serverConfig) {
+		c.priorityClassifier = classifier
+	}
+}
+
+// WithConcurrencyLimit limits the number of HTTP requests processed at once.
+// When the limit is approached, requests are shed with 503 by priority:
+// best-effort requests may use half of the slots, normal requests 90%, and
+// critical requests all of them. Health and metrics endpoints are never shed.
+//
+// Example:
+//
+//	grpckit.WithConcurrencyLimit(500)
+func WithConcurrencyLimit(max int) Option {
+	return func(c *serverConfig) {
+		c.maxConcurrentRequests = max
+	}
+}
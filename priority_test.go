@@ -0,0 +1,180 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+)
+
+func TestPriority_String(t *testing.T) {
+	tests := map[Priority]string{
+		PriorityBestEffort: "best-effort",
+		PriorityNormal:     "normal",
+		PriorityCritical:   "critical",
+	}
+	for p, expected := range tests {
+		if got := p.String(); got != expected {
+			t.Errorf("expected %q, got %q", expected, got)
+		}
+	}
+}
+
+func TestPriorityFromContext_Default(t *testing.T) {
+	if p := PriorityFromContext(context.Background()); p != PriorityNormal {
+		t.Errorf("expected normal priority, got %s", p)
+	}
+}
+
+func TestConcurrencyLimiter_Shares(t *testing.T) {
+	l := &concurrencyLimiter{max: 10}
+
+	// Fill half the slots with normal requests
+	for i := 0; i < 5; i++ {
+		if !l.acquire(PriorityNormal) {
+			t.Fatalf("expected normal request %d to be admitted", i)
+		}
+	}
+	if l.acquire(PriorityBestEffort) {
+		t.Error("expected best-effort request to be shed at 50% utilization")
+	}
+
+	for i := 0; i < 4; i++ {
+		l.acquire(PriorityNormal)
+	}
+	if l.acquire(PriorityNormal) {
+		t.Error("expected normal request to be shed at 90% utilization")
+	}
+	if !l.acquire(PriorityCritical) {
+		t.Error("expected critical request to be admitted")
+	}
+	if l.acquire(PriorityCritical) {
+		t.Error("expected critical request to be shed at 100% utilization")
+	}
+
+	l.release()
+	if !l.acquire(PriorityCritical) {
+		t.Error("expected slot to be available after release")
+	}
+}
+
+func TestPriorityMiddleware_Classifies(t *testing.T) {
+	cfg := newServerConfig()
+	WithRequestPriority(func(r *http.Request) Priority {
+		if strings.HasPrefix(r.URL.Path, "/exports") {
+			return PriorityBestEffort
+		}
+		return PriorityCritical
+	})(cfg)
+
+	var got Priority
+	handler := priorityMiddleware(cfg, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = PriorityFromContext(r.Context())
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/exports/all", nil))
+	if got != PriorityBestEffort {
+		t.Errorf("expected best-effort, got %s", got)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/payments", nil))
+	if got != PriorityCritical {
+		t.Errorf("expected critical, got %s", got)
+	}
+}
+
+func TestPriorityMiddleware_Sheds(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := newMetrics("shed_test", prometheus.DefaultRegisterer)
+
+	cfg := newServerConfig()
+	WithConcurrencyLimit(2)(cfg)
+	WithRequestPriority(func(r *http.Request) Priority {
+		if r.URL.Path == "/exports" {
+			return PriorityBestEffort
+		}
+		return PriorityNormal
+	})(cfg)
+
+	release := make(chan struct{})
+	entered := make(chan struct{})
+	handler := priorityMiddleware(cfg, m, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			entered <- struct{}{}
+			<-release
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// Occupy one of two slots
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+		close(done)
+	}()
+	<-entered
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/exports", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected best-effort request to be shed with 503, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected Retry-After header")
+	}
+	if !strings.Contains(rec.Body.String(), `"code":14`) || !strings.Contains(rec.Body.String(), `"retryDelay":"1s"`) {
+		t.Errorf("expected gateway error body with RetryInfo, got %q", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected health check never to be shed, got %d", rec.Code)
+	}
+
+	close(release)
+	<-done
+
+	if v := testutil.ToFloat64(m.requestsShed.WithLabelValues("best-effort")); v != 1 {
+		t.Errorf("expected 1 shed best-effort request, got %v", v)
+	}
+}
+
+func TestPriorityMiddleware_ServerChain(t *testing.T) {
+	var got Priority
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithRequestPriority(func(r *http.Request) Priority { return PriorityCritical }),
+		WithHTTPHandler("/probe", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			got = PriorityFromContext(r.Context())
+		})),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	resp, err := ts.HTTPClient().Get(ts.URL("/probe"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if got != PriorityCritical {
+		t.Errorf("expected classifier to run in server chain, got %s", got)
+	}
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	cfg := newServerConfig()
+	WithConcurrencyLimit(100)(cfg)
+
+	if cfg.maxConcurrentRequests != 100 {
+		t.Errorf("expected limit 100, got %d", cfg.maxConcurrentRequests)
+	}
+}
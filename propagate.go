@@ -0,0 +1,114 @@
+package grpckit
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// defaultPropagatedKeys are incoming metadata keys forwarded by default:
+// W3C trace context and baggage.
+var defaultPropagatedKeys = []string{"traceparent", "tracestate", "baggage"}
+
+// PropagationOption configures which request-scoped values are propagated.
+type PropagationOption func(*propagationConfig)
+
+// propagationConfig holds the propagation configuration.
+type propagationConfig struct {
+	keys   []string
+	values map[string]func(ctx context.Context) string
+}
+
+// PropagateMetadata forwards the given incoming metadata keys (e.g.
+// "x-tenant-id", "accept-language") to outbound calls, in addition to the
+// request ID and trace context propagated by default.
+func PropagateMetadata(keys ...string) PropagationOption {
+	return func(c *propagationConfig) {
+		c.keys = append(c.keys, keys...)
+	}
+}
+
+// PropagateValue sends the value returned by fn as outbound metadata key,
+// for request-scoped values stored in the context rather than in metadata
+// (e.g. a tenant set by the auth function). Empty values are not sent.
+func PropagateValue(key string, fn func(ctx context.Context) string) PropagationOption {
+	return func(c *propagationConfig) {
+		c.values[key] = fn
+	}
+}
+
+// newPropagationConfig applies the options on top of the defaults.
+func newPropagationConfig(opts ...PropagationOption) *propagationConfig {
+	c := &propagationConfig{
+		keys: append([]string(nil), defaultPropagatedKeys...),
+		values: map[string]func(ctx context.Context) string{
+			requestIDMetadataKey: requestIDFromContext,
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// propagate copies the configured values into the outgoing metadata. Keys
+// already set on the outgoing context are left untouched.
+func (c *propagationConfig) propagate(ctx context.Context) context.Context {
+	out, _ := metadata.FromOutgoingContext(ctx)
+	in, _ := metadata.FromIncomingContext(ctx)
+
+	var pairs []string
+	for key, fn := range c.values {
+		if len(out.Get(key)) > 0 {
+			continue
+		}
+		if v := fn(ctx); v != "" {
+			pairs = append(pairs, key, v)
+		} else if vs := in.Get(key); len(vs) > 0 {
+			pairs = append(pairs, key, vs[0])
+		}
+	}
+	for _, key := range c.keys {
+		if len(out.Get(key)) > 0 {
+			continue
+		}
+		for _, v := range in.Get(key) {
+			pairs = append(pairs, key, v)
+		}
+	}
+
+	if len(pairs) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+// PropagationUnaryClientInterceptor returns a client interceptor that copies
+// request-scoped values of the inbound request to outbound calls: the request
+// ID, W3C trace context and baggage, plus any configured metadata keys and
+// context values. Connections created with the grpckit/client package
+// install it by default.
+//
+// Example:
+//
+//	conn, _ := grpc.NewClient(addr, grpc.WithUnaryInterceptor(
+//	    grpckit.PropagationUnaryClientInterceptor(
+//	        grpckit.PropagateMetadata("x-tenant-id", "accept-language"),
+//	    ),
+//	))
+func PropagationUnaryClientInterceptor(opts ...PropagationOption) grpc.UnaryClientInterceptor {
+	cfg := newPropagationConfig(opts...)
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(cfg.propagate(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// PropagationStreamClientInterceptor is the stream variant of
+// PropagationUnaryClientInterceptor.
+func PropagationStreamClientInterceptor(opts ...PropagationOption) grpc.StreamClientInterceptor {
+	cfg := newPropagationConfig(opts...)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(cfg.propagate(ctx), desc, cc, method, opts...)
+	}
+}
@@ -0,0 +1,101 @@
+package grpckit
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// outgoingMD returns an invoker that records the outgoing metadata.
+func outgoingMD(got *metadata.MD) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*got, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+}
+
+func TestPropagation_Defaults(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(
+		"traceparent", "00-abc-def-01",
+		"x-tenant-id", "acme",
+	))
+	ctx = contextWithRequestID(ctx, "req-1")
+
+	var md metadata.MD
+	PropagationUnaryClientInterceptor()(ctx, "/m", nil, nil, nil, outgoingMD(&md))
+
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("expected request ID to be propagated, got %v", got)
+	}
+	if got := md.Get("traceparent"); len(got) != 1 {
+		t.Errorf("expected traceparent to be propagated, got %v", got)
+	}
+	if got := md.Get("x-tenant-id"); len(got) != 0 {
+		t.Errorf("expected unconfigured key not to be propagated, got %v", got)
+	}
+}
+
+func TestPropagation_RequestIDFromMetadata(t *testing.T) {
+	// gRPC handlers without the correlation interceptor still forward the inbound ID
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestIDMetadataKey, "req-md"))
+
+	var md metadata.MD
+	PropagationUnaryClientInterceptor()(ctx, "/m", nil, nil, nil, outgoingMD(&md))
+
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-md" {
+		t.Errorf("expected request ID from incoming metadata, got %v", got)
+	}
+}
+
+func TestPropagation_ConfiguredKeysAndValues(t *testing.T) {
+	type localeKey struct{}
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-tenant-id", "acme"))
+	ctx = context.WithValue(ctx, localeKey{}, "it-IT")
+
+	interceptor := PropagationUnaryClientInterceptor(
+		PropagateMetadata("x-tenant-id"),
+		PropagateValue("x-locale", func(ctx context.Context) string {
+			v, _ := ctx.Value(localeKey{}).(string)
+			return v
+		}),
+	)
+
+	var md metadata.MD
+	interceptor(ctx, "/m", nil, nil, nil, outgoingMD(&md))
+
+	if got := md.Get("x-tenant-id"); len(got) != 1 || got[0] != "acme" {
+		t.Errorf("expected tenant to be propagated, got %v", got)
+	}
+	if got := md.Get("x-locale"); len(got) != 1 || got[0] != "it-IT" {
+		t.Errorf("expected locale value to be propagated, got %v", got)
+	}
+}
+
+func TestPropagation_KeepsExplicitMetadata(t *testing.T) {
+	ctx := contextWithRequestID(context.Background(), "inbound")
+	ctx = metadata.AppendToOutgoingContext(ctx, requestIDMetadataKey, "explicit")
+
+	var md metadata.MD
+	PropagationUnaryClientInterceptor()(ctx, "/m", nil, nil, nil, outgoingMD(&md))
+
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "explicit" {
+		t.Errorf("expected explicit metadata to be kept, got %v", got)
+	}
+}
+
+func TestPropagation_Stream(t *testing.T) {
+	ctx := contextWithRequestID(context.Background(), "req-s")
+
+	var md metadata.MD
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil, nil
+	}
+	PropagationStreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/m", streamer)
+
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-s" {
+		t.Errorf("expected request ID on stream, got %v", got)
+	}
+}
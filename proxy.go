@@ -0,0 +1,154 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// proxyErrorResponse is the body returned when the upstream is unreachable.
+// It matches the grpc-gateway error format for codes.Unavailable.
+var proxyErrorResponse = []byte(`{"code":14,"message":"upstream unavailable","details":[]}`)
+
+// ProxyOption configures WithReverseProxy.
+type ProxyOption func(*proxyConfig)
+
+// proxyConfig holds the configuration of a reverse proxy.
+type proxyConfig struct {
+	pattern         string
+	target          string
+	targetURL       *url.URL
+	transforms      []RequestTransform
+	principalHeader string
+	preserveHost    bool
+	transport       http.RoundTripper
+}
+
+// ProxyTransform rewrites requests before they are proxied, e.g. with
+// StripPrefix to remove the mount point of a legacy app.
+func ProxyTransform(transforms ...RequestTransform) ProxyOption {
+	return func(c *proxyConfig) {
+		c.transforms = append(c.transforms, transforms...)
+	}
+}
+
+// ProxyPrincipalHeader passes the authenticated principal (see
+// ContextWithPrincipal) to the upstream in the named header. Any value sent
+// by the client in that header is dropped, so the upstream can trust it.
+func ProxyPrincipalHeader(name string) ProxyOption {
+	return func(c *proxyConfig) {
+		c.principalHeader = name
+	}
+}
+
+// ProxyPreserveHost sends the client's Host header upstream instead of the
+// target host, for apps that build absolute URLs from it.
+func ProxyPreserveHost() ProxyOption {
+	return func(c *proxyConfig) {
+		c.preserveHost = true
+	}
+}
+
+// ProxyTransport sets the transport used to reach the upstream (default
+// http.DefaultTransport).
+func ProxyTransport(rt http.RoundTripper) ProxyOption {
+	return func(c *proxyConfig) {
+		c.transport = rt
+	}
+}
+
+// WithReverseProxy forwards requests matching pattern to targetURL, so a
+// legacy app can be served from the same port during a migration. Proxied
+// requests go through the global middleware chain like any WithHTTPHandler
+// handler: auth (per WithProtectedEndpoints), timeouts, metrics and access
+// logs all apply. The target path is prepended to the request path, and
+// X-Forwarded-For, X-Forwarded-Host and X-Forwarded-Proto are set.
+// WebSocket upgrades are proxied too, without the WithHTTPTimeout timeout.
+//
+// If the upstream is unreachable, clients get 502 with a gateway-style JSON
+// body and the error is logged. An invalid targetURL makes New return
+// ErrInvalidConfig.
+//
+// Example:
+//
+//	grpckit.WithReverseProxy("/admin/", "http://legacy-admin:8000",
+//	    grpckit.ProxyTransform(grpckit.StripPrefix("/admin")),
+//	    grpckit.ProxyPrincipalHeader("X-Remote-User"),
+//	)
+func WithReverseProxy(pattern, targetURL string, opts ...ProxyOption) Option {
+	cfg := &proxyConfig{pattern: pattern, target: targetURL}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(c *serverConfig) {
+		target, err := url.Parse(targetURL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			c.invalid("WithReverseProxy: target %q must be an absolute http(s) URL", targetURL)
+			return
+		}
+		cfg.targetURL = target
+		c.reverseProxies = append(c.reverseProxies, cfg)
+	}
+}
+
+// reverseProxyRoutes returns the patterns of the WithReverseProxy handlers,
+// to be checked with those of WithHTTPHandler before they are registered.
+func reverseProxyRoutes(cfg *serverConfig) []httpHandlerRegistration {
+	routes := make([]httpHandlerRegistration, 0, len(cfg.reverseProxies))
+	for _, p := range cfg.reverseProxies {
+		routes = append(routes, httpHandlerRegistration{pattern: p.pattern, owner: "WithReverseProxy"})
+	}
+	return routes
+}
+
+// registerReverseProxies registers a handler for each WithReverseProxy.
+func registerReverseProxies(s *Server) {
+	for _, p := range s.cfg.reverseProxies {
+		var handler http.Handler = reverseProxyHandler(s, p, p.targetURL)
+		if len(p.transforms) > 0 {
+			handler = transformRequest(p.transforms, handler)
+		}
+		s.cfg.httpHandlers = append(s.cfg.httpHandlers, httpHandlerRegistration{
+			pattern: p.pattern,
+			handler: handler,
+			owner:   "WithReverseProxy",
+		})
+	}
+}
+
+// reverseProxyHandler creates the httputil.ReverseProxy for a target.
+func reverseProxyHandler(s *Server, p *proxyConfig, target *url.URL) *httputil.ReverseProxy {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+			if p.preserveHost {
+				pr.Out.Host = pr.In.Host
+			}
+			if p.principalHeader != "" {
+				pr.Out.Header.Del(p.principalHeader)
+				if principal := PrincipalFromContext(pr.In.Context()); principal != "" {
+					pr.Out.Header.Set(p.principalHeader, principal)
+				}
+			}
+		},
+		Transport: p.transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			// Client went away; there is no one to answer
+			if errors.Is(err, context.Canceled) {
+				return
+			}
+			s.logger.Error("upstream error", "component", "proxy",
+				"target", p.target,
+				"method", r.Method,
+				"path", r.URL.Path,
+				"request_id", requestIDFromContext(r.Context()),
+				"error", err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write(proxyErrorResponse)
+		},
+	}
+}
@@ -0,0 +1,191 @@
+package grpckit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+// newProxyTestHandler returns the HTTP handler chain of a server, with a
+// gateway answering 404 for unproxied paths.
+func newProxyTestHandler(t *testing.T, opts ...Option) http.Handler {
+	t.Helper()
+	return newSlowTestServer(t, opts...).buildHTTPHandler(http.NotFoundHandler())
+}
+
+func TestWithReverseProxy(t *testing.T) {
+	var got *http.Request
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		_, _ = w.Write([]byte("legacy"))
+	}))
+	defer upstream.Close()
+
+	handler := newProxyTestHandler(t,
+		WithAuth(MockAuthFunc("token", "admin-7")),
+		WithReverseProxy("/admin/", upstream.URL+"/app",
+			ProxyTransform(StripPrefix("/admin")),
+			ProxyPrincipalHeader("X-Remote-User"),
+		),
+	)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/users?page=2", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	req.Header.Set("X-Remote-User", "spoofed")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Body.String() != "legacy" {
+		t.Fatalf("expected proxied response, got %d %q", rec.Code, rec.Body.String())
+	}
+	if got.URL.Path != "/app/users" || got.URL.RawQuery != "page=2" {
+		t.Errorf("unexpected upstream URL %q", got.URL.String())
+	}
+	if got.Header.Get("X-Remote-User") != "admin-7" {
+		t.Errorf("expected principal header, got %q", got.Header.Get("X-Remote-User"))
+	}
+	if got.Header.Get("X-Forwarded-Host") != "example.com" {
+		t.Errorf("expected X-Forwarded-Host, got %v", got.Header)
+	}
+	if got.Host == "example.com" {
+		t.Errorf("expected target host by default, got %q", got.Host)
+	}
+
+	// Auth applies to proxied paths
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/users", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", rec.Code)
+	}
+}
+
+func TestWithReverseProxy_PreserveHost(t *testing.T) {
+	var host string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+	}))
+	defer upstream.Close()
+
+	handler := newProxyTestHandler(t, WithReverseProxy("/legacy/", upstream.URL, ProxyPreserveHost()))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/legacy/", nil))
+	if host != "example.com" {
+		t.Errorf("expected client host, got %q", host)
+	}
+}
+
+func TestWithReverseProxy_UpstreamDown(t *testing.T) {
+	buf := captureLog(t)
+	upstream := httptest.NewServer(http.NotFoundHandler())
+	upstream.Close()
+
+	handler := newProxyTestHandler(t, WithReverseProxy("/legacy/", upstream.URL))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/legacy/x", nil))
+
+	if rec.Code != http.StatusBadGateway || !strings.Contains(rec.Body.String(), `"code":14`) {
+		t.Errorf("expected 502 with gateway body, got %d %q", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(buf.String(), `msg="upstream error" component=proxy`) {
+		t.Errorf("expected upstream error to be logged, got %q", buf.String())
+	}
+}
+
+func TestWithReverseProxy_InvalidTarget(t *testing.T) {
+	for _, target := range []string{"legacy:8000", "ftp://legacy", "http://", "://bad"} {
+		_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithReverseProxy("/legacy/", target))
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%q: expected ErrInvalidConfig, got %v", target, err)
+		}
+	}
+}
+
+func TestWithReverseProxy_PatternConflicts(t *testing.T) {
+	tests := map[string][]Option{
+		"duplicate handler": {WithHTTPHandlerFunc("/legacy/", func(w http.ResponseWriter, r *http.Request) {}), WithReverseProxy("/legacy/", "http://127.0.0.1:9")},
+		"duplicate proxy":   {WithReverseProxy("/legacy/", "http://127.0.0.1:9"), WithReverseProxy("/legacy/", "http://127.0.0.1:10")},
+		"built-in path":     {WithMetrics(), WithReverseProxy("/metrics", "http://127.0.0.1:9")},
+		"empty pattern":     {WithReverseProxy("", "http://127.0.0.1:9")},
+	}
+	for name, opts := range tests {
+		prometheus.DefaultRegisterer = prometheus.NewRegistry()
+		opts = append(opts, WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if _, err := New(opts...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
+
+func TestWithReverseProxy_WebSocketUpgrade(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+
+	// An upstream switching to a line echo protocol
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		conn, brw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			t.Errorf("upstream hijack: %v", err)
+			return
+		}
+		defer conn.Close()
+		brw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+		brw.Flush()
+		for {
+			line, err := brw.ReadString('\n')
+			if err != nil {
+				return
+			}
+			brw.WriteString("echo: " + line)
+			brw.Flush()
+		}
+	}))
+	defer upstream.Close()
+
+	srv := httptest.NewServer(newProxyTestHandler(t,
+		WithMetrics(),
+		WithAccessLog(),
+		WithHTTPTimeout(50*time.Millisecond),
+		WithReverseProxy("/ws/", upstream.URL),
+	))
+	defer srv.Close()
+
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	fmt.Fprintf(conn, "GET /ws/chat HTTP/1.1\r\nHost: test\r\nConnection: Upgrade\r\nUpgrade: websocket\r\n\r\n")
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatalf("reading response: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	// The connection outlives the HTTP timeout
+	for _, msg := range []string{"hello", "again"} {
+		fmt.Fprintf(conn, "%s\n", msg)
+		if line, err := reader.ReadString('\n'); err != nil || line != "echo: "+msg+"\n" {
+			t.Fatalf("expected echoed message, got %q, %v", line, err)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	if out := buf.String(); strings.Contains(out, "hijacked") {
+		t.Errorf("unexpected write to the hijacked connection: %s", out)
+	}
+}
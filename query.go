@@ -0,0 +1,343 @@
+package grpckit
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// QueryArrayFormat selects how repeated fields are read from query strings.
+type QueryArrayFormat int
+
+const (
+	// QueryArrayRepeat reads repeated keys: ids=1&ids=2 (default).
+	QueryArrayRepeat QueryArrayFormat = iota
+
+	// QueryArrayComma also splits comma-separated values: ids=1,2.
+	// Values of repeated string fields can then not contain commas.
+	QueryArrayComma
+
+	// QueryArrayBrackets also reads bracket keys: ids[]=1&ids[]=2 or
+	// ids[0]=1&ids[1]=2.
+	QueryArrayBrackets
+)
+
+// UnknownQueryParams selects how query parameters that match no field of
+// the request message are handled.
+type UnknownQueryParams int
+
+const (
+	// UnknownQueryIgnore silently ignores them, like grpc-gateway (default).
+	UnknownQueryIgnore UnknownQueryParams = iota
+
+	// UnknownQueryLog logs them as a warning and ignores them, to find
+	// misbehaving clients before rejecting their requests.
+	UnknownQueryLog
+
+	// UnknownQueryReject answers 400 Bad Request listing them, so clients
+	// notice typos such as page_szie.
+	UnknownQueryReject
+)
+
+// QueryOptions configures how GET query strings bind to request messages.
+// Repeated keys (ids=1&ids=2), dotted paths (filter.name=x), map entries
+// (labels[env]=prod) and both proto (page_size) and JSON (pageSize) field
+// names are always accepted.
+type QueryOptions struct {
+	// ArrayFormat additionally accepts another representation of repeated
+	// fields. Default: QueryArrayRepeat.
+	ArrayFormat QueryArrayFormat
+
+	// DeepObjects binds bracket paths to nested messages, as emitted by
+	// qs-style frontend libraries: filter[name]=x&filter[owner][id]=5 is read
+	// as filter.name=x&filter.owner.id=5.
+	DeepObjects bool
+
+	// LooseFieldNames also binds keys matching a field's proto or JSON name
+	// when ignoring case, '_' and '-': PageSize, page-size and PAGE_SIZE all
+	// bind to page_size, and page_size to a field declared as pageSize. Keys
+	// matching several fields this way are ignored.
+	LooseFieldNames bool
+
+	// UnknownParams selects how parameters matching no field are handled.
+	// Default: UnknownQueryIgnore.
+	UnknownParams UnknownQueryParams
+}
+
+// WithQueryOptions configures query parameter binding for REST endpoints,
+// e.g. to accept the bracket syntax of frontend query-string libraries.
+//
+// Example:
+//
+//	grpckit.WithQueryOptions(grpckit.QueryOptions{
+//	    ArrayFormat: grpckit.QueryArrayBrackets, // ids[]=1&ids[]=2
+//	    DeepObjects: true,                       // filter[owner][id]=5
+//	})
+func WithQueryOptions(opts QueryOptions) Option {
+	return func(c *serverConfig) {
+		c.queryOptions = &opts
+	}
+}
+
+// The grpc-gateway query parser is process-wide and isn't given the request,
+// so a dispatching parser is installed once, and each server's gateway
+// handler registers its parser for the query values of the requests it
+// serves. Requests of servers without WithQueryOptions use the default
+// parser.
+var (
+	queryParsers       sync.Map // query values identity -> *queryParser
+	installQueryParser sync.Once
+)
+
+// dispatchQueryParser parses query values with the parser registered for
+// them by queryOptionsMiddleware.
+type dispatchQueryParser struct{}
+
+// Parse implements runtime.QueryParameterParser.
+func (dispatchQueryParser) Parse(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	if p, ok := queryParsers.Load(queryValuesKey(values)); ok {
+		return p.(*queryParser).Parse(msg, values, filter)
+	}
+	return (&runtime.DefaultQueryParser{}).Parse(msg, values, filter)
+}
+
+// queryValuesKey identifies a request's query values, which the generated
+// gateway handlers pass to the parser as is (req.Form).
+func queryValuesKey(values url.Values) uintptr {
+	return reflect.ValueOf(values).Pointer()
+}
+
+// queryOptionsMiddleware makes the gateway parse the query strings of the
+// requests served by next with p. It must wrap the gateway mux directly, as
+// the parser is looked up by the request's form values.
+func queryOptionsMiddleware(p *queryParser, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Form == nil {
+			if r.Header.Get("X-HTTP-Method-Override") != "" {
+				// Path length fallback: the gateway reads the query from
+				// the form body
+				if err := r.ParseForm(); err != nil {
+					http.Error(w, "invalid form body", http.StatusBadRequest)
+					return
+				}
+			} else {
+				// Leave bodies to the marshalers, which may decode forms
+				r.Form = r.URL.Query()
+			}
+		}
+		key := queryValuesKey(r.Form)
+		queryParsers.Store(key, p)
+		defer queryParsers.Delete(key)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// queryParser normalizes query strings to the default grpc-gateway form
+// before parsing them.
+type queryParser struct {
+	opts   QueryOptions
+	logger *slog.Logger
+}
+
+// Parse implements runtime.QueryParameterParser.
+func (p *queryParser) Parse(msg proto.Message, values url.Values, filter *utilities.DoubleArray) error {
+	md := msg.ProtoReflect().Descriptor()
+	normalized := make(url.Values, len(values))
+	var unknown []string
+	for key, vals := range values {
+		k, vs := p.normalize(md, key, vals)
+		normalized[k] = append(normalized[k], vs...)
+		if p.opts.UnknownParams != UnknownQueryIgnore && !p.known(md, k) {
+			unknown = append(unknown, key)
+		}
+	}
+
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		switch p.opts.UnknownParams {
+		case UnknownQueryLog:
+			p.logger.Warn("ignoring unknown query parameters", "component", "gateway",
+				"message", md.FullName(), "params", strings.Join(unknown, ","))
+		case UnknownQueryReject:
+			fields := make([]FieldError, len(unknown))
+			for i, key := range unknown {
+				fields[i] = FieldError{Field: key, Reason: "unknown query parameter"}
+			}
+			return &ValidationError{Fields: fields}
+		}
+	}
+	return (&runtime.DefaultQueryParser{}).Parse(msg, normalized, filter)
+}
+
+// known reports whether a normalized query key binds to a field of md.
+// Map entries and free-form types accept any nested key.
+func (p *queryParser) known(md protoreflect.MessageDescriptor, key string) bool {
+	segments, _ := splitQueryKey(key)
+	for i, seg := range segments {
+		fd := p.field(md, seg)
+		switch {
+		case fd == nil:
+			return false
+		case fd.IsMap() || i == len(segments)-1:
+			return true
+		case fd.Message() == nil || fd.IsList():
+			return false
+		case freeFormTypes[fd.Message().FullName()]:
+			return true
+		}
+		md = fd.Message()
+	}
+	return false
+}
+
+// normalize rewrites a query key and its values to the default form. Keys
+// that do not resolve to a field are returned unchanged.
+func (p *queryParser) normalize(md protoreflect.MessageDescriptor, key string, vals []string) (string, []string) {
+	segments, dotted := splitQueryKey(key)
+	brackets := p.opts.DeepObjects || p.opts.ArrayFormat == QueryArrayBrackets
+	if len(segments) > dotted && !brackets {
+		return key, vals
+	}
+
+	for i := 0; i < len(segments); i++ {
+		fd := p.field(md, segments[i])
+		if fd == nil {
+			return key, vals
+		}
+		segments[i] = string(fd.Name())
+		path := strings.Join(segments[:i+1], ".")
+		rest := segments[i+1:]
+
+		switch {
+		case fd.IsMap():
+			if len(rest) != 1 || i+1 < dotted {
+				return key, vals
+			}
+			return path + "[" + rest[0] + "]", vals
+		case fd.IsList():
+			if len(rest) == 1 && p.opts.ArrayFormat == QueryArrayBrackets && isArrayIndex(rest[0]) {
+				rest = nil
+			}
+			if len(rest) > 0 {
+				return key, vals
+			}
+			if p.opts.ArrayFormat == QueryArrayComma && fd.Message() == nil {
+				vals = splitCommaValues(vals)
+			}
+			return path, vals
+		case fd.Message() != nil && len(rest) > 0:
+			// Bracket segments only descend into messages with DeepObjects
+			if i+1 >= dotted && !p.opts.DeepObjects {
+				return key, vals
+			}
+			md = fd.Message()
+		default:
+			if len(rest) > 0 {
+				return key, vals
+			}
+			return path, vals
+		}
+	}
+	return key, vals
+}
+
+// field resolves a key segment to a field of md by proto or JSON name, then,
+// with LooseFieldNames, ignoring case and separators.
+func (p *queryParser) field(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+	if fd := fields.ByJSONName(name); fd != nil {
+		return fd
+	}
+	if !p.opts.LooseFieldNames {
+		return nil
+	}
+
+	loose := looseFieldName(name)
+	var match protoreflect.FieldDescriptor
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if looseFieldName(string(fd.Name())) != loose && looseFieldName(fd.JSONName()) != loose {
+			continue
+		}
+		if match != nil {
+			return nil // ambiguous
+		}
+		match = fd
+	}
+	return match
+}
+
+// looseFieldName lowercases name and drops '_' and '-' separators.
+func looseFieldName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, name)
+}
+
+// splitQueryKey splits "a.b[c][d]" into [a b c d], also returning the number
+// of dotted segments (2). Malformed bracket keys yield a single segment.
+func splitQueryKey(key string) ([]string, int) {
+	base, brackets, found := strings.Cut(key, "[")
+	segments := strings.Split(base, ".")
+	dotted := len(segments)
+	if !found {
+		return segments, dotted
+	}
+	for {
+		name, tail, ok := strings.Cut(brackets, "]")
+		if !ok {
+			return []string{key}, 1
+		}
+		segments = append(segments, name)
+		if tail == "" {
+			return segments, dotted
+		}
+		if !strings.HasPrefix(tail, "[") {
+			return []string{key}, 1
+		}
+		brackets = tail[1:]
+	}
+}
+
+// isArrayIndex reports whether s is an empty or numeric bracket segment.
+func isArrayIndex(s string) bool {
+	if s == "" {
+		return true
+	}
+	_, err := strconv.ParseUint(s, 10, 32)
+	return err == nil
+}
+
+// splitCommaValues splits comma-separated values, dropping empty items.
+// Values without items are kept for the parser to report.
+func splitCommaValues(vals []string) []string {
+	var out []string
+	for _, v := range vals {
+		for _, item := range strings.Split(v, ",") {
+			if item != "" {
+				out = append(out, item)
+			}
+		}
+	}
+	if len(out) == 0 {
+		return vals
+	}
+	return out
+}
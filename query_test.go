@@ -0,0 +1,243 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/utilities"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func parseQuery(t *testing.T, opts QueryOptions, msg proto.Message, query string) error {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		t.Fatalf("invalid query %q: %v", query, err)
+	}
+	p := &queryParser{opts: opts, logger: defaultLogger}
+	return p.Parse(msg, values, utilities.NewDoubleArray(nil))
+}
+
+func TestQueryParser_Repeat(t *testing.T) {
+	msg := &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{}, msg, "path=1&path=2&leading_comments=x"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(msg.Path, []int32{1, 2}) || msg.GetLeadingComments() != "x" {
+		t.Errorf("unexpected message %v", msg)
+	}
+}
+
+func TestQueryParser_Comma(t *testing.T) {
+	msg := &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{ArrayFormat: QueryArrayComma}, msg, "path=1,2&path=3&leadingComments=a,b"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !reflect.DeepEqual(msg.Path, []int32{1, 2, 3}) {
+		t.Errorf("expected comma-separated values to be split, got %v", msg.Path)
+	}
+	if msg.GetLeadingComments() != "a,b" {
+		t.Errorf("expected singular field to keep commas, got %q", msg.GetLeadingComments())
+	}
+}
+
+func TestQueryParser_Brackets(t *testing.T) {
+	tests := []string{
+		"path[]=1&path[]=2",
+		"path[0]=1&path[1]=2",
+	}
+	for _, query := range tests {
+		msg := &descriptorpb.SourceCodeInfo_Location{}
+		if err := parseQuery(t, QueryOptions{ArrayFormat: QueryArrayBrackets}, msg, query); err != nil {
+			t.Fatalf("%s: Parse failed: %v", query, err)
+		}
+		if len(msg.Path) != 2 {
+			t.Errorf("%s: expected 2 values, got %v", query, msg.Path)
+		}
+	}
+
+	// Without the option, bracket keys keep the default map semantics
+	msg := &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{}, msg, "path[]=1"); err == nil {
+		t.Error("expected default parser to reject bracket arrays")
+	}
+}
+
+func TestQueryParser_DeepObjects(t *testing.T) {
+	msg := &descriptorpb.DescriptorProto{}
+	query := "name=Item&options[deprecated]=true&options[features][field_presence]=EXPLICIT"
+	if err := parseQuery(t, QueryOptions{DeepObjects: true}, msg, query); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if !msg.GetOptions().GetDeprecated() {
+		t.Error("expected options.deprecated to be bound")
+	}
+	if msg.GetOptions().GetFeatures().GetFieldPresence() != descriptorpb.FeatureSet_EXPLICIT {
+		t.Errorf("expected nested bracket path to be bound, got %v", msg.GetOptions())
+	}
+
+	// Bracket paths do not descend into messages without DeepObjects
+	msg = &descriptorpb.DescriptorProto{}
+	if err := parseQuery(t, QueryOptions{ArrayFormat: QueryArrayBrackets}, msg, "options[deprecated]=true"); err == nil {
+		t.Error("expected bracket message path to be rejected without DeepObjects")
+	}
+}
+
+func TestQueryParser_Maps(t *testing.T) {
+	msg := &structpb.Struct{}
+	if err := parseQuery(t, QueryOptions{DeepObjects: true}, msg, `fields[env]="prod"`); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if got := msg.Fields["env"].GetStringValue(); got != "prod" {
+		t.Errorf("expected map entry, got %v", msg.Fields)
+	}
+}
+
+func TestQueryParser_LooseFieldNames(t *testing.T) {
+	const query = "LeadingComments=a&trailing-comments=b&LEADING_DETACHED_COMMENTS=c&Path=1"
+
+	msg := &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{LooseFieldNames: true}, msg, query); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if msg.GetLeadingComments() != "a" || msg.GetTrailingComments() != "b" ||
+		!reflect.DeepEqual(msg.LeadingDetachedComments, []string{"c"}) || !reflect.DeepEqual(msg.Path, []int32{1}) {
+		t.Errorf("unexpected message %v", msg)
+	}
+
+	// Without the option only proto and JSON names bind
+	msg = &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{}, msg, query+"&leadingComments=x"); err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if msg.GetLeadingComments() != "x" || msg.TrailingComments != nil || msg.Path != nil {
+		t.Errorf("unexpected message %v", msg)
+	}
+}
+
+func TestQueryParser_UnknownParams(t *testing.T) {
+	const query = "path=1&leading_coments=x&options.packed=1"
+
+	// Ignored by default
+	if err := parseQuery(t, QueryOptions{}, &descriptorpb.SourceCodeInfo_Location{}, query); err != nil {
+		t.Errorf("expected unknown parameters to be ignored, got %v", err)
+	}
+
+	buf := captureLog(t)
+	msg := &descriptorpb.SourceCodeInfo_Location{}
+	if err := parseQuery(t, QueryOptions{UnknownParams: UnknownQueryLog}, msg, query); err != nil {
+		t.Errorf("expected unknown parameters to be logged only, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "message=google.protobuf.SourceCodeInfo.Location params=leading_coments,options.packed") || !reflect.DeepEqual(msg.Path, []int32{1}) {
+		t.Errorf("unexpected log %q or message %v", buf.String(), msg)
+	}
+
+	err := parseQuery(t, QueryOptions{UnknownParams: UnknownQueryReject}, &descriptorpb.SourceCodeInfo_Location{}, query)
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 || verr.Fields[0].Field != "leading_coments" {
+		t.Errorf("expected validation error for both parameters, got %v", err)
+	}
+}
+
+func TestQueryParser_UnknownParamsKnownKeys(t *testing.T) {
+	opts := QueryOptions{UnknownParams: UnknownQueryReject, LooseFieldNames: true}
+	for _, query := range []string{"name=x&options.packed=1&jsonName=y", "Default-Value=1"} {
+		if err := parseQuery(t, opts, &descriptorpb.FieldDescriptorProto{}, query); err != nil {
+			t.Errorf("%q: unexpected error %v", query, err)
+		}
+	}
+	if err := parseQuery(t, opts, &descriptorpb.FieldDescriptorProto{}, "name.first=x"); err == nil {
+		t.Error("expected nested key on a scalar field to be unknown")
+	}
+	if err := parseQuery(t, QueryOptions{UnknownParams: UnknownQueryReject, DeepObjects: true}, &structpb.Struct{}, "fields[env]=1"); err != nil {
+		t.Errorf("expected map entries to be known, got %v", err)
+	}
+}
+
+func TestLooseFieldName(t *testing.T) {
+	for _, name := range []string{"page_size", "pageSize", "PageSize", "page-size", "PAGE_SIZE"} {
+		if got := looseFieldName(name); got != "pagesize" {
+			t.Errorf("looseFieldName(%q) = %q, want pagesize", name, got)
+		}
+	}
+}
+
+func TestSplitQueryKey(t *testing.T) {
+	tests := []struct {
+		key      string
+		segments []string
+		dotted   int
+	}{
+		{"a", []string{"a"}, 1},
+		{"a.b", []string{"a", "b"}, 2},
+		{"a[b][c]", []string{"a", "b", "c"}, 1},
+		{"a.b[]", []string{"a", "b", ""}, 2},
+		{"a[b", []string{"a[b"}, 1},
+		{"a[b]c", []string{"a[b]c"}, 1},
+	}
+	for _, tt := range tests {
+		segments, dotted := splitQueryKey(tt.key)
+		if !reflect.DeepEqual(segments, tt.segments) || dotted != tt.dotted {
+			t.Errorf("%s: expected %v/%d, got %v/%d", tt.key, tt.segments, tt.dotted, segments, dotted)
+		}
+	}
+}
+
+func TestQueryOptionsMiddleware_PerServer(t *testing.T) {
+	// serve parses the query like a generated gateway handler
+	serve := func(opts *QueryOptions, query string) error {
+		var err error
+		var h http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err = r.ParseForm(); err == nil {
+				err = dispatchQueryParser{}.Parse(&descriptorpb.SourceCodeInfo_Location{}, r.Form, utilities.NewDoubleArray(nil))
+			}
+		})
+		if opts != nil {
+			h = queryOptionsMiddleware(&queryParser{opts: *opts, logger: defaultLogger}, h)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/?"+query, nil))
+		return err
+	}
+
+	if err := serve(&QueryOptions{ArrayFormat: QueryArrayBrackets}, "path[]=1&path[]=2"); err != nil {
+		t.Errorf("expected bracket arrays to bind, got %v", err)
+	}
+	if err := serve(&QueryOptions{ArrayFormat: QueryArrayComma}, "path[]=1&path[]=2"); err == nil {
+		t.Error("expected another server's options not to apply")
+	}
+	// Servers without options use the default parser
+	if err := serve(nil, "path=1,2"); err == nil {
+		t.Error("expected the default parser without options")
+	}
+	if err := serve(&QueryOptions{ArrayFormat: QueryArrayComma}, "path=1,2"); err != nil {
+		t.Errorf("expected comma arrays to bind, got %v", err)
+	}
+	// The query of path length fallback requests is the form body
+	var got url.Values
+	h := queryOptionsMiddleware(&queryParser{logger: defaultLogger}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Form
+	}))
+	req := httptest.NewRequest(http.MethodPost, "/?a=1", strings.NewReader("b=2"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-HTTP-Method-Override", http.MethodGet)
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if got.Get("a") != "1" || got.Get("b") != "2" {
+		t.Errorf("expected query and form body values, got %v", got)
+	}
+}
+
+func TestWithQueryOptions(t *testing.T) {
+	cfg := newServerConfig()
+	WithQueryOptions(QueryOptions{ArrayFormat: QueryArrayComma})(cfg)
+
+	if cfg.queryOptions == nil || cfg.queryOptions.ArrayFormat != QueryArrayComma {
+		t.Errorf("expected query options to be set, got %+v", cfg.queryOptions)
+	}
+}
@@ -0,0 +1,299 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit/quota"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Quota response metadata keys, forwarded by the gateway as HTTP headers.
+const (
+	quotaLimitKey     = "x-ratelimit-limit"
+	quotaRemainingKey = "x-ratelimit-remaining"
+	quotaResetKey     = "x-ratelimit-reset"
+)
+
+// QuotaOption configures per-principal quotas.
+type QuotaOption func(*quotaConfig)
+
+// quotaConfig holds configuration for per-principal quotas.
+type quotaConfig struct {
+	store   quota.Store
+	limits  []quotaLimit
+	keyFunc func(ctx context.Context) string
+	now     func() time.Time
+}
+
+// quotaLimit allows a number of requests per fixed window.
+type quotaLimit struct {
+	requests int64
+	window   time.Duration
+}
+
+// QuotaLimit allows n requests per window for each principal. Windows are
+// fixed and aligned to the Unix epoch (a 24h window resets at midnight UTC).
+// Multiple limits may be combined; a request must fit within all of them.
+func QuotaLimit(n int64, window time.Duration) QuotaOption {
+	return func(c *quotaConfig) {
+		if n > 0 && window > 0 {
+			c.limits = append(c.limits, quotaLimit{requests: n, window: window})
+		}
+	}
+}
+
+// QuotaKey sets the function deriving the quota key from the authenticated
+// context, e.g. to share one quota across all users of a tenant. Requests
+// for which it returns "" are not limited.
+// Default: PrincipalFromContext
+func QuotaKey(fn func(ctx context.Context) string) QuotaOption {
+	return func(c *quotaConfig) {
+		c.keyFunc = fn
+	}
+}
+
+// WithPrincipalQuota limits the number of unary calls each authenticated
+// principal (see ContextWithPrincipal) may make, with counters kept in store.
+// Use quota.NewMemoryStore for a single instance, or a Redis-backed
+// quota.Store to share counters across instances.
+//
+// Quotas are enforced by a gRPC interceptor running after authentication, so
+// they cover both gRPC calls and REST calls through the gateway.
+// Unauthenticated calls, streaming calls and plain HTTP handlers are not
+// limited. Successful calls carry X-RateLimit-Limit, X-RateLimit-Remaining
+// and X-RateLimit-Reset (seconds) for the most restrictive limit, as gRPC
+// response headers and HTTP headers. Calls over quota fail with
+// codes.ResourceExhausted (HTTP 429) and QuotaFailure and RetryInfo details;
+// REST responses also carry Retry-After. If the store fails, calls are
+// allowed and the error is logged.
+//
+// With WithAdminAPI, GET /admin/quotas?principal=<key> shows a principal's
+// usage and POST /admin/quotas/reset?principal=<key> resets it.
+//
+// Example:
+//
+//	grpckit.WithPrincipalQuota(quota.NewMemoryStore(),
+//	    grpckit.QuotaLimit(100, time.Minute),
+//	    grpckit.QuotaLimit(10000, 24*time.Hour),
+//	)
+func WithPrincipalQuota(store quota.Store, opts ...QuotaOption) Option {
+	return func(c *serverConfig) {
+		cfg := &quotaConfig{
+			store:   store,
+			keyFunc: PrincipalFromContext,
+			now:     time.Now,
+		}
+		for _, opt := range opts {
+			opt(cfg)
+		}
+		c.quota = cfg
+	}
+}
+
+// QuotaUsage describes a principal's usage of one quota limit.
+type QuotaUsage struct {
+	Limit     int64
+	Window    time.Duration
+	Used      int64
+	Remaining int64
+	Reset     time.Time
+}
+
+// QuotaUsage returns the current usage of each quota limit for key.
+func (s *Server) QuotaUsage(ctx context.Context, key string) ([]QuotaUsage, error) {
+	q := s.cfg.quota
+	if q == nil {
+		return nil, fmt.Errorf("%w: quotas not configured", ErrInvalidConfig)
+	}
+
+	now := q.now()
+	usage := make([]QuotaUsage, 0, len(q.limits))
+	for _, l := range q.limits {
+		storeKey, reset := l.current(key, now)
+		used, err := q.store.Get(ctx, storeKey)
+		if err != nil {
+			return nil, err
+		}
+		usage = append(usage, QuotaUsage{
+			Limit:     l.requests,
+			Window:    l.window,
+			Used:      used,
+			Remaining: max(l.requests-used, 0),
+			Reset:     reset,
+		})
+	}
+	return usage, nil
+}
+
+// ResetQuota clears the current usage of every quota limit for key.
+func (s *Server) ResetQuota(ctx context.Context, key string) error {
+	q := s.cfg.quota
+	if q == nil {
+		return fmt.Errorf("%w: quotas not configured", ErrInvalidConfig)
+	}
+
+	now := q.now()
+	for _, l := range q.limits {
+		storeKey, _ := l.current(key, now)
+		if err := q.store.Delete(ctx, storeKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// current returns the store key and reset time of the window containing now.
+func (l quotaLimit) current(key string, now time.Time) (string, time.Time) {
+	index := now.UnixNano() / int64(l.window)
+	reset := time.Unix(0, (index+1)*int64(l.window))
+	return fmt.Sprintf("grpckit:quota:%s:%s:%d", key, l.window, index), reset
+}
+
+// quotaResult is the outcome of counting a call against all limits.
+type quotaResult struct {
+	limit     int64
+	remaining int64
+	resetIn   time.Duration
+	exceeded  *quotaLimit
+}
+
+// take counts a call for key against every limit. The reported limit is the
+// most restrictive one; when several limits are exceeded, the one resetting
+// last is reported so clients do not retry too early.
+func (q *quotaConfig) take(ctx context.Context, key string) (quotaResult, error) {
+	now := q.now()
+	var result quotaResult
+	for i, l := range q.limits {
+		storeKey, reset := l.current(key, now)
+		used, err := q.store.Incr(ctx, storeKey, reset.Sub(now))
+		if err != nil {
+			return quotaResult{}, err
+		}
+		remaining := l.requests - used
+		if remaining < 0 {
+			if result.exceeded == nil || reset.Sub(now) > result.resetIn {
+				result = quotaResult{limit: l.requests, remaining: 0, resetIn: reset.Sub(now), exceeded: &q.limits[i]}
+			}
+			continue
+		}
+		if result.exceeded == nil && (i == 0 || remaining < result.remaining) {
+			result = quotaResult{limit: l.requests, remaining: remaining, resetIn: reset.Sub(now)}
+		}
+	}
+	return result, nil
+}
+
+// quotaUnaryInterceptor enforces per-principal quotas on unary calls.
+func quotaUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	q := s.cfg.quota
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		key := q.keyFunc(ctx)
+		if key == "" || len(q.limits) == 0 {
+			return handler(ctx, req)
+		}
+
+		result, err := q.take(ctx, key)
+		if err != nil {
+			s.logger.Warn("quota store failed, allowing request", "component", "quota", "method", info.FullMethod, "error", err)
+			return handler(ctx, req)
+		}
+
+		resetIn := result.resetIn
+		_ = grpc.SetHeader(ctx, metadata.Pairs(
+			quotaLimitKey, strconv.FormatInt(result.limit, 10),
+			quotaRemainingKey, strconv.FormatInt(result.remaining, 10),
+			quotaResetKey, strconv.FormatInt(ceilSeconds(resetIn), 10),
+		))
+
+		s.metrics.observeTenantQuota(ctx, result.exceeded != nil)
+		if l := result.exceeded; l != nil {
+			st, _ := status.New(codes.ResourceExhausted, "quota exceeded").WithDetails(
+				&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{
+					Subject:     "principal:" + key,
+					Description: fmt.Sprintf("%d requests per %s", l.requests, l.window),
+				}}},
+				&errdetails.RetryInfo{RetryDelay: durationpb.New(resetIn)},
+			)
+			return nil, st.Err()
+		}
+		return handler(ctx, req)
+	}
+}
+
+// ceilSeconds rounds d up to whole seconds, as used by HTTP rate limit headers.
+func ceilSeconds(d time.Duration) int64 {
+	if d <= 0 {
+		return 0
+	}
+	return int64((d + time.Second - 1) / time.Second)
+}
+
+// quotaHeaderNames maps quota metadata keys to HTTP response headers.
+var quotaHeaderNames = map[string]string{
+	quotaLimitKey:     "X-RateLimit-Limit",
+	quotaRemainingKey: "X-RateLimit-Remaining",
+	quotaResetKey:     "X-RateLimit-Reset",
+}
+
+// quotaHeadersResponseOption copies quota metadata set by the gRPC service
+// into plain X-RateLimit-* headers on gateway responses.
+func quotaHeadersResponseOption(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+	setQuotaHeaders(ctx, w)
+	return nil
+}
+
+// setQuotaHeaders replaces forwarded Grpc-Metadata-X-Ratelimit-* headers
+// with their X-RateLimit-* names.
+func setQuotaHeaders(ctx context.Context, w http.ResponseWriter) {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return
+	}
+	for key, name := range quotaHeaderNames {
+		values := md.HeaderMD.Get(key)
+		if len(values) == 0 {
+			values = md.TrailerMD.Get(key)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		// The error handler forwards metadata after this runs; drop it from
+		// the metadata so only the plain header is sent
+		delete(md.HeaderMD, key)
+		delete(md.TrailerMD, key)
+		w.Header().Del(runtime.MetadataHeaderPrefix + key)
+		w.Header().Set(name, values[0])
+	}
+}
+
+// setRetryAfter sets Retry-After from a RetryInfo detail of a
+// ResourceExhausted or Unavailable error, e.g. an exceeded quota or an open
+// circuit breaker.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	st, ok := status.FromError(err)
+	if !ok || (st.Code() != codes.ResourceExhausted && st.Code() != codes.Unavailable) {
+		return
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.RetryInfo); ok && info.GetRetryDelay() != nil {
+			w.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(info.GetRetryDelay().AsDuration()), 10))
+			return
+		}
+	}
+}
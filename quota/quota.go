@@ -0,0 +1,146 @@
+// Package quota defines the counter storage used by grpckit's per-principal
+// quotas (see grpckit.WithPrincipalQuota).
+//
+// MemoryStore keeps counters in process memory, which is enough for a single
+// instance. Implement Store on top of Redis (or any store with atomic
+// increments and expiry) to share quotas across instances and restarts.
+//
+// Example Redis adapter (using go-redis v9):
+//
+//	type RedisStore struct {
+//	    client *redis.Client
+//	}
+//
+//	func (s *RedisStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+//	    pipe := s.client.TxPipeline()
+//	    incr := pipe.Incr(ctx, key)
+//	    pipe.ExpireNX(ctx, key, ttl)
+//	    if _, err := pipe.Exec(ctx); err != nil {
+//	        return 0, err
+//	    }
+//	    return incr.Val(), nil
+//	}
+//
+//	func (s *RedisStore) Get(ctx context.Context, key string) (int64, error) {
+//	    n, err := s.client.Get(ctx, key).Int64()
+//	    if errors.Is(err, redis.Nil) {
+//	        return 0, nil
+//	    }
+//	    return n, err
+//	}
+//
+//	func (s *RedisStore) Delete(ctx context.Context, key string) error {
+//	    return s.client.Del(ctx, key).Err()
+//	}
+package quota
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store persists quota counters. Implementations must be safe for concurrent
+// use; Incr must be atomic across all instances sharing the store.
+type Store interface {
+	// Incr adds one to the counter stored under key and returns the new
+	// value. A missing counter is created with the given time to live.
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+
+	// Get returns the value of the counter stored under key, or 0 if it does
+	// not exist or has expired.
+	Get(ctx context.Context, key string) (int64, error)
+
+	// Delete removes the counter stored under key. Deleting a missing
+	// counter is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore is an in-memory Store for single-instance deployments and tests.
+type MemoryStore struct {
+	mu       sync.Mutex
+	counters map[string]*counter
+	sweepAt  int
+	now      func() time.Time
+}
+
+// counter is a single expiring counter.
+type counter struct {
+	value   int64
+	expires time.Time
+}
+
+// minSweep is the number of counters below which expired ones are not swept.
+const minSweep = 1024
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		counters: make(map[string]*counter),
+		sweepAt:  minSweep,
+		now:      time.Now,
+	}
+}
+
+// SetClock sets the function returning the current time, which decides
+// when counters expire, e.g. to a fake clock's Now in tests. Call it before
+// using the store.
+func (m *MemoryStore) SetClock(now func() time.Time) {
+	m.now = now
+}
+
+// Incr adds one to the counter stored under key.
+func (m *MemoryStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c, ok := m.counters[key]
+	if !ok || !now.Before(c.expires) {
+		c = &counter{expires: now.Add(ttl)}
+		m.counters[key] = c
+		m.sweep(now)
+	}
+	c.value++
+	return c.value, nil
+}
+
+// Get returns the value of the counter stored under key.
+func (m *MemoryStore) Get(ctx context.Context, key string) (int64, error) {
+	now := m.now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if c, ok := m.counters[key]; ok && now.Before(c.expires) {
+		return c.value, nil
+	}
+	return 0, nil
+}
+
+// Delete removes the counter stored under key.
+func (m *MemoryStore) Delete(ctx context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.counters, key)
+	return nil
+}
+
+// sweep removes expired counters once the map has grown past sweepAt, so
+// memory stays proportional to the number of active counters.
+func (m *MemoryStore) sweep(now time.Time) {
+	if len(m.counters) < m.sweepAt {
+		return
+	}
+	for key, c := range m.counters {
+		if !now.Before(c.expires) {
+			delete(m.counters, key)
+		}
+	}
+	m.sweepAt = 2 * len(m.counters)
+	if m.sweepAt < minSweep {
+		m.sweepAt = minSweep
+	}
+}
@@ -0,0 +1,70 @@
+package quota
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_IncrAndExpiry(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	for want := int64(1); want <= 3; want++ {
+		got, err := m.Incr(ctx, "user-1", time.Minute)
+		if err != nil || got != want {
+			t.Fatalf("Incr: expected %d, got %d (%v)", want, got, err)
+		}
+	}
+	if got, _ := m.Get(ctx, "user-1"); got != 3 {
+		t.Errorf("Get: expected 3, got %d", got)
+	}
+	if got, _ := m.Get(ctx, "user-2"); got != 0 {
+		t.Errorf("Get: expected 0 for missing counter, got %d", got)
+	}
+
+	// The TTL is set when the counter is created, not extended by Incr
+	now = now.Add(time.Minute)
+	if got, _ := m.Get(ctx, "user-1"); got != 0 {
+		t.Errorf("expected expired counter to read 0, got %d", got)
+	}
+	if got, _ := m.Incr(ctx, "user-1", time.Minute); got != 1 {
+		t.Errorf("expected expired counter to restart at 1, got %d", got)
+	}
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	m := NewMemoryStore()
+
+	_, _ = m.Incr(ctx, "user-1", time.Minute)
+	if err := m.Delete(ctx, "user-1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if got, _ := m.Get(ctx, "user-1"); got != 0 {
+		t.Errorf("expected deleted counter to read 0, got %d", got)
+	}
+	if err := m.Delete(ctx, "missing"); err != nil {
+		t.Errorf("expected deleting a missing counter to succeed, got %v", err)
+	}
+}
+
+func TestMemoryStore_Sweep(t *testing.T) {
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	m := NewMemoryStore()
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < minSweep-1; i++ {
+		_, _ = m.Incr(ctx, "old-"+strconv.Itoa(i), time.Second)
+	}
+	now = now.Add(time.Minute)
+	_, _ = m.Incr(ctx, "new", time.Minute)
+
+	if n := len(m.counters); n != 1 {
+		t.Errorf("expected expired counters to be swept, got %d", n)
+	}
+}
@@ -0,0 +1,315 @@
+package grpckit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit/quota"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// failingQuotaStore is a quota.Store whose operations always fail.
+type failingQuotaStore struct{}
+
+func (failingQuotaStore) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	return 0, errors.New("store down")
+}
+
+func (failingQuotaStore) Get(ctx context.Context, key string) (int64, error) {
+	return 0, errors.New("store down")
+}
+
+func (failingQuotaStore) Delete(ctx context.Context, key string) error {
+	return errors.New("store down")
+}
+
+func newQuotaTestServer(t *testing.T, store quota.Store, opts ...QuotaOption) *Server {
+	t.Helper()
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithPrincipalQuota(store, opts...),
+		WithAdminAPI(AdminToken("admin-secret")),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	// Fix the clock 30s into a minute window
+	s.cfg.quota.now = func() time.Time { return time.Unix(1800, 0).Add(30 * time.Second) }
+	return s
+}
+
+func callWithQuota(s *Server, principal string) error {
+	interceptor := quotaUnaryInterceptor(s)
+	ctx := context.Background()
+	if principal != "" {
+		ctx = ContextWithPrincipal(ctx, principal)
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/GetItem"}
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "ok", nil
+	})
+	return err
+}
+
+func TestQuotaUnaryInterceptor_Exceeded(t *testing.T) {
+	s := newQuotaTestServer(t, quota.NewMemoryStore(), QuotaLimit(2, time.Minute), QuotaLimit(100, 24*time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if err := callWithQuota(s, "user-1"); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i+1, err)
+		}
+	}
+	err := callWithQuota(s, "user-1")
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	var failure *errdetails.QuotaFailure
+	var retry *errdetails.RetryInfo
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.QuotaFailure:
+			failure = d
+		case *errdetails.RetryInfo:
+			retry = d
+		}
+	}
+	if failure == nil || failure.Violations[0].Subject != "principal:user-1" || failure.Violations[0].Description != "2 requests per 1m0s" {
+		t.Errorf("unexpected QuotaFailure detail: %v", failure)
+	}
+	if retry == nil || retry.RetryDelay.AsDuration() != 30*time.Second {
+		t.Errorf("expected RetryInfo of 30s, got %v", retry)
+	}
+
+	// Other principals have their own counters
+	if err := callWithQuota(s, "user-2"); err != nil {
+		t.Errorf("expected user-2 to be allowed, got %v", err)
+	}
+}
+
+func TestQuotaUnaryInterceptor_Unauthenticated(t *testing.T) {
+	s := newQuotaTestServer(t, quota.NewMemoryStore(), QuotaLimit(1, time.Minute))
+
+	for i := 0; i < 3; i++ {
+		if err := callWithQuota(s, ""); err != nil {
+			t.Fatalf("expected unauthenticated calls not to be limited, got %v", err)
+		}
+	}
+}
+
+func TestQuotaUnaryInterceptor_StoreErrorAllows(t *testing.T) {
+	buf := captureLog(t)
+	s := newQuotaTestServer(t, failingQuotaStore{}, QuotaLimit(1, time.Minute))
+
+	if err := callWithQuota(s, "user-1"); err != nil {
+		t.Fatalf("expected call to be allowed on store error, got %v", err)
+	}
+	if buf.Len() == 0 {
+		t.Error("expected store error to be logged")
+	}
+}
+
+func TestQuotaKey(t *testing.T) {
+	s := newQuotaTestServer(t, quota.NewMemoryStore(),
+		QuotaLimit(1, time.Minute),
+		QuotaKey(func(ctx context.Context) string { return "tenant-a" }),
+	)
+
+	if err := callWithQuota(s, "user-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := callWithQuota(s, "user-2"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected tenant quota to be shared, got %v", err)
+	}
+}
+
+func TestQuotaTake_MostRestrictive(t *testing.T) {
+	s := newQuotaTestServer(t, quota.NewMemoryStore(), QuotaLimit(10, time.Minute), QuotaLimit(3, time.Hour))
+
+	result, err := s.cfg.quota.take(context.Background(), "user-1")
+	if err != nil {
+		t.Fatalf("take failed: %v", err)
+	}
+	if result.limit != 3 || result.remaining != 2 || result.exceeded != nil {
+		t.Errorf("expected hourly limit with 2 remaining, got %+v", result)
+	}
+	// 1800s+30s into the hour: the hourly window resets at 3600s
+	if result.resetIn != 1770*time.Second {
+		t.Errorf("expected reset in 1770s, got %v", result.resetIn)
+	}
+}
+
+func TestServer_QuotaUsageAndReset(t *testing.T) {
+	ctx := context.Background()
+	s := newQuotaTestServer(t, quota.NewMemoryStore(), QuotaLimit(5, time.Minute))
+
+	for i := 0; i < 2; i++ {
+		_ = callWithQuota(s, "user-1")
+	}
+	usage, err := s.QuotaUsage(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("QuotaUsage failed: %v", err)
+	}
+	if len(usage) != 1 || usage[0].Used != 2 || usage[0].Remaining != 3 || usage[0].Window != time.Minute {
+		t.Errorf("unexpected usage: %+v", usage)
+	}
+	if !usage[0].Reset.Equal(time.Unix(1860, 0)) {
+		t.Errorf("expected reset at the end of the minute window, got %v", usage[0].Reset)
+	}
+
+	if err := s.ResetQuota(ctx, "user-1"); err != nil {
+		t.Fatalf("ResetQuota failed: %v", err)
+	}
+	if usage, _ := s.QuotaUsage(ctx, "user-1"); usage[0].Used != 0 {
+		t.Errorf("expected usage to be reset, got %+v", usage)
+	}
+}
+
+func TestServer_QuotaNotConfigured(t *testing.T) {
+	s := newSlowTestServer(t)
+
+	if _, err := s.QuotaUsage(context.Background(), "user-1"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+	if err := s.ResetQuota(context.Background(), "user-1"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestAdmin_Quotas(t *testing.T) {
+	s := newQuotaTestServer(t, quota.NewMemoryStore(), QuotaLimit(5, time.Minute))
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+	_ = callWithQuota(s, "user-1")
+
+	rec := adminRequest(handler, http.MethodGet, "/admin/quotas?principal=user-1", "admin-secret")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var body struct {
+		Principal string `json:"principal"`
+		Limits    []struct {
+			Limit  int64  `json:"limit"`
+			Window string `json:"window"`
+			Used   int64  `json:"used"`
+		} `json:"limits"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if body.Principal != "user-1" || len(body.Limits) != 1 || body.Limits[0].Used != 1 || body.Limits[0].Window != "1m0s" {
+		t.Errorf("unexpected response: %s", rec.Body.String())
+	}
+
+	if rec := adminRequest(handler, http.MethodGet, "/admin/quotas", "admin-secret"); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without principal, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/admin/quotas/reset?principal=user-1", "admin-secret"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET reset, got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodPost, "/admin/quotas/reset?principal=user-1", "admin-secret"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for reset, got %d", rec.Code)
+	}
+	if usage, _ := s.QuotaUsage(context.Background(), "user-1"); usage[0].Used != 0 {
+		t.Errorf("expected admin reset to clear usage, got %+v", usage)
+	}
+}
+
+func TestAdmin_QuotasNotConfigured(t *testing.T) {
+	_, handler := newAdminTestServer(t, AdminToken("admin-secret"))
+
+	if rec := adminRequest(handler, http.MethodGet, "/admin/quotas?principal=user-1", "admin-secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 without quotas, got %d", rec.Code)
+	}
+}
+
+func TestQuotaHeadersResponseOption(t *testing.T) {
+	md := runtime.ServerMetadata{HeaderMD: metadata.Pairs(quotaRemainingKey, "7", quotaLimitKey, "10")}
+	ctx := runtime.NewServerMetadataContext(context.Background(), md)
+	rec := httptest.NewRecorder()
+	rec.Header().Set(runtime.MetadataHeaderPrefix+quotaRemainingKey, "7")
+
+	if err := quotaHeadersResponseOption(ctx, rec, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "7" {
+		t.Errorf("expected X-RateLimit-Remaining 7, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "10" {
+		t.Errorf("expected X-RateLimit-Limit 10, got %q", got)
+	}
+	if got := rec.Header().Get(runtime.MetadataHeaderPrefix + quotaRemainingKey); got != "" {
+		t.Errorf("expected forwarded metadata header to be removed, got %q", got)
+	}
+}
+
+func TestGatewayErrorHandler_QuotaExceeded(t *testing.T) {
+	st, _ := status.New(codes.ResourceExhausted, "quota exceeded").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(1500 * time.Millisecond)},
+	)
+	md := runtime.ServerMetadata{HeaderMD: metadata.Pairs(quotaRemainingKey, "0")}
+	ctx := runtime.NewServerMetadataContext(context.Background(), md)
+	rec := httptest.NewRecorder()
+
+	gatewayErrorHandler(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, rec, httptest.NewRequest(http.MethodGet, "/v1/items", nil), st.Err())
+
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected X-RateLimit-Remaining 0, got %q", got)
+	}
+	if got := rec.Header().Get(runtime.MetadataHeaderPrefix + quotaRemainingKey); got != "" {
+		t.Errorf("expected forwarded metadata header not to be sent, got %q", got)
+	}
+}
+
+func TestGatewayErrorHandler_CircuitOpen(t *testing.T) {
+	now := time.Unix(0, 0)
+	cb := newTestBreaker(&now)
+	interceptor := cb.UnaryClientInterceptor()
+	for i := 0; i < 4; i++ {
+		interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, invokeWith(status.Error(codes.Unavailable, "down")))
+	}
+	now = now.Add(2500 * time.Millisecond)
+	err := interceptor(context.Background(), "/test.Service/Get", nil, nil, nil, invokeWith(nil))
+
+	rec := httptest.NewRecorder()
+	gatewayErrorHandler(context.Background(), runtime.NewServeMux(), &runtime.JSONPb{}, rec, httptest.NewRequest(http.MethodGet, "/v1/items", nil), err)
+
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "8" {
+		t.Errorf("expected Retry-After 8, got %q", got)
+	}
+}
+
+func TestCeilSeconds(t *testing.T) {
+	cases := map[time.Duration]int64{
+		-time.Second:            0,
+		0:                       0,
+		time.Millisecond:        1,
+		time.Second:             1,
+		1001 * time.Millisecond: 2,
+	}
+	for d, want := range cases {
+		if got := ceilSeconds(d); got != want {
+			t.Errorf("ceilSeconds(%v): expected %d, got %d", d, want, got)
+		}
+	}
+}
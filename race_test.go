@@ -0,0 +1,7 @@
+//go:build race
+
+package grpckit
+
+// raceEnabled reports whether tests run with the race detector, which
+// makes sync.Pool drop items and skews allocation counts.
+const raceEnabled = true
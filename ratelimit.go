@@ -0,0 +1,413 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// rateLimitedMetadataKey carries, on gateway calls, the limits the REST
+// request was already checked against.
+const rateLimitedMetadataKey = "x-grpckit-rate-limited"
+
+// RateLimitKeyFunc returns the key of a request, such as a client IP, a
+// principal or an API key, for which requests are limited together.
+// Requests for which it returns "" are not limited. The context carries the
+// client address (peer.FromContext) and the HTTP headers or gRPC metadata
+// (metadata.FromIncomingContext) for both protocols, and the principal set
+// by the auth function.
+type RateLimitKeyFunc func(ctx context.Context) string
+
+// RateLimitByIP keys requests by client IP. The address of the connection
+// is used, not X-Forwarded-For, so behind a load balancer it is only
+// meaningful if client IPs are preserved.
+func RateLimitByIP(ctx context.Context) string {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return hostOnly(p.Addr.String())
+	}
+	return ""
+}
+
+// RateLimitByPrincipal keys requests by authenticated principal (see
+// ContextWithPrincipal). Unauthenticated requests are not limited.
+func RateLimitByPrincipal(ctx context.Context) string {
+	return PrincipalFromContext(ctx)
+}
+
+// RateLimitByAPIKey keys requests by the value of an HTTP header or gRPC
+// metadata key, such as "X-API-Key". Requests without it are not limited.
+func RateLimitByAPIKey(header string) RateLimitKeyFunc {
+	return func(ctx context.Context) string {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(header); len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+}
+
+// RateLimitConfig configures a rate limit.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the sustained rate allowed per key.
+	RequestsPerSecond float64
+
+	// Burst is the number of requests a key may make at once, after being
+	// idle.
+	// Default: RequestsPerSecond rounded up, at least 1
+	Burst int
+
+	// KeyFunc returns the key requests are limited by.
+	// Default: RateLimitByIP
+	KeyFunc RateLimitKeyFunc
+
+	// Patterns are the HTTP paths and gRPC methods limited, with the syntax
+	// of WithPublicEndpoints.
+	// Default: all requests but the built-in endpoints (health checks,
+	// metrics, ...)
+	Patterns []string
+}
+
+// WithRateLimit limits the rate of requests per key with a token bucket:
+// each key may make Burst requests at once, refilled at RequestsPerSecond.
+// HTTP requests are matched by path, gRPC calls by method. REST calls
+// through the gateway are matched by both their path and the gRPC method
+// handling them, and always keyed by the REST client rather than the
+// gateway; a limit matching both is counted once. Requests over the limit
+// are rejected with 429 Too Many Requests and Retry-After, or
+// codes.ResourceExhausted with a RetryInfo detail for gRPC.
+//
+// WithRateLimit may be used several times, e.g. for a global limit per IP
+// and a stricter one on expensive endpoints; a request must fit within
+// every limit matching it. Limits run after authentication, so they can be
+// keyed by principal. Buckets are kept in memory, per server instance; use
+// WithPrincipalQuota for counters shared across instances.
+//
+// Example:
+//
+//	grpckit.WithRateLimit(grpckit.RateLimitConfig{RequestsPerSecond: 50, Burst: 100}),
+//	grpckit.WithRateLimit(grpckit.RateLimitConfig{
+//	    RequestsPerSecond: 1,
+//	    Burst:             5,
+//	    KeyFunc:           grpckit.RateLimitByAPIKey("X-API-Key"),
+//	    Patterns:          []string{"/api/v1/reports/**", "/report.v1.ReportService/*"},
+//	}),
+func WithRateLimit(config RateLimitConfig) Option {
+	return func(c *serverConfig) {
+		if config.RequestsPerSecond <= 0 || math.IsInf(config.RequestsPerSecond, 0) || math.IsNaN(config.RequestsPerSecond) {
+			c.invalid("WithRateLimit: invalid RequestsPerSecond %v", config.RequestsPerSecond)
+			return
+		}
+		if config.Burst < 0 {
+			c.invalid("WithRateLimit: negative Burst %d", config.Burst)
+			return
+		}
+		if config.Burst == 0 {
+			config.Burst = max(int(math.Ceil(config.RequestsPerSecond)), 1)
+		}
+		if config.KeyFunc == nil {
+			config.KeyFunc = RateLimitByIP
+		}
+		l := &rateLimiter{
+			index:   len(c.rateLimits),
+			rate:    config.RequestsPerSecond,
+			burst:   float64(config.Burst),
+			keyFunc: config.KeyFunc,
+			buckets: make(map[string]*tokenBucket),
+		}
+		if len(config.Patterns) > 0 {
+			l.paths = c.compilePatterns("WithRateLimit", config.Patterns)
+		}
+		c.rateLimits = append(c.rateLimits, l)
+	}
+}
+
+// rateLimiter is a token bucket per key.
+type rateLimiter struct {
+	index   int
+	rate    float64
+	burst   float64
+	keyFunc RateLimitKeyFunc
+	paths   *pathmatch.Matcher // nil for all requests
+	exempt  *pathmatch.Matcher // built-in endpoints, without paths
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// tokenBucket holds the tokens of a key as of last.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// matches reports whether the limit applies to the path or method.
+func (l *rateLimiter) matches(pathOrMethod string) bool {
+	if l.paths == nil {
+		return !l.exempt.Match(pathOrMethod)
+	}
+	return l.paths.Match(pathOrMethod)
+}
+
+// exemptBuiltins keeps limits without patterns off the built-in endpoints,
+// so probes and scrapes are never rejected.
+func exemptBuiltins(cfg *serverConfig) {
+	if len(cfg.rateLimits) == 0 {
+		return
+	}
+	exempt := pathmatch.Compile(append(publicBuiltinPatterns(cfg), "/grpc.health.v1.Health/*")...)
+	for _, l := range cfg.rateLimits {
+		if l.paths == nil {
+			l.exempt = exempt
+		}
+	}
+}
+
+// take takes a token for key, returning how long to wait before retrying if
+// there is none.
+func (l *rateLimiter) take(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.tokens+elapsed.Seconds()*l.rate, l.burst)
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// sweep drops the buckets refilled since their last request, which are the
+// same as new ones, so idle keys don't accumulate. It runs at most once per
+// refill time, with a minimum of a minute.
+func (l *rateLimiter) sweep(now time.Time) {
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	if now.Sub(l.lastSweep) < max(refill, time.Minute) {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimitedKey is the context key of the rateLimitedRequest of an HTTP
+// request.
+type rateLimitedKey struct{}
+
+// rateLimitedRequest is what the gateway needs to check the limits of the
+// gRPC method a REST request is forwarded to, keyed by the REST client
+// rather than the gateway's own loopback connection.
+type rateLimitedRequest struct {
+	checked    map[int]bool // limits checked by path
+	remoteAddr string
+	header     http.Header
+}
+
+// newGatewayToken returns a secret identifying gateway calls to the gRPC
+// server, so clients can't skip checks by sending the metadata.
+func newGatewayToken() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// takeRateLimits takes a token from every limit matching pathOrMethod,
+// except those in skip. It returns how long to wait before retrying if a
+// limit is exceeded, the longest if several are.
+func (s *Server) takeRateLimits(ctx context.Context, pathOrMethod string, skip map[int]bool) (bool, time.Duration) {
+	allowed, wait := true, time.Duration(0)
+	now := s.now()
+	for _, l := range s.cfg.rateLimits {
+		if skip[l.index] || !l.matches(pathOrMethod) {
+			continue
+		}
+		key := l.keyFunc(ctx)
+		if key == "" {
+			continue
+		}
+		if ok, retry := l.take(key, now); !ok {
+			allowed, wait = false, max(wait, retry)
+		}
+	}
+	return allowed, wait
+}
+
+// remoteAddr is the net.Addr of an HTTP client.
+type remoteAddr string
+
+func (a remoteAddr) Network() string { return "tcp" }
+func (a remoteAddr) String() string  { return string(a) }
+
+// rateLimitKeyContext returns the context key functions see for an HTTP
+// request: its client address as peer and its headers as metadata, like a
+// gRPC call.
+func rateLimitKeyContext(ctx context.Context, addr string, header http.Header) context.Context {
+	md := make(metadata.MD, len(header))
+	for name, values := range header {
+		md[strings.ToLower(name)] = values
+	}
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: remoteAddr(addr)})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// rateLimitMiddleware limits HTTP requests.
+func rateLimitMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowed, wait := s.takeRateLimits(rateLimitKeyContext(r.Context(), r.RemoteAddr, r.Header), r.URL.Path, nil)
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.FormatInt(max(ceilSeconds(wait), 1), 10))
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		req := &rateLimitedRequest{checked: make(map[int]bool), remoteAddr: r.RemoteAddr, header: r.Header}
+		for _, l := range s.cfg.rateLimits {
+			if l.matches(r.URL.Path) {
+				req.checked[l.index] = true
+			}
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), rateLimitedKey{}, req)))
+	})
+}
+
+// gatewayRateLimit checks, on the gateway side, the limits of method not
+// already checked by path, keyed by the REST client, and tells the gRPC
+// server which limits the call was checked against.
+func (s *Server) gatewayRateLimit(ctx context.Context, method string) (context.Context, error) {
+	req, ok := ctx.Value(rateLimitedKey{}).(*rateLimitedRequest)
+	if !ok {
+		return ctx, nil
+	}
+	keyCtx := rateLimitKeyContext(ctx, req.remoteAddr, req.header)
+	if allowed, wait := s.takeRateLimits(keyCtx, method, req.checked); !allowed {
+		return ctx, rateLimitError(wait)
+	}
+	var checked []string
+	for _, l := range s.cfg.rateLimits {
+		if req.checked[l.index] || l.matches(method) {
+			checked = append(checked, strconv.Itoa(l.index))
+		}
+	}
+	return metadata.AppendToOutgoingContext(ctx, rateLimitedMetadataKey, s.rateLimitToken+":"+strings.Join(checked, ",")), nil
+}
+
+// rateLimitClientUnaryInterceptor applies gatewayRateLimit to unary
+// gateway calls.
+func (s *Server) rateLimitClientUnaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ctx, err := s.gatewayRateLimit(ctx, method)
+	if err != nil {
+		return err
+	}
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// rateLimitClientStreamInterceptor applies gatewayRateLimit to streaming
+// gateway calls.
+func (s *Server) rateLimitClientStreamInterceptor(
+	ctx context.Context,
+	desc *grpc.StreamDesc,
+	cc *grpc.ClientConn,
+	method string,
+	streamer grpc.Streamer,
+	opts ...grpc.CallOption,
+) (grpc.ClientStream, error) {
+	ctx, err := s.gatewayRateLimit(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+	return streamer(ctx, desc, cc, method, opts...)
+}
+
+// gatewayCheckedLimits returns the limits the gateway checked a call
+// against already. Clients may send the key too (as a Grpc-Metadata-*
+// header through the gateway), so only a value with the token counts.
+func (s *Server) gatewayCheckedLimits(ctx context.Context) map[int]bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(rateLimitedMetadataKey) {
+		token, checked, _ := strings.Cut(value, ":")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(s.rateLimitToken)) != 1 {
+			continue
+		}
+		skip := make(map[int]bool)
+		for _, index := range strings.Split(checked, ",") {
+			if i, err := strconv.Atoi(index); err == nil {
+				skip[i] = true
+			}
+		}
+		return skip
+	}
+	return nil
+}
+
+// rateLimitError is the error of a gRPC call over a rate limit.
+func rateLimitError(wait time.Duration) error {
+	st, _ := status.New(codes.ResourceExhausted, "rate limit exceeded").WithDetails(
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(max(wait, time.Second))},
+	)
+	return st.Err()
+}
+
+// rateLimitUnaryInterceptor limits unary calls.
+func rateLimitUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if allowed, wait := s.takeRateLimits(ctx, info.FullMethod, s.gatewayCheckedLimits(ctx)); !allowed {
+			return nil, rateLimitError(wait)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rateLimitStreamInterceptor limits streaming calls.
+func rateLimitStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := ss.Context()
+		if allowed, wait := s.takeRateLimits(ctx, info.FullMethod, s.gatewayCheckedLimits(ctx)); !allowed {
+			return rateLimitError(wait)
+		}
+		return handler(srv, ss)
+	}
+}
@@ -0,0 +1,230 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestRateLimiter_Take(t *testing.T) {
+	s := newSlowTestServer(t, WithRateLimit(RateLimitConfig{RequestsPerSecond: 2, Burst: 3}))
+	l := s.cfg.rateLimits[0]
+	now := time.Unix(1800, 0)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.take("a", now); !ok {
+			t.Fatalf("request %d: expected burst to be allowed", i+1)
+		}
+	}
+	ok, wait := l.take("a", now)
+	if ok || wait != 500*time.Millisecond {
+		t.Fatalf("expected rejection with 500ms wait, got %v, %v", ok, wait)
+	}
+	if ok, _ := l.take("b", now); !ok {
+		t.Error("expected other key to be allowed")
+	}
+	if ok, _ := l.take("a", now.Add(500*time.Millisecond)); !ok {
+		t.Error("expected a token after 500ms")
+	}
+
+	// Idle buckets are dropped
+	l.take("c", now.Add(2*time.Minute))
+	if _, ok := l.buckets["a"]; ok || len(l.buckets) != 1 {
+		t.Errorf("expected idle buckets to be swept, got %d", len(l.buckets))
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1800, 0))
+	s := newSlowTestServer(t, WithClock(clock), WithHealthCheck(), WithRateLimit(RateLimitConfig{RequestsPerSecond: 0.5}))
+	handler := rateLimitMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Value(rateLimitedKey{}).(*rateLimitedRequest); !ok {
+			t.Error("expected request to be marked as checked")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path, addr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = addr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("/api/v1/items", "203.0.113.7:1234"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	rec := serve("/api/v1/items", "203.0.113.7:5678")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After 2, got %q", got)
+	}
+	if rec := serve("/api/v1/items", "198.51.100.1:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for another client, got %d", rec.Code)
+	}
+	if rec := serve("/healthz", "203.0.113.7:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected health checks to be exempt, got %d", rec.Code)
+	}
+
+	clock.Advance(2 * time.Second)
+	if rec := serve("/api/v1/items", "203.0.113.7:1234"); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 after refill, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitByAPIKey_HTTP(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-API-Key", "k1")
+	ctx := rateLimitKeyContext(req.Context(), req.RemoteAddr, req.Header)
+	if got := RateLimitByAPIKey("X-API-Key")(ctx); got != "k1" {
+		t.Errorf("expected k1, got %q", got)
+	}
+	if got := RateLimitByIP(ctx); got != "192.0.2.1" {
+		t.Errorf("expected 192.0.2.1, got %q", got)
+	}
+}
+
+func TestRateLimit_GatewayMethod(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1800, 0))
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, itempb.UnimplementedItemServiceServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+		WithClock(clock),
+		WithRateLimit(RateLimitConfig{RequestsPerSecond: 1, Patterns: []string{"/item.v1.ItemService/GetItem"}}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Shutdown()
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	serve := func(addr string, header ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/items/1", nil)
+		req.RemoteAddr = addr
+		for i := 0; i < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// The method limit is keyed by the REST client, not the gateway
+	if rec := serve("203.0.113.7:1234"); rec.Code == http.StatusTooManyRequests {
+		t.Fatalf("expected first call to be allowed, got %d", rec.Code)
+	}
+	rec := serve("203.0.113.7:5678")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "1" {
+		t.Errorf("expected 429 with Retry-After, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := serve("198.51.100.1:1234"); rec.Code == http.StatusTooManyRequests {
+		t.Errorf("expected another client to have its own bucket, got %d", rec.Code)
+	}
+
+	// Forged metadata neither skips the limit nor counts the call twice
+	clock.Advance(time.Second)
+	if rec := serve("192.0.2.9:1234", "Grpc-Metadata-X-Grpckit-Rate-Limited", "forged:0"); rec.Code == http.StatusTooManyRequests {
+		t.Errorf("expected call with forged metadata to be counted once, got %d", rec.Code)
+	}
+	if rec := serve("192.0.2.9:1234", "Grpc-Metadata-X-Grpckit-Rate-Limited", "forged:0"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected forged metadata not to skip the limit, got %d", rec.Code)
+	}
+}
+
+func TestRateLimitUnaryInterceptor(t *testing.T) {
+	clock := NewFakeClock(time.Unix(1800, 0))
+	s := newSlowTestServer(t, WithClock(clock), WithRateLimit(RateLimitConfig{
+		RequestsPerSecond: 1,
+		KeyFunc:           RateLimitByAPIKey("x-api-key"),
+		Patterns:          []string{"/report.v1.ReportService/*"},
+	}))
+	call := func(method string, pairs ...string) error {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(append([]string{"x-api-key", "k1"}, pairs...)...))
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, err := rateLimitUnaryInterceptor(s)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		return err
+	}
+
+	if err := call("/report.v1.ReportService/Build"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	err := call("/report.v1.ReportService/Build")
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	var retry *errdetails.RetryInfo
+	for _, d := range status.Convert(err).Details() {
+		if info, ok := d.(*errdetails.RetryInfo); ok {
+			retry = info
+		}
+	}
+	if retry == nil || retry.GetRetryDelay().AsDuration() != time.Second {
+		t.Errorf("expected 1s RetryInfo, got %v", retry)
+	}
+	if err := call("/item.v1.ItemService/GetItem"); err != nil {
+		t.Errorf("unexpected error for unmatched method: %v", err)
+	}
+
+	// Gateway calls checked by the HTTP middleware are not counted again,
+	// unless the token is wrong
+	if err := call("/report.v1.ReportService/Build", rateLimitedMetadataKey, s.rateLimitToken+":0"); err != nil {
+		t.Errorf("expected gateway call to skip the limit, got %v", err)
+	}
+	if err := call("/report.v1.ReportService/Build", rateLimitedMetadataKey, "forged:0"); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected forged metadata to be ignored, got %v", err)
+	}
+}
+
+func TestRateLimitStreamInterceptor(t *testing.T) {
+	s := newSlowTestServer(t, WithClock(NewFakeClock(time.Unix(1800, 0))), WithRateLimit(RateLimitConfig{
+		RequestsPerSecond: 1,
+		KeyFunc:           RateLimitByPrincipal,
+	}))
+	stream := &contextServerStream{ctx: ContextWithPrincipal(context.Background(), "alice")}
+	info := &grpc.StreamServerInfo{FullMethod: "/item.v1.ItemService/Watch"}
+	call := func() error {
+		return rateLimitStreamInterceptor(s)(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+			return nil
+		})
+	}
+	if err := call(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := call(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted, got %v", err)
+	}
+}
+
+func TestWithRateLimit_Invalid(t *testing.T) {
+	tests := map[string]RateLimitConfig{
+		"zero rate":      {},
+		"negative burst": {RequestsPerSecond: 1, Burst: -1},
+		"bad pattern":    {RequestsPerSecond: 1, Patterns: []string{"/api/[*"}},
+	}
+	for name, config := range tests {
+		_, err := New(WithRateLimit(config), WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// rolesKey is the context key for the caller's roles.
+type rolesKey struct{}
+
+// ContextWithRoles returns a context carrying the roles (or OAuth scopes)
+// of the authenticated caller. Call it from the auth function so WithRBAC
+// can authorize requests.
+//
+// Example:
+//
+//	grpckit.WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+//	    claims, err := verify(token)
+//	    if err != nil {
+//	        return nil, grpckit.ErrUnauthorized
+//	    }
+//	    ctx = grpckit.ContextWithPrincipal(ctx, claims.Subject)
+//	    return grpckit.ContextWithRoles(ctx, strings.Fields(claims.Scope)...), nil
+//	})
+func ContextWithRoles(ctx context.Context, roles ...string) context.Context {
+	return context.WithValue(ctx, rolesKey{}, roles)
+}
+
+// RolesFromContext returns the roles set with ContextWithRoles.
+func RolesFromContext(ctx context.Context) []string {
+	roles, _ := ctx.Value(rolesKey{}).([]string)
+	return roles
+}
+
+// HasRole reports whether the caller has role.
+func HasRole(ctx context.Context, role string) bool {
+	return slices.Contains(RolesFromContext(ctx), role)
+}
+
+// RBACRule requires roles on the endpoints matching its patterns.
+type RBACRule struct {
+	// Patterns are HTTP paths ("/api/v1/admin/**") or gRPC methods
+	// ("/item.v1.ItemService/DeleteItem", "/item.v1.ItemService/*"), with
+	// the syntax of WithPublicEndpoints.
+	Patterns []string
+
+	// AnyOf lists roles of which the caller needs at least one.
+	AnyOf []string
+
+	// AllOf lists roles the caller needs all of.
+	AllOf []string
+}
+
+// RequireRoles returns a rule requiring at least one of roles on the
+// endpoints matching pattern.
+func RequireRoles(pattern string, roles ...string) RBACRule {
+	return RBACRule{Patterns: []string{pattern}, AnyOf: roles}
+}
+
+// rbacRule is the compiled form of RBACRule.
+type rbacRule struct {
+	paths *pathmatch.Matcher
+	anyOf []string
+	allOf []string
+}
+
+// WithRBAC requires roles, set by the auth function with ContextWithRoles,
+// on the endpoints matching the rules' patterns. Every rule matching a
+// request must be satisfied; requests matching no rule are allowed.
+// Requests failing authorization are rejected with 403 (PermissionDenied
+// for gRPC) before reaching the handler. Rules are checked after
+// authentication, so they cover REST calls (by path) and gRPC calls,
+// including those of the gateway (by method). It requires WithAuth or
+// WithBasicAuth.
+//
+// Example:
+//
+//	grpckit.WithRBAC(
+//	    grpckit.RequireRoles("/api/v1/admin/**", "admin"),
+//	    grpckit.RequireRoles("/item.v1.ItemService/DeleteItem", "admin", "items:delete"),
+//	    grpckit.RBACRule{Patterns: []string{"/billing.v1.BillingService/*"}, AllOf: []string{"billing", "mfa"}},
+//	)
+func WithRBAC(rules ...RBACRule) Option {
+	return func(c *serverConfig) {
+		for _, r := range rules {
+			if len(r.Patterns) == 0 {
+				c.invalid("WithRBAC: rule without patterns")
+				continue
+			}
+			if len(r.AnyOf) == 0 && len(r.AllOf) == 0 {
+				c.invalid("WithRBAC: rule for %s requires no roles", strings.Join(r.Patterns, ", "))
+				continue
+			}
+			c.rbacRules = append(c.rbacRules, rbacRule{
+				paths: c.compilePatterns("WithRBAC", r.Patterns),
+				anyOf: r.AnyOf,
+				allOf: r.AllOf,
+			})
+		}
+	}
+}
+
+// checkRBAC reports WithRBAC without an auth function to set roles.
+func checkRBAC(cfg *serverConfig) {
+	if len(cfg.rbacRules) > 0 && cfg.authFunc == nil {
+		cfg.invalid("WithRBAC: no auth function (see WithAuth)")
+	}
+}
+
+// authorized reports whether the roles of ctx satisfy every rule matching
+// the path or method.
+func authorized(rules []rbacRule, ctx context.Context, pathOrMethod string) bool {
+	roles := RolesFromContext(ctx)
+	for _, r := range rules {
+		if !r.paths.Match(pathOrMethod) {
+			continue
+		}
+		if len(r.anyOf) > 0 && !slices.ContainsFunc(r.anyOf, func(role string) bool { return slices.Contains(roles, role) }) {
+			return false
+		}
+		for _, role := range r.allOf {
+			if !slices.Contains(roles, role) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// logDenied logs a request rejected by WithRBAC.
+func (s *Server) logDenied(ctx context.Context, protocol, operation string) {
+	s.logger.Info("permission denied", "component", "rbac", "protocol", protocol, "operation", operation,
+		"principal", PrincipalFromContext(ctx), "roles", strings.Join(RolesFromContext(ctx), ","))
+}
+
+// rbacMiddleware authorizes HTTP requests.
+func rbacMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !authorized(s.cfg.rbacRules, r.Context(), r.URL.Path) {
+			s.logDenied(r.Context(), "http", r.URL.Path)
+			http.Error(w, "permission denied", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// rbacUnaryInterceptor authorizes unary calls.
+func rbacUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !authorized(s.cfg.rbacRules, ctx, info.FullMethod) {
+			s.logDenied(ctx, "grpc", info.FullMethod)
+			return nil, status.Error(codes.PermissionDenied, "permission denied")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// rbacStreamInterceptor authorizes streaming calls.
+func rbacStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if !authorized(s.cfg.rbacRules, ss.Context(), info.FullMethod) {
+			s.logDenied(ss.Context(), "grpc", info.FullMethod)
+			return status.Error(codes.PermissionDenied, "permission denied")
+		}
+		return handler(srv, ss)
+	}
+}
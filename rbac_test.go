@@ -0,0 +1,138 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// allowAll authenticates every request.
+func allowAll(ctx context.Context, token string) (context.Context, error) {
+	return ctx, nil
+}
+
+func TestRolesFromContext(t *testing.T) {
+	ctx := ContextWithRoles(context.Background(), "admin", "items:read")
+	if got := RolesFromContext(ctx); len(got) != 2 || got[0] != "admin" || got[1] != "items:read" {
+		t.Errorf("unexpected roles %v", got)
+	}
+	if !HasRole(ctx, "items:read") || HasRole(ctx, "items:write") {
+		t.Error("HasRole mismatch")
+	}
+	if got := RolesFromContext(context.Background()); got != nil {
+		t.Errorf("expected no roles, got %v", got)
+	}
+}
+
+func TestAuthorized(t *testing.T) {
+	s := newSlowTestServer(t, WithAuth(allowAll), WithRBAC(
+		RequireRoles("/api/v1/admin/**", "admin", "ops"),
+		RBACRule{Patterns: []string{"/billing.v1.BillingService/*"}, AllOf: []string{"billing", "mfa"}},
+		RequireRoles("/billing.v1.BillingService/Refund", "refunds"),
+	))
+
+	tests := []struct {
+		path  string
+		roles []string
+		want  bool
+	}{
+		{"/api/v1/items", nil, true},
+		{"/api/v1/admin/users", nil, false},
+		{"/api/v1/admin/users", []string{"ops"}, true},
+		{"/api/v1/admin/users", []string{"viewer"}, false},
+		{"/billing.v1.BillingService/Charge", []string{"billing"}, false},
+		{"/billing.v1.BillingService/Charge", []string{"mfa", "billing"}, true},
+		{"/billing.v1.BillingService/Refund", []string{"mfa", "billing"}, false},
+		{"/billing.v1.BillingService/Refund", []string{"mfa", "billing", "refunds"}, true},
+	}
+	for _, tt := range tests {
+		ctx := ContextWithRoles(context.Background(), tt.roles...)
+		if got := authorized(s.cfg.rbacRules, ctx, tt.path); got != tt.want {
+			t.Errorf("authorized(%s, %v) = %v, want %v", tt.path, tt.roles, got, tt.want)
+		}
+	}
+}
+
+func TestRBACMiddleware(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithAuth(allowAll), WithRBAC(RequireRoles("/api/v1/admin/**", "admin")))
+	handler := rbacMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(path string, roles ...string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req = req.WithContext(ContextWithRoles(ContextWithPrincipal(req.Context(), "alice"), roles...))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve("/api/v1/admin/users", "viewer"); code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", code)
+	}
+	if code := serve("/api/v1/admin/users", "admin"); code != http.StatusOK {
+		t.Errorf("expected 200, got %d", code)
+	}
+	if code := serve("/api/v1/items"); code != http.StatusOK {
+		t.Errorf("expected 200 for unmatched path, got %d", code)
+	}
+	if out := buf.String(); !strings.Contains(out, "component=rbac") || !strings.Contains(out, "principal=alice") {
+		t.Errorf("expected denial log, got %q", out)
+	}
+}
+
+func TestRBACInterceptors(t *testing.T) {
+	s := newSlowTestServer(t, WithAuth(allowAll), WithRBAC(RequireRoles("/item.v1.ItemService/DeleteItem", "admin")))
+
+	unary := func(method string, roles ...string) error {
+		ctx := ContextWithRoles(context.Background(), roles...)
+		info := &grpc.UnaryServerInfo{FullMethod: method}
+		_, err := rbacUnaryInterceptor(s)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "ok", nil
+		})
+		return err
+	}
+	if err := unary("/item.v1.ItemService/DeleteItem"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied, got %v", err)
+	}
+	if err := unary("/item.v1.ItemService/DeleteItem", "admin"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := unary("/item.v1.ItemService/GetItem"); err != nil {
+		t.Errorf("unexpected error for unmatched method: %v", err)
+	}
+
+	called := false
+	stream := &contextServerStream{ctx: ContextWithRoles(context.Background(), "viewer")}
+	info := &grpc.StreamServerInfo{FullMethod: "/item.v1.ItemService/DeleteItem"}
+	err := rbacStreamInterceptor(s)(nil, stream, info, func(srv interface{}, ss grpc.ServerStream) error {
+		called = true
+		return nil
+	})
+	if status.Code(err) != codes.PermissionDenied || called {
+		t.Errorf("expected PermissionDenied without calling the handler, got %v", err)
+	}
+}
+
+func TestWithRBAC_Invalid(t *testing.T) {
+	tests := map[string][]Option{
+		"no auth":     {WithRBAC(RequireRoles("/admin/**", "admin"))},
+		"no patterns": {WithAuth(allowAll), WithRBAC(RBACRule{AnyOf: []string{"admin"}})},
+		"no roles":    {WithAuth(allowAll), WithRBAC(RequireRoles("/admin/**"))},
+		"bad pattern": {WithAuth(allowAll), WithRBAC(RequireRoles("/admin/[*", "admin"))},
+	}
+	for name, opts := range tests {
+		opts = append(opts, WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if _, err := New(opts...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package grpckit
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// routeRewrite redirects or internally rewrites requests for a path.
+type routeRewrite struct {
+	from     string // path, or subtree prefix when fromTree is set
+	fromTree bool
+	to       string // path or URL, or subtree prefix when toTree is set
+	toTree   bool
+	code     int // redirect status; 0 for an alias
+}
+
+// WithRedirect redirects requests for from to to with the given status
+// (301, 302, 303, 307 or 308; 0 means 301). Paths ending in "/**" match a
+// subtree, and the rest of the path is appended to a "/**" target. The
+// query string is preserved. to may be a path or an absolute URL.
+//
+// Prefer 308 for non-GET endpoints: it preserves the method and body,
+// whereas clients may turn a redirected POST into a GET after 301.
+//
+// Example:
+//
+//	grpckit.WithRedirect("/docs", "/swagger/", http.StatusFound)
+//	grpckit.WithRedirect("/api/v1/orders/**", "/api/v2/orders/**", http.StatusPermanentRedirect)
+func WithRedirect(from, to string, code int) Option {
+	return func(c *serverConfig) {
+		if code == 0 {
+			code = http.StatusMovedPermanently
+		}
+		c.routeRewrites = append(c.routeRewrites, newRouteRewrite(from, to, code))
+	}
+}
+
+// WithRouteAlias serves requests for oldPattern as if they were sent to
+// newPattern, without a redirect, so renamed endpoints keep working for
+// clients that do not follow redirects. Patterns are paths or "/**"
+// subtrees, as in WithRedirect. Auth, timeouts and handlers see the new
+// path; access logs and metrics record the path that was requested.
+//
+// Example:
+//
+//	grpckit.WithRouteAlias("/api/v1/products/**", "/api/v1/items/**")
+func WithRouteAlias(oldPattern, newPattern string) Option {
+	return func(c *serverConfig) {
+		c.routeRewrites = append(c.routeRewrites, newRouteRewrite(oldPattern, newPattern, 0))
+	}
+}
+
+// newRouteRewrite parses the from and to patterns of a rewrite.
+func newRouteRewrite(from, to string, code int) routeRewrite {
+	rw := routeRewrite{from: from, to: to, code: code}
+	if strings.HasSuffix(from, "/**") {
+		rw.from, rw.fromTree = strings.TrimSuffix(from, "/**"), true
+	}
+	if strings.HasSuffix(to, "/**") {
+		rw.to, rw.toTree = strings.TrimSuffix(to, "/**"), true
+	}
+	return rw
+}
+
+// validateRouteRewrites checks redirect and alias patterns.
+func validateRouteRewrites(rewrites []routeRewrite) error {
+	for _, rw := range rewrites {
+		switch {
+		case !strings.HasPrefix(rw.from, "/") && !(rw.fromTree && rw.from == ""):
+			return fmt.Errorf("%w: route %q must start with /", ErrInvalidConfig, rw.from)
+		case strings.Contains(rw.from, "*"):
+			return fmt.Errorf("%w: route %q: only a trailing /** wildcard is supported", ErrInvalidConfig, rw.from)
+		case rw.toTree && !rw.fromTree:
+			return fmt.Errorf("%w: route target %q has /** but source %q does not", ErrInvalidConfig, rw.to, rw.from)
+		}
+		if rw.code == 0 {
+			if !strings.HasPrefix(rw.to, "/") && !(rw.toTree && rw.to == "") {
+				return fmt.Errorf("%w: alias target %q must be a path", ErrInvalidConfig, rw.to)
+			}
+			continue
+		}
+		switch rw.code {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+			http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		default:
+			return fmt.Errorf("%w: redirect status %d for %q is not a redirect", ErrInvalidConfig, rw.code, rw.from)
+		}
+	}
+	return nil
+}
+
+// target returns the rewritten path for urlPath, if the rewrite applies.
+func (rw routeRewrite) target(urlPath string) (string, bool) {
+	if !rw.fromTree {
+		return rw.to, urlPath == rw.from
+	}
+	if urlPath != rw.from && !strings.HasPrefix(urlPath, rw.from+"/") {
+		return "", false
+	}
+	if !rw.toTree {
+		return rw.to, true
+	}
+	target := rw.to + cleanSubpath(strings.TrimPrefix(urlPath, rw.from))
+	if target == "" {
+		target = "/"
+	}
+	return target, true
+}
+
+// cleanSubpath cleans the part of a path matched by "/**", so that a
+// request for "/old//evil.com" cannot produce the protocol-relative
+// target "//evil.com" (or "/\evil.com", which browsers treat the same).
+// A trailing slash is kept.
+func cleanSubpath(rest string) string {
+	if rest == "" {
+		return ""
+	}
+	cleaned := path.Clean("/" + strings.TrimLeft(rest, "/\\"))
+	if strings.HasSuffix(rest, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// routeRewriteMiddleware applies WithRedirect and WithRouteAlias rules;
+// the first matching rule wins.
+func routeRewriteMiddleware(rewrites []routeRewrite, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rw := range rewrites {
+			target, ok := rw.target(r.URL.Path)
+			if !ok {
+				continue
+			}
+
+			if rw.code != 0 {
+				if r.URL.RawQuery != "" {
+					target += "?" + r.URL.RawQuery
+				}
+				http.Redirect(w, r, target, rw.code)
+				return
+			}
+
+			u := *r.URL
+			u.Path, u.RawPath = target, ""
+			r2 := r.Clone(r.Context())
+			r2.URL = &u
+			r2.RequestURI = u.RequestURI()
+			next.ServeHTTP(w, r2)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
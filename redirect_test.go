@@ -0,0 +1,144 @@
+package grpckit
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestRouteRewriteTarget(t *testing.T) {
+	cases := []struct {
+		from, to string
+		path     string
+		want     string
+		ok       bool
+	}{
+		{"/docs", "/swagger/", "/docs", "/swagger/", true},
+		{"/docs", "/swagger/", "/docs/x", "", false},
+		{"/api/v1/orders/**", "/api/v2/orders/**", "/api/v1/orders/42/lines", "/api/v2/orders/42/lines", true},
+		{"/api/v1/orders/**", "/api/v2/orders/**", "/api/v1/orders", "/api/v2/orders", true},
+		{"/api/v1/orders/**", "/api/v2/orders/**", "/api/v1/ordersx", "", false},
+		{"/old/**", "/new", "/old/a/b", "/new", true},
+		{"/v1/**", "https://api.example.com/v1/**", "/v1/items", "https://api.example.com/v1/items", true},
+		{"/old/**", "/**", "/old//evil.com", "/evil.com", true},
+		{"/old/**", "/**", "/old/\\evil.com", "/evil.com", true},
+		{"/old/**", "/new/**", "/old/a/../../../etc/", "/new/etc/", true},
+		{"/old/**", "/new/**", "/old/", "/new/", true},
+	}
+	for _, tc := range cases {
+		got, ok := newRouteRewrite(tc.from, tc.to, 0).target(tc.path)
+		if ok != tc.ok || (ok && got != tc.want) {
+			t.Errorf("%s -> %s for %s: expected %q %v, got %q %v", tc.from, tc.to, tc.path, tc.want, tc.ok, got, ok)
+		}
+	}
+}
+
+func TestRouteRewriteMiddleware_Redirect(t *testing.T) {
+	cfg := newServerConfig()
+	WithRedirect("/api/v1/orders/**", "/api/v2/orders/**", http.StatusPermanentRedirect)(cfg)
+	WithRedirect("/docs", "/swagger/", 0)(cfg)
+
+	handler := routeRewriteMiddleware(cfg.routeRewrites, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected call to next handler for %s", r.URL.Path)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/orders/42?expand=lines", nil))
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != "/api/v2/orders/42?expand=lines" {
+		t.Errorf("expected 308 to v2 with query, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/swagger/" {
+		t.Errorf("expected default 301 to /swagger/, got %d %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestRouteRewriteMiddleware_OpenRedirect(t *testing.T) {
+	cfg := newServerConfig()
+	WithRedirect("/old/**", "/**", http.StatusFound)(cfg)
+	handler := routeRewriteMiddleware(cfg.routeRewrites, http.NotFoundHandler())
+
+	for _, target := range []string{"/old//evil.com", "/old///evil.com/x", "/old/%5Cevil.com"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if loc := rec.Header().Get("Location"); !strings.HasPrefix(loc, "/evil.com") {
+			t.Errorf("%s: expected a local redirect, got %d %q", target, rec.Code, loc)
+		}
+	}
+}
+
+func TestRouteRewriteMiddleware_Alias(t *testing.T) {
+	cfg := newServerConfig()
+	WithRouteAlias("/api/v1/products/**", "/api/v1/items/**")(cfg)
+
+	var gotPath, gotQuery, gotURI string
+	handler := routeRewriteMiddleware(cfg.routeRewrites, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath, gotQuery, gotURI = r.URL.Path, r.URL.RawQuery, r.RequestURI
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/7?fields=name", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected alias to be served without redirect, got %d", rec.Code)
+	}
+	if gotPath != "/api/v1/items/7" || gotQuery != "fields=name" || gotURI != "/api/v1/items/7?fields=name" {
+		t.Errorf("unexpected rewritten request: path=%q query=%q uri=%q", gotPath, gotQuery, gotURI)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/other", nil))
+	if gotPath != "/api/v1/other" {
+		t.Errorf("expected unmatched path to pass through, got %q", gotPath)
+	}
+}
+
+func TestRouteAlias_AuthSeesNewPath(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithProtectedEndpoints("/api/v1/items/**"),
+		WithRouteAlias("/api/v1/products/**", "/api/v1/items/**"),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	handler := s.buildHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/products/7", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected aliased path to require auth, got %d", rec.Code)
+	}
+}
+
+func TestValidateRouteRewrites(t *testing.T) {
+	invalid := []Option{
+		WithRedirect("/a", "/b", http.StatusOK),
+		WithRedirect("a", "/b", 0),
+		WithRedirect("/a/*/b", "/b", 0),
+		WithRedirect("/a", "/b/**", 0),
+		WithRouteAlias("/a/**", "https://example.com/**"),
+	}
+	for i, opt := range invalid {
+		_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), opt)
+		if !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("case %d: expected ErrInvalidConfig, got %v", i, err)
+		}
+	}
+
+	valid := []Option{
+		WithRedirect("/a", "https://example.com/a", http.StatusFound),
+		WithRedirect("/a/**", "/b/**", http.StatusSeeOther),
+		WithRouteAlias("/a/**", "/b"),
+	}
+	for i, opt := range valid {
+		if _, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), opt); err != nil {
+			t.Errorf("case %d: unexpected error: %v", i, err)
+		}
+	}
+}
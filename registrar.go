@@ -0,0 +1,75 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"reflect"
+	goruntime "runtime"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+)
+
+// WithContinueOnRESTFailure keeps the server running when a REST registrar
+// (see WithRESTService) returns an error or panics at startup. The failing
+// registrar is logged and skipped, the other services are served, and
+// /readyz reports 503 with a "rest:<registrar>" check so the instance is
+// taken out of rotation (gRPC keeps serving).
+//
+// By default a failing registrar stops the server, with an error naming it.
+//
+// Example:
+//
+//	grpckit.WithRESTService(pb.RegisterItemServiceHandlerFromEndpoint),
+//	grpckit.WithRESTService(pb.RegisterLegacyServiceHandlerFromEndpoint),
+//	grpckit.WithContinueOnRESTFailure(),
+//
+// Example output:
+//
+//	level=ERROR msg="REST service registration failed, continuing without it" component=gateway registrar=gen.RegisterLegacyServiceHandlerFromEndpoint error="panic: nil map"
+func WithContinueOnRESTFailure() Option {
+	return func(c *serverConfig) {
+		c.continueOnRESTFailure = true
+	}
+}
+
+// registerRESTServices runs the REST registrars against the gateway mux.
+func (s *Server) registerRESTServices(ctx context.Context, gwMux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+	for _, registrar := range s.cfg.restServices {
+		err := callRegistrar(ctx, registrar, gwMux, endpoint, opts)
+		if err == nil {
+			continue
+		}
+
+		name := registrarName(registrar)
+		if !s.cfg.continueOnRESTFailure {
+			return fmt.Errorf("failed to register REST service %s: %w", name, err)
+		}
+
+		s.logger.Error("REST service registration failed, continuing without it", "component", "gateway",
+			"registrar", name, "error", err)
+		s.healthHandler.addReadinessCheck(newHealthCheck("rest:"+name, func(context.Context) error { return err }))
+	}
+	return nil
+}
+
+// callRegistrar calls a registrar, turning a panic into an error.
+func callRegistrar(ctx context.Context, registrar RESTRegistrar, gwMux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return registrar(ctx, gwMux, endpoint, opts)
+}
+
+// registrarName returns the package-qualified function name of a registrar,
+// e.g. "gen.RegisterItemServiceHandlerFromEndpoint".
+func registrarName(registrar RESTRegistrar) string {
+	fn := goruntime.FuncForPC(reflect.ValueOf(registrar).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	return path.Base(fn.Name())
+}
@@ -0,0 +1,133 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func panickingRegistrar(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+	panic("nil map")
+}
+
+func failingRegistrar(ctx context.Context, mux *runtime.ServeMux, endpoint string, opts []grpc.DialOption) error {
+	return errors.New("dial failed")
+}
+
+func newRegistrarTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	opts = append([]Option{
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, itempb.UnimplementedItemServiceServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+	}, opts...)
+	s, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+func TestRegisterRESTServices_FailsByDefault(t *testing.T) {
+	for _, registrar := range []RESTRegistrar{panickingRegistrar, failingRegistrar} {
+		s := newRegistrarTestServer(t, WithRESTService(registrar))
+		dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+		_, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts)
+		if err == nil {
+			t.Fatal("expected registration error")
+		}
+		if name := registrarName(registrar); !strings.Contains(err.Error(), name) {
+			t.Errorf("expected error to name %s, got %v", name, err)
+		}
+	}
+}
+
+func TestRegisterRESTServices_Continue(t *testing.T) {
+	buf := captureLog(t)
+	s := newRegistrarTestServer(t, WithRESTService(panickingRegistrar), WithContinueOnRESTFailure())
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	gwMux, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts)
+	if err != nil {
+		t.Fatalf("expected startup to continue, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "registrar=grpckit.panickingRegistrar") || !strings.Contains(buf.String(), "panic: nil map") {
+		t.Errorf("expected failing registrar to be logged, got %q", buf.String())
+	}
+
+	// The other services are still served
+	rec := httptest.NewRecorder()
+	gwMux.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/items", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected item routes to be registered, got %d", rec.Code)
+	}
+
+	// Readiness is degraded, with one check however often the gateway is built
+	if _, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts); err != nil {
+		t.Fatalf("expected second build to continue, got %v", err)
+	}
+	if n := len(s.healthHandler.readiness()); n != 1 {
+		t.Errorf("expected one readiness check, got %d", n)
+	}
+	rec = httptest.NewRecorder()
+	s.healthHandler.ReadinessHandler()(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"rest:grpckit.panickingRegistrar":"panic: nil map"`) {
+		t.Errorf("expected degraded readiness, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRegistrarName(t *testing.T) {
+	if got := registrarName(itempb.RegisterItemServiceHandlerFromEndpoint); got != "gen.RegisterItemServiceHandlerFromEndpoint" {
+		t.Errorf("unexpected registrar name %q", got)
+	}
+}
+
+func TestWithGatewayMuxHook(t *testing.T) {
+	s := newRegistrarTestServer(t, WithGatewayMuxHook(func(mux *runtime.ServeMux) error {
+		return mux.HandlePath(http.MethodPost, "/api/v1/items/{id}:archive", func(w http.ResponseWriter, r *http.Request, params map[string]string) {
+			_, outbound := runtime.MarshalerForRequest(mux, r)
+			w.Header().Set("Content-Type", outbound.ContentType(nil))
+			data, _ := outbound.Marshal(map[string]string{"archived": params["id"]})
+			w.Write(data)
+		})
+	}))
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	gwMux, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts)
+	if err != nil {
+		t.Fatalf("newGatewayMux failed: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	gwMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/42:archive", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" || !strings.Contains(rec.Body.String(), `"archived":"42"`) {
+		t.Errorf("expected custom route through gateway marshalers, got %d %q %s", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestWithGatewayMuxHook_Error(t *testing.T) {
+	s := newRegistrarTestServer(t, WithGatewayMuxHook(func(mux *runtime.ServeMux) error {
+		return mux.HandlePath(http.MethodGet, "/api/{", nil)
+	}))
+
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	if _, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts); err == nil || !strings.Contains(err.Error(), "gateway mux hook") {
+		t.Errorf("expected hook error, got %v", err)
+	}
+}
+
+func TestWithGatewayMuxHook_Nil(t *testing.T) {
+	_, err := New(WithGatewayMuxHook(nil), WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
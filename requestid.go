@@ -0,0 +1,66 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc"
+)
+
+// WithRequestID gives every request an ID: the X-Request-ID header of HTTP
+// requests (or x-request-id metadata of gRPC calls) when the client sent a
+// valid one, a generated one otherwise. HTTP responses carry it back in
+// X-Request-ID, REST calls forward it to gRPC as x-request-id metadata, and
+// handlers read it with RequestIDFromContext.
+//
+// Example:
+//
+//	grpckit.WithRequestID()
+//
+//	func (s *orderService) Create(ctx context.Context, req *pb.CreateRequest) (*pb.Order, error) {
+//	    slog.InfoContext(ctx, "creating order", "request_id", grpckit.RequestIDFromContext(ctx))
+//	    ...
+//	}
+func WithRequestID() Option {
+	return func(c *serverConfig) {
+		c.requestID = true
+	}
+}
+
+// RequestIDFromContext returns the ID of the request being handled, or "" if
+// it has none. With WithRequestID every request has one; without it, only
+// requests that sent one or were logged (see WithAccessLog and
+// WithGRPCLogging) do.
+func RequestIDFromContext(ctx context.Context) string {
+	return requestIDFromContext(ctx)
+}
+
+// requestIDMiddleware assigns the request ID and returns it in the response.
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, id := ensureRequestID(r)
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requestIDUnaryInterceptor assigns a request ID to gRPC calls that arrived
+// without one.
+func requestIDUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(ensureGRPCRequestID(ctx), req)
+}
+
+// requestIDStreamInterceptor is the stream variant of requestIDUnaryInterceptor.
+func requestIDStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: ensureGRPCRequestID(ss.Context())})
+}
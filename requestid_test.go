@@ -0,0 +1,69 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var gotID string
+	var md metadata.MD
+	s := newSlowTestServer(t, WithRequestID(), WithHTTPHandlerFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		gotID = RequestIDFromContext(r.Context())
+		md = gatewayCorrelationMetadata(r.Context(), r)
+	}))
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/echo", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if gotID != "req-1" || rec.Header().Get(requestIDHeader) != "req-1" {
+		t.Errorf("expected client request ID to be honored, got %q in context and %q in response",
+			gotID, rec.Header().Get(requestIDHeader))
+	}
+	if got := md.Get(requestIDMetadataKey); len(got) != 1 || got[0] != "req-1" {
+		t.Errorf("expected gateway metadata to carry the request ID, got %v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/echo", nil)
+	req.Header.Set(requestIDHeader, "bad id")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if len(gotID) != 32 || rec.Header().Get(requestIDHeader) != gotID {
+		t.Errorf("expected generated request ID in context and response, got %q and %q",
+			gotID, rec.Header().Get(requestIDHeader))
+	}
+}
+
+func TestRequestIDUnaryInterceptor(t *testing.T) {
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return RequestIDFromContext(ctx), nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: "/test.Service/Get"}
+
+	got, _ := requestIDUnaryInterceptor(contextWithRequestID(context.Background(), "req-2"), nil, info, handler)
+	if got != "req-2" {
+		t.Errorf("expected request ID req-2 to be kept, got %q", got)
+	}
+
+	ctx, _ := withLogFields(context.Background())
+	got, _ = requestIDUnaryInterceptor(ctx, nil, info, handler)
+	if id := got.(string); len(id) != 32 || LogFields(ctx).String() != "request_id="+id {
+		t.Errorf("expected generated request ID in context and log fields, got %q and %q", id, LogFields(ctx).String())
+	}
+}
+
+func TestRequestIDFromContext_None(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("expected no request ID, got %q", id)
+	}
+}
@@ -0,0 +1,241 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Environment variables used to hand listeners over to a restarted process.
+const (
+	// envInheritFDs lists inherited listeners as "name=fd" pairs, e.g. "grpc=3,http=4".
+	envInheritFDs = "GRPCKIT_INHERIT_FDS"
+	// envReadyFD is the fd the child writes to once all its listeners are serving.
+	envReadyFD = "GRPCKIT_READY_FD"
+)
+
+// WithGracefulRestart enables zero-downtime binary restarts via socket inheritance.
+//
+// On SIGHUP (or a call to Server.Restart), the server re-executes its own binary,
+// passing the open listening sockets to the new process. Once the new process
+// is serving on the inherited sockets, the old process drains gracefully.
+// No connection is refused during the handover, so the binary can be replaced
+// on disk and restarted without an orchestrator.
+//
+// Not supported on Windows.
+//
+// Example:
+//
+//	grpckit.WithGracefulRestart()
+//
+//	// Deploy: replace the binary, then
+//	kill -HUP <pid>
+func WithGracefulRestart() Option {
+	return func(c *serverConfig) {
+		c.gracefulRestart = true
+	}
+}
+
+// WithReusePort sets SO_REUSEPORT on listening sockets, allowing several
+// processes to bind the same port. Use this to start a new instance of the
+// service before stopping the old one, as an alternative to WithGracefulRestart.
+//
+// Only supported on Linux and BSD-like systems; ignored elsewhere.
+func WithReusePort() Option {
+	return func(c *serverConfig) {
+		c.reusePort = true
+	}
+}
+
+// listen returns the listener for the given name ("grpc", "http" or "grpc+http").
+// An inherited listener from a parent process is reused when available. The
+// raw listener is kept for handover; the returned one enforces
+// WithMaxConnections.
+func (s *Server) listen(name, addr string) (net.Listener, error) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+
+	lis, ok := s.inherited[name]
+	if ok {
+		delete(s.inherited, name)
+		s.logger.Info("using inherited listener", "component", "restart", "listener", name, "addr", lis.Addr().String())
+	} else {
+		lc := net.ListenConfig{}
+		if s.cfg.reusePort {
+			lc.Control = reusePortControl
+		}
+		var err error
+		lis, err = lc.Listen(context.Background(), "tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+	}
+
+	if s.listeners == nil {
+		s.listeners = make(map[string]net.Listener)
+	}
+	s.listeners[name] = lis
+	return s.limitListener(name, lis), nil
+}
+
+// listenerBound records that a listener is serving, emits ListenerBound,
+// and notifies a waiting parent process once all listeners are up.
+func (s *Server) listenerBound(name string, lis net.Listener) {
+	s.emit(ListenerBound{Time: time.Now(), Protocol: name, Addr: lis.Addr().String()})
+
+	expected := int32(2)
+	if s.cfg.grpcPort == s.cfg.httpPort {
+		expected = 1
+	}
+	if s.boundCount.Add(1) == expected {
+		notifyParentReady()
+	}
+}
+
+// inheritedListeners reconstructs listeners passed by a parent process.
+// The environment variables are cleared so they don't leak to further children.
+func inheritedListeners(logger *slog.Logger) map[string]net.Listener {
+	spec := os.Getenv(envInheritFDs)
+	if spec == "" {
+		return nil
+	}
+	os.Unsetenv(envInheritFDs)
+
+	listeners := make(map[string]net.Listener)
+	for _, pair := range strings.Split(spec, ",") {
+		name, fdStr, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			continue
+		}
+		f := os.NewFile(uintptr(fd), name)
+		lis, err := net.FileListener(f)
+		f.Close() // FileListener dups the fd
+		if err != nil {
+			logger.Warn("failed to inherit listener", "component", "restart", "listener", name, "fd", fd, "error", err)
+			continue
+		}
+		listeners[name] = lis
+	}
+	return listeners
+}
+
+// notifyParentReady signals the parent process that this process is serving.
+func notifyParentReady() {
+	fdStr := os.Getenv(envReadyFD)
+	if fdStr == "" {
+		return
+	}
+	os.Unsetenv(envReadyFD)
+
+	fd, err := strconv.Atoi(fdStr)
+	if err != nil {
+		return
+	}
+	f := os.NewFile(uintptr(fd), "ready")
+	_, _ = f.Write([]byte{1})
+	f.Close()
+}
+
+// Restart starts a new copy of the current binary that inherits the listening
+// sockets, waits until it is serving, then gracefully shuts this server down.
+// If the new process fails to start or become ready, this server keeps running.
+func (s *Server) Restart() error {
+	s.listenersMu.Lock()
+	names := make([]string, 0, len(s.listeners))
+	for name := range s.listeners {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var files []*os.File
+	var spec []string
+	for _, name := range names {
+		fl, ok := s.listeners[name].(interface{ File() (*os.File, error) })
+		if !ok {
+			continue
+		}
+		f, err := fl.File()
+		if err != nil {
+			s.listenersMu.Unlock()
+			closeFiles(files)
+			return fmt.Errorf("failed to get %s listener fd: %w", name, err)
+		}
+		// ExtraFiles[i] becomes fd 3+i in the child
+		spec = append(spec, fmt.Sprintf("%s=%d", name, 3+len(files)))
+		files = append(files, f)
+	}
+	s.listenersMu.Unlock()
+	defer closeFiles(files)
+
+	if len(files) == 0 {
+		return errors.New("no listeners to hand over")
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer readyR.Close()
+
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.ExtraFiles = append(files, readyW)
+	cmd.Env = append(os.Environ(),
+		envInheritFDs+"="+strings.Join(spec, ","),
+		fmt.Sprintf("%s=%d", envReadyFD, 3+len(files)),
+	)
+	err = cmd.Start()
+	readyW.Close()
+	if err != nil {
+		return fmt.Errorf("failed to start new process: %w", err)
+	}
+	s.logger.Info("started new process, waiting for it to become ready", "component", "restart", "pid", cmd.Process.Pid)
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, err := readyR.Read(buf)
+		ready <- err
+	}()
+
+	select {
+	case err := <-ready:
+		if err != nil {
+			_ = cmd.Process.Kill()
+			return fmt.Errorf("new process exited before becoming ready: %w", err)
+		}
+	case <-time.After(s.cfg.gracefulTimeout):
+		_ = cmd.Process.Kill()
+		return errors.New("timed out waiting for new process to become ready")
+	}
+
+	// Reap the child asynchronously; it now runs independently
+	go func() { _ = cmd.Wait() }()
+
+	go s.shutdownWithReason(fmt.Sprintf("restart: handed over to pid %d", cmd.Process.Pid))
+	return nil
+}
+
+// closeFiles closes all files, ignoring errors.
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		f.Close()
+	}
+}
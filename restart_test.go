@@ -0,0 +1,132 @@
+//go:build unix
+
+package grpckit
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"google.golang.org/grpc"
+)
+
+func TestWithGracefulRestart(t *testing.T) {
+	cfg := newServerConfig()
+	WithGracefulRestart()(cfg)
+	WithReusePort()(cfg)
+
+	if !cfg.gracefulRestart {
+		t.Error("expected graceful restart enabled")
+	}
+	if !cfg.reusePort {
+		t.Error("expected reuse port enabled")
+	}
+}
+
+func TestInheritedListeners(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer lis.Close()
+
+	f, err := lis.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("listener fd failed: %v", err)
+	}
+	defer f.Close()
+
+	// inheritedListeners takes ownership of the fd, so hand it a duplicate
+	fd, err := syscall.Dup(int(f.Fd()))
+	if err != nil {
+		t.Fatalf("dup failed: %v", err)
+	}
+	t.Setenv(envInheritFDs, fmt.Sprintf("http=%d,bogus", fd))
+
+	inherited := inheritedListeners(defaultLogger)
+	if len(inherited) != 1 {
+		t.Fatalf("expected 1 inherited listener, got %d", len(inherited))
+	}
+	got := inherited["http"]
+	defer got.Close()
+	if got.Addr().String() != lis.Addr().String() {
+		t.Errorf("expected address %s, got %s", lis.Addr(), got.Addr())
+	}
+	if os.Getenv(envInheritFDs) != "" {
+		t.Error("expected inherit env var to be cleared")
+	}
+}
+
+func TestServerListen_UsesInherited(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	defer lis.Close()
+
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	s.inherited = map[string]net.Listener{"grpc": lis}
+
+	got, err := s.listen("grpc", ":1")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	if got != lis {
+		t.Error("expected inherited listener to be reused")
+	}
+	if _, ok := s.inherited["grpc"]; ok {
+		t.Error("expected inherited listener to be consumed")
+	}
+	if s.listeners["grpc"] != lis {
+		t.Error("expected listener to be tracked for handover")
+	}
+}
+
+func TestServerListen_ReusePort(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_REUSEPORT semantics tested on linux only")
+	}
+
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithReusePort(),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	first, err := s.listen("grpc", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("first listen failed: %v", err)
+	}
+	defer first.Close()
+
+	second, err := s.listen("http", first.Addr().String())
+	if err != nil {
+		t.Fatalf("expected second listener on same port to succeed: %v", err)
+	}
+	second.Close()
+}
+
+func TestNotifyParentReady(t *testing.T) {
+	// Use raw fds: notifyParentReady takes ownership of the write end
+	var fds [2]int
+	if err := syscall.Pipe(fds[:]); err != nil {
+		t.Fatalf("pipe failed: %v", err)
+	}
+	defer syscall.Close(fds[0])
+
+	t.Setenv(envReadyFD, fmt.Sprintf("%d", fds[1]))
+	notifyParentReady()
+
+	buf := make([]byte, 1)
+	if n, err := syscall.Read(fds[0], buf); err != nil || n != 1 {
+		t.Errorf("expected ready byte, got n=%d err=%v", n, err)
+	}
+}
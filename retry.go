@@ -0,0 +1,214 @@
+package grpckit
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RetryConfig configures automatic retries of idempotent unary calls.
+type RetryConfig struct {
+	// Methods lists the idempotent methods that may be retried, in the format
+	// /package.Service/Method. Glob patterns are supported
+	// (e.g. "/item.v1.ItemService/Get*"). Methods not listed are never retried.
+	Methods []string
+
+	// MaxAttempts is the total number of attempts, including the first.
+	// Default: 3
+	MaxAttempts int
+
+	// InitialBackoff is the upper bound of the first (jittered) backoff.
+	// Default: 50ms
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the backoff between attempts. Default: 1s
+	MaxBackoff time.Duration
+
+	// RetryCodes are the status codes that trigger a retry.
+	// Default: Unavailable, DeadlineExceeded
+	RetryCodes []codes.Code
+
+	// BudgetRatio is the number of retries allowed per request, averaged over
+	// time (0.1 allows retrying 10% of calls). Default: 0.1
+	BudgetRatio float64
+
+	// BudgetBurst is the maximum number of retries that can be spent at once
+	// when the budget is full. Default: 10
+	BudgetBurst int
+}
+
+// DefaultRetryConfig returns a RetryConfig with default values and no methods.
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:    3,
+		InitialBackoff: 50 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		RetryCodes:     []codes.Code{codes.Unavailable, codes.DeadlineExceeded},
+		BudgetRatio:    0.1,
+		BudgetBurst:    10,
+	}
+}
+
+// retryBudget is a token bucket shared by all calls: every call deposits
+// BudgetRatio tokens and every retry withdraws one. When backends fail
+// broadly, the budget drains and calls fail instead of multiplying load.
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	ratio  float64
+	max    float64
+}
+
+// deposit credits the budget for an original (non-retry) call.
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	b.tokens = min(b.tokens+b.ratio, b.max)
+	b.mu.Unlock()
+}
+
+// withdraw spends one retry token, reporting false if the budget is exhausted.
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// retrier holds the compiled retry configuration.
+type retrier struct {
+	cfg        RetryConfig
+	methods    *pathmatch.Matcher
+	retryCodes map[codes.Code]bool
+	budget     *retryBudget
+	sleep      func(ctx context.Context, d time.Duration) error
+}
+
+// newRetrier applies defaults and compiles the method patterns.
+func newRetrier(cfg RetryConfig) *retrier {
+	def := DefaultRetryConfig()
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = def.MaxAttempts
+	}
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = def.InitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = def.MaxBackoff
+	}
+	if len(cfg.RetryCodes) == 0 {
+		cfg.RetryCodes = def.RetryCodes
+	}
+	if cfg.BudgetRatio <= 0 {
+		cfg.BudgetRatio = def.BudgetRatio
+	}
+	if cfg.BudgetBurst <= 0 {
+		cfg.BudgetBurst = def.BudgetBurst
+	}
+
+	retryCodes := make(map[codes.Code]bool, len(cfg.RetryCodes))
+	for _, c := range cfg.RetryCodes {
+		retryCodes[c] = true
+	}
+
+	return &retrier{
+		cfg:        cfg,
+		methods:    pathmatch.Compile(cfg.Methods...),
+		retryCodes: retryCodes,
+		budget: &retryBudget{
+			tokens: float64(cfg.BudgetBurst),
+			ratio:  cfg.BudgetRatio,
+			max:    float64(cfg.BudgetBurst),
+		},
+		sleep: sleepContext,
+	}
+}
+
+// sleepContext waits for d or until ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// backoff returns a full-jitter exponential backoff for the given retry (1-based).
+func (r *retrier) backoff(retry int) time.Duration {
+	ceiling := r.cfg.MaxBackoff
+	if retry <= 30 {
+		ceiling = r.cfg.InitialBackoff << (retry - 1)
+	}
+	if ceiling <= 0 || ceiling > r.cfg.MaxBackoff {
+		ceiling = r.cfg.MaxBackoff
+	}
+	return time.Duration(rand.Int64N(int64(ceiling) + 1))
+}
+
+// unaryClientInterceptor retries idempotent calls within the budget.
+func (r *retrier) unaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if !r.methods.Match(method) {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		r.budget.deposit()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		for attempt := 1; attempt < r.cfg.MaxAttempts; attempt++ {
+			// Stop if the call succeeded, the error is not retryable, or the
+			// caller's own deadline has passed
+			if err == nil || !r.retryCodes[status.Code(err)] || ctx.Err() != nil {
+				return err
+			}
+			if !r.budget.withdraw() {
+				return err
+			}
+			if r.sleep(ctx, r.backoff(attempt)) != nil {
+				return err
+			}
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// RetryUnaryClientInterceptor returns a client interceptor that retries
+// idempotent methods on transient errors with jittered exponential backoff,
+// bounded by a retry budget shared across all calls through the interceptor.
+//
+// Example:
+//
+//	conn, _ := grpc.NewClient(addr, grpc.WithUnaryInterceptor(
+//	    grpckit.RetryUnaryClientInterceptor(grpckit.RetryConfig{
+//	        Methods: []string{"/item.v1.ItemService/GetItem", "/item.v1.ItemService/List*"},
+//	    }),
+//	))
+func RetryUnaryClientInterceptor(cfg RetryConfig) grpc.UnaryClientInterceptor {
+	return newRetrier(cfg).unaryClientInterceptor()
+}
+
+// WithGatewayRetry retries idempotent methods on the grpc-gateway → gRPC
+// connection. Only methods listed in cfg.Methods are retried; streaming calls
+// are never retried.
+//
+// Example:
+//
+//	grpckit.WithGatewayRetry(grpckit.RetryConfig{
+//	    Methods: []string{"/item.v1.ItemService/Get*", "/item.v1.ItemService/ListItems"},
+//	})
+func WithGatewayRetry(cfg RetryConfig) Option {
+	return func(c *serverConfig) {
+		c.gatewayRetry = newRetrier(cfg)
+	}
+}
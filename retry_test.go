@@ -0,0 +1,187 @@
+package grpckit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// countingInvoker returns errs in order (nil once exhausted) and counts calls.
+func countingInvoker(calls *int, errs ...error) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*calls++
+		if *calls <= len(errs) {
+			return errs[*calls-1]
+		}
+		return nil
+	}
+}
+
+func newTestRetrier(cfg RetryConfig) *retrier {
+	r := newRetrier(cfg)
+	r.sleep = func(ctx context.Context, d time.Duration) error { return nil }
+	return r
+}
+
+func TestNewRetrier_Defaults(t *testing.T) {
+	r := newRetrier(RetryConfig{})
+	def := DefaultRetryConfig()
+
+	if r.cfg.MaxAttempts != def.MaxAttempts {
+		t.Errorf("expected MaxAttempts %d, got %d", def.MaxAttempts, r.cfg.MaxAttempts)
+	}
+	if !r.retryCodes[codes.Unavailable] || !r.retryCodes[codes.DeadlineExceeded] {
+		t.Error("expected Unavailable and DeadlineExceeded to be retryable")
+	}
+	if r.budget.tokens != float64(def.BudgetBurst) {
+		t.Errorf("expected full budget %d, got %v", def.BudgetBurst, r.budget.tokens)
+	}
+}
+
+func TestRetry_IdempotentMethod(t *testing.T) {
+	r := newTestRetrier(RetryConfig{Methods: []string{"/test.Service/Get*"}})
+	interceptor := r.unaryClientInterceptor()
+
+	calls := 0
+	unavailable := status.Error(codes.Unavailable, "down")
+	err := interceptor(context.Background(), "/test.Service/GetItem", nil, nil, nil,
+		countingInvoker(&calls, unavailable, unavailable))
+
+	if err != nil {
+		t.Errorf("expected success after retries, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestRetry_MaxAttempts(t *testing.T) {
+	r := newTestRetrier(RetryConfig{Methods: []string{"/test.Service/Get"}, MaxAttempts: 2})
+	interceptor := r.unaryClientInterceptor()
+
+	calls := 0
+	unavailable := status.Error(codes.Unavailable, "down")
+	err := interceptor(context.Background(), "/test.Service/Get", nil, nil, nil,
+		countingInvoker(&calls, unavailable, unavailable, unavailable))
+
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("expected Unavailable, got %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("expected 2 calls, got %d", calls)
+	}
+}
+
+func TestRetry_NonIdempotentMethod(t *testing.T) {
+	r := newTestRetrier(RetryConfig{Methods: []string{"/test.Service/Get"}})
+	interceptor := r.unaryClientInterceptor()
+
+	calls := 0
+	interceptor(context.Background(), "/test.Service/Create", nil, nil, nil,
+		countingInvoker(&calls, status.Error(codes.Unavailable, "down")))
+
+	if calls != 1 {
+		t.Errorf("expected non-idempotent method not to be retried, got %d calls", calls)
+	}
+}
+
+func TestRetry_NonRetryableCode(t *testing.T) {
+	r := newTestRetrier(RetryConfig{Methods: []string{"/test.Service/Get"}})
+	interceptor := r.unaryClientInterceptor()
+
+	calls := 0
+	interceptor(context.Background(), "/test.Service/Get", nil, nil, nil,
+		countingInvoker(&calls, status.Error(codes.NotFound, "missing")))
+
+	if calls != 1 {
+		t.Errorf("expected NotFound not to be retried, got %d calls", calls)
+	}
+}
+
+func TestRetry_ContextDone(t *testing.T) {
+	r := newTestRetrier(RetryConfig{Methods: []string{"/test.Service/Get"}})
+	interceptor := r.unaryClientInterceptor()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	calls := 0
+	interceptor(ctx, "/test.Service/Get", nil, nil, nil,
+		countingInvoker(&calls, status.Error(codes.DeadlineExceeded, "late")))
+
+	if calls != 1 {
+		t.Errorf("expected no retry after caller context is done, got %d calls", calls)
+	}
+}
+
+func TestRetry_BudgetExhausted(t *testing.T) {
+	r := newTestRetrier(RetryConfig{
+		Methods:     []string{"/test.Service/Get"},
+		MaxAttempts: 5,
+		BudgetRatio: 0.1,
+		BudgetBurst: 2,
+	})
+	interceptor := r.unaryClientInterceptor()
+	unavailable := status.Error(codes.Unavailable, "down")
+
+	calls := 0
+	interceptor(context.Background(), "/test.Service/Get", nil, nil, nil,
+		countingInvoker(&calls, unavailable, unavailable, unavailable, unavailable, unavailable))
+
+	// 1 original call + 2 retries from the burst
+	if calls != 3 {
+		t.Errorf("expected budget to allow 2 retries, got %d calls", calls)
+	}
+
+	calls = 0
+	interceptor(context.Background(), "/test.Service/Get", nil, nil, nil,
+		countingInvoker(&calls, unavailable, unavailable))
+	if calls != 1 {
+		t.Errorf("expected exhausted budget to prevent retries, got %d calls", calls)
+	}
+}
+
+func TestRetryBudget_Deposit(t *testing.T) {
+	b := &retryBudget{ratio: 0.5, max: 1}
+	if b.withdraw() {
+		t.Error("expected empty budget to refuse withdrawal")
+	}
+	b.deposit()
+	b.deposit()
+	b.deposit()
+	if b.tokens != 1 {
+		t.Errorf("expected tokens capped at 1, got %v", b.tokens)
+	}
+	if !b.withdraw() {
+		t.Error("expected withdrawal to succeed")
+	}
+}
+
+func TestRetry_Backoff(t *testing.T) {
+	r := newRetrier(RetryConfig{InitialBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond})
+
+	for i := 0; i < 50; i++ {
+		if d := r.backoff(1); d < 0 || d > 10*time.Millisecond {
+			t.Fatalf("first backoff %v out of range", d)
+		}
+		if d := r.backoff(10); d < 0 || d > 25*time.Millisecond {
+			t.Fatalf("backoff %v exceeds MaxBackoff", d)
+		}
+	}
+}
+
+func TestWithGatewayRetry(t *testing.T) {
+	cfg := newServerConfig()
+	WithGatewayRetry(RetryConfig{Methods: []string{"/test.Service/Get"}})(cfg)
+
+	if cfg.gatewayRetry == nil {
+		t.Fatal("expected gateway retry to be set")
+	}
+	if !cfg.gatewayRetry.methods.Match("/test.Service/Get") {
+		t.Error("expected method to be compiled")
+	}
+}
@@ -0,0 +1,271 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// httpRoute is a REST route declared with a google.api.http annotation.
+type httpRoute struct {
+	method   string
+	template string
+	segments []string
+	verb     string
+}
+
+// collectHTTPRoutes returns the google.api.http routes of the services
+// registered on the gRPC server, including additional bindings.
+func collectHTTPRoutes(grpcServer *grpc.Server) []httpRoute {
+	var routes []httpRoute
+	for name := range grpcServer.GetServiceInfo() {
+		desc, err := protoregistry.GlobalFiles.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			continue
+		}
+		svc, ok := desc.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		methods := svc.Methods()
+		for i := 0; i < methods.Len(); i++ {
+			opts := methods.Get(i).Options()
+			if opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+				continue
+			}
+			rule, ok := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+			if !ok {
+				continue
+			}
+			for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+				if route, ok := newHTTPRoute(r); ok {
+					routes = append(routes, route)
+				}
+			}
+		}
+	}
+	return routes
+}
+
+// newHTTPRoute parses the method and path template of an HTTP rule.
+func newHTTPRoute(rule *annotations.HttpRule) (httpRoute, bool) {
+	var method, template string
+	switch p := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		method, template = http.MethodGet, p.Get
+	case *annotations.HttpRule_Put:
+		method, template = http.MethodPut, p.Put
+	case *annotations.HttpRule_Post:
+		method, template = http.MethodPost, p.Post
+	case *annotations.HttpRule_Delete:
+		method, template = http.MethodDelete, p.Delete
+	case *annotations.HttpRule_Patch:
+		method, template = http.MethodPatch, p.Patch
+	case *annotations.HttpRule_Custom:
+		method, template = p.Custom.GetKind(), p.Custom.GetPath()
+	}
+	if method == "" || !strings.HasPrefix(template, "/") {
+		return httpRoute{}, false
+	}
+	segments, verb := parsePathTemplate(template)
+	return httpRoute{method: method, template: template, segments: segments, verb: verb}, true
+}
+
+// parsePathTemplate splits a path template into segments, with variables
+// replaced by their patterns ("{id}" becomes "*", "{name=items/*}" becomes
+// "items", "*"), and returns the trailing ":verb" if any.
+func parsePathTemplate(template string) ([]string, string) {
+	template = strings.TrimPrefix(template, "/")
+
+	// Split on '/' and ':', ignoring both inside variables
+	var parts []string
+	verb := ""
+	depth, start := 0, 0
+	for i := 0; i < len(template); i++ {
+		switch c := template[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+		case c == '/' && depth == 0:
+			parts = append(parts, template[start:i])
+			start = i + 1
+		case c == ':' && depth == 0 && verb == "":
+			verb = template[i+1:]
+			template = template[:i]
+		}
+	}
+	parts = append(parts, template[start:])
+
+	var segments []string
+	for _, part := range parts {
+		if !strings.HasPrefix(part, "{") {
+			segments = append(segments, part)
+			continue
+		}
+		variable := strings.TrimSuffix(strings.TrimPrefix(part, "{"), "}")
+		if _, pattern, ok := strings.Cut(variable, "="); ok {
+			segments = append(segments, strings.Split(pattern, "/")...)
+		} else {
+			segments = append(segments, "*")
+		}
+	}
+	return segments, verb
+}
+
+// matches reports whether urlPath matches the route's path template.
+func (r httpRoute) matches(urlPath string) bool {
+	components := strings.Split(strings.TrimPrefix(urlPath, "/"), "/")
+	if r.verb != "" {
+		last := components[len(components)-1]
+		if !strings.HasSuffix(last, ":"+r.verb) {
+			return false
+		}
+		components[len(components)-1] = strings.TrimSuffix(last, ":"+r.verb)
+	}
+	return matchSegments(r.segments, components)
+}
+
+// shadowedBy reports whether the built-in mux pattern takes every request
+// to the route: pattern is an exact path, or a subtree if it ends in "/".
+// Routes that only match the pattern through a variable are not reported.
+func (r httpRoute) shadowedBy(pattern string) bool {
+	literal := strings.Split(strings.Trim(pattern, "/"), "/")
+	subtree := strings.HasSuffix(pattern, "/")
+	if len(r.segments) < len(literal) || !subtree && (len(r.segments) != len(literal) || r.verb != "") {
+		return false
+	}
+	for i, seg := range literal {
+		if r.segments[i] != seg {
+			return false
+		}
+	}
+	return true
+}
+
+// warnShadowedRoutes logs the REST routes that never reach the gateway
+// because a built-in endpoint is registered on their path.
+func warnShadowedRoutes(cfg *serverConfig, routes []httpRoute) {
+	for _, b := range builtinRoutes(cfg) {
+		if b.pattern == "/" {
+			continue
+		}
+		for _, r := range routes {
+			if r.shadowedBy(b.pattern) {
+				cfg.logger.Warn("REST route shadowed by a built-in endpoint", "component", "gateway",
+					"method", r.method, "route", r.template, "endpoint", b.pattern, "owner", b.owner)
+			}
+		}
+	}
+}
+
+// matchSegments matches path components against template segments, where
+// "*" matches one component and "**" matches all remaining ones.
+func matchSegments(segments, components []string) bool {
+	for i, seg := range segments {
+		if seg == "**" {
+			return true
+		}
+		if i >= len(components) {
+			return false
+		}
+		if seg != "*" && seg != components[i] {
+			return false
+		}
+	}
+	return len(segments) == len(components)
+}
+
+// allowedMethods returns the methods of the routes matching urlPath, plus
+// OPTIONS, or nil if no route matches.
+func allowedMethods(routes []httpRoute, urlPath string) []string {
+	seen := make(map[string]bool)
+	for _, r := range routes {
+		if r.matches(urlPath) {
+			seen[r.method] = true
+		}
+	}
+	if len(seen) == 0 {
+		return nil
+	}
+	seen[http.MethodOptions] = true
+
+	methods := make([]string, 0, len(seen))
+	for m := range seen {
+		methods = append(methods, m)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// WithNotFoundHandler sets the handler for requests matching no route. It
+// replaces the gateway's default error body, e.g. to return a branded JSON
+// error. Unknown paths always reach the gateway catch-all, so this covers
+// every 404 produced by routing (handlers may still return their own 404s).
+//
+// Example:
+//
+//	grpckit.WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+//	    w.Header().Set("Content-Type", "application/json")
+//	    w.WriteHeader(http.StatusNotFound)
+//	    json.NewEncoder(w).Encode(apiError{Code: "not_found", Message: "no such endpoint"})
+//	}))
+func WithNotFoundHandler(h http.Handler) Option {
+	return func(c *serverConfig) {
+		c.notFoundHandler = h
+	}
+}
+
+// WithMethodNotAllowedHandler sets the handler for requests to a known REST
+// path with an unsupported method. The Allow header is set before the
+// handler runs. OPTIONS requests are still answered with 204.
+func WithMethodNotAllowedHandler(h http.Handler) Option {
+	return func(c *serverConfig) {
+		c.methodNotAllowedHandler = h
+	}
+}
+
+// gatewayRoutingErrorHandler answers requests for known REST paths with an
+// unsupported method: OPTIONS gets 204 and other methods 405, both with an
+// Allow header listing the methods declared for the path. The gateway
+// itself reports these as 501 Not Implemented. Requests matching no route
+// go to the WithNotFoundHandler handler if set; other routing errors keep
+// the default handling.
+func gatewayRoutingErrorHandler(cfg *serverConfig, routes []httpRoute) runtime.RoutingErrorHandlerFunc {
+	return func(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, httpStatus int) {
+		switch {
+		case httpStatus == http.StatusNotFound && cfg.notFoundHandler != nil:
+			cfg.notFoundHandler.ServeHTTP(w, r)
+			return
+		case httpStatus != http.StatusMethodNotAllowed:
+			runtime.DefaultRoutingErrorHandler(ctx, mux, m, w, r, httpStatus)
+			return
+		}
+
+		if allow := allowedMethods(routes, r.URL.Path); len(allow) > 0 {
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+		}
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if cfg.methodNotAllowedHandler != nil {
+			cfg.methodNotAllowedHandler.ServeHTTP(w, r)
+			return
+		}
+		gatewayErrorHandler(ctx, mux, m, w, r, &runtime.HTTPStatusError{
+			HTTPStatus: http.StatusMethodNotAllowed,
+			Err:        status.Error(codes.Unimplemented, http.StatusText(http.StatusMethodNotAllowed)),
+		})
+	}
+}
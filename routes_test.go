@@ -0,0 +1,235 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func newItemGatewayHandler(t *testing.T, extra ...Option) http.Handler {
+	t.Helper()
+	opts := append([]Option{
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, itempb.UnimplementedItemServiceServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+	}, extra...)
+	s, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	dialOpts := []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}
+	gwMux, err := s.newGatewayMux(context.Background(), "localhost:0", dialOpts)
+	if err != nil {
+		t.Fatalf("newGatewayMux failed: %v", err)
+	}
+	return s.buildHTTPHandler(gwMux)
+}
+
+func TestGatewayRouting_MethodNotAllowed(t *testing.T) {
+	handler := newItemGatewayHandler(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/42", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("expected 405, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Allow"); got != "DELETE, GET, OPTIONS, PATCH, PUT" {
+		t.Errorf("unexpected Allow header %q", got)
+	}
+	if !strings.Contains(rec.Body.String(), "Method Not Allowed") {
+		t.Errorf("expected gateway error body, got %q", rec.Body.String())
+	}
+}
+
+func TestGatewayRouting_Options(t *testing.T) {
+	handler := newItemGatewayHandler(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/items", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "GET, OPTIONS, POST" {
+		t.Errorf("unexpected Allow header %q", got)
+	}
+}
+
+func TestGatewayRouting_UnknownPath(t *testing.T) {
+	handler := newItemGatewayHandler(t)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/unknown", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("Allow"); got != "" {
+		t.Errorf("expected no Allow header, got %q", got)
+	}
+}
+
+func TestWithNotFoundHandler(t *testing.T) {
+	handler := newItemGatewayHandler(t, WithNotFoundHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"not_found"}`))
+	})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil))
+	if rec.Code != http.StatusNotFound || rec.Body.String() != `{"code":"not_found"}` {
+		t.Errorf("expected custom 404, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// Method mismatches keep the built-in 405
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/42", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405, got %d", rec.Code)
+	}
+}
+
+func TestWithMethodNotAllowedHandler(t *testing.T) {
+	handler := newItemGatewayHandler(t, WithMethodNotAllowedHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		_, _ = w.Write([]byte(`{"code":"method_not_allowed","allow":"` + w.Header().Get("Allow") + `"}`))
+	})))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items/42", nil))
+	if rec.Code != http.StatusMethodNotAllowed || !strings.Contains(rec.Body.String(), `"allow":"DELETE, GET, OPTIONS, PATCH, PUT"`) {
+		t.Errorf("expected custom 405 with Allow, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// OPTIONS is still answered automatically
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/v1/items/42", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("expected 204 for OPTIONS, got %d", rec.Code)
+	}
+}
+
+func TestParsePathTemplate(t *testing.T) {
+	cases := []struct {
+		template string
+		segments []string
+		verb     string
+	}{
+		{"/api/v1/items", []string{"api", "v1", "items"}, ""},
+		{"/api/v1/items/{id}", []string{"api", "v1", "items", "*"}, ""},
+		{"/v1/{name=projects/*/items/*}", []string{"v1", "projects", "*", "items", "*"}, ""},
+		{"/v1/{name=items/*}:cancel", []string{"v1", "items", "*"}, "cancel"},
+		{"/v1/files/{path=**}", []string{"v1", "files", "**"}, ""},
+	}
+	for _, tc := range cases {
+		segments, verb := parsePathTemplate(tc.template)
+		if !reflect.DeepEqual(segments, tc.segments) || verb != tc.verb {
+			t.Errorf("parsePathTemplate(%q): got %q :%q, expected %q :%q", tc.template, segments, verb, tc.segments, tc.verb)
+		}
+	}
+}
+
+func TestHTTPRouteMatches(t *testing.T) {
+	cases := []struct {
+		template string
+		path     string
+		want     bool
+	}{
+		{"/api/v1/items/{id}", "/api/v1/items/42", true},
+		{"/api/v1/items/{id}", "/api/v1/items", false},
+		{"/api/v1/items/{id}", "/api/v1/items/42/tags", false},
+		{"/v1/{name=items/*}:cancel", "/v1/items/42:cancel", true},
+		{"/v1/{name=items/*}:cancel", "/v1/items/42", false},
+		{"/v1/files/{path=**}", "/v1/files/a/b/c.txt", true},
+	}
+	for _, tc := range cases {
+		route, ok := newHTTPRoute(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: tc.template}})
+		if !ok {
+			t.Fatalf("newHTTPRoute(%q) failed", tc.template)
+		}
+		if got := route.matches(tc.path); got != tc.want {
+			t.Errorf("%q matches %q: expected %v, got %v", tc.template, tc.path, tc.want, got)
+		}
+	}
+}
+
+func TestCollectHTTPRoutes(t *testing.T) {
+	grpcServer := grpc.NewServer()
+	itempb.RegisterItemServiceServer(grpcServer, itempb.UnimplementedItemServiceServer{})
+
+	routes := collectHTTPRoutes(grpcServer)
+	if len(routes) != 6 {
+		t.Fatalf("expected 6 routes, got %d", len(routes))
+	}
+	if got := allowedMethods(routes, "/api/v1/items/7"); !reflect.DeepEqual(got, []string{"DELETE", "GET", "OPTIONS", "PATCH", "PUT"}) {
+		t.Errorf("unexpected allowed methods %v", got)
+	}
+	if got := allowedMethods(routes, "/nope"); got != nil {
+		t.Errorf("expected no allowed methods, got %v", got)
+	}
+}
+
+func TestNewHTTPRoute_Custom(t *testing.T) {
+	route, ok := newHTTPRoute(&annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{
+		Custom: &annotations.CustomHttpPattern{Kind: "HEAD", Path: "/v1/items"},
+	}})
+	if !ok || route.method != "HEAD" || !route.matches("/v1/items") {
+		t.Errorf("unexpected custom route %+v", route)
+	}
+	if _, ok := newHTTPRoute(&annotations.HttpRule{}); ok {
+		t.Error("expected rule without pattern to be rejected")
+	}
+}
+
+func TestHTTPRouteShadowedBy(t *testing.T) {
+	cases := []struct {
+		template string
+		pattern  string
+		want     bool
+	}{
+		{"/metrics", "/metrics", true},
+		{"/metrics/{name}", "/metrics", false},
+		{"/metrics:reset", "/metrics", false},
+		{"/{name}", "/metrics", false},
+		{"/swagger", "/swagger/", true},
+		{"/swagger/{file=**}", "/swagger/", true},
+		{"/internal/healthz", "/internal/healthz", true},
+		{"/api/v1/items", "/swagger/", false},
+	}
+	for _, tc := range cases {
+		route, _ := newHTTPRoute(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: tc.template}})
+		if got := route.shadowedBy(tc.pattern); got != tc.want {
+			t.Errorf("%q shadowed by %q: expected %v, got %v", tc.template, tc.pattern, tc.want, got)
+		}
+	}
+}
+
+func TestWarnShadowedRoutes(t *testing.T) {
+	buf := captureLog(t)
+	cfg := newServerConfig()
+	WithMetricsPath("/internal/metrics")(cfg)
+	WithHealthCheck()(cfg)
+
+	var routes []httpRoute
+	for _, template := range []string{"/healthz", "/metrics", "/api/v1/items"} {
+		route, _ := newHTTPRoute(&annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: template}})
+		routes = append(routes, route)
+	}
+	warnShadowedRoutes(cfg, routes)
+
+	out := buf.String()
+	if !strings.Contains(out, "method=GET route=/healthz endpoint=/healthz owner=WithHealthCheck") {
+		t.Errorf("expected warning for /healthz, got %q", out)
+	}
+	if strings.Contains(out, "route=/metrics") || strings.Contains(out, "/api/v1/items") {
+		t.Errorf("unexpected warnings: %q", out)
+	}
+}
@@ -0,0 +1,68 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gyozatech/grpckit/secrets"
+)
+
+// WithSecrets sets where secrets are read from: the admin token of
+// AdminTokenSecret and the admin.token_secret config key, and the secrets
+// of the application read with Server.Secret (HMAC keys, API keys, ...).
+// Secrets of the configuration are read once by New; one that cannot be
+// read makes New return ErrInvalidConfig.
+//
+// Example:
+//
+//	grpckit.WithSecrets(secrets.Dir("/run/secrets")),
+//	grpckit.WithAdminAPI(grpckit.AdminTokenSecret("admin-token")),
+func WithSecrets(provider secrets.Provider) Option {
+	return func(c *serverConfig) {
+		if provider == nil {
+			c.invalid("WithSecrets: nil provider")
+			return
+		}
+		c.secrets = provider
+	}
+}
+
+// Secret returns the secret name from the provider set with WithSecrets.
+//
+// Example:
+//
+//	key, err := server.Secret(ctx, "jwt-hmac-key")
+func (s *Server) Secret(ctx context.Context, name string) (string, error) {
+	if s.cfg.secrets == nil {
+		return "", fmt.Errorf("%w: no secrets provider (see WithSecrets)", ErrInvalidConfig)
+	}
+	return s.cfg.secrets.Secret(ctx, name)
+}
+
+// resolveSecrets reads the secrets referenced by the configuration.
+func resolveSecrets(cfg *serverConfig) {
+	admin := cfg.adminConfig
+	if admin == nil {
+		return
+	}
+	switch {
+	case admin.tokenFile != "":
+		token, err := secrets.ReadFile(admin.tokenFile)
+		if err != nil {
+			cfg.invalid("admin token: %w", err)
+			return
+		}
+		admin.token = token
+	case admin.tokenSecret != "":
+		if cfg.secrets == nil {
+			cfg.invalid("admin token: secret %q without a secrets provider (see WithSecrets)", admin.tokenSecret)
+			return
+		}
+		token, err := cfg.secrets.Secret(context.Background(), admin.tokenSecret)
+		if err != nil {
+			cfg.invalid("admin token: %w", err)
+			return
+		}
+		admin.token = token
+	}
+}
@@ -0,0 +1,171 @@
+// Package secrets defines where grpckit reads secret values from (see
+// grpckit.WithSecrets), so tokens, HMAC keys and API keys never sit in the
+// YAML config or the code.
+//
+// Env reads environment variables, Dir reads files such as Kubernetes
+// secret volumes, and Vault reads a HashiCorp Vault KV v2 engine. Implement
+// Provider (or use ProviderFunc) for other secret managers.
+//
+// Example:
+//
+//	provider := secrets.Vault(secrets.VaultConfig{
+//	    Address: "https://vault.internal:8200",
+//	    Token:   os.Getenv("VAULT_TOKEN"),
+//	})
+//	key, err := provider.Secret(ctx, "orders/jwt#hmac_key")
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by providers for secrets that don't exist.
+var ErrNotFound = errors.New("secret not found")
+
+// Provider resolves secrets by name. Implementations must be safe for
+// concurrent use.
+type Provider interface {
+	// Secret returns the value of the secret name, or an error wrapping
+	// ErrNotFound if there is none.
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// ProviderFunc adapts a function to Provider.
+type ProviderFunc func(ctx context.Context, name string) (string, error)
+
+// Secret calls f.
+func (f ProviderFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// Env returns a Provider reading the environment variable prefix+name.
+func Env(prefix string) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		v, ok := os.LookupEnv(prefix + name)
+		if !ok {
+			return "", fmt.Errorf("%w: environment variable %s", ErrNotFound, prefix+name)
+		}
+		return v, nil
+	})
+}
+
+// Dir returns a Provider reading the file name in dir, such as a mounted
+// Kubernetes secret or /run/secrets of Docker. A trailing newline is
+// removed. Names must not leave dir.
+func Dir(dir string) Provider {
+	return ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		if !filepath.IsLocal(name) {
+			return "", fmt.Errorf("secret name %q is not a file in %s", name, dir)
+		}
+		return ReadFile(filepath.Join(dir, name))
+	})
+}
+
+// ReadFile reads a secret from a file, removing a trailing newline.
+func ReadFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%w: %v", ErrNotFound, err)
+	}
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimSuffix(string(data), "\n")
+	return strings.TrimSuffix(s, "\r"), nil
+}
+
+// VaultConfig configures a Vault provider.
+type VaultConfig struct {
+	// Address is the Vault server URL, e.g. "https://vault.internal:8200".
+	Address string
+
+	// Token authenticates the requests.
+	Token string
+
+	// Mount is the mount path of the KV v2 secrets engine.
+	// Default: "secret"
+	Mount string
+
+	// Namespace is the Vault Enterprise namespace, if any.
+	Namespace string
+
+	// Client sends the requests.
+	// Default: an http.Client with a 10s timeout
+	Client *http.Client
+}
+
+// Vault returns a Provider reading a HashiCorp Vault KV v2 secrets engine.
+// Names are "<path>#<key>", e.g. "orders/jwt#hmac_key"; without "#<key>"
+// the key is "value". Secrets are read on every call; cache them if they
+// are read per request.
+func Vault(config VaultConfig) Provider {
+	if config.Mount == "" {
+		config.Mount = "secret"
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &vaultProvider{config: config}
+}
+
+// vaultProvider reads secrets from the KV v2 HTTP API.
+type vaultProvider struct {
+	config VaultConfig
+}
+
+func (p *vaultProvider) Secret(ctx context.Context, name string) (string, error) {
+	path, key, ok := strings.Cut(name, "#")
+	if !ok {
+		key = "value"
+	}
+	endpoint, err := url.JoinPath(p.config.Address, "v1", p.config.Mount, "data", path)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	req.Header.Set("X-Vault-Token", p.config.Token)
+	if p.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.config.Namespace)
+	}
+
+	resp, err := p.config.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("vault: %w", err)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return "", fmt.Errorf("%w: vault path %s", ErrNotFound, path)
+	case resp.StatusCode != http.StatusOK:
+		return "", fmt.Errorf("vault: reading %s: %s", path, resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("vault: reading %s: %w", path, err)
+	}
+	v, ok := body.Data.Data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: vault key %s of %s", ErrNotFound, key, path)
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("vault: key %s of %s is not a string", key, path)
+}
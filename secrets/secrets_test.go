@@ -0,0 +1,79 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("APP_API_KEY", "k-123")
+	p := Env("APP_")
+
+	if v, err := p.Secret(context.Background(), "API_KEY"); err != nil || v != "k-123" {
+		t.Errorf("expected k-123, got %q, %v", v, err)
+	}
+	if _, err := p.Secret(context.Background(), "MISSING"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "hmac-key"), []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p := Dir(dir)
+
+	if v, err := p.Secret(context.Background(), "hmac-key"); err != nil || v != "s3cret" {
+		t.Errorf("expected s3cret without the newline, got %q, %v", v, err)
+	}
+	if _, err := p.Secret(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := p.Secret(context.Background(), "../etc/passwd"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected names outside the directory to be rejected, got %v", err)
+	}
+}
+
+func TestVault(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/data/orders/jwt":
+			w.Write([]byte(`{"data":{"data":{"hmac_key":"h-1","value":"v-1","port":8080},"metadata":{"version":3}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	p := Vault(VaultConfig{Address: srv.URL, Token: "root", Mount: "kv"})
+	ctx := context.Background()
+
+	if v, err := p.Secret(ctx, "orders/jwt#hmac_key"); err != nil || v != "h-1" {
+		t.Errorf("expected h-1, got %q, %v", v, err)
+	}
+	if v, err := p.Secret(ctx, "orders/jwt"); err != nil || v != "v-1" {
+		t.Errorf("expected the value key by default, got %q, %v", v, err)
+	}
+	for _, name := range []string{"orders/missing", "orders/jwt#missing"} {
+		if _, err := p.Secret(ctx, name); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound, got %v", name, err)
+		}
+	}
+	if _, err := p.Secret(ctx, "orders/jwt#port"); err == nil {
+		t.Error("expected an error for a non-string value")
+	}
+
+	denied := Vault(VaultConfig{Address: srv.URL, Token: "wrong", Mount: "kv"})
+	if _, err := denied.Secret(ctx, "orders/jwt"); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("expected a permission error, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gyozatech/grpckit/secrets"
+	"google.golang.org/grpc"
+)
+
+func TestAdminTokenSecret(t *testing.T) {
+	provider := secrets.ProviderFunc(func(ctx context.Context, name string) (string, error) {
+		if name == "admin-token" {
+			return "from-provider", nil
+		}
+		return "", secrets.ErrNotFound
+	})
+	s := newSlowTestServer(t, WithSecrets(provider), WithAdminAPI(AdminTokenSecret("admin-token")))
+	if s.cfg.adminConfig.token != "from-provider" {
+		t.Errorf("expected the admin token from the provider, got %q", s.cfg.adminConfig.token)
+	}
+	if v, err := s.Secret(context.Background(), "admin-token"); err != nil || v != "from-provider" {
+		t.Errorf("expected Secret to read the provider, got %q, %v", v, err)
+	}
+
+	_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithSecrets(provider), WithAdminAPI(AdminTokenSecret("missing")))
+	if !errors.Is(err, ErrInvalidConfig) || !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("expected a missing secret to be a config error, got %v", err)
+	}
+}
+
+func TestAdminTokenSecret_NoProvider(t *testing.T) {
+	_, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithAdminAPI(AdminTokenSecret("admin-token")))
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+
+	s := newSlowTestServer(t)
+	if _, err := s.Secret(context.Background(), "x"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig without a provider, got %v", err)
+	}
+}
+
+func TestWithConfigFile_AdminTokenFile(t *testing.T) {
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "admin-token")
+	if err := os.WriteFile(tokenPath, []byte("from-file\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(configPath, []byte("admin:\n  enabled: true\n  token_file: "+tokenPath+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := newSlowTestServer(t, WithConfigFile(configPath))
+	if s.cfg.adminConfig == nil || s.cfg.adminConfig.token != "from-file" {
+		t.Errorf("expected the admin token from the file, got %+v", s.cfg.adminConfig)
+	}
+}
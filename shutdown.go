@@ -0,0 +1,149 @@
+package grpckit
+
+import (
+	"context"
+	"net"
+
+	"google.golang.org/grpc"
+)
+
+// serverKey is the context key of the server handling a request.
+type serverKey struct{}
+
+// ShutdownContext returns a copy of ctx that is also cancelled, with cause
+// ErrServerShuttingDown, when the server handling the request starts
+// draining connections on shutdown (after WithShutdownDelay). Long-running
+// streams can use it to checkpoint and end cleanly while the graceful
+// shutdown timeout (see WithGracefulShutdown) still runs, instead of being
+// cut off when it expires.
+//
+// ctx must be the context of a gRPC call or HTTP request served by grpckit;
+// otherwise the returned context is only cancelled with ctx. Call cancel
+// once done to release resources.
+//
+// Example:
+//
+//	func (s *FeedService) Watch(req *pb.WatchRequest, stream pb.FeedService_WatchServer) error {
+//	    ctx, cancel := grpckit.ShutdownContext(stream.Context())
+//	    defer cancel()
+//	    for {
+//	        select {
+//	        case <-ctx.Done():
+//	            s.saveCursor(req.ClientId)
+//	            return context.Cause(ctx) // ErrServerShuttingDown: codes.Unavailable
+//	        case ev := <-s.events:
+//	            if err := stream.Send(ev); err != nil {
+//	                return err
+//	            }
+//	        }
+//	    }
+//	}
+func ShutdownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	s, ok := ctx.Value(serverKey{}).(*Server)
+	if !ok {
+		return ctx, func() { cancel(context.Canceled) }
+	}
+	draining := s.draining
+	stop := context.AfterFunc(draining, func() {
+		cancel(context.Cause(draining))
+	})
+	return ctx, func() {
+		stop()
+		cancel(context.Canceled)
+	}
+}
+
+// withServer returns ctx carrying the server, for ShutdownContext and the
+// server's logger.
+func (s *Server) withServer(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serverKey{}, s)
+}
+
+// shutdownUnaryInterceptor makes ShutdownContext work in unary handlers and
+// counts active calls, also per method for Stats.
+func (s *Server) shutdownUnaryInterceptor(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	s.rpcs.Add(1)
+	defer s.rpcDone()
+	active := counter(&s.stats.streams, info.FullMethod)
+	active.Add(1)
+	defer active.Add(-1)
+	return handler(s.withServer(ctx), req)
+}
+
+// shutdownStreamInterceptor makes ShutdownContext work in stream handlers and
+// counts active calls, also per method for Stats.
+func (s *Server) shutdownStreamInterceptor(
+	srv interface{},
+	ss grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	s.rpcs.Add(1)
+	defer s.rpcDone()
+	active := counter(&s.stats.streams, info.FullMethod)
+	active.Add(1)
+	defer active.Add(-1)
+	return handler(srv, &contextServerStream{ServerStream: ss, ctx: s.withServer(ss.Context())})
+}
+
+// rpcDone ends an active call, signaling waitRPCs when it was the last one.
+func (s *Server) rpcDone() {
+	if s.rpcs.Add(-1) == 0 && s.draining.Err() != nil {
+		select {
+		case s.rpcsIdle <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// waitRPCs waits until no gRPC call is active, or ctx is done. It must be
+// called after draining began.
+func (s *Server) waitRPCs(ctx context.Context) bool {
+	for s.rpcs.Load() > 0 {
+		select {
+		case <-s.rpcsIdle:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// stopGRPC stops the gRPC server once its active calls ended, or closes
+// them when ctx expires. In combined mode calls are served through the HTTP
+// server (grpc.Server.ServeHTTP), which GracefulStop doesn't support: the
+// HTTP server sends GOAWAY, and the calls are waited for here.
+func (s *Server) stopGRPC(ctx context.Context) {
+	if s.cfg.grpcPort == s.cfg.httpPort {
+		if !s.waitRPCs(ctx) {
+			s.logger.Warn("graceful shutdown timed out, closing remaining gRPC calls", "component", "shutdown", "timeout", s.cfg.gracefulTimeout)
+		}
+		s.grpcServer.Stop()
+		return
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		s.logger.Warn("graceful shutdown timed out, closing remaining gRPC calls", "component", "shutdown", "timeout", s.cfg.gracefulTimeout)
+		s.grpcServer.Stop()
+		<-stopped
+	}
+}
+
+// baseContext is the http.Server base context making ShutdownContext and the
+// server's logger available to HTTP handlers.
+func (s *Server) baseContext(net.Listener) context.Context {
+	return s.withServer(context.Background())
+}
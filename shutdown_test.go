@@ -0,0 +1,155 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestShutdownContext_WithoutServer(t *testing.T) {
+	ctx, cancel := ShutdownContext(context.Background())
+	if ctx.Err() != nil {
+		t.Fatal("expected context not to be cancelled")
+	}
+	cancel()
+	if !errors.Is(context.Cause(ctx), context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", context.Cause(ctx))
+	}
+}
+
+func TestShutdownContext_Interceptors(t *testing.T) {
+	s := newSlowTestServer(t)
+
+	var unaryCtx context.Context
+	_, _ = s.shutdownUnaryInterceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		unaryCtx = ctx
+		return nil, nil
+	})
+	var streamCtx context.Context
+	_ = s.shutdownStreamInterceptor(nil, &contextServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		streamCtx = ss.Context()
+		return nil
+	})
+	httpCtx := s.baseContext(nil)
+
+	var ctxs []context.Context
+	for _, parent := range []context.Context{unaryCtx, streamCtx, httpCtx} {
+		ctx, cancel := ShutdownContext(parent)
+		defer cancel()
+		if ctx.Err() != nil {
+			t.Fatal("expected context not to be cancelled before shutdown")
+		}
+		ctxs = append(ctxs, ctx)
+	}
+
+	s.drain(ErrServerShuttingDown)
+	for i, ctx := range ctxs {
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+			t.Fatalf("context %d: expected cancellation when draining begins", i)
+		}
+		if !errors.Is(context.Cause(ctx), ErrServerShuttingDown) {
+			t.Errorf("context %d: expected ErrServerShuttingDown, got %v", i, context.Cause(ctx))
+		}
+	}
+}
+
+// feedService is a streaming service whose Watch method ends on shutdown
+// and whose Stuck method ignores it.
+var feedService = grpc.ServiceDesc{
+	ServiceName: "test.Feed",
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Watch", ServerStreams: true, Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			ctx, cancel := ShutdownContext(stream.Context())
+			defer cancel()
+			if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+				return err
+			}
+			<-ctx.Done()
+			return context.Cause(ctx)
+		}},
+		{StreamName: "Stuck", ServerStreams: true, Handler: func(srv interface{}, stream grpc.ServerStream) error {
+			if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+				return err
+			}
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}},
+	},
+}
+
+func TestShutdown_NotifiesStreamsAndStopsAtDeadline(t *testing.T) {
+	bound := make(chan ListenerBound, 1)
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) { s.RegisterService(&feedService, struct{}{}) }),
+		WithGRPCPort(0),
+		WithHTTPPort(0),
+		WithGracefulShutdown(200*time.Millisecond),
+		WithEventListener(func(e Event) {
+			if lb, ok := e.(ListenerBound); ok {
+				bound <- lb
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+
+	var addr string
+	select {
+	case lb := <-bound:
+		addr = lb.Addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ListenerBound")
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	openStream := func(method string) grpc.ClientStream {
+		stream, err := conn.NewStream(context.Background(), &grpc.StreamDesc{ServerStreams: true}, method)
+		if err != nil {
+			t.Fatalf("%s: %v", method, err)
+		}
+		if err := stream.SendMsg(&emptypb.Empty{}); err != nil {
+			t.Fatalf("%s: send: %v", method, err)
+		}
+		_ = stream.CloseSend()
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			t.Fatalf("%s: recv: %v", method, err)
+		}
+		return stream
+	}
+	watch := openStream("/test.Feed/Watch")
+	stuck := openStream("/test.Feed/Stuck")
+
+	start := time.Now()
+	s.Shutdown()
+	if err := <-errCh; err != nil {
+		t.Fatalf("Start returned error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected shutdown to stop at the graceful timeout, took %v", elapsed)
+	}
+
+	if err := watch.RecvMsg(&emptypb.Empty{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected notified stream to end with Unavailable, got %v", err)
+	}
+	if err := stuck.RecvMsg(&emptypb.Empty{}); err == nil {
+		t.Error("expected stuck stream to be closed at the deadline")
+	}
+}
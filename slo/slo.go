@@ -0,0 +1,276 @@
+// Package slo generates Prometheus rules for availability and latency SLOs
+// of grpckit services, from the metrics exported with grpckit.WithMetrics.
+//
+// Every SLO gets the same recorded series, labeled with slo and service, so
+// one dashboard works for all services:
+//
+//	slo:error_ratio:rate5m (also 30m, 1h, 2h, 6h, 1d, 3d and the period)
+//	slo:burn_rate:rate5m   (same windows)
+//	slo:objective
+//	slo:error_budget_remaining
+//
+// and the multiwindow, multi-burn-rate alert SLOErrorBudgetBurn, with
+// severity page (2% of the budget burnt in 1h, or 5% in 6h) or ticket (10%
+// in 1d, or 10% in 3d).
+//
+// Errors are requests with a 5xx status class; for gRPC, calls whose code
+// the gateway maps to a 5xx status (Internal, Unavailable, Unknown, ...).
+//
+// Example:
+//
+//	rules, err := slo.Rules(
+//	    slo.Availability("orders", 0.999),
+//	    slo.Latency("orders", 0.99, 250*time.Millisecond),
+//	)
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	os.WriteFile("orders-slo.rules.yml", rules, 0o644)
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"gopkg.in/yaml.v3"
+)
+
+// Protocol selects the metrics an SLO is computed from.
+type Protocol string
+
+const (
+	// HTTP uses the HTTP metrics, which include the REST calls proxied to
+	// gRPC by the gateway.
+	HTTP Protocol = "http"
+	// GRPC uses the gRPC metrics, which include the gateway's calls too.
+	GRPC Protocol = "grpc"
+)
+
+// DefaultPeriod is the SLO period used when SLO.Period is zero. The alert
+// thresholds assume it.
+const DefaultPeriod = 30 * 24 * time.Hour
+
+// SLO is a service level objective of one service.
+type SLO struct {
+	// Name identifies the SLO in the slo label, e.g. "orders-availability".
+	// Defaults to the service followed by "-availability" or "-latency".
+	Name string
+
+	// Service is the value of the service label of the recorded series.
+	Service string
+
+	// Selector is the PromQL label matchers selecting the service's series.
+	// Defaults to job="<Service>".
+	Selector string
+
+	// Protocol selects the HTTP (default) or gRPC metrics.
+	Protocol Protocol
+
+	// Objective is the target fraction of good requests, e.g. 0.999.
+	Objective float64
+
+	// Latency makes this a latency SLO: good requests complete within it.
+	// It must be a bucket of the duration histograms (the Prometheus
+	// default buckets: 5ms, 10ms, 25ms, 50ms, 100ms, 250ms, 500ms, 1s, 2.5s,
+	// 5s, 10s). Zero makes it an availability SLO.
+	Latency time.Duration
+
+	// Period is the time the objective is measured over. Defaults to
+	// DefaultPeriod.
+	Period time.Duration
+
+	// Namespace is the metrics namespace. Defaults to "grpckit".
+	Namespace string
+}
+
+// Availability returns an SLO of the fraction of requests of service that
+// don't fail with a 5xx status.
+func Availability(service string, objective float64) SLO {
+	return SLO{Service: service, Objective: objective}
+}
+
+// Latency returns an SLO of the fraction of requests of service completing
+// within threshold.
+func Latency(service string, objective float64, threshold time.Duration) SLO {
+	return SLO{Service: service, Objective: objective, Latency: threshold}
+}
+
+// BurnRate returns how fast errorRatio consumes the error budget of
+// objective: at 1 the budget lasts exactly the SLO period, at 14.4 a 30-day
+// budget is gone in 50 hours.
+func BurnRate(errorRatio, objective float64) float64 {
+	return errorRatio / (1 - objective)
+}
+
+// windows are the error ratio windows recorded for every SLO.
+var windows = []time.Duration{
+	5 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 6 * time.Hour, 24 * time.Hour, 3 * 24 * time.Hour,
+}
+
+// burnAlerts are the multiwindow, multi-burn-rate alerts: the burn rate
+// must exceed the threshold over both windows.
+var burnAlerts = []struct {
+	long, short time.Duration
+	burnRate    float64
+	severity    string
+}{
+	{time.Hour, 5 * time.Minute, 14.4, "page"},
+	{6 * time.Hour, 30 * time.Minute, 6, "page"},
+	{24 * time.Hour, 2 * time.Hour, 3, "ticket"},
+	{3 * 24 * time.Hour, 6 * time.Hour, 1, "ticket"},
+}
+
+// Rules returns a Prometheus rule file with a group of recording and
+// alerting rules per SLO.
+func Rules(slos ...SLO) ([]byte, error) {
+	if len(slos) == 0 {
+		return nil, errors.New("slo: no SLOs")
+	}
+	file := ruleFile{}
+	names := map[string]bool{}
+	for _, s := range slos {
+		s, err := s.withDefaults()
+		if err != nil {
+			return nil, err
+		}
+		if names[s.Name] {
+			return nil, fmt.Errorf("slo: duplicate SLO %q", s.Name)
+		}
+		names[s.Name] = true
+		file.Groups = append(file.Groups, s.group())
+	}
+	return yaml.Marshal(file)
+}
+
+// withDefaults validates s and fills in its defaults.
+func (s SLO) withDefaults() (SLO, error) {
+	if s.Service == "" {
+		return s, errors.New("slo: missing service")
+	}
+	if s.Objective <= 0 || s.Objective >= 1 {
+		return s, fmt.Errorf("slo: objective of %s must be between 0 and 1, got %v", s.Service, s.Objective)
+	}
+	if s.Name == "" {
+		s.Name = s.Service + "-availability"
+		if s.Latency > 0 {
+			s.Name = s.Service + "-latency"
+		}
+	}
+	if s.Selector == "" {
+		s.Selector = "job=" + strconv.Quote(s.Service)
+	}
+	switch s.Protocol {
+	case "":
+		s.Protocol = HTTP
+	case HTTP, GRPC:
+	default:
+		return s, fmt.Errorf("slo: unknown protocol %q", s.Protocol)
+	}
+	if s.Latency < 0 || (s.Latency > 0 && !slices.Contains(prometheus.DefBuckets, s.Latency.Seconds())) {
+		return s, fmt.Errorf("slo: latency %v of %s is not a histogram bucket", s.Latency, s.Name)
+	}
+	if s.Period == 0 {
+		s.Period = DefaultPeriod
+	}
+	if s.Period < 0 {
+		return s, fmt.Errorf("slo: negative period for %s", s.Name)
+	}
+	if s.Namespace == "" {
+		s.Namespace = "grpckit"
+	}
+	return s, nil
+}
+
+// errorRatio returns the PromQL expression of the error ratio over window.
+func (s SLO) errorRatio(window string) string {
+	if s.Latency > 0 {
+		histogram := fmt.Sprintf("%s_%s_request_duration_seconds", s.Namespace, s.Protocol)
+		le := strconv.FormatFloat(s.Latency.Seconds(), 'f', -1, 64)
+		return fmt.Sprintf("1 - (\n  sum(rate(%s_bucket{%s,le=%q}[%s]))\n/\n  sum(rate(%s_count{%s}[%s]))\n)",
+			histogram, s.Selector, le, window, histogram, s.Selector, window)
+	}
+	counter := fmt.Sprintf("%s_%s_requests_total", s.Namespace, s.Protocol)
+	return fmt.Sprintf("(sum(rate(%s{%s,status_class=\"5xx\"}[%s])) or vector(0))\n/\nsum(rate(%s{%s}[%s]))",
+		counter, s.Selector, window, counter, s.Selector, window)
+}
+
+// group returns the rules of s.
+func (s SLO) group() ruleGroup {
+	labels := map[string]string{"slo": s.Name, "service": s.Service}
+	match := fmt.Sprintf("{slo=%q}", s.Name)
+	budget := strconv.FormatFloat(1-s.Objective, 'g', 10, 64) // rounds away float noise
+	period := promDuration(s.Period)
+
+	g := ruleGroup{Name: "slo:" + s.Name}
+	g.Rules = append(g.Rules, rule{
+		Record: "slo:objective",
+		Expr:   fmt.Sprintf("vector(%s)", strconv.FormatFloat(s.Objective, 'g', -1, 64)),
+		Labels: labels,
+	})
+	recorded := windows
+	if !slices.Contains(windows, s.Period) {
+		recorded = append(slices.Clone(windows), s.Period)
+	}
+	for _, w := range recorded {
+		window := promDuration(w)
+		g.Rules = append(g.Rules,
+			rule{Record: "slo:error_ratio:rate" + window, Expr: s.errorRatio(window), Labels: labels},
+			rule{Record: "slo:burn_rate:rate" + window, Expr: fmt.Sprintf("slo:error_ratio:rate%s%s / %s", window, match, budget), Labels: labels},
+		)
+	}
+	g.Rules = append(g.Rules, rule{
+		Record: "slo:error_budget_remaining",
+		Expr:   fmt.Sprintf("1 - slo:burn_rate:rate%s%s", period, match),
+		Labels: labels,
+	})
+
+	for _, a := range burnAlerts {
+		long, short := promDuration(a.long), promDuration(a.short)
+		threshold := strconv.FormatFloat(a.burnRate, 'g', -1, 64)
+		g.Rules = append(g.Rules, rule{
+			Alert: "SLOErrorBudgetBurn",
+			Expr: fmt.Sprintf("slo:burn_rate:rate%s%s > %s\nand\nslo:burn_rate:rate%s%s > %s",
+				long, match, threshold, short, match, threshold),
+			Labels: map[string]string{"slo": s.Name, "service": s.Service, "severity": a.severity, "long_window": long},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s is burning its error budget at %sx the sustainable rate over %s", s.Name, threshold, long),
+			},
+		})
+	}
+	return g
+}
+
+// promDuration formats d in the Prometheus duration format.
+func promDuration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%ds", d/time.Second)
+}
+
+// ruleFile is a Prometheus rule file.
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
@@ -0,0 +1,129 @@
+package slo
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// parseRules parses a rule file, indexing the rules of each group by
+// record name (alerts by their long window).
+func parseRules(t *testing.T, data []byte) map[string]map[string]rule {
+	t.Helper()
+	var file ruleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid rule file: %v\n%s", err, data)
+	}
+	groups := map[string]map[string]rule{}
+	for _, g := range file.Groups {
+		rules := map[string]rule{}
+		for _, r := range g.Rules {
+			name := r.Record
+			if r.Alert != "" {
+				name = r.Alert + "/" + r.Labels["long_window"]
+			}
+			rules[name] = r
+		}
+		groups[g.Name] = rules
+	}
+	return groups
+}
+
+func TestRules_Availability(t *testing.T) {
+	data, err := Rules(Availability("orders", 0.999))
+	if err != nil {
+		t.Fatalf("Rules failed: %v", err)
+	}
+	rules := parseRules(t, data)["slo:orders-availability"]
+	if rules == nil {
+		t.Fatalf("expected group slo:orders-availability, got\n%s", data)
+	}
+
+	ratio := rules["slo:error_ratio:rate5m"]
+	if !strings.Contains(ratio.Expr, `grpckit_http_requests_total{job="orders",status_class="5xx"}[5m]`) ||
+		ratio.Labels["slo"] != "orders-availability" || ratio.Labels["service"] != "orders" {
+		t.Errorf("unexpected error ratio rule %+v", ratio)
+	}
+	if burn := rules["slo:burn_rate:rate1h"]; burn.Expr != `slo:error_ratio:rate1h{slo="orders-availability"} / 0.001` {
+		t.Errorf("unexpected burn rate %q", burn.Expr)
+	}
+	if _, ok := rules["slo:error_ratio:rate30d"]; !ok {
+		t.Error("expected the error ratio over the period")
+	}
+	if remaining := rules["slo:error_budget_remaining"]; remaining.Expr != `1 - slo:burn_rate:rate30d{slo="orders-availability"}` {
+		t.Errorf("unexpected remaining budget %q", remaining.Expr)
+	}
+	if objective := rules["slo:objective"]; objective.Expr != "vector(0.999)" {
+		t.Errorf("unexpected objective %q", objective.Expr)
+	}
+
+	page := rules["SLOErrorBudgetBurn/1h"]
+	if page.Labels["severity"] != "page" || !strings.Contains(page.Expr, "rate1h{slo=\"orders-availability\"} > 14.4\nand\nslo:burn_rate:rate5m") {
+		t.Errorf("unexpected fast burn alert %+v", page)
+	}
+	if ticket := rules["SLOErrorBudgetBurn/3d"]; ticket.Labels["severity"] != "ticket" {
+		t.Errorf("unexpected slow burn alert %+v", ticket)
+	}
+}
+
+func TestRules_Latency(t *testing.T) {
+	data, err := Rules(SLO{
+		Service:   "orders",
+		Selector:  `namespace="shop",app="orders"`,
+		Protocol:  GRPC,
+		Objective: 0.99,
+		Latency:   250 * time.Millisecond,
+		Period:    7 * 24 * time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Rules failed: %v", err)
+	}
+	rules := parseRules(t, data)["slo:orders-latency"]
+	ratio := rules["slo:error_ratio:rate5m"].Expr
+	if !strings.Contains(ratio, `grpckit_grpc_request_duration_seconds_bucket{namespace="shop",app="orders",le="0.25"}[5m]`) ||
+		!strings.Contains(ratio, `grpckit_grpc_request_duration_seconds_count{namespace="shop",app="orders"}[5m]`) {
+		t.Errorf("unexpected latency error ratio %q", ratio)
+	}
+	if _, ok := rules["slo:error_ratio:rate7d"]; !ok {
+		t.Error("expected the error ratio over the 7d period")
+	}
+}
+
+func TestRules_Invalid(t *testing.T) {
+	tests := map[string][]SLO{
+		"no SLOs":       nil,
+		"no service":    {{Objective: 0.99}},
+		"objective":     {Availability("orders", 1)},
+		"protocol":      {{Service: "orders", Objective: 0.99, Protocol: "amqp"}},
+		"latency":       {Latency("orders", 0.99, 300*time.Millisecond)},
+		"duplicate SLO": {Availability("orders", 0.99), Availability("orders", 0.999)},
+	}
+	for name, slos := range tests {
+		if _, err := Rules(slos...); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestBurnRate(t *testing.T) {
+	if got := BurnRate(0.0144, 0.999); math.Abs(got-14.4) > 1e-9 {
+		t.Errorf("expected burn rate 14.4, got %v", got)
+	}
+}
+
+func TestPromDuration(t *testing.T) {
+	tests := map[time.Duration]string{
+		5 * time.Minute:     "5m",
+		2 * time.Hour:       "2h",
+		30 * 24 * time.Hour: "30d",
+		90 * time.Second:    "90s",
+	}
+	for d, want := range tests {
+		if got := promDuration(d); got != want {
+			t.Errorf("promDuration(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// slowRequestTimedMetadataKey marks gateway calls, which the HTTP middleware
+// times already, so they are not reported twice.
+const slowRequestTimedMetadataKey = "x-grpckit-slow-request-timed"
+
+// WithSlowRequestThreshold logs every HTTP request and unary gRPC call taking
+// longer than d, at warn level, with the method, duration, principal (see
+// ContextWithPrincipal) and request ID. With WithMetrics, slow requests are
+// also counted in grpckit_slow_requests_total{protocol,operation}. Streaming
+// calls are not checked, as they are long-lived by design. REST calls are
+// reported once, as HTTP requests, not again as the gRPC calls of the
+// gateway.
+//
+// The duration is measured after authentication, so it covers the handler
+// and the middleware registered with WithHTTPMiddleware.
+//
+// Example:
+//
+//	grpckit.WithSlowRequestThreshold(500 * time.Millisecond)
+//
+// Example output:
+//
+//	level=WARN msg="slow request" component=http method=GET path=/api/v1/items duration=1.52s threshold=500ms principal=user-42 request_id=4bf92f35
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(c *serverConfig) {
+		c.slowRequestThreshold = d
+	}
+}
+
+// slowRequestMiddleware reports HTTP requests exceeding the threshold.
+func slowRequestMiddleware(s *Server, next http.Handler) http.Handler {
+	threshold := s.cfg.slowRequestThreshold
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+
+		duration := time.Since(start)
+		if duration <= threshold {
+			return
+		}
+		if s.metrics != nil {
+			s.metrics.slowRequests.WithLabelValues("http", s.metrics.methodLabel(r.Method)+" "+s.metrics.pathLabel(r.URL.Path)).Inc()
+		}
+		if !s.logger.Enabled(r.Context(), slog.LevelWarn) {
+			return
+		}
+
+		requestID := requestIDFromContext(r.Context())
+		if requestID == "" {
+			requestID = r.Header.Get(requestIDHeader)
+		}
+		s.logger.Warn("slow request", "component", "http",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"duration", duration.Round(time.Millisecond),
+			"threshold", threshold,
+			"principal", PrincipalFromContext(r.Context()),
+			"request_id", requestID)
+	})
+}
+
+// slowRequestClientUnaryInterceptor marks unary gateway calls as timed by
+// slowRequestMiddleware.
+func (s *Server) slowRequestClientUnaryInterceptor(
+	ctx context.Context,
+	method string,
+	req, reply interface{},
+	cc *grpc.ClientConn,
+	invoker grpc.UnaryInvoker,
+	opts ...grpc.CallOption,
+) error {
+	ctx = metadata.AppendToOutgoingContext(ctx, slowRequestTimedMetadataKey, s.slowRequestToken)
+	return invoker(ctx, method, req, reply, cc, opts...)
+}
+
+// timedByGateway reports whether a call comes from the gateway, whose HTTP
+// request is timed already. Clients may send the key too (as a
+// Grpc-Metadata-* header through the gateway), so only the token counts.
+func (s *Server) timedByGateway(ctx context.Context) bool {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get(slowRequestTimedMetadataKey) {
+		if subtle.ConstantTimeCompare([]byte(value), []byte(s.slowRequestToken)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// slowRequestUnaryInterceptor reports unary calls exceeding the threshold.
+func slowRequestUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	threshold := s.cfg.slowRequestThreshold
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if s.timedByGateway(ctx) {
+			return handler(ctx, req)
+		}
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		duration := time.Since(start)
+		if duration <= threshold {
+			return resp, err
+		}
+		if s.metrics != nil {
+			s.metrics.slowRequests.WithLabelValues("grpc", info.FullMethod).Inc()
+		}
+		s.logger.Warn("slow request", "component", "grpc",
+			"method", info.FullMethod,
+			"duration", duration.Round(time.Millisecond),
+			"threshold", threshold,
+			"principal", PrincipalFromContext(ctx),
+			"request_id", requestIDFromContext(ctx))
+		return resp, err
+	}
+}
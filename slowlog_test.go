@@ -0,0 +1,126 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func newSlowTestServer(t *testing.T, opts ...Option) *Server {
+	t.Helper()
+	opts = append([]Option{WithGRPCService(func(s grpc.ServiceRegistrar) {})}, opts...)
+	s, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s
+}
+
+func TestSlowRequestMiddleware(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithSlowRequestThreshold(10*time.Millisecond))
+
+	handler := slowRequestMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if buf.Len() != 0 {
+		t.Errorf("expected fast request not to be logged, got %q", buf.String())
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/slow", nil)
+	req.Header.Set(requestIDHeader, "req-1")
+	req = req.WithContext(ContextWithPrincipal(req.Context(), "user-42"))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	for _, want := range []string{`msg="slow request" component=http`, "method=GET", "path=/slow", "threshold=10ms", "principal=user-42", "request_id=req-1"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log line, got %q", want, out)
+		}
+	}
+}
+
+func TestSlowRequestMiddleware_LogLevel(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithSlowRequestThreshold(time.Nanosecond), WithLogLevel("error"))
+
+	handler := slowRequestMiddleware(s, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(time.Millisecond)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if buf.Len() != 0 {
+		t.Errorf("expected no log at error level, got %q", buf.String())
+	}
+}
+
+func TestSlowRequestUnaryInterceptor(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithSlowRequestThreshold(5*time.Millisecond), WithMetrics())
+
+	interceptor := slowRequestUnaryInterceptor(s)
+	ctx := contextWithRequestID(ContextWithPrincipal(context.Background(), "svc-billing"), "req-2")
+	info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/ListItems"}
+	_, err := interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "ok", nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{`msg="slow request" component=grpc`, "method=/item.v1.ItemService/ListItems", "principal=svc-billing", "request_id=req-2"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in log line, got %q", want, out)
+		}
+	}
+	if v := testutil.ToFloat64(s.metrics.slowRequests.WithLabelValues("grpc", "/item.v1.ItemService/ListItems")); v != 1 {
+		t.Errorf("expected slow request to be counted, got %v", v)
+	}
+}
+
+func TestSlowRequestUnaryInterceptor_Gateway(t *testing.T) {
+	buf := captureLog(t)
+	s := newSlowTestServer(t, WithSlowRequestThreshold(5*time.Millisecond))
+	call := func(token string) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(slowRequestTimedMetadataKey, token))
+		info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/ListItems"}
+		slowRequestUnaryInterceptor(s)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			time.Sleep(10 * time.Millisecond)
+			return "ok", nil
+		})
+	}
+
+	// Gateway calls are reported by the HTTP middleware only
+	call(s.slowRequestToken)
+	if buf.Len() != 0 {
+		t.Errorf("expected gateway call not to be reported twice, got %q", buf.String())
+	}
+	call("forged")
+	if !strings.Contains(buf.String(), "slow request") {
+		t.Error("expected call with forged metadata to be reported")
+	}
+}
+
+func TestWithSlowRequestThreshold(t *testing.T) {
+	cfg := newServerConfig()
+	WithSlowRequestThreshold(time.Second)(cfg)
+
+	if cfg.slowRequestThreshold != time.Second {
+		t.Errorf("expected threshold 1s, got %v", cfg.slowRequestThreshold)
+	}
+}
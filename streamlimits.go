@@ -0,0 +1,158 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// StreamingLimits protects the server from slow consumers of server-streaming
+// REST endpoints. Without limits, a client that stops reading blocks the
+// gateway's write forever, pinning its goroutine, the gRPC stream and the
+// messages buffered for it.
+type StreamingLimits struct {
+	// WriteTimeout bounds each write of a stream message to the client. A
+	// client that doesn't read for that long is disconnected, which cancels
+	// the gRPC stream. Zero means no timeout.
+	WriteTimeout time.Duration
+
+	// MaxBuffered bounds the bytes of each gRPC stream the gateway receives
+	// ahead of a slow client (the gRPC flow-control window), so services
+	// block on Send instead of queueing messages. Must be at least 64KB.
+	// Zero keeps gRPC's dynamic window, which grows up to 16MB per stream.
+	MaxBuffered int
+}
+
+// WithStreamingLimits applies limits to server-streaming REST responses.
+// Clients disconnected by WriteTimeout are logged at warn level and, with
+// WithMetrics, counted in grpckit_http_slow_clients_total{operation}.
+//
+// Example:
+//
+//	grpckit.WithStreamingLimits(grpckit.StreamingLimits{
+//	    WriteTimeout: 30 * time.Second,
+//	    MaxBuffered:  256 << 10,
+//	})
+func WithStreamingLimits(limits StreamingLimits) Option {
+	return func(c *serverConfig) {
+		if limits.WriteTimeout < 0 {
+			c.invalid("WithStreamingLimits: invalid write timeout %v", limits.WriteTimeout)
+			return
+		}
+		if limits.MaxBuffered != 0 && (limits.MaxBuffered < 64<<10 || limits.MaxBuffered > 1<<30) {
+			c.invalid("WithStreamingLimits: max buffered %d outside 64KB..1GB", limits.MaxBuffered)
+			return
+		}
+		c.streamingLimits = &limits
+	}
+}
+
+// streamingLimitsDialOptions bounds the gRPC flow-control window of the
+// gateway connection to MaxBuffered. The connection window is larger, so
+// concurrent streams don't throttle each other.
+func streamingLimitsDialOptions(limits *StreamingLimits) []grpc.DialOption {
+	if limits == nil || limits.MaxBuffered == 0 {
+		return nil
+	}
+	return []grpc.DialOption{
+		grpc.WithInitialWindowSize(int32(limits.MaxBuffered)),
+		grpc.WithInitialConnWindowSize(int32(min(16*limits.MaxBuffered, 1<<30))),
+	}
+}
+
+// streamingLimitsKey is the context key of the *streamingLimitsWriter of a
+// gateway request.
+type streamingLimitsKey struct{}
+
+// streamingLimitsMiddleware applies WriteTimeout to server-streaming gateway
+// responses, marked by streamingLimitsResponseOption.
+func streamingLimitsMiddleware(s *Server, next http.Handler) http.Handler {
+	limits := s.cfg.streamingLimits
+	if limits == nil || limits.WriteTimeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &streamingLimitsWriter{ResponseWriter: w, rc: http.NewResponseController(w), timeout: limits.WriteTimeout}
+		next.ServeHTTP(sw, r.WithContext(context.WithValue(r.Context(), streamingLimitsKey{}, sw)))
+		if !sw.stream {
+			return
+		}
+		if sw.timedOut {
+			if s.metrics != nil {
+				s.metrics.slowClients.WithLabelValues(s.metrics.methodLabel(r.Method) + " " + s.metrics.pathLabel(r.URL.Path)).Inc()
+			}
+			s.logger.Warn("disconnected slow streaming client", "component", "http",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"write_timeout", limits.WriteTimeout,
+				"principal", PrincipalFromContext(r.Context()))
+			return
+		}
+		// Deadlines outlive the request on keep-alive connections: send the
+		// rest of the response under one, then clear it
+		_ = sw.rc.SetWriteDeadline(time.Now().Add(limits.WriteTimeout))
+		_ = sw.rc.Flush()
+		_ = sw.rc.SetWriteDeadline(time.Time{})
+	})
+}
+
+// streamingLimitsResponseOption is the gateway forward-response hook marking
+// server-streaming responses: the gateway calls it once with a nil message
+// before the first one.
+func streamingLimitsResponseOption(ctx context.Context, _ http.ResponseWriter, resp proto.Message) error {
+	if w, ok := ctx.Value(streamingLimitsKey{}).(*streamingLimitsWriter); ok && resp == nil {
+		w.stream = true
+	}
+	return nil
+}
+
+// streamingLimitsWriter sets a write deadline before each write and flush of
+// a server-streaming response.
+type streamingLimitsWriter struct {
+	http.ResponseWriter
+	rc       *http.ResponseController
+	timeout  time.Duration
+	stream   bool
+	timedOut bool
+}
+
+func (w *streamingLimitsWriter) Write(b []byte) (int, error) {
+	if !w.stream {
+		return w.ResponseWriter.Write(b)
+	}
+	_ = w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	n, err := w.ResponseWriter.Write(b)
+	w.checkTimeout(err)
+	return n, err
+}
+
+// FlushError flushes the response, reporting write errors to the gateway
+// (which uses http.ResponseController) so it stops streaming.
+func (w *streamingLimitsWriter) FlushError() error {
+	if w.stream {
+		_ = w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+	}
+	err := w.rc.Flush()
+	w.checkTimeout(err)
+	return err
+}
+
+func (w *streamingLimitsWriter) Flush() {
+	_ = w.FlushError()
+}
+
+func (w *streamingLimitsWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// checkTimeout records whether err is a write deadline being exceeded.
+func (w *streamingLimitsWriter) checkTimeout(err error) {
+	if w.stream && errors.Is(err, os.ErrDeadlineExceeded) {
+		w.timedOut = true
+	}
+}
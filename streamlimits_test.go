@@ -0,0 +1,134 @@
+package grpckit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestWithStreamingLimits(t *testing.T) {
+	cfg := newServerConfig()
+	WithStreamingLimits(StreamingLimits{WriteTimeout: time.Second, MaxBuffered: 256 << 10})(cfg)
+	if len(cfg.errs) != 0 || cfg.streamingLimits == nil || cfg.streamingLimits.MaxBuffered != 256<<10 {
+		t.Fatalf("unexpected config %+v, errors %v", cfg.streamingLimits, cfg.errs)
+	}
+	if opts := streamingLimitsDialOptions(cfg.streamingLimits); len(opts) != 2 {
+		t.Errorf("expected window size dial options, got %d", len(opts))
+	}
+	if opts := streamingLimitsDialOptions(&StreamingLimits{WriteTimeout: time.Second}); len(opts) != 0 {
+		t.Errorf("expected no dial options without MaxBuffered, got %d", len(opts))
+	}
+
+	for _, limits := range []StreamingLimits{{WriteTimeout: -time.Second}, {MaxBuffered: 1024}} {
+		cfg := newServerConfig()
+		WithStreamingLimits(limits)(cfg)
+		if len(cfg.errs) != 1 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+			t.Errorf("expected %+v to be rejected, got %v", limits, cfg.errs)
+		}
+	}
+}
+
+// streamingLimitsServer serves an endless server-streaming gateway response
+// through the full HTTP middleware chain, with WithStreamingLimits. done
+// receives once the handler returns.
+func streamingLimitsServer(t *testing.T, s *Server, done chan<- struct{}) *httptest.Server {
+	t.Helper()
+	mux := runtime.NewServeMux(runtime.WithForwardResponseOption(streamingLimitsResponseOption))
+	chunk := largeBadRequest(100)
+
+	handler := s.buildHTTPHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := runtime.NewServerMetadataContext(r.Context(), runtime.ServerMetadata{})
+		_, m := runtime.MarshalerForRequest(mux, r)
+		runtime.ForwardResponseStream(ctx, mux, m, w, r, func() (proto.Message, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			return chunk, nil
+		}, mux.GetForwardResponseOptions()...)
+	}))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() { done <- struct{}{} }()
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestStreamingLimits_DisconnectsSlowClient(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics(), WithAccessLog(), WithStreamingLimits(StreamingLimits{WriteTimeout: 100 * time.Millisecond}))
+	done := make(chan struct{}, 1)
+	srv := streamingLimitsServer(t, s, done)
+
+	// A client that sends a request and never reads the response
+	conn, err := net.Dial("tcp", srv.Listener.Addr().String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET /v1/items:stream HTTP/1.1\r\nHost: test\r\n\r\n")
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected stalled stream to be ended by the write timeout")
+	}
+	if v := testutil.ToFloat64(s.metrics.slowClients.WithLabelValues("GET /v1/items:stream")); v != 1 {
+		t.Errorf("expected 1 slow client, got %v", v)
+	}
+	if out := buf.String(); !strings.Contains(out, "disconnected slow streaming client") || !strings.Contains(out, "write_timeout=100ms") {
+		t.Errorf("expected slow client to be logged, got %q", out)
+	}
+}
+
+func TestStreamingLimits_ReadingClient(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t, WithMetrics(), WithStreamingLimits(StreamingLimits{WriteTimeout: 100 * time.Millisecond}))
+	done := make(chan struct{}, 1)
+	srv := streamingLimitsServer(t, s, done)
+
+	resp, err := http.Get(srv.URL + "/v1/items:stream")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	// Read slowly but steadily for longer than the write timeout
+	reader := bufio.NewReader(resp.Body)
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for lines := 0; time.Now().Before(deadline); lines++ {
+		if _, err := reader.ReadBytes('\n'); err != nil {
+			t.Fatalf("stream ended after %d messages: %v", lines, err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	resp.Body.Close()
+	<-done
+}
+
+func TestStreamingLimitsWriter_Unary(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &streamingLimitsWriter{ResponseWriter: rec, rc: http.NewResponseController(rec), timeout: time.Millisecond}
+
+	// Unary responses are written without deadlines (the recorder doesn't
+	// support them, which would otherwise not matter)
+	if _, err := w.Write([]byte("ok")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := w.FlushError(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.timedOut || rec.Body.String() != "ok" || !rec.Flushed {
+		t.Errorf("expected unary response to pass through, got %q", rec.Body.String())
+	}
+}
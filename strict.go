@@ -0,0 +1,123 @@
+package grpckit
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// StrictCheck names a production safety check run by WithStrictMode.
+type StrictCheck string
+
+// Production safety checks.
+const (
+	// StrictCORS fails when CORS allows any origin with credentials.
+	StrictCORS StrictCheck = "cors"
+	// StrictAuth fails when WithAuth is set but every endpoint is public.
+	StrictAuth StrictCheck = "auth"
+	// StrictReflection fails when gRPC reflection is enabled.
+	StrictReflection StrictCheck = "reflection"
+	// StrictTLS fails when TLS is not configured.
+	StrictTLS StrictCheck = "tls"
+	// StrictTimeouts fails when HTTP requests have no timeout.
+	StrictTimeouts StrictCheck = "timeouts"
+)
+
+// WithStrictMode makes New refuse configurations that are dangerous in
+// production. The full checklist is logged, and New returns
+// ErrInvalidConfig listing the failed checks:
+//   - cors: wildcard CORS origin with AllowCredentials
+//   - auth: WithAuth set, but WithPublicEndpoints("/**") makes everything public
+//   - reflection: gRPC reflection enabled (see WithGRPCReflection)
+//   - tls: no WithTLS or WithTLSConfig
+//   - timeouts: no WithHTTPTimeout
+//
+// Checks that do not apply can be skipped, e.g. StrictTLS when TLS is
+// terminated by a load balancer or mesh sidecar.
+//
+// Example:
+//
+//	grpckit.Run(
+//	    grpckit.ProductionDefaults(),
+//	    grpckit.WithStrictMode(grpckit.StrictTLS), // TLS terminated by the ingress
+//	    grpckit.WithGRPCService(...),
+//	)
+//
+// Example output:
+//
+//	level=INFO msg="strict mode check" component=strict check=cors result=ok message="no wildcard origin with credentials"
+//	level=INFO msg="strict mode check" component=strict check=auth result=ok message="protected endpoints configured"
+//	level=ERROR msg="strict mode check" component=strict check=reflection result=fail message="gRPC reflection is enabled; use WithGRPCReflection(false)"
+//	level=INFO msg="strict mode check" component=strict check=tls result=skip
+//	level=INFO msg="strict mode check" component=strict check=timeouts result=ok message="HTTP requests time out"
+func WithStrictMode(skip ...StrictCheck) Option {
+	return func(c *serverConfig) {
+		c.strictMode = true
+		c.strictSkip = append(c.strictSkip, skip...)
+	}
+}
+
+// strictResult is the outcome of one strict mode check.
+type strictResult struct {
+	check   StrictCheck
+	ok      bool
+	message string
+}
+
+// strictChecks runs the production safety checks against a configuration.
+func strictChecks(cfg *serverConfig) []strictResult {
+	result := func(check StrictCheck, ok bool, pass, fail string) strictResult {
+		if ok {
+			return strictResult{check: check, ok: true, message: pass}
+		}
+		return strictResult{check: check, message: fail}
+	}
+
+	wildcardCORS := cfg.corsEnabled && cfg.corsConfig != nil &&
+		cfg.corsConfig.AllowCredentials && slices.Contains(cfg.corsConfig.AllowedOrigins, "*")
+	allPublic := cfg.authFunc != nil && len(cfg.protectedEndpoints) == 0 &&
+		slices.Contains(cfg.publicEndpoints, "/**")
+
+	return []strictResult{
+		result(StrictCORS, !wildcardCORS,
+			"no wildcard origin with credentials",
+			"CORS allows any origin with credentials; list the allowed origins"),
+		result(StrictAuth, !allPublic,
+			"protected endpoints configured",
+			`WithAuth is set but "/**" is public; narrow WithPublicEndpoints`),
+		result(StrictReflection, cfg.noReflection,
+			"gRPC reflection disabled",
+			"gRPC reflection is enabled; use WithGRPCReflection(false)"),
+		result(StrictTLS, tlsEnabled(cfg),
+			"TLS configured",
+			"TLS is not configured; use WithTLS or skip StrictTLS if terminated upstream"),
+		result(StrictTimeouts, cfg.httpTimeout > 0,
+			"HTTP requests time out",
+			"HTTP requests have no timeout; use WithHTTPTimeout"),
+	}
+}
+
+// validateStrictMode logs the strict mode checklist and returns an error
+// listing the failed checks.
+func validateStrictMode(cfg *serverConfig) error {
+	if !cfg.strictMode {
+		return nil
+	}
+
+	var failed []string
+	for _, r := range strictChecks(cfg) {
+		switch {
+		case slices.Contains(cfg.strictSkip, r.check):
+			cfg.logger.Info("strict mode check", "component", "strict", "check", string(r.check), "result", "skip")
+		case r.ok:
+			cfg.logger.Info("strict mode check", "component", "strict", "check", string(r.check), "result", "ok", "message", r.message)
+		default:
+			cfg.logger.Error("strict mode check", "component", "strict", "check", string(r.check), "result", "fail", "message", r.message)
+			failed = append(failed, string(r.check))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w: strict mode: failed checks: %s", ErrInvalidConfig, strings.Join(failed, ", "))
+	}
+	return nil
+}
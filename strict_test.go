@@ -0,0 +1,105 @@
+package grpckit
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+)
+
+func TestStrictMode_Violations(t *testing.T) {
+	buf := captureLog(t)
+	_, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithStrictMode(),
+		WithCORSConfig(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true}),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/**"),
+	)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Fatalf("expected ErrInvalidConfig, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "failed checks: cors, auth, reflection, tls, timeouts") {
+		t.Errorf("expected all checks to fail, got %v", err)
+	}
+	if !strings.Contains(buf.String(), `check=tls result=fail message="TLS is not configured`) {
+		t.Errorf("expected checklist in log, got %q", buf.String())
+	}
+}
+
+func TestStrictMode_Passes(t *testing.T) {
+	buf := captureLog(t)
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	certFile, keyFile := writeTestCert(t)
+
+	_, err := New(
+		ProductionDefaults(),
+		WithStrictMode(),
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithTLS(certFile, keyFile),
+		WithCORSConfig(CORSConfig{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/healthz", "/readyz"),
+	)
+	if err != nil {
+		t.Fatalf("expected strict mode to pass, got %v", err)
+	}
+	if strings.Contains(buf.String(), "result=fail") {
+		t.Errorf("unexpected failures in %q", buf.String())
+	}
+}
+
+func TestStrictMode_CertReloader(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithStrictMode(StrictReflection),
+		WithHTTPTimeout(time.Second),
+		WithCertReloader(certFile, keyFile, time.Minute),
+	)
+	if err != nil {
+		t.Fatalf("expected the cert reloader to satisfy the TLS check, got %v", err)
+	}
+	s.Shutdown()
+}
+
+func TestStrictMode_Skip(t *testing.T) {
+	buf := captureLog(t)
+	_, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithStrictMode(StrictTLS, StrictReflection),
+		WithHTTPTimeout(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("expected skipped checks to pass, got %v", err)
+	}
+	if !strings.Contains(buf.String(), "check=tls result=skip") {
+		t.Errorf("expected skipped check in checklist, got %q", buf.String())
+	}
+}
+
+func TestStrictMode_Disabled(t *testing.T) {
+	buf := captureLog(t)
+	if _, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {})); err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "component=strict") {
+		t.Errorf("expected no checklist without strict mode, got %q", buf.String())
+	}
+}
+
+func TestWithGRPCReflection(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}), WithGRPCReflection(enabled))
+		if err != nil {
+			t.Fatalf("New failed: %v", err)
+		}
+		_, registered := s.grpcServer.GetServiceInfo()["grpc.reflection.v1.ServerReflection"]
+		if registered != enabled {
+			t.Errorf("WithGRPCReflection(%v): reflection registered = %v", enabled, registered)
+		}
+	}
+}
@@ -40,10 +40,14 @@ const swaggerUIHTML = `<!DOCTYPE html>
 </body>
 </html>`
 
+// defaultSwaggerPrefix is the path Swagger UI is served under by default.
+const defaultSwaggerPrefix = "/swagger"
+
 // swaggerHandler manages Swagger UI and spec serving.
 type swaggerHandler struct {
 	specPath string
 	specData []byte
+	prefix   string
 }
 
 // newSwaggerHandler creates a new Swagger handler from a file path.
@@ -63,6 +67,7 @@ func newSwaggerHandler(specPath string) (*swaggerHandler, error) {
 	return &swaggerHandler{
 		specPath: specPath,
 		specData: data,
+		prefix:   defaultSwaggerPrefix,
 	}, nil
 }
 
@@ -76,6 +81,7 @@ func newSwaggerHandlerFromBytes(data []byte) (*swaggerHandler, error) {
 
 	return &swaggerHandler{
 		specData: data,
+		prefix:   defaultSwaggerPrefix,
 	}, nil
 }
 
@@ -89,7 +95,7 @@ func (s *swaggerHandler) UIHandler() http.HandlerFunc {
 		data := struct {
 			SpecURL string
 		}{
-			SpecURL: "/swagger/spec.json",
+			SpecURL: s.prefix + "/spec.json",
 		}
 
 		if err := tmpl.Execute(w, data); err != nil {
@@ -106,32 +112,36 @@ func (s *swaggerHandler) SpecHandler() http.HandlerFunc {
 	}
 }
 
-// registerSwaggerEndpoints registers Swagger endpoints on the mux from a file path.
-func registerSwaggerEndpoints(mux *http.ServeMux, specPath string) error {
+// registerSwaggerEndpoints registers Swagger endpoints under prefix on the
+// mux from a file path.
+func registerSwaggerEndpoints(mux *http.ServeMux, specPath, prefix string) error {
 	handler, err := newSwaggerHandler(specPath)
 	if err != nil {
 		return err
 	}
 
+	handler.prefix = prefix
 	registerSwaggerHandler(mux, handler)
 	return nil
 }
 
-// registerSwaggerEndpointsFromBytes registers Swagger endpoints from embedded data.
-func registerSwaggerEndpointsFromBytes(mux *http.ServeMux, data []byte) error {
+// registerSwaggerEndpointsFromBytes registers Swagger endpoints under prefix
+// from embedded data.
+func registerSwaggerEndpointsFromBytes(mux *http.ServeMux, data []byte, prefix string) error {
 	handler, err := newSwaggerHandlerFromBytes(data)
 	if err != nil {
 		return err
 	}
 
+	handler.prefix = prefix
 	registerSwaggerHandler(mux, handler)
 	return nil
 }
 
 // registerSwaggerHandler registers the swagger handler on the mux.
 func registerSwaggerHandler(mux *http.ServeMux, handler *swaggerHandler) {
-	mux.HandleFunc("/swagger/", func(w http.ResponseWriter, r *http.Request) {
-		path := strings.TrimPrefix(r.URL.Path, "/swagger")
+	mux.HandleFunc(handler.prefix+"/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, handler.prefix)
 		if path == "" || path == "/" {
 			handler.UIHandler()(w, r)
 			return
@@ -146,8 +156,8 @@ func registerSwaggerHandler(mux *http.ServeMux, handler *swaggerHandler) {
 
 // registerSwaggerNotFound registers a 404 handler for swagger endpoints.
 // This is used when swagger is enabled but no data was loaded (make swagger wasn't run).
-func registerSwaggerNotFound(mux *http.ServeMux) {
-	mux.HandleFunc("/swagger/", func(w http.ResponseWriter, r *http.Request) {
+func registerSwaggerNotFound(mux *http.ServeMux, prefix string) {
+	mux.HandleFunc(prefix+"/", func(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "swagger not available - run 'make swagger' to enable", http.StatusNotFound)
 	})
 }
@@ -147,7 +147,7 @@ func TestRegisterSwaggerEndpoints(t *testing.T) {
 	}
 
 	mux := http.NewServeMux()
-	err := registerSwaggerEndpoints(mux, specPath)
+	err := registerSwaggerEndpoints(mux, specPath, defaultSwaggerPrefix)
 	if err != nil {
 		t.Fatalf("registerSwaggerEndpoints failed: %v", err)
 	}
@@ -173,7 +173,7 @@ func TestRegisterSwaggerEndpoints(t *testing.T) {
 
 func TestRegisterSwaggerEndpoints_InvalidFile(t *testing.T) {
 	mux := http.NewServeMux()
-	err := registerSwaggerEndpoints(mux, "/nonexistent/swagger.json")
+	err := registerSwaggerEndpoints(mux, "/nonexistent/swagger.json", defaultSwaggerPrefix)
 	if err == nil {
 		t.Error("expected error for non-existent file")
 	}
@@ -183,7 +183,7 @@ func TestRegisterSwaggerEndpointsFromBytes(t *testing.T) {
 	mux := http.NewServeMux()
 	specData := []byte(`{"openapi": "3.0.0"}`)
 
-	err := registerSwaggerEndpointsFromBytes(mux, specData)
+	err := registerSwaggerEndpointsFromBytes(mux, specData, defaultSwaggerPrefix)
 	if err != nil {
 		t.Fatalf("registerSwaggerEndpointsFromBytes failed: %v", err)
 	}
@@ -200,7 +200,7 @@ func TestRegisterSwaggerEndpointsFromBytes(t *testing.T) {
 
 func TestRegisterSwaggerEndpointsFromBytes_InvalidJSON(t *testing.T) {
 	mux := http.NewServeMux()
-	err := registerSwaggerEndpointsFromBytes(mux, []byte("invalid"))
+	err := registerSwaggerEndpointsFromBytes(mux, []byte("invalid"), defaultSwaggerPrefix)
 	if err == nil {
 		t.Error("expected error for invalid JSON")
 	}
@@ -235,9 +235,39 @@ func TestRegisterSwaggerHandler_Routing(t *testing.T) {
 	}
 }
 
+func TestWithSwaggerPrefix(t *testing.T) {
+	specPath := filepath.Join(t.TempDir(), "swagger.json")
+	if err := os.WriteFile(specPath, []byte(`{"openapi": "3.0.0"}`), 0644); err != nil {
+		t.Fatalf("failed to write spec: %v", err)
+	}
+	s := newSlowTestServer(t, WithSwaggerFile(specPath), WithSwaggerPrefix("/internal/docs/"))
+	handler := s.buildHTTPHandler(http.NotFoundHandler())
+
+	for path, want := range map[string]int{
+		"/internal/docs/":          http.StatusOK,
+		"/internal/docs/spec.json": http.StatusOK,
+		"/swagger/":                http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: expected %d, got %d", path, want, rec.Code)
+		}
+		if path == "/internal/docs/" && !strings.Contains(rec.Body.String(), `\/internal\/docs\/spec.json`) {
+			t.Errorf("expected relocated spec URL in UI, got %q", rec.Body.String())
+		}
+	}
+
+	cfg := newServerConfig()
+	WithSwaggerPrefix("/")(cfg)
+	if len(cfg.errs) != 1 {
+		t.Errorf("expected root prefix to be rejected, got %v", cfg.errs)
+	}
+}
+
 func TestRegisterSwaggerNotFound(t *testing.T) {
 	mux := http.NewServeMux()
-	registerSwaggerNotFound(mux)
+	registerSwaggerNotFound(mux, defaultSwaggerPrefix)
 
 	req := httptest.NewRequest(http.MethodGet, "/swagger/", nil)
 	rec := httptest.NewRecorder()
@@ -0,0 +1,165 @@
+package grpckit
+
+import (
+	"errors"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// TemplateDataFunc returns the data passed to a template for a request.
+// Returning an error value renders the "error.html" template (if present)
+// with the status derived from the error (ErrNotFound → 404, etc.).
+type TemplateDataFunc func(r *http.Request) any
+
+// TemplateError is the data passed to the "error.html" template.
+type TemplateError struct {
+	Status  int
+	Message string
+}
+
+// templateHandler renders HTML templates parsed once from an fs.FS.
+type templateHandler struct {
+	prefix string
+	tmpl   *template.Template
+	pages  map[string]bool // templates served as pages
+	data   TemplateDataFunc
+}
+
+// parseTemplates parses all .html and .tmpl files in fsys, and returns the
+// names of those served as pages: all but error.html and partials, whose
+// file names start with "_". Templates are named by their slash-separated
+// path within fsys.
+func parseTemplates(fsys fs.FS) (*template.Template, map[string]bool, error) {
+	if fsys == nil {
+		return nil, nil, errors.New("nil file system")
+	}
+	root := template.New("")
+	pages := make(map[string]bool)
+	found := false
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if ext := path.Ext(p); ext != ".html" && ext != ".tmpl" {
+			return nil
+		}
+		content, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		if _, err := root.New(p).Parse(string(content)); err != nil {
+			return err
+		}
+		found = true
+		if p != "error.html" && !strings.HasPrefix(path.Base(p), "_") {
+			pages[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if !found {
+		return nil, nil, errors.New("no .html or .tmpl templates found")
+	}
+	return root, pages, nil
+}
+
+// templateName maps a request path to a template name.
+// "/status/" → "index.html", "/status/users" → "users.html".
+func (h *templateHandler) templateName(urlPath string) string {
+	name := strings.Trim(strings.TrimPrefix(urlPath, h.prefix), "/")
+	if name == "" {
+		return "index.html"
+	}
+	if path.Ext(name) == "" {
+		name += ".html"
+	}
+	return name
+}
+
+// ServeHTTP renders the template for the request path.
+// Output is buffered so that rendering errors never produce partial pages.
+func (h *templateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := h.templateName(r.URL.Path)
+	if !h.pages[name] {
+		h.renderError(w, r, http.StatusNotFound, "page not found")
+		return
+	}
+
+	var data any
+	if h.data != nil {
+		data = h.data(r)
+	}
+	if err, ok := data.(error); ok {
+		code, message := fileErrorStatus(err), err.Error()
+		if code >= http.StatusInternalServerError {
+			// Keep internal details out of the page
+			loggerFrom(r.Context()).Error("failed to load page data", "component", "templates", "template", name, "error", err)
+			message = http.StatusText(code)
+		}
+		h.renderError(w, r, code, message)
+		return
+	}
+
+	h.render(w, r, http.StatusOK, name, data)
+}
+
+// render executes a template into a pooled buffer and writes it out.
+func (h *templateHandler) render(w http.ResponseWriter, r *http.Request, status int, name string, data any) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := h.tmpl.ExecuteTemplate(buf, name, data); err != nil {
+		loggerFrom(r.Context()).Error("failed to render template", "component", "templates", "template", name, "error", err)
+		http.Error(w, "failed to render page", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// renderError renders the "error.html" template if present, or a plain text error.
+func (h *templateHandler) renderError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if h.tmpl.Lookup("error.html") != nil {
+		h.render(w, r, status, "error.html", TemplateError{Status: status, Message: message})
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// WithTemplateHandler serves server-rendered HTML pages from templates in fsys.
+// Templates are parsed once at startup and cached. The template is selected from
+// the request path relative to pattern ("/" → index.html, "/users" → users.html).
+// An optional error.html template receives a TemplateError for error pages.
+// error.html and partials, templates whose file names start with "_" (such
+// as "_header.html"), are only used by other templates and not served as
+// pages. New fails if the templates cannot be parsed.
+//
+// Example:
+//
+//	//go:embed templates
+//	var templates embed.FS
+//
+//	sub, _ := fs.Sub(templates, "templates")
+//	grpckit.WithTemplateHandler("/status/", sub, func(r *http.Request) any {
+//	    return map[string]any{"Uptime": time.Since(started)}
+//	})
+func WithTemplateHandler(pattern string, templates fs.FS, data TemplateDataFunc) Option {
+	h := &templateHandler{prefix: pattern, data: data}
+	var err error
+	h.tmpl, h.pages, err = parseTemplates(templates)
+	return func(c *serverConfig) {
+		if err != nil {
+			c.invalid("WithTemplateHandler %q: %w", pattern, err)
+			return
+		}
+		WithHTTPHandler(pattern, h)(c)
+	}
+}
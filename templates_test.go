@@ -0,0 +1,147 @@
+package grpckit
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"google.golang.org/grpc"
+)
+
+func newTestTemplateHandler(fsys fstest.MapFS, data TemplateDataFunc) http.Handler {
+	cfg := newServerConfig()
+	WithTemplateHandler("/status/", fsys, data)(cfg)
+	return cfg.httpHandlers[0].handler
+}
+
+func TestTemplateHandler_Render(t *testing.T) {
+	handler := newTestTemplateHandler(fstest.MapFS{
+		"index.html": {Data: []byte(`<h1>{{.Name}}</h1>`)},
+		"users.html": {Data: []byte(`<p>users</p>`)},
+	}, func(r *http.Request) any {
+		return map[string]string{"Name": "<svc>"}
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected Content-Type: %s", ct)
+	}
+	if body := rec.Body.String(); body != "<h1>&lt;svc&gt;</h1>" {
+		t.Errorf("expected escaped output, got %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/users", nil))
+	if rec.Body.String() != "<p>users</p>" {
+		t.Errorf("expected users template, got %q", rec.Body.String())
+	}
+}
+
+func TestTemplateHandler_NotFound(t *testing.T) {
+	handler := newTestTemplateHandler(fstest.MapFS{
+		"index.html": {Data: []byte(`ok`)},
+		"error.html": {Data: []byte(`error {{.Status}}: {{.Message}}`)},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/missing", nil))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); body != "error 404: page not found" {
+		t.Errorf("expected error template output, got %q", body)
+	}
+}
+
+func TestTemplateHandler_DataError(t *testing.T) {
+	handler := newTestTemplateHandler(fstest.MapFS{
+		"index.html": {Data: []byte(`ok`)},
+	}, func(r *http.Request) any {
+		return ErrForbidden
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403, got %d", rec.Code)
+	}
+
+	captureLog(t)
+	handler = newTestTemplateHandler(fstest.MapFS{
+		"index.html": {Data: []byte(`ok`)},
+		"error.html": {Data: []byte(`{{.Message}}`)},
+	}, func(r *http.Request) any {
+		return errors.New("pq: password authentication failed for user \"app\"")
+	})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+	if rec.Code != http.StatusInternalServerError || rec.Body.String() != "Internal Server Error" {
+		t.Errorf("expected generic 500 page, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestTemplateHandler_ExecuteError(t *testing.T) {
+	handler := newTestTemplateHandler(fstest.MapFS{
+		"index.html": {Data: []byte(`before {{.Missing.Field}}`)},
+	}, func(r *http.Request) any {
+		return struct{}{}
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("expected 500, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "before") {
+		t.Error("expected no partial output on render error")
+	}
+}
+
+func TestTemplateHandler_Partials(t *testing.T) {
+	handler := newTestTemplateHandler(fstest.MapFS{
+		"index.html":         {Data: []byte(`{{template "_header.html"}}body`)},
+		"_header.html":       {Data: []byte(`header `)},
+		"admin/_footer.html": {Data: []byte(`footer`)},
+		"error.html":         {Data: []byte(`error {{.Status}}`)},
+	}, nil)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status/", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "header body" {
+		t.Errorf("expected page with partial, got %d %q", rec.Code, rec.Body.String())
+	}
+
+	for _, target := range []string{"/status/_header", "/status/admin/_footer.html", "/status/error"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != http.StatusNotFound || rec.Body.String() != "error 404" {
+			t.Errorf("%s: expected 404 error page, got %d %q", target, rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestTemplateHandler_ParseError(t *testing.T) {
+	tests := map[string]fs.FS{
+		"syntax error": fstest.MapFS{"index.html": {Data: []byte(`{{.Broken`)}},
+		"no templates": fstest.MapFS{"style.css": {Data: []byte(`body {}`)}},
+		"nil":          nil,
+	}
+	for name, fsys := range tests {
+		_, err := New(WithTemplateHandler("/status/", fsys, nil), WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if !errors.Is(err, ErrInvalidConfig) || !strings.Contains(err.Error(), `WithTemplateHandler "/status/"`) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
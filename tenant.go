@@ -0,0 +1,286 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// defaultTenantTopN is the number of tenants labeled by name by default.
+const defaultTenantTopN = 20
+
+// tenantKey is the context key for the request's tenant.
+type tenantKey struct{}
+
+// requestTenant is the tenant of a request and its metrics label.
+type requestTenant struct {
+	name  string
+	label string
+}
+
+// WithTenantResolver sets the function resolving the tenant of a request
+// from its authenticated context, e.g. from the principal or a claim set by
+// the auth function. It runs after authentication on HTTP requests and gRPC
+// calls, and handlers read its result with TenantFromContext. Requests for
+// which it returns "" have no tenant.
+//
+// Example:
+//
+//	grpckit.WithTenantResolver(func(ctx context.Context) string {
+//	    org, _, _ := strings.Cut(grpckit.PrincipalFromContext(ctx), "/")
+//	    return org
+//	})
+func WithTenantResolver(fn func(ctx context.Context) string) Option {
+	return func(c *serverConfig) {
+		if fn == nil {
+			c.invalid("WithTenantResolver: resolver is nil")
+			return
+		}
+		c.tenantResolver = fn
+	}
+}
+
+// TenantFromContext returns the tenant resolved by WithTenantResolver, or ""
+// if the request has none.
+func TenantFromContext(ctx context.Context) string {
+	if t, ok := ctx.Value(tenantKey{}).(*requestTenant); ok {
+		return t.name
+	}
+	return ""
+}
+
+// TenantMetricsConfig configures per-tenant metrics.
+type TenantMetricsConfig struct {
+	// TopN is the number of busiest tenants labeled by name; the others are
+	// counted as "other", which bounds the label cardinality.
+	// Default: 20
+	TopN int
+}
+
+// WithTenantMetrics counts requests per tenant, so noisy neighbors show up
+// on dashboards. It requires WithTenantResolver and WithMetrics, and adds:
+//
+//   - grpckit_tenant_requests_total{tenant,protocol,status_class}: requests
+//     and their errors; REST calls are counted as http and, by the gRPC
+//     service handling them, as grpc
+//   - grpckit_tenant_quota_requests_total{tenant,result}: calls counted
+//     against WithPrincipalQuota, allowed or exceeded
+//
+// Only the TopN tenants with the most requests since the server started are
+// labeled by name, the others are labeled "other". A tenant entering the
+// top N is labeled by name from then on, so its earlier requests remain
+// counted as "other". The series of a tenant dropping out of the top N are
+// deleted, so at most TopN tenants are exported at once.
+//
+// Example:
+//
+//	grpckit.WithMetrics(),
+//	grpckit.WithTenantResolver(tenantOf),
+//	grpckit.WithTenantMetrics(grpckit.TenantMetricsConfig{TopN: 50})
+func WithTenantMetrics(config TenantMetricsConfig) Option {
+	return func(c *serverConfig) {
+		if config.TopN < 0 {
+			c.invalid("WithTenantMetrics: negative TopN %d", config.TopN)
+			return
+		}
+		if config.TopN == 0 {
+			config.TopN = defaultTenantTopN
+		}
+		c.tenantMetrics = &config
+	}
+}
+
+// checkTenantMetrics reports WithTenantMetrics without its dependencies.
+func checkTenantMetrics(cfg *serverConfig) {
+	if cfg.tenantMetrics == nil {
+		return
+	}
+	if cfg.tenantResolver == nil {
+		cfg.invalid("WithTenantMetrics: no tenant resolver (see WithTenantResolver)")
+	}
+	if !cfg.metricsEnabled {
+		cfg.invalid("WithTenantMetrics: metrics are not enabled (see WithMetrics)")
+	}
+}
+
+// tenantLabeler maps tenants to metric labels: the TopN tenants with the
+// most requests get their own, the others "other". Counts are kept for a
+// bounded number of tenants with the space-saving algorithm, which keeps
+// the heaviest ones when it evicts.
+type tenantLabeler struct {
+	mu       sync.Mutex
+	topN     int
+	capacity int
+	counts   map[string]uint64
+	top      map[string]bool
+	pending  int
+	dropped  func(tenant string) // called for tenants leaving the top N
+}
+
+// tenantRefreshInterval is the number of observations between top N updates.
+const tenantRefreshInterval = 100
+
+// newTenantLabeler returns a labeler for the topN busiest tenants. dropped,
+// if not nil, is called with the tenants leaving the top N.
+func newTenantLabeler(topN int, dropped func(tenant string)) *tenantLabeler {
+	return &tenantLabeler{
+		topN:     topN,
+		capacity: 10 * topN,
+		counts:   make(map[string]uint64),
+		top:      make(map[string]bool),
+		dropped:  dropped,
+	}
+}
+
+// label counts a request of tenant and returns its label.
+func (l *tenantLabeler) label(tenant string) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.counts[tenant]; !ok && len(l.counts) >= l.capacity {
+		// Replace the lightest tenant, inheriting its count as an upper
+		// bound of the new tenant's requests
+		var lightest string
+		var min uint64
+		for t, n := range l.counts {
+			if lightest == "" || n < min {
+				lightest, min = t, n
+			}
+		}
+		delete(l.counts, lightest)
+		l.counts[tenant] = min
+	}
+	l.counts[tenant]++
+
+	// Fill the top N as tenants arrive, then refresh it periodically
+	l.pending++
+	if (len(l.top) < l.topN && !l.top[tenant]) || l.pending >= tenantRefreshInterval {
+		l.refresh()
+	}
+	if l.top[tenant] {
+		return tenant
+	}
+	return otherRoute
+}
+
+// refresh recomputes the top N tenants.
+func (l *tenantLabeler) refresh() {
+	l.pending = 0
+	tenants := make([]string, 0, len(l.counts))
+	for t := range l.counts {
+		tenants = append(tenants, t)
+	}
+	sort.Slice(tenants, func(i, j int) bool {
+		if l.counts[tenants[i]] != l.counts[tenants[j]] {
+			return l.counts[tenants[i]] > l.counts[tenants[j]]
+		}
+		return tenants[i] < tenants[j]
+	})
+	if len(tenants) > l.topN {
+		tenants = tenants[:l.topN]
+	}
+	top := make(map[string]bool, len(tenants))
+	for _, t := range tenants {
+		top[t] = true
+	}
+	if l.dropped != nil {
+		for t := range l.top {
+			if !top[t] {
+				l.dropped(t)
+			}
+		}
+	}
+	l.top = top
+}
+
+// tenantContext resolves the tenant of an authenticated request and, with
+// WithTenantMetrics, counts it towards the busiest tenants.
+func (s *Server) tenantContext(ctx context.Context) (context.Context, *requestTenant) {
+	name := s.cfg.tenantResolver(ctx)
+	if name == "" {
+		return ctx, nil
+	}
+	t := &requestTenant{name: name}
+	if s.metrics != nil && s.metrics.tenants != nil {
+		t.label = s.metrics.tenants.label(name)
+	}
+	return context.WithValue(ctx, tenantKey{}, t), t
+}
+
+// deleteTenant deletes the series of a tenant no longer labeled by name.
+func (m *Metrics) deleteTenant(tenant string) {
+	m.tenantRequests.DeletePartialMatch(prometheus.Labels{"tenant": tenant})
+	m.tenantQuota.DeletePartialMatch(prometheus.Labels{"tenant": tenant})
+}
+
+// observeTenant counts a finished request of t with WithTenantMetrics.
+func (m *Metrics) observeTenant(t *requestTenant, protocol string, code int) {
+	if t == nil || t.label == "" {
+		return
+	}
+	m.tenantRequests.WithLabelValues(t.label, protocol, statusClass(code)).Inc()
+}
+
+// observeTenantQuota counts a call of the request's tenant against quotas
+// with WithTenantMetrics.
+func (m *Metrics) observeTenantQuota(ctx context.Context, exceeded bool) {
+	t, ok := ctx.Value(tenantKey{}).(*requestTenant)
+	if !ok || t.label == "" {
+		return
+	}
+	result := "allowed"
+	if exceeded {
+		result = "exceeded"
+	}
+	m.tenantQuota.WithLabelValues(t.label, result).Inc()
+}
+
+// tenantMiddleware resolves the tenant of authenticated HTTP requests.
+func tenantMiddleware(s *Server, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, t := s.tenantContext(r.Context())
+		if t == nil || t.label == "" {
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+		wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		next.ServeHTTP(wrapped, r.WithContext(ctx))
+		s.metrics.observeTenant(t, "http", wrapped.statusCode)
+	})
+}
+
+// tenantUnaryInterceptor resolves the tenant of unary calls.
+func tenantUnaryInterceptor(s *Server) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ctx, t := s.tenantContext(ctx)
+		resp, err := handler(ctx, req)
+		s.metrics.observeTenant(t, "grpc", runtime.HTTPStatusFromCode(status.Code(err)))
+		return resp, err
+	}
+}
+
+// tenantStreamInterceptor is the stream variant of tenantUnaryInterceptor.
+func tenantStreamInterceptor(s *Server) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx, t := s.tenantContext(ss.Context())
+		err := handler(srv, &contextServerStream{ServerStream: ss, ctx: ctx})
+		s.metrics.observeTenant(t, "grpc", runtime.HTTPStatusFromCode(status.Code(err)))
+		return err
+	}
+}
@@ -0,0 +1,167 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyozatech/grpckit/quota"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tenantOfPrincipal resolves the tenant of principals like "acme/alice".
+func tenantOfPrincipal(ctx context.Context) string {
+	tenant, _, ok := strings.Cut(PrincipalFromContext(ctx), "/")
+	if !ok {
+		return ""
+	}
+	return tenant
+}
+
+func TestTenantLabeler(t *testing.T) {
+	var dropped []string
+	l := newTenantLabeler(2, func(tenant string) { dropped = append(dropped, tenant) })
+
+	// The first tenants fill the top N
+	if got := l.label("acme"); got != "acme" {
+		t.Errorf("expected acme to be labeled by name, got %q", got)
+	}
+	if got := l.label("globex"); got != "globex" {
+		t.Errorf("expected globex to be labeled by name, got %q", got)
+	}
+	if got := l.label("initech"); got != otherRoute {
+		t.Errorf("expected initech to be other, got %q", got)
+	}
+
+	// A busier tenant takes a place at the next refresh
+	for i := 0; i < tenantRefreshInterval; i++ {
+		l.label("initech")
+	}
+	if got := l.label("initech"); got != "initech" {
+		t.Errorf("expected busy initech to be labeled by name, got %q", got)
+	}
+	if len(dropped) != 1 || dropped[0] != "globex" {
+		t.Errorf("expected globex to leave the top N, got %v", dropped)
+	}
+
+	// The number of tracked tenants is bounded
+	for i := 0; i < 1000; i++ {
+		l.label(fmt.Sprintf("tenant-%d", i))
+	}
+	if len(l.counts) > l.capacity || len(l.top) > 2 {
+		t.Errorf("expected at most %d tracked and 2 top tenants, got %d and %d", l.capacity, len(l.counts), len(l.top))
+	}
+}
+
+func TestMetrics_DeleteTenant(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	m := newMetrics("grpckit", prometheus.DefaultRegisterer)
+	m.tenantRequests.WithLabelValues("acme", "http", "2xx").Inc()
+	m.tenantRequests.WithLabelValues("acme", "grpc", "5xx").Inc()
+	m.tenantRequests.WithLabelValues("globex", "http", "2xx").Inc()
+	m.tenantQuota.WithLabelValues("acme", "allowed").Inc()
+
+	m.deleteTenant("acme")
+	if n := testutil.CollectAndCount(m.tenantRequests); n != 1 {
+		t.Errorf("expected only globex requests to remain, got %d series", n)
+	}
+	if n := testutil.CollectAndCount(m.tenantQuota); n != 0 {
+		t.Errorf("expected acme quota series to be deleted, got %d", n)
+	}
+}
+
+func TestWithTenantMetrics_HTTP(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	var gotTenant string
+	s := newSlowTestServer(t,
+		WithMetrics(),
+		WithAuth(func(ctx context.Context, token string) (context.Context, error) {
+			return ContextWithPrincipal(ctx, token), nil
+		}),
+		WithTenantResolver(tenantOfPrincipal),
+		WithTenantMetrics(TenantMetricsConfig{}),
+		WithHTTPHandlerFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+			gotTenant = TenantFromContext(r.Context())
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}),
+	)
+	handler, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/fail", nil)
+	req.Header.Set("Authorization", "Bearer acme/alice")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if gotTenant != "acme" {
+		t.Errorf("expected tenant acme in the handler, got %q", gotTenant)
+	}
+	if v := testutil.ToFloat64(s.metrics.tenantRequests.WithLabelValues("acme", "http", "5xx")); v != 1 {
+		t.Errorf("expected 1 failed request of acme, got %v", v)
+	}
+}
+
+func TestTenantUnaryInterceptor_Quota(t *testing.T) {
+	prometheus.DefaultRegisterer = prometheus.NewRegistry()
+	s := newSlowTestServer(t,
+		WithMetrics(),
+		WithTenantResolver(tenantOfPrincipal),
+		WithTenantMetrics(TenantMetricsConfig{TopN: 5}),
+		WithPrincipalQuota(quota.NewMemoryStore(), QuotaLimit(1, time.Minute), QuotaKey(tenantOfPrincipal)),
+	)
+	s.cfg.quota.now = func() time.Time { return time.Unix(1800, 0) }
+	info := &grpc.UnaryServerInfo{FullMethod: "/item.v1.ItemService/GetItem"}
+	call := func() error {
+		ctx := ContextWithPrincipal(context.Background(), "acme/alice")
+		_, err := tenantUnaryInterceptor(s)(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return quotaUnaryInterceptor(s)(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "ok", nil
+			})
+		})
+		return err
+	}
+
+	if err := call(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := call(); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+
+	for result, want := range map[string]float64{"allowed": 1, "exceeded": 1} {
+		if v := testutil.ToFloat64(s.metrics.tenantQuota.WithLabelValues("acme", result)); v != want {
+			t.Errorf("expected %v %s quota calls of acme, got %v", want, result, v)
+		}
+	}
+	for class, want := range map[string]float64{"2xx": 1, "4xx": 1} {
+		if v := testutil.ToFloat64(s.metrics.tenantRequests.WithLabelValues("acme", "grpc", class)); v != want {
+			t.Errorf("expected %v %s calls of acme, got %v", want, class, v)
+		}
+	}
+}
+
+func TestWithTenantMetrics_Invalid(t *testing.T) {
+	tests := map[string][]Option{
+		"no resolver": {WithMetrics(), WithTenantMetrics(TenantMetricsConfig{})},
+		"no metrics":  {WithTenantResolver(tenantOfPrincipal), WithTenantMetrics(TenantMetricsConfig{})},
+		"negative":    {WithMetrics(), WithTenantResolver(tenantOfPrincipal), WithTenantMetrics(TenantMetricsConfig{TopN: -1})},
+		"nil":         {WithTenantResolver(nil)},
+	}
+	for name, opts := range tests {
+		prometheus.DefaultRegisterer = prometheus.NewRegistry()
+		opts = append(opts, WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+		if _, err := New(opts...); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig, got %v", name, err)
+		}
+	}
+}
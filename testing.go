@@ -8,8 +8,8 @@ import (
 	"net/http/httptest"
 	"strings"
 	"sync"
+	"time"
 
-	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/test/bufconn"
@@ -53,6 +53,7 @@ type TestServer struct {
 	grpcListener *bufconn.Listener
 	httpServer   *httptest.Server
 	grpcConn     *grpc.ClientConn
+	tokenConns   map[string]*grpc.ClientConn
 	mu           sync.Mutex
 	closed       bool
 }
@@ -75,7 +76,7 @@ func NewTestServer(opts ...Option) (*TestServer, error) {
 	}()
 
 	// Build HTTP handler (similar to startHTTP but without starting a real server)
-	httpHandler, err := buildHTTPHandler(server, grpcListener)
+	httpHandler, err := buildTestHTTPHandler(server, grpcListener)
 	if err != nil {
 		server.grpcServer.Stop()
 		return nil, err
@@ -91,13 +92,8 @@ func NewTestServer(opts ...Option) (*TestServer, error) {
 	}, nil
 }
 
-// buildHTTPHandler creates the HTTP handler for the test server.
-func buildHTTPHandler(s *Server, grpcListener *bufconn.Listener) (http.Handler, error) {
-	ctx := context.Background()
-
-	// Create grpc-gateway mux with marshaler options
-	gwMux := runtime.NewServeMux(buildMarshalerOptions(s.cfg)...)
-
+// buildTestHTTPHandler creates the HTTP handler for the test server.
+func buildTestHTTPHandler(s *Server, grpcListener *bufconn.Listener) (http.Handler, error) {
 	// Create a dialer that uses the bufconn listener
 	bufDialer := func(context.Context, string) (net.Conn, error) {
 		return grpcListener.Dial()
@@ -109,57 +105,15 @@ func buildHTTPHandler(s *Server, grpcListener *bufconn.Listener) (http.Handler,
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
 	}
 
-	for _, registrar := range s.cfg.restServices {
-		if err := registrar(ctx, gwMux, "bufnet", opts); err != nil {
-			return nil, fmt.Errorf("failed to register REST service: %w", err)
-		}
-	}
-
-	// Create main HTTP mux
-	mux := http.NewServeMux()
-
-	// Register health endpoints
-	if s.cfg.healthEnabled {
-		registerHealthEndpoints(mux, s.healthHandler)
-	}
-
-	// Register metrics endpoint
-	if s.cfg.metricsEnabled {
-		registerMetricsEndpoint(mux)
-	}
-
-	// Register custom HTTP handlers
-	for _, h := range s.cfg.httpHandlers {
-		mux.Handle(h.pattern, h.handler)
-	}
-
-	// Mount grpc-gateway mux for all other paths
-	mux.Handle("/", gwMux)
-
-	// Build middleware chain
-	var handler http.Handler = mux
-
-	// Apply custom HTTP middlewares
-	for i := len(s.cfg.httpMiddlewares) - 1; i >= 0; i-- {
-		handler = s.cfg.httpMiddlewares[i](handler)
-	}
-
-	// Apply built-in auth middleware
-	if s.cfg.authFunc != nil {
-		handler = authMiddleware(s.cfg, handler)
-	}
-
-	// Apply built-in metrics middleware
-	if s.cfg.metricsEnabled && s.metrics != nil {
-		handler = metricsMiddleware(s.metrics, handler)
-	}
-
-	// Apply built-in CORS middleware
-	if s.cfg.corsEnabled && s.cfg.corsConfig != nil {
-		handler = corsMiddleware(*s.cfg.corsConfig)(handler)
+	gwMux, err := s.newGatewayMux(context.Background(), "bufnet", opts)
+	if err != nil {
+		return nil, err
 	}
 
-	return handler, nil
+	httpHandler := s.buildHTTPHandler(gwMux)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpHandler.ServeHTTP(w, r.WithContext(s.withServer(r.Context())))
+	}), nil
 }
 
 // GRPCClientConn returns a client connection to the in-memory gRPC server.
@@ -169,24 +123,54 @@ func (ts *TestServer) GRPCClientConn(ctx context.Context) *grpc.ClientConn {
 	ts.mu.Lock()
 	defer ts.mu.Unlock()
 
-	if ts.grpcConn != nil {
-		return ts.grpcConn
+	if ts.grpcConn == nil {
+		ts.grpcConn = ts.dial()
 	}
+	return ts.grpcConn
+}
 
+// GRPCClientConnWithToken returns a client connection to the in-memory gRPC
+// server sending token as a bearer token on every call (see
+// InsecureTokenCredentials). Connections are cached per token.
+// The caller should NOT close this connection; use TestServer.Close() instead.
+//
+// Example:
+//
+//	ts, _ := grpckit.NewTestServer(
+//	    grpckit.WithAuth(grpckit.MockAuthFunc("valid-token", "user-123")),
+//	    // ... other options
+//	)
+//	client := pb.NewMyServiceClient(ts.GRPCClientConnWithToken(ctx, "valid-token"))
+func (ts *TestServer) GRPCClientConnWithToken(ctx context.Context, token string) *grpc.ClientConn {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if conn, ok := ts.tokenConns[token]; ok {
+		return conn
+	}
+	if ts.tokenConns == nil {
+		ts.tokenConns = make(map[string]*grpc.ClientConn)
+	}
+	conn := ts.dial(grpc.WithPerRPCCredentials(InsecureTokenCredentials(token)))
+	ts.tokenConns[token] = conn
+	return conn
+}
+
+// dial creates a client connection to the in-memory gRPC server.
+func (ts *TestServer) dial(opts ...grpc.DialOption) *grpc.ClientConn {
 	bufDialer := func(context.Context, string) (net.Conn, error) {
 		return ts.grpcListener.Dial()
 	}
 
-	conn, err := grpc.NewClient("passthrough:///bufnet",
+	opts = append([]grpc.DialOption{
 		grpc.WithContextDialer(bufDialer),
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+	}, opts...)
+	conn, err := grpc.NewClient("passthrough:///bufnet", opts...)
 	if err != nil {
 		// This should not happen with bufconn, but handle it gracefully
 		panic(fmt.Sprintf("failed to dial bufnet: %v", err))
 	}
-
-	ts.grpcConn = conn
 	return conn
 }
 
@@ -219,10 +203,13 @@ func (ts *TestServer) Close() {
 	}
 	ts.closed = true
 
-	// Close gRPC connection if created
+	// Close gRPC connections if created
 	if ts.grpcConn != nil {
 		ts.grpcConn.Close()
 	}
+	for _, conn := range ts.tokenConns {
+		conn.Close()
+	}
 
 	// Stop HTTP test server
 	ts.httpServer.Close()
@@ -282,6 +269,46 @@ func MockAuthFuncAllowAll() AuthFunc {
 	}
 }
 
+// FakeClock is a Clock for tests that only moves when told to. Pass it to
+// WithClock to expire quotas, lockouts and circuit breaker timeouts without
+// sleeping. It is safe for concurrent use.
+//
+// Example:
+//
+//	clock := grpckit.NewFakeClock(time.Now())
+//	ts, _ := grpckit.NewTestServer(grpckit.WithClock(clock), ...)
+//	clock.Advance(time.Minute)
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock creates a fake clock set to now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// Set sets the clock to t.
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = t
+}
+
 // TestServerOption is an option specifically for TestServer.
 type TestServerOption func(*testServerConfig)
 
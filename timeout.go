@@ -0,0 +1,312 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gyozatech/grpckit/internal/pathmatch"
+)
+
+// timeoutResponse is the body returned when a request exceeds its timeout.
+// It matches the grpc-gateway error format for codes.DeadlineExceeded, so
+// clients see the same body whether the gateway or the middleware timed out.
+var timeoutResponse = []byte(`{"code":4,"message":"request timed out","details":[]}`)
+
+// invalidRequestTimeoutResponse is the body returned for an invalid
+// X-Request-Timeout header, in the grpc-gateway error format.
+var invalidRequestTimeoutResponse = []byte(`{"code":3,"message":"invalid X-Request-Timeout header","details":[]}`)
+
+// timeoutOverride holds a per-pattern timeout.
+type timeoutOverride struct {
+	paths   *pathmatch.Matcher
+	timeout time.Duration
+}
+
+// WithHTTPTimeout sets the default timeout for HTTP requests.
+// When exceeded, the request context is cancelled (cancelling the downstream
+// gRPC call) and the client receives 504 Gateway Timeout.
+//
+// Responses are buffered until the handler returns, so a timeout can still
+// replace them with the 504. Responses the handler flushes (server-streaming
+// REST calls, Server-Sent Events) or larger than 1 MiB are written through
+// from then on; a timeout then ends them without a 504. Streams lasting
+// longer than the timeout should be excluded with
+// WithHTTPTimeoutFor(pattern, 0). Protocol upgrades, such as WebSocket
+// connections through WithReverseProxy, have no timeout.
+//
+// Example:
+//
+//	grpckit.WithHTTPTimeout(10 * time.Second)
+func WithHTTPTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.httpTimeout = timeout
+	}
+}
+
+// WithHTTPTimeoutFor overrides the HTTP timeout for paths matching the patterns.
+// Patterns support the same globs as WithProtectedEndpoints. The first matching
+// override wins. A timeout of 0 disables the timeout for matching paths.
+//
+// Example:
+//
+//	grpckit.WithHTTPTimeout(5 * time.Second),
+//	grpckit.WithHTTPTimeoutFor(60*time.Second, "/api/v1/reports/*"),
+//	grpckit.WithHTTPTimeoutFor(0, "/api/v1/events/**"),
+func WithHTTPTimeoutFor(timeout time.Duration, patterns ...string) Option {
+	return func(c *serverConfig) {
+		c.httpTimeoutOverrides = append(c.httpTimeoutOverrides, timeoutOverride{
+			paths:   c.compilePatterns("WithHTTPTimeoutFor", patterns),
+			timeout: timeout,
+		})
+	}
+}
+
+// WithRequestTimeoutHeaders lets REST clients set the deadline of the
+// proxied gRPC call, so their timeout budget propagates end-to-end: the gRPC
+// handler sees it as its context deadline, and the client receives 504
+// Gateway Timeout when it is exceeded. The timeout is read from an
+// X-Request-Timeout header, as a duration ("2.5s", "500ms") or a number of
+// seconds, or from a Grpc-Timeout header in gRPC format ("500m" for 500ms).
+// Requested timeouts are capped at max.
+//
+// Without this option the gateway honors Grpc-Timeout uncapped, and ignores
+// X-Request-Timeout. WithHTTPTimeout still applies: the shorter deadline wins.
+//
+// Example:
+//
+//	grpckit.WithRequestTimeoutHeaders(30 * time.Second)
+//
+//	curl -H "X-Request-Timeout: 2s" http://localhost:8080/api/v1/items
+func WithRequestTimeoutHeaders(max time.Duration) Option {
+	return func(c *serverConfig) {
+		if max <= 0 {
+			c.invalid("WithRequestTimeoutHeaders: max must be positive, got %v", max)
+			return
+		}
+		c.maxRequestTimeout = max
+	}
+}
+
+// requestTimeoutHeaderMiddleware sets the deadline of gateway requests from
+// their X-Request-Timeout header, capped at max. Grpc-Timeout headers are
+// parsed by the gateway itself, which derives the call's context from the
+// request's: setting the max as the request's deadline caps them.
+func requestTimeoutHeaderMiddleware(max time.Duration, next http.Handler) http.Handler {
+	if max <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := max
+		if v := r.Header.Get("X-Request-Timeout"); v != "" {
+			d, ok := parseRequestTimeout(v)
+			if !ok {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write(invalidRequestTimeoutResponse)
+				return
+			}
+			timeout = min(d, max)
+		} else if r.Header.Get("Grpc-Timeout") == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// parseRequestTimeout parses a positive duration, or number of seconds.
+func parseRequestTimeout(v string) (time.Duration, bool) {
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		if !(secs > 0 && secs <= math.MaxInt64/float64(time.Second)) { // also rejects NaN
+			return 0, false
+		}
+		return time.Duration(secs * float64(time.Second)), true
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil && d > 0
+}
+
+// timeoutFor returns the timeout that applies to a path.
+func timeoutFor(cfg *serverConfig, urlPath string) time.Duration {
+	for _, o := range cfg.httpTimeoutOverrides {
+		if o.paths.Match(urlPath) {
+			return o.timeout
+		}
+	}
+	return cfg.httpTimeout
+}
+
+// timeoutMiddleware enforces request timeouts with http.TimeoutHandler semantics,
+// but responds with 504 Gateway Timeout and a gateway-compatible JSON body.
+func timeoutMiddleware(cfg *serverConfig, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout := timeoutFor(cfg, r.URL.Path)
+		if timeout <= 0 || isUpgrade(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{w: w, h: make(http.Header)}
+		done := make(chan struct{})
+		panicCh := make(chan any, 1)
+
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicCh <- p
+				}
+			}()
+			next.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicCh:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if !tw.committed {
+				tw.commitLocked()
+			}
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+			if !tw.committed && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusGatewayTimeout)
+				_, _ = w.Write(timeoutResponse)
+			}
+			tw.timedOut = true
+		}
+	})
+}
+
+// isUpgrade reports whether r asks to switch protocols, e.g. to WebSocket.
+// Upgraded connections outlive the request, so no timeout applies.
+func isUpgrade(r *http.Request) bool {
+	if r.Header.Get("Upgrade") == "" {
+		return false
+	}
+	for _, v := range r.Header.Values("Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// timeoutMaxBuffer is the size from which timeoutWriter writes responses
+// through instead of buffering them.
+const timeoutMaxBuffer = 1 << 20
+
+// timeoutWriter buffers a response until the handler completes or times out,
+// or until it flushes or outgrows timeoutMaxBuffer.
+type timeoutWriter struct {
+	w    http.ResponseWriter
+	h    http.Header
+	buf  bytes.Buffer
+	mu   sync.Mutex
+	code int
+
+	timedOut    bool
+	wroteHeader bool
+	committed   bool // the header is sent and writes go through to w
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.committed {
+		// Trailers are set after the header is sent
+		return tw.w.Header()
+	}
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	if !tw.committed && tw.buf.Len()+len(p) > timeoutMaxBuffer {
+		tw.commitLocked()
+	}
+	if tw.committed {
+		return tw.w.Write(p)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// FlushError sends the buffered response and writes the rest through, so
+// streaming responses reach the client as they are produced.
+func (tw *timeoutWriter) FlushError() error {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return http.ErrHandlerTimeout
+	}
+	if !tw.committed {
+		tw.commitLocked()
+	}
+	return http.NewResponseController(tw.w).Flush()
+}
+
+func (tw *timeoutWriter) Flush() {
+	_ = tw.FlushError()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, e.g. to
+// set write deadlines.
+func (tw *timeoutWriter) Unwrap() http.ResponseWriter {
+	return tw.w
+}
+
+// commitLocked sends the header and the buffered body.
+func (tw *timeoutWriter) commitLocked() {
+	dst := tw.w.Header()
+	for k, v := range tw.h {
+		dst[k] = v
+	}
+	if tw.code == 0 {
+		tw.code = http.StatusOK
+	}
+	tw.w.WriteHeader(tw.code)
+	_, _ = tw.w.Write(tw.buf.Bytes())
+	tw.buf.Reset()
+	tw.committed = true
+}
@@ -0,0 +1,303 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	itempb "github.com/gyozatech/grpckit/example/proto/gen"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestTimeoutFor(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(5 * time.Second)(cfg)
+	WithHTTPTimeoutFor(time.Minute, "/api/v1/reports/*")(cfg)
+	WithHTTPTimeoutFor(0, "/api/v1/events/**")(cfg)
+
+	tests := []struct {
+		path     string
+		expected time.Duration
+	}{
+		{"/api/v1/items", 5 * time.Second},
+		{"/api/v1/reports/daily", time.Minute},
+		{"/api/v1/events/stream/1", 0},
+	}
+
+	for _, tt := range tests {
+		if got := timeoutFor(cfg, tt.path); got != tt.expected {
+			t.Errorf("timeoutFor(%s) = %v, want %v", tt.path, got, tt.expected)
+		}
+	}
+}
+
+func TestTimeoutMiddleware_Completes(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(time.Second)(cfg)
+
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); !ok {
+			t.Error("expected request context to have a deadline")
+		}
+		w.Header().Set("X-Custom", "yes")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/items", nil))
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("expected 201, got %d", rec.Code)
+	}
+	if rec.Header().Get("X-Custom") != "yes" {
+		t.Error("expected handler headers to be copied")
+	}
+	if rec.Body.String() != "created" {
+		t.Errorf("expected body 'created', got %q", rec.Body.String())
+	}
+}
+
+func TestTimeoutMiddleware_TimesOut(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(20 * time.Millisecond)(cfg)
+
+	cancelled := make(chan struct{})
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		close(cancelled)
+		_, _ = w.Write([]byte("late"))
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow", nil))
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d", rec.Code)
+	}
+	if rec.Body.String() != string(timeoutResponse) {
+		t.Errorf("unexpected body: %s", rec.Body.String())
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("expected handler context to be cancelled")
+	}
+}
+
+func TestTimeoutMiddleware_Disabled(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(time.Second)(cfg)
+	WithHTTPTimeoutFor(0, "/stream")(cfg)
+
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := r.Context().Deadline(); ok {
+			t.Error("expected no deadline for excluded path")
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/stream", nil))
+}
+
+func TestTimeoutMiddleware_PropagatesPanic(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(time.Second)(cfg)
+
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic to propagate")
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"2.5s", 2500 * time.Millisecond, true},
+		{"500ms", 500 * time.Millisecond, true},
+		{"3", 3 * time.Second, true},
+		{"0.25", 250 * time.Millisecond, true},
+		{"0", 0, false},
+		{"-1s", 0, false},
+		{"NaN", 0, false},
+		{"1e300", 0, false},
+		{"soon", 0, false},
+	}
+	for _, tt := range tests {
+		d, ok := parseRequestTimeout(tt.value)
+		if ok != tt.ok || ok && d != tt.expected {
+			t.Errorf("parseRequestTimeout(%q) = %v, %v; expected %v, %v", tt.value, d, ok, tt.expected, tt.ok)
+		}
+	}
+}
+
+func TestRequestTimeoutHeaderMiddleware(t *testing.T) {
+	var deadline time.Duration
+	var hasDeadline bool
+	handler := requestTimeoutHeaderMiddleware(10*time.Second, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, ok := r.Context().Deadline()
+		deadline, hasDeadline = time.Until(d), ok
+	}))
+
+	tests := []struct {
+		header, value string
+		expected      time.Duration // 0 for no deadline
+	}{
+		{"", "", 0},
+		{"X-Request-Timeout", "2s", 2 * time.Second},
+		{"X-Request-Timeout", "1h", 10 * time.Second}, // capped
+		{"Grpc-Timeout", "1H", 10 * time.Second},      // capped, the gateway applies shorter ones
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+		if tt.header != "" {
+			req.Header.Set(tt.header, tt.value)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if hasDeadline != (tt.expected > 0) || tt.expected > 0 && (deadline > tt.expected || deadline < tt.expected-time.Second) {
+			t.Errorf("%s: %q: expected deadline %v, got %v (set: %v)", tt.header, tt.value, tt.expected, deadline, hasDeadline)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+	req.Header.Set("X-Request-Timeout", "soon")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "X-Request-Timeout") {
+		t.Errorf("expected 400 for an invalid header, got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+type blockingItemServer struct {
+	itempb.UnimplementedItemServiceServer
+}
+
+func (blockingItemServer) GetItem(ctx context.Context, req *itempb.GetItemRequest) (*itempb.GetItemResponse, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		return nil, status.Error(codes.FailedPrecondition, "no deadline")
+	}
+	<-ctx.Done()
+	return nil, status.FromContextError(ctx.Err()).Err()
+}
+
+func TestWithRequestTimeoutHeaders(t *testing.T) {
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {
+			itempb.RegisterItemServiceServer(s, blockingItemServer{})
+		}),
+		WithRESTService(itempb.RegisterItemServiceHandlerFromEndpoint),
+		WithRequestTimeoutHeaders(time.Second),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	defer s.Shutdown()
+	h, err := s.Handler()
+	if err != nil {
+		t.Fatalf("Handler failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/items/1", nil)
+	req.Header.Set("X-Request-Timeout", "50ms")
+	rec := httptest.NewRecorder()
+	start := time.Now()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("expected 504, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the client's timeout to apply, took %v", elapsed)
+	}
+
+	if _, err := New(WithGRPCService(func(grpc.ServiceRegistrar) {}), WithRequestTimeoutHeaders(0)); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for a zero max, got %v", err)
+	}
+}
+
+func TestTimeoutMiddleware_Streams(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(time.Second)(cfg)
+
+	release := make(chan struct{})
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		if err := http.NewResponseController(w).Flush(); err != nil {
+			t.Errorf("flush: %v", err)
+		}
+		<-release
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}))
+
+	rec := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-done
+
+	if !rec.Flushed || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Errorf("expected flushed response with handler headers, got %v", rec.Header())
+	}
+	if rec.Body.String() != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("unexpected body %q", rec.Body.String())
+	}
+}
+
+func TestTimeoutMiddleware_LargeResponse(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(time.Second)(cfg)
+
+	chunk := strings.Repeat("x", 64<<10)
+	var buffered int
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 32; i++ {
+			_, _ = w.Write([]byte(chunk))
+		}
+		buffered = w.(*timeoutWriter).buf.Len()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+	if buffered > timeoutMaxBuffer {
+		t.Errorf("expected at most %d bytes buffered, got %d", timeoutMaxBuffer, buffered)
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != 32*len(chunk) {
+		t.Errorf("expected full 200 response, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestTimeoutMiddleware_TimesOutAfterFlush(t *testing.T) {
+	cfg := newServerConfig()
+	WithHTTPTimeout(20 * time.Millisecond)(cfg)
+
+	handler := timeoutMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("partial"))
+		http.NewResponseController(w).Flush()
+		<-r.Context().Done()
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/events", nil))
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" {
+		t.Errorf("expected the streamed response to end without a 504, got %d %q", rec.Code, rec.Body.String())
+	}
+}
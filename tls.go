@@ -0,0 +1,115 @@
+package grpckit
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// WithTLS serves gRPC and HTTP over TLS using the PEM-encoded certificate
+// and key files. The files are loaded by New, which fails if they cannot be
+// read.
+//
+// In single port mode (same gRPC and HTTP port), one TLS listener serves
+// both protocols: ALPN negotiates HTTP/2 or HTTP/1.1, and HTTP/2 requests
+// with an application/grpc content type are routed to the gRPC server, so a
+// single 443 listener can sit behind an ingress or load balancer.
+//
+// Example:
+//
+//	grpckit.Run(
+//	    grpckit.WithGRPCPort(443),
+//	    grpckit.WithHTTPPort(443),
+//	    grpckit.WithTLS("/etc/tls/tls.crt", "/etc/tls/tls.key"),
+//	)
+func WithTLS(certFile, keyFile string) Option {
+	return func(c *serverConfig) {
+		c.tlsCertFile = certFile
+		c.tlsKeyFile = keyFile
+	}
+}
+
+// WithTLSConfig serves gRPC and HTTP over TLS with the given configuration,
+// e.g. to set certificates via GetCertificate or restrict cipher suites.
+// Certificates from WithTLS are added to it. See WithTLS for single port mode.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *serverConfig) {
+		c.tlsConfig = cfg
+	}
+}
+
+// tlsEnabled reports whether any TLS option is configured.
+func tlsEnabled(cfg *serverConfig) bool {
+	return cfg.tlsConfig != nil || cfg.tlsCertFile != "" || cfg.tlsKeyFile != "" || cfg.mtlsCAFile != "" || cfg.certProvider != nil
+}
+
+// buildTLSConfig returns the server TLS configuration, or nil when TLS is
+// not configured.
+func buildTLSConfig(cfg *serverConfig) (*tls.Config, error) {
+	if !tlsEnabled(cfg) {
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{}
+	if cfg.tlsConfig != nil {
+		tlsCfg = cfg.tlsConfig.Clone()
+	}
+	if tlsCfg.MinVersion == 0 {
+		tlsCfg.MinVersion = tls.VersionTLS12
+	}
+	if cfg.tlsCertFile != "" || cfg.tlsKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.tlsCertFile, cfg.tlsKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("%w: loading TLS certificate: %v", ErrInvalidConfig, err)
+		}
+		tlsCfg.Certificates = append(tlsCfg.Certificates, cert)
+	}
+	if cfg.certProvider != nil {
+		getCertificate, err := loadCertificateProvider(cfg)
+		if err != nil {
+			return nil, err
+		}
+		tlsCfg.GetCertificate = getCertificate
+	}
+	if cfg.mtlsCAFile != "" {
+		if err := configureClientCAs(tlsCfg, cfg); err != nil {
+			return nil, err
+		}
+	}
+	if len(tlsCfg.Certificates) == 0 && tlsCfg.GetCertificate == nil && tlsCfg.GetConfigForClient == nil {
+		return nil, fmt.Errorf("%w: TLS enabled without a certificate", ErrInvalidConfig)
+	}
+	return tlsCfg, nil
+}
+
+// gatewayTransportCredentials returns the credentials the gateway uses to
+// dial the local gRPC endpoint.
+func (s *Server) gatewayTransportCredentials() grpc.DialOption {
+	if s.tlsConfig == nil {
+		return grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+	// The gateway dials this process's own listener on localhost, which the
+	// serving certificate is usually not issued for
+	tlsCfg := &tls.Config{
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS12,
+	}
+	if s.gatewayCert != nil {
+		tlsCfg.Certificates = []tls.Certificate{*s.gatewayCert}
+	}
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))
+}
+
+// serveHTTP serves the HTTP server on lis, terminating TLS when configured.
+// ServeTLS also enables HTTP/2 via ALPN, which gRPC clients require.
+func (s *Server) serveHTTP(lis net.Listener) error {
+	if s.tlsConfig == nil {
+		return s.httpServer.Serve(lis)
+	}
+	s.httpServer.TLSConfig = s.tlsConfig.Clone()
+	return s.httpServer.ServeTLS(lis, "", "")
+}
@@ -0,0 +1,182 @@
+package grpckit
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// writeTestCert writes a self-signed certificate for localhost and returns
+// the certificate and key file paths.
+func writeTestCert(t *testing.T) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "grpckit-test"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+
+	if cfg, err := buildTLSConfig(newServerConfig()); cfg != nil || err != nil {
+		t.Errorf("expected no TLS by default, got %v (%v)", cfg, err)
+	}
+
+	c := newServerConfig()
+	WithTLS(certFile, keyFile)(c)
+	cfg, err := buildTLSConfig(c)
+	if err != nil {
+		t.Fatalf("buildTLSConfig failed: %v", err)
+	}
+	if len(cfg.Certificates) != 1 || cfg.MinVersion != tls.VersionTLS12 {
+		t.Errorf("unexpected TLS config: %d certificates, min version %x", len(cfg.Certificates), cfg.MinVersion)
+	}
+
+	c = newServerConfig()
+	WithTLS(filepath.Join(t.TempDir(), "missing.crt"), keyFile)(c)
+	if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig for missing file, got %v", err)
+	}
+
+	c = newServerConfig()
+	WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13})(c)
+	if _, err := buildTLSConfig(c); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig without certificate, got %v", err)
+	}
+
+	c = newServerConfig()
+	WithTLSConfig(&tls.Config{MinVersion: tls.VersionTLS13})(c)
+	WithTLS(certFile, keyFile)(c)
+	if cfg, err := buildTLSConfig(c); err != nil || cfg.MinVersion != tls.VersionTLS13 {
+		t.Errorf("expected custom min version to be kept, got %v (%v)", cfg, err)
+	}
+}
+
+func TestNew_InvalidTLS(t *testing.T) {
+	_, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithTLS("missing.crt", "missing.key"),
+	)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig, got %v", err)
+	}
+}
+
+func TestStartCombined_TLS(t *testing.T) {
+	certFile, keyFile := writeTestCert(t)
+	bound := make(chan ListenerBound, 1)
+
+	s, err := New(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCPort(0),
+		WithHTTPPort(0),
+		WithTLS(certFile, keyFile),
+		WithHealthCheck(),
+		WithGRPCHealthService(),
+		WithEventListener(func(e Event) {
+			if lb, ok := e.(ListenerBound); ok {
+				bound <- lb
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- s.Start() }()
+	defer func() {
+		s.Shutdown()
+		if err := <-errCh; err != nil {
+			t.Errorf("Start returned error: %v", err)
+		}
+	}()
+
+	var addr string
+	select {
+	case lb := <-bound:
+		addr = lb.Addr
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for ListenerBound")
+	}
+	clientTLS := &tls.Config{InsecureSkipVerify: true}
+
+	// HTTPS over HTTP/2 and HTTP/1.1
+	for _, h2 := range []bool{true, false} {
+		transport := &http.Transport{TLSClientConfig: clientTLS.Clone(), ForceAttemptHTTP2: h2}
+		if !h2 {
+			transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		}
+		resp, err := (&http.Client{Transport: transport}).Get("https://" + addr + "/healthz")
+		if err != nil {
+			t.Fatalf("GET /healthz (h2=%v) failed: %v", h2, err)
+		}
+		resp.Body.Close()
+		wantProto := map[bool]int{true: 2, false: 1}[h2]
+		if resp.StatusCode != http.StatusOK || resp.ProtoMajor != wantProto {
+			t.Errorf("h2=%v: expected 200 over HTTP/%d, got %d over %s", h2, wantProto, resp.StatusCode, resp.Proto)
+		}
+	}
+
+	// gRPC on the same TLS port
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(clientTLS)))
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("gRPC health check failed: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING, got %v", resp.Status)
+	}
+}
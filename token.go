@@ -0,0 +1,134 @@
+package grpckit
+
+import (
+	"context"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenKey is the context key for the inbound bearer token.
+type tokenKey struct{}
+
+// contextWithToken returns a context carrying the inbound bearer token.
+func contextWithToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, tokenKey{}, token)
+}
+
+// TokenFromContext returns the bearer token of the inbound request (without
+// the "Bearer " prefix), or "" if there is none or the request uses another
+// scheme, such as Basic. It works in HTTP handlers behind WithAuth and in
+// gRPC handlers.
+func TokenFromContext(ctx context.Context) string {
+	if token, ok := ctx.Value(tokenKey{}).(string); ok {
+		return token
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get("authorization"); len(values) > 0 {
+		if scheme, credentials := parseAuthorization(values[0]); isBearer(scheme) {
+			return credentials
+		}
+	}
+	return ""
+}
+
+// TokenExchangeFunc exchanges the inbound token for one valid for a
+// downstream target (e.g. an OAuth token exchange or a service token).
+type TokenExchangeFunc func(ctx context.Context, token string) (string, error)
+
+// TokenOption configures token pass-through.
+type TokenOption func(*tokenConfig)
+
+// tokenConfig holds the pass-through configuration for one target.
+type tokenConfig struct {
+	exchange TokenExchangeFunc
+	required bool
+}
+
+// TokenExchange replaces the inbound token with the result of fn before it
+// is sent downstream.
+func TokenExchange(fn TokenExchangeFunc) TokenOption {
+	return func(c *tokenConfig) {
+		c.exchange = fn
+	}
+}
+
+// TokenRequired fails outbound calls with Unauthenticated when there is no
+// inbound token, instead of sending them without credentials.
+func TokenRequired() TokenOption {
+	return func(c *tokenConfig) {
+		c.required = true
+	}
+}
+
+// attachToken adds the inbound (or exchanged) token to the outgoing metadata,
+// leaving calls that already carry an authorization header untouched.
+func (c *tokenConfig) attachToken(ctx context.Context) (context.Context, error) {
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get("authorization")) > 0 {
+		return ctx, nil
+	}
+
+	token := TokenFromContext(ctx)
+	if token == "" {
+		if c.required {
+			return nil, status.Error(codes.Unauthenticated, "no inbound token to forward")
+		}
+		return ctx, nil
+	}
+
+	if c.exchange != nil {
+		exchanged, err := c.exchange(ctx, token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, fmt.Sprintf("token exchange failed: %v", err))
+		}
+		token = exchanged
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token), nil
+}
+
+// TokenPassthroughUnaryClientInterceptor returns a client interceptor that
+// forwards the inbound request's bearer token on outbound calls, so identity
+// propagates through a service chain. Configure one interceptor per target
+// connection, e.g. with a TokenExchange for targets that need another token.
+//
+// Example:
+//
+//	conn, _ := grpc.NewClient(billingAddr,
+//	    grpc.WithUnaryInterceptor(grpckit.TokenPassthroughUnaryClientInterceptor()),
+//	)
+//
+//	// In a handler: the caller's token is attached automatically
+//	resp, err := billing.Charge(ctx, req)
+func TokenPassthroughUnaryClientInterceptor(opts ...TokenOption) grpc.UnaryClientInterceptor {
+	cfg := &tokenConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, err := cfg.attachToken(ctx)
+		if err != nil {
+			return err
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// TokenPassthroughStreamClientInterceptor is the stream variant of
+// TokenPassthroughUnaryClientInterceptor.
+func TokenPassthroughStreamClientInterceptor(opts ...TokenOption) grpc.StreamClientInterceptor {
+	cfg := &tokenConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, err := cfg.attachToken(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return streamer(ctx, desc, cc, method, opts...)
+	}
+}
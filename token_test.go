@@ -0,0 +1,134 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// outgoingAuth returns an invoker that records the outgoing authorization header.
+func outgoingAuth(got *string) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		if v := md.Get("authorization"); len(v) > 0 {
+			*got = v[0]
+		}
+		return nil
+	}
+}
+
+func TestTokenFromContext(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer grpc-token"))
+	if got := TokenFromContext(ctx); got != "grpc-token" {
+		t.Errorf("expected token from incoming metadata, got %q", got)
+	}
+
+	ctx = contextWithToken(ctx, "http-token")
+	if got := TokenFromContext(ctx); got != "http-token" {
+		t.Errorf("expected token from context value, got %q", got)
+	}
+
+	if got := TokenFromContext(context.Background()); got != "" {
+		t.Errorf("expected empty token, got %q", got)
+	}
+}
+
+func TestTokenPassthrough_Forwards(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer abc"))
+
+	var got string
+	err := TokenPassthroughUnaryClientInterceptor()(ctx, "/billing.v1.Billing/Charge", nil, nil, nil, outgoingAuth(&got))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "Bearer abc" {
+		t.Errorf("expected forwarded token, got %q", got)
+	}
+}
+
+func TestTokenPassthrough_KeepsExplicitAuth(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer inbound"))
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer explicit")
+
+	var got string
+	TokenPassthroughUnaryClientInterceptor()(ctx, "/m", nil, nil, nil, outgoingAuth(&got))
+
+	if got != "Bearer explicit" {
+		t.Errorf("expected explicit authorization to be kept, got %q", got)
+	}
+}
+
+func TestTokenPassthrough_Exchange(t *testing.T) {
+	ctx := contextWithToken(context.Background(), "user-token")
+	interceptor := TokenPassthroughUnaryClientInterceptor(TokenExchange(func(ctx context.Context, token string) (string, error) {
+		return "svc-" + token, nil
+	}))
+
+	var got string
+	interceptor(ctx, "/m", nil, nil, nil, outgoingAuth(&got))
+	if got != "Bearer svc-user-token" {
+		t.Errorf("expected exchanged token, got %q", got)
+	}
+
+	failing := TokenPassthroughUnaryClientInterceptor(TokenExchange(func(ctx context.Context, token string) (string, error) {
+		return "", errors.New("denied")
+	}))
+	err := failing(ctx, "/m", nil, nil, nil, outgoingAuth(&got))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated on exchange failure, got %v", err)
+	}
+}
+
+func TestTokenPassthrough_Required(t *testing.T) {
+	var got string
+	err := TokenPassthroughUnaryClientInterceptor(TokenRequired())(context.Background(), "/m", nil, nil, nil, outgoingAuth(&got))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without inbound token, got %v", err)
+	}
+
+	err = TokenPassthroughUnaryClientInterceptor()(context.Background(), "/m", nil, nil, nil, outgoingAuth(&got))
+	if err != nil || got != "" {
+		t.Errorf("expected call without token to proceed unauthenticated, got err=%v auth=%q", err, got)
+	}
+}
+
+func TestTokenPassthrough_Stream(t *testing.T) {
+	ctx := contextWithToken(context.Background(), "abc")
+
+	var got string
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get("authorization")[0]
+		return nil, nil
+	}
+	TokenPassthroughStreamClientInterceptor()(ctx, &grpc.StreamDesc{}, nil, "/m", streamer)
+
+	if got != "Bearer abc" {
+		t.Errorf("expected forwarded token on stream, got %q", got)
+	}
+}
+
+func TestAuthMiddleware_StoresToken(t *testing.T) {
+	cfg := newServerConfig()
+	WithAuth(MockAuthFuncAllowAll())(cfg)
+
+	var got string
+	handler := authMiddleware(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = TokenFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.Header.Set("Authorization", "Bearer xyz")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got != "xyz" {
+		t.Errorf("expected token in HTTP handler context, got %q", got)
+	}
+}
@@ -0,0 +1,225 @@
+package grpckit
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	// middlewareTraceHeader is the HTTP response header listing the
+	// middleware that ran for a request.
+	middlewareTraceHeader = "X-Middleware-Trace"
+
+	// middlewareTraceMetadata is the gRPC header (unary) or trailer (stream)
+	// listing the interceptors that ran for a call.
+	middlewareTraceMetadata = "x-middleware-trace"
+)
+
+// WithMiddlewareTrace records which middleware and interceptors ran for each
+// request, in order, to diagnose why a middleware did not fire for a path.
+// Also enabled with GRPCKIT_MIDDLEWARE_TRACE=true or middleware_trace.enabled
+// in the config file. It adds a small overhead to every request and exposes
+// the server's internals, so only enable it while debugging.
+//
+// HTTP responses carry the trace in the X-Middleware-Trace header, ending
+// with the matched route. gRPC calls carry it in the x-middleware-trace
+// header (unary) or trailer (streams); for REST calls through the gateway it
+// shows up as Grpc-Metadata-X-Middleware-Trace. Built-in steps that let the
+// request through without acting, such as auth on a public endpoint or an
+// interceptor excluded with ExceptEndpoints, are marked "(skipped)". Each
+// trace is also logged at debug level.
+//
+// Example output:
+//
+//	X-Middleware-Trace: access_log, metrics, auth (skipped), slow_request, middleware[0], route /
+//	level=DEBUG msg="middleware trace" component=trace protocol=http method=GET path=/api/v1/items trace="access_log > metrics > auth (skipped) > slow_request > middleware[0] > route /"
+func WithMiddlewareTrace() Option {
+	return func(c *serverConfig) {
+		c.middlewareTrace = true
+	}
+}
+
+// middlewareTrace collects the steps run for one request.
+type middlewareTrace struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+type middlewareTraceKey struct{}
+
+func (t *middlewareTrace) add(step string) {
+	t.mu.Lock()
+	t.steps = append(t.steps, step)
+	t.mu.Unlock()
+}
+
+func (t *middlewareTrace) join(sep string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return strings.Join(t.steps, sep)
+}
+
+// traceFromContext returns the request's trace, or nil when tracing is off.
+func traceFromContext(ctx context.Context) *middlewareTrace {
+	t, _ := ctx.Value(middlewareTraceKey{}).(*middlewareTrace)
+	return t
+}
+
+// traceSkipped marks the most recent step as having let the request through
+// without acting on it. It is a no-op when tracing is off.
+func traceSkipped(ctx context.Context) {
+	t := traceFromContext(ctx)
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	if n := len(t.steps); n > 0 {
+		t.steps[n-1] += " (skipped)"
+	}
+	t.mu.Unlock()
+}
+
+// traced records name in the request's trace before running next. It
+// returns next unchanged when tracing is off.
+func (s *Server) traced(name string, next http.Handler) http.Handler {
+	if !s.cfg.middlewareTrace {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t := traceFromContext(r.Context()); t != nil {
+			t.add(name)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// traceRoute records the mux pattern matching the request.
+func (s *Server) traceRoute(mux *http.ServeMux) http.Handler {
+	if !s.cfg.middlewareTrace {
+		return mux
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t := traceFromContext(r.Context()); t != nil {
+			_, pattern := mux.Handler(r)
+			t.add("route " + pattern)
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// traceMiddleware starts a trace for every HTTP request and writes it to the
+// response header before the first byte is sent.
+func (s *Server) traceMiddleware(next http.Handler) http.Handler {
+	if !s.cfg.middlewareTrace {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t := &middlewareTrace{}
+		tw := &traceResponseWriter{ResponseWriter: w, trace: t}
+		next.ServeHTTP(tw, r.WithContext(context.WithValue(r.Context(), middlewareTraceKey{}, t)))
+		tw.writeHeader()
+		if s.logger.Enabled(r.Context(), slog.LevelDebug) {
+			s.logger.Debug("middleware trace", "component", "trace",
+				"protocol", "http", "method", r.Method, "path", r.URL.Path, "trace", t.join(" > "))
+		}
+	})
+}
+
+// traceResponseWriter adds the trace header once, before the response
+// headers are written.
+type traceResponseWriter struct {
+	http.ResponseWriter
+	trace   *middlewareTrace
+	written bool
+}
+
+func (w *traceResponseWriter) writeHeader() {
+	if w.written {
+		return
+	}
+	w.written = true
+	w.ResponseWriter.Header().Set(middlewareTraceHeader, w.trace.join(", "))
+}
+
+func (w *traceResponseWriter) WriteHeader(code int) {
+	w.writeHeader()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *traceResponseWriter) Write(b []byte) (int, error) {
+	w.writeHeader()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *traceResponseWriter) Flush() {
+	w.writeHeader()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *traceResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// tracedUnary records name in the call's trace before running interceptor.
+// It returns interceptor unchanged when tracing is off.
+func (s *Server) tracedUnary(name string, interceptor grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	if !s.cfg.middlewareTrace {
+		return interceptor
+	}
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if t := traceFromContext(ctx); t != nil {
+			t.add(name)
+		}
+		return interceptor(ctx, req, info, handler)
+	}
+}
+
+// tracedStream is the stream equivalent of tracedUnary.
+func (s *Server) tracedStream(name string, interceptor grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	if !s.cfg.middlewareTrace {
+		return interceptor
+	}
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if t := traceFromContext(ss.Context()); t != nil {
+			t.add(name)
+		}
+		return interceptor(srv, ss, info, handler)
+	}
+}
+
+// traceUnaryInterceptor starts a trace for every unary call and sends it as
+// response header metadata.
+func (s *Server) traceUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	t := &middlewareTrace{}
+	resp, err := handler(context.WithValue(ctx, middlewareTraceKey{}, t), req)
+
+	// Fails if the handler already sent its headers; nothing to do then
+	_ = grpc.SetHeader(ctx, metadata.Pairs(middlewareTraceMetadata, t.join(", ")))
+	if s.logger.Enabled(ctx, slog.LevelDebug) {
+		s.logger.Debug("middleware trace", "component", "trace",
+			"protocol", "grpc", "method", info.FullMethod, "trace", t.join(" > "))
+	}
+	return resp, err
+}
+
+// traceStreamInterceptor starts a trace for every stream and sends it as
+// trailer metadata, as the headers may be sent before the stream ends.
+func (s *Server) traceStreamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	t := &middlewareTrace{}
+	err := handler(srv, &contextServerStream{ServerStream: ss, ctx: context.WithValue(ss.Context(), middlewareTraceKey{}, t)})
+
+	ss.SetTrailer(metadata.Pairs(middlewareTraceMetadata, t.join(", ")))
+	if s.logger.Enabled(ss.Context(), slog.LevelDebug) {
+		s.logger.Debug("middleware trace", "component", "trace",
+			"protocol", "grpc", "method", info.FullMethod, "trace", t.join(" > "))
+	}
+	return err
+}
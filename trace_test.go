@@ -0,0 +1,109 @@
+package grpckit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+)
+
+func newTraceTestHandler(t *testing.T, opts ...Option) http.Handler {
+	t.Helper()
+	opts = append([]Option{
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithMiddlewareTrace(),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/public"),
+		WithHTTPMiddleware(func(next http.Handler) http.Handler { return next }),
+		WithHTTPHandler("/public", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})),
+		WithHTTPHandler("/private", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte("ok"))
+		})),
+	}, opts...)
+	s, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	return s.buildHTTPHandler(http.NotFoundHandler())
+}
+
+func TestMiddlewareTrace_HTTP(t *testing.T) {
+	buf := captureLog(t)
+	handler := newTraceTestHandler(t, WithAccessLog(), WithLogLevel("debug"))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/public", nil))
+	want := "access_log, auth (skipped), middleware[0], route /public"
+	if got := rec.Header().Get(middlewareTraceHeader); got != want {
+		t.Errorf("expected trace %q, got %q", want, got)
+	}
+	if !strings.Contains(buf.String(), `protocol=http method=GET path=/public trace="access_log > auth (skipped) > middleware[0] > route /public"`) {
+		t.Errorf("expected trace in debug log, got %q", buf.String())
+	}
+
+	// Rejected requests show where the chain stopped
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/private", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rec.Code)
+	}
+	if got := rec.Header().Get(middlewareTraceHeader); got != "access_log, auth" {
+		t.Errorf("expected trace to stop at auth, got %q", got)
+	}
+}
+
+func TestMiddlewareTrace_Disabled(t *testing.T) {
+	s, err := New(WithGRPCService(func(s grpc.ServiceRegistrar) {}))
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.buildHTTPHandler(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := rec.Header().Get(middlewareTraceHeader); got != "" {
+		t.Errorf("expected no trace header, got %q", got)
+	}
+}
+
+func TestMiddlewareTrace_EnvVar(t *testing.T) {
+	t.Setenv("GRPCKIT_MIDDLEWARE_TRACE", "true")
+	cfg := newServerConfig()
+	applyEnvVars(cfg)
+	if !cfg.middlewareTrace {
+		t.Error("expected GRPCKIT_MIDDLEWARE_TRACE to enable tracing")
+	}
+}
+
+func TestMiddlewareTrace_GRPC(t *testing.T) {
+	ts, err := NewTestServer(
+		WithGRPCService(func(s grpc.ServiceRegistrar) {}),
+		WithGRPCHealthService(),
+		WithMiddlewareTrace(),
+		WithAuth(MockAuthFunc("token", "user")),
+		WithPublicEndpoints("/grpc.health.v1.Health/Check"),
+		WithUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}, ExceptEndpoints("/grpc.health.v1.Health/Check")),
+	)
+	if err != nil {
+		t.Fatalf("NewTestServer failed: %v", err)
+	}
+	defer ts.Close()
+
+	var header metadata.MD
+	client := healthpb.NewHealthClient(ts.GRPCClientConn(context.Background()))
+	if _, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{}, grpc.Header(&header)); err != nil {
+		t.Fatalf("health check failed: %v", err)
+	}
+
+	want := "correlation, errors, auth (skipped), interceptor[0] (skipped)"
+	if got := header.Get(middlewareTraceMetadata); len(got) != 1 || got[0] != want {
+		t.Errorf("expected trace %q, got %v", want, got)
+	}
+}
@@ -0,0 +1,100 @@
+package grpckit
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/textproto"
+	"slices"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"golang.org/x/net/http/httpguts"
+	"google.golang.org/protobuf/proto"
+)
+
+// WithTrailerHeaders exposes gRPC trailers set by services with
+// grpc.SetTrailer (e.g. query cost or debug information) as headers of REST
+// responses, including error responses. The gateway otherwise only sends
+// trailers as HTTP trailers to clients sending "TE: trailers", which
+// browsers and most HTTP clients don't, so they are dropped.
+//
+// headers maps trailer keys to header names; an empty name keeps the
+// gateway's "Grpc-Trailer-" prefixed name. Values of binary ("-bin")
+// trailers are base64-encoded. Other trailers are forwarded as before.
+// Streaming responses are sent before their trailers are known and are
+// unaffected.
+//
+// Example:
+//
+//	grpckit.WithTrailerHeaders(map[string]string{
+//	    "x-query-cost": "X-Query-Cost",
+//	    "x-debug-id":   "",
+//	})
+func WithTrailerHeaders(headers map[string]string) Option {
+	return func(c *serverConfig) {
+		if c.trailerHeaders == nil {
+			c.trailerHeaders = make(map[string]string)
+		}
+		for key, name := range headers {
+			key = strings.ToLower(key)
+			if name == "" {
+				name = runtime.MetadataTrailerPrefix + key
+			}
+			if key == "" || !httpguts.ValidHeaderFieldName(name) {
+				c.invalid("WithTrailerHeaders: invalid header %q for trailer %q", name, key)
+				continue
+			}
+			c.trailerHeaders[key] = textproto.CanonicalMIMEHeaderKey(name)
+		}
+	}
+}
+
+// trailerHeadersResponseOption is the gateway forward-response hook applying
+// WithTrailerHeaders.
+func trailerHeadersResponseOption(headers map[string]string) func(context.Context, http.ResponseWriter, proto.Message) error {
+	return func(ctx context.Context, w http.ResponseWriter, _ proto.Message) error {
+		setTrailerHeaders(ctx, w, headers)
+		return nil
+	}
+}
+
+// trailerHeadersErrorHandler applies WithTrailerHeaders before next writes
+// the error response.
+func trailerHeadersErrorHandler(headers map[string]string, next runtime.ErrorHandlerFunc) runtime.ErrorHandlerFunc {
+	return func(ctx context.Context, mux *runtime.ServeMux, m runtime.Marshaler, w http.ResponseWriter, r *http.Request, err error) {
+		setTrailerHeaders(ctx, w, headers)
+		next(ctx, mux, m, w, r, err)
+	}
+}
+
+// setTrailerHeaders copies the configured trailers into response headers.
+func setTrailerHeaders(ctx context.Context, w http.ResponseWriter, headers map[string]string) {
+	md, ok := runtime.ServerMetadataFromContext(ctx)
+	if !ok {
+		return
+	}
+	h := w.Header()
+	for key, name := range headers {
+		values := md.TrailerMD.Get(key)
+		if len(values) == 0 {
+			continue
+		}
+		// Sent as a header now, so the gateway must not send it again as an
+		// HTTP trailer, nor announce it
+		delete(md.TrailerMD, key)
+		trailer := textproto.CanonicalMIMEHeaderKey(runtime.MetadataTrailerPrefix + key)
+		if announced := slices.DeleteFunc(h["Trailer"], func(v string) bool { return v == trailer }); len(announced) > 0 {
+			h["Trailer"] = announced
+		} else {
+			delete(h, "Trailer")
+		}
+
+		for _, v := range values {
+			if strings.HasSuffix(key, "-bin") {
+				v = base64.StdEncoding.EncodeToString([]byte(v))
+			}
+			h.Add(name, v)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithTrailerHeaders(t *testing.T) {
+	cfg := newServerConfig()
+	WithTrailerHeaders(map[string]string{"X-Query-Cost": "x-cost", "x-debug-id": ""})(cfg)
+
+	if len(cfg.errs) != 0 {
+		t.Fatalf("unexpected errors: %v", cfg.errs)
+	}
+	if got := cfg.trailerHeaders["x-query-cost"]; got != "X-Cost" {
+		t.Errorf("expected X-Cost, got %q", got)
+	}
+	if got := cfg.trailerHeaders["x-debug-id"]; got != "Grpc-Trailer-X-Debug-Id" {
+		t.Errorf("expected default trailer header name, got %q", got)
+	}
+
+	cfg = newServerConfig()
+	WithTrailerHeaders(map[string]string{"x-cost": "X Cost", "": "X-Empty"})(cfg)
+	if len(cfg.errs) != 2 || !errors.Is(cfg.errs[0], ErrInvalidConfig) {
+		t.Errorf("expected invalid header names to be rejected, got %v", cfg.errs)
+	}
+}
+
+func TestTrailerHeadersResponseOption(t *testing.T) {
+	md := runtime.ServerMetadata{TrailerMD: metadata.Pairs("x-query-cost", "42", "x-trace-bin", "\x01\x02", "x-other", "v")}
+	ctx := runtime.NewServerMetadataContext(context.Background(), md)
+	rec := httptest.NewRecorder()
+	rec.Header()["Trailer"] = []string{"Grpc-Trailer-X-Query-Cost", "Grpc-Trailer-X-Other"}
+
+	headers := map[string]string{"x-query-cost": "X-Query-Cost", "x-trace-bin": "X-Trace", "x-missing": "X-Missing"}
+	if err := trailerHeadersResponseOption(headers)(ctx, rec, nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := rec.Header().Get("X-Query-Cost"); got != "42" {
+		t.Errorf("expected X-Query-Cost 42, got %q", got)
+	}
+	if got := rec.Header().Get("X-Trace"); got != "AQI=" {
+		t.Errorf("expected base64 binary trailer, got %q", got)
+	}
+	if _, ok := rec.Header()["X-Missing"]; ok {
+		t.Error("expected missing trailer not to be set")
+	}
+	if got := rec.Header()["Trailer"]; len(got) != 1 || got[0] != "Grpc-Trailer-X-Other" {
+		t.Errorf("expected only unconfigured trailers to be announced, got %v", got)
+	}
+	if _, ok := md.TrailerMD["x-query-cost"]; ok {
+		t.Error("expected exposed trailer to be removed from the forwarded trailers")
+	}
+	if _, ok := md.TrailerMD["x-other"]; !ok {
+		t.Error("expected unconfigured trailer to be kept")
+	}
+}
+
+func TestTrailerHeadersErrorHandler(t *testing.T) {
+	md := runtime.ServerMetadata{TrailerMD: metadata.Pairs("x-query-cost", "7")}
+	ctx := runtime.NewServerMetadataContext(context.Background(), md)
+	rec := httptest.NewRecorder()
+
+	handler := trailerHeadersErrorHandler(map[string]string{"x-query-cost": "X-Query-Cost"}, gatewayErrorHandler)
+	req := httptest.NewRequest(http.MethodGet, "/v1/items", nil)
+	req.Header.Set("TE", "trailers")
+	handler(ctx, runtime.NewServeMux(), &runtime.JSONPb{}, rec, req, status.Error(codes.NotFound, "not found"))
+
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-Query-Cost"); got != "7" {
+		t.Errorf("expected X-Query-Cost 7, got %q", got)
+	}
+	if got := rec.Header().Get("Grpc-Trailer-X-Query-Cost"); got != "" {
+		t.Errorf("expected trailer not to be sent twice, got %q", got)
+	}
+}
@@ -0,0 +1,78 @@
+package grpckit
+
+import (
+	"net/http"
+	"strings"
+)
+
+// RequestTransform modifies a request before it reaches a handler registered
+// with WithHTTPHandler. Transforms receive a copy of the request, so they
+// may change its URL and headers freely.
+type RequestTransform func(r *http.Request)
+
+// StripPrefix removes prefix from the request path, like http.StripPrefix,
+// but leaves paths without the prefix unchanged instead of returning 404.
+//
+// Example:
+//
+//	grpckit.WithHTTPHandler("/legacy/", legacyProxy, grpckit.StripPrefix("/legacy"))
+func StripPrefix(prefix string) RequestTransform {
+	return func(r *http.Request) {
+		p, ok := strings.CutPrefix(r.URL.Path, prefix)
+		if !ok {
+			return
+		}
+		if !strings.HasPrefix(p, "/") {
+			p = "/" + p
+		}
+		r.URL.Path, r.URL.RawPath = p, ""
+	}
+}
+
+// RewritePath replaces the request path matching from with to. Patterns are
+// paths or "/**" subtrees, as in WithRouteAlias: with
+// RewritePath("/v1/**", "/internal/**"), "/v1/items/7" becomes
+// "/internal/items/7". Paths that do not match are left unchanged.
+func RewritePath(from, to string) RequestTransform {
+	rw := newRouteRewrite(from, to, 0)
+	return func(r *http.Request) {
+		if target, ok := rw.target(r.URL.Path); ok {
+			r.URL.Path, r.URL.RawPath = target, ""
+		}
+	}
+}
+
+// SetRequestHeader sets a request header, replacing any existing values.
+func SetRequestHeader(key, value string) RequestTransform {
+	return func(r *http.Request) {
+		r.Header.Set(key, value)
+	}
+}
+
+// AddRequestHeader appends a value to a request header.
+func AddRequestHeader(key, value string) RequestTransform {
+	return func(r *http.Request) {
+		r.Header.Add(key, value)
+	}
+}
+
+// RemoveRequestHeader deletes a request header, e.g. to keep credentials
+// meant for this server from being forwarded upstream.
+func RemoveRequestHeader(key string) RequestTransform {
+	return func(r *http.Request) {
+		r.Header.Del(key)
+	}
+}
+
+// transformRequest applies the transforms, in order, to a copy of each
+// request before calling next.
+func transformRequest(transforms []RequestTransform, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r2 := r.Clone(r.Context())
+		for _, t := range transforms {
+			t(r2)
+		}
+		r2.RequestURI = r2.URL.RequestURI()
+		next.ServeHTTP(w, r2)
+	})
+}
@@ -0,0 +1,67 @@
+package grpckit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestTransforms(t *testing.T) {
+	cases := []struct {
+		name      string
+		transform RequestTransform
+		path      string
+		want      string
+	}{
+		{"strip prefix", StripPrefix("/legacy"), "/legacy/items/7", "/items/7"},
+		{"strip whole path", StripPrefix("/legacy"), "/legacy", "/"},
+		{"strip without prefix", StripPrefix("/legacy"), "/items/7", "/items/7"},
+		{"rewrite subtree", RewritePath("/v1/**", "/internal/**"), "/v1/items/7", "/internal/items/7"},
+		{"rewrite exact", RewritePath("/ping", "/healthz"), "/ping", "/healthz"},
+		{"rewrite without match", RewritePath("/v1/**", "/internal/**"), "/v2/items", "/v2/items"},
+	}
+	for _, tc := range cases {
+		r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		tc.transform(r)
+		if r.URL.Path != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, r.URL.Path)
+		}
+	}
+}
+
+func TestWithHTTPHandler_Transforms(t *testing.T) {
+	var got *http.Request
+	cfg := newServerConfig()
+	WithHTTPHandlerFunc("/billing/", func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	},
+		StripPrefix("/billing"),
+		RewritePath("/invoices/**", "/v2/invoices/**"),
+		SetRequestHeader("X-Forwarded-Prefix", "/billing"),
+		AddRequestHeader("X-Tag", "b"),
+		RemoveRequestHeader("Authorization"),
+	)(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/billing/invoices/9?expand=lines", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Tag", "a")
+	cfg.httpHandlers[0].handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	if got == nil {
+		t.Fatal("handler was not called")
+	}
+	if got.URL.Path != "/v2/invoices/9" || got.RequestURI != "/v2/invoices/9?expand=lines" {
+		t.Errorf("unexpected path %q (RequestURI %q)", got.URL.Path, got.RequestURI)
+	}
+	if got.Header.Get("X-Forwarded-Prefix") != "/billing" || got.Header.Get("Authorization") != "" {
+		t.Errorf("unexpected headers %v", got.Header)
+	}
+	if tags := got.Header.Values("X-Tag"); len(tags) != 2 {
+		t.Errorf("expected appended header, got %v", tags)
+	}
+
+	// The original request is left untouched
+	if req.URL.Path != "/billing/invoices/9" || req.Header.Get("Authorization") == "" {
+		t.Errorf("original request was modified: %q %v", req.URL.Path, req.Header)
+	}
+}
@@ -0,0 +1,410 @@
+package grpckit
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// FieldError describes a request field that could not be decoded.
+type FieldError struct {
+	// Field is the field path, using dot notation for nested fields.
+	Field string
+	// Value is the raw value sent by the client.
+	Value string
+	// Reason explains what was expected.
+	Reason string
+}
+
+// description returns the reason followed by the offending value, if any.
+func (f FieldError) description() string {
+	if f.Value == "" {
+		return f.Reason
+	}
+	return fmt.Sprintf("%s, got %q", f.Reason, f.Value)
+}
+
+// ValidationError is returned by the Form, Multipart and XML marshalers when
+// one or more fields cannot be converted to their proto type. It converts to
+// an InvalidArgument status with a google.rpc.BadRequest detail listing every
+// field, so clients can report all problems at once.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + ": " + f.description()
+	}
+	return "invalid fields: " + strings.Join(parts, "; ")
+}
+
+// GRPCStatus returns the InvalidArgument status with per-field violations.
+func (e *ValidationError) GRPCStatus() *status.Status {
+	br := &errdetails.BadRequest{}
+	for _, f := range e.Fields {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       f.Field,
+			Description: f.description(),
+		})
+	}
+
+	st := status.New(codes.InvalidArgument, e.Error())
+	if withDetails, err := st.WithDetails(br); err == nil {
+		return withDetails
+	}
+	return st
+}
+
+// fieldValues holds raw request values by field name. Each item is either a
+// string or a nested fieldValues for message fields.
+type fieldValues map[string][]interface{}
+
+// validationError inspects the raw values against the message descriptor of
+// v and returns a *ValidationError listing every invalid field. When no field
+// can be blamed (or v is not a proto message), decodeErr is returned as is.
+func validationError(v interface{}, values fieldValues, decodeErr error) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return decodeErr
+	}
+	fields := validateFields(msg.ProtoReflect().Descriptor(), values, "")
+	if len(fields) == 0 {
+		return decodeErr
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// validateFields checks values against md. Unknown fields are ignored, as the
+// marshalers discard them.
+func validateFields(md protoreflect.MessageDescriptor, values fieldValues, prefix string) []FieldError {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []FieldError
+	for _, key := range keys {
+		fd := findField(md, key)
+		if fd == nil || fd.IsMap() {
+			continue
+		}
+		path := prefix + string(fd.Name())
+
+		for _, item := range values[key] {
+			switch val := item.(type) {
+			case fieldValues:
+				if fd.Message() != nil {
+					errs = append(errs, validateFields(fd.Message(), val, path+".")...)
+				}
+			case string:
+				// Well-known message types (timestamps, wrappers, ...) are left to protojson
+				if fd.Message() != nil {
+					continue
+				}
+				if reason := checkScalar(fd, val); reason != "" {
+					errs = append(errs, FieldError{Field: path, Value: val, Reason: reason})
+				}
+			}
+		}
+	}
+	return errs
+}
+
+// unknownFieldsError returns a *ValidationError listing the keys of values
+// that are not fields of v (matched by proto or JSON name, like protojson),
+// or nil if there are none.
+func unknownFieldsError(v interface{}, values fieldValues) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil
+	}
+	fields := unknownFields(msg.ProtoReflect().Descriptor(), values, "")
+	if len(fields) == 0 {
+		return nil
+	}
+	return &ValidationError{Fields: fields}
+}
+
+// unknownFields returns the keys of values, recursively, that are not fields of md.
+func unknownFields(md protoreflect.MessageDescriptor, values fieldValues, prefix string) []FieldError {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var errs []FieldError
+	for _, key := range keys {
+		fd := md.Fields().ByName(protoreflect.Name(key))
+		if fd == nil {
+			fd = md.Fields().ByJSONName(key)
+		}
+		if fd == nil {
+			errs = append(errs, FieldError{Field: prefix + key, Reason: "unknown field"})
+			continue
+		}
+		// Maps and free-form types (Struct, Any, ...) hold arbitrary keys
+		if fd.Message() == nil || fd.IsMap() || freeFormTypes[fd.Message().FullName()] {
+			continue
+		}
+		for _, item := range values[key] {
+			if nested, ok := item.(fieldValues); ok {
+				errs = append(errs, unknownFields(fd.Message(), nested, prefix+key+".")...)
+			}
+		}
+	}
+	return errs
+}
+
+// freeFormTypes are well-known types whose JSON objects hold arbitrary keys.
+var freeFormTypes = map[protoreflect.FullName]bool{
+	"google.protobuf.Any":       true,
+	"google.protobuf.Struct":    true,
+	"google.protobuf.Value":     true,
+	"google.protobuf.ListValue": true,
+}
+
+// unknownJSONFieldsError is unknownFieldsError for a JSON object, including
+// nested objects and arrays of objects.
+func unknownJSONFieldsError(v interface{}, data []byte) error {
+	values, ok := jsonFieldValues(data)
+	if !ok {
+		return nil
+	}
+	return unknownFieldsError(v, values)
+}
+
+// jsonFieldValues converts a JSON object to fieldValues. Scalars are kept as
+// their raw JSON text.
+func jsonFieldValues(data []byte) (fieldValues, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return nil, false
+	}
+
+	values := fieldValues{}
+	for key, raw := range obj {
+		if nested, ok := jsonFieldValues(raw); ok {
+			values[key] = append(values[key], nested)
+			continue
+		}
+		var arr []json.RawMessage
+		if err := json.Unmarshal(raw, &arr); err == nil {
+			for _, elem := range arr {
+				if nested, ok := jsonFieldValues(elem); ok {
+					values[key] = append(values[key], nested)
+				} else {
+					values[key] = append(values[key], string(elem))
+				}
+			}
+			if len(arr) > 0 {
+				continue
+			}
+		}
+		values[key] = append(values[key], string(raw))
+	}
+	return values, true
+}
+
+// findField looks up a field by proto name, JSON name, or Go field name
+// (as used by XML element names), ignoring case and underscores.
+func findField(md protoreflect.MessageDescriptor, key string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(key)); fd != nil {
+		return fd
+	}
+	if fd := fields.ByJSONName(key); fd != nil {
+		return fd
+	}
+
+	normalize := func(s string) string { return strings.ReplaceAll(strings.ToLower(s), "_", "") }
+	want := normalize(key)
+	for i := 0; i < fields.Len(); i++ {
+		if normalize(string(fields.Get(i).Name())) == want {
+			return fields.Get(i)
+		}
+	}
+	return nil
+}
+
+// checkScalar returns why s is not a valid value for fd, or "" if it is.
+func checkScalar(fd protoreflect.FieldDescriptor, s string) string {
+	var err error
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if _, err = strconv.ParseBool(s); err != nil {
+			return "expected a boolean"
+		}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if _, err = strconv.ParseInt(s, 10, 32); err != nil {
+			return "expected a 32-bit integer"
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if _, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return "expected a 64-bit integer"
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if _, err = strconv.ParseUint(s, 10, 32); err != nil {
+			return "expected an unsigned 32-bit integer"
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if _, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return "expected an unsigned 64-bit integer"
+		}
+	case protoreflect.FloatKind:
+		if _, err = strconv.ParseFloat(s, 32); err != nil {
+			return "expected a number"
+		}
+	case protoreflect.DoubleKind:
+		if _, err = strconv.ParseFloat(s, 64); err != nil {
+			return "expected a number"
+		}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		if values.ByName(protoreflect.Name(s)) != nil {
+			return ""
+		}
+		if n, err := strconv.ParseInt(s, 10, 32); err == nil && values.ByNumber(protoreflect.EnumNumber(n)) != nil {
+			return ""
+		}
+		names := make([]string, values.Len())
+		for i := range names {
+			names[i] = string(values.Get(i).Name())
+		}
+		return "expected one of " + strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// formFieldValues converts form values to fieldValues, nesting dotted keys
+// the same way valuesToJSON does.
+func formFieldValues(values url.Values) fieldValues {
+	result := fieldValues{}
+	for key, vals := range values {
+		parts := strings.Split(key, ".")
+		if current := nestedFieldValues(result, parts[:len(parts)-1]); current != nil {
+			last := parts[len(parts)-1]
+			for _, v := range vals {
+				current[last] = append(current[last], v)
+			}
+		}
+	}
+	return result
+}
+
+// nestedFieldValues returns the nested values at path, creating them as
+// needed, or nil if a scalar value is already set along the path.
+func nestedFieldValues(values fieldValues, path []string) fieldValues {
+	for _, part := range path {
+		if len(values[part]) == 0 {
+			values[part] = []interface{}{fieldValues{}}
+		}
+		nested, ok := values[part][0].(fieldValues)
+		if !ok {
+			return nil
+		}
+		values = nested
+	}
+	return values
+}
+
+// xmlFieldValues parses the children of the XML root element into
+// fieldValues: leaf elements become strings, others nested values.
+func xmlFieldValues(data []byte) (fieldValues, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			values, _, err := xmlElement(dec)
+			return values, err
+		}
+	}
+}
+
+// xmlElement reads the content of the current element up to its end tag,
+// returning its child elements and its text.
+func xmlElement(dec *xml.Decoder) (fieldValues, string, error) {
+	values := fieldValues{}
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			children, childText, err := xmlElement(dec)
+			if err != nil {
+				return nil, "", err
+			}
+			if len(children) > 0 {
+				values[t.Name.Local] = append(values[t.Name.Local], children)
+			} else {
+				values[t.Name.Local] = append(values[t.Name.Local], strings.TrimSpace(childText))
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			return values, text.String(), nil
+		}
+	}
+}
+
+// validationBody wraps gateway request bodies so that decoders can hand a
+// *ValidationError to the gateway error handler: generated gateway code
+// flattens decode errors into a plain InvalidArgument message, dropping the
+// per-field details.
+type validationBody struct {
+	io.ReadCloser
+	err *ValidationError
+}
+
+// withValidationBody wraps request bodies before they reach the gateway.
+func withValidationBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = &validationBody{ReadCloser: r.Body}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reportValidationError records err on the request body if it is a
+// *ValidationError and r is a wrapped body. err is returned unchanged.
+func reportValidationError(r io.Reader, err error) error {
+	var verr *ValidationError
+	if body, ok := r.(*validationBody); ok && errors.As(err, &verr) {
+		body.err = verr
+	}
+	return err
+}
+
+// requestValidationError returns the *ValidationError recorded while
+// decoding the body of r, if any.
+func requestValidationError(r *http.Request) *ValidationError {
+	if body, ok := r.Body.(*validationBody); ok {
+		return body.err
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestFormMarshaler_ValidationError(t *testing.T) {
+	m := &FormMarshaler{}
+	msg := &descriptorpb.FieldDescriptorProto{}
+
+	err := m.Unmarshal([]byte("name=id&number=abc&label=LABEL_BOGUS&options.packed=maybe&unknown=x"), msg)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+
+	want := []FieldError{
+		{Field: "label", Value: "LABEL_BOGUS", Reason: "expected one of LABEL_OPTIONAL, LABEL_REPEATED, LABEL_REQUIRED"},
+		{Field: "number", Value: "abc", Reason: "expected a 32-bit integer"},
+		{Field: "options.packed", Value: "maybe", Reason: "expected a boolean"},
+	}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), verr.Fields)
+	}
+	for i, f := range want {
+		if verr.Fields[i] != f {
+			t.Errorf("field error %d: expected %+v, got %+v", i, f, verr.Fields[i])
+		}
+	}
+}
+
+func TestFormMarshaler_ValidValues(t *testing.T) {
+	m := &FormMarshaler{}
+	msg := &descriptorpb.FieldDescriptorProto{}
+
+	if err := m.Unmarshal([]byte("name=id&number=7&label=LABEL_REPEATED"), msg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if msg.GetNumber() != 7 || msg.GetLabel() != descriptorpb.FieldDescriptorProto_LABEL_REPEATED {
+		t.Errorf("unexpected message: %v", msg)
+	}
+}
+
+func TestMultipartMarshaler_ValidationError(t *testing.T) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	_ = w.WriteField("name", "id")
+	_ = w.WriteField("number", "1.5")
+	_ = w.Close()
+
+	msg := &descriptorpb.FieldDescriptorProto{}
+	err := (&MultipartMarshaler{}).NewDecoder(&buf).Decode(msg)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if len(verr.Fields) != 1 || verr.Fields[0].Field != "number" {
+		t.Errorf("unexpected field errors: %+v", verr.Fields)
+	}
+}
+
+func TestXMLMarshaler_ValidationError(t *testing.T) {
+	m := &XMLMarshaler{}
+	msg := &descriptorpb.FieldDescriptorProto{}
+
+	err := m.Unmarshal([]byte("<Field><Name>id</Name><Number>abc</Number></Field>"), msg)
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *ValidationError, got %T: %v", err, err)
+	}
+	if len(verr.Fields) != 1 || verr.Fields[0].Field != "number" || verr.Fields[0].Value != "abc" {
+		t.Errorf("unexpected field errors: %+v", verr.Fields)
+	}
+}
+
+func TestValidationError_GRPCStatus(t *testing.T) {
+	verr := &ValidationError{Fields: []FieldError{
+		{Field: "number", Value: "abc", Reason: "expected a 32-bit integer"},
+		{Field: "options.packed", Value: "maybe", Reason: "expected a boolean"},
+	}}
+
+	st := status.Convert(verr)
+	if st.Code() != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument, got %v", st.Code())
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("expected one detail, got %d", len(st.Details()))
+	}
+	br, ok := st.Details()[0].(*errdetails.BadRequest)
+	if !ok || len(br.FieldViolations) != 2 {
+		t.Fatalf("expected BadRequest with 2 violations, got %v", st.Details()[0])
+	}
+	if v := br.FieldViolations[0]; v.Field != "number" || v.Description != `expected a 32-bit integer, got "abc"` {
+		t.Errorf("unexpected violation: %v", v)
+	}
+}
+
+func TestValidationError_NotBlamable(t *testing.T) {
+	// Errors no field can be blamed for are returned unchanged
+	decodeErr := errors.New("decode failed")
+	msg := &descriptorpb.FieldDescriptorProto{}
+	if err := validationError(msg, fieldValues{"name": {"ok"}}, decodeErr); err != decodeErr {
+		t.Errorf("expected original error, got %v", err)
+	}
+	if err := validationError(struct{}{}, fieldValues{"name": {"ok"}}, decodeErr); err != decodeErr {
+		t.Errorf("expected original error for non-proto values, got %v", err)
+	}
+}
+
+func TestGatewayErrorHandler_ValidationDetails(t *testing.T) {
+	var handlerErr error
+	handler := withValidationBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// What generated gateway code does with a decode error
+		msg := &descriptorpb.FieldDescriptorProto{}
+		if err := (&FormMarshaler{}).NewDecoder(r.Body).Decode(msg); err != nil {
+			handlerErr = status.Errorf(codes.InvalidArgument, "%v", err)
+		}
+		gatewayErrorHandler(r.Context(), runtime.NewServeMux(), &runtime.JSONPb{}, w, r, handlerErr)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/fields", strings.NewReader("number=abc&label=nope"))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"google.rpc.BadRequest", `"field":"number"`, `"field":"label"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected body to contain %s, got %s", want, body)
+		}
+	}
+}
+
+func TestGatewayErrorHandler_NoValidationBody(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/fields", nil)
+	rec := httptest.NewRecorder()
+	gatewayErrorHandler(context.Background(), runtime.NewServeMux(), &runtime.JSONPb{}, rec, req, status.Error(codes.InvalidArgument, "bad"))
+
+	if rec.Code != http.StatusBadRequest || strings.Contains(rec.Body.String(), "BadRequest") {
+		t.Errorf("expected plain 400, got %d %s", rec.Code, rec.Body.String())
+	}
+}
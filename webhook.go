@@ -0,0 +1,182 @@
+package grpckit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gyozatech/grpckit/dedup"
+)
+
+// dedupScanLimit is the number of body bytes inspected by DedupJSONField.
+const dedupScanLimit = 1 << 20
+
+// DedupOption configures webhook deduplication.
+type DedupOption func(*dedupConfig)
+
+// dedupConfig holds configuration for webhook deduplication.
+type dedupConfig struct {
+	keyFunc  func(r *http.Request) string
+	ttl      time.Duration
+	claimTTL time.Duration
+}
+
+// DedupHeader reads the event ID from a request header,
+// e.g. "X-GitHub-Delivery".
+// Default: "Idempotency-Key"
+func DedupHeader(name string) DedupOption {
+	return func(c *dedupConfig) {
+		c.keyFunc = func(r *http.Request) string {
+			return r.Header.Get(name)
+		}
+	}
+}
+
+// DedupJSONField reads the event ID from a top-level string field of a JSON
+// body, e.g. "id" for Stripe events. The body is restored for the handler.
+func DedupJSONField(field string) DedupOption {
+	return func(c *dedupConfig) {
+		c.keyFunc = func(r *http.Request) string {
+			return jsonBodyField(r, field)
+		}
+	}
+}
+
+// DedupKey sets a custom function extracting the event ID from the request.
+func DedupKey(fn func(r *http.Request) string) DedupOption {
+	return func(c *dedupConfig) {
+		c.keyFunc = fn
+	}
+}
+
+// DedupTTL sets how long processed event IDs are remembered. It should
+// exceed the provider's retry period.
+// Default: 24 hours
+func DedupTTL(ttl time.Duration) DedupOption {
+	return func(c *dedupConfig) {
+		c.ttl = ttl
+	}
+}
+
+// DedupClaimTTL sets how long an event stays claimed while its handler
+// runs. If the instance processing it dies, the provider's retries are
+// rejected until the claim expires, then processed.
+// Default: 5 minutes
+func DedupClaimTTL(ttl time.Duration) DedupOption {
+	return func(c *dedupConfig) {
+		c.claimTTL = ttl
+	}
+}
+
+// DeduplicateWebhooks returns middleware that processes each webhook event
+// at most once per TTL window, keyed by the provider's event ID. Providers
+// such as Stripe and GitHub deliver at least once and retry on failure, so
+// handlers registered with WithHTTPHandler otherwise see duplicates.
+//
+// The first delivery of an event claims its ID in store and reaches the
+// handler. Deliveries arriving while it is processed get 409 Conflict, so
+// the provider retries them later. Once the handler responds with a 2xx
+// status the event is marked completed, and later deliveries get 200 OK
+// with an X-Webhook-Duplicate header and are not passed on. If the handler
+// responds with a non-2xx status or panics, the claim is released so the
+// provider's next retry is processed.
+// Requests without an event ID, and requests arriving while the store is
+// unavailable, are passed on unchanged.
+//
+// Use dedup.NewMemoryStore for a single instance, or a Redis-backed
+// dedup.Store to deduplicate across instances.
+//
+// Example:
+//
+//	store := dedup.NewMemoryStore()
+//
+//	grpckit.WithHTTPHandler("/webhooks/github",
+//	    grpckit.DeduplicateWebhooks(store, grpckit.DedupHeader("X-GitHub-Delivery"))(githubHandler),
+//	)
+//	grpckit.WithHTTPHandler("/webhooks/stripe",
+//	    grpckit.DeduplicateWebhooks(store, grpckit.DedupJSONField("id"))(stripeHandler),
+//	)
+func DeduplicateWebhooks(store dedup.Store, opts ...DedupOption) HTTPMiddleware {
+	cfg := &dedupConfig{ttl: 24 * time.Hour, claimTTL: 5 * time.Minute}
+	DedupHeader("Idempotency-Key")(cfg)
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := cfg.keyFunc(r)
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Namespace by path so IDs from different providers cannot collide
+			key := "grpckit:webhook:" + r.URL.Path + ":" + id
+			state, err := store.Claim(r.Context(), key, cfg.claimTTL)
+			if err != nil {
+				loggerFrom(r.Context()).Warn("dedup store failed, processing event", "component", "webhook",
+					"path", r.URL.Path, "event_id", id, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			switch state {
+			case dedup.Pending:
+				http.Error(w, "event is being processed", http.StatusConflict)
+				return
+			case dedup.Completed:
+				w.Header().Set("X-Webhook-Duplicate", "true")
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+
+			wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			processed := false
+			defer func() {
+				// Ignore cancellation: the client may have gone away before the update
+				ctx := context.WithoutCancel(r.Context())
+				if processed {
+					err = store.Complete(ctx, key, cfg.ttl)
+				} else {
+					err = store.Release(ctx, key)
+				}
+				if err != nil {
+					loggerFrom(r.Context()).Error("failed to update event", "component", "webhook",
+						"path", r.URL.Path, "event_id", id, "processed", processed, "error", err)
+				}
+			}()
+
+			next.ServeHTTP(wrapped, r)
+			processed = wrapped.statusCode >= 200 && wrapped.statusCode < 300
+		})
+	}
+}
+
+// jsonBodyField returns a top-level string field of a JSON request body,
+// restoring the body for the next handler.
+func jsonBodyField(r *http.Request, field string) string {
+	if r.Body == nil {
+		return ""
+	}
+	head, err := io.ReadAll(io.LimitReader(r.Body, dedupScanLimit))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	if err != nil {
+		return ""
+	}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(head, &fields) != nil {
+		return ""
+	}
+	var value string
+	if json.Unmarshal(fields[field], &value) != nil {
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,245 @@
+package grpckit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gyozatech/grpckit/dedup"
+)
+
+// failingDedupStore is a dedup.Store whose operations always fail.
+type failingDedupStore struct{}
+
+func (failingDedupStore) Claim(ctx context.Context, key string, ttl time.Duration) (dedup.State, error) {
+	return dedup.Claimed, errors.New("store down")
+}
+
+func (failingDedupStore) Complete(ctx context.Context, key string, ttl time.Duration) error {
+	return errors.New("store down")
+}
+
+func (failingDedupStore) Release(ctx context.Context, key string) error {
+	return errors.New("store down")
+}
+
+func webhookRequest(path, id string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+	if id != "" {
+		req.Header.Set("Idempotency-Key", id)
+	}
+	return req
+}
+
+func TestDeduplicateWebhooks(t *testing.T) {
+	calls := 0
+	handler := DeduplicateWebhooks(dedup.NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusAccepted)
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusAccepted {
+		t.Errorf("expected first delivery to reach the handler, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Webhook-Duplicate") != "true" {
+		t.Errorf("expected duplicate to be acknowledged, got %d %v", rec.Code, rec.Header())
+	}
+
+	// Same ID on another path and requests without an ID are processed
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/stripe", "evt_1"))
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", ""))
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", ""))
+
+	if calls != 4 {
+		t.Errorf("expected 4 handler calls, got %d", calls)
+	}
+}
+
+func TestDeduplicateWebhooks_InFlight(t *testing.T) {
+	store := dedup.NewMemoryStore()
+	entered, release := make(chan struct{}), make(chan struct{})
+	calls := 0
+	handler := DeduplicateWebhooks(store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		close(entered)
+		<-release
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+	}()
+	<-entered
+
+	// A retry while the first delivery is processed must not be acknowledged
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusConflict || rec.Header().Get("X-Webhook-Duplicate") != "" {
+		t.Errorf("expected 409 for an in-flight duplicate, got %d %v", rec.Code, rec.Header())
+	}
+
+	close(release)
+	<-done
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Webhook-Duplicate") != "true" {
+		t.Errorf("expected completed duplicate to be acknowledged, got %d %v", rec.Code, rec.Header())
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 handler call, got %d", calls)
+	}
+}
+
+func TestDeduplicateWebhooks_ClaimExpires(t *testing.T) {
+	now := time.Unix(1000, 0)
+	store := dedup.NewMemoryStore()
+	store.SetClock(func() time.Time { return now })
+	handler := DeduplicateWebhooks(store, DedupClaimTTL(time.Minute))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// A claim left by an instance that died is retried once it expires
+	if state, _ := store.Claim(context.Background(), "grpckit:webhook:/webhooks/github:evt_1", time.Minute); state != dedup.Claimed {
+		t.Fatalf("unexpected state %v", state)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 while claimed, got %d", rec.Code)
+	}
+	now = now.Add(time.Minute)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, webhookRequest("/webhooks/github", "evt_1"))
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Webhook-Duplicate") != "" {
+		t.Errorf("expected event to be processed after the claim expired, got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestDeduplicateWebhooks_ReleasesOnFailure(t *testing.T) {
+	fail := true
+	calls := 0
+	handler := DeduplicateWebhooks(dedup.NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if fail {
+			http.Error(w, "database unavailable", http.StatusServiceUnavailable)
+		}
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+	fail = false
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+
+	if calls != 2 {
+		t.Errorf("expected retry after failure to be processed once, got %d calls", calls)
+	}
+}
+
+func TestDeduplicateWebhooks_ReleasesOnPanic(t *testing.T) {
+	calls := 0
+	handler := DeduplicateWebhooks(dedup.NewMemoryStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+	}))
+
+	func() {
+		defer func() { _ = recover() }()
+		handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+
+	if calls != 2 {
+		t.Errorf("expected retry after panic to be processed, got %d calls", calls)
+	}
+}
+
+func TestDeduplicateWebhooks_StoreError(t *testing.T) {
+	buf := captureLog(t)
+	calls := 0
+	handler := DeduplicateWebhooks(failingDedupStore{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	handler.ServeHTTP(httptest.NewRecorder(), webhookRequest("/webhooks/github", "evt_1"))
+	if calls != 1 {
+		t.Errorf("expected event to be processed on store error, got %d calls", calls)
+	}
+	if !strings.Contains(buf.String(), `msg="dedup store failed, processing event" component=webhook`) {
+		t.Errorf("expected store error to be logged, got %q", buf.String())
+	}
+}
+
+func TestDedupHeader(t *testing.T) {
+	calls := 0
+	handler := DeduplicateWebhooks(dedup.NewMemoryStore(), DedupHeader("X-GitHub-Delivery"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for i := 0; i < 2; i++ {
+		req := webhookRequest("/webhooks/github", "")
+		req.Header.Set("X-GitHub-Delivery", "72d3162e")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 handler call, got %d", calls)
+	}
+}
+
+func TestDedupJSONField(t *testing.T) {
+	var bodies []string
+	handler := DeduplicateWebhooks(dedup.NewMemoryStore(), DedupJSONField("id"))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+	}))
+
+	payload := `{"id":"evt_1","type":"invoice.paid"}`
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/webhooks/stripe", strings.NewReader(payload))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(bodies) != 1 || bodies[0] != payload {
+		t.Errorf("expected one call with the full body, got %q", bodies)
+	}
+}
+
+func TestJSONBodyField(t *testing.T) {
+	cases := []struct {
+		body string
+		want string
+	}{
+		{`{"id":"evt_1"}`, "evt_1"},
+		{`{"id":42}`, ""},
+		{`{"other":"x"}`, ""},
+		{`not json`, ""},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+		if got := jsonBodyField(req, "id"); got != tc.want {
+			t.Errorf("jsonBodyField(%q): expected %q, got %q", tc.body, tc.want, got)
+		}
+		if rest, _ := io.ReadAll(req.Body); string(rest) != tc.body {
+			t.Errorf("expected body to be restored, got %q", rest)
+		}
+	}
+}
+
+func TestDedupTTL(t *testing.T) {
+	cfg := &dedupConfig{}
+	DedupTTL(time.Hour)(cfg)
+	DedupClaimTTL(time.Minute)(cfg)
+
+	if cfg.ttl != time.Hour || cfg.claimTTL != time.Minute {
+		t.Errorf("expected TTLs 1h and 1m, got %v and %v", cfg.ttl, cfg.claimTTL)
+	}
+}
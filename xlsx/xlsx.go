@@ -0,0 +1,395 @@
+// Package xlsx provides an Excel (.xlsx) export marshaler for grpckit.
+//
+// Responses are written as workbooks: every repeated message field becomes a
+// sheet with one row per element and one typed column per field (numbers and
+// booleans are stored as such, so they can be summed and filtered). Other
+// fields of the response go to a sheet named after the message. The package
+// only uses the standard library.
+//
+// Example:
+//
+//	grpckit.Run(
+//	    grpckit.WithGRPCService(...),
+//	    grpckit.WithRESTService(...),
+//	    xlsx.WithSupport(),
+//	)
+//
+//	curl -H "Accept: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet" \
+//	    -o items.xlsx http://localhost:8080/api/v1/items
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ContentType is the MIME type of .xlsx workbooks.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+// maxSheetName is the longest sheet name Excel accepts.
+const maxSheetName = 31
+
+// maxExactNumber is the largest integer a spreadsheet number (a float64)
+// holds exactly; larger 64-bit values are written as text.
+const maxExactNumber = 1<<53 - 1
+
+// WithSupport registers the XLSX marshaler for ContentType. Clients request
+// workbooks with "Accept: application/vnd.openxmlformats-officedocument.spreadsheetml.sheet".
+func WithSupport() grpckit.Option {
+	return grpckit.WithMarshaler(ContentType, &Marshaler{})
+}
+
+// Marshaler writes proto responses as XLSX workbooks. It is output only:
+// requests cannot be decoded from workbooks.
+type Marshaler struct{}
+
+// ContentType returns the XLSX MIME type.
+func (m *Marshaler) ContentType(_ interface{}) string {
+	return ContentType
+}
+
+// Marshal writes v as a workbook.
+func (m *Marshaler) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("xlsx marshaler: unsupported type %T", v)
+	}
+
+	var buf bytes.Buffer
+	if err := writeWorkbook(&buf, sheets(msg.ProtoReflect())); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal is not supported: the XLSX marshaler is output only.
+func (m *Marshaler) Unmarshal(_ []byte, _ interface{}) error {
+	return errors.New("xlsx marshaler: decoding is not supported")
+}
+
+// NewDecoder returns a decoder that always fails.
+func (m *Marshaler) NewDecoder(_ io.Reader) runtime.Decoder {
+	return runtime.DecoderFunc(func(v interface{}) error {
+		return m.Unmarshal(nil, v)
+	})
+}
+
+// NewEncoder returns an encoder writing workbooks to w.
+func (m *Marshaler) NewEncoder(w io.Writer) runtime.Encoder {
+	return runtime.EncoderFunc(func(v interface{}) error {
+		data, err := m.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	})
+}
+
+// cell is a typed spreadsheet value.
+type cell struct {
+	text   string
+	number bool
+	bool   bool
+}
+
+// sheet is a named table; the first row holds the column headers.
+type sheet struct {
+	name string
+	rows [][]cell
+}
+
+// sheets splits m into one sheet per repeated message field, plus a sheet
+// for the remaining fields.
+func sheets(m protoreflect.Message) []sheet {
+	var result []sheet
+	var other []protoreflect.FieldDescriptor
+
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if !fd.IsList() || fd.Message() == nil {
+			other = append(other, fd)
+			continue
+		}
+		columns := fieldList(fd.Message().Fields())
+		s := sheet{name: string(fd.Name()), rows: [][]cell{headers(columns)}}
+		list := m.Get(fd).List()
+		for j := 0; j < list.Len(); j++ {
+			s.rows = append(s.rows, row(list.Get(j).Message(), columns))
+		}
+		result = append(result, s)
+	}
+
+	if len(other) > 0 || len(result) == 0 {
+		s := sheet{name: string(m.Descriptor().Name()), rows: [][]cell{headers(other)}}
+		if len(other) > 0 {
+			s.rows = append(s.rows, row(m, other))
+		}
+		result = append([]sheet{s}, result...)
+	}
+	return result
+}
+
+// fieldList returns the fields in declaration order.
+func fieldList(fields protoreflect.FieldDescriptors) []protoreflect.FieldDescriptor {
+	list := make([]protoreflect.FieldDescriptor, fields.Len())
+	for i := range list {
+		list[i] = fields.Get(i)
+	}
+	return list
+}
+
+// headers returns the header row for columns.
+func headers(columns []protoreflect.FieldDescriptor) []cell {
+	row := make([]cell, len(columns))
+	for i, fd := range columns {
+		row[i] = cell{text: string(fd.Name())}
+	}
+	return row
+}
+
+// row returns the cells of m for columns.
+func row(m protoreflect.Message, columns []protoreflect.FieldDescriptor) []cell {
+	cells := make([]cell, len(columns))
+	for i, fd := range columns {
+		cells[i] = fieldCell(m, fd)
+	}
+	return cells
+}
+
+// fieldCell converts the value of fd in m to a cell. Repeated and map
+// fields are joined into text; unset fields with presence are empty.
+func fieldCell(m protoreflect.Message, fd protoreflect.FieldDescriptor) cell {
+	if fd.HasPresence() && !m.Has(fd) {
+		return cell{}
+	}
+	v := m.Get(fd)
+	switch {
+	case fd.IsList():
+		list := v.List()
+		parts := make([]string, list.Len())
+		for i := range parts {
+			parts[i] = valueCell(fd, list.Get(i)).text
+		}
+		return cell{text: strings.Join(parts, ", ")}
+	case fd.IsMap():
+		var parts []string
+		v.Map().Range(func(k protoreflect.MapKey, val protoreflect.Value) bool {
+			parts = append(parts, k.String()+": "+valueCell(fd.MapValue(), val).text)
+			return true
+		})
+		sort.Strings(parts)
+		return cell{text: strings.Join(parts, ", ")}
+	default:
+		return valueCell(fd, v)
+	}
+}
+
+// valueCell converts a single value of fd to a cell.
+func valueCell(fd protoreflect.FieldDescriptor, v protoreflect.Value) cell {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			return cell{text: "1", bool: true}
+		}
+		return cell{text: "0", bool: true}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return cell{text: v.String(), number: true}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n := v.Int()
+		return cell{text: v.String(), number: n <= maxExactNumber && n >= -maxExactNumber}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return cell{text: v.String(), number: v.Uint() <= maxExactNumber}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		f := v.Float()
+		// NaN and infinities have no spreadsheet number representation
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return cell{text: v.String()}
+		}
+		return cell{text: strconv.FormatFloat(f, 'g', -1, 64), number: true}
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return cell{text: string(ev.Name())}
+		}
+		return cell{text: strconv.Itoa(int(v.Enum()))}
+	case protoreflect.BytesKind:
+		return cell{text: base64.StdEncoding.EncodeToString(v.Bytes())}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		data, err := protojson.Marshal(v.Message().Interface())
+		if err != nil {
+			return cell{text: err.Error()}
+		}
+		// Well-known scalars (Timestamp, Duration, wrappers) marshal to JSON strings
+		if s, err := strconv.Unquote(string(data)); err == nil {
+			return cell{text: s}
+		}
+		return cell{text: string(data)}
+	default:
+		return cell{text: v.String()}
+	}
+}
+
+// sheetNames returns unique, valid sheet names.
+func sheetNames(sheets []sheet) []string {
+	names := make([]string, len(sheets))
+	used := make(map[string]bool, len(sheets))
+	for i, s := range sheets {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, s.name)
+		if name == "" {
+			name = "Sheet"
+		}
+		base := name
+		name = truncate(base, maxSheetName)
+		for n := 2; used[strings.ToLower(name)]; n++ {
+			suffix := "_" + strconv.Itoa(n)
+			name = truncate(base, maxSheetName-len(suffix)) + suffix
+		}
+		used[strings.ToLower(name)] = true
+		names[i] = name
+	}
+	return names
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
+
+// columnName returns the spreadsheet column letters for index i (0 → A).
+func columnName(i int) string {
+	name := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		name = string(rune('A'+(i-1)%26)) + name
+	}
+	return name
+}
+
+// xmlText escapes s for XML character data.
+func xmlText(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`%s</Types>`
+
+const rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// stylesXML defines style 0 (default) and style 1 (bold, for headers).
+const stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`</styleSheet>`
+
+// writeWorkbook writes sheets as an XLSX package to w.
+func writeWorkbook(w io.Writer, sheets []sheet) error {
+	names := sheetNames(sheets)
+	var overrides, workbook, rels strings.Builder
+	for i, name := range names {
+		n := strconv.Itoa(i + 1)
+		overrides.WriteString(`<Override PartName="/xl/worksheets/sheet` + n + `.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`)
+		workbook.WriteString(`<sheet name="` + xmlText(name) + `" sheetId="` + n + `" r:id="rId` + n + `"/>`)
+		rels.WriteString(`<Relationship Id="rId` + n + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet` + n + `.xml"/>`)
+	}
+	stylesID := strconv.Itoa(len(names) + 1)
+	rels.WriteString(`<Relationship Id="rId` + stylesID + `" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`)
+
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", fmt.Sprintf(contentTypesXML, overrides.String())},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+			`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+			`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>` +
+			workbook.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n" +
+			`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+		{"xl/styles.xml", stylesXML},
+	}
+	for i, s := range sheets {
+		parts = append(parts, struct{ name, content string }{
+			fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheetXML(s),
+		})
+	}
+
+	zw := zip.NewWriter(w)
+	for _, p := range parts {
+		f, err := zw.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.content); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// sheetXML renders a worksheet; the first row is styled as the header.
+func sheetXML(s sheet) string {
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, cells := range s.rows {
+		r := strconv.Itoa(i + 1)
+		b.WriteString(`<row r="` + r + `">`)
+		for j, c := range cells {
+			ref := columnName(j) + r
+			style := ""
+			if i == 0 {
+				style = ` s="1"`
+			}
+			switch {
+			case c.bool:
+				b.WriteString(`<c r="` + ref + `"` + style + ` t="b"><v>` + c.text + `</v></c>`)
+			case c.number:
+				b.WriteString(`<c r="` + ref + `"` + style + `><v>` + c.text + `</v></c>`)
+			case c.text != "":
+				b.WriteString(`<c r="` + ref + `"` + style + ` t="inlineStr"><is><t xml:space="preserve">` + xmlText(c.text) + `</t></is></c>`)
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String()
+}
@@ -0,0 +1,172 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/gyozatech/grpckit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// readWorkbook returns the parts of an XLSX package by name.
+func readWorkbook(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("expected a zip package: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("open %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatalf("read %s: %v", f.Name, err)
+		}
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed XML: %v", f.Name, err)
+			}
+		}
+		parts[f.Name] = string(content)
+	}
+	return parts
+}
+
+func TestMarshaler_ListSheet(t *testing.T) {
+	m := &Marshaler{}
+	data, err := m.Marshal(&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+		{Field: "name", Description: "<required> & missing"},
+	}})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parts := readWorkbook(t, data)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("expected part %s", name)
+		}
+	}
+	if _, ok := parts["xl/worksheets/sheet2.xml"]; ok {
+		t.Error("expected a single sheet for a list-only message")
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="field_violations" sheetId="1" r:id="rId1"/>`) {
+		t.Errorf("expected sheet named after the repeated field, got %s", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, `<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">field</t></is></c>`) {
+		t.Errorf("expected bold header row, got %s", sheet)
+	}
+	if !strings.Contains(sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">&lt;required&gt; &amp; missing</t></is></c>`) {
+		t.Errorf("expected escaped text cell, got %s", sheet)
+	}
+}
+
+func TestMarshaler_TypedColumns(t *testing.T) {
+	m := &Marshaler{}
+	data, err := m.Marshal(&descriptorpb.DescriptorProto{
+		Name: proto.String("Item"),
+		Field: []*descriptorpb.FieldDescriptorProto{{
+			Name:           proto.String("id"),
+			Number:         proto.Int32(7),
+			Label:          descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			Proto3Optional: proto.Bool(true),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	parts := readWorkbook(t, data)
+
+	workbook := parts["xl/workbook.xml"]
+	if !strings.Contains(workbook, `<sheet name="DescriptorProto" sheetId="1"`) {
+		t.Errorf("expected first sheet for the remaining fields, got %s", workbook)
+	}
+	if !strings.Contains(workbook, `<sheet name="field" sheetId="2"`) {
+		t.Errorf("expected sheet per repeated message field, got %s", workbook)
+	}
+
+	fields := parts["xl/worksheets/sheet2.xml"]
+	if !strings.Contains(fields, `<c r="B2"><v>7</v></c>`) {
+		t.Errorf("expected numeric cell for number, got %s", fields)
+	}
+	if !strings.Contains(fields, `<c r="C2" t="inlineStr"><is><t xml:space="preserve">LABEL_OPTIONAL</t></is></c>`) {
+		t.Errorf("expected enum name, got %s", fields)
+	}
+	if !strings.Contains(fields, `t="b"><v>1</v></c>`) {
+		t.Errorf("expected boolean cell, got %s", fields)
+	}
+}
+
+func TestValueCell_Int64Precision(t *testing.T) {
+	m := (&descriptorpb.UninterpretedOption{}).ProtoReflect()
+	pos := m.Descriptor().Fields().ByName("positive_int_value")
+	neg := m.Descriptor().Fields().ByName("negative_int_value")
+
+	m.Set(pos, protoreflect.ValueOfUint64(1<<53-1))
+	if c := fieldCell(m, pos); !c.number {
+		t.Errorf("expected %s to be a number", c.text)
+	}
+	m.Set(pos, protoreflect.ValueOfUint64(1<<60))
+	if c := fieldCell(m, pos); c.number || c.text != "1152921504606846976" {
+		t.Errorf("expected large value as exact text, got %+v", c)
+	}
+	m.Set(neg, protoreflect.ValueOfInt64(-(1 << 60)))
+	if c := fieldCell(m, neg); c.number {
+		t.Errorf("expected large negative value as text, got %+v", c)
+	}
+}
+
+func TestSheetNames(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	names := sheetNames([]sheet{{name: "a/b"}, {name: "A_b"}, {name: long}, {name: long}, {name: ""}})
+
+	want := []string{"a_b", "A_b_2", strings.Repeat("x", 31), strings.Repeat("x", 29) + "_2", "Sheet"}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("name %d: expected %q, got %q", i, want[i], names[i])
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for i, want := range tests {
+		if got := columnName(i); got != want {
+			t.Errorf("columnName(%d): expected %s, got %s", i, want, got)
+		}
+	}
+}
+
+func TestMarshaler_Unmarshal(t *testing.T) {
+	m := &Marshaler{}
+	if err := m.Unmarshal([]byte("PK"), &errdetails.BadRequest{}); err == nil {
+		t.Error("expected decoding to be rejected")
+	}
+	if _, err := m.Marshal(map[string]string{"a": "b"}); err == nil {
+		t.Error("expected non-proto values to be rejected")
+	}
+}
+
+func TestWithSupport(t *testing.T) {
+	// The option must be usable with grpckit's option list
+	var _ grpckit.Option = WithSupport()
+	if ct := (&Marshaler{}).ContentType(nil); ct != ContentType {
+		t.Errorf("expected %s, got %s", ContentType, ct)
+	}
+}